@@ -0,0 +1,249 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Metadata key of the layer annotations
+const AnnotationsMetadata = "Annotations"
+
+// Suffix of the sidecar file that holds the annotations
+// of file formats that can't store them
+const AnnotationsSuffix = ".notes.json"
+
+// Annotations are notes (ie QA observations) on layers, by layer index
+type Annotations map[int]string
+
+// Layers returns the annotated layer indexes, in order
+func (annotations Annotations) Layers() (layers []int) {
+	for index := range annotations {
+		layers = append(layers, index)
+	}
+	sort.Ints(layers)
+
+	return
+}
+
+// RemapLayers returns a copy of the annotations, with the note of each
+// layer moved to the index from remap. Notes moved to the same layer
+// are joined, and those of layers that remap drops are discarded.
+func (annotations Annotations) RemapLayers(remap func(index int) (int, bool)) LayerIndexed {
+	remapped := Annotations{}
+	for _, index := range annotations.Layers() {
+		to, ok := remap(index)
+		if ok {
+			remapped.add(to, annotations[index])
+		}
+	}
+
+	return remapped
+}
+
+// MergeLayers returns a copy of the annotations, with the notes of
+// another set of annotations added
+func (annotations Annotations) MergeLayers(other LayerIndexed) LayerIndexed {
+	merged, _ := annotations.RemapLayers(func(index int) (int, bool) { return index, true }).(Annotations)
+
+	more, _ := other.(Annotations)
+	for _, index := range more.Layers() {
+		merged.add(index, more[index])
+	}
+
+	return merged
+}
+
+// add adds a note to a layer, after any note it already has
+func (annotations Annotations) add(index int, note string) {
+	prior, ok := annotations[index]
+	if ok {
+		note = strings.Join([]string{prior, note}, "; ")
+	}
+
+	annotations[index] = note
+}
+
+// AnnotatingFormatter is implemented by formatters that
+// store the annotations in the file itself.
+type AnnotatingFormatter interface {
+	StoresAnnotations() bool
+}
+
+// LayerAnnotations returns a copy of the annotations of a printable
+func LayerAnnotations(printable Printable) (annotations Annotations) {
+	annotations = Annotations{}
+
+	data, ok := printable.Metadata(AnnotationsMetadata)
+	if !ok {
+		return
+	}
+
+	original, _ := data.(Annotations)
+	for index, note := range original {
+		annotations[index] = note
+	}
+
+	return
+}
+
+// AnnotatedPrintable replaces the annotations of a printable
+type AnnotatedPrintable struct {
+	Printable
+	Annotations Annotations
+}
+
+func (ap *AnnotatedPrintable) MetadataKeys() (keys []string) {
+	for _, key := range ap.Printable.MetadataKeys() {
+		if key != AnnotationsMetadata {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(ap.Annotations) > 0 {
+		keys = append(keys, AnnotationsMetadata)
+	}
+
+	return
+}
+
+func (ap *AnnotatedPrintable) Metadata(key string) (data interface{}, ok bool) {
+	if key != AnnotationsMetadata {
+		return ap.Printable.Metadata(key)
+	}
+
+	if len(ap.Annotations) == 0 {
+		return
+	}
+
+	return ap.Annotations, true
+}
+
+// annotationsLoad reads the sidecar annotations of a file, if any
+func annotationsLoad(filename string) (annotations Annotations, err error) {
+	data, err := ioutil.ReadFile(filename + AnnotationsSuffix)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, &annotations)
+
+	return
+}
+
+// annotationsSave writes (or removes) the sidecar annotations of a file
+func annotationsSave(filename string, annotations Annotations) (err error) {
+	sidecar := filename + AnnotationsSuffix
+
+	if len(annotations) == 0 {
+		err = os.Remove(sidecar)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+
+	data, err := json.MarshalIndent(annotations, "", "  ")
+	if err != nil {
+		return
+	}
+
+	err = ioutil.WriteFile(sidecar, append(data, '\n'), 0644)
+
+	return
+}
+
+// LayerIndexed is implemented by metadata that is kept by layer index
+// (ie Annotations), so that filters which add, drop, or move layers can
+// keep it with the right layers.
+type LayerIndexed interface {
+	// RemapLayers returns a copy, with the entry of each layer moved
+	// to the index from remap, or dropped if remap returns false
+	RemapLayers(remap func(index int) (int, bool)) LayerIndexed
+
+	// MergeLayers returns a copy, with the entries of other added
+	MergeLayers(other LayerIndexed) LayerIndexed
+}
+
+// LayerRemap moves the layers of a filter's input to the filter's
+// output. Remap returns the output layer of an input layer, or false if
+// the input layer was dropped.
+type LayerRemap struct {
+	Printable Printable
+	Remap     func(index int) (int, bool)
+}
+
+// RemapLayers returns the output of a filter, with the layer indexed
+// metadata of its inputs moved to the output's layers
+func RemapLayers(output Printable, inputs ...LayerRemap) Printable {
+	metadata := map[string]LayerIndexed{}
+	for _, input := range inputs {
+		for _, key := range input.Printable.MetadataKeys() {
+			data, _ := input.Printable.Metadata(key)
+			indexed, ok := data.(LayerIndexed)
+			if !ok {
+				continue
+			}
+
+			indexed = indexed.RemapLayers(input.Remap)
+
+			prior, ok := metadata[key]
+			if ok {
+				indexed = prior.MergeLayers(indexed)
+			}
+
+			metadata[key] = indexed
+		}
+	}
+
+	if len(metadata) == 0 {
+		return output
+	}
+
+	return &remappedPrintable{
+		Printable: output,
+		metadata:  metadata,
+	}
+}
+
+// remappedPrintable replaces the layer indexed metadata of a printable
+type remappedPrintable struct {
+	Printable
+	metadata map[string]LayerIndexed
+}
+
+func (rp *remappedPrintable) MetadataKeys() (keys []string) {
+	for _, key := range rp.Printable.MetadataKeys() {
+		_, ok := rp.metadata[key]
+		if !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for key := range rp.metadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return
+}
+
+func (rp *remappedPrintable) Metadata(key string) (data interface{}, ok bool) {
+	indexed, ok := rp.metadata[key]
+	if !ok {
+		return rp.Printable.Metadata(key)
+	}
+
+	return indexed, true
+}
@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/pflag"
+)
+
+type sidecarFormatter struct {
+	*pflag.FlagSet
+}
+
+func (sf *sidecarFormatter) Decode(reader Reader, size int64) (printable Printable, err error) {
+	printable = &Print{Properties: Properties{Size: Size{X: 1, Y: 1, Layers: 4}}}
+	return
+}
+
+func (sf *sidecarFormatter) Encode(writer Writer, printable Printable) (err error) {
+	return
+}
+
+func TestAnnotationsSidecar(t *testing.T) {
+	RegisterFormatter(".test-notes", func(suffix string) Formatter {
+		return &sidecarFormatter{FlagSet: pflag.NewFlagSet(suffix, pflag.ContinueOnError)}
+	})
+
+	dir, err := ioutil.TempDir("", "uv3dp-annotations")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "foo.test-notes")
+	format, err := NewFormat(filename, []string{})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := Annotations{3: "Support failure seen here", 0: "Raft lifted"}
+	annotated := &AnnotatedPrintable{
+		Printable:   &Print{Properties: Properties{Size: Size{X: 1, Y: 1, Layers: 4}}},
+		Annotations: expected,
+	}
+
+	err = format.SetPrintable(annotated)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	printable, err := format.Printable()
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	annotations := LayerAnnotations(printable)
+	if !cmp.Equal(expected, annotations) {
+		t.Errorf("expected %v, got %v", expected, annotations)
+	}
+
+	if !cmp.Equal([]int{0, 3}, annotations.Layers()) {
+		t.Errorf("expected [0 3], got %v", annotations.Layers())
+	}
+
+	// Without annotations, the sidecar is removed
+	err = format.SetPrintable(annotated.Printable)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	_, err = os.Stat(filename + AnnotationsSuffix)
+	if !os.IsNotExist(err) {
+		t.Errorf("expected the sidecar to be removed, got %v", err)
+	}
+}
+
+func TestRemapLayers(t *testing.T) {
+	input := &AnnotatedPrintable{
+		Printable:   &Print{Properties: Properties{Size: Size{X: 1, Y: 1, Layers: 4}}},
+		Annotations: Annotations{0: "Raft lifted", 1: "Stringing", 3: "Support failure seen here"},
+	}
+
+	other := &AnnotatedPrintable{
+		Printable:   &Print{Properties: Properties{Size: Size{X: 1, Y: 1, Layers: 2}}},
+		Annotations: Annotations{0: "Seam"},
+	}
+
+	// Drop the first layer, and merge the two layers above it
+	output := RemapLayers(input,
+		LayerRemap{
+			Printable: input,
+			Remap: func(index int) (int, bool) {
+				if index == 0 {
+					return 0, false
+				}
+				return (index - 1) / 2, true
+			},
+		},
+		LayerRemap{
+			Printable: other,
+			Remap:     func(index int) (int, bool) { return index + 1, true },
+		},
+	)
+
+	expected := Annotations{0: "Stringing", 1: "Support failure seen here; Seam"}
+	annotations := LayerAnnotations(output)
+	if !cmp.Equal(expected, annotations) {
+		t.Errorf("expected %v, got %v", expected, annotations)
+	}
+
+	if !cmp.Equal([]string{AnnotationsMetadata}, output.MetadataKeys()) {
+		t.Errorf("expected [%v], got %v", AnnotationsMetadata, output.MetadataKeys())
+	}
+
+	// Without layer indexed metadata, the output is unchanged
+	plain := &Print{Properties: Properties{Size: Size{X: 1, Y: 1, Layers: 4}}}
+	if RemapLayers(plain, LayerRemap{Printable: plain}) != plain {
+		t.Errorf("expected the output to be unchanged")
+	}
+}
@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+// The v1 API, as documented in the package documentation. Any change
+// here that breaks existing programs must wait for v2.
+var (
+	_ Printable = (*Print)(nil)
+	_ Printable = (*DecimatedPrintable)(nil)
+	_ Printable = (*MorphedPrintable)(nil)
+	_ Printable = (*PyramidPrintable)(nil)
+
+	_ func(Properties) *Print                           = NewEmptyPrintable
+	_ func(Printable) *DecimatedPrintable               = NewDecimatedPrintable
+	_ func(Printable, MorphOperation) *MorphedPrintable = NewMorphedPrintable
+	_ func(Printable, int) *PyramidPrintable            = NewPyramidPrintable
+	_ func(Printable, bool) Printable                   = MirrorNormalize
+	_ func(string, NewFormatter)                        = RegisterFormatter
+	_ func() []string                                   = Formatters
+	_ func(string) (string, bool)                       = FormatterSuffix
+	_ func(string, []string) (*Format, error)           = NewFormat
+	_ func(string, string, []string) (*Format, error)   = NewFormatType
+	_ func(string, Machine, string, ...string) error    = RegisterMachine
+	_ func(map[string]Machine, string, ...string) error = RegisterMachines
+	_ func() []string                                   = Machines
+	_ func(string) (MachineFormat, error)               = LookupMachine
+	_ func(Printable, func(Printable, int))             = WithAllLayers
+	_ func(Printable, func(Printable, int))             = WithEachLayer
+	_ func(Printable, ...Validator) *ValidationReport   = Validate
+	_ func(Printable) float32                           = PrintVolume
+	_ func(Printable) time.Duration                     = PrintDuration
+	_ func(Printable) (PhaseTime, PhaseTime)            = EstimatePrintTime
+	_ func(*Properties, int) Exposure                   = (*Properties).LayerExposure
+	_ func(*Properties) image.Rectangle                 = (*Properties).Bounds
+	_ func(*Format) (Printable, error)                  = (*Format).Printable
+	_ func(*Format, Printable) error                    = (*Format).SetPrintable
+	_ func(*Exposure, Exposure, float32) Exposure       = (*Exposure).Interpolate
+	_ func(*Exposure) time.Duration                     = (*Exposure).Duration
+)
+
+func TestMachines(t *testing.T) {
+	names := Machines()
+	for n := 1; n < len(names); n++ {
+		if names[n-1] >= names[n] {
+			t.Errorf("expected sorted names, got %v before %v", names[n-1], names[n])
+		}
+	}
+
+	for _, name := range names {
+		if _, err := LookupMachine(name); err != nil {
+			t.Errorf("%v: %v", name, err)
+		}
+	}
+}
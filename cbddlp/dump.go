@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package cbddlp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/go-restruct/restruct"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// DumpRegions describes the header regions of a CBDDLP file
+func (cf *Formatter) DumpRegions(file uv3dp.Reader, filesize int64) (regions []uv3dp.DumpRegion, err error) {
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return
+	}
+
+	header := cbddlpHeader{}
+	err = restruct.Unpack(data, binary.LittleEndian, &header)
+	if err != nil {
+		return
+	}
+
+	if header.Magic != defaultHeaderMagic {
+		err = fmt.Errorf("Unknown header magic: 0x%08x", header.Magic)
+		return
+	}
+
+	regions = append(regions, uv3dp.DumpStruct("header", 0, &header))
+
+	// unpack adds the region of a structure, if it is within the file
+	unpack := func(name string, offset uint32, value interface{}) bool {
+		if offset == 0 || int(offset) >= len(data) {
+			return false
+		}
+
+		if restruct.Unpack(data[offset:], binary.LittleEndian, value) != nil {
+			return false
+		}
+
+		regions = append(regions, uv3dp.DumpStruct(name, int64(offset), value))
+		return true
+	}
+
+	if header.Version > 1 {
+		unpack("param", header.ParamOffset, &cbddlpParam{})
+	}
+	unpack("preview (huge)", header.PreviewHigh, &cbddlpPreview{})
+	unpack("preview (tiny)", header.PreviewLow, &cbddlpPreview{})
+
+	if header.LayerCount > 0 && unpack("layer 0 definition", header.LayerDefs, &cbddlpLayerDef{}) {
+		if header.LayerCount > 1 {
+			regions = append(regions, layerDefsRegion(regions[len(regions)-1], header.LayerCount))
+		}
+	}
+
+	return
+}
+
+// layerDefsRegion is the region of the layer definitions after the first
+func layerDefsRegion(first uv3dp.DumpRegion, count uint32) uv3dp.DumpRegion {
+	return uv3dp.DumpRegion{
+		Name:   fmt.Sprintf("layer 1..%v definitions", count-1),
+		Offset: first.Offset + first.Size,
+		Size:   first.Size * int64(count-1),
+	}
+}
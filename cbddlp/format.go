@@ -112,8 +112,9 @@ func align4(in uint32) (out uint32) {
 type Formatter struct {
 	*pflag.FlagSet
 
-	Version   int // Version of file to use, one of [1,2]
-	AntiAlias int // AntiAlias level, one of [1,2,4,8]
+	Version   int  // Version of file to use, one of [1,2]
+	AntiAlias int  // AntiAlias level, one of [1,2,4,8]
+	Dedup     bool // Share the image data of identical layers
 }
 
 func NewFormatter(suffix string) (cf *Formatter) {
@@ -143,12 +144,16 @@ func NewFormatter(suffix string) (cf *Formatter) {
 
 	cf.IntVarP(&cf.Version, "version", "v", version, "Override header Version")
 	cf.IntVarP(&cf.AntiAlias, "anti-alias", "a", antialias, "Override antialias level (1..16)")
+	cf.BoolVarP(&cf.Dedup, "dedup", "d", true, "Share the image data of identical layers")
 
 	return
 }
 
 // Save a uv3dp.Printable in CBD DLP format
 func (cf *Formatter) Encode(writer uv3dp.Writer, p uv3dp.Printable) (err error) {
+	// The printer shows the previews, so render any that are missing
+	p = uv3dp.WithPreviews(p)
+
 	switch cf.Version {
 	case 1:
 		if cf.AntiAlias != 1 {
@@ -255,9 +260,10 @@ func (cf *Formatter) Encode(writer uv3dp.Writer, p uv3dp.Printable) (err error)
 		doneMap[n] = make(chan layerInfo, cf.AntiAlias)
 	}
 
-	uv3dp.WithAllLayers(p, func(p uv3dp.Printable, n int) {
+	// The LCD_X_MIRROR projector has its images stored mirrored
+	uv3dp.WithAllLayers(uv3dp.MirrorNormalize(p, true), func(p uv3dp.Printable, n int) {
 		for bit := 0; bit < cf.AntiAlias; bit++ {
-			rle, hash, bitsOn := rleEncodeBitmap(p.LayerImage(n), bit, cf.AntiAlias)
+			rle, hash, bitsOn := rleEncodeLayer(p.LayerImage(n), bit, cf.AntiAlias)
 			doneMap[n] <- layerInfo{
 				Z:        p.LayerZ(n),
 				Exposure: p.LayerExposure(n),
@@ -272,6 +278,9 @@ func (cf *Formatter) Encode(writer uv3dp.Writer, p uv3dp.Printable) (err error)
 	for n := 0; n < size.Layers; n++ {
 		for bit := 0; bit < cf.AntiAlias; bit++ {
 			info := <-doneMap[n]
+			if !cf.Dedup {
+				info.Hash = uint64(n + bit*size.Layers)
+			}
 			_, ok := rleHash[info.Hash]
 			if !ok {
 				rleHash[info.Hash] = rleInfo{offset: imageBase, rle: info.Rle}
@@ -568,7 +577,8 @@ func (cf *Formatter) Decode(file uv3dp.Reader, filesize int64) (printable uv3dp.
 		rleMap:   rleMap,
 	}
 
-	printable = cbd
+	// The LCD_X_MIRROR projector has its images stored mirrored
+	printable = uv3dp.MirrorNormalize(cbd, header.Projector == 1)
 
 	return
 }
@@ -187,3 +187,39 @@ func TestRawToEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestDedup(t *testing.T) {
+	for _, antiAlias := range []int{1, 4} {
+		encoded := map[bool][]byte{}
+		for _, dedup := range []bool{false, true} {
+			formatter := NewFormatter(".cbddlp")
+			formatter.AntiAlias = antiAlias
+			formatter.Dedup = dedup
+
+			buffer := &bytes.Buffer{}
+			err := formatter.Encode(buffer, emptyPrintable)
+			if err != nil {
+				t.Fatalf("aa%v dedup %v: expected nil, got %v", antiAlias, dedup, err)
+			}
+			encoded[dedup] = buffer.Bytes()
+
+			result, err := formatter.Decode(&bufferMap{Buffer: buffer.Bytes()}, int64(buffer.Len()))
+			if err != nil {
+				t.Fatalf("aa%v dedup %v: expected nil, got %v", antiAlias, dedup, err)
+			}
+
+			for n := 0; n < emptyPrintable.Size().Layers; n++ {
+				expected := emptyPrintable.LayerImage(n)
+				got := result.LayerImage(n)
+				if !bytes.Equal(expected.Pix, got.Pix) {
+					t.Errorf("aa%v dedup %v: layer %v: image does not match", antiAlias, dedup, n)
+				}
+			}
+		}
+
+		if len(encoded[true]) >= len(encoded[false]) {
+			t.Errorf("aa%v: expected a smaller file with dedup, got %v vs %v bytes",
+				antiAlias, len(encoded[true]), len(encoded[false]))
+		}
+	}
+}
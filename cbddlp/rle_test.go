@@ -6,8 +6,13 @@
 package cbddlp
 
 import (
+	"bytes"
 	"image"
+	"io/ioutil"
+	"os"
 	"testing"
+
+	"github.com/nicarran/uv3dp"
 )
 
 func TestDecodeBinary(t *testing.T) {
@@ -143,3 +148,32 @@ func TestEncodeBinary(t *testing.T) {
 	}
 
 }
+
+func TestEncodeLayer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cbddlp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	uv3dp.SetLayerStore(&uv3dp.LayerStore{Dir: dir})
+	defer uv3dp.SetLayerStore(nil)
+
+	gm := image.NewGray(image.Rect(0, 0, 16, 16))
+	for n := range gm.Pix {
+		gm.Pix[n] = uint8(n)
+	}
+
+	// Layers from the store match the layers encoded directly
+	for _, levels := range []int{1, 2, 4, 8} {
+		for level := 0; level < levels; level++ {
+			rle, hash, bitsOn := rleEncodeBitmap(gm, level, levels)
+			for pass := 0; pass < 2; pass++ {
+				storeRle, storeHash, storeBitsOn := rleEncodeLayer(gm, level, levels)
+				if !bytes.Equal(rle, storeRle) || hash != storeHash || bitsOn != storeBitsOn {
+					t.Errorf("%v/%v, pass %v: expected %v bits on, got %v", level, levels, pass, bitsOn, storeBitsOn)
+				}
+			}
+		}
+	}
+}
@@ -40,12 +40,35 @@ Printers known to work with this tool:
 | NOVA3D Elfin     | cws          | None                                              |
 | Phrozen Sonic    | phz          | None                                              |
 | Zortrax Inkspire | zcodex       | Read-only (for format conversion)                 |
+| -                | stl, obj     | Write-only (mesh reconstructed from the layers)   |
+| -                | 3mf          | Write-only (layers as 3MF slice extension)        |
 
 ## Installation
 
 * Release package: [https://github.com/ezrec/uv3dp/releases](https://github.com/ezrec/uv3dp/releases)
 * Go install: `go get github.com/ezrec/uv3dp/cmd/uv3dp; ${GOROOT}/bin/uv3dp`
 
+File formats can be left out of the build to reduce its size, with the
+`uv3dp_no_FORMAT` build tags (ie `-tags uv3dp_no_sl1`), or the `minimal` build
+tag, which only includes the `uvj` format:
+
+    go build -tags minimal github.com/ezrec/uv3dp/cmd/uv3dp
+
+Programs using `uv3dp` as a library can import `github.com/ezrec/uv3dp/formats`
+for all of the file formats, or only the format packages they need. The
+stable (v1) library API, and the deprecated exports that are kept for
+compatibility, are listed in the package documentation (`go doc uv3dp`).
+
+Release builds can update themselves to the latest release, after checking
+its SHA-256 checksum. The checksums are published with the release, so they
+detect a corrupted download, but not a tampered release; there is no
+signature check. Notices of new releases are off by default, and are
+checked for at most once a day when enabled:
+
+    uv3dp self-update --check             # Shows if a new release is available
+    uv3dp self-update                     # Installs the latest release
+    uv3dp self-update --notify            # Enables notices of new releases
+
 ## Command Line Tool (`uv3dp`)
 
 The command line tool is designed to be used in a 'pipeline' style, for example:
@@ -54,6 +77,519 @@ The command line tool is designed to be used in a 'pipeline' style, for example:
     uv3dp foo.sl1 decimate bar.cbddlp     # Convert and decimates a SL1 file to a CBDDLP file
     uv3dp foo.sl1 qux.cbddlp --version 1  # Convert a SL1 file to a Version 1CBDDLP file
 
+For simple conversions to a specific machine, `convert` adapts the bed size,
+checks the result, and reports anything the new file format could not keep:
+
+    uv3dp convert --to e6 foo.sl1         # Writes foo.ctb for the EPAX E6 mono
+    uv3dp convert --to mars --mirror foo.sl1 out/
+
+### File format types
+
+The file format is normally chosen from the file's extension. To override
+this for a single file, follow the file name with `-t TYPE` (or `--format TYPE`).
+To override it for all files, use `-t TYPE` before the first file:
+
+    uv3dp foo.photon -t ctb info          # foo.photon is really a CTB file
+    uv3dp -t uvj foo.bin bar.bin          # Both files are UVJ files
+
+The file format types available are shown by `uv3dp formats list`.
+
+When a file's extension does not match its contents, `uv3dp formats test FILE`
+tries to read it as each file format type, and shows how confident it is
+that the file is of each type:
+
+    uv3dp formats test foo.photon
+
+The file name `-` reads from standard input, or writes to standard output,
+and always needs a file format type:
+
+    curl -s http://example.com/foo.sl1 | uv3dp - -t sl1 bar.ctb
+    uv3dp foo.sl1 - -t ctb | ssh printer 'cat >bar.ctb'
+
+Input files can also be HTTP or HTTPS URLs:
+
+    uv3dp -p https://example.com/jobs/foo.sl1 bar.ctb
+
+With `--machine NAME` (or `-M NAME`), output files without an extension, and
+output directories, use the file format of that machine:
+
+    uv3dp -M e6 foo.sl1 bar               # Writes bar.ctb, as a Version 3 CTB file
+    uv3dp -M e6 foo.sl1 outdir/           # Writes outdir/foo.ctb
+
+### Print history
+
+Print outcomes can be recorded in `~/.config/uv3dp/history.jsonl`, along with
+the exposure settings of the print file, and summarized by resin and exposure:
+
+    uv3dp log success foo.ctb --resin "Siraya Blu" --notes "Crisp details"
+    uv3dp log failure bar.ctb --resin "Siraya Blu"
+    uv3dp log report
+
+The `advise` command applies the exposure with the best success rate for the
+resin, machine, and layer height from the print history:
+
+    uv3dp foo.sl1 advise --resin "Siraya Blu" --machine e6 bar.ctb
+
+### Exposure matrix
+
+The exposure of every layer can be exported to a CSV file, edited in a
+spreadsheet, and applied to the print again. The `Z` column is for reference
+only, and is ignored on import.
+
+    uv3dp foo.ctb exposure export matrix.csv
+    uv3dp foo.ctb exposure import matrix.csv bar.ctb
+
+Individual settings of ranges of layers can be changed with the
+`--per-layer` option, from a CSV file whose first column is `Layer` or `Z`
+(in millimeters), as a single value or a `FIRST-LAST` range, and whose other
+columns are any of the matrix settings. Empty cells are left unchanged, and
+later rows override earlier ones:
+
+    Layer,LightOnTime,LiftSpeed
+    10-19,9.5,
+    40,,45
+
+A JSON file (with a `.json` extension) lists the same settings as objects:
+
+    [{"Z": "2.5-3.0", "LightOnTime": 9.5, "LightPWM": 200}]
+
+The `ctb` (version 3 and later), `uvj`, and `sl1` files keep the settings of
+each layer. SL1 printers only use the normal and bottom exposures.
+
+### Bottom layer fade
+
+The `fade` style of the `bottom` command steps the exposure time down from
+the bottom layers to the normal layers over a number of transition layers,
+instead of in a single step, with a `linear`, `sinusoidal`, or `exponential`
+curve:
+
+    uv3dp foo.ctb bottom --count 3 --style fade --transition 5 --curve exponential bar.ctb
+
+### Rest times
+
+High viscosity resins may need the platform to rest before the light is
+on, after the exposure, and after the lift. The `exposure` command sets the
+`--wait-before-cure` and `--wait-after-cure` times of the normal layers,
+the `lift` command sets the `--wait` time after the lift, and the `bottom`
+command sets all three for the bottom layers:
+
+    uv3dp foo.ctb exposure --wait-before-cure 1 --wait-after-cure 0.5 lift --wait 2 bar.ctb --version 4
+
+The rest times are saved by `ctb` version 4 and `uvj` files, and are
+included in the print time estimates. `ctb` files are saved as version 3
+unless `--version 4` is given. The `pws` 2.x and `goo` formats, which
+can also save rest times, are not supported yet. The `convert` command reports
+the rest times that the target format can not save.
+
+### Two-stage lift
+
+Large printers can lift and retract in two stages (TSMC), lifting slowly
+off of the film and then quickly, and retracting quickly and then slowly
+for the last millimeters. The `--height2` and `--speed2` options of the
+`lift` command set the second stage of the lift, and those of the `retract`
+command set the last stage of the retract:
+
+    uv3dp foo.ctb lift --height 3 --speed 60 --height2 5 --speed2 300 retract --speed 300 --height2 2 --speed2 60 bar.ctb --version 4
+
+The stages are saved by `ctb` version 4 and `uvj` files.
+
+The `bottom` command sets the same lift, retract, and rest settings for
+the bottom layers, without editing a `uvj` file:
+
+    uv3dp foo.ctb bottom --lift-height 5 --lift-speed 30 --retract-height 4 --retract-speed 90 --wait-after-lift 2 bar.ctb
+
+### Reslicing
+
+The `reslice` command resamples the layers to a new layer height, without
+the original model. Thinner layers duplicate the layer they are in, and
+thicker layers blend the layers they cover as grayscale. The `nearest` mode
+uses the layer at the middle of each new layer instead:
+
+    uv3dp foo.ctb reslice --layer-height 0.025 bar.ctb
+
+The bottom layers cover the same height as before. The exposure times are
+not changed, so adjust them for the new layer height with the `exposure`
+and `bottom` commands.
+
+### Variable layer heights
+
+The Z height of each layer can be exported to a CSV file of `Layer` and `Z`
+(in millimeters) columns, edited, and imported again. Layers missing from
+the imported file keep their height, and the heights must increase from
+layer to layer:
+
+    uv3dp foo.ctb ztable export z.csv
+    uv3dp foo.ctb ztable import z.csv bar.ctb
+
+The `ctb`, `cbddlp`, `uvj`, and `sl1` files keep the height of each layer,
+and the G-code of `cws` files moves to it. SL1 printers only use the layer
+height of the print.
+
+### Z offset
+
+The `zoffset` command moves every layer up or down, for a build plate
+that is trammed slightly high or low. It can instead lower only the first
+layer (`--squish`), pressing it harder against the build plate, and spread
+that over the first `--layers` layers:
+
+    uv3dp foo.ctb zoffset --offset -0.02 bar.ctb
+    uv3dp foo.ctb zoffset --squish 0.03 --layers 3 bar.ctb
+
+The new heights are saved by the same formats as variable layer heights.
+
+### Resuming a failed print
+
+The `resume` command removes the layers below a Z height (`--z`) or a
+layer (`--layer`), keeping the rest at their original heights, so that a
+failed print can be restarted on top of the part left on the build plate.
+The resumed layers use the normal exposure, unless `--bottom` exposes some
+of them as bottom layers:
+
+    uv3dp foo.ctb resume --z 12.5 bar.ctb
+
+### Subpixel rendering
+
+Some older printers use RGB panels, where each pixel is three subpixels
+side by side. The `subpixel` command renders layers sliced at three times
+the horizontal resolution of the panel, so that each gray pixel is one
+subpixel, in the order of the `--panel` (`rgb`, `bgr`, or `vrgb` and `vbgr`
+for vertically stacked subpixels). The `--filter` spreads the light of each
+subpixel over its neighbours, to even out the exposure, and `--weights`
+brightens the subpixels whose color filters pass less UV light:
+
+    uv3dp foo.ctb subpixel --panel bgr --weights 0.6,0.8,1 bar.ctb
+
+### Preview images
+
+The `preview` command exports a preview image as PNG, or replaces it with
+a PNG, JPEG, GIF, or BMP image. The `--type` selects the `tiny` or `huge`
+preview. An imported image is resized to the preview it replaces (or to
+`--width` and `--height`), and letterboxed to keep its aspect ratio:
+
+    uv3dp foo.ctb preview --type huge export huge.png
+    uv3dp foo.ctb preview --type tiny import logo.jpg bar.ctb
+
+Formats whose previews are shown on the printer's screen (`.ctb`,
+`.cbddlp`, `.photon`, `.fdg`, `.phz`, `.pws`, and `.pw0`) render any missing
+previews from the layers, as a top-down view shaded by the height of the
+model, instead of leaving them blank.
+
+### Mesh export
+
+Writing to a `.stl` or `.obj` file reconstructs a triangle mesh of the
+model from the layers, with marching cubes, to recover its geometry when
+the source model is lost. The `--step` option samples fewer pixels, for a
+smaller mesh:
+
+    uv3dp foo.ctb model.stl
+    uv3dp foo.ctb model.obj --step 4
+
+### Packing plates
+
+The `pack` command arranges the models of several files for the same
+machine (with the same bed and layer height) onto one plate, in shelves,
+with `--spacing` millimeters between them and around the edges of the
+plate, and merges their layers. The exposure settings of the first file
+are used.
+
+    uv3dp part-a.ctb pack --input part-b.ctb --input part-c.ctb --spacing 3 plate.ctb
+
+### Cross sections
+
+The `section` command saves the layer nearest to a Z height (`--z`) to a
+PNG file, or a vertical section through all of the layers at a column
+(`--x`) or row (`--y`) of the layer images, in millimeters. Vertical
+sections are to scale, with the top of the print at the top of the image:
+
+    uv3dp foo.ctb section --z 12.5 layer.png
+    uv3dp foo.ctb section --x 34 side.png
+
+### Print profiles
+
+The exposure, bottom, lift, and retract settings of a print can be saved to
+a JSON profile file, and applied to other prints. Settings missing from a
+profile file are left as they are.
+
+    uv3dp good.ctb profile export good.json
+    uv3dp new.ctb profile apply good.json new-good.ctb
+
+### Layer notes
+
+Notes, such as QA observations, can be attached to layers. They are stored
+in the file for the `uvj` format, and in a `FILE.notes.json` file next to
+the print file for all other formats.
+
+    uv3dp foo.ctb annotate --layer 120 --note "Support failure seen here" foo-notes.ctb
+    uv3dp foo-notes.ctb info
+
+Commands that add, remove, or move layers (`raft`, `stack`, `split`,
+`reslice`, `resume`, and `select`) keep the notes with their layers. Notes
+of layers that are removed are dropped, and the notes of layers that are
+merged by `reslice` are joined.
+
+### Pipeline reports
+
+With `--report`, the pipeline is shown as JSON when it is done, with the
+values of all options of each file and command. The `CommandFile` entry is a
+command file that repeats the pipeline, and is also saved in the `Pipeline`
+metadata of the output files (for formats that keep metadata):
+
+    uv3dp --report foo.ctb exposure --light-on 6 bar.ctb > report.json
+
+### Debugging file formats
+
+To help with adding support for new printer firmware, `debug dump` shows a
+hexdump of the header regions of a file, with the value of each field. Fields
+whose purpose is not known are marked with `?`, and the regions between the
+known structures are shown as unknown regions. Only the `ctb`, `cbddlp`, and
+`photon` formats can be dumped.
+
+    uv3dp debug dump foo.ctb
+
+### File information
+
+The `info` command shows the size, estimated print time (by phase), and
+settings of a file. It can also show the statistics of each layer
+(`--layers`), the use of each gray level (`--histogram`), which shows if
+the layers are anti-aliased, and the resin used (`--usage`). With `--json`,
+all of it is shown as JSON, for scripts and print farm dashboards.
+
+    uv3dp foo.ctb info --layers --histogram
+    uv3dp foo.ctb info --json --usage --price 40
+
+### Comparing files
+
+The `diff` command compares the input with a second file, layer by layer,
+and fails if they differ, which checks that a conversion or a filter chain
+kept everything. It shows the settings that differ, and with `--layers`,
+the number of pixels and the settings that differ on each layer.
+
+    uv3dp foo.sl1 bar.ctb
+    uv3dp bar.ctb diff --layers --input foo.sl1
+
+### Shared layer store
+
+Print farms that encode many similar files can share a store of encoded
+layers, so that identical layers are only encoded once. The store is a
+directory, which can be shared by many jobs at once. The layers of `ctb`,
+`cbddlp`, `photon`, `phz`, `fdg`, `pws`, and `pw0` files are kept in the
+store, and are addressed by the SHA-256 of their images, whatever the
+`--hash` option.
+
+    uv3dp --layer-store /srv/uv3dp/layers foo.sl1 foo.ctb
+
+The `--hash` option selects the hash of layers and files: `crc64` (the
+default for layers), `xxhash`, or `sha256` (the default for files, such as
+in the print history).
+
+### Light source aging
+
+The UV output of a machine's light source declines as it ages. The `age`
+sub-command keeps count of the light source hours of each machine, and the
+`compensate-age` command scales the exposures of a print to match.
+
+    uv3dp age add e6 6h30m     # Add the hours of a print
+    uv3dp age set e6 812       # Set the hours, ie from the printer's menu
+    uv3dp age fetch e6 http://printer/status
+    uv3dp age curve e6 0:1,1000:1.08,3000:1.25
+    uv3dp -M e6 foo.sl1 compensate-age bar
+
+The `fetch` sub-command reads the hours from a printer's JSON status, from
+the `hours` field (or from the `--field` option's dotted path). Machines
+with no curve of their own use a generic curve for LED arrays.
+
+### Custom machines
+
+Machines that are not built in can be defined in `~/.config/uv3dp/machines.json`
+(or in the file of the `--machines` option), by name, without recompiling.
+Machines with the name of a built-in machine replace it. The machines
+shown by `uv3dp --help` include where each machine was defined.
+
+    {
+      "my-printer": {
+        "Vendor": "Acme", "Model": "Printer",
+        "Size": { "X": 2560, "Y": 1440, "Xmm": 120.96, "Ymm": 68.04 },
+        "Height": 150, "MaxSpeed": 180, "Power": 3.5,
+        "Format": "ctb", "Args": [ "--version=3" ]
+      }
+    }
+
+### Resin profiles
+
+Resins are read from the local ChiTuBox config, and from the user resins
+files `~/.config/uv3dp/resins.json` and `~/.config/uv3dp/resins.toml`.
+The `resin` command can save the exposure settings of a tuned print file as
+a user resin, import the resins of a JSON or TOML file, and list the known
+resins and where they were defined:
+
+    uv3dp tuned.ctb resin --save "Siraya Blu"
+    uv3dp resin --import friends-resins.toml --list
+    uv3dp foo.sl1 resin --type "Siraya Blu" bar.ctb
+
+Resins files have the exposure settings of each resin, by name:
+
+    ["Siraya Blu"]
+    Density = 1.1
+    Price = 45
+
+    ["Siraya Blu".Exposure]
+    LightOnTime = 2.5
+    LiftHeight = 5
+    LiftSpeed = 60
+
+    ["Siraya Blu".Bottom]
+    Count = 6
+    LightOnTime = 30
+    LiftHeight = 5
+    LiftSpeed = 40
+
+### Importing slicer profiles
+
+The `import` command converts the printer and material profiles of UVtools
+and PrusaSlicer (`.ini` profiles and config bundles) and ChiTuBox (`.cfg`
+exports) into user machines and resins, so that they do not need to be
+retyped. Lychee profiles are not supported yet.
+
+    uv3dp import PrusaSlicer_config_bundle.ini
+    uv3dp import ~/.config/ChiTuBox/machine/0.cfg
+    uv3dp -M epax-e6-mono foo.sl1 resin --type "Siraya Blu" bar
+
+### Placing prints on a new bed
+
+The `bed` command places the old bed on the new bed at its `--anchor`
+(the center, an edge, or a corner), or centers the model itself on the new
+bed (`--center`). Models that do not fit on the new bed are an error, that
+shows how far they are over its edges, unless `--fit` scales them down (if
+needed) and moves them onto the bed.
+
+Machines with pixels that are not square are resampled by different
+factors in X and Y, so that prints keep their size in millimeters, unless
+`--square-pixels` is used.
+
+    uv3dp foo.ctb bed --machine orange10 --center bar.lgs
+    uv3dp foo.ctb bed --machine orange10 --anchor top-left --fit bar.lgs
+
+### Exposure scaling
+
+When the `bed` command retargets a file to another machine, the exposure
+times are copied as they are, unless they are scaled by a factor
+(`--exposure-scale`), or by the ratio of the light power (the `Power` of
+the machines, in mW/cm²) of the machine the file was sliced for and the
+new machine (`--from`). Built-in machines have no known power, so
+it must be set for user machines:
+
+    uv3dp foo.ctb bed --machine my-printer --from my-old-printer bar.ctb
+    uv3dp foo.ctb bed --machine e6 --exposure-scale 1.2 bar.ctb
+
+### Validating against a machine
+
+The `validate` command checks a file against the limits of a machine (its
+resolution, bed size, build height, and lift speed) and the quirks of its
+firmware, showing every issue found. It fails on errors (or on warnings,
+with `--strict`), so that print farms can check files before printing them.
+
+    uv3dp -M e6 -F 1.2.3 foo.ctb validate
+    uv3dp foo.ctb validate --machine e6 --strict --islands
+
+### Vat capacity
+
+Output files for a `--machine` with a known vat capacity are checked for
+resin use that would need the vat to be refilled during the print. The
+`refill` command adds a wait before each layer that would use more resin
+than is left in the vat, so that it can be refilled.
+
+    uv3dp foo.ctb refill --vat 200 --wait 300 bar.ctb
+
+### Tall prints
+
+Prints that are taller than a printer's Z travel can be split into parts
+of at most a `--height`, at the joints with the smallest areas. With
+`--keys`, registration keys are added on top of each part, with matching
+sockets in the bottom of the part above, so that the parts line up when
+they are glued together.
+
+    uv3dp foo.ctb split --height 150 --keys 3 --output foo-%d.ctb
+
+### Screen exclusions
+
+Areas of a machine's screen with permanent defects can be listed in the
+`~/.config/uv3dp/exclusions.txt` file (`%LOCALAPPDATA%\uv3dp\exclusions.txt`
+on Windows), in pixels, as rectangles or polygons. They are blanked in all
+layers of output files for the `--machine`, with a warning if the model
+overlaps them.
+
+    # Dead columns, and a damaged corner
+    e6: rect 812,0,816,2560
+    e6: polygon 0,0 120,0 0,90
+
+### Screen tests
+
+The `lcdtest` input generates a one layer print of a test pattern at a
+machine's native resolution, for checking a screen for dead pixels and the
+uniformity of its light without a slicer:
+
+    uv3dp lcdtest -M mars2-pro -P checker --size 32 checker.ctb
+    uv3dp lcdtest -M mars2-pro -P gradient --exposure 4 gradient.ctb
+
+The patterns are `white`, `checker`, `gradient`, and `grid`.
+
+### Tolerance tests
+
+The `tolerance` input generates a peg and hole test for a machine, with a
+plate for each clearance, next to a peg that fits its hole. Print it, and
+find the smallest clearance that still fits, to dial in the XY compensation
+of a resin:
+
+    uv3dp tolerance -M mars --clearances 0.1,0.15,0.2,0.25 --diameter 4 tolerance.cbddlp
+
+The tests are laid out along the long side of the bed, in the order of
+their clearances.
+
+### Exposure tests
+
+Anycubic printers print files named `R_E_R_F` as exposure tests, with the
+screen divided into 8 regions, each exposed for a scaled amount of the
+normal exposure time. The `--rerf` option of the `pws` and `pw0` files
+places a copy of the model in each region, and sets the normal exposure time
+so that the region exposures bracket the exposure of the input:
+
+    uv3dp model.pws R_E_R_F.pws --rerf
+
+The `--rerf-scale` option sets the exposure scale of each region, if the
+firmware of a machine uses a different table.
+
+### Shared layer images
+
+Identical layers, such as the repeated layers of calibration prints, share
+one copy of their image data in `cbddlp`, `photon`, `pws`, and `pw0` files,
+and in unencrypted version 1 `ctb` files. Use the
+`--dedup=false` option of a file to store every layer's image separately:
+
+    uv3dp model.ctb model.pws --dedup=false
+
+### Mirrored files
+
+Some file formats may store the layer images mirrored, depending on the
+projector type of the machine. By default, layer images are read and written
+as they are stored. The `--normalize-orientation` option mirrors the layer
+images of `ctb`, `cbddlp`, `photon`, `fdg`, and `phz` files whose projector
+type is `LCD_X_MIRROR` (as uv3dp writes them) when reading and writing them,
+so that conversions to and from other formats keep the orientation of the
+print. This has not been verified against files from the printer vendors'
+slicers, so check the orientation of a converted print before relying on it.
+
+### Macros
+
+User defined commands can be added to `~/.config/uv3dp/macros.cmd`
+(`%LOCALAPPDATA%\uv3dp\macros.cmd` on Windows), one per line:
+
+    # name: command [options] [command [options]]...
+    mars-night: exposure --light-on 8 lift --height 6
+
+Macros can then be used in pipelines and command files like any other command. A macro
+can't have the name of a command, or of a subcommand such as `stats` or
+`convert`.
+
 ### Command summary:
 EOF
 
@@ -0,0 +1,149 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type AdviseCommand struct {
+	*pflag.FlagSet
+
+	Filename string
+	Resin    string
+	Machine  string
+}
+
+func NewAdviseCommand() (cmd *AdviseCommand) {
+	flagSet := pflag.NewFlagSet("advise", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &AdviseCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Filename, "file", "f", HistoryPath, "Print history file")
+	cmd.StringVarP(&cmd.Resin, "resin", "r", "", "Resin to suggest exposure for")
+	cmd.StringVarP(&cmd.Machine, "machine", "M", "", "Machine to suggest exposure for (default is from the input)")
+
+	return
+}
+
+// HistoryAdvice is a suggested exposure, and the history supporting it
+type HistoryAdvice struct {
+	Exposure uv3dp.Exposure
+	Bottom   uv3dp.Bottom
+	Success  int
+	Failure  int
+}
+
+// HistoryAdvise suggests the exposure with the best success rate of the
+// matching prints, preferring the most successes when rates are equal.
+// An empty resin or machine matches all prints.
+func HistoryAdvise(records []HistoryRecord, resin string, machine string, layerHeight float32) (advice HistoryAdvice, ok bool) {
+	type key struct {
+		Exposure uv3dp.Exposure
+		Bottom   uv3dp.Bottom
+	}
+
+	byExposure := map[key]*HistoryAdvice{}
+	order := []key{}
+
+	for _, record := range records {
+		if resin != "" && record.Resin != resin {
+			continue
+		}
+		if machine != "" && record.Machine != machine {
+			continue
+		}
+		if math.Abs(float64(record.LayerHeight-layerHeight)) > 0.001 {
+			continue
+		}
+
+		k := key{Exposure: record.Exposure, Bottom: record.Bottom}
+		item, found := byExposure[k]
+		if !found {
+			item = &HistoryAdvice{Exposure: record.Exposure, Bottom: record.Bottom}
+			byExposure[k] = item
+			order = append(order, k)
+		}
+
+		if record.Outcome == HistorySuccess {
+			item.Success++
+		} else {
+			item.Failure++
+		}
+	}
+
+	rate := func(item *HistoryAdvice) float64 {
+		return float64(item.Success) / float64(item.Success+item.Failure)
+	}
+
+	var best *HistoryAdvice
+	for _, k := range order {
+		item := byExposure[k]
+		if item.Success == 0 {
+			continue
+		}
+
+		if best == nil || rate(item) > rate(best) ||
+			(rate(item) == rate(best) && item.Success > best.Success) {
+			best = item
+		}
+	}
+
+	if best != nil {
+		advice = *best
+		ok = true
+	}
+
+	return
+}
+
+func (cmd *AdviseCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	output = input
+
+	records, err := HistoryLoadFile(cmd.Filename)
+	if err != nil {
+		return
+	}
+
+	machine := cmd.Machine
+	if !cmd.Changed("machine") {
+		data, ok := input.Metadata("Machine")
+		if ok {
+			machine, _ = data.(string)
+		}
+	}
+
+	layerHeight := input.Size().LayerHeight
+
+	advice, ok := HistoryAdvise(records, cmd.Resin, machine, layerHeight)
+	if !ok {
+		err = fmt.Errorf("advise: no successful prints recorded for resin '%v', machine '%v', at %.3g mm layers",
+			cmd.Resin, machine, layerHeight)
+		return
+	}
+
+	fmt.Printf("Advice: %.3gs exposure, %.3gs for %d bottom layers (%d of %d prints succeeded)\n",
+		advice.Exposure.LightOnTime, advice.Bottom.Exposure.LightOnTime, advice.Bottom.Count,
+		advice.Success, advice.Success+advice.Failure)
+
+	output = &resinModifier{
+		Printable: input,
+		Resin: Resin{
+			Name:     cmd.Resin,
+			Exposure: advice.Exposure,
+			Bottom:   advice.Bottom,
+		},
+	}
+
+	return
+}
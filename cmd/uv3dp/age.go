@@ -0,0 +1,322 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// AgeConfigPath is the file of the light source hours of each machine
+var AgeConfigPath = uv3dpPath("aging.json")
+
+type AgeCommand struct {
+	*pflag.FlagSet
+
+	Filename string
+	Field    string
+}
+
+func NewAgeCommand() (cmd *AgeCommand) {
+	flagSet := pflag.NewFlagSet("age", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &AgeCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Filename, "file", "f", AgeConfigPath, "Light source hours file")
+	cmd.StringVarP(&cmd.Field, "field", "", "hours", "Field of the printer status JSON with the light source hours, as a dotted path (ie 'status.uv_hours')")
+
+	return
+}
+
+// AgeMachineName is the name machines are stored by in the hours file.
+// All variants of a machine share their light source, so the variant
+// is not included.
+func AgeMachineName(name string) (base string, err error) {
+	_, err = uv3dp.LookupMachine(name)
+	if err != nil {
+		return
+	}
+
+	base = strings.SplitN(name, "@", 2)[0]
+
+	return
+}
+
+// AgeLoad reads the light source hours of machines. A missing file has no machines.
+func AgeLoad(filename string) (ages map[string]uv3dp.MachineAging, err error) {
+	ages = map[string]uv3dp.MachineAging{}
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, &ages)
+	if err != nil {
+		err = fmt.Errorf("%v: %w", filename, err)
+	}
+
+	return
+}
+
+// AgeSave writes the light source hours of machines
+func AgeSave(filename string, ages map[string]uv3dp.MachineAging) (err error) {
+	data, err := json.MarshalIndent(ages, "", "  ")
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return
+	}
+
+	// Replace the file in one step, so that an interrupted save
+	// does not lose the hours.
+	tmpname := filename + ".tmp"
+	err = ioutil.WriteFile(tmpname, append(data, '\n'), 0644)
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(tmpname, filename)
+
+	return
+}
+
+// AgeLookup returns the aging of a machine: the machine profile's,
+// updated by the hours file's.
+func AgeLookup(ages map[string]uv3dp.MachineAging, name string) (aging uv3dp.MachineAging, err error) {
+	machine, err := uv3dp.LookupMachine(name)
+	if err != nil {
+		return
+	}
+
+	aging = machine.Aging
+
+	base, _ := AgeMachineName(name)
+	saved, ok := ages[base]
+	if ok {
+		aging.Hours = saved.Hours
+		if len(saved.Curve) > 0 {
+			aging.Curve = saved.Curve
+		}
+	}
+
+	return
+}
+
+// AgeParseHours parses hours, either as a number (ie '2.5') or a duration (ie '2h30m')
+func AgeParseHours(text string) (hours float32, err error) {
+	value, err := strconv.ParseFloat(text, 32)
+	if err == nil {
+		hours = float32(value)
+		return
+	}
+
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		err = fmt.Errorf("'%v' is not a number of hours, or a duration", text)
+		return
+	}
+
+	hours = float32(duration.Hours())
+
+	return
+}
+
+// AgeParseCurve parses a compensation curve, as 'HOURS:SCALE,HOURS:SCALE,...'
+func AgeParseCurve(text string) (curve []uv3dp.AgingPoint, err error) {
+	for _, item := range strings.Split(text, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 2 {
+			err = fmt.Errorf("curve point '%v' is not HOURS:SCALE", item)
+			return
+		}
+
+		var hours, scale float64
+		hours, err = strconv.ParseFloat(parts[0], 32)
+		if err == nil {
+			scale, err = strconv.ParseFloat(parts[1], 32)
+		}
+		if err != nil {
+			err = fmt.Errorf("curve point '%v': %w", item, err)
+			return
+		}
+
+		if hours < 0 || scale <= 0 {
+			err = fmt.Errorf("curve point '%v': hours must not be negative, and scale must be positive", item)
+			return
+		}
+
+		curve = append(curve, uv3dp.AgingPoint{Hours: float32(hours), Scale: float32(scale)})
+	}
+
+	sort.Slice(curve, func(i, j int) bool { return curve[i].Hours < curve[j].Hours })
+
+	for n := 1; n < len(curve); n++ {
+		if curve[n].Hours == curve[n-1].Hours {
+			err = fmt.Errorf("curve has more than one point at %v hours", curve[n].Hours)
+			return
+		}
+	}
+
+	return
+}
+
+// AgeFetch reads the light source hours from a printer's JSON status,
+// at a dotted path of fields
+func AgeFetch(client *http.Client, location string, field string) (hours float32, err error) {
+	data, err := updateGet(client, location)
+	if err != nil {
+		return
+	}
+
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		err = fmt.Errorf("%v: %w", location, err)
+		return
+	}
+
+	for _, name := range strings.Split(field, ".") {
+		object, ok := value.(map[string]interface{})
+		if ok {
+			value, ok = object[name]
+		}
+		if !ok {
+			err = fmt.Errorf("%v: no '%v' field in status", location, field)
+			return
+		}
+	}
+
+	number, ok := value.(float64)
+	if !ok {
+		err = fmt.Errorf("%v: '%v' field is not a number", location, field)
+		return
+	}
+
+	hours = float32(number)
+
+	return
+}
+
+// AgeShow shows the light source hours and exposure scale of machines
+func AgeShow(writer io.Writer, ages map[string]uv3dp.MachineAging, names []string) {
+	for _, name := range names {
+		aging, err := AgeLookup(ages, name)
+		if err != nil {
+			fmt.Fprintf(writer, "%-16s %v\n", name, err)
+			continue
+		}
+
+		fmt.Fprintf(writer, "%-16s %8.1f hours, exposure scale %.3f\n", name, aging.Hours, aging.Scale())
+	}
+}
+
+// Run executes the 'age' sub-command
+func (cmd *AgeCommand) Run() (err error) {
+	args := cmd.Args()
+	if len(args) == 0 {
+		err = fmt.Errorf("age: expected 'show', 'add', 'set', 'curve' or 'fetch' sub-command")
+		return
+	}
+
+	ages, err := AgeLoad(cmd.Filename)
+	if err != nil {
+		return
+	}
+
+	if args[0] == "show" {
+		names := args[1:]
+		if len(names) == 0 {
+			for name := range ages {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+		AgeShow(os.Stdout, ages, names)
+		return
+	}
+
+	if len(args) != 3 {
+		err = fmt.Errorf("age: expected '%v MACHINE VALUE', got %v", args[0], args)
+		return
+	}
+
+	name, err := AgeMachineName(args[1])
+	if err != nil {
+		err = fmt.Errorf("age: %w", err)
+		return
+	}
+
+	aging, err := AgeLookup(ages, name)
+	if err != nil {
+		return
+	}
+
+	// Only the hours, and curves set by the user, are saved
+	saved := ages[name]
+
+	switch args[0] {
+	case "add", "set":
+		var hours float32
+		hours, err = AgeParseHours(args[2])
+		if err != nil {
+			break
+		}
+		if args[0] == "add" {
+			hours += aging.Hours
+		}
+		if hours < 0 {
+			err = fmt.Errorf("hours must not be negative")
+			break
+		}
+		saved.Hours = hours
+	case "curve":
+		saved.Hours = aging.Hours
+		saved.Curve, err = AgeParseCurve(args[2])
+	case "fetch":
+		client := &http.Client{Timeout: 10 * time.Second}
+		saved.Hours, err = AgeFetch(client, args[2], cmd.Field)
+	default:
+		err = fmt.Errorf("'%v' is not a sub-command", args[0])
+	}
+	if err != nil {
+		err = fmt.Errorf("age: %w", err)
+		return
+	}
+
+	ages[name] = saved
+
+	err = AgeSave(cmd.Filename, ages)
+	if err != nil {
+		return
+	}
+
+	AgeShow(os.Stdout, ages, []string{name})
+
+	return
+}
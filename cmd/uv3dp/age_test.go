@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	machine := uv3dp.Machine{
+		Vendor: "Test",
+		Model:  "Aging",
+		Aging: uv3dp.MachineAging{
+			Hours: 10,
+			Curve: []uv3dp.AgingPoint{{Hours: 0, Scale: 1.0}, {Hours: 1000, Scale: 1.5}},
+		},
+	}
+	uv3dp.RegisterMachine("test-aging", machine, ".uvj")
+	uv3dp.RegisterMachineVariant("test-aging", "binned", uv3dp.MachineSize{})
+}
+
+func TestAgeParse(t *testing.T) {
+	for text, expected := range map[string]float32{"2.5": 2.5, "90m": 1.5, "1h30m": 1.5} {
+		hours, err := AgeParseHours(text)
+		if err != nil || hours != expected {
+			t.Errorf("%v: expected %v, got %v (%v)", text, expected, hours, err)
+		}
+	}
+
+	_, err := AgeParseHours("soon")
+	if err == nil {
+		t.Errorf("expected an error for an invalid number of hours")
+	}
+
+	curve, err := AgeParseCurve("1000:1.2, 0:1")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := []uv3dp.AgingPoint{{Hours: 0, Scale: 1}, {Hours: 1000, Scale: 1.2}}
+	if !cmp.Equal(expected, curve) {
+		t.Errorf("expected %v, got %v", expected, curve)
+	}
+
+	for _, text := range []string{"1000", "0:1,0:2", "10:-1", "a:b"} {
+		_, err = AgeParseCurve(text)
+		if err == nil {
+			t.Errorf("%v: expected an error", text)
+		}
+	}
+}
+
+func TestAgeLookup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "age")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "uv3dp", "aging.json")
+
+	// A missing file uses the machine profile's aging
+	ages, err := AgeLoad(filename)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	aging, err := AgeLookup(ages, "test-aging")
+	if err != nil || aging.Hours != 10 {
+		t.Errorf("expected 10 hours, got %v (%v)", aging.Hours, err)
+	}
+
+	ages["test-aging"] = uv3dp.MachineAging{Hours: 500}
+	err = AgeSave(filename, ages)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	ages, err = AgeLoad(filename)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	// Variants share the hours, and the profile's curve is kept
+	aging, err = AgeLookup(ages, "test-aging@binned")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if aging.Hours != 500 || aging.Scale() != 1.25 {
+		t.Errorf("expected 500 hours and scale 1.25, got %v and %v", aging.Hours, aging.Scale())
+	}
+
+	_, err = AgeLookup(ages, "test-unknown")
+	if err == nil {
+		t.Errorf("expected an error for an unknown machine")
+	}
+}
+
+func TestAgeFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": {"uv_hours": 123.5, "state": "idle"}}`))
+	}))
+	defer server.Close()
+
+	hours, err := AgeFetch(server.Client(), server.URL, "status.uv_hours")
+	if err != nil || hours != 123.5 {
+		t.Errorf("expected 123.5, got %v (%v)", hours, err)
+	}
+
+	for _, field := range []string{"hours", "status.state", "status.uv_hours.more"} {
+		_, err = AgeFetch(server.Client(), server.URL, field)
+		if err == nil {
+			t.Errorf("%v: expected an error", field)
+		}
+	}
+}
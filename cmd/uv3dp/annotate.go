@@ -0,0 +1,72 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type AnnotateCommand struct {
+	*pflag.FlagSet
+
+	Layer int
+	Note  string
+	Clear bool
+}
+
+func NewAnnotateCommand() (cmd *AnnotateCommand) {
+	flagSet := pflag.NewFlagSet("annotate", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &AnnotateCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.IntVarP(&cmd.Layer, "layer", "l", 0, "Layer to annotate")
+	cmd.StringVarP(&cmd.Note, "note", "n", "", "Note for the layer")
+	cmd.BoolVarP(&cmd.Clear, "clear", "c", false, "Remove the note of the layer (or of all layers, if no --layer)")
+
+	return
+}
+
+func (cmd *AnnotateCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	output = input
+
+	if cmd.Clear == cmd.Changed("note") {
+		err = fmt.Errorf("annotate: exactly one of --note or --clear must be specified")
+		return
+	}
+
+	layers := input.Size().Layers
+	if cmd.Layer < 0 || cmd.Layer >= layers {
+		err = fmt.Errorf("annotate: layer %v is not in the range 0..%v", cmd.Layer, layers-1)
+		return
+	}
+
+	annotations := uv3dp.LayerAnnotations(input)
+
+	switch {
+	case cmd.Clear && !cmd.Changed("layer"):
+		TraceVerbosef(VerbosityNotice, "  Removing all layer notes")
+		annotations = uv3dp.Annotations{}
+	case cmd.Clear:
+		TraceVerbosef(VerbosityNotice, "  Removing the note of layer %v", cmd.Layer)
+		delete(annotations, cmd.Layer)
+	default:
+		TraceVerbosef(VerbosityNotice, "  Layer %v: %v", cmd.Layer, cmd.Note)
+		annotations[cmd.Layer] = cmd.Note
+	}
+
+	output = &uv3dp.AnnotatedPrintable{
+		Printable:   input,
+		Annotations: annotations,
+	}
+
+	return
+}
@@ -0,0 +1,99 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestAnnotate(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 4, LayerHeight: 0.05},
+	}
+
+	var output uv3dp.Printable = &uv3dp.Print{Properties: prop}
+
+	table := []struct {
+		Args        []string
+		Annotations uv3dp.Annotations
+		Valid       bool
+	}{
+		{Args: []string{"-l", "2", "-n", "Support failure seen here"}, Annotations: uv3dp.Annotations{2: "Support failure seen here"}, Valid: true},
+		{Args: []string{"-l", "0", "-n", "Raft lifted"}, Annotations: uv3dp.Annotations{0: "Raft lifted", 2: "Support failure seen here"}, Valid: true},
+		{Args: []string{"-l", "2", "-c"}, Annotations: uv3dp.Annotations{0: "Raft lifted"}, Valid: true},
+		{Args: []string{"-c"}, Annotations: uv3dp.Annotations{}, Valid: true},
+		{Args: []string{"-l", "4", "-n", "Past the top"}},
+		{Args: []string{"-l", "1"}},
+	}
+
+	for n, item := range table {
+		cmd := NewAnnotateCommand()
+		err := cmd.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("%v: %v", n, err)
+		}
+
+		var result uv3dp.Printable
+		result, err = cmd.Filter(output)
+		if (err == nil) != item.Valid {
+			t.Errorf("%v: expected valid %v, got %v", n, item.Valid, err)
+			continue
+		}
+
+		if !item.Valid {
+			continue
+		}
+
+		output = result
+		annotations := uv3dp.LayerAnnotations(output)
+		if !cmp.Equal(item.Annotations, annotations) {
+			t.Errorf("%v: expected %v, got %v", n, item.Annotations, annotations)
+		}
+	}
+}
+
+func TestAnnotateLayerFilters(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 4, LayerHeight: 0.05},
+	}
+
+	input := &uv3dp.AnnotatedPrintable{
+		Printable:   uv3dp.NewEmptyPrintable(prop),
+		Annotations: uv3dp.Annotations{1: "Stringing", 3: "Support failure seen here"},
+	}
+
+	table := []struct {
+		Command     Commander
+		Args        []string
+		Annotations uv3dp.Annotations
+	}{
+		{NewRaftCommand(), []string{"--layers", "3"}, uv3dp.Annotations{4: "Stringing", 6: "Support failure seen here"}},
+		{NewSelectCommand(), []string{"--first", "2"}, uv3dp.Annotations{1: "Support failure seen here"}},
+		{NewResumeCommand(), []string{"--layer", "1"}, uv3dp.Annotations{0: "Stringing", 2: "Support failure seen here"}},
+		{NewResliceCommand(), []string{"--layer-height", "0.1"}, uv3dp.Annotations{0: "Stringing", 1: "Support failure seen here"}},
+		{NewDecimateCommand(), []string{}, uv3dp.Annotations{1: "Stringing", 3: "Support failure seen here"}},
+	}
+
+	for _, item := range table {
+		err := item.Command.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", item.Args, err)
+		}
+
+		output, err := item.Command.Filter(input)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", item.Args, err)
+		}
+
+		annotations := uv3dp.LayerAnnotations(output)
+		if !cmp.Equal(item.Annotations, annotations) {
+			t.Errorf("%v: expected %v, got %v", item.Args, item.Annotations, annotations)
+		}
+	}
+}
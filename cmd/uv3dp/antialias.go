@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type AntialiasCommand struct {
+	*pflag.FlagSet
+
+	Method string
+	Sigma  float32
+}
+
+func NewAntialiasCommand() (cmd *AntialiasCommand) {
+	flagSet := pflag.NewFlagSet("antialias", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &AntialiasCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Method, "method", "m", "edge", "Method: 'gaussian' (blur the whole layer) or 'edge' (blur only the edges of islands)")
+	cmd.Float32VarP(&cmd.Sigma, "sigma", "s", 1.0, "Gaussian blur standard deviation, in pixels")
+
+	return
+}
+
+// antialiasModifier smooths the edges of every layer image
+type antialiasModifier struct {
+	uv3dp.Printable
+
+	kernel    []float32
+	edgesOnly bool
+}
+
+func (am *antialiasModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := am.Printable.LayerImage(index)
+	bounds := srcImage.Bounds()
+	dx, dy := bounds.Dx(), bounds.Dy()
+
+	light := make([]float32, dx*dy)
+	for y := 0; y < dy; y++ {
+		for x := 0; x < dx; x++ {
+			light[y*dx+x] = float32(srcImage.Pix[y*srcImage.Stride+x])
+		}
+	}
+
+	radius := len(am.kernel) / 2
+
+	// Only blur the pixels within the radius of an island's edge, and
+	// the pixels that they are blurred from
+	var edge, near []uint8
+	if am.edgesOnly {
+		edgeImage := edgeMask(srcImage, radius)
+		edge = edgeImage.Pix
+		near = uv3dp.MorphImage(edgeImage, uv3dp.MorphDilate, radius).Pix
+	}
+
+	light = convolve(light, dx, dy, am.kernel, true, near)
+	light = convolve(light, dx, dy, am.kernel, false, edge)
+
+	grayImage = image.NewGray(bounds)
+	for y := 0; y < dy; y++ {
+		for x := 0; x < dx; x++ {
+			pix := srcImage.Pix[y*srcImage.Stride+x]
+			if !am.edgesOnly || edge[y*dx+x] != 0 {
+				pix = uint8(math.Min(math.Round(float64(light[y*dx+x])), 255))
+			}
+			grayImage.Pix[y*grayImage.Stride+x] = pix
+		}
+	}
+
+	return
+}
+
+// edgeMask returns the pixels that are within the radius of an island's
+// edge, as the difference of the dilated and eroded islands
+func edgeMask(img *image.Gray, radius int) (mask *image.Gray) {
+	bounds := img.Bounds()
+	dx, dy := bounds.Dx(), bounds.Dy()
+
+	solid := image.NewGray(image.Rect(0, 0, dx, dy))
+	for y := 0; y < dy; y++ {
+		for x := 0; x < dx; x++ {
+			if img.Pix[y*img.Stride+x] > 127 {
+				solid.Pix[y*solid.Stride+x] = 0xff
+			}
+		}
+	}
+
+	mask = uv3dp.MorphImage(solid, uv3dp.MorphDilate, radius)
+	eroded := uv3dp.MorphImage(solid, uv3dp.MorphErode, radius)
+	for n, pix := range eroded.Pix {
+		mask.Pix[n] -= pix
+	}
+
+	return
+}
+
+func (cmd *AntialiasCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Method != "gaussian" && cmd.Method != "edge" {
+		err = fmt.Errorf("antialias: unknown --method '%v'", cmd.Method)
+		return
+	}
+
+	if cmd.Sigma <= 0 {
+		err = fmt.Errorf("antialias: --sigma must be greater than zero")
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Smoothing layers by %v, with a sigma of %v pixels", cmd.Method, cmd.Sigma)
+
+	output = &antialiasModifier{
+		Printable: input,
+		kernel:    gaussianKernel(float64(cmd.Sigma)),
+		edgesOnly: cmd.Method == "edge",
+	}
+
+	return
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestAntialias(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 40, Y: 40, Layers: 1, LayerHeight: 0.05},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	for _, method := range []string{"gaussian", "edge"} {
+		cmd := NewAntialiasCommand()
+		err := cmd.Parse([]string{"--method", method, "--sigma", "1"})
+		if err != nil {
+			t.Fatalf("%v: %v", method, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Fatalf("%v: %v", method, err)
+		}
+
+		img := output.LayerImage(0)
+
+		table := []struct {
+			X, Y     int
+			Min, Max uint8
+		}{
+			{X: 20, Y: 20, Min: 0xff, Max: 0xff}, // Inside
+			{X: 2, Y: 2, Min: 0, Max: 0},         // Outside
+			{X: 10, Y: 20, Min: 0x80, Max: 0xfe}, // Inside edge
+			{X: 9, Y: 20, Min: 0x01, Max: 0x7f},  // Outside edge
+		}
+
+		for _, item := range table {
+			pix := img.GrayAt(item.X, item.Y).Y
+			if pix < item.Min || pix > item.Max {
+				t.Errorf("%v: (%v,%v): expected %#x..%#x, got %#x", method, item.X, item.Y, item.Min, item.Max, pix)
+			}
+		}
+	}
+
+	cmd := NewAntialiasCommand()
+	cmd.Parse([]string{"--method", "sharpen"})
+	_, err := cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for an unknown method")
+	}
+}
+
+func TestEdgeMask(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 40, Y: 40, Layers: 1, LayerHeight: 0.05},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	mask := edgeMask(input.LayerImage(0), 3)
+
+	table := []struct {
+		X, Y int
+		Pix  uint8
+	}{
+		{X: 20, Y: 20, Pix: 0x00}, // Inside
+		{X: 13, Y: 20, Pix: 0x00}, // Inside, beyond the radius
+		{X: 12, Y: 20, Pix: 0xff}, // Inside, within the radius
+		{X: 10, Y: 20, Pix: 0xff}, // Inside edge
+		{X: 7, Y: 20, Pix: 0xff},  // Outside, within the radius
+		{X: 6, Y: 20, Pix: 0x00},  // Outside, beyond the radius
+		{X: 7, Y: 7, Pix: 0xff},   // Outside the corner
+		{X: 6, Y: 7, Pix: 0x00},   // Outside the corner, beyond the radius
+	}
+
+	for _, item := range table {
+		pix := mask.GrayAt(item.X, item.Y).Y
+		if pix != item.Pix {
+			t.Errorf("(%v,%v): expected %#x, got %#x", item.X, item.Y, item.Pix, pix)
+		}
+	}
+}
@@ -7,6 +7,7 @@ package main
 import (
 	"fmt"
 	"math"
+	"strings"
 
 	"image"
 	"image/color"
@@ -24,6 +25,15 @@ type BedCommand struct {
 	Millimeters []float32
 	Machine     string
 	Reflect     bool
+
+	ExposureScale float32
+	From          string
+
+	Center bool
+	Fit    bool
+	Anchor string
+
+	SquarePixels bool
 }
 
 func NewBedCommand() (bc *BedCommand) {
@@ -34,22 +44,136 @@ func NewBedCommand() (bc *BedCommand) {
 	bc.IntSliceVarP(&bc.Pixels, "pixels", "p", []int{1440, 2560}, "Bed size, in pixels")
 	bc.Float32SliceVarP(&bc.Millimeters, "millimeters", "m", []float32{68.04, 120.96}, "Bed size, in millimeters")
 
-	bc.StringVarP(&bc.Machine, "machine", "M", "EPAX-X1", "Size preset by machine type")
+	bc.StringVarP(&bc.Machine, "machine", "M", "EPAX-X1", "Size preset by machine type, or machine@variant")
 	bc.BoolVarP(&bc.Reflect, "reflect", "r", false, "Mirror image along the X axis")
+	bc.Float32VarP(&bc.ExposureScale, "exposure-scale", "e", 1.0, "Scale the exposure times by this factor")
+	bc.StringVarP(&bc.From, "from", "f", "", "Machine the input was sliced for, to scale the exposure times by its light power relative to the --machine's")
+	bc.BoolVarP(&bc.Center, "center", "c", false, "Center the model on the new bed, instead of anchoring the old bed")
+	bc.BoolVarP(&bc.Fit, "fit", "", false, "Scale the model down to fit the new bed, if it is too large")
+	bc.StringVarP(&bc.Anchor, "anchor", "a", "center", "Where the old bed is placed on the new bed: center, left, right, top, bottom, top-left, top-right, bottom-left or bottom-right")
+	bc.BoolVarP(&bc.SquarePixels, "square-pixels", "", false, "Scale the old bed by the same factor in X and Y, as if its pixels were square, instead of preserving its size in millimeters")
 	bc.SetInterspersed(false)
 
 	return
 }
 
+// bedLandscape returns true if a bed is wider than it is long. Pixels may
+// not be square, so the size in millimeters is used if it is known.
+func bedLandscape(size uv3dp.Size) bool {
+	if size.Millimeter.X > 0 && size.Millimeter.Y > 0 {
+		return size.Millimeter.X > size.Millimeter.Y
+	}
+
+	return size.X > size.Y
+}
+
+// BedAnchor returns the offset of a rectangle of a size on a bed, at an anchor
+func BedAnchor(anchor string, size image.Point, bed image.Point) (offset image.Point, err error) {
+	valid := map[string]bool{
+		"center": true, "left": true, "right": true, "top": true, "bottom": true,
+		"top-left": true, "top-right": true, "bottom-left": true, "bottom-right": true,
+	}
+	if !valid[anchor] {
+		err = fmt.Errorf("'%v' is not an anchor", anchor)
+		return
+	}
+
+	offset = bed.Sub(size).Div(2)
+
+	switch {
+	case strings.Contains(anchor, "left"):
+		offset.X = 0
+	case strings.Contains(anchor, "right"):
+		offset.X = bed.X - size.X
+	}
+
+	switch {
+	case strings.Contains(anchor, "top"):
+		offset.Y = 0
+	case strings.Contains(anchor, "bottom"):
+		offset.Y = bed.Y - size.Y
+	}
+
+	return
+}
+
+// bedModelBounds returns the bounds of the model on the old bed, as it
+// is rotated and reflected
+func bedModelBounds(input uv3dp.Printable, srcSize uv3dp.Size, rotate bool, reflect bool) (bounds image.Rectangle) {
+	bounds = uv3dp.ModelBounds(input)
+	if bounds.Empty() {
+		return
+	}
+
+	if rotate {
+		bounds = image.Rect(bounds.Min.Y, bounds.Min.X, bounds.Max.Y, bounds.Max.X)
+	}
+
+	// Our trivial rotation also causes a reflection
+	if reflect != rotate {
+		bounds.Min.X, bounds.Max.X = srcSize.X-bounds.Max.X, srcSize.X-bounds.Min.X
+	}
+
+	return
+}
+
+// bedScaleBounds scales bounds on the old bed to the pixels of the old
+// bed, when it is scaled to a size in new pixels
+func bedScaleBounds(bounds image.Rectangle, srcSize uv3dp.Size, scaled image.Point) image.Rectangle {
+	scaleX := float64(scaled.X) / float64(srcSize.X)
+	scaleY := float64(scaled.Y) / float64(srcSize.Y)
+
+	return image.Rect(
+		int(math.Floor(float64(bounds.Min.X)*scaleX)),
+		int(math.Floor(float64(bounds.Min.Y)*scaleY)),
+		int(math.Ceil(float64(bounds.Max.X)*scaleX)),
+		int(math.Ceil(float64(bounds.Max.Y)*scaleY)),
+	)
+}
+
+// BedPowerRatio returns the exposure scale for the prints of a machine to
+// get the same light dose on another machine: the ratio of their powers
+func BedPowerRatio(from string, to string) (ratio float32, err error) {
+	power := [2]float32{}
+	for n, name := range []string{from, to} {
+		var machine uv3dp.MachineFormat
+		machine, err = uv3dp.LookupMachine(name)
+		if err != nil {
+			return
+		}
+		if machine.Power <= 0 {
+			err = fmt.Errorf("machine '%v' has no known light power", name)
+			return
+		}
+		power[n] = machine.Power
+	}
+
+	ratio = power[0] / power[1]
+
+	return
+}
+
+// bedOverlap returns how far a span is beyond the edges of a bed
+func bedOverlap(min int, max int, bed int) (over int) {
+	if min < 0 {
+		over -= min
+	}
+	if max > bed {
+		over += max - bed
+	}
+
+	return
+}
+
 func (bc *BedCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
 	srcSize := input.Size()
 	dstSize := srcSize
 	rotate := false
 
 	if bc.Changed("machine") {
-		machine, found := uv3dp.MachineFormats[bc.Machine]
-		if !found {
-			err = fmt.Errorf("machine '%s' is not a known machine type", bc.Machine)
+		var machine uv3dp.MachineFormat
+		machine, err = uv3dp.LookupMachine(bc.Machine)
+		if err != nil {
 			return
 		}
 		size := machine.Machine.Size
@@ -59,6 +183,26 @@ func (bc *BedCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err
 		dstSize.Millimeter.Y = size.Ymm
 	}
 
+	scale := bc.ExposureScale
+	if scale <= 0 {
+		err = fmt.Errorf("bed: --exposure-scale must be greater than 0")
+		return
+	}
+
+	if bc.Changed("from") {
+		if !bc.Changed("machine") {
+			err = fmt.Errorf("bed: --from needs a --machine to compare with")
+			return
+		}
+		var ratio float32
+		ratio, err = BedPowerRatio(bc.From, bc.Machine)
+		if err != nil {
+			err = fmt.Errorf("bed: %w", err)
+			return
+		}
+		scale *= ratio
+	}
+
 	if bc.Changed("pixels") {
 		dstSize.X = bc.Pixels[0]
 		dstSize.Y = bc.Pixels[1]
@@ -71,7 +215,7 @@ func (bc *BedCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err
 
 	// Determine if we need to rotate
 	origSize := srcSize
-	if (dstSize.X > dstSize.Y) != (srcSize.X > srcSize.Y) {
+	if bedLandscape(dstSize) != bedLandscape(srcSize) {
 		rotate = true
 		srcSize.X = origSize.Y
 		srcSize.Y = origSize.X
@@ -85,14 +229,90 @@ func (bc *BedCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err
 
 	// Compute desitination rectange
 
-	// First, get the size of the src bed, scaled to the size in dest pixels
-	dstRect := image.Rect(0, 0, int(math.Round(float64(srcSize.Millimeter.X/dstXPpm))), int(math.Round(float64(srcSize.Millimeter.Y/dstYPpm))))
+	// First, get the size of the src bed, scaled to the size in dest
+	// pixels. Pixels may not be square, so X and Y are scaled separately.
+	scaled := image.Pt(int(math.Round(float64(srcSize.Millimeter.X/dstXPpm))), int(math.Round(float64(srcSize.Millimeter.Y/dstYPpm))))
+	if bc.SquarePixels {
+		scale := float64(scaled.X) / float64(srcSize.X)
+		scaled.Y = int(math.Round(float64(srcSize.Y) * scale))
+	} else {
+		scaleX := float64(scaled.X) / float64(srcSize.X)
+		scaleY := float64(scaled.Y) / float64(srcSize.Y)
+		if math.Abs(scaleX-scaleY) > 0.001*scaleX {
+			TraceVerbosef(VerbosityNotice, "  Scaling by %.4g in X and %.4g in Y, to preserve the size in millimeters", scaleX, scaleY)
+		}
+	}
+	bed := image.Pt(dstSize.X, dstSize.Y)
+
+	if bc.Center && bc.Changed("anchor") {
+		err = fmt.Errorf("bed: only one of --center or --anchor can be used")
+		return
+	}
+
+	// The model only needs to be found if it is placed, or if the old
+	// bed does not fit on the new bed
+	var srcModel, model image.Rectangle
+	if bc.Center || bc.Fit || scaled.X > bed.X || scaled.Y > bed.Y {
+		srcModel = bedModelBounds(input, srcSize, rotate, bc.Reflect)
+		model = bedScaleBounds(srcModel, srcSize, scaled)
+	}
+
+	if bc.Fit && !model.Empty() {
+		fit := math.Min(float64(bed.X)/float64(model.Dx()), float64(bed.Y)/float64(model.Dy()))
+		if fit < 1.0 {
+			TraceVerbosef(VerbosityNotice, "  Scaling the model by %.3f to fit the bed", fit)
+			scaled = image.Pt(int(float64(scaled.X)*fit), int(float64(scaled.Y)*fit))
+			model = bedScaleBounds(srcModel, srcSize, scaled)
+
+			// Rounding may leave the model a pixel too large
+			for (model.Dx() > bed.X || model.Dy() > bed.Y) && scaled.X > 1 && scaled.Y > 1 {
+				scaled = scaled.Sub(image.Pt(1, 1))
+				model = bedScaleBounds(srcModel, srcSize, scaled)
+			}
+		}
+	}
 
-	// Center on bed
-	dstRect = dstRect.Add(image.Point{
-		X: (dstSize.X - dstRect.Max.X) / 2,
-		Y: (dstSize.Y - dstRect.Max.Y) / 2,
-	})
+	var offset image.Point
+	if bc.Center && !model.Empty() {
+		offset = CenterOffset(model, image.Rectangle{Max: bed})
+	} else {
+		offset, err = BedAnchor(bc.Anchor, scaled, bed)
+		if err != nil {
+			err = fmt.Errorf("bed: %w", err)
+			return
+		}
+	}
+
+	if !model.Empty() {
+		placed := model.Add(offset)
+
+		// Move the model onto the bed, if it fits
+		if bc.Fit {
+			if placed.Min.X < 0 {
+				offset.X -= placed.Min.X
+			} else if placed.Max.X > bed.X {
+				offset.X -= placed.Max.X - bed.X
+			}
+			if placed.Min.Y < 0 {
+				offset.Y -= placed.Min.Y
+			} else if placed.Max.Y > bed.Y {
+				offset.Y -= placed.Max.Y - bed.Y
+			}
+			placed = model.Add(offset)
+		}
+
+		if !placed.In(image.Rectangle{Max: bed}) {
+			overX := bedOverlap(placed.Min.X, placed.Max.X, bed.X)
+			overY := bedOverlap(placed.Min.Y, placed.Max.Y, bed.Y)
+			err = fmt.Errorf("bed: the model (%.2f x %.2f mm) does not fit on the %.2f x %.2f mm bed, and is over its edges by %.2f mm in X and %.2f mm in Y (use --fit, or --center)",
+				float32(model.Dx())*dstXPpm, float32(model.Dy())*dstYPpm,
+				dstSize.Millimeter.X, dstSize.Millimeter.Y,
+				float32(overX)*dstXPpm, float32(overY)*dstYPpm)
+			return
+		}
+	}
+
+	dstRect := image.Rectangle{Max: scaled}.Add(offset)
 
 	var action string
 	if rotate {
@@ -118,6 +338,14 @@ func (bc *BedCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err
 
 	output = bm
 
+	if scale != 1.0 {
+		TraceVerbosef(VerbosityNotice, "  Scaling exposures by %.3f", scale)
+		output = &compensateModifier{
+			Printable: output,
+			scale:     scale,
+		}
+	}
+
 	return
 }
 
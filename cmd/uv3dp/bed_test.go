@@ -0,0 +1,215 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	size := uv3dp.MachineSize{X: 20, Y: 40, Xmm: 10, Ymm: 20}
+	uv3dp.RegisterMachine("test-bed-dim", uv3dp.Machine{Size: size, Power: 2}, ".uvj")
+	uv3dp.RegisterMachine("test-bed-bright", uv3dp.Machine{Size: size, Power: 4}, ".uvj")
+	uv3dp.RegisterMachine("test-bed-unknown", uv3dp.Machine{Size: size}, ".uvj")
+}
+
+func TestBedExposureScale(t *testing.T) {
+	input := uv3dp.NewEmptyPrintable(uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 20, Y: 40, Layers: 4, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 10, Y: 20},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+		Bottom:   uv3dp.Bottom{Count: 1, Exposure: uv3dp.Exposure{LightOnTime: 40}},
+	})
+
+	table := map[string]struct {
+		args     []string
+		exposure float32
+		bottom   float32
+	}{
+		"none": {
+			args:     []string{"--machine", "test-bed-bright"},
+			exposure: 8,
+			bottom:   40,
+		},
+		"scale": {
+			args:     []string{"--machine", "test-bed-bright", "--exposure-scale", "1.5"},
+			exposure: 12,
+			bottom:   60,
+		},
+		"power": {
+			args:     []string{"--machine", "test-bed-bright", "--from", "test-bed-dim"},
+			exposure: 4,
+			bottom:   20,
+		},
+		"both": {
+			args:     []string{"--machine", "test-bed-dim", "--from", "test-bed-bright", "--exposure-scale", "0.5"},
+			exposure: 8,
+			bottom:   40,
+		},
+	}
+
+	for name, item := range table {
+		cmd := NewBedCommand()
+		err := cmd.Parse(item.args)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", name, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Errorf("%v: expected nil, got %v", name, err)
+			continue
+		}
+
+		if output.Exposure().LightOnTime != item.exposure || output.LayerExposure(2).LightOnTime != item.exposure {
+			t.Errorf("%v: expected exposure %v, got %v", name, item.exposure, output.Exposure().LightOnTime)
+		}
+
+		if output.Bottom().LightOnTime != item.bottom || output.LayerExposure(0).LightOnTime != item.bottom {
+			t.Errorf("%v: expected bottom exposure %v, got %v", name, item.bottom, output.Bottom().LightOnTime)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--machine", "test-bed-bright", "--from", "test-bed-unknown"},
+		{"--from", "test-bed-dim"},
+		{"--exposure-scale", "0"},
+	} {
+		cmd := NewBedCommand()
+		cmd.Parse(args)
+		_, err := cmd.Filter(input)
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+// bedPrint has a block of lit pixels on every layer
+type bedPrint struct {
+	uv3dp.Print
+	block image.Rectangle
+}
+
+func (bp *bedPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(bp.Properties.Bounds())
+	for y := bp.block.Min.Y; y < bp.block.Max.Y; y++ {
+		for x := bp.block.Min.X; x < bp.block.Max.X; x++ {
+			grayImage.Pix[y*grayImage.Stride+x] = 0xff
+		}
+	}
+
+	return
+}
+
+func TestBedPlacement(t *testing.T) {
+	// A 20x40 pixel, 10x20 mm bed, with a 4x6 mm model near its top left
+	input := &bedPrint{
+		Print: uv3dp.Print{Properties: uv3dp.Properties{
+			Size: uv3dp.Size{
+				X: 20, Y: 40, Layers: 2, LayerHeight: 0.05,
+				Millimeter: uv3dp.SizeMillimeter{X: 10, Y: 20},
+			},
+		}},
+		block: image.Rect(2, 4, 10, 16),
+	}
+
+	table := map[string]struct {
+		args   []string
+		bounds image.Rectangle
+	}{
+		"smaller bed, centered": {
+			args:   []string{"--pixels", "16,24", "--millimeters", "8,12", "--center"},
+			bounds: image.Rect(4, 6, 12, 18),
+		},
+		"larger bed, anchored": {
+			args:   []string{"--pixels", "30,50", "--millimeters", "15,25", "--anchor", "bottom-right"},
+			bounds: image.Rect(12, 14, 20, 26),
+		},
+		"larger bed, centered": {
+			args:   []string{"--pixels", "30,50", "--millimeters", "15,25", "--center"},
+			bounds: image.Rect(11, 19, 19, 31),
+		},
+		"rotated bed, centered": {
+			args:   []string{"--pixels", "40,20", "--millimeters", "20,10", "--center"},
+			bounds: image.Rect(14, 6, 26, 14),
+		},
+		"tall pixels": {
+			args:   []string{"--pixels", "40,40", "--millimeters", "20,40"},
+			bounds: image.Rect(12, 12, 20, 18),
+		},
+		"tall pixels, square": {
+			args:   []string{"--pixels", "40,40", "--millimeters", "20,40", "--square-pixels"},
+			bounds: image.Rect(12, 4, 20, 16),
+		},
+		"wide pixels, portrait bed": {
+			args:   []string{"--pixels", "40,30", "--millimeters", "10,30"},
+			bounds: image.Rect(4, 7, 20, 13),
+		},
+	}
+
+	for name, item := range table {
+		cmd := NewBedCommand()
+		err := cmd.Parse(item.args)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", name, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Errorf("%v: expected nil, got %v", name, err)
+			continue
+		}
+
+		bounds := uv3dp.ModelBounds(output)
+		if bounds != item.bounds {
+			t.Errorf("%v: expected the model at %v, got %v", name, item.bounds, bounds)
+		}
+	}
+
+	cmd := NewBedCommand()
+	cmd.Parse([]string{"--pixels", "12,24", "--millimeters", "6,12", "--anchor", "bottom-right"})
+	_, err := cmd.Filter(input)
+	if err == nil || !strings.Contains(err.Error(), "3.00 mm in X and 6.00 mm in Y") {
+		t.Errorf("expected an error with the overlap, got %v", err)
+	}
+
+	// A 3x4 mm bed is too small for the model, unless it is scaled down
+	cmd = NewBedCommand()
+	cmd.Parse([]string{"--pixels", "6,8", "--millimeters", "3,4"})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for a model that is too large")
+	}
+
+	cmd = NewBedCommand()
+	cmd.Parse([]string{"--pixels", "6,8", "--millimeters", "3,4", "--fit"})
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	bounds := uv3dp.ModelBounds(output)
+	if bounds.Empty() || !bounds.In(image.Rect(0, 0, 6, 8)) || bounds.Dy() < 7 {
+		t.Errorf("expected the model to be scaled to fit the bed, got %v", bounds)
+	}
+
+	for _, args := range [][]string{
+		{"--anchor", "middle"},
+		{"--anchor", "top", "--center"},
+	} {
+		cmd := NewBedCommand()
+		cmd.Parse(args)
+		_, err := cmd.Filter(input)
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -5,6 +5,9 @@
 package main
 
 import (
+	"fmt"
+	"math"
+
 	"github.com/nicarran/uv3dp"
 	"github.com/spf13/pflag"
 )
@@ -19,6 +22,19 @@ type BottomCommand struct {
 	LiftHeight   float32
 	LiftSpeed    float32
 	Count        int
+	Transition   int
+	Curve        string
+
+	WaitBeforeCure float32
+	WaitAfterCure  float32
+	WaitAfterLift  float32
+
+	LiftHeight2    float32
+	LiftSpeed2     float32
+	RetractHeight  float32
+	RetractSpeed   float32
+	RetractHeight2 float32
+	RetractSpeed2  float32
 }
 
 func NewBottomCommand() (cmd *BottomCommand) {
@@ -33,15 +49,56 @@ func NewBottomCommand() (cmd *BottomCommand) {
 	cmd.Uint8VarP(&cmd.LightPWM, "pwm", "p", 255, "Light PWM rate (0..255)")
 	cmd.Float32VarP(&cmd.LiftHeight, "lift-height", "h", 0.0, "Bottom layer lift height in mm")
 	cmd.Float32VarP(&cmd.LiftSpeed, "lift-speed", "s", 0.0, "Bottom layer lift speed in mm/min")
+	cmd.IntVarP(&cmd.Transition, "transition", "t", 0, "Number of 'fade' transition layers (default is the bottom layer count)")
+	cmd.StringVarP(&cmd.Curve, "curve", "C", "linear", "Exposure curve of the 'fade' transition layers - 'linear', 'sinusoidal', or 'exponential'")
+	cmd.Float32VarP(&cmd.WaitBeforeCure, "wait-before-cure", "w", 0.0, "Bottom layer rest time before the light is on, in seconds")
+	cmd.Float32VarP(&cmd.WaitAfterCure, "wait-after-cure", "W", 0.0, "Bottom layer rest time before the lift, in seconds")
+	cmd.Float32VarP(&cmd.WaitAfterLift, "wait-after-lift", "l", 0.0, "Bottom layer rest time after the lift, in seconds")
+	cmd.Float32VarP(&cmd.LiftHeight2, "lift-height2", "H", 0.0, "Bottom layer second stage (TSMC) lift height in mm")
+	cmd.Float32VarP(&cmd.LiftSpeed2, "lift-speed2", "S", 0.0, "Bottom layer second stage (TSMC) lift speed in mm/min")
+	cmd.Float32VarP(&cmd.RetractHeight, "retract-height", "r", 0.0, "Bottom layer retract height in mm")
+	cmd.Float32VarP(&cmd.RetractSpeed, "retract-speed", "R", 0.0, "Bottom layer retract speed in mm/min")
+	cmd.Float32VarP(&cmd.RetractHeight2, "retract-height2", "", 0.0, "Bottom layer last stage (TSMC) retract height in mm")
+	cmd.Float32VarP(&cmd.RetractSpeed2, "retract-speed2", "", 0.0, "Bottom layer last stage (TSMC) retract speed in mm/min")
 
 	cmd.SetInterspersed(false)
 
 	return
 }
 
+// BottomFadeTime returns the exposure time of a transition layer, at a
+// position (0.0 to 1.0) between the bottom and normal exposure times.
+//
+// The 'linear' curve steps evenly between the times, 'sinusoidal' eases
+// in and out of the bottom and normal times, and 'exponential' scales the
+// time by an equal ratio on each layer.
+func BottomFadeTime(curve string, position float32, bottom float32, normal float32) (lightOnTime float32) {
+	t := float64(position)
+	from, to := float64(bottom), float64(normal)
+
+	var value float64
+	switch curve {
+	case "sinusoidal":
+		value = from + (to-from)*(1-math.Cos(math.Pi*t))/2
+	case "exponential":
+		if from > 0 && to > 0 {
+			value = from * math.Pow(to/from, t)
+			break
+		}
+		fallthrough
+	default:
+		value = from + (to-from)*t
+	}
+
+	lightOnTime = float32(value)
+
+	return
+}
+
 type bottomModifier struct {
 	uv3dp.Printable
 	bottom uv3dp.Bottom
+	curve  string // Transition curve, if the transition is generated
 }
 
 func (mod *bottomModifier) Bottom() (bottom uv3dp.Bottom) {
@@ -53,13 +110,27 @@ func (mod *bottomModifier) Bottom() (bottom uv3dp.Bottom) {
 
 func (mod *bottomModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
 	bot := mod.bottom
+	inputBot := mod.Printable.Bottom()
 
-	if index < bot.Count {
+	wasBottom := index < inputBot.Count+inputBot.Transition
+	isTransition := index >= bot.Count && index < bot.Count+bot.Transition
+
+	switch {
+	case index < bot.Count:
 		exposure = bot.Exposure
-	} else {
+		return
+	case wasBottom && (mod.curve != "" || !isTransition):
+		// The bottom settings of the input no longer apply
+		exposure = mod.Printable.Exposure()
+	default:
 		exposure = mod.Printable.LayerExposure(index)
 	}
 
+	if isTransition && mod.curve != "" {
+		position := float32(index-bot.Count+1) / float32(bot.Transition+1)
+		exposure.LightOnTime = BottomFadeTime(mod.curve, position, bot.LightOnTime, mod.Printable.Exposure().LightOnTime)
+	}
+
 	return
 }
 
@@ -87,18 +158,95 @@ func (cmd *BottomCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable,
 	}
 
 	if cmd.Changed("lift-height") {
-		TraceVerbosef(VerbosityNotice, "  Setting default bottom lift height to %v", cmd.LightOnTime)
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom lift height to %v", cmd.LiftHeight)
 		bot.Exposure.LiftHeight = cmd.LiftHeight
 	}
 
 	if cmd.Changed("lift-speed") {
-		TraceVerbosef(VerbosityNotice, "  Setting default bottom lift speed to %v", cmd.LightOffTime)
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom lift speed to %v", cmd.LiftSpeed)
 		bot.Exposure.LiftSpeed = cmd.LiftSpeed
 	}
 
+	if cmd.Changed("lift-height2") {
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom second stage lift height to %v", cmd.LiftHeight2)
+		bot.Exposure.LiftHeight2 = cmd.LiftHeight2
+	}
+
+	if cmd.Changed("lift-speed2") {
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom second stage lift speed to %v", cmd.LiftSpeed2)
+		bot.Exposure.LiftSpeed2 = cmd.LiftSpeed2
+	}
+
+	if cmd.Changed("retract-height") {
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom retract height to %v", cmd.RetractHeight)
+		bot.Exposure.RetractHeight = cmd.RetractHeight
+	}
+
+	if cmd.Changed("retract-speed") {
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom retract speed to %v", cmd.RetractSpeed)
+		bot.Exposure.RetractSpeed = cmd.RetractSpeed
+	}
+
+	if cmd.Changed("retract-height2") {
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom last stage retract height to %v", cmd.RetractHeight2)
+		bot.Exposure.RetractHeight2 = cmd.RetractHeight2
+	}
+
+	if cmd.Changed("retract-speed2") {
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom last stage retract speed to %v", cmd.RetractSpeed2)
+		bot.Exposure.RetractSpeed2 = cmd.RetractSpeed2
+	}
+
+	if cmd.Changed("wait-before-cure") {
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom wait before cure to %v", cmd.WaitBeforeCure)
+		bot.Exposure.WaitBeforeCure = cmd.WaitBeforeCure
+	}
+
+	if cmd.Changed("wait-after-cure") {
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom wait after cure to %v", cmd.WaitAfterCure)
+		bot.Exposure.WaitAfterCure = cmd.WaitAfterCure
+	}
+
+	if cmd.Changed("wait-after-lift") {
+		TraceVerbosef(VerbosityNotice, "  Setting default bottom wait after lift to %v", cmd.WaitAfterLift)
+		bot.Exposure.WaitAfterLift = cmd.WaitAfterLift
+	}
+
+	curve := ""
+
+	switch cmd.Style {
+	case "slow":
+		if cmd.Changed("style") {
+			bot.Transition = 0
+		}
+	case "fade":
+		switch cmd.Curve {
+		case "linear", "sinusoidal", "exponential":
+		default:
+			err = fmt.Errorf("bottom: unknown curve '%v'", cmd.Curve)
+			return
+		}
+
+		bot.Transition = bot.Count
+		if cmd.Changed("transition") {
+			bot.Transition = cmd.Transition
+		}
+		if bot.Transition < 1 {
+			err = fmt.Errorf("bottom: 'fade' requires at least one transition layer")
+			return
+		}
+
+		TraceVerbosef(VerbosityNotice, "  Setting %v transition layers, with a %v curve", bot.Transition, cmd.Curve)
+		curve = cmd.Curve
+	default:
+		err = fmt.Errorf("bottom: unknown style '%v'", cmd.Style)
+		return
+	}
+
 	mod := &bottomModifier{
 		Printable: input,
 		bottom:    bot,
+		curve:     curve,
 	}
 
 	output = mod
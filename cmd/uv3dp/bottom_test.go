@@ -0,0 +1,106 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestBottomFade(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 10, Layers: 8, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 10, LightPWM: 255, LiftHeight: 5, LiftSpeed: 60},
+		Bottom:   uv3dp.Bottom{Count: 2, Exposure: uv3dp.Exposure{LightOnTime: 40, LightPWM: 255, LiftHeight: 5, LiftSpeed: 30}},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	table := map[string]struct {
+		Args     []string
+		Expected []float32
+	}{
+		"slow":        {Args: []string{}, Expected: []float32{40, 40, 10, 10, 10, 10}},
+		"linear":      {Args: []string{"--style", "fade", "--transition", "3"}, Expected: []float32{40, 40, 32.5, 25, 17.5, 10}},
+		"sinusoidal":  {Args: []string{"-y", "fade", "-t", "3", "-C", "sinusoidal"}, Expected: []float32{40, 40, 35.61, 25, 14.39, 10}},
+		"exponential": {Args: []string{"-y", "fade", "-t", "3", "-C", "exponential"}, Expected: []float32{40, 40, 28.28, 20, 14.14, 10}},
+		"count":       {Args: []string{"-y", "fade", "-c", "1"}, Expected: []float32{40, 25, 10, 10, 10, 10}},
+	}
+
+	for name, item := range table {
+		cmd := NewBottomCommand()
+		err := cmd.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+
+		for n, expected := range item.Expected {
+			exposure := output.LayerExposure(n)
+			if !convertEqual(float32(int(exposure.LightOnTime*100+0.5))/100, expected) {
+				t.Errorf("%v: layer %v: expected %v, got %v", name, n, expected, exposure.LightOnTime)
+			}
+			if exposure.LightOnTime < 40 && exposure.LiftSpeed != prop.Exposure.LiftSpeed {
+				t.Errorf("%v: layer %v: expected the normal lift speed, got %v", name, n, exposure.LiftSpeed)
+			}
+		}
+	}
+
+	for _, args := range [][]string{{"-y", "fast"}, {"-y", "fade", "-C", "cubic"}, {"-y", "fade", "-t", "0"}} {
+		cmd := NewBottomCommand()
+		err := cmd.Parse(args)
+		if err == nil {
+			_, err = cmd.Filter(input)
+		}
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestBottomExposure(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 10, Layers: 4, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 10, LightPWM: 255, LiftHeight: 5, LiftSpeed: 60, RetractHeight: 5, RetractSpeed: 150},
+		Bottom:   uv3dp.Bottom{Count: 2, Exposure: uv3dp.Exposure{LightOnTime: 40, LightPWM: 255, LiftHeight: 5, LiftSpeed: 30}},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	cmd := NewBottomCommand()
+	err := cmd.Parse([]string{"-r", "4", "-R", "90", "--retract-height2", "1", "--retract-speed2", "30", "-H", "3", "-S", "120", "-l", "2"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := uv3dp.Exposure{
+		LightOnTime: 40, LightPWM: 255, LiftHeight: 5, LiftSpeed: 30,
+		RetractHeight: 4, RetractSpeed: 90, RetractHeight2: 1, RetractSpeed2: 30,
+		LiftHeight2: 3, LiftSpeed2: 120, WaitAfterLift: 2,
+	}
+
+	for n := 0; n < prop.Size.Layers; n++ {
+		want := prop.Exposure
+		if n < prop.Bottom.Count {
+			want = expected
+		}
+
+		got := output.LayerExposure(n)
+		if got != want {
+			t.Errorf("layer %v: expected %+v, got %+v", n, want, got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type CenterCommand struct {
+	*pflag.FlagSet
+
+	Margins []float32
+	OnlyX   bool
+	OnlyY   bool
+}
+
+func NewCenterCommand() (cmd *CenterCommand) {
+	flagSet := pflag.NewFlagSet("center", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &CenterCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.Float32SliceVarP(&cmd.Margins, "margins", "m", []float32{0}, "Margins from the edges of the bed, in millimeters, as MARGIN or LEFT,TOP,RIGHT,BOTTOM")
+	cmd.BoolVarP(&cmd.OnlyX, "only-x", "x", false, "Only center the model in X")
+	cmd.BoolVarP(&cmd.OnlyY, "only-y", "y", false, "Only center the model in Y")
+
+	return
+}
+
+// CenterOffset returns the offset that centers a model's bounds within an area
+func CenterOffset(bounds image.Rectangle, area image.Rectangle) (offset image.Point) {
+	offset.X = area.Min.X + (area.Dx()-bounds.Dx())/2 - bounds.Min.X
+	offset.Y = area.Min.Y + (area.Dy()-bounds.Dy())/2 - bounds.Min.Y
+
+	return
+}
+
+func (cmd *CenterCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.OnlyX && cmd.OnlyY {
+		err = fmt.Errorf("center: only one of --only-x or --only-y can be used")
+		return
+	}
+
+	margins := cmd.Margins
+	switch len(margins) {
+	case 1:
+		margins = []float32{margins[0], margins[0], margins[0], margins[0]}
+	case 4:
+	default:
+		err = fmt.Errorf("center: --margins expects MARGIN or LEFT,TOP,RIGHT,BOTTOM")
+		return
+	}
+
+	size := input.Size()
+	toPixel := func(mm float32, pixels int, millimeters float32) int {
+		return int(math.Round(float64(mm * float32(pixels) / millimeters)))
+	}
+
+	area := image.Rect(
+		toPixel(margins[0], size.X, size.Millimeter.X),
+		toPixel(margins[1], size.Y, size.Millimeter.Y),
+		size.X-toPixel(margins[2], size.X, size.Millimeter.X),
+		size.Y-toPixel(margins[3], size.Y, size.Millimeter.Y),
+	)
+
+	output = input
+
+	bounds := uv3dp.ModelBounds(input)
+	if bounds.Empty() {
+		TraceVerbosef(VerbosityWarning, "center: no model to center")
+		return
+	}
+
+	offset := CenterOffset(bounds, area)
+	if cmd.OnlyX {
+		offset.Y = 0
+	}
+	if cmd.OnlyY {
+		offset.X = 0
+	}
+
+	moved := bounds.Add(offset)
+	if !moved.In(area) {
+		err = fmt.Errorf("center: model at %v does not fit within the margins of the %dx%d bed, at %v", bounds, size.X, size.Y, area)
+		return
+	}
+
+	if offset == (image.Point{}) {
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Centering model by %v pixels, from %v to %v", offset, bounds, moved)
+
+	output = &offsetModifier{
+		Printable: input,
+		offset:    offset,
+	}
+
+	return
+}
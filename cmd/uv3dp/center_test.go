@@ -0,0 +1,22 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCenter(t *testing.T) {
+	testBounds(t, func() Commander { return NewCenterCommand() }, image.Rect(10, 20, 30, 50), map[string]boundsTest{
+		"center":      {Args: []string{}, Bounds: image.Rect(40, 35, 60, 65), Valid: true},
+		"only-x":      {Args: []string{"-x"}, Bounds: image.Rect(40, 20, 60, 50), Valid: true},
+		"only-y":      {Args: []string{"-y"}, Bounds: image.Rect(10, 35, 30, 65), Valid: true},
+		"margins":     {Args: []string{"-m", "10,0,0,0"}, Bounds: image.Rect(50, 35, 70, 65), Valid: true},
+		"too-big":     {Args: []string{"-m", "20"}},
+		"bad-margins": {Args: []string{"-m", "1,2"}},
+		"both":        {Args: []string{"-x", "-y"}},
+	})
+}
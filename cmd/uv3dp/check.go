@@ -37,7 +37,35 @@ func (mod *checkModifier) LayerZ(index int) (z float32) {
 	return
 }
 
+// checkValidators returns the validators of output files, including
+// those of the limits of the --machine
+func checkValidators() (validators []uv3dp.Validator) {
+	validators = []uv3dp.Validator{uv3dp.ValidateResolution, uv3dp.ValidateExposure}
+
+	if param.Machine == "" {
+		return
+	}
+
+	machine, err := uv3dp.LookupMachine(param.Machine)
+	if err == nil && machine.Vat > 0 {
+		validators = append(validators, uv3dp.ValidateVat(machine.Vat))
+	}
+
+	return
+}
+
 func CheckFilter(input uv3dp.Printable) (mod uv3dp.Printable, err error) {
+	// Report all of the problems at once
+	report := uv3dp.Validate(input, checkValidators()...)
+	err = report.Err()
+	if err != nil {
+		return
+	}
+
+	for _, issue := range report.Issues {
+		TraceVerbosef(VerbosityWarning, "%v", issue)
+	}
+
 	mod = &checkModifier{
 		Printable: input,
 	}
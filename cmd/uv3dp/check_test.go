@@ -0,0 +1,63 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+	"github.com/nicarran/uv3dp/uvj"
+)
+
+func TestCheckFilterMetadata(t *testing.T) {
+	layerMetadata := uvj.LayerMetadata{
+		1: {"Support": true, "Z-Hop": float32(0.25)},
+	}
+
+	input := uv3dp.NewEmptyPrintable(uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 4, Y: 4, Layers: 3, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 2, Y: 2},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: 8, LightPWM: 255},
+		Metadata: map[string]interface{}{
+			uvj.LayerMetadataKey:      layerMetadata,
+			uv3dp.AnnotationsMetadata: uv3dp.Annotations{2: "Check the supports"},
+		},
+	})
+
+	// Every file saved from the command line is checked first
+	checked, err := CheckFilter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	formatter := uvj.NewUVJFormatter(".uvj")
+
+	buffer := &bytes.Buffer{}
+	err = formatter.Encode(buffer, checked)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	reader := bytes.NewReader(buffer.Bytes())
+	output, err := formatter.Decode(reader, reader.Size())
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	data, _ := output.Metadata(uvj.LayerMetadataKey)
+	got, _ := data.(uvj.LayerMetadata)
+	if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", layerMetadata) {
+		t.Errorf("expected layer metadata %v, got %v", layerMetadata, got)
+	}
+
+	annotations := uv3dp.LayerAnnotations(output)
+	if len(annotations) != 1 || annotations[2] != "Check the supports" {
+		t.Errorf("expected the annotations to be kept, got %v", annotations)
+	}
+}
@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type CompensateAgeCommand struct {
+	*pflag.FlagSet
+
+	Machine  string
+	Hours    float32
+	Curve    string
+	Filename string
+}
+
+func NewCompensateAgeCommand() (cmd *CompensateAgeCommand) {
+	flagSet := pflag.NewFlagSet("compensate-age", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &CompensateAgeCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Machine, "machine", "M", "", "Machine to compensate for (default is the --machine)")
+	cmd.Float32VarP(&cmd.Hours, "hours", "H", 0, "Light source hours (default is the machine's hours, from 'uv3dp age')")
+	cmd.StringVarP(&cmd.Curve, "curve", "c", "", "Compensation curve, as HOURS:SCALE,... (default is the machine's curve)")
+	cmd.StringVarP(&cmd.Filename, "file", "f", AgeConfigPath, "Light source hours file")
+
+	return
+}
+
+// compensateModifier scales the light on time of all exposures
+type compensateModifier struct {
+	uv3dp.Printable
+
+	scale float32
+}
+
+func (cm *compensateModifier) Exposure() (exposure uv3dp.Exposure) {
+	exposure = cm.Printable.Exposure()
+	exposure.LightOnTime *= cm.scale
+
+	return
+}
+
+func (cm *compensateModifier) Bottom() (bottom uv3dp.Bottom) {
+	bottom = cm.Printable.Bottom()
+	bottom.Exposure.LightOnTime *= cm.scale
+
+	return
+}
+
+func (cm *compensateModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	exposure = cm.Printable.LayerExposure(index)
+	exposure.LightOnTime *= cm.scale
+
+	return
+}
+
+func (cmd *CompensateAgeCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	name := cmd.Machine
+	if name == "" {
+		name = param.Machine
+	}
+
+	if name == "" {
+		err = fmt.Errorf("compensate-age: no machine selected (use --machine)")
+		return
+	}
+
+	ages, err := AgeLoad(cmd.Filename)
+	if err != nil {
+		err = fmt.Errorf("compensate-age: %w", err)
+		return
+	}
+
+	aging, err := AgeLookup(ages, name)
+	if err != nil {
+		err = fmt.Errorf("compensate-age: %w", err)
+		return
+	}
+
+	if cmd.Changed("hours") {
+		if cmd.Hours < 0 {
+			err = fmt.Errorf("compensate-age: --hours must not be negative")
+			return
+		}
+		aging.Hours = cmd.Hours
+	}
+
+	if cmd.Changed("curve") {
+		aging.Curve, err = AgeParseCurve(cmd.Curve)
+		if err != nil {
+			err = fmt.Errorf("compensate-age: %w", err)
+			return
+		}
+	}
+
+	scale := aging.Scale()
+
+	TraceVerbosef(VerbosityNotice, "  %v: %.1f light source hours, scaling exposures by %.3f", name, aging.Hours, scale)
+
+	output = &compensateModifier{
+		Printable: input,
+		scale:     scale,
+	}
+
+	return
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestCompensateAge(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 10, Layers: 4, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8, LightOffTime: 1},
+		Bottom: uv3dp.Bottom{
+			Count:    1,
+			Exposure: uv3dp.Exposure{LightOnTime: 40, LightOffTime: 1},
+		},
+	}
+
+	input := uv3dp.NewEmptyPrintable(prop)
+
+	// The test machine's curve scales by 1.5 at 1000 hours
+	cmd := NewCompensateAgeCommand()
+	err := cmd.Parse([]string{"--machine", "test-aging", "--hours", "1000",
+		"--file", filepath.Join("testdata", "missing.json")})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if output.Exposure().LightOnTime != 12 || output.Exposure().LightOffTime != 1 {
+		t.Errorf("expected 12s light on, 1s light off, got %+v", output.Exposure())
+	}
+
+	if output.Bottom().LightOnTime != 60 {
+		t.Errorf("expected 60s bottom light on, got %v", output.Bottom().LightOnTime)
+	}
+
+	if output.LayerExposure(0).LightOnTime != 60 || output.LayerExposure(3).LightOnTime != 12 {
+		t.Errorf("expected 60s and 12s layer exposures, got %v and %v",
+			output.LayerExposure(0).LightOnTime, output.LayerExposure(3).LightOnTime)
+	}
+
+	cmd = NewCompensateAgeCommand()
+	cmd.Parse([]string{"--machine", "test-aging", "--curve", "0:1,100:2", "--hours", "50",
+		"--file", filepath.Join("testdata", "missing.json")})
+	output, err = cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if output.Exposure().LightOnTime != 12 {
+		t.Errorf("expected 12s light on, got %v", output.Exposure().LightOnTime)
+	}
+
+	cmd = NewCompensateAgeCommand()
+	cmd.Parse([]string{"--machine", "test-unknown"})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for an unknown machine")
+	}
+}
@@ -0,0 +1,293 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Largest difference between values that is not considered a loss
+const convertTolerance = 0.001
+
+type ConvertCommand struct {
+	*pflag.FlagSet
+
+	To       string
+	Firmware string
+	Mirror   bool
+	Force    bool
+}
+
+func NewConvertCommand() (cmd *ConvertCommand) {
+	flagSet := pflag.NewFlagSet("convert", pflag.ContinueOnError)
+	flagSet.SetInterspersed(true)
+
+	cmd = &ConvertCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.To, "to", "", "", "Target machine [see 'Known machines' in help]")
+	cmd.StringVarP(&cmd.Firmware, "firmware", "", "", "Firmware version of the target machine, to work around its known quirks")
+	cmd.BoolVarP(&cmd.Mirror, "mirror", "m", false, "Mirror layers horizontally, for machines that project a mirrored image")
+	cmd.BoolVarP(&cmd.Force, "force", "f", false, "Overwrite an existing output file")
+
+	return
+}
+
+// Run executes the 'convert' sub-command
+func (cmd *ConvertCommand) Run() (err error) {
+	if cmd.NArg() < 1 || cmd.NArg() > 2 {
+		err = fmt.Errorf("convert: expected INFILE [OUTFILE], got %v", cmd.Args())
+		return
+	}
+
+	if cmd.To == "" {
+		err = fmt.Errorf("convert: a target machine must be selected with --to")
+		return
+	}
+
+	machine, err := uv3dp.LookupMachine(cmd.To)
+	if err != nil {
+		err = fmt.Errorf("convert: %w", err)
+		return
+	}
+
+	inName := cmd.Arg(0)
+	outName := "."
+	if cmd.NArg() > 1 {
+		outName = cmd.Arg(1)
+	}
+
+	outName, formatType, formatArgs, err := MachineOutput(cmd.To, outName, inName)
+	if err != nil {
+		return
+	}
+
+	// Guard rails
+	if sameFile(inName, outName) {
+		err = fmt.Errorf("convert: %v: output would overwrite the input", outName)
+		return
+	}
+
+	_, statErr := os.Stat(outName)
+	if statErr == nil && !cmd.Force {
+		err = fmt.Errorf("convert: %v: output exists (use --force to overwrite)", outName)
+		return
+	}
+
+	inFormat, err := uv3dp.NewFormat(inName, []string{})
+	if err != nil {
+		return
+	}
+
+	input, err := inFormat.Printable()
+	if err != nil {
+		return
+	}
+
+	// Adapt to the machine's bed
+	size := input.Size()
+	bed := machine.Machine.Size
+	if size.X != bed.X || size.Y != bed.Y ||
+		!convertEqual(size.Millimeter.X, bed.Xmm) || !convertEqual(size.Millimeter.Y, bed.Ymm) {
+		bc := NewBedCommand()
+		err = bc.Parse([]string{"--machine", cmd.To})
+		if err != nil {
+			return
+		}
+
+		input, err = bc.Filter(input)
+		if err != nil {
+			return
+		}
+	}
+
+	if cmd.Mirror {
+		input = &uv3dp.MirroredPrintable{Printable: input, Horizontal: true}
+	}
+
+	input, err = FirmwareFilter(input, cmd.To, cmd.Firmware)
+	if err != nil {
+		return
+	}
+
+	// Validate
+	input, err = CheckFilter(input)
+	if err != nil {
+		return
+	}
+
+	outFormat, err := uv3dp.NewFormatType(outName, formatType, formatArgs)
+	if err != nil {
+		return
+	}
+
+	if param.Progress {
+		uv3dp.SetProgress(&cliProgress{Format: outFormat})
+	}
+
+	err = outFormat.SetPrintable(input)
+	if err != nil {
+		return
+	}
+
+	fmt.Printf("%v: %v %v (%v)\n", outName, machine.Vendor, machine.Model, cmd.To)
+
+	// Report what the output format could not represent
+	output, err := outFormat.Printable()
+	if err != nil {
+		err = fmt.Errorf("convert: %v: can't verify output: %w", outName, err)
+		return
+	}
+
+	losses := ConvertLosses(input, output)
+	ConvertReport(os.Stdout, losses)
+
+	return
+}
+
+// sameFile returns true if both names refer to the same file
+func sameFile(a, b string) bool {
+	aInfo, aErr := os.Stat(a)
+	bInfo, bErr := os.Stat(b)
+	if aErr == nil && bErr == nil {
+		return os.SameFile(aInfo, bInfo)
+	}
+
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+func convertEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) <= convertTolerance
+}
+
+func exposureLosses(expected, got uv3dp.Exposure) (losses []string) {
+	fields := []struct {
+		Name          string
+		Expected, Got float32
+	}{
+		{"light on time", expected.LightOnTime, got.LightOnTime},
+		{"light off time", expected.LightOffTime, got.LightOffTime},
+		{"light PWM", float32(expected.LightPWM), float32(got.LightPWM)},
+		{"lift height", expected.LiftHeight, got.LiftHeight},
+		{"lift speed", expected.LiftSpeed, got.LiftSpeed},
+		{"retract height", expected.RetractHeight, got.RetractHeight},
+		{"retract speed", expected.RetractSpeed, got.RetractSpeed},
+		{"wait before cure", expected.WaitBeforeCure, got.WaitBeforeCure},
+		{"wait after cure", expected.WaitAfterCure, got.WaitAfterCure},
+		{"wait after lift", expected.WaitAfterLift, got.WaitAfterLift},
+		{"second lift height", expected.LiftHeight2, got.LiftHeight2},
+		{"second lift speed", expected.LiftSpeed2, got.LiftSpeed2},
+		{"second retract height", expected.RetractHeight2, got.RetractHeight2},
+		{"second retract speed", expected.RetractSpeed2, got.RetractSpeed2},
+	}
+
+	for _, field := range fields {
+		if !convertEqual(field.Expected, field.Got) {
+			losses = append(losses, fmt.Sprintf("%v %.4g became %.4g", field.Name, field.Expected, field.Got))
+		}
+	}
+
+	return
+}
+
+// ConvertLosses compares a printable with its encoded and decoded result,
+// and describes everything that was not preserved.
+func ConvertLosses(expected, got uv3dp.Printable) (losses []string) {
+	eSize := expected.Size()
+	gSize := got.Size()
+	if eSize.X != gSize.X || eSize.Y != gSize.Y || eSize.Layers != gSize.Layers ||
+		!convertEqual(eSize.Millimeter.X, gSize.Millimeter.X) ||
+		!convertEqual(eSize.Millimeter.Y, gSize.Millimeter.Y) ||
+		!convertEqual(eSize.LayerHeight, gSize.LayerHeight) {
+		losses = append(losses, fmt.Sprintf("size %+v became %+v", eSize, gSize))
+	}
+
+	for _, loss := range exposureLosses(expected.Exposure(), got.Exposure()) {
+		losses = append(losses, "exposure: "+loss)
+	}
+
+	eBottom := expected.Bottom()
+	gBottom := got.Bottom()
+	if eBottom.Count != gBottom.Count || eBottom.Transition != gBottom.Transition {
+		losses = append(losses, fmt.Sprintf("bottom: %v layers (%v transition) became %v layers (%v transition)",
+			eBottom.Count, eBottom.Transition, gBottom.Count, gBottom.Transition))
+	}
+
+	for _, loss := range exposureLosses(eBottom.Exposure, gBottom.Exposure) {
+		losses = append(losses, "bottom exposure: "+loss)
+	}
+
+	layers := expected.Size().Layers
+	if got.Size().Layers < layers {
+		layers = got.Size().Layers
+	}
+
+	zChanged := 0
+	exposureChanged := 0
+	for n := 0; n < layers; n++ {
+		if !convertEqual(expected.LayerZ(n), got.LayerZ(n)) {
+			zChanged++
+		}
+		if len(exposureLosses(expected.LayerExposure(n), got.LayerExposure(n))) > 0 {
+			exposureChanged++
+		}
+	}
+
+	if zChanged > 0 {
+		losses = append(losses, fmt.Sprintf("layer Z height changed on %v of %v layers", zChanged, layers))
+	}
+
+	if exposureChanged > 0 {
+		losses = append(losses, fmt.Sprintf("per-layer exposure changed on %v of %v layers", exposureChanged, layers))
+	}
+
+	previews := map[uv3dp.PreviewType]string{
+		uv3dp.PreviewTypeTiny: "tiny",
+		uv3dp.PreviewTypeHuge: "huge",
+	}
+
+	for _, code := range []uv3dp.PreviewType{uv3dp.PreviewTypeTiny, uv3dp.PreviewTypeHuge} {
+		_, eOk := expected.Preview(code)
+		_, gOk := got.Preview(code)
+		if eOk && !gOk {
+			losses = append(losses, fmt.Sprintf("%v preview was dropped", previews[code]))
+		}
+	}
+
+	keys := expected.MetadataKeys()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		_, ok := got.Metadata(key)
+		if !ok {
+			losses = append(losses, fmt.Sprintf("metadata '%v' was dropped", key))
+		}
+	}
+
+	return
+}
+
+// ConvertReport shows the losses of a conversion
+func ConvertReport(writer io.Writer, losses []string) {
+	if len(losses) == 0 {
+		fmt.Fprintln(writer, "Lossless: all properties were preserved")
+		return
+	}
+
+	fmt.Fprintln(writer, "Lossy:")
+	for _, loss := range losses {
+		fmt.Fprintf(writer, "  %v\n", loss)
+	}
+}
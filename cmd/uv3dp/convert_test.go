@@ -0,0 +1,53 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type convertPrint struct {
+	uv3dp.Print
+	z float32
+}
+
+func (cp *convertPrint) LayerZ(index int) float32 {
+	return cp.z
+}
+
+func TestConvertLosses(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 20, Layers: 1, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8, LightPWM: 255},
+		Metadata: map[string]interface{}{"Machine": "test"},
+	}
+
+	expected := &convertPrint{Print: uv3dp.Print{Properties: prop}, z: 0.05}
+
+	lossless := &convertPrint{Print: uv3dp.Print{Properties: prop}, z: 0.0505}
+	if losses := ConvertLosses(expected, lossless); len(losses) != 0 {
+		t.Errorf("expected no losses, got %v", losses)
+	}
+
+	prop.Exposure.LightPWM = 128
+	prop.Metadata = nil
+	lossy := &convertPrint{Print: uv3dp.Print{Properties: prop}, z: 0.1}
+
+	want := []string{
+		"exposure: light PWM 255 became 128",
+		"layer Z height changed on 1 of 1 layers",
+		"per-layer exposure changed on 1 of 1 layers",
+		"metadata 'Machine' was dropped",
+	}
+
+	losses := ConvertLosses(expected, lossy)
+	if !cmp.Equal(want, losses) {
+		t.Errorf("expected %v, got %v", want, losses)
+	}
+}
@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type CropCommand struct {
+	*pflag.FlagSet
+
+	Pixels      []int
+	Millimeters []float32
+}
+
+func NewCropCommand() (cmd *CropCommand) {
+	flagSet := pflag.NewFlagSet("crop", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &CropCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.IntSliceVarP(&cmd.Pixels, "pixels", "p", []int{}, "Crop rectangle as X,Y,WIDTH,HEIGHT, in pixels")
+	cmd.Float32SliceVarP(&cmd.Millimeters, "millimeters", "m", []float32{}, "Crop rectangle as X,Y,WIDTH,HEIGHT, in millimeters")
+
+	return
+}
+
+// cropModifier crops all layers to a rectangle
+type cropModifier struct {
+	uv3dp.Printable
+
+	size uv3dp.Size
+	rect image.Rectangle
+}
+
+func (cm *cropModifier) Size() (size uv3dp.Size) {
+	size = cm.size
+
+	return
+}
+
+func (cm *cropModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := cm.Printable.LayerImage(index)
+
+	grayImage = image.NewGray(image.Rect(0, 0, cm.rect.Dx(), cm.rect.Dy()))
+	draw.Draw(grayImage, grayImage.Bounds(), srcImage, cm.rect.Min, draw.Src)
+
+	return
+}
+
+func (cmd *CropCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	size := input.Size()
+	mmPerPixelX := size.Millimeter.X / float32(size.X)
+	mmPerPixelY := size.Millimeter.Y / float32(size.Y)
+
+	var rect image.Rectangle
+
+	switch {
+	case cmd.Changed("pixels") && cmd.Changed("millimeters"):
+		err = fmt.Errorf("crop: only one of --pixels or --millimeters can be used")
+		return
+	case cmd.Changed("pixels"):
+		if len(cmd.Pixels) != 4 {
+			err = fmt.Errorf("crop: --pixels expects X,Y,WIDTH,HEIGHT")
+			return
+		}
+		p := cmd.Pixels
+		rect = image.Rect(p[0], p[1], p[0]+p[2], p[1]+p[3])
+	case cmd.Changed("millimeters"):
+		if len(cmd.Millimeters) != 4 {
+			err = fmt.Errorf("crop: --millimeters expects X,Y,WIDTH,HEIGHT")
+			return
+		}
+		toPixel := func(mm float32, mmPerPixel float32) int {
+			return int(math.Round(float64(mm / mmPerPixel)))
+		}
+		m := cmd.Millimeters
+		x := toPixel(m[0], mmPerPixelX)
+		y := toPixel(m[1], mmPerPixelY)
+		rect = image.Rect(x, y, x+toPixel(m[2], mmPerPixelX), y+toPixel(m[3], mmPerPixelY))
+	default:
+		err = fmt.Errorf("crop: one of --pixels or --millimeters is required")
+		return
+	}
+
+	if rect.Empty() || !rect.In(image.Rect(0, 0, size.X, size.Y)) {
+		err = fmt.Errorf("crop: %v is not within the %dx%d bed", rect, size.X, size.Y)
+		return
+	}
+
+	newSize := size
+	newSize.X = rect.Dx()
+	newSize.Y = rect.Dy()
+	newSize.Millimeter.X = float32(rect.Dx()) * mmPerPixelX
+	newSize.Millimeter.Y = float32(rect.Dy()) * mmPerPixelY
+
+	TraceVerbosef(VerbosityNotice, "  Cropping to %v (%.4g x %.4g mm)", rect, newSize.Millimeter.X, newSize.Millimeter.Y)
+
+	output = &cropModifier{
+		Printable: input,
+		size:      newSize,
+		rect:      rect,
+	}
+
+	return
+}
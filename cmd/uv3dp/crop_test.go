@@ -0,0 +1,22 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestCrop(t *testing.T) {
+	testBounds(t, func() Commander { return NewCropCommand() }, image.Rect(40, 30, 60, 50), map[string]boundsTest{
+		"pixels":      {Args: []string{"-p", "30,20,40,20"}, Size: uv3dp.SizeMillimeter{X: 20, Y: 10}, Bounds: image.Rect(10, 10, 30, 20), Valid: true},
+		"millimeters": {Args: []string{"-m", "10,10,25,25"}, Size: uv3dp.SizeMillimeter{X: 25, Y: 25}, Bounds: image.Rect(20, 10, 40, 30), Valid: true},
+		"off-bed":     {Args: []string{"-p", "90,90,20,20"}},
+		"both":        {Args: []string{"-p", "0,0,10,10", "-m", "0,0,5,5"}},
+		"none":        {Args: []string{}},
+	})
+}
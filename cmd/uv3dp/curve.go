@@ -0,0 +1,168 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type CurveCommand struct {
+	*pflag.FlagSet
+
+	Gamma  float32
+	Points []string
+}
+
+func NewCurveCommand() (cmd *CurveCommand) {
+	flagSet := pflag.NewFlagSet("curve", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &CurveCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.Float32VarP(&cmd.Gamma, "gamma", "g", 1.0, "Gamma of the curve (less than 1 brightens, more than 1 darkens)")
+	cmd.StringSliceVarP(&cmd.Points, "points", "P", []string{}, "Control points of the curve, as IN:OUT gray levels (ie '0:0,128:96,255:255')")
+
+	return
+}
+
+// curveModifier remaps the gray levels of every layer image
+type curveModifier struct {
+	uv3dp.Printable
+
+	table [256]uint8
+}
+
+func (cm *curveModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := cm.Printable.LayerImage(index)
+	bounds := srcImage.Bounds()
+
+	grayImage = image.NewGray(bounds)
+
+	dx := bounds.Dx()
+	for y := 0; y < bounds.Dy(); y++ {
+		src := srcImage.Pix[y*srcImage.Stride : y*srcImage.Stride+dx]
+		dst := grayImage.Pix[y*grayImage.Stride : y*grayImage.Stride+dx]
+		for x, pix := range src {
+			dst[x] = cm.table[pix]
+		}
+	}
+
+	return
+}
+
+// CurveGamma returns the lookup table of a gamma curve
+func CurveGamma(gamma float64) (table [256]uint8) {
+	for n := range table {
+		table[n] = uint8(math.Round(math.Pow(float64(n)/255.0, gamma) * 255.0))
+	}
+
+	return
+}
+
+// CurvePoints returns the lookup table of a curve through the control
+// points, interpolated linearly. Levels before the first point, or after
+// the last point, have the output level of that point.
+func CurvePoints(points []image.Point) (table [256]uint8) {
+	points = append([]image.Point{}, points...)
+	sort.Slice(points, func(i, j int) bool { return points[i].X < points[j].X })
+
+	for n := range table {
+		next := sort.Search(len(points), func(i int) bool { return points[i].X >= n })
+
+		var level float64
+		switch {
+		case next == 0:
+			level = float64(points[0].Y)
+		case next == len(points):
+			level = float64(points[len(points)-1].Y)
+		default:
+			a := points[next-1]
+			b := points[next]
+			level = float64(a.Y) + float64(b.Y-a.Y)*float64(n-a.X)/float64(b.X-a.X)
+		}
+
+		table[n] = uint8(math.Round(level))
+	}
+
+	return
+}
+
+// curveParsePoints parses IN:OUT control points
+func curveParsePoints(args []string) (points []image.Point, err error) {
+	for _, arg := range args {
+		parts := strings.Split(arg, ":")
+		if len(parts) != 2 {
+			err = fmt.Errorf("point '%v' is not IN:OUT", arg)
+			return
+		}
+
+		var point [2]int
+		for n, part := range parts {
+			point[n], err = strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || point[n] < 0 || point[n] > 255 {
+				err = fmt.Errorf("point '%v' levels must be in the range 0..255", arg)
+				return
+			}
+		}
+
+		points = append(points, image.Point{X: point[0], Y: point[1]})
+	}
+
+	return
+}
+
+func (cmd *CurveCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	output = input
+
+	if cmd.Changed("gamma") == cmd.Changed("points") {
+		err = fmt.Errorf("curve: exactly one of --gamma or --points must be specified")
+		return
+	}
+
+	var table [256]uint8
+
+	if cmd.Changed("gamma") {
+		if cmd.Gamma <= 0 {
+			err = fmt.Errorf("curve: --gamma must be greater than zero")
+			return
+		}
+
+		TraceVerbosef(VerbosityNotice, "  Applying a gamma of %v", cmd.Gamma)
+		table = CurveGamma(float64(cmd.Gamma))
+	} else {
+		var points []image.Point
+		points, err = curveParsePoints(cmd.Points)
+		if err != nil {
+			err = fmt.Errorf("curve: %w", err)
+			return
+		}
+
+		if len(points) == 0 {
+			err = fmt.Errorf("curve: --points needs at least one point")
+			return
+		}
+
+		TraceVerbosef(VerbosityNotice, "  Applying a curve through %v", points)
+		table = CurvePoints(points)
+	}
+
+	output = &curveModifier{
+		Printable: input,
+		table:     table,
+	}
+
+	return
+}
@@ -0,0 +1,44 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCurve(t *testing.T) {
+	gamma := CurveGamma(2.0)
+	for _, item := range []struct{ In, Out uint8 }{{0, 0}, {128, 64}, {255, 255}} {
+		if gamma[item.In] != item.Out {
+			t.Errorf("gamma: %v: expected %v, got %v", item.In, item.Out, gamma[item.In])
+		}
+	}
+
+	points, err := curveParsePoints([]string{"255:200", "64:0", "128:100"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	table := CurvePoints(points)
+	for _, item := range []struct{ In, Out uint8 }{{0, 0}, {64, 0}, {96, 50}, {128, 100}, {255, 200}} {
+		if table[item.In] != item.Out {
+			t.Errorf("points: %v: expected %v, got %v", item.In, item.Out, table[item.In])
+		}
+	}
+
+	for _, arg := range []string{"1", "1:2:3", "0:256", "a:0"} {
+		_, err = curveParsePoints([]string{arg})
+		if err == nil {
+			t.Errorf("%v: expected an error", arg)
+		}
+	}
+
+	// Control points at the same level
+	table = CurvePoints([]image.Point{{X: 100, Y: 0}, {X: 100, Y: 255}})
+	if table[99] != 0 || table[101] != 255 {
+		t.Errorf("expected a step at 100, got %v, %v", table[99], table[101])
+	}
+}
@@ -0,0 +1,239 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type DeadPixelCommand struct {
+	*pflag.FlagSet
+
+	Pixels []string
+	File   string
+	Mode   string
+}
+
+func NewDeadPixelCommand() (cmd *DeadPixelCommand) {
+	flagSet := pflag.NewFlagSet("deadpixel", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &DeadPixelCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringArrayVarP(&cmd.Pixels, "pixel", "x", []string{}, "Dead pixel at X,Y (may be repeated)")
+	cmd.StringVarP(&cmd.File, "file", "f", "", "CSV file of dead pixels, one X,Y per line")
+	cmd.StringVarP(&cmd.Mode, "mode", "m", "remap", "Mode: 'remap' (move the light of dead pixels to their neighbors) or 'warn' (only report layers using them)")
+
+	return
+}
+
+// deadPixelParse parses a pixel coordinate from its X and Y fields
+func deadPixelParse(fields []string) (pixel image.Point, err error) {
+	if len(fields) != 2 {
+		err = fmt.Errorf("expected X,Y, got '%v'", strings.Join(fields, ","))
+		return
+	}
+
+	pixel.X, err = strconv.Atoi(strings.TrimSpace(fields[0]))
+	if err != nil {
+		return
+	}
+
+	pixel.Y, err = strconv.Atoi(strings.TrimSpace(fields[1]))
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// DeadPixelLoad reads dead pixel coordinates from a CSV file, with one
+// X,Y pair per line. An optional 'X,Y' header and '#' comments are allowed.
+func DeadPixelLoad(reader io.Reader) (pixels []image.Point, err error) {
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+
+		if line == 1 && strings.EqualFold(strings.ReplaceAll(text, " ", ""), "X,Y") {
+			continue
+		}
+
+		var pixel image.Point
+		pixel, err = deadPixelParse(strings.Split(text, ","))
+		if err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+
+		pixels = append(pixels, pixel)
+	}
+
+	err = scanner.Err()
+
+	return
+}
+
+// DeadPixelRemap moves the light of each dead pixel in a layer image to
+// its working neighbors, so that the cured area is kept as close as
+// possible to the original.
+func DeadPixelRemap(srcImage *image.Gray, dead map[image.Point]bool) (grayImage *image.Gray) {
+	grayImage = image.NewGray(srcImage.Bounds())
+	copy(grayImage.Pix, srcImage.Pix)
+
+	bounds := grayImage.Bounds()
+	neighbors := []image.Point{{X: -1}, {X: 1}, {Y: -1}, {Y: 1}}
+
+	for pixel := range dead {
+		if !pixel.In(bounds) {
+			continue
+		}
+
+		offset := grayImage.PixOffset(pixel.X, pixel.Y)
+		light := int(srcImage.Pix[offset])
+		grayImage.Pix[offset] = 0
+
+		if light == 0 {
+			continue
+		}
+
+		targets := []int{}
+		for _, delta := range neighbors {
+			near := pixel.Add(delta)
+			if near.In(bounds) && !dead[near] {
+				targets = append(targets, grayImage.PixOffset(near.X, near.Y))
+			}
+		}
+
+		for n, target := range targets {
+			// Spread the light evenly, with any remainder on the first neighbors
+			share := light / len(targets)
+			if n < light%len(targets) {
+				share++
+			}
+
+			pix := int(grayImage.Pix[target]) + share
+			if pix > 255 {
+				pix = 255
+			}
+			grayImage.Pix[target] = uint8(pix)
+		}
+	}
+
+	return
+}
+
+// DeadPixelLayers returns the layers that light any of the dead pixels
+func DeadPixelLayers(input uv3dp.Printable, dead map[image.Point]bool) (layers []int) {
+	for n := 0; n < input.Size().Layers; n++ {
+		img := input.LayerImage(n)
+		for pixel := range dead {
+			if pixel.In(img.Bounds()) && img.GrayAt(pixel.X, pixel.Y).Y > 0 {
+				layers = append(layers, n)
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// deadPixelModifier remaps the dead pixels of every layer image
+type deadPixelModifier struct {
+	uv3dp.Printable
+
+	dead map[image.Point]bool
+}
+
+func (dm *deadPixelModifier) LayerImage(index int) *image.Gray {
+	return DeadPixelRemap(dm.Printable.LayerImage(index), dm.dead)
+}
+
+func (cmd *DeadPixelCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Mode != "remap" && cmd.Mode != "warn" {
+		err = fmt.Errorf("deadpixel: unknown --mode '%v'", cmd.Mode)
+		return
+	}
+
+	pixels := []image.Point{}
+
+	for _, text := range cmd.Pixels {
+		var pixel image.Point
+		pixel, err = deadPixelParse(strings.Split(text, ","))
+		if err != nil {
+			err = fmt.Errorf("deadpixel: --pixel %v: %w", text, err)
+			return
+		}
+		pixels = append(pixels, pixel)
+	}
+
+	if cmd.File != "" {
+		var reader *os.File
+		reader, err = os.Open(cmd.File)
+		if err != nil {
+			return
+		}
+		defer func() { reader.Close() }()
+
+		var loaded []image.Point
+		loaded, err = DeadPixelLoad(reader)
+		if err != nil {
+			err = fmt.Errorf("deadpixel: %v: %w", cmd.File, err)
+			return
+		}
+		pixels = append(pixels, loaded...)
+	}
+
+	if len(pixels) == 0 {
+		err = fmt.Errorf("deadpixel: no dead pixels given with --pixel or --file")
+		return
+	}
+
+	size := input.Size()
+	bed := image.Rect(0, 0, size.X, size.Y)
+
+	dead := map[image.Point]bool{}
+	for _, pixel := range pixels {
+		if !pixel.In(bed) {
+			err = fmt.Errorf("deadpixel: pixel %v,%v is outside of the %vx%v display", pixel.X, pixel.Y, size.X, size.Y)
+			return
+		}
+		dead[pixel] = true
+	}
+
+	layers := DeadPixelLayers(input, dead)
+	if len(layers) > 0 {
+		TraceVerbosef(VerbosityWarning, "deadpixel: %v of %v layers use dead pixels (first is layer %v, last is layer %v)",
+			len(layers), size.Layers, layers[0], layers[len(layers)-1])
+	}
+
+	if cmd.Mode == "warn" {
+		output = input
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Remapping %v dead pixels", len(dead))
+
+	output = &deadPixelModifier{
+		Printable: input,
+		dead:      dead,
+	}
+
+	return
+}
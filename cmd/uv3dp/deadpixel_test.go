@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestDeadPixelLoad(t *testing.T) {
+	pixels, err := DeadPixelLoad(strings.NewReader("X,Y\n# Corner\n0,0\n\n 12, 34\n"))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := []image.Point{{X: 0, Y: 0}, {X: 12, Y: 34}}
+	if !cmp.Equal(expected, pixels) {
+		t.Errorf("expected %v, got %v", expected, pixels)
+	}
+
+	_, err = DeadPixelLoad(strings.NewReader("1,2\n3\n"))
+	if err == nil {
+		t.Errorf("expected an error for a missing Y")
+	}
+}
+
+func TestDeadPixelRemap(t *testing.T) {
+	srcImage := image.NewGray(image.Rect(0, 0, 3, 3))
+	srcImage.Pix = []uint8{
+		0x00, 0x10, 0x00,
+		0xf0, 0xff, 0x00,
+		0x00, 0x00, 0x00,
+	}
+
+	dead := map[image.Point]bool{{X: 1, Y: 1}: true, {X: 1, Y: 2}: true}
+
+	grayImage := DeadPixelRemap(srcImage, dead)
+
+	expected := []uint8{
+		0x00, 0x65, 0x00,
+		0xff, 0x00, 0x55,
+		0x00, 0x00, 0x00,
+	}
+
+	if !cmp.Equal(expected, grayImage.Pix) {
+		t.Errorf("expected %#v, got %#v", expected, grayImage.Pix)
+	}
+}
+
+func TestDeadPixel(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 40, Y: 40, Layers: 2, LayerHeight: 0.05},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	dead := map[image.Point]bool{{X: 20, Y: 20}: true}
+	layers := DeadPixelLayers(input, dead)
+	if !cmp.Equal([]int{0, 1}, layers) {
+		t.Errorf("expected [0 1], got %v", layers)
+	}
+
+	cmd := NewDeadPixelCommand()
+	err := cmd.Parse([]string{"--pixel", "20,20", "--pixel", "5,5"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	img := output.LayerImage(0)
+	if img.GrayAt(20, 20).Y != 0 {
+		t.Errorf("expected dead pixel to be off, got %#x", img.GrayAt(20, 20).Y)
+	}
+
+	cmd = NewDeadPixelCommand()
+	cmd.Parse([]string{"--pixel", "40,0"})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for a pixel outside of the display")
+	}
+}
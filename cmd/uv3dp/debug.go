@@ -0,0 +1,77 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type DebugCommand struct {
+	*pflag.FlagSet
+}
+
+func NewDebugCommand() (cmd *DebugCommand) {
+	flagSet := pflag.NewFlagSet("debug", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &DebugCommand{
+		FlagSet: flagSet,
+	}
+
+	return
+}
+
+// Run executes the 'debug' sub-command
+func (cmd *DebugCommand) Run() (err error) {
+	if cmd.NArg() != 2 || cmd.Arg(0) != "dump" {
+		err = fmt.Errorf("debug: expected 'dump FILE', got %v", cmd.Args())
+		return
+	}
+
+	err = DebugDump(os.Stdout, cmd.Arg(1), param.Format)
+
+	return
+}
+
+// DebugDump writes an annotated hexdump of the header regions of a file
+func DebugDump(writer io.Writer, filename string, formatType string) (err error) {
+	format, err := uv3dp.NewFormatType(filename, formatType, nil)
+	if err != nil {
+		return
+	}
+
+	dumper, ok := format.Formatter.(uv3dp.DumpingFormatter)
+	if !ok {
+		err = fmt.Errorf("debug: %v: the '%v' file format can not be dumped", filename, format.Suffix)
+		return
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer func() { file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+
+	regions, err := dumper.DumpRegions(file, info.Size())
+	if err != nil {
+		err = fmt.Errorf("debug: %v: %w", filename, err)
+		return
+	}
+
+	err = uv3dp.DumpWrite(writer, file, info.Size(), regions)
+
+	return
+}
@@ -0,0 +1,169 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type DiffCommand struct {
+	*pflag.FlagSet
+
+	Input     string
+	Layers    bool
+	Threshold int
+}
+
+func NewDiffCommand() (cmd *DiffCommand) {
+	flagSet := pflag.NewFlagSet("diff", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &DiffCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Input, "input", "i", "", "Printable to compare with")
+	cmd.BoolVarP(&cmd.Layers, "layers", "l", false, "Show the differences of each layer")
+	cmd.IntVarP(&cmd.Threshold, "threshold", "t", 0, "Ignore pixels whose values differ by at most this (0..255)")
+
+	return
+}
+
+// DiffLayer is the differences of a layer of two printables
+type DiffLayer struct {
+	Layer   int
+	Pixels  int      // Number of pixels that differ
+	Changes []string // Z height and exposure differences
+}
+
+// DiffResult is the differences of two printables
+type DiffResult struct {
+	Properties []string    // Size, exposure, and preview differences
+	Layers     []DiffLayer // Layers that differ
+	Compared   int         // Number of layers compared
+}
+
+// Differ returns true if there are any differences
+func (result *DiffResult) Differ() bool {
+	return len(result.Properties) > 0 || len(result.Layers) > 0
+}
+
+// diffPixels counts the pixels of two images whose values differ by more
+// than a threshold
+func diffPixels(a, b []uint8, threshold int) (count int) {
+	for n, pix := range a {
+		delta := int(pix) - int(b[n])
+		if delta > threshold || -delta > threshold {
+			count++
+		}
+	}
+
+	return
+}
+
+// DiffPrintables compares two printables, and each of the layers they both
+// have. Layer images are only compared if both have the same resolution.
+func DiffPrintables(a, b uv3dp.Printable, threshold int) (result DiffResult) {
+	result.Properties = ConvertLosses(a, b)
+
+	aSize, bSize := a.Size(), b.Size()
+	result.Compared = aSize.Layers
+	if bSize.Layers < result.Compared {
+		result.Compared = bSize.Layers
+	}
+
+	sameResolution := aSize.X == bSize.X && aSize.Y == bSize.Y
+
+	layers := make([]DiffLayer, result.Compared)
+	uv3dp.WithAllLayers(a, func(p uv3dp.Printable, n int) {
+		if n >= result.Compared {
+			return
+		}
+
+		item := DiffLayer{Layer: n}
+
+		if !convertEqual(p.LayerZ(n), b.LayerZ(n)) {
+			item.Changes = append(item.Changes, fmt.Sprintf("Z %.4g became %.4g", p.LayerZ(n), b.LayerZ(n)))
+		}
+		item.Changes = append(item.Changes, exposureLosses(p.LayerExposure(n), b.LayerExposure(n))...)
+
+		if sameResolution {
+			item.Pixels = diffPixels(p.LayerImage(n).Pix, b.LayerImage(n).Pix, threshold)
+		}
+
+		layers[n] = item
+	})
+
+	for _, item := range layers {
+		if item.Pixels > 0 || len(item.Changes) > 0 {
+			result.Layers = append(result.Layers, item)
+		}
+	}
+
+	return
+}
+
+// DiffReport shows the differences of two printables, and optionally the
+// differences of each layer
+func DiffReport(writer io.Writer, result DiffResult, detail bool) {
+	for _, change := range result.Properties {
+		fmt.Fprintf(writer, "Changed: %v\n", change)
+	}
+
+	pixels := 0
+	for _, item := range result.Layers {
+		pixels += item.Pixels
+		if detail {
+			changes := []string{fmt.Sprintf("%v pixels", item.Pixels)}
+			changes = append(changes, item.Changes...)
+			fmt.Fprintf(writer, "Layer %v: %v\n", item.Layer, strings.Join(changes, ", "))
+		}
+	}
+
+	fmt.Fprintf(writer, "Layers: %v of %v differ, %v pixels\n", len(result.Layers), result.Compared, pixels)
+}
+
+func (cmd *DiffCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Input == "" {
+		err = fmt.Errorf("diff: --input must be specified")
+		return
+	}
+
+	if cmd.Threshold < 0 || cmd.Threshold > 255 {
+		err = fmt.Errorf("diff: --threshold must be in the range 0..255")
+		return
+	}
+
+	format, err := uv3dp.NewFormat(cmd.Input, nil)
+	if err != nil {
+		return
+	}
+
+	other, err := format.Printable()
+	if err != nil {
+		return
+	}
+
+	result := DiffPrintables(input, other, cmd.Threshold)
+	DiffReport(os.Stdout, result, cmd.Layers)
+
+	if result.Differ() {
+		err = fmt.Errorf("diff: %v differs", cmd.Input)
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "  %v is the same", cmd.Input)
+
+	output = input
+
+	return
+}
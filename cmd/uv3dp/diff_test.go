@@ -0,0 +1,74 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestDiff(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 10, Y: 10, Layers: 3, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 5, Y: 5},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+		Bottom:   uv3dp.Bottom{Count: 1, Exposure: uv3dp.Exposure{LightOnTime: 60}},
+	}
+
+	input := &scalePrint{Print: uv3dp.Print{Properties: prop}, model: image.Rect(2, 2, 6, 6)}
+
+	longer := prop
+	longer.Size.Layers = 4
+
+	brighter := prop
+	brighter.Exposure.LightOnTime = 9
+
+	table := map[string]struct {
+		Other      uv3dp.Printable
+		Properties int
+		Layers     int
+		Pixels     int
+		Compared   int
+	}{
+		"same":     {Other: &scalePrint{Print: uv3dp.Print{Properties: prop}, model: image.Rect(2, 2, 6, 6)}, Compared: 3},
+		"pixels":   {Other: &scalePrint{Print: uv3dp.Print{Properties: prop}, model: image.Rect(2, 2, 7, 6)}, Layers: 3, Pixels: 4, Compared: 3},
+		"exposure": {Other: &scalePrint{Print: uv3dp.Print{Properties: brighter}, model: image.Rect(2, 2, 6, 6)}, Properties: 2, Layers: 2, Compared: 3},
+		"layers":   {Other: &scalePrint{Print: uv3dp.Print{Properties: longer}, model: image.Rect(2, 2, 6, 6)}, Properties: 1, Compared: 3},
+	}
+
+	for name, item := range table {
+		result := DiffPrintables(input, item.Other, 0)
+		if len(result.Properties) != item.Properties {
+			t.Errorf("%v: expected %v property changes, got %v", name, item.Properties, result.Properties)
+		}
+		if len(result.Layers) != item.Layers {
+			t.Errorf("%v: expected %v layers to differ, got %+v", name, item.Layers, result.Layers)
+		}
+		for _, layer := range result.Layers {
+			if layer.Pixels != item.Pixels {
+				t.Errorf("%v: layer %v: expected %v pixels to differ, got %v", name, layer.Layer, item.Pixels, layer.Pixels)
+			}
+		}
+		if result.Compared != item.Compared {
+			t.Errorf("%v: expected %v layers compared, got %v", name, item.Compared, result.Compared)
+		}
+		if result.Differ() != (name != "same") {
+			t.Errorf("%v: expected differ to be %v", name, name != "same")
+		}
+	}
+
+	result := DiffPrintables(input, table["pixels"].Other, 0)
+	buff := &bytes.Buffer{}
+	DiffReport(buff, result, true)
+	expected := "Layer 0: 4 pixels\nLayer 1: 4 pixels\nLayer 2: 4 pixels\nLayers: 3 of 3 differ, 12 pixels\n"
+	if buff.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buff.String())
+	}
+}
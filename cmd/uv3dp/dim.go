@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type DimCommand struct {
+	*pflag.FlagSet
+
+	Wall       int
+	Brightness float32
+	Bottom     bool
+}
+
+func NewDimCommand() (cmd *DimCommand) {
+	flagSet := pflag.NewFlagSet("dim", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &DimCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.IntVarP(&cmd.Wall, "wall", "w", 8, "Thickness of the full brightness shell, in pixels")
+	cmd.Float32VarP(&cmd.Brightness, "brightness", "b", 80, "Brightness of the interior, in percent")
+	cmd.BoolVarP(&cmd.Bottom, "bottom", "B", false, "Also dim the bottom and transition layers")
+
+	return
+}
+
+// dimModifier reduces the brightness of the interior of solid areas,
+// keeping a shell of full brightness around them.
+type dimModifier struct {
+	uv3dp.Printable
+
+	interior   uv3dp.Printable // Layers eroded by the shell thickness
+	brightness float32
+	firstLayer int
+}
+
+func (dm *dimModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := dm.Printable.LayerImage(index)
+	if index < dm.firstLayer {
+		return srcImage
+	}
+
+	interior := dm.interior.LayerImage(index)
+
+	grayImage = image.NewGray(srcImage.Bounds())
+	copy(grayImage.Pix, srcImage.Pix)
+
+	for n, pix := range interior.Pix {
+		if pix != 0 {
+			grayImage.Pix[n] = uint8(math.Round(float64(grayImage.Pix[n]) * float64(dm.brightness) / 100.0))
+		}
+	}
+
+	return
+}
+
+func (cmd *DimCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Wall < 1 {
+		err = fmt.Errorf("dim: --wall must be at least 1")
+		return
+	}
+
+	if cmd.Brightness < 0 || cmd.Brightness > 100 {
+		err = fmt.Errorf("dim: --brightness must be in the range 0..100")
+		return
+	}
+
+	firstLayer := 0
+	if !cmd.Bottom {
+		bottom := input.Bottom()
+		firstLayer = bottom.Count + bottom.Transition
+	}
+
+	interior := uv3dp.NewMorphedPrintable(input, uv3dp.MorphErode)
+	interior.Radius = cmd.Wall
+
+	TraceVerbosef(VerbosityNotice, "  Dimming interiors to %v%%, inside a %v pixel wall, from layer %v",
+		cmd.Brightness, cmd.Wall, firstLayer)
+
+	output = &dimModifier{
+		Printable:  input,
+		interior:   interior,
+		brightness: cmd.Brightness,
+		firstLayer: firstLayer,
+	}
+
+	return
+}
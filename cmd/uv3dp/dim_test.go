@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestDim(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:   uv3dp.Size{X: 40, Y: 40, Layers: 3, LayerHeight: 0.05},
+		Bottom: uv3dp.Bottom{Count: 1},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	cmd := NewDimCommand()
+	err := cmd.Parse([]string{"--wall", "4", "--brightness", "50"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	table := []struct {
+		Layer int
+		X, Y  int
+		Pix   uint8
+	}{
+		{Layer: 0, X: 20, Y: 20, Pix: 0xff}, // Bottom layer is not dimmed
+		{Layer: 1, X: 20, Y: 20, Pix: 0x80}, // Interior
+		{Layer: 1, X: 14, Y: 20, Pix: 0x80}, // Just inside the wall
+		{Layer: 1, X: 13, Y: 20, Pix: 0xff}, // Wall
+		{Layer: 1, X: 10, Y: 20, Pix: 0xff}, // Edge
+		{Layer: 1, X: 5, Y: 20, Pix: 0x00},  // Outside
+	}
+
+	for n, item := range table {
+		pix := output.LayerImage(item.Layer).GrayAt(item.X, item.Y).Y
+		if pix != item.Pix {
+			t.Errorf("%v: layer %v (%v,%v): expected %#x, got %#x", n, item.Layer, item.X, item.Y, item.Pix, pix)
+		}
+	}
+
+	cmd = NewDimCommand()
+	cmd.Parse([]string{"--brightness", "120"})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for a brightness over 100%%")
+	}
+}
@@ -29,19 +29,33 @@ func NewEmptyFormatter() (ef *EmptyFormatter) {
 		FlagSet: pflag.NewFlagSet("empty", pflag.ContinueOnError),
 	}
 
-	defaultMachine := uv3dp.MachineFormats["photon"]
-	size := &defaultMachine.Machine.Size
+	size := emptyMachineSize("photon")
 
 	ef.Uint8VarP(&ef.Gray, "gray", "g", 0, "Grayscale color (0 for black, 255 for white)")
 	ef.IntSliceVarP(&ef.Pixels, "pixels", "p", []int{size.X, size.Y}, "Empty size, in pixels")
 	ef.Float32SliceVarP(&ef.Millimeters, "millimeters", "m", []float32{size.Xmm, size.Ymm}, "Empty size, in millimeters")
 	ef.IntVarP(&ef.Layers, "layers", "l", 1, "Number of 0.05mm layers")
-	ef.StringVarP(&ef.Machine, "machine", "M", "photon", "Size preset by machine type")
+	ef.StringVarP(&ef.Machine, "machine", "M", "photon", "Size preset by machine type, or machine@variant")
 	ef.SetInterspersed(false)
 
 	return
 }
 
+// Size of the 'photon', used if it is not included in this build
+var emptyDefaultSize = uv3dp.MachineSize{X: 1440, Y: 2560, Xmm: 68.04, Ymm: 120.96}
+
+func emptyMachineSize(name string) (size *uv3dp.MachineSize) {
+	machine, err := uv3dp.LookupMachine(name)
+	if err != nil {
+		size = &emptyDefaultSize
+		return
+	}
+
+	size = &machine.Machine.Size
+
+	return
+}
+
 type EmptyPrint struct {
 	uv3dp.Print
 
@@ -57,7 +71,14 @@ func (ef *EmptyFormatter) Decode(file uv3dp.Reader, filesize int64) (printable u
 
 	size := &prop.Size
 
-	msize := uv3dp.MachineFormats[ef.Machine].Machine.Size
+	if ef.Changed("machine") {
+		_, err = uv3dp.LookupMachine(ef.Machine)
+		if err != nil {
+			return
+		}
+	}
+
+	msize := emptyMachineSize(ef.Machine)
 	size.X = msize.X
 	size.Y = msize.Y
 	size.Millimeter.X = msize.Xmm
@@ -93,6 +114,11 @@ func (ef *EmptyFormatter) Decode(file uv3dp.Reader, filesize int64) (printable u
 	return
 }
 
+// GeneratesPrintable is true, as empty printables are not read from a file
+func (ef *EmptyFormatter) GeneratesPrintable() bool {
+	return true
+}
+
 func (ef *EmptyFormatter) Encode(writer uv3dp.Writer, p uv3dp.Printable) (err error) {
 	return
 }
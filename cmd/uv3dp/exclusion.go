@@ -0,0 +1,268 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// ExclusionConfigPath is the file of the screen areas of each machine
+// that must not be printed on
+var ExclusionConfigPath = uv3dpPath("exclusions.txt")
+
+// ExclusionRegion is an area of a machine's screen, in pixels
+type ExclusionRegion struct {
+	Polygon []image.Point
+}
+
+// Contains returns true if the center of a pixel is inside of the region
+func (region *ExclusionRegion) Contains(x, y int) (inside bool) {
+	px, py := float64(x)+0.5, float64(y)+0.5
+
+	points := region.Polygon
+	for n := range points {
+		a := points[n]
+		b := points[(n+len(points)-1)%len(points)]
+		if (float64(a.Y) > py) == (float64(b.Y) > py) {
+			continue
+		}
+
+		cross := float64(a.X) + (py-float64(a.Y))*float64(b.X-a.X)/float64(b.Y-a.Y)
+		if px < cross {
+			inside = !inside
+		}
+	}
+
+	return
+}
+
+// exclusionPoint parses a point, as 'X,Y'
+func exclusionPoint(text string) (pt image.Point, err error) {
+	parts := strings.Split(text, ",")
+	if len(parts) != 2 {
+		err = fmt.Errorf("'%v' is not X,Y", text)
+		return
+	}
+
+	pt.X, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err == nil {
+		pt.Y, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+	}
+	if err != nil {
+		err = fmt.Errorf("'%v' is not X,Y", text)
+	}
+
+	return
+}
+
+// ExclusionParse reads the excluded regions of machines, one per line, as:
+//
+//	# Comment
+//	machine: rect X0,Y0,X1,Y1
+//	machine: polygon X,Y X,Y X,Y...
+//
+// Rectangles include X0,Y0, but not X1,Y1. Machine names include the
+// variant, if any, since the regions are in pixels of its resolution.
+func ExclusionParse(reader io.Reader) (exclusions map[string][]ExclusionRegion, err error) {
+	exclusions = map[string][]ExclusionRegion{}
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+
+		fields := strings.SplitN(text, ":", 2)
+		name := strings.TrimSpace(fields[0])
+		if len(fields) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+			err = fmt.Errorf("line %v: expected 'machine: rect X0,Y0,X1,Y1' or 'machine: polygon X,Y X,Y X,Y...'", line)
+			return
+		}
+
+		words := strings.Fields(fields[1])
+		if len(words) == 0 {
+			err = fmt.Errorf("line %v: machine '%v' has no region", line, name)
+			return
+		}
+
+		var region ExclusionRegion
+		switch words[0] {
+		case "rect":
+			if len(words) != 2 {
+				err = fmt.Errorf("line %v: expected 'rect X0,Y0,X1,Y1'", line)
+				return
+			}
+			var rect image.Rectangle
+			parts := strings.Split(words[1], ",")
+			if len(parts) == 4 {
+				rect.Min, err = exclusionPoint(parts[0] + "," + parts[1])
+				if err == nil {
+					rect.Max, err = exclusionPoint(parts[2] + "," + parts[3])
+				}
+			}
+			if len(parts) != 4 || err != nil {
+				err = fmt.Errorf("line %v: '%v' is not X0,Y0,X1,Y1", line, words[1])
+				return
+			}
+			rect = rect.Canon()
+			region.Polygon = []image.Point{rect.Min, {X: rect.Max.X, Y: rect.Min.Y}, rect.Max, {X: rect.Min.X, Y: rect.Max.Y}}
+		case "polygon":
+			for _, word := range words[1:] {
+				var pt image.Point
+				pt, err = exclusionPoint(word)
+				if err != nil {
+					err = fmt.Errorf("line %v: %w", line, err)
+					return
+				}
+				region.Polygon = append(region.Polygon, pt)
+			}
+			if len(region.Polygon) < 3 {
+				err = fmt.Errorf("line %v: a polygon needs at least 3 points", line)
+				return
+			}
+		default:
+			err = fmt.Errorf("line %v: '%v' is not 'rect' or 'polygon'", line, words[0])
+			return
+		}
+
+		exclusions[name] = append(exclusions[name], region)
+	}
+
+	err = scanner.Err()
+
+	return
+}
+
+// ExclusionMask returns an image with the pixels of the regions set
+func ExclusionMask(regions []ExclusionRegion, rect image.Rectangle) (mask *image.Gray) {
+	mask = image.NewGray(rect)
+
+	for _, region := range regions {
+		bounds := image.Rectangle{}
+		for _, pt := range region.Polygon {
+			bounds = bounds.Union(image.Rectangle{Min: pt, Max: pt.Add(image.Point{X: 1, Y: 1})})
+		}
+
+		bounds = bounds.Intersect(rect)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if region.Contains(x, y) {
+					mask.Pix[mask.PixOffset(x, y)] = 0xff
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// exclusionModifier blanks the excluded pixels of all layers
+type exclusionModifier struct {
+	uv3dp.Printable
+
+	mask *image.Gray
+}
+
+func (em *exclusionModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := em.Printable.LayerImage(index)
+
+	grayImage = image.NewGray(srcImage.Bounds())
+	copy(grayImage.Pix, srcImage.Pix)
+
+	for n, pix := range em.mask.Pix {
+		if pix != 0 {
+			grayImage.Pix[n] = 0
+		}
+	}
+
+	return
+}
+
+// ExclusionSafe blanks the pixels of the layers in the regions, and
+// returns the layers that had lit pixels in them.
+func ExclusionSafe(input uv3dp.Printable, regions []ExclusionRegion) (output uv3dp.Printable, overlaps []int) {
+	output = input
+	if len(regions) == 0 {
+		return
+	}
+
+	size := input.Size()
+	mask := ExclusionMask(regions, image.Rect(0, 0, size.X, size.Y))
+
+	var mutex sync.Mutex
+	uv3dp.WithAllLayers(input, func(p uv3dp.Printable, n int) {
+		layer := p.LayerImage(n)
+		for i, pix := range mask.Pix {
+			if pix != 0 && layer.Pix[i] != 0 {
+				mutex.Lock()
+				overlaps = append(overlaps, n)
+				mutex.Unlock()
+				return
+			}
+		}
+	})
+
+	sort.Ints(overlaps)
+
+	output = &exclusionModifier{
+		Printable: input,
+		mask:      mask,
+	}
+
+	return
+}
+
+// ExclusionFilter blanks the excluded regions of a machine's screen,
+// as listed in the ExclusionConfigPath file, warning of layers that
+// printed on them.
+func ExclusionFilter(input uv3dp.Printable, machineName string) (output uv3dp.Printable, err error) {
+	output = input
+
+	if machineName == "" {
+		return
+	}
+
+	reader, err := os.Open(ExclusionConfigPath)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+
+	exclusions, err := ExclusionParse(reader)
+	if err != nil {
+		err = fmt.Errorf("%v: %w", ExclusionConfigPath, err)
+		return
+	}
+
+	regions := exclusions[machineName]
+	if len(regions) == 0 {
+		return
+	}
+
+	output, overlaps := ExclusionSafe(input, regions)
+
+	TraceVerbosef(VerbosityNotice, "%v: blanking %v excluded regions", machineName, len(regions))
+	if len(overlaps) > 0 {
+		TraceVerbosef(VerbosityWarning, "%v: %v layers (from layer %v to %v) overlap excluded regions, and were blanked there",
+			machineName, len(overlaps), overlaps[0], overlaps[len(overlaps)-1])
+	}
+
+	return
+}
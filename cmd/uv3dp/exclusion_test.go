@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestExclusionParse(t *testing.T) {
+	text := `
+# Dead column
+e6: rect 10,0,12,2560
+e6: polygon 0,0 4,0 0,4
+e6@binned:rect 5,5,1,1
+`
+
+	exclusions, err := ExclusionParse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := map[string][]ExclusionRegion{
+		"e6": {
+			{Polygon: []image.Point{{10, 0}, {12, 0}, {12, 2560}, {10, 2560}}},
+			{Polygon: []image.Point{{0, 0}, {4, 0}, {0, 4}}},
+		},
+		"e6@binned": {
+			{Polygon: []image.Point{{1, 1}, {5, 1}, {5, 5}, {1, 5}}},
+		},
+	}
+
+	if !cmp.Equal(expected, exclusions) {
+		t.Errorf("expected %v, got %v", expected, exclusions)
+	}
+
+	for _, text := range []string{"e6 rect 1,1,2,2", "e6: rect 1,1,2", "e6: polygon 0,0 1,1", "e6: circle 1,1", "e6:", "e6: rect a,b,c,d"} {
+		_, err = ExclusionParse(strings.NewReader(text))
+		if err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
+
+func TestExclusionMask(t *testing.T) {
+	regions := []ExclusionRegion{
+		{Polygon: []image.Point{{0, 0}, {4, 0}, {0, 4}}},
+		{Polygon: []image.Point{{5, 1}, {7, 1}, {7, 3}, {5, 3}}},
+	}
+
+	mask := ExclusionMask(regions, image.Rect(0, 0, 8, 4))
+
+	expected := []uint8{
+		0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xff, 0xff, 0x00, 0x00, 0x00, 0xff, 0xff, 0x00,
+		0xff, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	if !cmp.Equal(expected, mask.Pix) {
+		t.Errorf("expected %#v, got %#v", expected, mask.Pix)
+	}
+}
+
+func TestExclusionSafe(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 40, Y: 40, Layers: 3, LayerHeight: 0.05},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	// Regions away from the model do not overlap
+	regions := []ExclusionRegion{{Polygon: []image.Point{{0, 0}, {5, 0}, {5, 5}, {0, 5}}}}
+	_, overlaps := ExclusionSafe(input, regions)
+	if len(overlaps) != 0 {
+		t.Errorf("expected no overlaps, got %v", overlaps)
+	}
+
+	regions = append(regions, ExclusionRegion{Polygon: []image.Point{{25, 0}, {27, 0}, {27, 40}, {25, 40}}})
+	output, overlaps := ExclusionSafe(input, regions)
+	if !cmp.Equal([]int{0, 1, 2}, overlaps) {
+		t.Errorf("expected overlaps on all layers, got %v", overlaps)
+	}
+
+	img := output.LayerImage(1)
+	if img.GrayAt(25, 20).Y != 0 || img.GrayAt(26, 20).Y != 0 {
+		t.Errorf("expected excluded pixels to be blank")
+	}
+	if img.GrayAt(24, 20).Y != 0xff || img.GrayAt(27, 20).Y != 0xff {
+		t.Errorf("expected other pixels to be kept")
+	}
+}
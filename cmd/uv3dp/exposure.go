@@ -5,6 +5,15 @@
 package main
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	"github.com/spf13/pflag"
 
 	"github.com/nicarran/uv3dp"
@@ -16,6 +25,14 @@ type ExposureCommand struct {
 	LightOnTime  float32
 	LightOffTime float32
 	LightPWM     uint8
+	PerLayer     string
+
+	WaitBeforeCure float32
+	WaitAfterCure  float32
+
+	Matrix     string // 'export' or 'import'
+	MatrixFile string
+	args       []string
 }
 
 func NewExposureCommand() (cmd *ExposureCommand) {
@@ -26,12 +43,46 @@ func NewExposureCommand() (cmd *ExposureCommand) {
 	cmd.Float32VarP(&cmd.LightOnTime, "light-on", "o", 0.0, "Normal layer light-on time in seconds")
 	cmd.Float32VarP(&cmd.LightOffTime, "light-off", "f", 0.0, "Normal layer light-off time in seconds")
 	cmd.Uint8VarP(&cmd.LightPWM, "pwm", "p", 255, "Light PWM rate (0..255)")
+	cmd.StringVarP(&cmd.PerLayer, "per-layer", "l", "", "CSV or JSON file of exposure settings for layer or Z ranges")
+	cmd.Float32VarP(&cmd.WaitBeforeCure, "wait-before-cure", "w", 0.0, "Normal layer rest time before the light is on, in seconds")
+	cmd.Float32VarP(&cmd.WaitAfterCure, "wait-after-cure", "W", 0.0, "Normal layer rest time before the lift, in seconds")
 
 	cmd.SetInterspersed(false)
 
 	return
 }
 
+// Parse parses the flags, and an optional 'export FILE' or 'import FILE' action
+func (cmd *ExposureCommand) Parse(args []string) (err error) {
+	err = cmd.FlagSet.Parse(args)
+	if err != nil {
+		return
+	}
+
+	cmd.args = cmd.FlagSet.Args()
+	if len(cmd.args) > 0 && (cmd.args[0] == "export" || cmd.args[0] == "import") {
+		if len(cmd.args) < 2 {
+			err = fmt.Errorf("exposure: %v requires a CSV file", cmd.args[0])
+			return
+		}
+		cmd.Matrix = cmd.args[0]
+		cmd.MatrixFile = cmd.args[1]
+		cmd.args = cmd.args[2:]
+	}
+
+	return
+}
+
+// Args returns the arguments remaining after parsing
+func (cmd *ExposureCommand) Args() []string {
+	return cmd.args
+}
+
+// NArg returns the number of arguments remaining after parsing
+func (cmd *ExposureCommand) NArg() int {
+	return len(cmd.args)
+}
+
 type exposureModifier struct {
 	uv3dp.Printable
 
@@ -76,9 +127,552 @@ func (cmd *ExposureCommand) Filter(input uv3dp.Printable) (mod uv3dp.Printable,
 		exp.LightPWM = cmd.LightPWM
 	}
 
-	mod = &exposureModifier{
-		Printable: input,
-		exposure:  exp,
+	if cmd.Changed("wait-before-cure") {
+		TraceVerbosef(VerbosityNotice, "  Setting default wait before cure to %v", cmd.WaitBeforeCure)
+		exp.WaitBeforeCure = cmd.WaitBeforeCure
+	}
+
+	if cmd.Changed("wait-after-cure") {
+		TraceVerbosef(VerbosityNotice, "  Setting default wait after cure to %v", cmd.WaitAfterCure)
+		exp.WaitAfterCure = cmd.WaitAfterCure
+	}
+
+	mod = input
+
+	// Only override the per-layer exposures if the defaults were changed
+	changed := cmd.Changed("light-on") || cmd.Changed("light-off") || cmd.Changed("pwm") ||
+		cmd.Changed("wait-before-cure") || cmd.Changed("wait-after-cure")
+	if changed || (cmd.Matrix == "" && cmd.PerLayer == "") {
+		mod = &exposureModifier{
+			Printable: input,
+			exposure:  exp,
+		}
+	}
+
+	switch cmd.Matrix {
+	case "export":
+		TraceVerbosef(VerbosityNotice, "  Exporting exposure matrix to %v", cmd.MatrixFile)
+		err = cmd.withMatrixFile(func(file *os.File) error {
+			return ExposureExport(file, mod)
+		})
+	case "import":
+		TraceVerbosef(VerbosityNotice, "  Importing exposure matrix from %v", cmd.MatrixFile)
+		var layers map[int]uv3dp.Exposure
+		err = cmd.withMatrixFile(func(file *os.File) (err error) {
+			layers, err = ExposureImport(file, mod.Size().Layers)
+			return
+		})
+		mod = &layerExposureModifier{
+			Printable: mod,
+			layers:    layers,
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("exposure: %v: %w", cmd.MatrixFile, err)
+		return
+	}
+
+	if cmd.PerLayer != "" {
+		TraceVerbosef(VerbosityNotice, "  Applying per-layer exposures from %v", cmd.PerLayer)
+		var layers map[int]uv3dp.Exposure
+		layers, err = cmd.perLayer(mod)
+		if err != nil {
+			err = fmt.Errorf("exposure: %v: %w", cmd.PerLayer, err)
+			return
+		}
+		mod = &layerExposureModifier{
+			Printable: mod,
+			layers:    layers,
+		}
+	}
+
+	return
+}
+
+// perLayer reads the --per-layer file, and applies it to a printable
+func (cmd *ExposureCommand) perLayer(printable uv3dp.Printable) (exposures map[int]uv3dp.Exposure, err error) {
+	var reader io.Reader = os.Stdin
+	if cmd.PerLayer != uv3dp.FilenameStdio {
+		var file *os.File
+		file, err = os.Open(cmd.PerLayer)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	var overrides []ExposureOverride
+	if strings.ToLower(filepath.Ext(cmd.PerLayer)) == ".json" {
+		overrides, err = ExposureOverridesJSON(reader)
+	} else {
+		overrides, err = ExposureOverridesCSV(reader)
+	}
+	if err != nil {
+		return
+	}
+
+	exposures, err = ExposurePerLayer(printable, overrides)
+
+	return
+}
+
+func (cmd *ExposureCommand) withMatrixFile(do func(file *os.File) error) (err error) {
+	if cmd.MatrixFile == uv3dp.FilenameStdio {
+		if cmd.Matrix == "export" {
+			return do(os.Stdout)
+		}
+		return do(os.Stdin)
+	}
+
+	var file *os.File
+	if cmd.Matrix == "export" {
+		file, err = os.Create(cmd.MatrixFile)
+	} else {
+		file, err = os.Open(cmd.MatrixFile)
+	}
+	if err != nil {
+		return
+	}
+
+	err = do(file)
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return
+}
+
+// layerExposureModifier replaces the exposure of selected layers
+type layerExposureModifier struct {
+	uv3dp.Printable
+
+	layers map[int]uv3dp.Exposure
+}
+
+func (mod *layerExposureModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	exposure, ok := mod.layers[index]
+	if !ok {
+		exposure = mod.Printable.LayerExposure(index)
+	}
+
+	return
+}
+
+// Columns of an exposure matrix. The Z column is for reference only,
+// and is ignored on import.
+var exposureMatrixHeader = []string{
+	"Layer", "Z",
+	"LightOnTime", "LightOffTime", "LightPWM",
+	"LiftHeight", "LiftSpeed",
+	"RetractHeight", "RetractSpeed",
+	"WaitBeforeCure", "WaitAfterCure", "WaitAfterLift",
+	"LiftHeight2", "LiftSpeed2",
+	"RetractHeight2", "RetractSpeed2",
+}
+
+// Fewest columns of an exposure matrix, as exported before the wait times.
+// Later columns were added at the end, so older matrices are still valid.
+const exposureMatrixMinColumns = 9
+
+func formatMatrixFloat(value float32) string {
+	return strconv.FormatFloat(float64(value), 'g', -1, 32)
+}
+
+// ExposureExport writes the exposure of every layer as CSV
+func ExposureExport(writer io.Writer, printable uv3dp.Printable) (err error) {
+	csvWriter := csv.NewWriter(writer)
+
+	err = csvWriter.Write(exposureMatrixHeader)
+	if err != nil {
+		return
+	}
+
+	layers := printable.Size().Layers
+	for n := 0; n < layers; n++ {
+		exposure := printable.LayerExposure(n)
+		err = csvWriter.Write([]string{
+			strconv.Itoa(n),
+			formatMatrixFloat(printable.LayerZ(n)),
+			formatMatrixFloat(exposure.LightOnTime),
+			formatMatrixFloat(exposure.LightOffTime),
+			strconv.Itoa(int(exposure.LightPWM)),
+			formatMatrixFloat(exposure.LiftHeight),
+			formatMatrixFloat(exposure.LiftSpeed),
+			formatMatrixFloat(exposure.RetractHeight),
+			formatMatrixFloat(exposure.RetractSpeed),
+			formatMatrixFloat(exposure.WaitBeforeCure),
+			formatMatrixFloat(exposure.WaitAfterCure),
+			formatMatrixFloat(exposure.WaitAfterLift),
+			formatMatrixFloat(exposure.LiftHeight2),
+			formatMatrixFloat(exposure.LiftSpeed2),
+			formatMatrixFloat(exposure.RetractHeight2),
+			formatMatrixFloat(exposure.RetractSpeed2),
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	csvWriter.Flush()
+	err = csvWriter.Error()
+
+	return
+}
+
+// ExposureImport reads an exposure matrix written by ExposureExport,
+// returning the exposure of each layer listed. Settings whose columns
+// are missing from older matrices are zero.
+func ExposureImport(reader io.Reader, layers int) (exposures map[int]uv3dp.Exposure, err error) {
+	csvReader := csv.NewReader(reader)
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return
+	}
+
+	if len(header) < exposureMatrixMinColumns || len(header) > len(exposureMatrixHeader) {
+		err = fmt.Errorf("expected %v to %v columns, got %v", exposureMatrixMinColumns, len(exposureMatrixHeader), len(header))
+		return
+	}
+
+	for n, name := range exposureMatrixHeader[:len(header)] {
+		if header[n] != name {
+			err = fmt.Errorf("column %v: expected '%v', got '%v'", n+1, name, header[n])
+			return
+		}
+	}
+
+	exposures = map[int]uv3dp.Exposure{}
+
+	for line := 2; ; line++ {
+		var record []string
+		record, err = csvReader.Read()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+
+		var index int
+		index, err = strconv.Atoi(record[0])
+		if err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+
+		if index < 0 || index >= layers {
+			err = fmt.Errorf("line %v: layer %v is not in the range 0..%v", line, index, layers-1)
+			return
+		}
+
+		values := make([]float32, len(exposureMatrixHeader))
+		for n := 2; n < len(record); n++ {
+			var value float64
+			value, err = strconv.ParseFloat(record[n], 32)
+			if err != nil {
+				err = fmt.Errorf("line %v: %v: %w", line, exposureMatrixHeader[n], err)
+				return
+			}
+			values[n] = float32(value)
+		}
+
+		if values[4] < 0 || values[4] > 255 {
+			err = fmt.Errorf("line %v: LightPWM %v is not in the range 0..255", line, values[4])
+			return
+		}
+
+		exposures[index] = uv3dp.Exposure{
+			LightOnTime:    values[2],
+			LightOffTime:   values[3],
+			LightPWM:       uint8(values[4]),
+			LiftHeight:     values[5],
+			LiftSpeed:      values[6],
+			RetractHeight:  values[7],
+			RetractSpeed:   values[8],
+			WaitBeforeCure: values[9],
+			WaitAfterCure:  values[10],
+			WaitAfterLift:  values[11],
+			LiftHeight2:    values[12],
+			LiftSpeed2:     values[13],
+			RetractHeight2: values[14],
+			RetractSpeed2:  values[15],
+		}
+	}
+
+	return
+}
+
+// exposureSetters set the exposure settings, by their matrix column names
+var exposureSetters = map[string]func(exposure *uv3dp.Exposure, value float32){
+	"LightOnTime":    func(exposure *uv3dp.Exposure, value float32) { exposure.LightOnTime = value },
+	"LightOffTime":   func(exposure *uv3dp.Exposure, value float32) { exposure.LightOffTime = value },
+	"LightPWM":       func(exposure *uv3dp.Exposure, value float32) { exposure.LightPWM = uint8(value) },
+	"LiftHeight":     func(exposure *uv3dp.Exposure, value float32) { exposure.LiftHeight = value },
+	"LiftSpeed":      func(exposure *uv3dp.Exposure, value float32) { exposure.LiftSpeed = value },
+	"RetractHeight":  func(exposure *uv3dp.Exposure, value float32) { exposure.RetractHeight = value },
+	"RetractSpeed":   func(exposure *uv3dp.Exposure, value float32) { exposure.RetractSpeed = value },
+	"WaitBeforeCure": func(exposure *uv3dp.Exposure, value float32) { exposure.WaitBeforeCure = value },
+	"WaitAfterCure":  func(exposure *uv3dp.Exposure, value float32) { exposure.WaitAfterCure = value },
+	"WaitAfterLift":  func(exposure *uv3dp.Exposure, value float32) { exposure.WaitAfterLift = value },
+	"LiftHeight2":    func(exposure *uv3dp.Exposure, value float32) { exposure.LiftHeight2 = value },
+	"LiftSpeed2":     func(exposure *uv3dp.Exposure, value float32) { exposure.LiftSpeed2 = value },
+	"RetractHeight2": func(exposure *uv3dp.Exposure, value float32) { exposure.RetractHeight2 = value },
+	"RetractSpeed2":  func(exposure *uv3dp.Exposure, value float32) { exposure.RetractSpeed2 = value },
+}
+
+// ExposureOverride changes some of the exposure settings of a range of
+// layers, selected by layer index or by Z height
+type ExposureOverride struct {
+	Source string // Where the override was read from, for errors
+
+	ByZ         bool
+	First, Last int     // Layer range, inclusive
+	MinZ, MaxZ  float32 // Z range in millimeters, inclusive
+
+	Values map[string]float32 // Settings, by matrix column name
+}
+
+// Matches returns true if a layer is in the range of the override
+func (eo *ExposureOverride) Matches(index int, z float32) bool {
+	if eo.ByZ {
+		return z > eo.MinZ-convertTolerance && z < eo.MaxZ+convertTolerance
+	}
+
+	return index >= eo.First && index <= eo.Last
+}
+
+// parseRange parses a range selector, as 'VALUE' or 'FIRST-LAST'
+func (eo *ExposureOverride) parseRange(selector string) (err error) {
+	parts := strings.SplitN(strings.TrimSpace(selector), "-", 2)
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+
+	if eo.ByZ {
+		var min, max float64
+		min, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 32)
+		if err == nil {
+			max, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 32)
+		}
+		if err != nil {
+			err = fmt.Errorf("%v: Z range '%v' is invalid", eo.Source, selector)
+			return
+		}
+		eo.MinZ, eo.MaxZ = float32(min), float32(max)
+		if eo.MinZ > eo.MaxZ {
+			err = fmt.Errorf("%v: Z range '%v' is reversed", eo.Source, selector)
+		}
+		return
+	}
+
+	eo.First, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err == nil {
+		eo.Last, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+	}
+	if err != nil {
+		err = fmt.Errorf("%v: layer range '%v' is invalid", eo.Source, selector)
+		return
+	}
+	if eo.First < 0 || eo.First > eo.Last {
+		err = fmt.Errorf("%v: layer range '%v' is reversed, or negative", eo.Source, selector)
+	}
+
+	return
+}
+
+// setValue checks and sets a setting of the override
+func (eo *ExposureOverride) setValue(name string, value float32) (err error) {
+	_, ok := exposureSetters[name]
+	if !ok {
+		err = fmt.Errorf("%v: unknown setting '%v'", eo.Source, name)
+		return
+	}
+
+	if value < 0 || (name == "LightPWM" && value > 255) {
+		err = fmt.Errorf("%v: %v %v is out of range", eo.Source, name, value)
+		return
+	}
+
+	if eo.Values == nil {
+		eo.Values = map[string]float32{}
+	}
+	eo.Values[name] = value
+
+	return
+}
+
+// ExposureOverridesCSV reads overrides from CSV. The first column is
+// 'Layer' or 'Z', with a single value or a FIRST-LAST range, and the
+// other columns are any of the exposure matrix settings. Empty cells
+// keep the setting of the layer.
+func ExposureOverridesCSV(reader io.Reader) (overrides []ExposureOverride, err error) {
+	csvReader := csv.NewReader(reader)
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return
+	}
+
+	byZ := false
+	switch header[0] {
+	case "Layer":
+	case "Z":
+		byZ = true
+	default:
+		err = fmt.Errorf("column 1: expected 'Layer' or 'Z', got '%v'", header[0])
+		return
+	}
+
+	for n, name := range header[1:] {
+		_, ok := exposureSetters[name]
+		if !ok {
+			err = fmt.Errorf("column %v: unknown setting '%v'", n+2, name)
+			return
+		}
+	}
+
+	for line := 2; ; line++ {
+		var record []string
+		record, err = csvReader.Read()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+
+		override := ExposureOverride{Source: fmt.Sprintf("line %v", line), ByZ: byZ}
+		err = override.parseRange(record[0])
+		if err != nil {
+			return
+		}
+
+		for n, cell := range record[1:] {
+			if strings.TrimSpace(cell) == "" {
+				continue
+			}
+
+			var value float64
+			value, err = strconv.ParseFloat(strings.TrimSpace(cell), 32)
+			if err != nil {
+				err = fmt.Errorf("line %v: %v: %w", line, header[n+1], err)
+				return
+			}
+
+			err = override.setValue(header[n+1], float32(value))
+			if err != nil {
+				return
+			}
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	return
+}
+
+// ExposureOverridesJSON reads overrides from a JSON list of objects, each
+// with a 'Layer' or 'Z' range (as a number, or a "FIRST-LAST" string),
+// and any of the exposure matrix settings.
+func ExposureOverridesJSON(reader io.Reader) (overrides []ExposureOverride, err error) {
+	var entries []map[string]interface{}
+
+	err = json.NewDecoder(reader).Decode(&entries)
+	if err != nil {
+		return
+	}
+
+	for n, entry := range entries {
+		override := ExposureOverride{Source: fmt.Sprintf("entry %v", n+1)}
+
+		layer, hasLayer := entry["Layer"]
+		z, hasZ := entry["Z"]
+		if hasLayer == hasZ {
+			err = fmt.Errorf("%v: expected one of 'Layer' or 'Z'", override.Source)
+			return
+		}
+
+		selector := layer
+		if hasZ {
+			override.ByZ = true
+			selector = z
+		}
+
+		err = override.parseRange(fmt.Sprint(selector))
+		if err != nil {
+			return
+		}
+
+		for name, data := range entry {
+			if name == "Layer" || name == "Z" {
+				continue
+			}
+
+			value, ok := data.(float64)
+			if !ok {
+				err = fmt.Errorf("%v: %v must be a number", override.Source, name)
+				return
+			}
+
+			err = override.setValue(name, float32(value))
+			if err != nil {
+				return
+			}
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	return
+}
+
+// ExposurePerLayer applies overrides to the exposure of each layer of a
+// printable, returning the exposures of the changed layers. Overrides
+// are applied in order, so later overrides replace earlier settings.
+func ExposurePerLayer(printable uv3dp.Printable, overrides []ExposureOverride) (exposures map[int]uv3dp.Exposure, err error) {
+	layers := printable.Size().Layers
+	matched := make([]bool, len(overrides))
+
+	exposures = map[int]uv3dp.Exposure{}
+
+	for n := 0; n < layers; n++ {
+		z := printable.LayerZ(n)
+		exposure := printable.LayerExposure(n)
+		changed := false
+
+		for index, override := range overrides {
+			if !override.Matches(n, z) {
+				continue
+			}
+
+			for name, value := range override.Values {
+				exposureSetters[name](&exposure, value)
+			}
+			matched[index] = true
+			changed = true
+		}
+
+		if changed {
+			exposures[n] = exposure
+		}
+	}
+
+	for index, override := range overrides {
+		if !override.ByZ && override.Last >= layers {
+			err = fmt.Errorf("%v: layer %v is not in the range 0..%v", override.Source, override.Last, layers-1)
+			return
+		}
+		if !matched[index] {
+			err = fmt.Errorf("%v: no layers are in the range", override.Source)
+			return
+		}
 	}
 
 	return
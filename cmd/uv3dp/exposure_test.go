@@ -0,0 +1,172 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestExposureMatrix(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 3, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{
+			LightOnTime: 8.5, LightOffTime: 1, LightPWM: 255,
+			LiftHeight: 5, LiftSpeed: 60, RetractHeight: 5, RetractSpeed: 150,
+			WaitAfterCure: 0.5, WaitAfterLift: 1.5,
+			LiftHeight2: 3, LiftSpeed2: 240,
+		},
+		Bottom: uv3dp.Bottom{
+			Count:    1,
+			Exposure: uv3dp.Exposure{LightOnTime: 60.25, LightOffTime: 1, LightPWM: 255, LiftHeight: 5, LiftSpeed: 60},
+		},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	buffer := &bytes.Buffer{}
+	err := ExposureExport(buffer, input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"Layer,Z,LightOnTime,LightOffTime,LightPWM,LiftHeight,LiftSpeed,RetractHeight,RetractSpeed,WaitBeforeCure,WaitAfterCure,WaitAfterLift,LiftHeight2,LiftSpeed2,RetractHeight2,RetractSpeed2",
+		"0,0.05,60.25,1,255,5,60,0,0,0,0,0,0,0,0,0",
+		"1,0.1,8.5,1,255,5,60,5,150,0,0.5,1.5,3,240,0,0",
+		"2,0.15,8.5,1,255,5,60,5,150,0,0.5,1.5,3,240,0,0",
+		"",
+	}, "\n")
+	if buffer.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buffer.String())
+	}
+
+	exposures, err := ExposureImport(bytes.NewReader(buffer.Bytes()), 3)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	for n := 0; n < 3; n++ {
+		if !cmp.Equal(exposures[n], input.LayerExposure(n)) {
+			t.Errorf("layer %v: expected %+v, got %+v", n, input.LayerExposure(n), exposures[n])
+		}
+	}
+
+	// Matrices without the later columns are still accepted
+	legacy := "Layer,Z,LightOnTime,LightOffTime,LightPWM,LiftHeight,LiftSpeed,RetractHeight,RetractSpeed\n" +
+		"1,0.1,9,1,255,5,60,5,150\n"
+	exposures, err = ExposureImport(strings.NewReader(legacy), 3)
+	if err != nil {
+		t.Fatalf("legacy: expected nil, got %v", err)
+	}
+
+	expectedLegacy := uv3dp.Exposure{
+		LightOnTime: 9, LightOffTime: 1, LightPWM: 255,
+		LiftHeight: 5, LiftSpeed: 60, RetractHeight: 5, RetractSpeed: 150,
+	}
+	if !cmp.Equal(exposures[1], expectedLegacy) {
+		t.Errorf("legacy: expected %+v, got %+v", expectedLegacy, exposures[1])
+	}
+
+	invalid := map[string]string{
+		"header":  "Layer,Z,On,LightOffTime,LightPWM,LiftHeight,LiftSpeed,RetractHeight,RetractSpeed\n",
+		"layer":   expected + "3,0.2,8,1,255,5,60,5,150,0,0,0,0,0,0,0\n",
+		"pwm":     expected + "2,0.15,8,1,256,5,60,5,150,0,0,0,0,0,0,0\n",
+		"value":   expected + "2,0.15,fast,1,255,5,60,5,150,0,0,0,0,0,0,0\n",
+		"columns": expected + "2,0.15,8,1,255,5,60,5,150\n",
+	}
+
+	for name, data := range invalid {
+		_, err = ExposureImport(strings.NewReader(data), 3)
+		if err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
+
+func TestExposureParse(t *testing.T) {
+	cmd := NewExposureCommand()
+	err := cmd.Parse([]string{"-o", "8", "export", "matrix.csv", "out.uvj"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if cmd.Matrix != "export" || cmd.MatrixFile != "matrix.csv" {
+		t.Errorf("expected export of matrix.csv, got %v of %v", cmd.Matrix, cmd.MatrixFile)
+	}
+
+	if !cmp.Equal(cmd.Args(), []string{"out.uvj"}) {
+		t.Errorf("expected [out.uvj], got %v", cmd.Args())
+	}
+
+	err = NewExposureCommand().Parse([]string{"import"})
+	if err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestExposurePerLayer(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 10, Layers: 6, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8, LightPWM: 255, LiftHeight: 5, LiftSpeed: 60},
+		Bottom:   uv3dp.Bottom{Count: 1, Exposure: uv3dp.Exposure{LightOnTime: 60, LightPWM: 255, LiftHeight: 5, LiftSpeed: 60}},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	overrides, err := ExposureOverridesCSV(strings.NewReader("Layer,LightOnTime,LiftSpeed\n1-2,10,\n4,,90\n"))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	more, err := ExposureOverridesJSON(strings.NewReader(`[{"Z": "0.25-0.3", "LightPWM": 128}, {"Layer": 5, "LightOnTime": 12}]`))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	exposures, err := ExposurePerLayer(input, append(overrides, more...))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := map[int]uv3dp.Exposure{
+		1: {LightOnTime: 10, LightPWM: 255, LiftHeight: 5, LiftSpeed: 60},
+		2: {LightOnTime: 10, LightPWM: 255, LiftHeight: 5, LiftSpeed: 60},
+		4: {LightOnTime: 8, LightPWM: 128, LiftHeight: 5, LiftSpeed: 90},
+		5: {LightOnTime: 12, LightPWM: 128, LiftHeight: 5, LiftSpeed: 60},
+	}
+	if !cmp.Equal(exposures, expected) {
+		t.Errorf("expected %+v, got %+v", expected, exposures)
+	}
+
+	invalid := map[string]string{
+		"column":   "Layer,Bogus\n",
+		"selector": "Height,LightOnTime\n",
+		"reversed": "Layer,LightOnTime\n5-2,1\n",
+		"layer":    "Layer,LightOnTime\n9,1\n",
+		"z":        "Z,LightOnTime\n5,1\n",
+		"pwm":      "Layer,LightPWM\n1,300\n",
+	}
+
+	for name, data := range invalid {
+		overrides, err = ExposureOverridesCSV(strings.NewReader(data))
+		if err == nil {
+			_, err = ExposurePerLayer(input, overrides)
+		}
+		if err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+
+	_, err = ExposureOverridesJSON(strings.NewReader(`[{"Layer": 1, "Z": 0.1, "LightOnTime": 1}]`))
+	if err == nil {
+		t.Errorf("expected an error for both a layer and Z range")
+	}
+}
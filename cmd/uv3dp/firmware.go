@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// firmwareModifier forces the light PWM of layers to full power
+type firmwareModifier struct {
+	uv3dp.Printable
+
+	bottomPWMFull bool
+	normalPWMFull bool
+}
+
+func (fm *firmwareModifier) Exposure() (exposure uv3dp.Exposure) {
+	exposure = fm.Printable.Exposure()
+	if fm.normalPWMFull {
+		exposure.LightPWM = 255
+	}
+
+	return
+}
+
+func (fm *firmwareModifier) Bottom() (bottom uv3dp.Bottom) {
+	bottom = fm.Printable.Bottom()
+	if fm.bottomPWMFull {
+		bottom.Exposure.LightPWM = 255
+	}
+
+	return
+}
+
+func (fm *firmwareModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	exposure = fm.Printable.LayerExposure(index)
+
+	bottom := fm.Printable.Bottom()
+	if (index < bottom.Count && fm.bottomPWMFull) || (index >= bottom.Count && fm.normalPWMFull) {
+		exposure.LightPWM = 255
+	}
+
+	return
+}
+
+// FirmwareSafe adapts a printable to the quirks of a firmware version,
+// returning a description of each change made.
+func FirmwareSafe(input uv3dp.Printable, quirks uv3dp.FirmwareQuirks) (output uv3dp.Printable, changes []string, err error) {
+	output = input
+
+	size := input.Size()
+	if quirks.MaxLayers > 0 && size.Layers > quirks.MaxLayers {
+		err = fmt.Errorf("%v layers is more than the firmware's limit of %v layers (see 'split')", size.Layers, quirks.MaxLayers)
+		return
+	}
+
+	bottom := input.Bottom()
+	exposure := input.Exposure()
+
+	bottomPWM := false
+	normalPWM := false
+	for n := 0; n < size.Layers; n++ {
+		if input.LayerExposure(n).LightPWM == 255 {
+			continue
+		}
+		if n < bottom.Count {
+			bottomPWM = true
+		} else {
+			normalPWM = true
+		}
+	}
+
+	bottomPWM = quirks.BottomPWMFull && (bottomPWM || bottom.Exposure.LightPWM != 255)
+	normalPWM = quirks.NormalPWMFull && (normalPWM || exposure.LightPWM != 255)
+
+	if bottomPWM {
+		changes = append(changes, "bottom layer light PWM set to 255")
+	}
+
+	if normalPWM {
+		changes = append(changes, "normal layer light PWM set to 255")
+	}
+
+	if bottomPWM || normalPWM {
+		output = &firmwareModifier{
+			Printable:     input,
+			bottomPWMFull: bottomPWM,
+			normalPWMFull: normalPWM,
+		}
+	}
+
+	return
+}
+
+// FirmwareFilter applies the quirks of a machine's firmware version
+func FirmwareFilter(input uv3dp.Printable, machineName string, version string) (output uv3dp.Printable, err error) {
+	output = input
+
+	if version == "" {
+		return
+	}
+
+	if machineName == "" {
+		err = fmt.Errorf("firmware '%v': a machine must be selected with --machine", version)
+		return
+	}
+
+	machine, err := uv3dp.LookupMachine(machineName)
+	if err != nil {
+		return
+	}
+
+	quirks, ok := machine.LookupFirmware(version)
+	if !ok {
+		TraceVerbosef(VerbosityNotice, "%v firmware %v: no known quirks", machineName, version)
+		return
+	}
+
+	output, changes, err := FirmwareSafe(input, quirks)
+	if err != nil {
+		err = fmt.Errorf("%v firmware %v: %w", machineName, version, err)
+		return
+	}
+
+	for _, change := range changes {
+		TraceVerbosef(VerbosityWarning, "%v firmware %v: %v", machineName, version, change)
+	}
+
+	return
+}
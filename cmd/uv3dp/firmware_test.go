@@ -0,0 +1,52 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestFirmwareSafe(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 10, Layers: 4, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8, LightPWM: 200},
+		Bottom:   uv3dp.Bottom{Count: 2, Exposure: uv3dp.Exposure{LightOnTime: 60, LightPWM: 128}},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	output, changes, err := FirmwareSafe(input, uv3dp.FirmwareQuirks{BottomPWMFull: true})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if !cmp.Equal(changes, []string{"bottom layer light PWM set to 255"}) {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	for n, pwm := range []uint8{255, 255, 200, 200} {
+		if output.LayerExposure(n).LightPWM != pwm {
+			t.Errorf("layer %v: expected PWM %v, got %v", n, pwm, output.LayerExposure(n).LightPWM)
+		}
+	}
+
+	if output.Bottom().LightPWM != 255 || output.Exposure().LightPWM != 200 {
+		t.Errorf("unexpected PWM %v bottom, %v normal", output.Bottom().LightPWM, output.Exposure().LightPWM)
+	}
+
+	_, changes, _ = FirmwareSafe(output, uv3dp.FirmwareQuirks{BottomPWMFull: true})
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+
+	_, _, err = FirmwareSafe(input, uv3dp.FirmwareQuirks{MaxLayers: 3})
+	if err == nil {
+		t.Errorf("expected an error for too many layers")
+	}
+}
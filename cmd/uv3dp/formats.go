@@ -0,0 +1,231 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type FormatsCommand struct {
+	*pflag.FlagSet
+}
+
+func NewFormatsCommand() (cmd *FormatsCommand) {
+	flagSet := pflag.NewFlagSet("formats", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &FormatsCommand{
+		FlagSet: flagSet,
+	}
+
+	return
+}
+
+// Run executes the 'formats' sub-command
+func (cmd *FormatsCommand) Run() (err error) {
+	switch {
+	case cmd.NArg() == 1 && cmd.Arg(0) == "list":
+		FormatsList(os.Stdout)
+	case cmd.NArg() == 2 && cmd.Arg(0) == "test":
+		var results []FormatsTestResult
+		results, err = FormatsTest(cmd.Arg(1))
+		if err != nil {
+			return
+		}
+		FormatsTestReport(os.Stdout, results)
+	default:
+		err = fmt.Errorf("formats: expected 'list' or 'test FILE' sub-command, got %v", cmd.Args())
+	}
+
+	return
+}
+
+// FormatsList shows the file format types compiled into this program,
+// and those that were excluded by build tags.
+func FormatsList(writer io.Writer) {
+	list := []string{}
+	for _, suffix := range uv3dp.Formatters() {
+		list = append(list, strings.TrimPrefix(suffix, "."))
+	}
+	sort.Strings(list)
+
+	for _, name := range list {
+		fmt.Fprintln(writer, name)
+	}
+
+	missing := uv3dp.FormattersMissing()
+	if len(missing) > 0 {
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "Not included in this build:")
+		for _, suffix := range missing {
+			fmt.Fprintf(writer, "  %s\n", strings.TrimPrefix(suffix, "."))
+		}
+	}
+}
+
+// FormatsConfidence is how likely a file is to be of a file format type
+type FormatsConfidence int
+
+const (
+	FormatsNone   = FormatsConfidence(iota) // Could not be decoded
+	FormatsLow                              // Decoded, but the print or its layers are not valid
+	FormatsMedium                           // Decoded, with warnings
+	FormatsHigh                             // Decoded, with no warnings
+)
+
+var formatsConfidenceNames = map[FormatsConfidence]string{
+	FormatsNone:   "no",
+	FormatsLow:    "low",
+	FormatsMedium: "medium",
+	FormatsHigh:   "high",
+}
+
+func (confidence FormatsConfidence) String() string {
+	name, ok := formatsConfidenceNames[confidence]
+	if !ok {
+		return fmt.Sprintf("FormatsConfidence(%d)", int(confidence))
+	}
+
+	return name
+}
+
+// FormatsTestResult is the result of decoding a file as a file format type
+type FormatsTestResult struct {
+	Type       string
+	Extension  bool // File has the extension of the type
+	Confidence FormatsConfidence
+	Size       uv3dp.Size
+	Problem    error // Why the confidence is not high
+}
+
+// formatsDecode decodes a file with a formatter, checking a few of its
+// layers, and recovering from decoders that panic on unexpected data.
+func formatsDecode(formatter uv3dp.Formatter, reader uv3dp.Reader, filesize int64) (size uv3dp.Size, valid bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	printable, err := formatter.Decode(reader, filesize)
+	if err != nil {
+		return
+	}
+
+	size = printable.Size()
+	if size.X <= 0 || size.Y <= 0 || size.Layers <= 0 ||
+		size.Millimeter.X <= 0 || size.Millimeter.Y <= 0 ||
+		size.LayerHeight <= 0 || size.LayerHeight > 1 {
+		return
+	}
+
+	for _, index := range []int{0, size.Layers / 2, size.Layers - 1} {
+		if printable.LayerImage(index).Bounds() != image.Rect(0, 0, size.X, size.Y) {
+			return
+		}
+	}
+
+	valid = true
+
+	return
+}
+
+// FormatsTest tries to decode a file with all of the file format types,
+// ordering the results from the most to the least likely type.
+func FormatsTest(filename string) (results []FormatsTestResult, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer func() { file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+
+	// Count the decoding issues of each file format type
+	var issues []error
+	uv3dp.SetDecodeWarning(func(issue error) {
+		issues = append(issues, issue)
+	})
+	defer uv3dp.SetDecodeWarning(func(issue error) {
+		TraceVerbosef(VerbosityWarning, "%v", issue)
+	})
+
+	for _, suffix := range uv3dp.Formatters() {
+		if !strings.HasPrefix(suffix, ".") {
+			continue
+		}
+
+		formatType, _ := uv3dp.FormatterSuffix(suffix)
+		var format *uv3dp.Format
+		format, err = uv3dp.NewFormatType(filename, formatType, nil)
+		if err != nil {
+			return
+		}
+
+		issues = nil
+		result := FormatsTestResult{
+			Type:      strings.TrimPrefix(suffix, "."),
+			Extension: strings.HasSuffix(strings.ToLower(filename), suffix),
+		}
+
+		var valid bool
+		reader := io.NewSectionReader(file, 0, info.Size())
+		result.Size, valid, result.Problem = formatsDecode(format.Formatter, reader, info.Size())
+		switch {
+		case result.Problem != nil:
+			result.Confidence = FormatsNone
+		case !valid:
+			result.Confidence = FormatsLow
+			result.Problem = fmt.Errorf("size or layers are not valid")
+		case len(issues) > 0:
+			result.Confidence = FormatsMedium
+			result.Problem = issues[0]
+		default:
+			result.Confidence = FormatsHigh
+		}
+
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if a.Extension != b.Extension {
+			return a.Extension
+		}
+		return a.Type < b.Type
+	})
+
+	return
+}
+
+// FormatsTestReport shows the results of FormatsTest
+func FormatsTestReport(writer io.Writer, results []FormatsTestResult) {
+	for _, result := range results {
+		line := fmt.Sprintf("%-8s %-7v", result.Type, result.Confidence)
+		if result.Confidence != FormatsNone {
+			size := result.Size
+			line += fmt.Sprintf(" %vx%v, %v layers of %v mm", size.X, size.Y, size.Layers, size.LayerHeight)
+		}
+		if result.Problem != nil {
+			line += fmt.Sprintf(" (%v)", result.Problem)
+		}
+		fmt.Fprintln(writer, strings.TrimSpace(line))
+	}
+}
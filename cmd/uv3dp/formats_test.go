@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestFormatsTest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 40, Y: 40, Layers: 2, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 20, Y: 20},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	// A CTB file, with the wrong extension
+	filename := filepath.Join(dir, "model.photon")
+	format, err := uv3dp.NewFormatType(filename, "ctb", nil)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	err = format.SetPrintable(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	results, err := FormatsTest(filename)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if len(results) < 2 {
+		t.Fatalf("expected results for all formats, got %+v", results)
+	}
+
+	if results[0].Type != "ctb" || results[0].Confidence != FormatsHigh || results[0].Size.Layers != 2 {
+		t.Errorf("expected ctb with high confidence, got %+v", results[0])
+	}
+
+	for _, result := range results[1:] {
+		if result.Confidence == FormatsHigh {
+			t.Errorf("expected only ctb with high confidence, got %+v", result)
+		}
+		if result.Type == "photon" && !result.Extension {
+			t.Errorf("expected photon to match the extension")
+		}
+	}
+}
@@ -0,0 +1,272 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+const (
+	HistorySuccess = "success"
+	HistoryFailure = "failure"
+)
+
+var HistoryPath string
+
+// HistoryRecord is the outcome of a single print, as saved in the history file
+type HistoryRecord struct {
+	Time        time.Time
+	Hash        string // Hash of the print file (SHA-256, unless another is selected)
+	File        string // Base name of the print file
+	Outcome     string // HistorySuccess or HistoryFailure
+	Resin       string `json:",omitempty"`
+	Machine     string `json:",omitempty"`
+	LayerHeight float32
+	Exposure    uv3dp.Exposure
+	Bottom      uv3dp.Bottom
+	Notes       string `json:",omitempty"`
+}
+
+// HistoryAppend appends a record to the history file, creating it if needed
+func HistoryAppend(filename string, record HistoryRecord) (err error) {
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return
+	}
+
+	writer, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer func() { writer.Close() }()
+
+	data, err := json.Marshal(&record)
+	if err != nil {
+		return
+	}
+
+	_, err = writer.Write(append(data, '\n'))
+
+	return
+}
+
+// HistoryLoad reads all of the records from a history file
+func HistoryLoad(reader io.Reader) (records []HistoryRecord, err error) {
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record HistoryRecord
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+
+		records = append(records, record)
+	}
+
+	err = scanner.Err()
+
+	return
+}
+
+// HistoryLoadFile reads all of the records from the named history file.
+// A missing file has no records.
+func HistoryLoadFile(filename string) (records []HistoryRecord, err error) {
+	reader, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	defer func() { reader.Close() }()
+
+	records, err = HistoryLoad(reader)
+	if err != nil {
+		err = fmt.Errorf("%v: %w", filename, err)
+	}
+
+	return
+}
+
+type HistoryCommand struct {
+	*pflag.FlagSet
+
+	Filename string
+	Resin    string
+	Machine  string
+	Notes    string
+}
+
+func NewHistoryCommand() (cmd *HistoryCommand) {
+	flagSet := pflag.NewFlagSet("log", pflag.ContinueOnError)
+
+	cmd = &HistoryCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Filename, "file", "f", HistoryPath, "Print history file")
+	cmd.StringVarP(&cmd.Resin, "resin", "r", "", "Resin used for the print")
+	cmd.StringVarP(&cmd.Machine, "machine", "M", "", "Machine used for the print (default is from the print file)")
+	cmd.StringVarP(&cmd.Notes, "notes", "n", "", "Notes about the print")
+
+	return
+}
+
+// Run executes the 'log' sub-command
+func (cmd *HistoryCommand) Run() (err error) {
+	if cmd.NArg() == 1 && cmd.Arg(0) == "report" {
+		var records []HistoryRecord
+		records, err = HistoryLoadFile(cmd.Filename)
+		if err != nil {
+			return
+		}
+
+		HistoryReport(os.Stdout, records)
+		return
+	}
+
+	if cmd.NArg() != 2 || (cmd.Arg(0) != HistorySuccess && cmd.Arg(0) != HistoryFailure) {
+		err = fmt.Errorf("log: expected 'success FILE', 'failure FILE', or 'report', got %v", cmd.Args())
+		return
+	}
+
+	outcome := cmd.Arg(0)
+	filename := cmd.Arg(1)
+
+	reader, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer func() { reader.Close() }()
+
+	hash := uv3dp.NewFileHash()
+	_, err = io.Copy(hash, reader)
+	if err != nil {
+		return
+	}
+
+	format, err := uv3dp.NewFormat(filename, []string{})
+	if err != nil {
+		return
+	}
+
+	printable, err := format.Printable()
+	if err != nil {
+		return
+	}
+
+	record := HistoryRecord{
+		Time:        time.Now(),
+		Hash:        uv3dp.FileHashString(hash.Sum(nil)),
+		File:        filepath.Base(filename),
+		Outcome:     outcome,
+		Resin:       cmd.Resin,
+		Machine:     cmd.Machine,
+		LayerHeight: printable.Size().LayerHeight,
+		Exposure:    printable.Exposure(),
+		Bottom:      printable.Bottom(),
+		Notes:       cmd.Notes,
+	}
+
+	if !cmd.Changed("machine") {
+		machine, ok := printable.Metadata("Machine")
+		if ok {
+			record.Machine, _ = machine.(string)
+		}
+	}
+
+	err = HistoryAppend(cmd.Filename, record)
+	if err != nil {
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "%v: %v recorded in %v", filename, outcome, cmd.Filename)
+
+	return
+}
+
+// HistoryReport summarizes the success rate of each resin and exposure
+func HistoryReport(writer io.Writer, records []HistoryRecord) {
+	type summary struct {
+		Success int
+		Failure int
+	}
+
+	byResin := map[string]map[string]*summary{}
+
+	for _, record := range records {
+		resin := record.Resin
+		if resin == "" {
+			resin = "(unknown resin)"
+		}
+
+		exposures, ok := byResin[resin]
+		if !ok {
+			exposures = map[string]*summary{}
+			byResin[resin] = exposures
+		}
+
+		exposure := fmt.Sprintf("%.3gs, bottom %.3gs @ %.3g mm",
+			record.Exposure.LightOnTime, record.Bottom.Exposure.LightOnTime, record.LayerHeight)
+		item, ok := exposures[exposure]
+		if !ok {
+			item = &summary{}
+			exposures[exposure] = item
+		}
+
+		if record.Outcome == HistorySuccess {
+			item.Success++
+		} else {
+			item.Failure++
+		}
+	}
+
+	resins := []string{}
+	for resin := range byResin {
+		resins = append(resins, resin)
+	}
+	sort.Strings(resins)
+
+	fmt.Fprintf(writer, "Prints: %d\n", len(records))
+
+	for _, resin := range resins {
+		fmt.Fprintln(writer)
+		fmt.Fprintf(writer, "%v:\n", resin)
+
+		exposures := []string{}
+		for exposure := range byResin[resin] {
+			exposures = append(exposures, exposure)
+		}
+		sort.Strings(exposures)
+
+		for _, exposure := range exposures {
+			item := byResin[resin][exposure]
+			total := item.Success + item.Failure
+			fmt.Fprintf(writer, "  %-32s %3d of %3d succeeded (%.0f%%)\n",
+				exposure, item.Success, total, float64(item.Success)*100.0/float64(total))
+		}
+	}
+}
+
+func init() {
+	HistoryPath = uv3dpPath("history.jsonl")
+}
@@ -0,0 +1,108 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "sub", "history.jsonl")
+
+	records := []HistoryRecord{
+		{Outcome: HistorySuccess, Resin: "grey", LayerHeight: 0.05, Exposure: uv3dp.Exposure{LightOnTime: 8}},
+		{Outcome: HistoryFailure, Resin: "grey", LayerHeight: 0.05, Exposure: uv3dp.Exposure{LightOnTime: 8}},
+		{Outcome: HistorySuccess, LayerHeight: 0.05, Exposure: uv3dp.Exposure{LightOnTime: 6}},
+	}
+
+	for _, record := range records {
+		err = HistoryAppend(filename, record)
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+	}
+
+	loaded, err := HistoryLoadFile(filename)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if len(loaded) != len(records) {
+		t.Fatalf("expected %v records, got %v", len(records), len(loaded))
+	}
+
+	buff := &bytes.Buffer{}
+	HistoryReport(buff, loaded)
+
+	expected := `Prints: 3
+
+(unknown resin):
+  6s, bottom 0s @ 0.05 mm            1 of   1 succeeded (100%)
+
+grey:
+  8s, bottom 0s @ 0.05 mm            1 of   2 succeeded (50%)
+`
+	if buff.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buff.String())
+	}
+
+	missing, err := HistoryLoadFile(filepath.Join(dir, "missing.jsonl"))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("expected no records, got %v (%v)", missing, err)
+	}
+}
+
+func TestHistoryAdvise(t *testing.T) {
+	exp := func(on float32) uv3dp.Exposure { return uv3dp.Exposure{LightOnTime: on} }
+
+	records := []HistoryRecord{
+		{Outcome: HistorySuccess, Resin: "grey", Machine: "e6", LayerHeight: 0.05, Exposure: exp(8)},
+		{Outcome: HistoryFailure, Resin: "grey", Machine: "e6", LayerHeight: 0.05, Exposure: exp(8)},
+		{Outcome: HistorySuccess, Resin: "grey", Machine: "e6", LayerHeight: 0.05, Exposure: exp(9)},
+		{Outcome: HistorySuccess, Resin: "grey", Machine: "e6", LayerHeight: 0.05, Exposure: exp(10)},
+		{Outcome: HistorySuccess, Resin: "grey", Machine: "e6", LayerHeight: 0.05, Exposure: exp(10)},
+		{Outcome: HistorySuccess, Resin: "grey", Machine: "e6", LayerHeight: 0.1, Exposure: exp(12)},
+		{Outcome: HistorySuccess, Resin: "clear", Machine: "e6", LayerHeight: 0.05, Exposure: exp(5)},
+		{Outcome: HistoryFailure, Resin: "grey", Machine: "mars", LayerHeight: 0.05, Exposure: exp(3)},
+	}
+
+	table := []struct {
+		Resin       string
+		Machine     string
+		LayerHeight float32
+		LightOnTime float32
+		Ok          bool
+	}{
+		{Resin: "grey", Machine: "e6", LayerHeight: 0.05, LightOnTime: 10, Ok: true},
+		{Resin: "grey", Machine: "e6", LayerHeight: 0.1, LightOnTime: 12, Ok: true},
+		{Resin: "clear", LayerHeight: 0.05, LightOnTime: 5, Ok: true},
+		{Resin: "grey", Machine: "mars", LayerHeight: 0.05},
+		{Resin: "grey", LayerHeight: 0.025},
+	}
+
+	for n, item := range table {
+		advice, ok := HistoryAdvise(records, item.Resin, item.Machine, item.LayerHeight)
+		if ok != item.Ok {
+			t.Errorf("%v: expected %v, got %v", n, item.Ok, ok)
+			continue
+		}
+
+		if ok && advice.Exposure.LightOnTime != item.LightOnTime {
+			t.Errorf("%v: expected %v, got %+v", n, item.LightOnTime, advice)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"runtime"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+	"github.com/nicarran/uv3dp/view"
+)
+
+type HollowCommand struct {
+	*pflag.FlagSet
+
+	Wall        int
+	Layers      int
+	Infill      int
+	InfillWidth int
+}
+
+func NewHollowCommand() (cmd *HollowCommand) {
+	flagSet := pflag.NewFlagSet("hollow", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &HollowCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.IntVarP(&cmd.Wall, "wall", "w", 40, "Thickness of the walls, in pixels")
+	cmd.IntVarP(&cmd.Layers, "layers", "l", 0, "Thickness of the floors and ceilings, in layers (0 to match the wall thickness)")
+	cmd.IntVarP(&cmd.Infill, "infill", "i", 0, "Spacing of the infill grid, in pixels (0 for no infill)")
+	cmd.IntVarP(&cmd.InfillWidth, "infill-width", "W", 4, "Width of the infill grid lines, in pixels")
+
+	return
+}
+
+// hollowModifier removes the interior of solid areas, where an
+// interior is solid in all of the layers within the floor and ceiling
+// thickness, and is farther from an edge than the wall thickness.
+type hollowModifier struct {
+	uv3dp.Printable
+
+	wall        int
+	layers      int
+	infill      int
+	infillWidth int
+
+	source *view.Viewer // Decoded layers of the printable
+}
+
+// interior returns the pixels that are solid from the layer below
+// to the layer above, eroded by the wall thickness. Layers outside of
+// the print are empty, so that the first and last layers are kept.
+func (hm *hollowModifier) interior(rect image.Rectangle, below, above int) (interior *image.Gray) {
+	if below < 0 || above >= hm.Size().Layers {
+		return image.NewGray(rect)
+	}
+
+	solid := image.NewGray(rect)
+	copy(solid.Pix, hm.source.Layer(below).(*image.Gray).Pix)
+
+	for index := below + 1; index <= above; index++ {
+		for n, pix := range hm.source.Layer(index).(*image.Gray).Pix {
+			if pix < solid.Pix[n] {
+				solid.Pix[n] = pix
+			}
+		}
+	}
+
+	interior = uv3dp.MorphImage(solid, uv3dp.MorphErode, hm.wall)
+
+	return
+}
+
+// isInfill returns true if a pixel is on the infill grid
+func (hm *hollowModifier) isInfill(x, y int) bool {
+	if hm.infill <= 0 {
+		return false
+	}
+
+	return x%hm.infill < hm.infillWidth || y%hm.infill < hm.infillWidth
+}
+
+func (hm *hollowModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := hm.source.Layer(index).(*image.Gray)
+	interior := hm.interior(srcImage.Bounds(), index-hm.layers, index+hm.layers)
+
+	grayImage = image.NewGray(srcImage.Bounds())
+	copy(grayImage.Pix, srcImage.Pix)
+
+	rect := grayImage.Bounds()
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			n := y*grayImage.Stride + x
+			if interior.Pix[n] != 0 && !hm.isInfill(x, y) {
+				grayImage.Pix[n] = 0
+			}
+		}
+	}
+
+	return
+}
+
+func (cmd *HollowCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Wall < 1 {
+		err = fmt.Errorf("hollow: --wall must be at least 1")
+		return
+	}
+
+	if cmd.Layers < 0 {
+		err = fmt.Errorf("hollow: --layers must not be negative")
+		return
+	}
+
+	if cmd.Infill < 0 || (cmd.Infill > 0 && (cmd.InfillWidth < 1 || cmd.InfillWidth >= cmd.Infill)) {
+		err = fmt.Errorf("hollow: --infill-width must be at least 1, and less than --infill")
+		return
+	}
+
+	layers := cmd.Layers
+	if layers == 0 {
+		// Match the wall thickness, in millimeters
+		size := input.Size()
+		if size.X > 0 && size.LayerHeight > 0 {
+			pixel := float64(size.Millimeter.X) / float64(size.X)
+			layers = int(math.Ceil(float64(cmd.Wall) * pixel / float64(size.LayerHeight)))
+		}
+		if layers < 1 {
+			layers = 1
+		}
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Hollowing with a %v pixel wall, and %v layer floors and ceilings", cmd.Wall, layers)
+	if cmd.Infill > 0 {
+		TraceVerbosef(VerbosityNotice, "  Infill grid every %v pixels, %v pixels wide", cmd.Infill, cmd.InfillWidth)
+	}
+
+	output = &hollowModifier{
+		Printable:   input,
+		wall:        cmd.Wall,
+		layers:      layers,
+		infill:      cmd.Infill,
+		infillWidth: cmd.InfillWidth,
+
+		// Keep the decoded layers of the floor to ceiling window of
+		// each of the layers being hollowed in parallel
+		source: view.NewViewer(input, 2*layers+1+runtime.GOMAXPROCS(0)),
+	}
+
+	return
+}
@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestHollow(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 40, Y: 40, Layers: 5, LayerHeight: 0.05},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	cmd := NewHollowCommand()
+	err := cmd.Parse([]string{"--wall", "4", "--layers", "1", "--infill", "8", "--infill-width", "2"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	table := []struct {
+		Layer int
+		X, Y  int
+		Pix   uint8
+	}{
+		{Layer: 0, X: 21, Y: 21, Pix: 0xff}, // Floor
+		{Layer: 4, X: 21, Y: 21, Pix: 0xff}, // Ceiling
+		{Layer: 2, X: 21, Y: 21, Pix: 0x00}, // Hollowed
+		{Layer: 2, X: 16, Y: 21, Pix: 0xff}, // Infill
+		{Layer: 2, X: 14, Y: 21, Pix: 0x00}, // Just inside the wall
+		{Layer: 2, X: 13, Y: 21, Pix: 0xff}, // Wall
+		{Layer: 2, X: 5, Y: 21, Pix: 0x00},  // Outside
+	}
+
+	for n, item := range table {
+		pix := output.LayerImage(item.Layer).GrayAt(item.X, item.Y).Y
+		if pix != item.Pix {
+			t.Errorf("%v: layer %v (%v,%v): expected %#x, got %#x", n, item.Layer, item.X, item.Y, item.Pix, pix)
+		}
+	}
+
+	cmd = NewHollowCommand()
+	cmd.Parse([]string{"--infill", "4", "--infill-width", "4"})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for an infill width of the infill spacing")
+	}
+}
+
+// decodeCountPrint counts the layer images decoded
+type decodeCountPrint struct {
+	scalePrint
+	decoded int
+}
+
+func (dp *decodeCountPrint) LayerImage(index int) *image.Gray {
+	dp.decoded++
+	return dp.scalePrint.LayerImage(index)
+}
+
+func TestHollowDecodes(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 40, Y: 40, Layers: 20, LayerHeight: 0.05},
+	}
+
+	input := &decodeCountPrint{
+		scalePrint: scalePrint{
+			Print: uv3dp.Print{Properties: prop},
+			model: image.Rect(10, 10, 30, 30),
+		},
+	}
+
+	cmd := NewHollowCommand()
+	cmd.Parse([]string{"--wall", "4", "--layers", "3"})
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	for n := 0; n < prop.Size.Layers; n++ {
+		output.LayerImage(n)
+	}
+
+	if input.decoded != prop.Size.Layers {
+		t.Errorf("expected %v layers decoded, got %v", prop.Size.Layers, input.decoded)
+	}
+}
@@ -0,0 +1,399 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type ImportCommand struct {
+	*pflag.FlagSet
+
+	MachineFile string
+	ResinFile   string
+}
+
+func NewImportCommand() (cmd *ImportCommand) {
+	flagSet := pflag.NewFlagSet("import", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &ImportCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.MachineFile, "machine-file", "m", MachinesConfigPath, "User machines file to add the imported machines to")
+	cmd.StringVarP(&cmd.ResinFile, "resin-file", "r", ResinUserPath, "User resins file to add the imported resins to")
+
+	return
+}
+
+// importName returns a machine or resin name for a profile name, as a
+// machine name can not contain spaces or '@'
+func importName(name string, machine bool) string {
+	name = strings.TrimSpace(name)
+	if machine {
+		name = strings.ToLower(strings.Join(strings.Fields(name), "-"))
+		name = strings.ReplaceAll(name, "@", "-")
+	}
+
+	return name
+}
+
+// importFloat parses a profile value as a float, or 0 if it is not one
+func importFloat(value string) float32 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(value), 32)
+	return float32(f)
+}
+
+// importNote returns the value of a 'KEY_VALUE' item of UVtools' notes,
+// or an empty string if there is no such item
+func importNote(notes string, key string) string {
+	for _, item := range strings.Fields(strings.ReplaceAll(notes, `\n`, " ")) {
+		if strings.HasPrefix(item, key+"_") {
+			return strings.TrimPrefix(item, key+"_")
+		}
+	}
+
+	return ""
+}
+
+// importPrusaSlicerPrinter converts a PrusaSlicer printer profile, as used
+// by UVtools, into a machine entry
+func importPrusaSlicerPrinter(keys map[string]string) (entry uv3dp.MachineEntry) {
+	notes := keys["printer_notes"]
+
+	entry.Model = keys["printer_model"]
+	entry.Vendor = keys["printer_vendor"]
+	if vendor := importNote(notes, "PRINTER_VENDOR"); vendor != "" {
+		entry.Vendor = vendor
+	}
+
+	entry.Size.X = int(importFloat(keys["display_pixels_x"]))
+	entry.Size.Y = int(importFloat(keys["display_pixels_y"]))
+	entry.Size.Xmm = importFloat(keys["display_width"])
+	entry.Size.Ymm = importFloat(keys["display_height"])
+
+	// Portrait displays have their long side along the Y axis of the files
+	if keys["display_orientation"] == "portrait" {
+		entry.Size.X, entry.Size.Y = entry.Size.Y, entry.Size.X
+		entry.Size.Xmm, entry.Size.Ymm = entry.Size.Ymm, entry.Size.Xmm
+	}
+
+	entry.Height = importFloat(keys["max_print_height"])
+
+	// PrusaSlicer's own file format, unless UVtools' notes select another
+	entry.Format = "sl1"
+	if format := importNote(notes, "FILEFORMAT"); format != "" {
+		entry.Format = strings.ToLower(format)
+	}
+
+	return
+}
+
+// importPrusaSlicerMaterial converts a PrusaSlicer material profile, with
+// the lift and bottom settings in UVtools' notes, into a resin entry
+func importPrusaSlicerMaterial(keys map[string]string) (entry ResinEntry) {
+	notes := keys["material_notes"]
+
+	entry.Exposure.LightOnTime = importFloat(keys["exposure_time"])
+	entry.Exposure.LightOffTime = importFloat(importNote(notes, "LightOffDelay"))
+	entry.Exposure.LiftHeight = importFloat(importNote(notes, "LiftHeight"))
+	entry.Exposure.LiftSpeed = importFloat(importNote(notes, "LiftSpeed"))
+	entry.Exposure.RetractSpeed = importFloat(importNote(notes, "RetractSpeed"))
+	entry.Exposure.LightPWM = uint8(importFloat(importNote(notes, "LightPWM")))
+
+	entry.Bottom.Count = int(importFloat(importNote(notes, "BottomLayerCount")))
+	entry.Bottom.LightOnTime = importFloat(keys["initial_exposure_time"])
+	entry.Bottom.LightOffTime = importFloat(importNote(notes, "BottomLightOffDelay"))
+	entry.Bottom.LiftHeight = importFloat(importNote(notes, "BottomLiftHeight"))
+	entry.Bottom.LiftSpeed = importFloat(importNote(notes, "BottomLiftSpeed"))
+	entry.Bottom.RetractSpeed = entry.Exposure.RetractSpeed
+	entry.Bottom.LightPWM = uint8(importFloat(importNote(notes, "BottomLightPWM")))
+
+	entry.Density = importFloat(keys["material_density"])
+
+	// Bottle cost and volume (in ml), as a price per liter
+	volume := importFloat(keys["bottle_volume"])
+	if volume > 0 {
+		entry.Price = importFloat(keys["bottle_cost"]) * 1000 / volume
+	}
+
+	return
+}
+
+// ImportPrusaSlicer reads the printer and material profiles of a
+// PrusaSlicer or UVtools '.ini' file. Bundles have a '[printer:NAME]' or
+// '[sla_material:NAME]' section for each profile; single profiles are
+// named after their file.
+func ImportPrusaSlicer(filename string, data []byte) (machines map[string]uv3dp.MachineEntry, resins map[string]ResinEntry, err error) {
+	machines = map[string]uv3dp.MachineEntry{}
+	resins = map[string]ResinEntry{}
+
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	section := ""
+	keys := map[string]string{}
+
+	flush := func() {
+		// Bundles also have FFF printers, and print settings, to skip
+		switch {
+		case (section == "printer" || section == "") && keys["display_pixels_x"] != "":
+			machines[importName(name, true)] = importPrusaSlicerPrinter(keys)
+		case (section == "sla_material" || section == "") && keys["exposure_time"] != "":
+			resins[importName(name, false)] = importPrusaSlicerMaterial(keys)
+		}
+		keys = map[string]string{}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			flush()
+			header := strings.SplitN(line[1:len(line)-1], ":", 2)
+			section = header[0]
+			if len(header) == 2 {
+				name = header[1]
+			}
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) == 2 {
+			keys[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	flush()
+
+	err = scanner.Err()
+
+	return
+}
+
+// ImportChitubox reads the machine and resin profiles of a ChiTuBox '.cfg'
+// file, which has '@@NAME@@ATTRIBUTE:VALUE' lines. Profiles with a
+// resolution are machines, and profiles with an exposure time are resins.
+func ImportChitubox(filename string, data []byte) (machines map[string]uv3dp.MachineEntry, resins map[string]ResinEntry, err error) {
+	machines = map[string]uv3dp.MachineEntry{}
+	resins = map[string]ResinEntry{}
+
+	profiles := map[string]map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "@@") {
+			continue
+		}
+
+		l := strings.SplitN(line[2:], "@@", 2)
+		if len(l) != 2 || l[0] == "" {
+			continue
+		}
+
+		av := strings.SplitN(l[1], ":", 2)
+		if len(av) != 2 {
+			continue
+		}
+
+		attrs, ok := profiles[l[0]]
+		if !ok {
+			attrs = map[string]string{}
+			profiles[l[0]] = attrs
+		}
+		attrs[av[0]] = av[1]
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return
+	}
+
+	for name, attrs := range profiles {
+		if attrs["resolutionX"] != "" {
+			entry := uv3dp.MachineEntry{Format: "ctb"}
+			entry.Model = name
+			entry.Size.X = int(importFloat(attrs["resolutionX"]))
+			entry.Size.Y = int(importFloat(attrs["resolutionY"]))
+			entry.Size.Xmm = importFloat(attrs["machineX"])
+			entry.Size.Ymm = importFloat(attrs["machineY"])
+			entry.Height = importFloat(attrs["machineZ"])
+			machines[importName(name, true)] = entry
+		}
+
+		if attrs["normalExposureTime"] != "" {
+			resin := &Resin{}
+			for attr, val := range attrs {
+				chituboxResinSet(resin, attr, val)
+			}
+			resins[importName(name, false)] = ResinEntry{
+				Exposure: resin.Exposure,
+				Bottom:   resin.Bottom,
+				Density:  resin.Density,
+				Price:    resin.Price,
+			}
+		}
+	}
+
+	return
+}
+
+// ImportProfiles reads the machine and resin profiles of a slicer's
+// profile file, by its extension
+func ImportProfiles(filename string) (machines map[string]uv3dp.MachineEntry, resins map[string]ResinEntry, err error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ini":
+		machines, resins, err = ImportPrusaSlicer(filename, data)
+	case ".cfg":
+		machines, resins, err = ImportChitubox(filename, data)
+	default:
+		err = fmt.Errorf("%v: not a UVtools or PrusaSlicer '.ini', or ChiTuBox '.cfg' profile", filename)
+	}
+
+	if err == nil && len(machines) == 0 && len(resins) == 0 {
+		err = fmt.Errorf("%v: no machine or resin profiles found", filename)
+	}
+
+	return
+}
+
+// ImportSaveMachines adds machine entries to a user machines file
+func ImportSaveMachines(filename string, machines map[string]uv3dp.MachineEntry) (err error) {
+	saved := map[string]uv3dp.MachineEntry{}
+
+	data, err := ioutil.ReadFile(filename)
+	if err == nil {
+		err = json.Unmarshal(data, &saved)
+		if err != nil {
+			err = fmt.Errorf("%v: %w", filename, err)
+			return
+		}
+	} else if !os.IsNotExist(err) {
+		return
+	}
+
+	for name, entry := range machines {
+		saved[name] = entry
+	}
+
+	data, err = json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return
+	}
+
+	// Check the machines, and register them
+	err = uv3dp.LoadMachines(bytes.NewReader(data), filename)
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return
+	}
+
+	// Replace the file in one step, so that an interrupted save
+	// does not lose the machines.
+	tmpname := filename + ".tmp"
+	err = ioutil.WriteFile(tmpname, append(data, '\n'), 0644)
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(tmpname, filename)
+
+	return
+}
+
+// Run executes the 'import' command
+func (cmd *ImportCommand) Run() (err error) {
+	if cmd.NArg() == 0 {
+		err = fmt.Errorf("import: expected one or more profile files")
+		return
+	}
+
+	machines := map[string]uv3dp.MachineEntry{}
+	resins := map[string]ResinEntry{}
+
+	for _, filename := range cmd.Args() {
+		var fileMachines map[string]uv3dp.MachineEntry
+		var fileResins map[string]ResinEntry
+		fileMachines, fileResins, err = ImportProfiles(filename)
+		if err != nil {
+			err = fmt.Errorf("import: %w", err)
+			return
+		}
+
+		for name, entry := range fileMachines {
+			machines[name] = entry
+		}
+		for name, entry := range fileResins {
+			resins[name] = entry
+		}
+	}
+
+	if len(machines) > 0 {
+		err = ImportSaveMachines(cmd.MachineFile, machines)
+		if err != nil {
+			err = fmt.Errorf("import: %w", err)
+			return
+		}
+	}
+
+	if len(resins) > 0 {
+		var saved map[string]ResinEntry
+		saved, err = ResinLoad(cmd.ResinFile)
+		if err == nil {
+			err = ResinRegister(resins, cmd.ResinFile)
+		}
+		if err == nil {
+			for name, entry := range resins {
+				saved[name] = entry
+			}
+			err = ResinSave(cmd.ResinFile, saved)
+		}
+		if err != nil {
+			err = fmt.Errorf("import: %w", err)
+			return
+		}
+	}
+
+	names := []string{}
+	for name := range machines {
+		names = append(names, "machine "+name)
+	}
+	for name := range resins {
+		names = append(names, "resin "+name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Println("Imported", name)
+	}
+
+	return
+}
@@ -0,0 +1,207 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+const importBundleINI = `# PrusaSlicer config bundle
+[printer:Original Prusa i3 MK3S]
+printer_technology = FFF
+
+[printer:EPAX E6 Mono]
+printer_technology = SLA
+display_pixels_x = 2560
+display_pixels_y = 1620
+display_width = 128
+display_height = 81
+display_orientation = portrait
+max_print_height = 155
+printer_model = E6 Mono
+printer_notes = Do not remove the keywords below!\nPRINTER_VENDOR_EPAX\nPRINTER_MODEL_E6\nFILEFORMAT_CTB
+
+[sla_material:Test Resin @E6]
+exposure_time = 2.5
+initial_exposure_time = 30
+material_density = 1.1
+bottle_cost = 30
+bottle_volume = 500
+material_notes = LiftHeight_6\nLiftSpeed_80\nRetractSpeed_150\nBottomLayerCount_4\nBottomLiftHeight_8\nBottomLiftSpeed_40
+`
+
+const importChituboxCFG = `@@Test Printer@@resolutionX:1440
+@@Test Printer@@resolutionY:2560
+@@Test Printer@@machineX:68.04
+@@Test Printer@@machineY:120.96
+@@Test Printer@@machineZ:150
+@@Test Resin@@normalExposureTime:6
+@@Test Resin@@normalLayerLiftSpeed:65
+@@Test Resin@@bottomLayerCount:5
+@@Test Resin@@bottomLayExposureTime:40
+@@Test Resin@@resinDensity:1.05
+`
+
+func TestImportProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	table := map[string]struct {
+		content  string
+		machines map[string]uv3dp.MachineEntry
+		resins   map[string]ResinEntry
+	}{
+		"bundle.ini": {
+			content: importBundleINI,
+			machines: map[string]uv3dp.MachineEntry{
+				"epax-e6-mono": {
+					Machine: uv3dp.Machine{
+						Vendor: "EPAX",
+						Model:  "E6 Mono",
+						Size:   uv3dp.MachineSize{X: 1620, Y: 2560, Xmm: 81, Ymm: 128},
+						Height: 155,
+					},
+					Format: "ctb",
+				},
+			},
+			resins: map[string]ResinEntry{
+				"Test Resin @E6": {
+					Exposure: uv3dp.Exposure{LightOnTime: 2.5, LiftHeight: 6, LiftSpeed: 80, RetractSpeed: 150},
+					Bottom: uv3dp.Bottom{
+						Count:    4,
+						Exposure: uv3dp.Exposure{LightOnTime: 30, LiftHeight: 8, LiftSpeed: 40, RetractSpeed: 150},
+					},
+					Density: 1.1,
+					Price:   60,
+				},
+			},
+		},
+		"Test Material.ini": {
+			content:  "exposure_time = 3\ninitial_exposure_time = 20\n",
+			machines: map[string]uv3dp.MachineEntry{},
+			resins: map[string]ResinEntry{
+				"Test Material": {
+					Exposure: uv3dp.Exposure{LightOnTime: 3},
+					Bottom:   uv3dp.Bottom{Exposure: uv3dp.Exposure{LightOnTime: 20}},
+				},
+			},
+		},
+		"0.cfg": {
+			content: importChituboxCFG,
+			machines: map[string]uv3dp.MachineEntry{
+				"test-printer": {
+					Machine: uv3dp.Machine{
+						Model:  "Test Printer",
+						Size:   uv3dp.MachineSize{X: 1440, Y: 2560, Xmm: 68.04, Ymm: 120.96},
+						Height: 150,
+					},
+					Format: "ctb",
+				},
+			},
+			resins: map[string]ResinEntry{
+				"Test Resin": {
+					Exposure: uv3dp.Exposure{LightOnTime: 6, LiftSpeed: 65},
+					Bottom:   uv3dp.Bottom{Count: 5, Exposure: uv3dp.Exposure{LightOnTime: 40}},
+					Density:  1.05,
+				},
+			},
+		},
+	}
+
+	for name, item := range table {
+		filename := filepath.Join(dir, name)
+		err = ioutil.WriteFile(filename, []byte(item.content), 0644)
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+
+		machines, resins, err := ImportProfiles(filename)
+		if err != nil {
+			t.Errorf("%v: expected nil, got %v", name, err)
+			continue
+		}
+
+		if !cmp.Equal(item.machines, machines) {
+			t.Errorf("%v: expected %+v, got %+v", name, item.machines, machines)
+		}
+
+		if !cmp.Equal(item.resins, resins) {
+			t.Errorf("%v: expected %+v, got %+v", name, item.resins, resins)
+		}
+	}
+
+	for _, name := range []string{"empty.ini", "profile.lys"} {
+		filename := filepath.Join(dir, name)
+		ioutil.WriteFile(filename, []byte("# Nothing\n"), 0644)
+		_, _, err = ImportProfiles(filename)
+		if err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	profile := filepath.Join(dir, "0.cfg")
+	err = ioutil.WriteFile(profile, []byte(importChituboxCFG), 0644)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	machineFile := filepath.Join(dir, "machines.json")
+	resinFile := filepath.Join(dir, "resins.json")
+
+	cmd := NewImportCommand()
+	err = cmd.Parse([]string{"--machine-file", machineFile, "--resin-file", resinFile, profile})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	err = cmd.Run()
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	machine, err := uv3dp.LookupMachine("test-printer")
+	if err != nil || machine.Origin != machineFile || machine.Extension != ".ctb" {
+		t.Errorf("expected the machine to be registered from %v, got %+v (%v)", machineFile, machine, err)
+	}
+
+	_, ok := ResinMap["Test Resin"]
+	if !ok {
+		t.Errorf("expected the resin to be registered")
+	}
+
+	resins, err := ResinLoad(resinFile)
+	if err != nil || len(resins) != 1 {
+		t.Errorf("expected the resin to be saved, got %+v (%v)", resins, err)
+	}
+
+	reader, err := os.Open(machineFile)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer reader.Close()
+
+	err = uv3dp.LoadMachines(reader, machineFile)
+	if err != nil {
+		t.Errorf("expected the machines file to load, got %v", err)
+	}
+}
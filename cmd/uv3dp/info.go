@@ -5,8 +5,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"image"
+	"io"
+	"os"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -19,7 +24,14 @@ type InfoCommand struct {
 
 	SizeSummary     bool
 	LayerDetail     bool
+	LayerStats      bool
+	Histogram       bool
 	ExposureSummary bool
+	ResinUsage      bool
+	ResinName       string
+	Density         float32
+	Price           float32
+	JSON            bool
 }
 
 func NewInfoCommand() (info *InfoCommand) {
@@ -33,6 +45,13 @@ func NewInfoCommand() (info *InfoCommand) {
 	info.BoolVarP(&info.SizeSummary, "size", "s", true, "Show size summary")
 	info.BoolVarP(&info.ExposureSummary, "exposure", "e", true, "Show summary of the exposure settings")
 	info.BoolVarP(&info.LayerDetail, "layer", "l", false, "Show layer detail")
+	info.BoolVarP(&info.Histogram, "histogram", "H", false, "Show the use of each gray level by all layers (reads all layers)")
+	info.BoolVarP(&info.LayerStats, "layers", "L", false, "Show the Z, exposure, lit area, largest island, and bounds of each layer (reads all layers)")
+	info.BoolVarP(&info.ResinUsage, "usage", "u", false, "Show the estimated volume, mass, and cost of the resin used (reads all layers)")
+	info.StringVarP(&info.ResinName, "resin", "r", "", "Resin type, for the density and price of the resin [see 'Known resins' in help]")
+	info.Float32VarP(&info.Density, "density", "D", defaultResinDensity, "Resin density, in g/ml")
+	info.Float32VarP(&info.Price, "price", "P", 0, "Resin price, per liter")
+	info.BoolVarP(&info.JSON, "json", "j", false, "Show the information as JSON, including the --layers, --histogram, and --usage if selected")
 
 	return
 }
@@ -43,30 +62,324 @@ func printExposure(mode string, exp *uv3dp.Exposure) {
 	if exp.LightPWM != 255 {
 		fmt.Printf(", PWM %v", exp.LightPWM)
 	}
-	fmt.Printf("  Lift: %v mm, %v mm/min\n",
-		exp.LiftHeight, exp.LiftSpeed)
-	fmt.Printf("  Retract: %v mm, %v mm/min\n",
-		exp.RetractHeight, exp.RetractSpeed)
+	fmt.Printf("  Lift: %v mm, %v mm/min", exp.LiftHeight, exp.LiftSpeed)
+	if exp.LiftHeight2 != 0 {
+		fmt.Printf(", then %v mm, %v mm/min", exp.LiftHeight2, exp.LiftSpeed2)
+	}
+	fmt.Printf("\n")
+	fmt.Printf("  Retract: %v mm, %v mm/min", exp.RetractHeight, exp.RetractSpeed)
+	if exp.RetractHeight2 != 0 {
+		fmt.Printf(", then %v mm, %v mm/min", exp.RetractHeight2, exp.RetractSpeed2)
+	}
+	fmt.Printf("\n")
+	if exp.WaitBeforeCure != 0 || exp.WaitAfterCure != 0 || exp.WaitAfterLift != 0 {
+		fmt.Printf("  Wait: %vs before cure, %vs after cure, %vs after lift\n",
+			exp.WaitBeforeCure, exp.WaitAfterCure, exp.WaitAfterLift)
+	}
+}
+
+func printPhaseTime(mode string, pt *uv3dp.PhaseTime) {
+	wait := ""
+	if pt.Wait > 0 {
+		wait = fmt.Sprintf(", %v wait", pt.Wait.Truncate(time.Second))
+	}
+
+	fmt.Printf("%v: %v (%v exposure, %v light off, %v lift, %v retract%v)\n", mode,
+		pt.Total().Truncate(time.Second), pt.LightOn.Truncate(time.Second), pt.LightOff.Truncate(time.Second),
+		pt.Lift.Truncate(time.Second), pt.Retract.Truncate(time.Second), wait)
+}
+
+// InfoLayer is the statistics of a layer
+type InfoLayer struct {
+	Layer    int
+	Z        float32
+	Exposure uv3dp.Exposure
+	Pixels   int             // Lit pixels
+	Area     float32         // Lit area, in square millimeters
+	Largest  int             // Area of the largest island, in pixels
+	Bounds   image.Rectangle // Bounds of the lit pixels
+}
+
+// InfoLayerStats returns the statistics of all of the layers
+func InfoLayerStats(input uv3dp.Printable) (stats []InfoLayer) {
+	size := input.Size()
+
+	pixelArea := float32(0)
+	if size.X > 0 && size.Y > 0 {
+		pixelArea = size.Millimeter.X / float32(size.X) * size.Millimeter.Y / float32(size.Y)
+	}
+
+	stats = make([]InfoLayer, size.Layers)
+	uv3dp.WithAllLayers(input, func(p uv3dp.Printable, n int) {
+		layer := p.LayerImage(n)
+
+		item := InfoLayer{
+			Layer:    n,
+			Z:        p.LayerZ(n),
+			Exposure: p.LayerExposure(n),
+			Pixels:   uv3dp.LitPixels(layer),
+			Bounds:   uv3dp.ImageBounds(layer),
+		}
+		item.Area = float32(item.Pixels) * pixelArea
+
+		// With nothing below it, every connected area is an island
+		for _, island := range uv3dp.LayerIslands(image.NewGray(layer.Bounds()), layer) {
+			if island.Area > item.Largest {
+				item.Largest = island.Area
+			}
+		}
+
+		stats[n] = item
+	})
+
+	return
+}
+
+// InfoLayerReport shows the statistics of each layer
+func InfoLayerReport(writer io.Writer, size uv3dp.Size, stats []InfoLayer) {
+	pixelArea := float32(0)
+	if size.X > 0 && size.Y > 0 {
+		pixelArea = size.Millimeter.X / float32(size.X) * size.Millimeter.Y / float32(size.Y)
+	}
+
+	fmt.Fprintf(writer, "%6s %8s %8s %10s %10s %12s %v\n", "Layer", "Z", "Exposure", "Pixels", "Area mm^2", "Island mm^2", "Bounds")
+	for _, item := range stats {
+		fmt.Fprintf(writer, "%6d %8.3f %8.3g %10d %10.2f %12.2f %v\n",
+			item.Layer, item.Z, item.Exposure.LightOnTime, item.Pixels, item.Area, float32(item.Largest)*pixelArea, item.Bounds)
+	}
+}
+
+// InfoHistogram returns the number of pixels of all layers at each gray level
+func InfoHistogram(input uv3dp.Printable) (histogram [256]int64) {
+	var mutex sync.Mutex
+
+	uv3dp.WithAllLayers(input, func(p uv3dp.Printable, n int) {
+		var layer [256]int64
+		for _, pix := range p.LayerImage(n).Pix {
+			layer[pix]++
+		}
+
+		mutex.Lock()
+		for level, count := range layer {
+			histogram[level] += count
+		}
+		mutex.Unlock()
+	})
+
+	return
+}
+
+// InfoHistogramReport shows the use of each gray level of lit pixels, and
+// whether the layers are anti-aliased.
+func InfoHistogramReport(writer io.Writer, histogram [256]int64) {
+	lit := int64(0)
+	for level := 1; level < 256; level++ {
+		lit += histogram[level]
+	}
+
+	levels := 0
+	for level := 1; level < 256; level++ {
+		count := histogram[level]
+		if count == 0 {
+			continue
+		}
+		levels++
+		fmt.Fprintf(writer, "%5d: %12d pixels (%.2f%%)\n", level, count, 100*float64(count)/float64(lit))
+	}
+
+	partial := lit - histogram[255]
+	percent := float64(0)
+	if lit > 0 {
+		percent = 100 * float64(partial) / float64(lit)
+	}
+
+	fmt.Fprintf(writer, "Gray levels: %v used, %v lit pixels, %.2f%% partially lit\n", levels, lit, percent)
+	if lit > 0 && partial == 0 {
+		fmt.Fprintf(writer, "Anti-aliasing: none (all pixels are fully lit or off)\n")
+	}
+}
+
+// InfoResin is the estimated resin use of a printable
+type InfoResin struct {
+	Volume float32 // In milliliters
+	Mass   float32 // In grams
+	Cost   float32 `json:",omitempty"` // In the currency of the price per liter
+}
+
+// InfoTime is the estimated time of each phase of a print, in seconds
+type InfoTime struct {
+	Total    float64
+	LightOn  float64
+	LightOff float64
+	Lift     float64
+	Retract  float64
+	Wait     float64 `json:",omitempty"`
+}
+
+func newInfoTime(pt uv3dp.PhaseTime) InfoTime {
+	return InfoTime{
+		Total:    pt.Total().Seconds(),
+		LightOn:  pt.LightOn.Seconds(),
+		LightOff: pt.LightOff.Seconds(),
+		Lift:     pt.Lift.Seconds(),
+		Retract:  pt.Retract.Seconds(),
+		Wait:     pt.Wait.Seconds(),
+	}
+}
+
+// InfoPreview is the size of a preview image
+type InfoPreview struct {
+	Width, Height int
+}
+
+// InfoJSON is the information about a printable, as shown by 'info --json'
+type InfoJSON struct {
+	Size        uv3dp.Size
+	Exposure    uv3dp.Exposure
+	Bottom      uv3dp.Bottom
+	Previews    map[string]InfoPreview `json:",omitempty"`
+	Metadata    map[string]interface{} `json:",omitempty"`
+	Annotations uv3dp.Annotations      `json:",omitempty"`
+	Time        InfoTime
+	BottomTime  InfoTime
+	Resin       *InfoResin  `json:",omitempty"`
+	Histogram   []int64     `json:",omitempty"` // Pixels at each gray level
+	Layers      []InfoLayer `json:",omitempty"`
+}
+
+// resinSelected returns true if the resin use should be shown
+func (info *InfoCommand) resinSelected() bool {
+	return info.ResinUsage || info.Changed("resin") || info.Changed("density") || info.Changed("price")
+}
+
+// resinUsage estimates the resin used by a printable, with the density
+// and price of the selected resin
+func (info *InfoCommand) resinUsage(input uv3dp.Printable) (usage InfoResin, err error) {
+	density := info.Density
+	price := info.Price
+	if info.Changed("resin") {
+		resin, ok := ResinMap[info.ResinName]
+		if !ok {
+			err = fmt.Errorf("info: unknown resin name \"%v\"", info.ResinName)
+			return
+		}
+		if resin.Density > 0 && !info.Changed("density") {
+			density = resin.Density
+		}
+		if resin.Price > 0 && !info.Changed("price") {
+			price = resin.Price
+		}
+	}
+
+	usage.Volume = uv3dp.PrintVolume(input)
+	usage.Mass = usage.Volume * density
+	usage.Cost = usage.Volume * price / 1000
+
+	return
+}
+
+// InfoJSONWrite writes the information about a printable as JSON
+func (info *InfoCommand) InfoJSONWrite(writer io.Writer, input uv3dp.Printable) (err error) {
+	all, bottom := uv3dp.EstimatePrintTime(input)
+
+	data := &InfoJSON{
+		Size:        input.Size(),
+		Exposure:    input.Exposure(),
+		Bottom:      input.Bottom(),
+		Previews:    map[string]InfoPreview{},
+		Metadata:    map[string]interface{}{},
+		Annotations: uv3dp.LayerAnnotations(input),
+		Time:        newInfoTime(all),
+		BottomTime:  newInfoTime(bottom),
+	}
+
+	previews := map[uv3dp.PreviewType]string{
+		uv3dp.PreviewTypeTiny: "tiny",
+		uv3dp.PreviewTypeHuge: "huge",
+	}
+
+	for code, name := range previews {
+		pic, ok := input.Preview(code)
+		if ok {
+			bounds := pic.Bounds()
+			data.Previews[name] = InfoPreview{Width: bounds.Dx(), Height: bounds.Dy()}
+		}
+	}
+
+	for _, key := range input.MetadataKeys() {
+		if key == uv3dp.AnnotationsMetadata {
+			continue
+		}
+		data.Metadata[key], _ = input.Metadata(key)
+	}
+
+	if info.resinSelected() {
+		var resin InfoResin
+		resin, err = info.resinUsage(input)
+		if err != nil {
+			return
+		}
+		data.Resin = &resin
+	}
+
+	if info.Histogram {
+		histogram := InfoHistogram(input)
+		data.Histogram = histogram[:]
+	}
+
+	if info.LayerStats {
+		data.Layers = InfoLayerStats(input)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(data)
+	if err != nil {
+		err = fmt.Errorf("info: %w", err)
+	}
+
+	return
 }
 
 func (info *InfoCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if info.JSON {
+		err = info.InfoJSONWrite(os.Stdout, input)
+		if err == nil {
+			output = input
+		}
+		return
+	}
+
 	exp := input.Exposure()
 	bot := input.Bottom()
 
 	if info.SizeSummary {
 		size := input.Size()
+		height := float32(0)
+		if size.Layers > 0 {
+			height = input.LayerZ(size.Layers - 1)
+		}
 		fmt.Printf("Layers: %v, %vx%v slices, %.2f x %.2f x %.2f mm bed required\n",
 			size.Layers, size.X, size.Y,
-			size.Millimeter.X, size.Millimeter.Y, float32(size.Layers)*size.LayerHeight)
-		exposureTime := time.Duration(0)
-		for n := 0; n < size.Layers; n++ {
-			exposureTime += time.Duration(input.LayerExposure(n).LightOnTime * float32(time.Second))
+			size.Millimeter.X, size.Millimeter.Y, height)
+		all, bottom := uv3dp.EstimatePrintTime(input)
+		printPhaseTime("Total time", &all)
+		printPhaseTime("Bottom time", &bottom)
+	}
+
+	if info.resinSelected() {
+		var resin InfoResin
+		resin, err = info.resinUsage(input)
+		if err != nil {
+			return
 		}
-		exposureTime = exposureTime.Truncate(time.Second)
-		totalTime := uv3dp.PrintDuration(input).Truncate(time.Second)
 
-		fmt.Printf("Total time: %v (%v exposure, %v motion)\n",
-			totalTime, exposureTime, totalTime-exposureTime)
+		fmt.Printf("Resin: %.2f ml, %.2f g", resin.Volume, resin.Mass)
+		if resin.Cost > 0 {
+			fmt.Printf(", %.2f cost", resin.Cost)
+		}
+		fmt.Printf("\n")
 	}
 
 	if info.ExposureSummary {
@@ -78,20 +391,44 @@ func (info *InfoCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable,
 		sort.Strings(keys)
 
 		for _, k := range keys {
+			if k == uv3dp.AnnotationsMetadata {
+				continue
+			}
 			data, _ := input.Metadata(k)
 			fmt.Printf("%v: %v\n", k, data)
 		}
+
+		annotations := uv3dp.LayerAnnotations(input)
+		if len(annotations) > 0 {
+			fmt.Printf("Annotations:\n")
+			for _, n := range annotations.Layers() {
+				fmt.Printf("  Layer %v: %v\n", n, annotations[n])
+			}
+		}
 	}
 
 	if info.LayerDetail {
+		annotations := uv3dp.LayerAnnotations(input)
 		size := input.Size()
 		for n := 0; n < size.Layers; n++ {
 			layerZ := input.LayerZ(n)
 			layerExposure := input.LayerExposure(n)
-			fmt.Printf("%d: @%.2f %+v\n", n, layerZ, layerExposure)
+			fmt.Printf("%d: @%.2f %+v", n, layerZ, layerExposure)
+			if note, ok := annotations[n]; ok {
+				fmt.Printf(" (%v)", note)
+			}
+			fmt.Printf("\n")
 		}
 	}
 
+	if info.Histogram {
+		InfoHistogramReport(os.Stdout, InfoHistogram(input))
+	}
+
+	if info.LayerStats {
+		InfoLayerReport(os.Stdout, input.Size(), InfoLayerStats(input))
+	}
+
 	output = input
 
 	return
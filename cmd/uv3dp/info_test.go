@@ -0,0 +1,141 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestInfoLayerStats(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 10, Y: 10, Layers: 2, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 5, Y: 5},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+		Bottom:   uv3dp.Bottom{Count: 1, Exposure: uv3dp.Exposure{LightOnTime: 60}},
+	}
+
+	input := &scalePrint{Print: uv3dp.Print{Properties: prop}, model: image.Rect(2, 3, 6, 5)}
+
+	stats := InfoLayerStats(input)
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 layers, got %v", len(stats))
+	}
+
+	for n, item := range stats {
+		expected := InfoLayer{
+			Layer:    n,
+			Z:        input.LayerZ(n),
+			Exposure: input.LayerExposure(n),
+			Pixels:   8,
+			Area:     2,
+			Largest:  8,
+			Bounds:   image.Rect(2, 3, 6, 5),
+		}
+		if item != expected {
+			t.Errorf("layer %v: expected %+v, got %+v", n, expected, item)
+		}
+	}
+
+	buff := &bytes.Buffer{}
+	InfoLayerReport(buff, prop.Size, stats[1:])
+	expected := " Layer        Z Exposure     Pixels  Area mm^2  Island mm^2 Bounds\n" +
+		"     1    0.100        8          8       2.00         2.00 (2,3)-(6,5)\n"
+	if buff.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buff.String())
+	}
+}
+
+func TestInfoHistogram(t *testing.T) {
+	var histogram [256]int64
+	histogram[0] = 80
+	histogram[128] = 5
+	histogram[255] = 15
+
+	buff := &bytes.Buffer{}
+	InfoHistogramReport(buff, histogram)
+	expected := "  128:            5 pixels (25.00%)\n" +
+		"  255:           15 pixels (75.00%)\n" +
+		"Gray levels: 2 used, 20 lit pixels, 25.00% partially lit\n"
+	if buff.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buff.String())
+	}
+
+	prop := uv3dp.Properties{Size: uv3dp.Size{X: 10, Y: 10, Layers: 2, LayerHeight: 0.05}}
+	input := &scalePrint{Print: uv3dp.Print{Properties: prop}, model: image.Rect(2, 3, 6, 5)}
+
+	histogram = InfoHistogram(input)
+	if histogram[0] != 184 || histogram[255] != 16 {
+		t.Errorf("expected 184 off and 16 lit pixels, got %v and %v", histogram[0], histogram[255])
+	}
+
+	buff.Reset()
+	InfoHistogramReport(buff, histogram)
+	expected = "  255:           16 pixels (100.00%)\n" +
+		"Gray levels: 1 used, 16 lit pixels, 0.00% partially lit\n" +
+		"Anti-aliasing: none (all pixels are fully lit or off)\n"
+	if buff.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buff.String())
+	}
+}
+
+func TestInfoJSON(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 10, Y: 10, Layers: 2, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 5, Y: 5},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+		Bottom:   uv3dp.Bottom{Count: 1, Exposure: uv3dp.Exposure{LightOnTime: 60}},
+		Preview:  map[uv3dp.PreviewType]image.Image{uv3dp.PreviewTypeTiny: image.NewRGBA(image.Rect(0, 0, 4, 3))},
+		Metadata: map[string]interface{}{"Machine": "test"},
+	}
+
+	input := &scalePrint{Print: uv3dp.Print{Properties: prop}, model: image.Rect(2, 3, 6, 5)}
+
+	info := NewInfoCommand()
+	err := info.Parse([]string{"--json", "--layers", "--histogram", "--usage"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buff := &bytes.Buffer{}
+	err = info.InfoJSONWrite(buff, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data InfoJSON
+	err = json.Unmarshal(buff.Bytes(), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Size != prop.Size || data.Exposure != prop.Exposure || data.Bottom != prop.Bottom {
+		t.Errorf("expected properties %+v, got %+v %+v %+v", prop, data.Size, data.Exposure, data.Bottom)
+	}
+
+	if data.Previews["tiny"] != (InfoPreview{Width: 4, Height: 3}) || len(data.Previews) != 1 {
+		t.Errorf("expected a 4x3 tiny preview, got %+v", data.Previews)
+	}
+
+	if data.Metadata["Machine"] != "test" {
+		t.Errorf("expected machine metadata, got %+v", data.Metadata)
+	}
+
+	if data.Time.Total != 68 || data.BottomTime.LightOn != 60 {
+		t.Errorf("expected 68s total and 60s bottom exposure, got %+v %+v", data.Time, data.BottomTime)
+	}
+
+	if data.Resin == nil || len(data.Histogram) != 256 || data.Histogram[255] != 16 || len(data.Layers) != 2 {
+		t.Errorf("expected resin, histogram, and layers, got %+v %v %+v", data.Resin, data.Histogram, data.Layers)
+	}
+}
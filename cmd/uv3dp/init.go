@@ -18,4 +18,12 @@ func init() {
 	newEmptyFormatter := func(suffix string) uv3dp.Formatter { return NewEmptyFormatter() }
 
 	uv3dp.RegisterFormatter("empty", newEmptyFormatter)
+
+	newLCDTestFormatter := func(suffix string) uv3dp.Formatter { return NewLCDTestFormatter() }
+
+	uv3dp.RegisterFormatter("lcdtest", newLCDTestFormatter)
+
+	newToleranceFormatter := func(suffix string) uv3dp.Formatter { return NewToleranceFormatter() }
+
+	uv3dp.RegisterFormatter("tolerance", newToleranceFormatter)
 }
@@ -0,0 +1,200 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type InterleaveCommand struct {
+	*pflag.FlagSet
+
+	Input string
+	Mode  string
+}
+
+func NewInterleaveCommand() (cmd *InterleaveCommand) {
+	cmd = &InterleaveCommand{
+		FlagSet: pflag.NewFlagSet("interleave", pflag.ContinueOnError),
+	}
+
+	cmd.StringVarP(&cmd.Input, "input", "i", "", "Second (B) printable to interleave with the input (A)")
+	cmd.StringVarP(&cmd.Mode, "mode", "m", "halves", "Interleave mode: 'halves' (A left, B right) or 'layers' (A even, B odd, at half the layer height)")
+
+	cmd.SetInterspersed(false)
+
+	return
+}
+
+// interleaveModifier combines two printables. In 'layers' mode, each
+// layer of the inputs is split into two half height layers, the first
+// from A and the second from B, so that all of the layers of both are kept.
+type interleaveModifier struct {
+	uv3dp.Printable
+
+	other  uv3dp.Printable
+	layers int
+	halves bool
+}
+
+func (mod *interleaveModifier) Size() (size uv3dp.Size) {
+	size = mod.Printable.Size()
+	size.Layers = mod.layers
+
+	if !mod.halves {
+		size.Layers *= 2
+		size.LayerHeight /= 2
+	}
+
+	return
+}
+
+func (mod *interleaveModifier) Bottom() (bottom uv3dp.Bottom) {
+	bottom = mod.Printable.Bottom()
+
+	if !mod.halves {
+		bottom.Count *= 2
+		bottom.Transition *= 2
+	}
+
+	return
+}
+
+// source returns the input, and its layer, of a layer
+func (mod *interleaveModifier) source(index int) (uv3dp.Printable, int) {
+	if mod.halves {
+		return mod.Printable, index
+	}
+
+	if (index % 2) == 1 {
+		return mod.other, index / 2
+	}
+
+	return mod.Printable, index / 2
+}
+
+func (mod *interleaveModifier) LayerZ(index int) (z float32) {
+	source, layer := mod.source(index)
+	z = source.LayerZ(layer)
+
+	if !mod.halves && (index%2) == 0 {
+		// Halfway up from the layer below
+		below := float32(0.0)
+		if layer > 0 {
+			below = source.LayerZ(layer - 1)
+		}
+		z = (below + z) / 2
+	}
+
+	return
+}
+
+func (mod *interleaveModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	source, layer := mod.source(index)
+	exposure = source.LayerExposure(layer)
+
+	if mod.halves {
+		// Use the longer of the two exposures, the shorter
+		// exposure's half is dimmed to match its own dose.
+		other := mod.other.LayerExposure(index)
+		if other.LightOnTime > exposure.LightOnTime {
+			exposure.LightOnTime = other.LightOnTime
+		}
+	}
+
+	return
+}
+
+func (mod *interleaveModifier) LayerImage(index int) (gray *image.Gray) {
+	if !mod.halves {
+		source, layer := mod.source(index)
+		gray = source.LayerImage(layer)
+		return
+	}
+
+	onTime := mod.LayerExposure(index).LightOnTime
+
+	aImage := mod.Printable.LayerImage(index)
+	bImage := mod.other.LayerImage(index)
+	aScale := float32(1.0)
+	bScale := float32(1.0)
+	if onTime > 0.0 {
+		aScale = mod.Printable.LayerExposure(index).LightOnTime / onTime
+		bScale = mod.other.LayerExposure(index).LightOnTime / onTime
+	}
+
+	bounds := aImage.Bounds()
+	middle := bounds.Min.X + bounds.Dx()/2
+
+	gray = image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pix := aImage.GrayAt(x, y).Y
+			scale := aScale
+			if x >= middle {
+				pix = bImage.GrayAt(x, y).Y
+				scale = bScale
+			}
+			gray.Pix[gray.PixOffset(x, y)] = uint8(float32(pix) * scale)
+		}
+	}
+
+	return
+}
+
+func (cmd *InterleaveCommand) Filter(input uv3dp.Printable) (mod uv3dp.Printable, err error) {
+	if cmd.Mode != "halves" && cmd.Mode != "layers" {
+		err = fmt.Errorf("interleave: unknown --mode '%v'", cmd.Mode)
+		return
+	}
+
+	if cmd.Input == "" {
+		err = fmt.Errorf("interleave: --input must be specified")
+		return
+	}
+
+	format, err := uv3dp.NewFormat(cmd.Input, nil)
+	if err != nil {
+		return
+	}
+
+	other, err := format.Printable()
+	if err != nil {
+		return
+	}
+
+	aSize := input.Size()
+	bSize := other.Size()
+	err = MergeCheck(cmd.Input, aSize, bSize)
+	if err != nil {
+		err = fmt.Errorf("interleave: %v", err)
+		return
+	}
+
+	layers := aSize.Layers
+	if bSize.Layers < layers {
+		layers = bSize.Layers
+	}
+
+	if aSize.Layers != bSize.Layers {
+		TraceVerbosef(VerbosityWarning, "interleave: %v: only the first %v layers of %v and %v layers are used", cmd.Input, layers, bSize.Layers, aSize.Layers)
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Interleaving %v layers of %v by %v", layers, cmd.Input, cmd.Mode)
+
+	mod = &interleaveModifier{
+		Printable: input,
+		other:     other,
+		layers:    layers,
+		halves:    cmd.Mode == "halves",
+	}
+
+	return
+}
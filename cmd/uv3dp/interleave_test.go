@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func newInterleavePrint(layers int, onTime float32, model image.Rectangle) *scalePrint {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 100, Y: 100, Layers: layers, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 50, Y: 50},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: onTime},
+		Bottom:   uv3dp.Bottom{Count: 1, Exposure: uv3dp.Exposure{LightOnTime: onTime * 10}},
+	}
+
+	return &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: model,
+	}
+}
+
+func TestInterleaveLayers(t *testing.T) {
+	aModel := image.Rect(10, 10, 30, 30)
+	bModel := image.Rect(60, 60, 80, 80)
+
+	output := &interleaveModifier{
+		Printable: newInterleavePrint(3, 2.0, aModel),
+		other:     newInterleavePrint(3, 3.0, bModel),
+		layers:    3,
+	}
+
+	// Every layer of both inputs is kept, at half the layer height
+	size := output.Size()
+	if size.Layers != 6 || size.LayerHeight != 0.025 {
+		t.Errorf("expected 6 layers of 0.025mm, got %v layers of %vmm", size.Layers, size.LayerHeight)
+	}
+
+	if output.Bottom().Count != 2 {
+		t.Errorf("expected 2 bottom layers, got %v", output.Bottom().Count)
+	}
+
+	table := []struct {
+		Z      float32
+		OnTime float32
+		Bounds image.Rectangle
+	}{
+		{Z: 0.025, OnTime: 20.0, Bounds: aModel},
+		{Z: 0.05, OnTime: 30.0, Bounds: bModel},
+		{Z: 0.075, OnTime: 2.0, Bounds: aModel},
+		{Z: 0.1, OnTime: 3.0, Bounds: bModel},
+		{Z: 0.125, OnTime: 2.0, Bounds: aModel},
+		{Z: 0.15, OnTime: 3.0, Bounds: bModel},
+	}
+
+	for n, item := range table {
+		z := output.LayerZ(n)
+		if z < item.Z-0.0001 || z > item.Z+0.0001 {
+			t.Errorf("layer %v: expected Z %v, got %v", n, item.Z, z)
+		}
+
+		onTime := output.LayerExposure(n).LightOnTime
+		if onTime != item.OnTime {
+			t.Errorf("layer %v: expected %v s, got %v s", n, item.OnTime, onTime)
+		}
+
+		bounds := uv3dp.ImageBounds(output.LayerImage(n))
+		if bounds != item.Bounds {
+			t.Errorf("layer %v: expected %v, got %v", n, item.Bounds, bounds)
+		}
+	}
+}
+
+func TestInterleaveHalves(t *testing.T) {
+	model := image.Rect(10, 10, 90, 90)
+
+	output := &interleaveModifier{
+		Printable: newInterleavePrint(2, 2.0, model),
+		other:     newInterleavePrint(2, 4.0, model),
+		layers:    2,
+		halves:    true,
+	}
+
+	if output.Size().Layers != 2 {
+		t.Errorf("expected 2 layers, got %v", output.Size().Layers)
+	}
+
+	// The longer exposure is used, and the shorter half is dimmed
+	if output.LayerExposure(1).LightOnTime != 4.0 {
+		t.Errorf("expected 4 s, got %v s", output.LayerExposure(1).LightOnTime)
+	}
+
+	img := output.LayerImage(1)
+	if pix := img.GrayAt(20, 50).Y; pix != 0x7f {
+		t.Errorf("left half: expected 0x7f, got %#x", pix)
+	}
+	if pix := img.GrayAt(80, 50).Y; pix != 0xff {
+		t.Errorf("right half: expected 0xff, got %#x", pix)
+	}
+}
+
+func TestInterleaveCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := newInterleavePrint(2, 2.0, image.Rect(10, 10, 30, 30))
+
+	table := map[string]struct {
+		LayerHeight float32
+		Error       bool
+	}{
+		"same.uvj":  {LayerHeight: 0.05},
+		"thick.uvj": {LayerHeight: 0.1, Error: true},
+	}
+
+	for name, item := range table {
+		other := newInterleavePrint(2, 3.0, image.Rect(10, 10, 30, 30))
+		other.Properties.Size.LayerHeight = item.LayerHeight
+
+		filename := filepath.Join(dir, name)
+		format, err := uv3dp.NewFormat(filename, nil)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", name, err)
+		}
+		err = format.SetPrintable(other)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", name, err)
+		}
+
+		cmd := NewInterleaveCommand()
+		cmd.Parse([]string{"--input", filename, "--mode", "layers"})
+
+		_, err = cmd.Filter(input)
+		if item.Error && err == nil {
+			t.Errorf("%v: expected an error", name)
+		} else if !item.Error && err != nil {
+			t.Errorf("%v: expected nil, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type IslandsCommand struct {
+	*pflag.FlagSet
+
+	Remove int
+	Fail   bool
+}
+
+func NewIslandsCommand() (cmd *IslandsCommand) {
+	flagSet := pflag.NewFlagSet("islands", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &IslandsCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.IntVarP(&cmd.Remove, "remove", "r", 0, "Remove islands smaller than this area, in pixels")
+	cmd.BoolVarP(&cmd.Fail, "fail", "f", false, "Fail if any islands are left")
+
+	return
+}
+
+// IslandsLayer is an island found in a layer
+type IslandsLayer struct {
+	Layer   int
+	Island  uv3dp.Island
+	Removed bool
+}
+
+// IslandsFind finds the islands of all of the layers, removing those
+// smaller than an area (if not zero). Layers are checked against the
+// layer below after its islands are removed, so islands that were only
+// supported by removed islands are found too.
+func IslandsFind(input uv3dp.Printable, remove int) (found []IslandsLayer) {
+	var below *image.Gray
+
+	for n := 0; n < input.Size().Layers; n++ {
+		layer := input.LayerImage(n)
+
+		if n > 0 {
+			removed := false
+			for _, island := range uv3dp.LayerIslands(below, layer) {
+				item := IslandsLayer{Layer: n, Island: island, Removed: island.Area < remove}
+				found = append(found, item)
+				if !item.Removed {
+					continue
+				}
+
+				if !removed {
+					removed = true
+					copied := image.NewGray(layer.Bounds())
+					copy(copied.Pix, layer.Pix)
+					layer = copied
+				}
+				for _, pt := range island.Pixels {
+					layer.Pix[layer.PixOffset(pt.X, pt.Y)] = 0
+				}
+			}
+		}
+
+		below = layer
+	}
+
+	return
+}
+
+// IslandsReport shows the islands, with their positions and areas in millimeters
+func IslandsReport(writer io.Writer, size uv3dp.Size, found []IslandsLayer) {
+	pixelX := size.Millimeter.X / float32(size.X)
+	pixelY := size.Millimeter.Y / float32(size.Y)
+
+	for _, item := range found {
+		island := item.Island
+		center := island.Bounds.Min.Add(island.Bounds.Max).Div(2)
+
+		fmt.Fprintf(writer, "Layer %v: %v pixels (%.3g mm^2) at %.2f,%.2f mm, %v",
+			item.Layer, island.Area, float32(island.Area)*pixelX*pixelY,
+			float32(center.X)*pixelX, float32(center.Y)*pixelY, island.Bounds)
+		if item.Removed {
+			fmt.Fprintf(writer, " (removed)")
+		}
+		fmt.Fprintln(writer)
+	}
+}
+
+// islandsModifier removes the pixels of islands
+type islandsModifier struct {
+	uv3dp.Printable
+
+	removed map[int][]image.Point
+}
+
+func (im *islandsModifier) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = im.Printable.LayerImage(index)
+
+	pixels, ok := im.removed[index]
+	if !ok {
+		return
+	}
+
+	srcImage := grayImage
+	grayImage = image.NewGray(srcImage.Bounds())
+	copy(grayImage.Pix, srcImage.Pix)
+
+	for _, pt := range pixels {
+		grayImage.Pix[grayImage.PixOffset(pt.X, pt.Y)] = 0
+	}
+
+	return
+}
+
+func (cmd *IslandsCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Remove < 0 {
+		err = fmt.Errorf("islands: --remove must not be negative")
+		return
+	}
+
+	found := IslandsFind(input, cmd.Remove)
+	IslandsReport(os.Stdout, input.Size(), found)
+
+	removed := map[int][]image.Point{}
+	left := 0
+	for _, item := range found {
+		if item.Removed {
+			removed[item.Layer] = append(removed[item.Layer], item.Island.Pixels...)
+		} else {
+			left++
+		}
+	}
+
+	TraceVerbosef(VerbosityNotice, "  %v islands found, %v removed", len(found), len(found)-left)
+
+	if cmd.Fail && left > 0 {
+		err = fmt.Errorf("islands: %v islands found", left)
+		return
+	}
+
+	output = input
+	if len(removed) > 0 {
+		output = &islandsModifier{
+			Printable: input,
+			removed:   removed,
+		}
+	}
+
+	return
+}
@@ -0,0 +1,110 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// islandsPrint has a supported block on every layer, a single pixel
+// speck on layer 1, and a two pixel speck above it on layer 2
+type islandsPrint struct {
+	uv3dp.Print
+}
+
+func (ip *islandsPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(ip.Properties.Bounds())
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			grayImage.Pix[grayImage.PixOffset(x, y)] = 0xff
+		}
+	}
+
+	if index >= 1 {
+		grayImage.Pix[grayImage.PixOffset(8, 8)] = 0xff
+	}
+	if index >= 2 {
+		grayImage.Pix[grayImage.PixOffset(8, 9)] = 0xff
+	}
+
+	return
+}
+
+func TestIslandsFind(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 3, LayerHeight: 0.05},
+	}
+	prop.Size.Millimeter.X = 5
+	prop.Size.Millimeter.Y = 5
+
+	input := &islandsPrint{Print: uv3dp.Print{Properties: prop}}
+
+	// Without removal, the layer 2 speck is supported by layer 1
+	found := IslandsFind(input, 0)
+	if len(found) != 1 || found[0].Layer != 1 || found[0].Island.Area != 1 || found[0].Removed {
+		t.Fatalf("expected one island on layer 1, got %+v", found)
+	}
+
+	// Removing the layer 1 speck leaves the layer 2 speck unsupported
+	found = IslandsFind(input, 2)
+	if len(found) != 2 {
+		t.Fatalf("expected two islands, got %+v", found)
+	}
+	if found[0].Layer != 1 || !found[0].Removed {
+		t.Errorf("expected layer 1 island to be removed, got %+v", found[0])
+	}
+	if found[1].Layer != 2 || found[1].Island.Area != 2 || found[1].Removed {
+		t.Errorf("expected layer 2 island to be kept, got %+v", found[1])
+	}
+
+	buff := &bytes.Buffer{}
+	IslandsReport(buff, input.Size(), found)
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "(removed)") {
+		t.Errorf("unexpected report %q", buff.String())
+	}
+}
+
+func TestIslands(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 3, LayerHeight: 0.05},
+	}
+
+	input := &islandsPrint{Print: uv3dp.Print{Properties: prop}}
+
+	cmd := NewIslandsCommand()
+	err := cmd.Parse([]string{"--remove", "3"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	for n := 1; n < 3; n++ {
+		img := output.LayerImage(n)
+		if img.GrayAt(8, 8).Y != 0 || img.GrayAt(8, 9).Y != 0 {
+			t.Errorf("layer %v: expected islands to be removed", n)
+		}
+		if img.GrayAt(0, 0).Y != 0xff {
+			t.Errorf("layer %v: expected supported area to be kept", n)
+		}
+	}
+
+	cmd = NewIslandsCommand()
+	cmd.Parse([]string{"--fail"})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for islands that are left")
+	}
+}
@@ -0,0 +1,203 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"math"
+	"os"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type LabelCommand struct {
+	*pflag.FlagSet
+
+	Text     string
+	Image    string
+	Height   float32
+	Position []float32
+	Layer    int
+	Depth    float32
+	Engrave  bool
+}
+
+func NewLabelCommand() (cmd *LabelCommand) {
+	flagSet := pflag.NewFlagSet("label", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &LabelCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Text, "text", "t", "", "Text of the label")
+	cmd.StringVarP(&cmd.Image, "image", "i", "", "PNG image to use as the label, instead of text")
+	cmd.Float32VarP(&cmd.Height, "height", "H", 5, "Height of the label on the bed, in millimeters")
+	cmd.Float32SliceVarP(&cmd.Position, "position", "p", []float32{5, 5}, "Position of the top left of the label, in millimeters")
+	cmd.IntVarP(&cmd.Layer, "layer", "l", 0, "First layer of the label")
+	cmd.Float32VarP(&cmd.Depth, "depth", "d", 1, "Depth of the label, in millimeters")
+	cmd.BoolVarP(&cmd.Engrave, "engrave", "e", false, "Engrave the label into the model, instead of embossing it")
+
+	return
+}
+
+// LabelText renders text as a stamp, with one pixel per font pixel
+func LabelText(text string) (stamp *image.Gray) {
+	face := basicfont.Face7x13
+
+	drawer := &font.Drawer{
+		Src:  image.NewUniform(color.Gray{Y: 0xff}),
+		Face: face,
+	}
+
+	width := drawer.MeasureString(text).Ceil()
+	stamp = image.NewGray(image.Rect(0, 0, width, face.Height))
+
+	drawer.Dst = stamp
+	drawer.Dot = fixed.P(0, face.Ascent)
+	drawer.DrawString(text)
+
+	return
+}
+
+// LabelImage converts an image to a stamp, where transparent areas are black
+func LabelImage(img image.Image) (stamp *image.Gray) {
+	bounds := img.Bounds()
+	stamp = image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(stamp, stamp.Bounds(), img, bounds.Min, draw.Over)
+
+	return
+}
+
+// labelModifier draws a stamp into a range of layers
+type labelModifier struct {
+	uv3dp.Printable
+
+	stamp      *image.Gray // Stamp, scaled to its area on the bed
+	firstLayer int
+	layers     int
+	engrave    bool
+}
+
+func (lm *labelModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := lm.Printable.LayerImage(index)
+	if index < lm.firstLayer || index >= lm.firstLayer+lm.layers {
+		return srcImage
+	}
+
+	grayImage = image.NewGray(srcImage.Bounds())
+	copy(grayImage.Pix, srcImage.Pix)
+
+	rect := lm.stamp.Bounds()
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			pix := lm.stamp.GrayAt(x, y).Y
+			n := grayImage.PixOffset(x, y)
+			if lm.engrave {
+				// Remove the stamp from the model
+				if 0xff-pix < grayImage.Pix[n] {
+					grayImage.Pix[n] = 0xff - pix
+				}
+			} else if pix > grayImage.Pix[n] {
+				grayImage.Pix[n] = pix
+			}
+		}
+	}
+
+	return
+}
+
+func (cmd *LabelCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if (cmd.Text == "") == (cmd.Image == "") {
+		err = fmt.Errorf("label: exactly one of --text or --image must be specified")
+		return
+	}
+
+	if len(cmd.Position) != 2 {
+		err = fmt.Errorf("label: --position expects two values")
+		return
+	}
+
+	if cmd.Height <= 0 || cmd.Depth <= 0 {
+		err = fmt.Errorf("label: --height and --depth must be positive")
+		return
+	}
+
+	size := input.Size()
+	if cmd.Layer < 0 || cmd.Layer >= size.Layers {
+		err = fmt.Errorf("label: layer %v is not in the range 0..%v", cmd.Layer, size.Layers-1)
+		return
+	}
+
+	var stamp *image.Gray
+	if cmd.Image != "" {
+		var reader *os.File
+		reader, err = os.Open(cmd.Image)
+		if err != nil {
+			return
+		}
+		defer func() { reader.Close() }()
+
+		var img image.Image
+		img, _, err = image.Decode(reader)
+		if err != nil {
+			err = fmt.Errorf("label: %v: %w", cmd.Image, err)
+			return
+		}
+		stamp = LabelImage(img)
+	} else {
+		stamp = LabelText(cmd.Text)
+	}
+
+	// Scale the stamp to the height, keeping its aspect ratio on the bed
+	pixelX := float64(size.Millimeter.X) / float64(size.X)
+	pixelY := float64(size.Millimeter.Y) / float64(size.Y)
+	stampBounds := stamp.Bounds()
+	height := float64(cmd.Height) / pixelY
+	width := height * float64(stampBounds.Dx()) / float64(stampBounds.Dy()) * pixelY / pixelX
+
+	x := int(math.Round(float64(cmd.Position[0]) / pixelX))
+	y := int(math.Round(float64(cmd.Position[1]) / pixelY))
+	rect := image.Rect(x, y, x+int(math.Round(width)), y+int(math.Round(height)))
+
+	if rect.Empty() || !rect.In(image.Rect(0, 0, size.X, size.Y)) {
+		err = fmt.Errorf("label: label at %v is not within the %dx%d bed", rect, size.X, size.Y)
+		return
+	}
+
+	layers := int(math.Ceil(float64(cmd.Depth / size.LayerHeight)))
+	if cmd.Layer+layers > size.Layers {
+		layers = size.Layers - cmd.Layer
+	}
+
+	operation := "Embossing"
+	if cmd.Engrave {
+		operation = "Engraving"
+	}
+
+	TraceVerbosef(VerbosityNotice, "  %v label at %v, on layers %v..%v", operation, rect, cmd.Layer, cmd.Layer+layers-1)
+
+	scaled := image.NewGray(rect)
+	draw.BiLinear.Scale(scaled, rect, stamp, stampBounds, draw.Src, nil)
+
+	output = &labelModifier{
+		Printable:  input,
+		stamp:      scaled,
+		firstLayer: cmd.Layer,
+		layers:     layers,
+		engrave:    cmd.Engrave,
+	}
+
+	return
+}
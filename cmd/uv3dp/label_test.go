@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestLabelText(t *testing.T) {
+	stamp := LabelText("AB")
+
+	if stamp.Bounds() != image.Rect(0, 0, 14, 13) {
+		t.Errorf("expected 14x13 stamp, got %v", stamp.Bounds())
+	}
+
+	bounds := uv3dp.ImageBounds(stamp)
+	if bounds.Empty() {
+		t.Errorf("expected text in the stamp")
+	}
+}
+
+func TestLabel(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 100, Y: 100, Layers: 4, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 50, Y: 50},
+		},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(0, 0, 50, 50),
+	}
+
+	count := func(img *image.Gray) (lit int) {
+		for _, pix := range img.Pix {
+			if pix != 0 {
+				lit++
+			}
+		}
+		return
+	}
+
+	model := count(input.LayerImage(0))
+
+	table := map[string]struct {
+		Args   []string
+		Layers []int // Layers with the label
+		More   bool  // Label adds lit pixels
+	}{
+		"emboss":  {Args: []string{"-t", "AB", "-H", "6.5", "-p", "20,20", "-l", "1", "-d", "0.1"}, Layers: []int{1, 2}, More: true},
+		"engrave": {Args: []string{"-t", "AB", "-H", "6.5", "-p", "5,5", "-e"}, Layers: []int{0, 1, 2, 3}},
+	}
+
+	for name, item := range table {
+		cmd := NewLabelCommand()
+		err := cmd.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", name, err)
+		}
+
+		labeled := map[int]bool{}
+		for _, layer := range item.Layers {
+			labeled[layer] = true
+		}
+
+		for layer := 0; layer < 4; layer++ {
+			lit := count(output.LayerImage(layer))
+			switch {
+			case !labeled[layer] && lit != model:
+				t.Errorf("%v: layer %v: expected %v pixels, got %v", name, layer, model, lit)
+			case labeled[layer] && item.More && lit <= model:
+				t.Errorf("%v: layer %v: expected more than %v pixels, got %v", name, layer, model, lit)
+			case labeled[layer] && !item.More && lit >= model:
+				t.Errorf("%v: layer %v: expected less than %v pixels, got %v", name, layer, model, lit)
+			}
+		}
+	}
+
+	cmd := NewLabelCommand()
+	cmd.Parse([]string{"-t", "AB", "-p", "45,45"})
+	_, err := cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for a label off of the bed")
+	}
+}
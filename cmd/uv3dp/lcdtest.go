@@ -0,0 +1,158 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// LCD test patterns
+var lcdTestPatterns = []string{"white", "checker", "gradient", "grid"}
+
+type LCDTestFormatter struct {
+	*pflag.FlagSet
+
+	Machine  string
+	Pattern  string
+	Size     int
+	Line     int
+	Bars     int
+	Exposure float32
+}
+
+func NewLCDTestFormatter() (lf *LCDTestFormatter) {
+	lf = &LCDTestFormatter{
+		FlagSet: pflag.NewFlagSet("lcdtest", pflag.ContinueOnError),
+	}
+
+	lf.StringVarP(&lf.Machine, "machine", "M", "photon", "Machine whose native resolution is used, or machine@variant")
+	lf.StringVarP(&lf.Pattern, "pattern", "P", "white", "Test pattern (white, checker, gradient, grid)")
+	lf.IntVarP(&lf.Size, "size", "s", 64, "Size of the checker squares and grid cells, in pixels")
+	lf.IntVarP(&lf.Line, "line", "w", 2, "Width of the grid lines, in pixels")
+	lf.IntVarP(&lf.Bars, "bars", "b", 8, "Number of gradient bars")
+	lf.Float32VarP(&lf.Exposure, "exposure", "e", 10.0, "Exposure time of the layer, in seconds")
+	lf.SetInterspersed(false)
+
+	return
+}
+
+// LCDTestPattern draws a screen test pattern over an entire image.
+//
+// Patterns are 'white' (all pixels lit), 'checker' (alternating squares
+// of 'size' pixels), 'gradient' (vertical bars of increasing brightness,
+// the last at full brightness), and 'grid' (lines 'line' pixels wide,
+// 'size' pixels apart, with a border around the screen).
+func LCDTestPattern(img *image.Gray, pattern string, size int, line int, bars int) (err error) {
+	rect := img.Rect
+	dx := rect.Dx()
+
+	var level func(x, y int) uint8
+
+	switch pattern {
+	case "white":
+		level = func(x, y int) uint8 { return 255 }
+	case "checker":
+		if size < 1 {
+			err = fmt.Errorf("lcdtest: checker size must be at least 1 pixel")
+			return
+		}
+		level = func(x, y int) uint8 {
+			if (x/size+y/size)%2 == 0 {
+				return 255
+			}
+			return 0
+		}
+	case "gradient":
+		if bars < 1 || bars > 255 {
+			err = fmt.Errorf("lcdtest: gradient bars must be in the range 1..255")
+			return
+		}
+		level = func(x, y int) uint8 {
+			bar := x * bars / dx
+			return uint8(255 * (bar + 1) / bars)
+		}
+	case "grid":
+		if line < 1 || size <= line {
+			err = fmt.Errorf("lcdtest: grid line width must be at least 1 pixel, and less than the grid size")
+			return
+		}
+		dy := rect.Dy()
+		level = func(x, y int) uint8 {
+			if x%size < line || y%size < line || x >= dx-line || y >= dy-line {
+				return 255
+			}
+			return 0
+		}
+	default:
+		err = fmt.Errorf("lcdtest: unknown pattern '%v' (expected one of %v)", pattern, lcdTestPatterns)
+		return
+	}
+
+	for y := 0; y < rect.Dy(); y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < dx; x++ {
+			row[x] = level(x, y)
+		}
+	}
+
+	return
+}
+
+func (lf *LCDTestFormatter) Decode(file uv3dp.Reader, filesize int64) (printable uv3dp.Printable, err error) {
+	var prop uv3dp.Properties
+
+	size := &prop.Size
+
+	machine, err := uv3dp.LookupMachine(lf.Machine)
+	if err != nil {
+		return
+	}
+
+	if lf.Exposure <= 0 {
+		err = fmt.Errorf("lcdtest: exposure must be greater than 0 seconds")
+		return
+	}
+
+	msize := &machine.Machine.Size
+	size.X = msize.X
+	size.Y = msize.Y
+	size.Millimeter.X = msize.Xmm
+	size.Millimeter.Y = msize.Ymm
+	size.LayerHeight = 0.05
+	size.Layers = 1
+
+	// The single layer is the only bottom layer
+	prop.Exposure.LightOnTime = lf.Exposure
+	prop.Exposure.LightPWM = 255
+	prop.Bottom.Count = 1
+	prop.Bottom.Exposure = prop.Exposure
+
+	layerImage := image.NewGray(prop.Bounds())
+	err = LCDTestPattern(layerImage, lf.Pattern, lf.Size, lf.Line, lf.Bars)
+	if err != nil {
+		return
+	}
+
+	printable = &EmptyPrint{
+		Print: uv3dp.Print{Properties: prop},
+		Image: layerImage,
+	}
+
+	return
+}
+
+// GeneratesPrintable is true, as test patterns are not read from a file
+func (lf *LCDTestFormatter) GeneratesPrintable() bool {
+	return true
+}
+
+func (lf *LCDTestFormatter) Encode(writer uv3dp.Writer, p uv3dp.Printable) (err error) {
+	return
+}
@@ -0,0 +1,51 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestLCDTestPattern(t *testing.T) {
+	table := map[string]struct {
+		Pattern string
+		Rows    []string
+	}{
+		"white":    {Pattern: "white", Rows: []string{"######", "######", "######", "######"}},
+		"checker":  {Pattern: "checker", Rows: []string{"##..##", "##..##", "..##..", "..##.."}},
+		"gradient": {Pattern: "gradient", Rows: []string{"--==##", "--==##", "--==##", "--==##"}},
+		"grid":     {Pattern: "grid", Rows: []string{"######", "#.#.##", "######", "######"}},
+	}
+
+	level := map[uint8]byte{0: '.', 85: '-', 170: '=', 255: '#'}
+
+	for name, item := range table {
+		img := image.NewGray(image.Rect(0, 0, 6, 4))
+		err := LCDTestPattern(img, item.Pattern, 2, 1, 3)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+
+		for y, expected := range item.Rows {
+			row := []byte{}
+			for x := 0; x < 6; x++ {
+				row = append(row, level[img.GrayAt(x, y).Y])
+			}
+			if string(row) != expected {
+				t.Errorf("%v: row %v: expected %v, got %v", name, y, expected, string(row))
+			}
+		}
+	}
+
+	img := image.NewGray(image.Rect(0, 0, 6, 4))
+	for _, pattern := range []string{"stripes", "grid"} {
+		err := LCDTestPattern(img, pattern, 1, 1, 3)
+		if err == nil {
+			t.Errorf("%v: expected an error", pattern)
+		}
+	}
+}
@@ -15,6 +15,10 @@ type LiftCommand struct {
 
 	LiftHeight float32
 	LiftSpeed  float32
+	Wait       float32
+
+	LiftHeight2 float32
+	LiftSpeed2  float32
 }
 
 func NewLiftCommand() (cmd *LiftCommand) {
@@ -24,6 +28,9 @@ func NewLiftCommand() (cmd *LiftCommand) {
 
 	cmd.Float32VarP(&cmd.LiftHeight, "height", "h", 0.0, "Lift height in mm")
 	cmd.Float32VarP(&cmd.LiftSpeed, "speed", "s", 0.0, "Lift speed in mm/min")
+	cmd.Float32VarP(&cmd.Wait, "wait", "w", 0.0, "Rest time after the lift, in seconds")
+	cmd.Float32VarP(&cmd.LiftHeight2, "height2", "H", 0.0, "Second stage (TSMC) lift height in mm")
+	cmd.Float32VarP(&cmd.LiftSpeed2, "speed2", "S", 0.0, "Second stage (TSMC) lift speed in mm/min")
 
 	cmd.SetInterspersed(false)
 
@@ -68,6 +75,21 @@ func (cmd *LiftCommand) Filter(input uv3dp.Printable) (mod uv3dp.Printable, err
 		exp.LiftSpeed = cmd.LiftSpeed
 	}
 
+	if cmd.Changed("wait") {
+		TraceVerbosef(VerbosityNotice, "  Setting default wait after lift to %v", cmd.Wait)
+		exp.WaitAfterLift = cmd.Wait
+	}
+
+	if cmd.Changed("height2") {
+		TraceVerbosef(VerbosityNotice, "  Setting default second stage lift height to %v mm", cmd.LiftHeight2)
+		exp.LiftHeight2 = cmd.LiftHeight2
+	}
+
+	if cmd.Changed("speed2") {
+		TraceVerbosef(VerbosityNotice, "  Setting default second stage lift speed to %v mm/min", cmd.LiftSpeed2)
+		exp.LiftSpeed2 = cmd.LiftSpeed2
+	}
+
 	mod = &liftModifier{
 		Printable: input,
 		exposure:  exp,
@@ -7,29 +7,137 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/nicarran/uv3dp"
 )
 
+// MachinesConfigPath is the default user machines file
+var MachinesConfigPath = uv3dpPath("machines.json")
+
+// MachinesLoad registers the machines of a user machines file, or of
+// the default user machines file (if it exists) if filename is empty
+func MachinesLoad(filename string) (err error) {
+	if filename == "" {
+		filename = MachinesConfigPath
+		_, statErr := os.Stat(filename)
+		if os.IsNotExist(statErr) {
+			return
+		}
+	}
+
+	reader, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+
+	err = uv3dp.LoadMachines(reader, filename)
+	if err != nil {
+		err = fmt.Errorf("%v: %w", filename, err)
+	}
+
+	return
+}
+
+// machinesLoaded is true once the user machines file has been loaded
+var machinesLoaded bool
+
+// machinesLoadOnce loads the user machines file selected by --machines,
+// for the commands that use machines
+func machinesLoadOnce() (err error) {
+	if machinesLoaded {
+		return
+	}
+
+	machinesLoaded = true
+	err = MachinesLoad(param.Machines)
+
+	return
+}
+
 func PrintMachines() {
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintln(os.Stderr, "Known machines:")
 	fmt.Fprintln(os.Stderr)
 
-	keys := []string{}
-	for key := range uv3dp.MachineFormats {
-		keys = append(keys, key)
+	// Show the machines that could be loaded
+	err := machinesLoadOnce()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "    (user machines not loaded: %v)\n", err)
 	}
 
-	sort.Strings(keys)
-
-	for _, key := range keys {
-		item := uv3dp.MachineFormats[key]
+	for _, key := range uv3dp.Machines() {
+		item, _ := uv3dp.LookupMachine(key)
 		size := &item.Machine.Size
 		fmt.Fprintf(os.Stderr, "    %-16s %10s %-16s Size: %dx%d, %.3gx%.3g mm,\t", key,
 			item.Machine.Vendor, item.Machine.Model, size.X, size.Y, size.Xmm, size.Ymm)
-		fmt.Fprintf(os.Stderr, "Format: %s %v\n", item.Extension, strings.Join(item.Args, " "))
+		fmt.Fprintf(os.Stderr, "Format: %s %v\tOrigin: %s\n", item.Extension, strings.Join(item.Args, " "), item.Origin)
+
+		variants := []string{}
+		for variant := range item.Variants {
+			variants = append(variants, variant)
+		}
+		sort.Strings(variants)
+
+		for _, variant := range variants {
+			size := item.Variants[variant]
+			fmt.Fprintf(os.Stderr, "    %-16s %10s %-16s Size: %dx%d, %.3gx%.3g mm\n", key+"@"+variant,
+				"", "", size.X, size.Y, size.Xmm, size.Ymm)
+		}
+
+		versions := []string{}
+		for version := range item.Firmware {
+			versions = append(versions, version)
+		}
+		sort.Strings(versions)
+
+		for _, version := range versions {
+			fmt.Fprintf(os.Stderr, "    %-16s %10s %-16s Firmware %v: %v\n", "", "", "", version, item.Firmware[version].Notes)
+		}
+	}
+}
+
+// MachineOutput selects the output file name, file format type, and file
+// format arguments from a machine's profile for output files with no
+// extension, and output directories. Output directories are given a
+// file named after the input file.
+func MachineOutput(machineName string, filename string, inputName string) (outname string, formatType string, args []string, err error) {
+	outname = filename
+
+	machine, err := uv3dp.LookupMachine(machineName)
+	if err != nil {
+		return
+	}
+
+	if filename == uv3dp.FilenameStdio {
+		formatType = machine.Extension
+		args = machine.Args
+		return
+	}
+
+	info, statErr := os.Stat(filename)
+	isDir := statErr == nil && info.IsDir()
+
+	if !isDir && filepath.Ext(filename) != "" {
+		// Use the extension the user gave
+		return
 	}
+
+	if isDir {
+		base := "uv3dp"
+		if inputName != uv3dp.FilenameStdio && inputName != "empty" {
+			base = filepath.Base(inputName)
+			base = strings.TrimSuffix(base, filepath.Ext(base))
+		}
+		outname = filepath.Join(filename, base)
+	}
+
+	outname += machine.Extension
+	formatType = machine.Extension
+	args = append([]string{}, machine.Args...)
+
+	return
 }
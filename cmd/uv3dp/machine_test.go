@@ -0,0 +1,125 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestMachineOutput(t *testing.T) {
+	uv3dp.RegisterMachine("test-machine", uv3dp.Machine{}, ".ctb", "--version=3")
+
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	table := []struct {
+		Filename   string
+		Outname    string
+		FormatType string
+		Args       []string
+	}{
+		{Filename: "out", Outname: "out.ctb", FormatType: ".ctb", Args: []string{"--version=3"}},
+		{Filename: "out.sl1", Outname: "out.sl1"},
+		{Filename: "-", Outname: "-", FormatType: ".ctb", Args: []string{"--version=3"}},
+		{Filename: dir, Outname: filepath.Join(dir, "in.ctb"), FormatType: ".ctb", Args: []string{"--version=3"}},
+	}
+
+	for _, item := range table {
+		outname, formatType, args, err := MachineOutput("test-machine", item.Filename, "path/in.sl1")
+		if err != nil {
+			t.Errorf("%v: expected nil, got %v", item.Filename, err)
+			continue
+		}
+
+		if outname != item.Outname || formatType != item.FormatType || !cmp.Equal(args, item.Args) {
+			t.Errorf("%v: expected %v %v %v, got %v %v %v", item.Filename,
+				item.Outname, item.FormatType, item.Args, outname, formatType, args)
+		}
+	}
+
+	_, _, _, err = MachineOutput("no-such-machine", "out", "in.sl1")
+	if err == nil {
+		t.Errorf("expected unknown machine error")
+	}
+}
+
+func TestMachinesLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "machines.json")
+	err = ioutil.WriteFile(filename, []byte(`{
+		"test-user-machine": {
+			"Size": { "X": 100, "Y": 50, "Xmm": 10, "Ymm": 5 },
+			"Format": "ctb"
+		}
+	}`), 0644)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	err = MachinesLoad(filename)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	machine, err := uv3dp.LookupMachine("test-user-machine")
+	if err != nil {
+		t.Fatalf("expected the user machine to be registered, got %v", err)
+	}
+
+	if machine.Extension != ".ctb" || machine.Origin != filename {
+		t.Errorf("expected .ctb from %v, got %v from %v", filename, machine.Extension, machine.Origin)
+	}
+
+	err = MachinesLoad(filepath.Join(dir, "missing.json"))
+	if err == nil {
+		t.Errorf("expected an error for a missing machines file")
+	}
+}
+
+func TestMachinesLoadOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "machines.json")
+	ioutil.WriteFile(filename, []byte("{bad"), 0644)
+
+	defer func(machines string) {
+		param.Machines = machines
+		machinesLoaded = false
+	}(param.Machines)
+
+	param.Machines = filename
+	machinesLoaded = false
+
+	// Commands that do not use machines ignore a bad machines file
+	err = evaluate([]string{"formats", "list"})
+	if err != nil {
+		t.Errorf("formats: expected nil, got %v", err)
+	}
+
+	err = evaluate([]string{"convert", "in.ctb", "out.sl1"})
+	if err == nil || !strings.Contains(err.Error(), filename) {
+		t.Errorf("convert: expected an error for %v, got %v", filename, err)
+	}
+}
@@ -0,0 +1,201 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Maximum depth of macros that expand to other macros
+const macroDepthLimit = 16
+
+var MacroConfigPath string
+
+// Names that are handled before the command map, when they are the
+// first argument, and so can't be used as macro names
+var macroReserved = map[string]bool{
+	"age":         true,
+	"convert":     true,
+	"debug":       true,
+	"formats":     true,
+	"help":        true,
+	"import":      true,
+	"log":         true,
+	"resin":       true,
+	"self-update": true,
+	"stats":       true,
+}
+
+func uv3dpPath(suffix string) string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("LOCALAPPDATA") + strings.ReplaceAll("/uv3dp/"+suffix, "/", "\\")
+	} else {
+		return os.Getenv("HOME") + "/.config/uv3dp/" + suffix
+	}
+}
+
+type MacroCommand struct {
+	*pflag.FlagSet
+
+	Name      string
+	Expansion []string
+
+	depth int
+}
+
+func NewMacroCommand(name string, expansion []string) (cmd *MacroCommand) {
+	cmd = &MacroCommand{
+		FlagSet:   pflag.NewFlagSet(name, pflag.ContinueOnError),
+		Name:      name,
+		Expansion: expansion,
+	}
+
+	cmd.SetInterspersed(false)
+
+	return
+}
+
+func (cmd *MacroCommand) PrintDefaults() {
+	fmt.Fprintf(os.Stderr, "  (macro) %v\n", strings.Join(cmd.Expansion, " "))
+}
+
+func (cmd *MacroCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.depth >= macroDepthLimit {
+		err = fmt.Errorf("macro '%v': expansion is too deep (recursive macro?)", cmd.Name)
+		return
+	}
+
+	output = input
+
+	args := cmd.Expansion
+	for len(args) > 0 {
+		item, found := commandMap[args[0]]
+		if !found {
+			err = fmt.Errorf("macro '%v': '%v' is not a command", cmd.Name, args[0])
+			return
+		}
+
+		filter := item.NewCommander()
+		err = filter.Parse(args[1:])
+		if err != nil {
+			err = fmt.Errorf("macro '%v': %w", cmd.Name, err)
+			return
+		}
+		TraceVerbosef(VerbosityNotice, "  %v: %v", cmd.Name, args)
+		args = filter.Args()
+
+		macro, ok := filter.(*MacroCommand)
+		if ok {
+			macro.depth = cmd.depth + 1
+		}
+
+		output, err = filter.Filter(output)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// MacroParse reads macro definitions, one per line, as:
+//
+//	# Comment
+//	name: command [options] [command [options]]...
+//
+// The expansion is split into arguments in the same way as a command file.
+func MacroParse(reader io.Reader) (macros map[string][]string, err error) {
+	macros = map[string][]string{}
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+
+		fields := strings.SplitN(text, ":", 2)
+		name := strings.TrimSpace(fields[0])
+		if len(fields) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+			err = fmt.Errorf("line %v: expected 'name: command [options]...'", line)
+			return
+		}
+
+		var expansion []string
+		expansion, err = CommandExpand(strings.NewReader(fields[1]))
+		if err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+
+		if len(expansion) == 0 {
+			err = fmt.Errorf("line %v: macro '%v' is empty", line, name)
+			return
+		}
+
+		macros[name] = expansion
+	}
+
+	err = scanner.Err()
+
+	return
+}
+
+// RegisterMacros adds the macros to the command map.
+// Macros may not replace built-in commands or subcommands.
+func RegisterMacros(macros map[string][]string) (err error) {
+	for name, expansion := range macros {
+		if macroReserved[name] {
+			err = fmt.Errorf("macro '%v': conflicts with the '%v' subcommand", name, name)
+			return
+		}
+
+		_, found := commandMap[name]
+		if found {
+			err = fmt.Errorf("macro '%v': conflicts with an existing command", name)
+			return
+		}
+
+		name, expansion := name, expansion
+		commandMap[name] = struct {
+			NewCommander func() (cmd Commander)
+			Description  string
+		}{
+			NewCommander: func() Commander { return NewMacroCommand(name, expansion) },
+			Description:  "Macro for: " + strings.Join(expansion, " "),
+		}
+	}
+
+	return
+}
+
+func init() {
+	MacroConfigPath = uv3dpPath("macros.cmd")
+
+	reader, err := os.Open(MacroConfigPath)
+	if err != nil {
+		// This is fine.
+		return
+	}
+	defer reader.Close()
+
+	macros, err := MacroParse(reader)
+	if err == nil {
+		err = RegisterMacros(macros)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", MacroConfigPath, err)
+	}
+}
@@ -0,0 +1,76 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMacroParse(t *testing.T) {
+	table := []struct {
+		input  string
+		output map[string][]string
+		valid  bool
+	}{
+		{
+			input:  "# comment\n\nmars-night: exposure --light-on 8 lift --height 6\n",
+			output: map[string][]string{"mars-night": {"exposure", "--light-on", "8", "lift", "--height", "6"}},
+			valid:  true,
+		},
+		{
+			input:  "quoted: select --first '1'\n",
+			output: map[string][]string{"quoted": {"select", "--first", "1"}},
+			valid:  true,
+		},
+		{input: "no colon here\n"},
+		{input: "two words: info\n"},
+		{input: "empty:\n"},
+	}
+
+	for n, item := range table {
+		macros, err := MacroParse(strings.NewReader(item.input))
+		if (err == nil) != item.valid {
+			t.Errorf("%v: expected valid %v, got %v", n, item.valid, err)
+			continue
+		}
+
+		if item.valid && !cmp.Equal(item.output, macros) {
+			t.Errorf("%v: expected %+v, got %+v", n, item.output, macros)
+		}
+	}
+}
+
+func TestRegisterMacros(t *testing.T) {
+	table := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "test-register-macro", valid: true},
+		{name: "scale", valid: false},
+		{name: "stats", valid: false},
+		{name: "convert", valid: false},
+		{name: "log", valid: false},
+		{name: "formats", valid: false},
+		{name: "debug", valid: false},
+		{name: "age", valid: false},
+		{name: "import", valid: false},
+		{name: "resin", valid: false},
+		{name: "self-update", valid: false},
+	}
+
+	for _, item := range table {
+		err := RegisterMacros(map[string][]string{item.name: {"scale", "--scale", "50"}})
+		if item.valid && err != nil {
+			t.Errorf("%v: expected nil, got %v", item.name, err)
+		} else if !item.valid && err == nil {
+			t.Errorf("%v: expected an error", item.name)
+		}
+	}
+
+	delete(commandMap, "test-register-macro")
+}
@@ -6,21 +6,14 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/nicarran/uv3dp"
-	_ "github.com/nicarran/uv3dp/cbddlp"
-	_ "github.com/nicarran/uv3dp/ctb"
-	_ "github.com/nicarran/uv3dp/cws"
-	_ "github.com/nicarran/uv3dp/czip"
-	_ "github.com/nicarran/uv3dp/fdg"
-	_ "github.com/nicarran/uv3dp/lgs"
-	_ "github.com/nicarran/uv3dp/phz"
-	_ "github.com/nicarran/uv3dp/pws"
-	_ "github.com/nicarran/uv3dp/sl1"
-	_ "github.com/nicarran/uv3dp/uvj"
-	_ "github.com/nicarran/uv3dp/zcodex"
+	_ "github.com/nicarran/uv3dp/formats"
 
 	"github.com/spf13/pflag"
 )
@@ -37,15 +30,30 @@ const (
 )
 
 var param struct {
-	Verbose  int  // Verbose counts the number of '-v' flags
-	Version  bool // Show version
-	Progress bool // Show progress bar
+	Verbose  int    // Verbose counts the number of '-v' flags
+	Version  bool   // Show version
+	Progress bool   // Show progress bar
+	Stats    string // Local stats file, if any
+	Format   string // Forced file format type, if any
+	Machine  string // Target machine, for output files without an extension
+	Firmware string // Firmware version of the target machine, if any
+	Machines string // User machines file, if not the default
+	Report   bool   // Show the evaluated pipeline as JSON
+
+	DecodeStrict  bool // Treat all decoding issues as errors
+	DecodeNormal  bool // Treat bad checksums as errors, other decoding issues as warnings
+	DecodeLenient bool // Treat all decoding issues as warnings
+
+	NormalizeOrientation bool // Normalize the orientation of files stored mirrored
+
+	Hash       string // Hash algorithm of layers and files, if not the default
+	LayerStore string // Directory of the shared store of encoded layers, if any
 }
 
 func TraceVerbosef(level Verbosity, format string, args ...interface{}) {
 	if param.Verbose >= int(level) {
-		fmt.Printf("<%v>", level)
-		fmt.Printf(format+"\n", args...)
+		fmt.Fprintf(os.Stderr, "<%v>", level)
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
 	}
 }
 
@@ -65,14 +73,54 @@ var commandMap = map[string]struct {
 		NewCommander: func() Commander { return NewInfoCommand() },
 		Description:  "Dumps information about the printable",
 	},
+	"advise": {
+		NewCommander: func() Commander { return NewAdviseCommand() },
+		Description:  "Sets the exposure that has been most successful in the print history",
+	},
+	"annotate": {
+		NewCommander: func() Commander { return NewAnnotateCommand() },
+		Description:  "Adds or removes notes on layers, kept with the file",
+	},
+	"antialias": {
+		NewCommander: func() Commander { return NewAntialiasCommand() },
+		Description:  "Smooths the edges of layer images with a gaussian blur",
+	},
 	"bed": {
 		NewCommander: func() Commander { return NewBedCommand() },
 		Description:  "Adjust image for a different bed size/resolution",
 	},
+	"center": {
+		NewCommander: func() Commander { return NewCenterCommand() },
+		Description:  "Centers the model on the bed, within optional margins",
+	},
+	"compensate-age": {
+		NewCommander: func() Commander { return NewCompensateAgeCommand() },
+		Description:  "Scales exposures to compensate for the aging of the machine's light source",
+	},
+	"crop": {
+		NewCommander: func() Commander { return NewCropCommand() },
+		Description:  "Crops layers to a rectangle, changing the bed size",
+	},
+	"curve": {
+		NewCommander: func() Commander { return NewCurveCommand() },
+		Description:  "Remaps the gray levels of layers through a gamma or control point curve",
+	},
+	"deadpixel": {
+		NewCommander: func() Commander { return NewDeadPixelCommand() },
+		Description:  "Moves the light of dead LCD pixels to their neighbors, or warns of layers using them",
+	},
 	"decimate": {
 		NewCommander: func() Commander { return NewDecimateCommand() },
 		Description:  "Remove outmost pixels of all islands in each layer (reduces over-curing on edges)",
 	},
+	"diff": {
+		NewCommander: func() Commander { return NewDiffCommand() },
+		Description:  "Compares the layers and settings with a second printable, failing if they differ",
+	},
+	"dim": {
+		NewCommander: func() Commander { return NewDimCommand() },
+		Description:  "Dims the interior of solid areas, keeping a full brightness wall, to reduce over-curing and suction",
+	},
 	"exposure": {
 		NewCommander: func() Commander { return NewExposureCommand() },
 		Description:  "Alters exposure times",
@@ -81,29 +129,155 @@ var commandMap = map[string]struct {
 		NewCommander: func() Commander { return NewBottomCommand() },
 		Description:  "Alters bottom layer exposure",
 	},
+	"hollow": {
+		NewCommander: func() Commander { return NewHollowCommand() },
+		Description:  "Hollows out solid interiors, keeping a wall of a given thickness, with an optional infill grid",
+	},
+	"islands": {
+		NewCommander: func() Commander { return NewIslandsCommand() },
+		Description:  "Reports areas of layers with no support from the layer below, and optionally removes small ones",
+	},
+	"interleave": {
+		NewCommander: func() Commander { return NewInterleaveCommand() },
+		Description:  "Interleaves layers or plate halves with a second printable, for comparison prints",
+	},
+	"label": {
+		NewCommander: func() Commander { return NewLabelCommand() },
+		Description:  "Embosses or engraves a text label or PNG stamp into a range of layers",
+	},
 	"lift": {
 		NewCommander: func() Commander { return NewLiftCommand() },
 		Description:  "Alters layer lift properties",
 	},
+	"merge": {
+		NewCommander: func() Commander { return NewMergeCommand() },
+		Description:  "Merges the layers of a second printable onto the plate",
+	},
+	"morph": {
+		NewCommander: func() Commander { return NewMorphCommand() },
+		Description:  "Erodes, dilates, opens, or closes the islands of a range of layers",
+	},
+	"resume": {
+		NewCommander: func() Commander { return NewResumeCommand() },
+		Description:  "Removes the layers below a Z height or layer, to resume a failed print on top of the partial part",
+	},
 	"retract": {
 		NewCommander: func() Commander { return NewRetractCommand() },
 		Description:  "Alters layer retract properties",
 	},
+	"mirror": {
+		NewCommander: func() Commander { return NewMirrorCommand() },
+		Description:  "Mirrors layer images horizontally and/or vertically",
+	},
+	"qr": {
+		NewCommander: func() Commander { return NewQRCommand() },
+		Description:  "Embosses or debosses a QR code into the bottom layers, for traceability",
+	},
+	"raft": {
+		NewCommander: func() Commander { return NewRaftCommand() },
+		Description:  "Adds a raft below the model, from the footprint of its first layer",
+	},
+	"refill": {
+		NewCommander: func() Commander { return NewRefillCommand() },
+		Description:  "Pauses the print to refill the vat, before layers that would use more resin than is left in it",
+	},
+	"reslice": {
+		NewCommander: func() Commander { return NewResliceCommand() },
+		Description:  "Resamples the layers to a new layer height, blending the overlapping layers",
+	},
 	"resin": {
 		NewCommander: func() Commander { return NewResinCommand() },
 		Description:  "Changes all properties to match a selected resin",
 	},
+	"offset": {
+		NewCommander: func() Commander { return NewOffsetCommand() },
+		Description:  "Moves the model on the bed, by pixels or millimeters",
+	},
+	"overhang": {
+		NewCommander: func() Commander { return NewOverhangCommand() },
+		Description:  "Reports areas of layers that overhang the layer below, with a risk score for the print",
+	},
+	"pack": {
+		NewCommander: func() Commander { return NewPackCommand() },
+		Description:  "Packs the models of other printables onto the plate, in shelves",
+	},
+	"preview": {
+		NewCommander: func() Commander { return NewPreviewCommand() },
+		Description:  "Exports a preview image, or replaces it with a resized image",
+	},
+	"proof": {
+		NewCommander: func() Commander { return NewProofCommand() },
+		Description:  "Simulates the cured result of each layer from a simple resin exposure model",
+	},
+	"profile": {
+		NewCommander: func() Commander { return NewProfileCommand() },
+		Description:  "Exports the exposure, bottom, lift, and retract settings to a JSON file ('export FILE'), or applies them from one ('apply FILE')",
+	},
+	"stream": {
+		NewCommander: func() Commander { return NewStreamCommand() },
+		Description:  "Serves layers and exposure timing over HTTP, for network projectors",
+	},
+	"scale": {
+		NewCommander: func() Commander { return NewScaleCommand() },
+		Description:  "Scales the model in X and Y, around its center",
+	},
+	"split": {
+		NewCommander: func() Commander { return NewSplitCommand() },
+		Description:  "Writes ranges of layers to separate output files",
+	},
+	"stack": {
+		NewCommander: func() Commander { return NewStackCommand() },
+		Description:  "Appends the layers of a second printable on top of the model",
+	},
+	"section": {
+		NewCommander: func() Commander { return NewSectionCommand() },
+		Description:  "Saves the layer nearest to a Z height, or a vertical section through the layers, to a PNG file",
+	},
 	"select": {
 		NewCommander: func() Commander { return NewSelectCommand() },
 		Description:  "Select to print only a range of layers",
 	},
+	"validate": {
+		NewCommander: func() Commander { return NewValidateCommand() },
+		Description:  "Checks the printable against the limits of a machine and its firmware, failing on errors",
+	},
+	"subpixel": {
+		NewCommander: func() Commander { return NewSubpixelCommand() },
+		Description:  "Renders layers sliced at subpixel resolution for older printers with RGB panels",
+	},
+	"suction": {
+		NewCommander: func() Commander { return NewSuctionCommand() },
+		Description:  "Reports cavities that are closed towards the build plate, and form suction cups against the FEP",
+	},
+	"zoffset": {
+		NewCommander: func() Commander { return NewZOffsetCommand() },
+		Description:  "Offsets the Z height of all layers, or squishes the first layers against the build plate",
+	},
+	"ztable": {
+		NewCommander: func() Commander { return NewZTableCommand() },
+		Description:  "Exports the Z height of each layer to a CSV file ('export FILE'), or imports them from one ('import FILE')",
+	},
 }
 
 func Usage() {
 	fmt.Fprintln(os.Stderr, "Usage:")
 	fmt.Fprintln(os.Stderr)
-	fmt.Fprintln(os.Stderr, "  uv3dp [options] INFILE [command [options] | OUTFILE]...")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] INFILE [-t TYPE] [command [options] | OUTFILE [-t TYPE]]...")
 	fmt.Fprintln(os.Stderr, "  uv3dp [options] @cmdfile.cmd")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] stats [--file STATSFILE] report")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] formats list")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] formats test FILE")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] log [--resin NAME] [--notes TEXT] (success | failure) PRINTFILE")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] log report")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] debug dump FILE")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] age [--file AGEFILE] show [MACHINE]...")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] age [--file AGEFILE] (add | set) MACHINE HOURS")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] age [--file AGEFILE] curve MACHINE HOURS:SCALE[,HOURS:SCALE]...")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] age [--file AGEFILE] [--field NAME] fetch MACHINE URL")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] resin [--file RESINFILE] [--import FILE] [--list]")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] import [--machine-file MACHINEFILE] [--resin-file RESINFILE] PROFILE...")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] self-update [--check] [--force] [--notify[=false]]")
+	fmt.Fprintln(os.Stderr, "  uv3dp [options] convert --to MACHINE [--firmware VERSION] [--mirror] [--force] INFILE [OUTFILE | OUTDIR]")
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintln(os.Stderr, "Options:")
 	fmt.Fprintln(os.Stderr)
@@ -146,20 +320,60 @@ type cliProgress struct {
 }
 
 func (cp *cliProgress) Show(percent float32) {
-	fmt.Printf("%v: %.2f%%\r", cp.Format.Filename, percent)
+	fmt.Fprintf(os.Stderr, "%v: %.2f%%\r", cp.Format.Filename, percent)
 }
 
 func (cp *cliProgress) Stop() {
-	fmt.Println()
+	fmt.Fprintln(os.Stderr)
 }
 
 func init() {
 	pflag.BoolVarP(&param.Progress, "progress", "p", false, "Show progress during operations")
 	pflag.CountVarP(&param.Verbose, "verbose", "v", "Verbosity")
 	pflag.BoolVarP(&param.Version, "version", "V", false, "Show version")
+	pflag.StringVarP(&param.Stats, "stats", "", "", "Record conversion statistics to a local file")
+	pflag.StringVarP(&param.Machine, "machine", "M", "", "Target machine, selecting the file format of output files and directories with no extension")
+	pflag.StringVarP(&param.Firmware, "firmware", "F", "", "Firmware version of the --machine, to work around its known quirks")
+	pflag.StringVarP(&param.Machines, "machines", "", "", "JSON file of user machines (default is ~/.config/uv3dp/machines.json, if it exists)")
+	pflag.StringVarP(&param.Format, "format", "t", "", "Force the file format type (ie 'ctb') of all files, regardless of extension")
+	pflag.BoolVarP(&param.Report, "report", "", false, "Show the pipeline, with all option values, as JSON (and save it in the 'Pipeline' metadata of output files)")
+	pflag.BoolVarP(&param.DecodeStrict, "decode-strict", "", false, "Reject input files with unknown fields, bad checksums, or unexpected versions")
+	pflag.BoolVarP(&param.DecodeNormal, "decode-normal", "", false, "Reject input files with bad checksums, warn of unknown fields and unexpected versions (default)")
+	pflag.BoolVarP(&param.DecodeLenient, "decode-lenient", "", false, "Warn of unknown fields, bad checksums, and unexpected versions in input files")
+	pflag.BoolVarP(&param.NormalizeOrientation, "normalize-orientation", "", false, "Mirror the layer images of files with an LCD_X_MIRROR projector when reading and writing them")
+	pflag.StringVarP(&param.Hash, "hash", "", "default", "Hash algorithm of layers and files (crc64, xxhash, or sha256)")
+	pflag.StringVarP(&param.LayerStore, "layer-store", "", "", "Directory of a store of encoded layers, shared by jobs to encode identical layers only once")
 	pflag.SetInterspersed(false)
 }
 
+// decodeStrictness selects the decoding strictness from the --decode-* options
+func decodeStrictness() (strictness uv3dp.DecodeStrictness, err error) {
+	modes := 0
+	strictness = uv3dp.DecodeNormal
+
+	if param.DecodeStrict {
+		strictness = uv3dp.DecodeStrict
+		modes++
+	}
+
+	if param.DecodeNormal {
+		strictness = uv3dp.DecodeNormal
+		modes++
+	}
+
+	if param.DecodeLenient {
+		strictness = uv3dp.DecodeLenient
+		modes++
+	}
+
+	if modes > 1 {
+		err = fmt.Errorf("only one of --decode-strict, --decode-normal, or --decode-lenient may be specified")
+		return
+	}
+
+	return
+}
+
 func evaluate(args []string) (err error) {
 	if param.Version {
 		fmt.Printf("Version %v\n", Version)
@@ -171,8 +385,155 @@ func evaluate(args []string) (err error) {
 		return
 	}
 
+	strictness, err := decodeStrictness()
+	if err != nil {
+		return
+	}
+
+	uv3dp.SetDecodeStrictness(strictness)
+	uv3dp.SetMirrorNormalize(param.NormalizeOrientation)
+
+	algorithm, err := uv3dp.ParseHashAlgorithm(param.Hash)
+	if err != nil {
+		return
+	}
+	uv3dp.SetHashAlgorithm(algorithm)
+
+	if param.LayerStore != "" {
+		uv3dp.SetLayerStore(&uv3dp.LayerStore{Dir: param.LayerStore})
+	}
+	uv3dp.SetDecodeWarning(func(issue error) {
+		TraceVerbosef(VerbosityWarning, "%v", issue)
+	})
+
+	if args[0] == "stats" {
+		cmd := NewStatsCommand()
+		err = cmd.Parse(args[1:])
+		if err != nil {
+			return
+		}
+
+		err = cmd.Run()
+		return
+	}
+
+	if args[0] == "convert" {
+		err = machinesLoadOnce()
+		if err != nil {
+			return
+		}
+
+		cmd := NewConvertCommand()
+		err = cmd.Parse(args[1:])
+		if err != nil {
+			return
+		}
+
+		err = cmd.Run()
+		return
+	}
+
+	if args[0] == "log" {
+		cmd := NewHistoryCommand()
+		err = cmd.Parse(args[1:])
+		if err != nil {
+			return
+		}
+
+		err = cmd.Run()
+		return
+	}
+
+	if args[0] == "self-update" {
+		cmd := NewUpdateCommand()
+		err = cmd.Parse(args[1:])
+		if err != nil {
+			return
+		}
+
+		err = cmd.Run()
+		return
+	}
+
+	if args[0] == "debug" {
+		cmd := NewDebugCommand()
+		err = cmd.Parse(args[1:])
+		if err != nil {
+			return
+		}
+
+		err = cmd.Run()
+		return
+	}
+
+	if args[0] == "age" {
+		err = machinesLoadOnce()
+		if err != nil {
+			return
+		}
+
+		cmd := NewAgeCommand()
+		err = cmd.Parse(args[1:])
+		if err != nil {
+			return
+		}
+
+		err = cmd.Run()
+		return
+	}
+
+	if args[0] == "import" {
+		cmd := NewImportCommand()
+		err = cmd.Parse(args[1:])
+		if err != nil {
+			return
+		}
+
+		err = cmd.Run()
+		return
+	}
+
+	if args[0] == "resin" {
+		cmd := NewResinCommand()
+		err = cmd.Parse(args[1:])
+		if err != nil {
+			return
+		}
+
+		err = cmd.Run()
+		return
+	}
+
+	if args[0] == "formats" {
+		cmd := NewFormatsCommand()
+		err = cmd.Parse(args[1:])
+		if err != nil {
+			return
+		}
+
+		err = cmd.Run()
+		return
+	}
+
+	// Files and their commands may use the user machines
+	err = machinesLoadOnce()
+	if err != nil {
+		return
+	}
+
 	var input uv3dp.Printable
 	var format *uv3dp.Format
+	var inputSuffix string
+	var inputName string
+
+	var report *Report
+	reportWriter := io.Writer(os.Stdout)
+	if param.Report {
+		report = NewReport()
+	}
+
+	// Start of the work for the next output
+	start := time.Now()
 
 	for len(args) > 0 {
 		if args[0] == "help" {
@@ -182,25 +543,69 @@ func evaluate(args []string) (err error) {
 
 		item, found := commandMap[args[0]]
 		if !found {
-			format, err = uv3dp.NewFormat(args[0], args[1:])
-			if err != nil {
-				return err
+			// Per-file format type override, ie 'foo.photon -t ctb'
+			formatType := param.Format
+			fileArgs := args[1:]
+			if len(fileArgs) > 0 && strings.HasPrefix(fileArgs[0], "--format=") {
+				formatType = strings.TrimPrefix(fileArgs[0], "--format=")
+				fileArgs = fileArgs[1:]
+			} else if len(fileArgs) > 0 && (fileArgs[0] == "-t" || fileArgs[0] == "--format") {
+				if len(fileArgs) < 2 {
+					err = fmt.Errorf("%v: %v requires a file format type", args[0], fileArgs[0])
+					return
+				}
+				formatType = fileArgs[1]
+				fileArgs = fileArgs[2:]
 			}
-			err = format.Parse(args[1:])
+
+			filename := args[0]
+			if input != nil && formatType == "" && param.Machine != "" {
+				// Output file format, from the machine profile
+				var machineArgs []string
+				filename, formatType, machineArgs, err = MachineOutput(param.Machine, filename, inputName)
+				if err != nil {
+					return
+				}
+				fileArgs = append(machineArgs, fileArgs...)
+			}
+
+			format, err = uv3dp.NewFormatType(filename, formatType, fileArgs)
 			if err != nil {
 				return err
 			}
 			TraceVerbosef(VerbosityNotice, "%v", args)
 			args = format.Args()
 
+			if report != nil {
+				report.AddFile(format, fileArgs[:len(fileArgs)-len(args)])
+			}
+
 			if input == nil {
+				if param.Progress && uv3dp.IsURL(format.Filename) {
+					uv3dp.SetProgress(&cliProgress{Format: format})
+				}
+
 				// If we have no input, get it from this file
 				input, err = format.Printable()
 				TraceVerbosef(VerbosityDebug, "%v: Input (err: %v)", format.Filename, err)
 				if err != nil {
 					return
 				}
+				inputSuffix = format.Suffix
+				inputName = format.Filename
 			} else {
+				// Work around the quirks of the machine's firmware
+				input, err = FirmwareFilter(input, param.Machine, param.Firmware)
+				if err != nil {
+					return
+				}
+
+				// Blank the screen areas the machine must not print on
+				input, err = ExclusionFilter(input, param.Machine)
+				if err != nil {
+					return
+				}
+
 				// Check the file before saving
 				input, err = CheckFilter(input)
 				if err != nil {
@@ -212,11 +617,36 @@ func evaluate(args []string) (err error) {
 					uv3dp.SetProgress(&cliProgress{Format: format})
 				}
 
-				err = format.SetPrintable(input)
+				output := input
+				if report != nil {
+					output = &reportModifier{Printable: input, pipeline: report.Pipeline()}
+					if format.Filename == "-" {
+						reportWriter = os.Stderr
+					}
+				}
+
+				err = format.SetPrintable(output)
 				TraceVerbosef(VerbosityDebug, "%v: Output (err: %v)", format.Filename, err)
 				if err != nil {
 					return
 				}
+
+				if param.Stats != "" {
+					record := StatsRecord{
+						Time:     time.Now(),
+						Input:    inputSuffix,
+						Output:   format.Suffix,
+						Layers:   input.Size().Layers,
+						Duration: time.Since(start).Seconds(),
+					}
+					// Statistics are advisory, so don't fail the conversion
+					statsErr := StatsAppend(param.Stats, record)
+					if statsErr != nil {
+						TraceVerbosef(VerbosityWarning, "%v: %v", param.Stats, statsErr)
+					}
+				}
+
+				start = time.Now()
 			}
 		} else if input != nil {
 			cmd := item.NewCommander()
@@ -225,8 +655,13 @@ func evaluate(args []string) (err error) {
 				return
 			}
 			TraceVerbosef(VerbosityNotice, "%v", args)
+			name, consumed := args[0], args[1:len(args)-cmd.NArg()]
 			args = cmd.Args()
 
+			if report != nil {
+				report.AddCommand(name, cmd, consumed)
+			}
+
 			input, err = cmd.Filter(input)
 			if err != nil {
 				return
@@ -237,6 +672,10 @@ func evaluate(args []string) (err error) {
 		}
 	}
 
+	if report != nil {
+		err = report.Write(reportWriter)
+	}
+
 	return
 }
 
@@ -265,8 +704,6 @@ func argExpand(in []string) (out []string, err error) {
 }
 
 func main() {
-	fmt.Println("hello")
-
 	var err error
 	os.Args, err = argExpand(os.Args)
 	if err != nil {
@@ -275,7 +712,12 @@ func main() {
 
 	pflag.Parse()
 
-	err = evaluate(pflag.Args())
+	args := pflag.Args()
+	if len(args) == 0 || args[0] != "self-update" {
+		UpdateNotice(os.Stderr, UpdatePath, time.Now())
+	}
+
+	err = evaluate(args)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,199 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type MergeCommand struct {
+	*pflag.FlagSet
+
+	Input   string
+	OffsetX int
+	OffsetY int
+}
+
+func NewMergeCommand() (cmd *MergeCommand) {
+	flagSet := pflag.NewFlagSet("merge", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &MergeCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Input, "input", "i", "", "Second printable to merge onto the plate")
+	cmd.IntVarP(&cmd.OffsetX, "offset-x", "", 0, "Offset of the second printable in X, in pixels")
+	cmd.IntVarP(&cmd.OffsetY, "offset-y", "", 0, "Offset of the second printable in Y, in pixels")
+
+	return
+}
+
+// mergeModifier combines the layers of a second printable with the input's
+type mergeModifier struct {
+	uv3dp.Printable
+
+	other  uv3dp.Printable
+	offset image.Point
+	layers int
+}
+
+func (mm *mergeModifier) Size() (size uv3dp.Size) {
+	size = mm.Printable.Size()
+	size.Layers = mm.layers
+
+	return
+}
+
+// source is the printable that supplies the properties of a layer
+func (mm *mergeModifier) source(index int) uv3dp.Printable {
+	if index >= mm.Printable.Size().Layers {
+		return mm.other
+	}
+
+	return mm.Printable
+}
+
+func (mm *mergeModifier) LayerZ(index int) float32 {
+	return mm.source(index).LayerZ(index)
+}
+
+func (mm *mergeModifier) LayerExposure(index int) uv3dp.Exposure {
+	return mm.source(index).LayerExposure(index)
+}
+
+func (mm *mergeModifier) LayerImage(index int) (grayImage *image.Gray) {
+	size := mm.Printable.Size()
+	if index < size.Layers {
+		srcImage := mm.Printable.LayerImage(index)
+		grayImage = image.NewGray(srcImage.Bounds())
+		copy(grayImage.Pix, srcImage.Pix)
+	} else {
+		grayImage = image.NewGray(image.Rect(0, 0, size.X, size.Y))
+	}
+
+	if index >= mm.other.Size().Layers {
+		return
+	}
+
+	otherImage := mm.other.LayerImage(index)
+	bounds := otherImage.Bounds()
+
+	// Keep the brightest of the two pixels (a logical OR for bi-level images)
+	dstRect := bounds.Add(mm.offset).Intersect(grayImage.Bounds())
+	for y := dstRect.Min.Y; y < dstRect.Max.Y; y++ {
+		for x := dstRect.Min.X; x < dstRect.Max.X; x++ {
+			pix := otherImage.GrayAt(x-mm.offset.X, y-mm.offset.Y).Y
+			offset := grayImage.PixOffset(x, y)
+			if pix > grayImage.Pix[offset] {
+				grayImage.Pix[offset] = pix
+			}
+		}
+	}
+
+	return
+}
+
+// MergeCheck checks that a printable can be merged onto a plate: that
+// they have the same resolution, bed size and layer height
+func MergeCheck(name string, size uv3dp.Size, otherSize uv3dp.Size) (err error) {
+	if size.X != otherSize.X || size.Y != otherSize.Y {
+		err = fmt.Errorf("%v: resolution %vx%v does not match %vx%v",
+			name, otherSize.X, otherSize.Y, size.X, size.Y)
+		return
+	}
+
+	if math.Abs(float64(size.Millimeter.X-otherSize.Millimeter.X)) > 0.001 ||
+		math.Abs(float64(size.Millimeter.Y-otherSize.Millimeter.Y)) > 0.001 {
+		err = fmt.Errorf("%v: bed size %vx%v mm does not match %vx%v mm",
+			name, otherSize.Millimeter.X, otherSize.Millimeter.Y, size.Millimeter.X, size.Millimeter.Y)
+		return
+	}
+
+	if math.Abs(float64(size.LayerHeight-otherSize.LayerHeight)) > 0.0001 {
+		err = fmt.Errorf("%v: layer height %v mm does not match %v mm",
+			name, otherSize.LayerHeight, size.LayerHeight)
+		return
+	}
+
+	return
+}
+
+func (cmd *MergeCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Input == "" {
+		err = fmt.Errorf("merge: --input must be specified")
+		return
+	}
+
+	format, err := uv3dp.NewFormat(cmd.Input, nil)
+	if err != nil {
+		return
+	}
+
+	other, err := format.Printable()
+	if err != nil {
+		return
+	}
+
+	size := input.Size()
+	otherSize := other.Size()
+
+	err = MergeCheck(cmd.Input, size, otherSize)
+	if err != nil {
+		err = fmt.Errorf("merge: %w", err)
+		return
+	}
+
+	offset := image.Point{X: cmd.OffsetX, Y: cmd.OffsetY}
+
+	bounds := uv3dp.ModelBounds(other)
+	moved := bounds.Add(offset)
+	if !moved.In(image.Rect(0, 0, size.X, size.Y)) {
+		err = fmt.Errorf("merge: %v: model at %v would be moved off of the %dx%d bed, to %v",
+			cmd.Input, bounds, size.X, size.Y, moved)
+		return
+	}
+
+	if moved.Overlaps(uv3dp.ModelBounds(input)) {
+		TraceVerbosef(VerbosityWarning, "merge: %v: model at %v overlaps the existing model", cmd.Input, moved)
+	}
+
+	if input.Exposure() != other.Exposure() || input.Bottom() != other.Bottom() {
+		TraceVerbosef(VerbosityWarning, "merge: %v: exposure differs, using the exposure of the input", cmd.Input)
+	}
+
+	layers := size.Layers
+	if otherSize.Layers > layers {
+		layers = otherSize.Layers
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Merging %v layers of %v at %v", otherSize.Layers, cmd.Input, moved)
+
+	output = &mergeModifier{
+		Printable: input,
+		other:     other,
+		offset:    offset,
+		layers:    layers,
+	}
+
+	output = uv3dp.RemapLayers(output,
+		uv3dp.LayerRemap{
+			Printable: input,
+			Remap:     func(index int) (int, bool) { return index, true },
+		},
+		uv3dp.LayerRemap{
+			Printable: other,
+			Remap:     func(index int) (int, bool) { return index, true },
+		},
+	)
+
+	return
+}
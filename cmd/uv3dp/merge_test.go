@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestMerge(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 100, Y: 100, Layers: 2, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 50, Y: 50},
+		},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	prop.Size.Layers = 3
+	other := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	output := &mergeModifier{
+		Printable: input,
+		other:     other,
+		offset:    image.Point{X: 50, Y: 20},
+		layers:    3,
+	}
+
+	if output.Size().Layers != 3 {
+		t.Errorf("expected 3 layers, got %v", output.Size().Layers)
+	}
+
+	table := []struct {
+		Index  int
+		Bounds image.Rectangle
+	}{
+		{Index: 0, Bounds: image.Rect(10, 10, 80, 50)},
+		{Index: 2, Bounds: image.Rect(60, 30, 80, 50)},
+	}
+
+	for _, item := range table {
+		bounds := uv3dp.ImageBounds(output.LayerImage(item.Index))
+		if bounds != item.Bounds {
+			t.Errorf("layer %v: expected %v, got %v", item.Index, item.Bounds, bounds)
+		}
+	}
+
+	cmd := NewMergeCommand()
+	_, err := cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error without --input")
+	}
+}
+
+func TestMergeAnnotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 100, Y: 100, Layers: 3, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 50, Y: 50},
+		},
+	}
+
+	input := &uv3dp.AnnotatedPrintable{
+		Printable: &scalePrint{
+			Print: uv3dp.Print{Properties: prop},
+			model: image.Rect(10, 10, 30, 30),
+		},
+		Annotations: uv3dp.Annotations{0: "Raft lifted", 2: "Stringing"},
+	}
+
+	other := &uv3dp.AnnotatedPrintable{
+		Printable: &scalePrint{
+			Print: uv3dp.Print{Properties: prop},
+			model: image.Rect(10, 10, 30, 30),
+		},
+		Annotations: uv3dp.Annotations{1: "Support failure", 2: "Warped"},
+	}
+
+	filename := filepath.Join(dir, "other.uvj")
+	format, err := uv3dp.NewFormat(filename, nil)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	err = format.SetPrintable(other)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	cmd := NewMergeCommand()
+	err = cmd.Parse([]string{"--input", filename, "--offset-x", "50"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := uv3dp.Annotations{0: "Raft lifted", 1: "Support failure", 2: "Stringing; Warped"}
+	annotations := uv3dp.LayerAnnotations(output)
+	if !cmp.Equal(expected, annotations) {
+		t.Errorf("expected %v, got %v", expected, annotations)
+	}
+}
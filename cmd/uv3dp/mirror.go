@@ -0,0 +1,56 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type MirrorCommand struct {
+	*pflag.FlagSet
+
+	Horizontal bool
+	Vertical   bool
+}
+
+func NewMirrorCommand() (cmd *MirrorCommand) {
+	flagSet := pflag.NewFlagSet("mirror", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &MirrorCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.BoolVarP(&cmd.Horizontal, "horizontal", "x", false, "Mirror layers horizontally (default if no direction is given)")
+	cmd.BoolVarP(&cmd.Vertical, "vertical", "y", false, "Mirror layers vertically")
+
+	return
+}
+
+func (cmd *MirrorCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	mm := &uv3dp.MirroredPrintable{
+		Printable:  input,
+		Horizontal: cmd.Horizontal,
+		Vertical:   cmd.Vertical,
+	}
+
+	if !cmd.Changed("horizontal") && !cmd.Changed("vertical") {
+		mm.Horizontal = true
+	}
+
+	if mm.Horizontal {
+		TraceVerbosef(VerbosityNotice, "  Mirroring layers horizontally")
+	}
+
+	if mm.Vertical {
+		TraceVerbosef(VerbosityNotice, "  Mirroring layers vertically")
+	}
+
+	output = mm
+
+	return
+}
@@ -0,0 +1,50 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type mirrorPrint struct {
+	uv3dp.Print
+}
+
+func (mp *mirrorPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(image.Rect(0, 0, 3, 2))
+	copy(grayImage.Pix, []uint8{1, 2, 3, 4, 5, 6})
+	return
+}
+
+func TestMirror(t *testing.T) {
+	table := map[string]struct {
+		Args []string
+		Pix  []uint8
+	}{
+		"default":    {Args: []string{}, Pix: []uint8{3, 2, 1, 6, 5, 4}},
+		"horizontal": {Args: []string{"-x"}, Pix: []uint8{3, 2, 1, 6, 5, 4}},
+		"vertical":   {Args: []string{"-y"}, Pix: []uint8{4, 5, 6, 1, 2, 3}},
+		"both":       {Args: []string{"-x", "-y"}, Pix: []uint8{6, 5, 4, 3, 2, 1}},
+	}
+
+	input := &mirrorPrint{}
+
+	for name, item := range table {
+		cmd := NewMirrorCommand()
+		err := cmd.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		output, _ := cmd.Filter(input)
+		got := output.LayerImage(0).Pix
+		if string(got) != string(item.Pix) {
+			t.Errorf("%v: expected %v, got %v", name, item.Pix, got)
+		}
+	}
+}
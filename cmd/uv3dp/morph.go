@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type MorphCommand struct {
+	*pflag.FlagSet
+
+	Operation  string
+	Radius     int
+	Iterations int
+	First      int
+	Count      int
+}
+
+func NewMorphCommand() (cmd *MorphCommand) {
+	flagSet := pflag.NewFlagSet("morph", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &MorphCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Operation, "operation", "o", "erode", "Operation: 'erode', 'dilate', 'open' (erode, then dilate), or 'close' (dilate, then erode)")
+	cmd.IntVarP(&cmd.Radius, "radius", "r", 1, "Radius of the operation, in pixels")
+	cmd.IntVarP(&cmd.Iterations, "iterations", "n", 1, "Number of times to apply the operation")
+	cmd.IntVarP(&cmd.First, "first", "f", 0, "First layer to apply the operation to")
+	cmd.IntVarP(&cmd.Count, "count", "c", -1, "Count of layers to apply the operation to (-1 for all layers after first)")
+
+	return
+}
+
+func (cmd *MorphCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	op, err := uv3dp.ParseMorphOperation(cmd.Operation)
+	if err != nil {
+		err = fmt.Errorf("morph: %w", err)
+		return
+	}
+
+	if cmd.Radius < 1 {
+		err = fmt.Errorf("morph: --radius must be at least 1")
+		return
+	}
+
+	if cmd.Iterations < 1 {
+		err = fmt.Errorf("morph: --iterations must be at least 1")
+		return
+	}
+
+	layers := input.Size().Layers
+
+	count := cmd.Count
+	if count < 0 || cmd.First+count > layers {
+		count = layers - cmd.First
+	}
+
+	mp := uv3dp.NewMorphedPrintable(input, op)
+	mp.Radius = cmd.Radius
+	mp.Iterations = cmd.Iterations
+	mp.FirstLayer = cmd.First
+	mp.Layers = count
+
+	TraceVerbosef(VerbosityNotice, "  Applying %v (radius %v, %v times) to layers %v..%v",
+		op, cmd.Radius, cmd.Iterations, cmd.First, cmd.First+count-1)
+
+	output = mp
+
+	return
+}
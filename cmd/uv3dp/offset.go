@@ -0,0 +1,100 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type OffsetCommand struct {
+	*pflag.FlagSet
+
+	Pixels      []int
+	Millimeters []float32
+}
+
+func NewOffsetCommand() (cmd *OffsetCommand) {
+	flagSet := pflag.NewFlagSet("offset", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &OffsetCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.IntSliceVarP(&cmd.Pixels, "pixels", "p", []int{0, 0}, "Offset of the model in X and Y, in pixels")
+	cmd.Float32SliceVarP(&cmd.Millimeters, "millimeters", "m", []float32{0, 0}, "Offset of the model in X and Y, in millimeters")
+
+	return
+}
+
+// offsetModifier moves the model of all layers
+type offsetModifier struct {
+	uv3dp.Printable
+
+	offset image.Point
+}
+
+func (om *offsetModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := om.Printable.LayerImage(index)
+	bounds := srcImage.Bounds()
+
+	grayImage = image.NewGray(bounds)
+	draw.Draw(grayImage, bounds.Add(om.offset), srcImage, bounds.Min, draw.Src)
+
+	return
+}
+
+func (cmd *OffsetCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	size := input.Size()
+
+	var offset image.Point
+
+	if cmd.Changed("pixels") {
+		if len(cmd.Pixels) != 2 {
+			err = fmt.Errorf("offset: --pixels expects two values")
+			return
+		}
+		offset.X += cmd.Pixels[0]
+		offset.Y += cmd.Pixels[1]
+	}
+
+	if cmd.Changed("millimeters") {
+		if len(cmd.Millimeters) != 2 {
+			err = fmt.Errorf("offset: --millimeters expects two values")
+			return
+		}
+		offset.X += int(math.Round(float64(cmd.Millimeters[0] * float32(size.X) / size.Millimeter.X)))
+		offset.Y += int(math.Round(float64(cmd.Millimeters[1] * float32(size.Y) / size.Millimeter.Y)))
+	}
+
+	output = input
+
+	if offset == (image.Point{}) {
+		return
+	}
+
+	bounds := uv3dp.ModelBounds(input)
+	moved := bounds.Add(offset)
+	if !moved.In(image.Rect(0, 0, size.X, size.Y)) {
+		err = fmt.Errorf("offset: model at %v would be moved off of the %dx%d bed, to %v", bounds, size.X, size.Y, moved)
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Moving model by %v pixels, from %v to %v", offset, bounds, moved)
+
+	output = &offsetModifier{
+		Printable: input,
+		offset:    offset,
+	}
+
+	return
+}
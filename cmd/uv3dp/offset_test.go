@@ -0,0 +1,19 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestOffset(t *testing.T) {
+	testBounds(t, func() Commander { return NewOffsetCommand() }, image.Rect(40, 30, 60, 50), map[string]boundsTest{
+		"none":        {Args: []string{}, Bounds: image.Rect(40, 30, 60, 50), Valid: true},
+		"pixels":      {Args: []string{"-p", "-10,5"}, Bounds: image.Rect(30, 35, 50, 55), Valid: true},
+		"millimeters": {Args: []string{"-m", "5,-2.5"}, Bounds: image.Rect(50, 25, 70, 45), Valid: true},
+		"off-bed":     {Args: []string{"-p", "50,0"}},
+	})
+}
@@ -0,0 +1,178 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type OverhangCommand struct {
+	*pflag.FlagSet
+
+	Ratio     float32
+	Tolerance int
+	MinArea   int
+	MaxRisk   float32
+}
+
+func NewOverhangCommand() (cmd *OverhangCommand) {
+	flagSet := pflag.NewFlagSet("overhang", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &OverhangCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.Float32VarP(&cmd.Ratio, "ratio", "r", 10, "Flag overhangs larger than this percentage of the area of the layer below")
+	cmd.IntVarP(&cmd.Tolerance, "tolerance", "t", 2, "Growth from the layer below that is not an overhang, in pixels")
+	cmd.IntVarP(&cmd.MinArea, "min-area", "a", 1, "Ignore overhangs smaller than this area, in pixels")
+	cmd.Float32VarP(&cmd.MaxRisk, "max-risk", "m", 0, "Fail if the risk score is above this (0 to never fail)")
+
+	return
+}
+
+// OverhangLayer is the flagged overhangs of a layer
+type OverhangLayer struct {
+	Layer     int
+	Below     int            // Lit area of the layer below, in pixels
+	Overhangs []uv3dp.Island // Overhangs, with the largest first
+}
+
+// OverhangResult is the overhang analysis of a printable
+type OverhangResult struct {
+	Layers  []OverhangLayer // Layers with flagged overhangs
+	Area    int             // Lit area of all layers, in pixels
+	Flagged int             // Area of all flagged overhangs, in pixels
+}
+
+// Risk is the percentage of the lit area of the printable in flagged overhangs
+func (result *OverhangResult) Risk() float32 {
+	if result.Area == 0 {
+		return 0
+	}
+
+	return 100 * float32(result.Flagged) / float32(result.Area)
+}
+
+// overhangArea returns the number of lit pixels of an image
+func overhangArea(img *image.Gray) (area int) {
+	for _, pix := range img.Pix {
+		if pix != 0 {
+			area++
+		}
+	}
+
+	return
+}
+
+// OverhangFind returns the areas of a layer outside of the layer below,
+// grown by a tolerance.
+func OverhangFind(below, layer *image.Gray, tolerance int) (overhangs []uv3dp.Island) {
+	support := uv3dp.MorphImage(below, uv3dp.MorphDilate, tolerance)
+
+	mask := image.NewGray(layer.Bounds())
+	for n, pix := range layer.Pix {
+		if pix != 0 && support.Pix[n] == 0 {
+			mask.Pix[n] = 0xff
+		}
+	}
+
+	// With nothing below it, every area of the mask is an island
+	overhangs = uv3dp.LayerIslands(image.NewGray(layer.Bounds()), mask)
+
+	return
+}
+
+// OverhangAnalyze compares each layer to the one below, flagging overhangs
+// of at least a minimum area that are larger than a percentage of the
+// area of the layer below.
+func OverhangAnalyze(input uv3dp.Printable, ratio float32, tolerance int, minArea int) (result OverhangResult) {
+	var below *image.Gray
+	belowArea := 0
+
+	for n := 0; n < input.Size().Layers; n++ {
+		layer := input.LayerImage(n)
+		area := overhangArea(layer)
+		result.Area += area
+
+		if below != nil {
+			item := OverhangLayer{Layer: n, Below: belowArea}
+			for _, overhang := range OverhangFind(below, layer, tolerance) {
+				if overhang.Area < minArea || float32(overhang.Area) <= float32(belowArea)*ratio/100 {
+					continue
+				}
+				item.Overhangs = append(item.Overhangs, overhang)
+				result.Flagged += overhang.Area
+			}
+
+			if len(item.Overhangs) > 0 {
+				overhangs := item.Overhangs
+				sort.SliceStable(overhangs, func(i, j int) bool { return overhangs[i].Area > overhangs[j].Area })
+				result.Layers = append(result.Layers, item)
+			}
+		}
+
+		below = layer
+		belowArea = area
+	}
+
+	return
+}
+
+// OverhangReport shows the flagged overhangs of each layer, and the risk score
+func OverhangReport(writer io.Writer, size uv3dp.Size, result OverhangResult) {
+	pixelX := size.Millimeter.X / float32(size.X)
+	pixelY := size.Millimeter.Y / float32(size.Y)
+
+	for _, item := range result.Layers {
+		fmt.Fprintf(writer, "Layer %v: %v overhangs\n", item.Layer, len(item.Overhangs))
+		for _, overhang := range item.Overhangs {
+			center := overhang.Bounds.Min.Add(overhang.Bounds.Max).Div(2)
+			percent := float32(100)
+			if item.Below > 0 {
+				percent = 100 * float32(overhang.Area) / float32(item.Below)
+			}
+			fmt.Fprintf(writer, "  %v pixels (%.1f%% of layer below) at %.2f,%.2f mm, %v\n",
+				overhang.Area, percent, float32(center.X)*pixelX, float32(center.Y)*pixelY, overhang.Bounds)
+		}
+	}
+
+	fmt.Fprintf(writer, "Layers with overhangs: %v of %v\n", len(result.Layers), size.Layers)
+	fmt.Fprintf(writer, "Risk score: %.2f\n", result.Risk())
+}
+
+func (cmd *OverhangCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Ratio < 0 {
+		err = fmt.Errorf("overhang: --ratio must not be negative")
+		return
+	}
+
+	if cmd.Tolerance < 0 {
+		err = fmt.Errorf("overhang: --tolerance must not be negative")
+		return
+	}
+
+	result := OverhangAnalyze(input, cmd.Ratio, cmd.Tolerance, cmd.MinArea)
+	OverhangReport(os.Stdout, input.Size(), result)
+
+	TraceVerbosef(VerbosityNotice, "  %v layers with overhangs, risk score %.2f", len(result.Layers), result.Risk())
+
+	if cmd.MaxRisk > 0 && result.Risk() > cmd.MaxRisk {
+		err = fmt.Errorf("overhang: risk score %.2f is above %.2f", result.Risk(), cmd.MaxRisk)
+		return
+	}
+
+	output = input
+
+	return
+}
@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// overhangPrint is a 10x10 pixel square on layer 0, grown by one
+// pixel on layer 1, and with a ledge of 5 pixels on layer 2
+type overhangPrint struct {
+	uv3dp.Print
+}
+
+func (op *overhangPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(op.Properties.Bounds())
+
+	rect := image.Rect(10, 10, 20, 20)
+	switch index {
+	case 1:
+		rect = rect.Inset(-1)
+	case 2:
+		rect = image.Rect(9, 9, 26, 21)
+	}
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			grayImage.Pix[grayImage.PixOffset(x, y)] = 0xff
+		}
+	}
+
+	return
+}
+
+func TestOverhangFind(t *testing.T) {
+	below := image.NewGray(image.Rect(0, 0, 8, 8))
+	layer := image.NewGray(below.Rect)
+	for y := 2; y < 6; y++ {
+		for x := 2; x < 6; x++ {
+			below.Pix[below.PixOffset(x, y)] = 0xff
+			layer.Pix[layer.PixOffset(x+2, y)] = 0xff
+		}
+	}
+
+	overhangs := OverhangFind(below, layer, 0)
+	if len(overhangs) != 1 || overhangs[0].Area != 8 || overhangs[0].Bounds != image.Rect(6, 2, 8, 6) {
+		t.Errorf("expected one 8 pixel overhang, got %+v", overhangs)
+	}
+
+	overhangs = OverhangFind(below, layer, 2)
+	if len(overhangs) != 0 {
+		t.Errorf("expected no overhangs within the tolerance, got %+v", overhangs)
+	}
+}
+
+func TestOverhang(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 40, Y: 40, Layers: 3, LayerHeight: 0.05},
+	}
+	prop.Size.Millimeter.X = 20
+	prop.Size.Millimeter.Y = 20
+
+	input := &overhangPrint{Print: uv3dp.Print{Properties: prop}}
+
+	// Only the layer 2 ledge is larger than 10% of the layer below
+	result := OverhangAnalyze(input, 10, 1, 1)
+	if len(result.Layers) != 1 || result.Layers[0].Layer != 2 {
+		t.Fatalf("expected overhangs on layer 2, got %+v", result.Layers)
+	}
+
+	overhang := result.Layers[0].Overhangs[0]
+	if overhang.Area != 48 || overhang.Bounds != image.Rect(22, 9, 26, 21) {
+		t.Errorf("expected a 48 pixel ledge, got %+v", overhang)
+	}
+
+	expected := float32(100*48) / float32(100+144+204)
+	if result.Risk() != expected {
+		t.Errorf("expected risk %v, got %v", expected, result.Risk())
+	}
+
+	buff := &bytes.Buffer{}
+	OverhangReport(buff, input.Size(), result)
+	if !strings.Contains(buff.String(), "Layer 2: 1 overhangs") || !strings.Contains(buff.String(), "Risk score: 10.71") {
+		t.Errorf("unexpected report %q", buff.String())
+	}
+
+	// Without a tolerance, the layer 1 growth is 44% of layer 0, and
+	// the layer 2 ledge is 42% of layer 1
+	result = OverhangAnalyze(input, 43, 0, 1)
+	if len(result.Layers) != 1 || result.Layers[0].Layer != 1 || result.Layers[0].Overhangs[0].Area != 44 {
+		t.Errorf("expected a 44 pixel overhang on layer 1, got %+v", result.Layers)
+	}
+
+	cmd := NewOverhangCommand()
+	cmd.Parse([]string{"--max-risk", "5"})
+	_, err := cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for a risk score above the maximum")
+	}
+}
@@ -0,0 +1,209 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type PackCommand struct {
+	*pflag.FlagSet
+
+	Inputs  []string
+	Spacing float32
+}
+
+func NewPackCommand() (cmd *PackCommand) {
+	flagSet := pflag.NewFlagSet("pack", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &PackCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringSliceVarP(&cmd.Inputs, "input", "i", []string{}, "Other printables to pack onto the plate")
+	cmd.Float32VarP(&cmd.Spacing, "spacing", "s", 2.0, "Space between the models, and around the edges of the plate, in millimeters")
+
+	return
+}
+
+// PackShelves arranges rectangles of some sizes on a plate in shelves,
+// tallest first, with spacing between them, and returns where each one
+// is placed. The arrangement is centered on the plate.
+func PackShelves(sizes []image.Point, plate image.Rectangle, spacing image.Point) (places []image.Point, err error) {
+	order := make([]int, len(sizes))
+	for n := range order {
+		order[n] = n
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return sizes[order[i]].Y > sizes[order[j]].Y
+	})
+
+	places = make([]image.Point, len(sizes))
+
+	at := plate.Min
+	shelf := 0
+	var used image.Rectangle
+	for placed, n := range order {
+		size := sizes[n]
+		if at.X > plate.Min.X && at.X+size.X > plate.Max.X {
+			at = image.Pt(plate.Min.X, at.Y+shelf+spacing.Y)
+			shelf = 0
+		}
+
+		place := image.Rectangle{Min: at, Max: at.Add(size)}
+		if !place.In(plate) {
+			err = fmt.Errorf("only %v of %v models fit on the plate", placed, len(sizes))
+			return
+		}
+
+		places[n] = at
+		used = used.Union(place)
+
+		at.X += size.X + spacing.X
+		if size.Y > shelf {
+			shelf = size.Y
+		}
+	}
+
+	offset := CenterOffset(used, plate)
+	for n := range places {
+		places[n] = places[n].Add(offset)
+	}
+
+	return
+}
+
+// PackPrintables packs the models of other printables, and of the
+// input, onto the input's plate
+func PackPrintables(input uv3dp.Printable, others []uv3dp.Printable, names []string, spacing float32) (output uv3dp.Printable, err error) {
+	size := input.Size()
+
+	printables := append([]uv3dp.Printable{input}, others...)
+	names = append([]string{"input"}, names...)
+
+	for n, other := range others {
+		err = MergeCheck(names[n+1], size, other.Size())
+		if err != nil {
+			return
+		}
+	}
+
+	toPixel := func(mm float32, pixels int, millimeters float32) int {
+		return int(math.Round(float64(mm * float32(pixels) / millimeters)))
+	}
+
+	space := image.Pt(
+		toPixel(spacing, size.X, size.Millimeter.X),
+		toPixel(spacing, size.Y, size.Millimeter.Y),
+	)
+	plate := image.Rectangle{Min: space, Max: image.Pt(size.X, size.Y).Sub(space)}
+
+	bounds := make([]image.Rectangle, len(printables))
+	sizes := make([]image.Point, len(printables))
+	for n, printable := range printables {
+		bounds[n] = uv3dp.ModelBounds(printable)
+		if bounds[n].Empty() {
+			err = fmt.Errorf("%v: no model to pack", names[n])
+			return
+		}
+		sizes[n] = bounds[n].Size()
+
+		if sizes[n].X > plate.Dx() || sizes[n].Y > plate.Dy() {
+			err = fmt.Errorf("%v: model is larger than the %.4g x %.4g mm plate, less the spacing",
+				names[n], size.Millimeter.X, size.Millimeter.Y)
+			return
+		}
+	}
+
+	places, err := PackShelves(sizes, plate, space)
+	if err != nil {
+		return
+	}
+
+	output = input
+	if offset := places[0].Sub(bounds[0].Min); offset != (image.Point{}) {
+		output = &offsetModifier{
+			Printable: input,
+			offset:    offset,
+		}
+	}
+
+	for n, other := range others {
+		if other.Exposure() != input.Exposure() || other.Bottom() != input.Bottom() {
+			TraceVerbosef(VerbosityWarning, "pack: %v: exposure differs, using the exposure of the input", names[n+1])
+		}
+
+		TraceVerbosef(VerbosityNotice, "  Packing %v at %v", names[n+1], places[n+1])
+
+		layers := output.Size().Layers
+		if other.Size().Layers > layers {
+			layers = other.Size().Layers
+		}
+
+		output = &mergeModifier{
+			Printable: output,
+			other:     other,
+			offset:    places[n+1].Sub(bounds[n+1].Min),
+			layers:    layers,
+		}
+	}
+
+	// Keep the annotations of all of the printables
+	remaps := []uv3dp.LayerRemap{}
+	for _, printable := range printables {
+		remaps = append(remaps, uv3dp.LayerRemap{
+			Printable: printable,
+			Remap:     func(index int) (int, bool) { return index, true },
+		})
+	}
+
+	output = uv3dp.RemapLayers(output, remaps...)
+
+	return
+}
+
+func (cmd *PackCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if len(cmd.Inputs) == 0 {
+		err = fmt.Errorf("pack: --input must be specified")
+		return
+	}
+
+	if cmd.Spacing < 0 {
+		err = fmt.Errorf("pack: --spacing must not be negative")
+		return
+	}
+
+	others := []uv3dp.Printable{}
+	for _, name := range cmd.Inputs {
+		var format *uv3dp.Format
+		format, err = uv3dp.NewFormat(name, nil)
+		if err != nil {
+			return
+		}
+
+		var other uv3dp.Printable
+		other, err = format.Printable()
+		if err != nil {
+			return
+		}
+
+		others = append(others, other)
+	}
+
+	output, err = PackPrintables(input, others, cmd.Inputs, cmd.Spacing)
+	if err != nil {
+		err = fmt.Errorf("pack: %w", err)
+	}
+
+	return
+}
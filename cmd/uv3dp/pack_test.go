@@ -0,0 +1,131 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestPackShelves(t *testing.T) {
+	plate := image.Rect(4, 4, 96, 96)
+	spacing := image.Pt(4, 4)
+
+	sizes := []image.Point{{40, 30}, {40, 50}, {30, 20}}
+	places, err := PackShelves(sizes, plate, spacing)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	// Tallest first, and the last on a second shelf, centered on the plate
+	expected := []image.Point{{52, 13}, {8, 13}, {8, 67}}
+	if !cmp.Equal(expected, places) {
+		t.Errorf("expected %v, got %v", expected, places)
+	}
+
+	_, err = PackShelves([]image.Point{{60, 60}, {60, 60}}, plate, spacing)
+	if err == nil {
+		t.Errorf("expected an error for models that do not fit")
+	}
+}
+
+func TestPackPrintables(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 100, Y: 100, Layers: 2, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 50, Y: 50},
+		},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(30, 30, 70, 60),
+	}
+
+	prop.Size.Layers = 4
+	tall := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(50, 40, 90, 90),
+	}
+
+	prop.Size.Layers = 3
+	small := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(0, 0, 30, 20),
+	}
+
+	output, err := PackPrintables(input, []uv3dp.Printable{tall, small}, []string{"tall", "small"}, 2.0)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if output.Size().Layers != 4 {
+		t.Errorf("expected 4 layers, got %v", output.Size().Layers)
+	}
+
+	table := []struct {
+		Index  int
+		Bounds image.Rectangle
+		Pixels int
+	}{
+		{Index: 0, Bounds: image.Rect(8, 13, 92, 87), Pixels: 40*30 + 40*50 + 30*20},
+		{Index: 2, Bounds: image.Rect(8, 13, 48, 87), Pixels: 40*50 + 30*20},
+		{Index: 3, Bounds: image.Rect(8, 13, 48, 63), Pixels: 40 * 50},
+	}
+
+	for _, item := range table {
+		layer := output.LayerImage(item.Index)
+		bounds := uv3dp.ImageBounds(layer)
+		if bounds != item.Bounds {
+			t.Errorf("layer %v: expected %v, got %v", item.Index, item.Bounds, bounds)
+		}
+		if uv3dp.LitPixels(layer) != item.Pixels {
+			t.Errorf("layer %v: expected %v lit pixels, got %v", item.Index, item.Pixels, uv3dp.LitPixels(layer))
+		}
+	}
+
+	annotated, err := PackPrintables(
+		&uv3dp.AnnotatedPrintable{Printable: input, Annotations: uv3dp.Annotations{1: "Stringing"}},
+		[]uv3dp.Printable{
+			&uv3dp.AnnotatedPrintable{Printable: tall, Annotations: uv3dp.Annotations{1: "Warped", 3: "Support failure"}},
+			small,
+		},
+		[]string{"tall", "small"}, 2.0)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := uv3dp.Annotations{1: "Stringing; Warped", 3: "Support failure"}
+	annotations := uv3dp.LayerAnnotations(annotated)
+	if !cmp.Equal(expected, annotations) {
+		t.Errorf("expected %v, got %v", expected, annotations)
+	}
+
+	prop.Size.LayerHeight = 0.1
+	other := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(0, 0, 30, 20),
+	}
+
+	_, err = PackPrintables(input, []uv3dp.Printable{other}, []string{"other"}, 2.0)
+	if err == nil {
+		t.Errorf("expected an error for a different layer height")
+	}
+
+	_, err = PackPrintables(input, []uv3dp.Printable{tall, tall, tall}, []string{"a", "b", "c"}, 2.0)
+	if err == nil {
+		t.Errorf("expected an error for models that do not fit")
+	}
+
+	cmd := NewPackCommand()
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error without --input")
+	}
+}
@@ -0,0 +1,184 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Preview types, by name
+var previewTypes = map[string]uv3dp.PreviewType{
+	"tiny": uv3dp.PreviewTypeTiny,
+	"huge": uv3dp.PreviewTypeHuge,
+}
+
+type PreviewCommand struct {
+	*pflag.FlagSet
+
+	Type   string
+	Width  int
+	Height int
+
+	Action   string // 'export' or 'import'
+	Filename string
+	args     []string
+}
+
+func NewPreviewCommand() (cmd *PreviewCommand) {
+	flagSet := pflag.NewFlagSet("preview", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &PreviewCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Type, "type", "t", "huge", "Preview type: 'tiny' or 'huge'")
+	cmd.IntVarP(&cmd.Width, "width", "W", 0, "Width of an imported preview, in pixels (default is the width of the preview it replaces)")
+	cmd.IntVarP(&cmd.Height, "height", "H", 0, "Height of an imported preview, in pixels (default is the height of the preview it replaces)")
+
+	return
+}
+
+// Parse parses the flags, and the 'export FILE' or 'import FILE' action
+func (cmd *PreviewCommand) Parse(args []string) (err error) {
+	err = cmd.FlagSet.Parse(args)
+	if err != nil {
+		return
+	}
+
+	cmd.args = cmd.FlagSet.Args()
+	if len(cmd.args) < 2 || (cmd.args[0] != "export" && cmd.args[0] != "import") {
+		err = fmt.Errorf("preview: expected 'export FILE' or 'import FILE'")
+		return
+	}
+
+	cmd.Action = cmd.args[0]
+	cmd.Filename = cmd.args[1]
+	cmd.args = cmd.args[2:]
+
+	return
+}
+
+// Args returns the arguments after the action
+func (cmd *PreviewCommand) Args() []string {
+	return cmd.args
+}
+
+func (cmd *PreviewCommand) NArg() int {
+	return len(cmd.args)
+}
+
+// previewModifier replaces a preview image
+type previewModifier struct {
+	uv3dp.Printable
+
+	previewType uv3dp.PreviewType
+	pic         image.Image
+}
+
+func (mod *previewModifier) Preview(index uv3dp.PreviewType) (pic image.Image, ok bool) {
+	if index != mod.previewType {
+		return mod.Printable.Preview(index)
+	}
+
+	pic = mod.pic
+	ok = true
+
+	return
+}
+
+// PreviewSize returns the size of a new preview, which is the size of
+// the preview it replaces, unless overridden by a width or height
+func PreviewSize(input uv3dp.Printable, previewType uv3dp.PreviewType, width int, height int) (size image.Point) {
+	size = uv3dp.DefaultPreviewSize(previewType)
+
+	pic, ok := input.Preview(previewType)
+	if ok && !pic.Bounds().Empty() {
+		size = pic.Bounds().Size()
+	}
+
+	if width > 0 {
+		size.X = width
+	}
+
+	if height > 0 {
+		size.Y = height
+	}
+
+	return
+}
+
+func (cmd *PreviewCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	output = input
+
+	previewType, ok := previewTypes[cmd.Type]
+	if !ok {
+		err = fmt.Errorf("preview: unknown --type '%v'", cmd.Type)
+		return
+	}
+
+	if cmd.Width < 0 || cmd.Height < 0 {
+		err = fmt.Errorf("preview: --width and --height must not be negative")
+		return
+	}
+
+	switch cmd.Action {
+	case "export":
+		pic, ok := input.Preview(previewType)
+		if !ok {
+			err = fmt.Errorf("no %v preview", cmd.Type)
+			break
+		}
+		TraceVerbosef(VerbosityNotice, "  Exporting %v preview to %v", cmd.Type, cmd.Filename)
+		writer := os.Stdout
+		if cmd.Filename != uv3dp.FilenameStdio {
+			writer, err = os.Create(cmd.Filename)
+			if err != nil {
+				break
+			}
+		}
+		err = png.Encode(writer, pic)
+		if writer != os.Stdout {
+			closeErr := writer.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}
+	case "import":
+		reader := os.Stdin
+		if cmd.Filename != uv3dp.FilenameStdio {
+			reader, err = os.Open(cmd.Filename)
+			if err != nil {
+				break
+			}
+			defer reader.Close()
+		}
+		var pic image.Image
+		pic, err = uv3dp.DecodePreview(reader)
+		if err != nil {
+			break
+		}
+		size := PreviewSize(input, previewType, cmd.Width, cmd.Height)
+		TraceVerbosef(VerbosityNotice, "  Importing %v preview from %v, at %vx%v", cmd.Type, cmd.Filename, size.X, size.Y)
+		output = &previewModifier{
+			Printable:   input,
+			previewType: previewType,
+			pic:         uv3dp.FitPreview(pic, size),
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("preview: %v: %w", cmd.Filename, err)
+	}
+
+	return
+}
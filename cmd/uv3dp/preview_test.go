@@ -0,0 +1,136 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestPreviewSize(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 1, LayerHeight: 0.05},
+		Preview: map[uv3dp.PreviewType]image.Image{
+			uv3dp.PreviewTypeHuge: image.NewRGBA(image.Rect(0, 0, 800, 480)),
+		},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	table := map[string]struct {
+		previewType   uv3dp.PreviewType
+		width, height int
+		expect        image.Point
+	}{
+		"replaced": {previewType: uv3dp.PreviewTypeHuge, expect: image.Pt(800, 480)},
+		"default":  {previewType: uv3dp.PreviewTypeTiny, expect: image.Pt(200, 125)},
+		"width":    {previewType: uv3dp.PreviewTypeHuge, width: 640, expect: image.Pt(640, 480)},
+		"height":   {previewType: uv3dp.PreviewTypeTiny, height: 100, expect: image.Pt(200, 100)},
+	}
+
+	for name, item := range table {
+		got := PreviewSize(input, item.previewType, item.width, item.height)
+		if got != item.expect {
+			t.Errorf("%v: expected %v, got %v", name, item.expect, got)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "source.png")
+	pic := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	pic.SetRGBA(10, 5, color.RGBA{R: 0xff, A: 0xff})
+
+	writer, err := os.Create(source)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	png.Encode(writer, pic)
+	writer.Close()
+
+	input := &uv3dp.Print{Properties: uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 1, LayerHeight: 0.05},
+	}}
+
+	cmd := NewPreviewCommand()
+	err = cmd.Parse([]string{"--type", "tiny", "import", source})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	tiny, ok := output.Preview(uv3dp.PreviewTypeTiny)
+	if !ok {
+		t.Fatalf("expected a tiny preview")
+	}
+
+	if tiny.Bounds().Size() != image.Pt(200, 125) {
+		t.Errorf("expected size 200x125, got %v", tiny.Bounds().Size())
+	}
+
+	_, ok = output.Preview(uv3dp.PreviewTypeHuge)
+	if ok {
+		t.Errorf("expected no huge preview")
+	}
+
+	exported := filepath.Join(dir, "exported.png")
+	cmd = NewPreviewCommand()
+	cmd.Parse([]string{"--type", "tiny", "export", exported})
+	_, err = cmd.Filter(output)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	reader, err := os.Open(exported)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer reader.Close()
+
+	got, err := png.Decode(reader)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if got.Bounds() != tiny.Bounds() {
+		t.Errorf("expected bounds %v, got %v", tiny.Bounds(), got.Bounds())
+	}
+
+	cmd = NewPreviewCommand()
+	cmd.Parse([]string{"export", exported})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error exporting a missing preview")
+	}
+
+	cmd = NewPreviewCommand()
+	cmd.Parse([]string{"--type", "medium", "import", source})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for an unknown type")
+	}
+
+	err = NewPreviewCommand().Parse([]string{"replace", source})
+	if err == nil {
+		t.Errorf("expected an error for an unknown action")
+	}
+}
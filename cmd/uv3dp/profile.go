@@ -0,0 +1,185 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Profile is the print settings of a printable, as saved in a profile file
+type Profile struct {
+	Exposure uv3dp.Exposure // Normal layer exposure, lift, and retract
+	Bottom   uv3dp.Bottom   // Bottom layer exposure, lift, retract, and counts
+}
+
+type ProfileCommand struct {
+	*pflag.FlagSet
+
+	Action   string // 'export' or 'apply'
+	Filename string
+	args     []string
+}
+
+func NewProfileCommand() (cmd *ProfileCommand) {
+	flagSet := pflag.NewFlagSet("profile", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &ProfileCommand{
+		FlagSet: flagSet,
+	}
+
+	return
+}
+
+// Parse parses the flags, and the 'export FILE' or 'apply FILE' action
+func (cmd *ProfileCommand) Parse(args []string) (err error) {
+	err = cmd.FlagSet.Parse(args)
+	if err != nil {
+		return
+	}
+
+	cmd.args = cmd.FlagSet.Args()
+	if len(cmd.args) < 2 || (cmd.args[0] != "export" && cmd.args[0] != "apply") {
+		err = fmt.Errorf("profile: expected 'export FILE' or 'apply FILE'")
+		return
+	}
+
+	cmd.Action = cmd.args[0]
+	cmd.Filename = cmd.args[1]
+	cmd.args = cmd.args[2:]
+
+	return
+}
+
+// Args returns the arguments after the action
+func (cmd *ProfileCommand) Args() []string {
+	return cmd.args
+}
+
+func (cmd *ProfileCommand) NArg() int {
+	return len(cmd.args)
+}
+
+// ProfileExport writes the print settings of a printable as JSON
+func ProfileExport(writer io.Writer, printable uv3dp.Printable) (err error) {
+	profile := &Profile{
+		Exposure: printable.Exposure(),
+		Bottom:   printable.Bottom(),
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(profile)
+
+	return
+}
+
+// ProfileImport reads print settings. Settings missing from the profile
+// are those of the printable.
+func ProfileImport(reader io.Reader, printable uv3dp.Printable) (profile Profile, err error) {
+	profile.Exposure = printable.Exposure()
+	profile.Bottom = printable.Bottom()
+
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&profile)
+	if err != nil {
+		return
+	}
+
+	if profile.Bottom.Count < 0 || profile.Bottom.Transition < 0 {
+		err = fmt.Errorf("bottom layer counts must not be negative")
+		return
+	}
+
+	return
+}
+
+// profileModifier replaces the print settings of all layers
+type profileModifier struct {
+	uv3dp.Printable
+
+	profile Profile
+}
+
+func (mod *profileModifier) Exposure() (exposure uv3dp.Exposure) {
+	exposure = mod.profile.Exposure
+
+	return
+}
+
+func (mod *profileModifier) Bottom() (bottom uv3dp.Bottom) {
+	bottom = mod.profile.Bottom
+
+	return
+}
+
+func (mod *profileModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	prop := uv3dp.Properties{
+		Exposure: mod.profile.Exposure,
+		Bottom:   mod.profile.Bottom,
+	}
+
+	exposure = prop.LayerExposure(index)
+
+	return
+}
+
+func (cmd *ProfileCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	output = input
+
+	switch cmd.Action {
+	case "export":
+		TraceVerbosef(VerbosityNotice, "  Exporting print profile to %v", cmd.Filename)
+		writer := os.Stdout
+		if cmd.Filename != uv3dp.FilenameStdio {
+			writer, err = os.Create(cmd.Filename)
+			if err != nil {
+				break
+			}
+		}
+		err = ProfileExport(writer, input)
+		if writer != os.Stdout {
+			closeErr := writer.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}
+	case "apply":
+		TraceVerbosef(VerbosityNotice, "  Applying print profile from %v", cmd.Filename)
+		reader := os.Stdin
+		if cmd.Filename != uv3dp.FilenameStdio {
+			reader, err = os.Open(cmd.Filename)
+			if err != nil {
+				break
+			}
+			defer reader.Close()
+		}
+		var profile Profile
+		profile, err = ProfileImport(reader, input)
+		if err != nil {
+			break
+		}
+		output = &profileModifier{
+			Printable: input,
+			profile:   profile,
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("profile: %v: %w", cmd.Filename, err)
+	}
+
+	return
+}
@@ -0,0 +1,113 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestProfileRoundTrip(t *testing.T) {
+	source := uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 8, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8, LightOffTime: 1, LightPWM: 200,
+			LiftHeight: 5, LiftSpeed: 60, RetractHeight: 4, RetractSpeed: 150},
+		Bottom: uv3dp.Bottom{
+			Count:      2,
+			Transition: 1,
+			Exposure: uv3dp.Exposure{LightOnTime: 40, LightOffTime: 2, LightPWM: 255,
+				LiftHeight: 7, LiftSpeed: 40},
+		},
+	}
+
+	target := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 20, Y: 20, Layers: 8, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 3, LightPWM: 255},
+		Bottom:   uv3dp.Bottom{Count: 5, Exposure: uv3dp.Exposure{LightOnTime: 30, LightPWM: 255}},
+	}
+
+	buff := &bytes.Buffer{}
+	err := ProfileExport(buff, uv3dp.NewEmptyPrintable(source))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	input := uv3dp.NewEmptyPrintable(target)
+	profile, err := ProfileImport(buff, input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output := &profileModifier{Printable: input, profile: profile}
+
+	if !cmp.Equal(source.Exposure, output.Exposure()) {
+		t.Errorf("expected %+v, got %+v", source.Exposure, output.Exposure())
+	}
+
+	if !cmp.Equal(source.Bottom, output.Bottom()) {
+		t.Errorf("expected %+v, got %+v", source.Bottom, output.Bottom())
+	}
+
+	for n := 0; n < source.Size.Layers; n++ {
+		expected := source.LayerExposure(n)
+		if !cmp.Equal(expected, output.LayerExposure(n)) {
+			t.Errorf("layer %v: expected %+v, got %+v", n, expected, output.LayerExposure(n))
+		}
+	}
+
+	// The size is not part of the profile
+	if output.Size() != target.Size {
+		t.Errorf("expected %+v, got %+v", target.Size, output.Size())
+	}
+}
+
+func TestProfileImport(t *testing.T) {
+	prop := uv3dp.Properties{
+		Exposure: uv3dp.Exposure{LightOnTime: 3, LiftHeight: 5},
+		Bottom:   uv3dp.Bottom{Count: 5},
+	}
+	input := uv3dp.NewEmptyPrintable(prop)
+
+	// Missing settings are kept
+	profile, err := ProfileImport(strings.NewReader(`{"Exposure": {"LightOnTime": 9}}`), input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if profile.Exposure.LightOnTime != 9 || profile.Exposure.LiftHeight != 5 || profile.Bottom.Count != 5 {
+		t.Errorf("unexpected profile %+v", profile)
+	}
+
+	for _, text := range []string{`{"Exposure": {"LightOn": 9}}`, `{"Bottom": {"Count": -1}}`, `[]`} {
+		_, err = ProfileImport(strings.NewReader(text), input)
+		if err == nil {
+			t.Errorf("%v: expected an error", text)
+		}
+	}
+}
+
+func TestProfileParse(t *testing.T) {
+	cmd := NewProfileCommand()
+	err := cmd.Parse([]string{"apply", "good.json", "out.ctb"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if cmd.Action != "apply" || cmd.Filename != "good.json" || !cmp.Equal([]string{"out.ctb"}, cmd.Args()) {
+		t.Errorf("unexpected parse %+v", cmd)
+	}
+
+	for _, args := range [][]string{{}, {"export"}, {"load", "good.json"}} {
+		err = NewProfileCommand().Parse(args)
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type ProofCommand struct {
+	*pflag.FlagSet
+
+	Sensitivity float32 // Critical exposure, in seconds at full power
+	Spread      float32 // Light spread, in mm
+	Dose        bool    // Render relative dose instead of cured/uncured
+}
+
+func NewProofCommand() (cmd *ProofCommand) {
+	cmd = &ProofCommand{
+		FlagSet: pflag.NewFlagSet("proof", pflag.ContinueOnError),
+	}
+
+	cmd.Float32VarP(&cmd.Sensitivity, "sensitivity", "s", 2.0, "Resin critical exposure, in seconds at full power")
+	cmd.Float32VarP(&cmd.Spread, "spread", "d", 0.02, "Light spread (gaussian sigma) in millimeters")
+	cmd.BoolVarP(&cmd.Dose, "dose", "g", false, "Render the relative dose as grayscale, instead of the cured area")
+
+	cmd.SetInterspersed(false)
+
+	return
+}
+
+type proofModifier struct {
+	uv3dp.Printable
+
+	sensitivity float32
+	kernel      []float32
+	dose        bool
+}
+
+// gaussianKernel returns a normalized 1D gaussian kernel
+func gaussianKernel(sigma float64) (kernel []float32) {
+	if sigma < 0.1 {
+		kernel = []float32{1.0}
+		return
+	}
+
+	radius := int(math.Ceil(sigma * 3))
+	kernel = make([]float32, radius*2+1)
+
+	sum := float32(0.0)
+	for n := range kernel {
+		x := float64(n - radius)
+		kernel[n] = float32(math.Exp(-(x * x) / (2 * sigma * sigma)))
+		sum += kernel[n]
+	}
+
+	for n := range kernel {
+		kernel[n] /= sum
+	}
+
+	return
+}
+
+// convolve applies the kernel along one axis of a width x height plane.
+// If mask is not nil, only the pixels where it is non-zero are computed,
+// and the others are left at zero.
+func convolve(in []float32, width, height int, kernel []float32, horizontal bool, mask []uint8) (out []float32) {
+	out = make([]float32, len(in))
+	radius := len(kernel) / 2
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if mask != nil && mask[y*width+x] == 0 {
+				continue
+			}
+			sum := float32(0.0)
+			for k, weight := range kernel {
+				sx, sy := x, y
+				if horizontal {
+					sx += k - radius
+				} else {
+					sy += k - radius
+				}
+				if sx < 0 || sx >= width || sy < 0 || sy >= height {
+					continue
+				}
+				sum += in[sy*width+sx] * weight
+			}
+			out[y*width+x] = sum
+		}
+	}
+
+	return
+}
+
+func (mod *proofModifier) LayerImage(index int) (gray *image.Gray) {
+	source := mod.Printable.LayerImage(index)
+	bounds := source.Bounds()
+	size := bounds.Size()
+
+	// Light intensity of each pixel, spread to its neighbors
+	light := make([]float32, size.X*size.Y)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			light[y*size.X+x] = float32(source.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y) / 255.0
+		}
+	}
+
+	light = convolve(light, size.X, size.Y, mod.kernel, true, nil)
+	light = convolve(light, size.X, size.Y, mod.kernel, false, nil)
+
+	// Dose, in seconds at full power
+	exposure := mod.Printable.LayerExposure(index)
+	pwm := exposure.LightPWM
+	if pwm == 0 {
+		pwm = 255
+	}
+	scale := exposure.LightOnTime * float32(pwm) / 255.0
+
+	gray = image.NewGray(bounds)
+	for n, level := range light {
+		ratio := level * scale / mod.sensitivity
+		if mod.dose {
+			// A dose of 2x the critical exposure is full white
+			gray.Pix[n] = uint8(math.Min(float64(ratio)*127.5, 255))
+		} else if ratio >= 1.0 {
+			gray.Pix[n] = 255
+		}
+	}
+
+	return
+}
+
+func (cmd *ProofCommand) Filter(input uv3dp.Printable) (mod uv3dp.Printable, err error) {
+	if cmd.Sensitivity <= 0.0 {
+		err = fmt.Errorf("proof: --sensitivity must be greater than zero")
+		return
+	}
+
+	if cmd.Spread < 0.0 {
+		err = fmt.Errorf("proof: --spread must not be negative")
+		return
+	}
+
+	size := input.Size()
+	if size.X <= 0 || size.Millimeter.X <= 0 {
+		err = fmt.Errorf("proof: the printable has no pixel size (%v pixels, %v mm wide)", size.X, size.Millimeter.X)
+		return
+	}
+
+	pitch := float64(size.Millimeter.X) / float64(size.X)
+	sigma := float64(cmd.Spread) / pitch
+
+	TraceVerbosef(VerbosityNotice, "  Simulating %v s sensitivity, %v mm spread (%.2f pixels)", cmd.Sensitivity, cmd.Spread, sigma)
+
+	mod = &proofModifier{
+		Printable:   input,
+		sensitivity: cmd.Sensitivity,
+		kernel:      gaussianKernel(sigma),
+		dose:        cmd.Dose,
+	}
+
+	return
+}
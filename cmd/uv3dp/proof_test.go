@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestProof(t *testing.T) {
+	// 0.05mm pixels
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 40, Y: 40, Layers: 1, LayerHeight: 0.05},
+	}
+	prop.Size.Millimeter.X = 2.0
+	prop.Size.Millimeter.Y = 2.0
+
+	table := []struct {
+		Args        []string
+		LightOnTime float32
+		LightPWM    uint8
+		Inside      uint8 // Middle of the model
+		Edge        uint8 // Inside edge of the model
+		Outside     uint8 // Outside edge of the model
+	}{
+		// Under-exposed edges are not cured
+		{Args: []string{"--spread", "0.05"}, LightOnTime: 2.5, Inside: 0xff, Edge: 0x00, Outside: 0x00},
+		{Args: []string{"--spread", "0.05"}, LightOnTime: 3.0, Inside: 0xff, Edge: 0xff, Outside: 0x00},
+		{Args: []string{"--spread", "0.05"}, LightOnTime: 3.0, LightPWM: 128, Inside: 0x00, Edge: 0x00, Outside: 0x00},
+		{Args: []string{"--spread", "0.05"}, LightOnTime: 12.0, Inside: 0xff, Edge: 0xff, Outside: 0xff},
+		// No spread
+		{Args: []string{"--spread", "0"}, LightOnTime: 2.0, Inside: 0xff, Edge: 0xff, Outside: 0x00},
+		// A dose of 2x the critical exposure is full white
+		{Args: []string{"--spread", "0", "--dose"}, LightOnTime: 3.0, Inside: 0xbf, Edge: 0xbf, Outside: 0x00},
+	}
+
+	for n, item := range table {
+		prop.Exposure.LightOnTime = item.LightOnTime
+		prop.Exposure.LightPWM = item.LightPWM
+
+		input := &scalePrint{
+			Print: uv3dp.Print{Properties: prop},
+			model: image.Rect(10, 10, 30, 30),
+		}
+
+		cmd := NewProofCommand()
+		err := cmd.Parse(append([]string{"--sensitivity", "2"}, item.Args...))
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", n, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", n, err)
+		}
+
+		img := output.LayerImage(0)
+		for _, pix := range []struct {
+			Name string
+			X    int
+			Want uint8
+		}{
+			{Name: "inside", X: 20, Want: item.Inside},
+			{Name: "edge", X: 10, Want: item.Edge},
+			{Name: "outside", X: 9, Want: item.Outside},
+		} {
+			got := img.GrayAt(pix.X, 20).Y
+			if got != pix.Want {
+				t.Errorf("%v: %v: expected %#x, got %#x", n, pix.Name, pix.Want, got)
+			}
+		}
+	}
+}
+
+func TestProofNoPixelSize(t *testing.T) {
+	table := []uv3dp.Size{
+		{X: 40, Y: 40, Layers: 1},
+		{X: 0, Y: 40, Layers: 1},
+	}
+	table[0].Millimeter.X = 0.0
+	table[1].Millimeter.X = 2.0
+
+	for n, size := range table {
+		input := uv3dp.NewEmptyPrintable(uv3dp.Properties{Size: size})
+
+		cmd := NewProofCommand()
+		cmd.Parse([]string{})
+
+		_, err := cmd.Filter(input)
+		if err == nil {
+			t.Errorf("%v: expected an error", n)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/image/draw"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type QRCommand struct {
+	*pflag.FlagSet
+
+	Text     string
+	Level    string
+	Size     float32
+	Position []float32
+	Layers   int
+	Deboss   bool
+	Mirror   bool
+}
+
+func NewQRCommand() (cmd *QRCommand) {
+	flagSet := pflag.NewFlagSet("qr", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &QRCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Text, "text", "t", "", "Text of the QR code (ie job ID or URL)")
+	cmd.StringVarP(&cmd.Level, "level", "L", "M", "Error correction level (L, M, Q, or H)")
+	cmd.Float32VarP(&cmd.Size, "size", "s", 10, "Size of the QR code, in millimeters")
+	cmd.Float32SliceVarP(&cmd.Position, "position", "p", []float32{5, 5}, "Position of the top left of the QR code, in millimeters")
+	cmd.IntVarP(&cmd.Layers, "layers", "l", 10, "Number of bottom layers with the QR code")
+	cmd.BoolVarP(&cmd.Deboss, "deboss", "d", false, "Deboss the QR code into the model, instead of embossing it")
+	cmd.BoolVarP(&cmd.Mirror, "mirror", "m", false, "Mirror the QR code, so that it reads correctly from the other side")
+
+	return
+}
+
+// QRStamp returns a QR code as a stamp, with one pixel per module,
+// and without its quiet zone.
+func QRStamp(modules [][]bool, mirror bool) (stamp *image.Gray) {
+	size := len(modules)
+	stamp = image.NewGray(image.Rect(0, 0, size, size))
+
+	for y, row := range modules {
+		for x, dark := range row {
+			if mirror {
+				x = size - 1 - x
+			}
+			if dark {
+				stamp.Pix[y*stamp.Stride+x] = 0xff
+			}
+		}
+	}
+
+	return
+}
+
+func (cmd *QRCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Text == "" {
+		err = fmt.Errorf("qr: --text must be specified")
+		return
+	}
+
+	if len(cmd.Position) != 2 {
+		err = fmt.Errorf("qr: --position expects two values")
+		return
+	}
+
+	if cmd.Size <= 0 || cmd.Layers < 1 {
+		err = fmt.Errorf("qr: --size and --layers must be positive")
+		return
+	}
+
+	level, err := ParseQRLevel(cmd.Level)
+	if err != nil {
+		return
+	}
+
+	modules, err := QREncode([]byte(cmd.Text), level)
+	if err != nil {
+		return
+	}
+
+	size := input.Size()
+	pixelX := float64(size.Millimeter.X) / float64(size.X)
+	pixelY := float64(size.Millimeter.Y) / float64(size.Y)
+
+	x := int(math.Round(float64(cmd.Position[0]) / pixelX))
+	y := int(math.Round(float64(cmd.Position[1]) / pixelY))
+	rect := image.Rect(x, y, x+int(math.Round(float64(cmd.Size)/pixelX)), y+int(math.Round(float64(cmd.Size)/pixelY)))
+
+	if !rect.In(image.Rect(0, 0, size.X, size.Y)) {
+		err = fmt.Errorf("qr: QR code at %v is not within the %dx%d bed", rect, size.X, size.Y)
+		return
+	}
+
+	if rect.Dx() < len(modules) || rect.Dy() < len(modules) {
+		err = fmt.Errorf("qr: %v mm is too small for the %vx%v modules of the QR code", cmd.Size, len(modules), len(modules))
+		return
+	}
+
+	layers := cmd.Layers
+	if layers > size.Layers {
+		layers = size.Layers
+	}
+
+	operation := "Embossing"
+	if cmd.Deboss {
+		operation = "Debossing"
+	}
+
+	TraceVerbosef(VerbosityNotice, "  %v %vx%v module QR code at %v, on layers 0..%v", operation, len(modules), len(modules), rect, layers-1)
+
+	// Modules must keep their sharp edges
+	stamp := QRStamp(modules, cmd.Mirror)
+	scaled := image.NewGray(rect)
+	draw.NearestNeighbor.Scale(scaled, rect, stamp, stamp.Bounds(), draw.Src, nil)
+
+	output = &labelModifier{
+		Printable:  input,
+		stamp:      scaled,
+		firstLayer: 0,
+		layers:     layers,
+		engrave:    cmd.Deboss,
+	}
+
+	return
+}
@@ -0,0 +1,65 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestQR(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 100, Y: 100, Layers: 4, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 50, Y: 50},
+		},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(0, 0, 100, 100),
+	}
+
+	cmd := NewQRCommand()
+	err := cmd.Parse([]string{"--text", "job-1234", "--size", "21", "--position", "10,10", "--layers", "2", "--deboss"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	// 21 modules of 2 pixels, at (20,20); the top left finder is debossed
+	table := []struct {
+		Layer int
+		X, Y  int
+		Pix   uint8
+	}{
+		{Layer: 0, X: 20, Y: 20, Pix: 0x00}, // Finder corner
+		{Layer: 0, X: 22, Y: 22, Pix: 0xff}, // Finder ring
+		{Layer: 0, X: 27, Y: 27, Pix: 0x00}, // Finder center
+		{Layer: 1, X: 27, Y: 27, Pix: 0x00},
+		{Layer: 2, X: 27, Y: 27, Pix: 0xff}, // Above the QR code
+		{Layer: 0, X: 19, Y: 19, Pix: 0xff}, // Outside the QR code
+	}
+
+	for n, item := range table {
+		pix := output.LayerImage(item.Layer).GrayAt(item.X, item.Y).Y
+		if pix != item.Pix {
+			t.Errorf("%v: layer %v (%v,%v): expected %#x, got %#x", n, item.Layer, item.X, item.Y, item.Pix, pix)
+		}
+	}
+
+	cmd = NewQRCommand()
+	cmd.Parse([]string{"--text", "job-1234", "--size", "5"})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for a QR code with modules smaller than a pixel")
+	}
+}
@@ -0,0 +1,476 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QRLevel is the error correction level of a QR code
+type QRLevel int
+
+const (
+	QRLevelL = QRLevel(iota) // Recovers 7% of the code
+	QRLevelM                 // Recovers 15% of the code
+	QRLevelQ                 // Recovers 25% of the code
+	QRLevelH                 // Recovers 30% of the code
+)
+
+// Largest QR code version supported
+const qrVersionMax = 10
+
+var (
+	// Error correction codewords per block, by level and version
+	qrECCodewords = [4][qrVersionMax + 1]int{
+		{0, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18},
+		{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26},
+		{0, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24},
+		{0, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28},
+	}
+
+	// Error correction blocks, by level and version
+	qrECBlocks = [4][qrVersionMax + 1]int{
+		{0, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4},
+		{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5},
+		{0, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8},
+		{0, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8},
+	}
+
+	// Format information bits of each level
+	qrLevelBits = [4]int{1, 0, 3, 2}
+)
+
+// ParseQRLevel returns the error correction level for a name, ie 'M'
+func ParseQRLevel(name string) (level QRLevel, err error) {
+	for n, levelName := range []string{"L", "M", "Q", "H"} {
+		if strings.ToUpper(name) == levelName {
+			level = QRLevel(n)
+			return
+		}
+	}
+
+	err = fmt.Errorf("unknown QR code error correction level '%v'", name)
+	return
+}
+
+// qrCodewords returns the count of data and error correction codewords of a version
+func qrCodewords(version int) int {
+	modules := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		modules -= (25*align-10)*align - 55
+		if version >= 7 {
+			modules -= 36
+		}
+	}
+
+	return modules / 8
+}
+
+// qrDataCodewords returns the count of data codewords of a version and level
+func qrDataCodewords(version int, level QRLevel) int {
+	return qrCodewords(version) - qrECCodewords[level][version]*qrECBlocks[level][version]
+}
+
+// qrMultiply multiplies in GF(2^8), modulo x^8 + x^4 + x^3 + x^2 + 1
+func qrMultiply(x, y uint8) (z uint8) {
+	for i := 7; i >= 0; i-- {
+		carry := z >> 7
+		z = (z << 1) ^ (carry * 0x1d)
+		z ^= ((y >> uint(i)) & 1) * x
+	}
+
+	return
+}
+
+// qrReedSolomon returns the error correction codewords of a block
+func qrReedSolomon(data []byte, degree int) (ec []byte) {
+	// Generator polynomial, without its leading term
+	divisor := make([]byte, degree)
+	divisor[degree-1] = 1
+	root := uint8(1)
+	for i := 0; i < degree; i++ {
+		for j := range divisor {
+			divisor[j] = qrMultiply(divisor[j], root)
+			if j+1 < degree {
+				divisor[j] ^= divisor[j+1]
+			}
+		}
+		root = qrMultiply(root, 0x02)
+	}
+
+	ec = make([]byte, degree)
+	for _, b := range data {
+		factor := b ^ ec[0]
+		ec = append(ec[1:], 0)
+		for i := range ec {
+			ec[i] ^= qrMultiply(divisor[i], factor)
+		}
+	}
+
+	return
+}
+
+// qrBuilder draws the modules of a QR code
+type qrBuilder struct {
+	size     int
+	modules  [][]bool
+	function [][]bool // Modules that are not data
+}
+
+func (qb *qrBuilder) set(x, y int, dark bool) {
+	qb.modules[y][x] = dark
+	qb.function[y][x] = true
+}
+
+func (qb *qrBuilder) finder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= qb.size || y >= qb.size {
+				continue
+			}
+			dist := dx
+			if dist < 0 {
+				dist = -dist
+			}
+			ady := dy
+			if ady < 0 {
+				ady = -ady
+			}
+			if ady > dist {
+				dist = ady
+			}
+			qb.set(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (qb *qrBuilder) alignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			qb.set(cx+dx, cy+dy, dx == -2 || dx == 2 || dy == -2 || dy == 2 || (dx == 0 && dy == 0))
+		}
+	}
+}
+
+// format draws the format information, for a level and mask
+func (qb *qrBuilder) format(level QRLevel, mask int) {
+	data := qrLevelBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	bit := func(i int) bool { return (bits>>uint(i))&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		qb.set(8, i, bit(i))
+	}
+	qb.set(8, 7, bit(6))
+	qb.set(8, 8, bit(7))
+	qb.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qb.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		qb.set(qb.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qb.set(8, qb.size-15+i, bit(i))
+	}
+	qb.set(8, qb.size-8, true)
+}
+
+// version draws the version information, for versions 7 and above
+func (qb *qrBuilder) version(version int) {
+	if version < 7 {
+		return
+	}
+
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	bits := version<<12 | rem
+
+	for i := 0; i < 18; i++ {
+		dark := (bits>>uint(i))&1 != 0
+		a := qb.size - 11 + i%3
+		b := i / 3
+		qb.set(a, b, dark)
+		qb.set(b, a, dark)
+	}
+}
+
+// qrMasks are the data mask patterns
+var qrMasks = [8]func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+// mask inverts the data modules of a mask pattern
+func (qb *qrBuilder) mask(mask int) {
+	for y := 0; y < qb.size; y++ {
+		for x := 0; x < qb.size; x++ {
+			if !qb.function[y][x] && qrMasks[mask](x, y) {
+				qb.modules[y][x] = !qb.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code would be to read
+func (qb *qrBuilder) penalty() (score int) {
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return qb.modules[x][y]
+		}
+		return qb.modules[y][x]
+	}
+
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < qb.size; y++ {
+			// Runs of modules of the same color
+			run := 1
+			for x := 1; x <= qb.size; x++ {
+				if x < qb.size && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// Patterns that look like finders
+			for x := 0; x+11 <= qb.size; x++ {
+				for _, pattern := range finderLike {
+					match := true
+					for n, dark := range pattern {
+						if at(x+n, y, transpose) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						score += 40
+					}
+				}
+			}
+		}
+	}
+
+	// Blocks of modules of the same color, and the balance of dark modules
+	dark := 0
+	for y := 0; y < qb.size; y++ {
+		for x := 0; x < qb.size; x++ {
+			if qb.modules[y][x] {
+				dark++
+			}
+			if x > 0 && y > 0 {
+				c := qb.modules[y][x]
+				if qb.modules[y][x-1] == c && qb.modules[y-1][x] == c && qb.modules[y-1][x-1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+
+	balance := dark*100/(qb.size*qb.size) - 50
+	if balance < 0 {
+		balance = -balance
+	}
+	score += balance / 5 * 10
+
+	return
+}
+
+// QREncode encodes data as a QR code, in byte mode, with the smallest
+// version (up to version 10) that can hold it. Modules are true if dark,
+// and do not include the quiet zone.
+func QREncode(data []byte, level QRLevel) (modules [][]bool, err error) {
+	version := 0
+	for v := 1; v <= qrVersionMax; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+len(data)*8 <= qrDataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+
+	if version == 0 {
+		err = fmt.Errorf("qr: %v bytes of data is too large for a QR code", len(data))
+		return
+	}
+
+	// Mode, count, and data bits
+	bits := []bool{}
+	appendBits := func(value int, count int) {
+		for i := count - 1; i >= 0; i-- {
+			bits = append(bits, (value>>uint(i))&1 != 0)
+		}
+	}
+
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	appendBits(0x4, 4)
+	appendBits(len(data), countBits)
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+
+	capacity := qrDataCodewords(version, level) * 8
+	terminator := capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	appendBits(0, terminator)
+	appendBits(0, (8-len(bits)%8)%8)
+	for pad := 0xec; len(bits) < capacity; pad ^= 0xec ^ 0x11 {
+		appendBits(pad, 8)
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for n, bit := range bits {
+		if bit {
+			codewords[n/8] |= 0x80 >> uint(n%8)
+		}
+	}
+
+	// Split into blocks, and interleave them with their error correction
+	blocks := qrECBlocks[level][version]
+	ecLen := qrECCodewords[level][version]
+	total := qrCodewords(version)
+	shortBlocks := blocks - total%blocks
+	shortLen := total / blocks
+
+	dataBlocks := [][]byte{}
+	ecBlocks := [][]byte{}
+	offset := 0
+	for n := 0; n < blocks; n++ {
+		dataLen := shortLen - ecLen
+		if n >= shortBlocks {
+			dataLen++
+		}
+		block := codewords[offset : offset+dataLen]
+		offset += dataLen
+		dataBlocks = append(dataBlocks, block)
+		ecBlocks = append(ecBlocks, qrReedSolomon(block, ecLen))
+	}
+
+	interleaved := []byte{}
+	for i := 0; i <= shortLen-ecLen; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				interleaved = append(interleaved, block[i])
+			}
+		}
+	}
+	for i := 0; i < ecLen; i++ {
+		for _, block := range ecBlocks {
+			interleaved = append(interleaved, block[i])
+		}
+	}
+
+	// Function patterns
+	size := version*4 + 17
+	qb := &qrBuilder{size: size}
+	for y := 0; y < size; y++ {
+		qb.modules = append(qb.modules, make([]bool, size))
+		qb.function = append(qb.function, make([]bool, size))
+	}
+
+	for i := 0; i < size; i++ {
+		qb.set(6, i, i%2 == 0)
+		qb.set(i, 6, i%2 == 0)
+	}
+
+	qb.finder(3, 3)
+	qb.finder(size-4, 3)
+	qb.finder(3, size-4)
+
+	if version >= 2 {
+		count := version/7 + 2
+		step := (version*4 + count*2 + 1) / (count*2 - 2) * 2
+		positions := make([]int, count)
+		positions[0] = 6
+		for i, pos := count-1, size-7; i >= 1; i, pos = i-1, pos-step {
+			positions[i] = pos
+		}
+		for i, y := range positions {
+			for j, x := range positions {
+				if (i == 0 && j == 0) || (i == 0 && j == count-1) || (i == count-1 && j == 0) {
+					continue
+				}
+				qb.alignment(x, y)
+			}
+		}
+	}
+
+	// Reserve the format areas, then draw the version
+	qb.format(level, 0)
+	qb.version(version)
+
+	// Data, in a zigzag from the bottom right
+	i := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if !qb.function[y][x] && i < len(interleaved)*8 {
+					qb.modules[y][x] = (interleaved[i/8]>>uint(7-i%8))&1 != 0
+					i++
+				}
+			}
+		}
+	}
+
+	// Select the mask with the lowest penalty
+	best, bestScore := 0, -1
+	for mask := 0; mask < len(qrMasks); mask++ {
+		qb.mask(mask)
+		qb.format(level, mask)
+		score := qb.penalty()
+		if bestScore < 0 || score < bestScore {
+			best, bestScore = mask, score
+		}
+		qb.mask(mask)
+	}
+
+	qb.mask(best)
+	qb.format(level, best)
+
+	modules = qb.modules
+
+	return
+}
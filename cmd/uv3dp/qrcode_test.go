@@ -0,0 +1,99 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQRReedSolomon(t *testing.T) {
+	// 'HELLO WORLD', version 1-M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	ec := qrReedSolomon(data, 10)
+	if !cmp.Equal(expected, ec) {
+		t.Errorf("expected %v, got %v", expected, ec)
+	}
+}
+
+func TestQRFormat(t *testing.T) {
+	table := []struct {
+		Level QRLevel
+		Mask  int
+		Bits  string
+	}{
+		{Level: QRLevelL, Mask: 0, Bits: "111011111000100"},
+		{Level: QRLevelL, Mask: 4, Bits: "110011000101111"},
+		{Level: QRLevelM, Mask: 0, Bits: "101010000010010"},
+	}
+
+	for _, item := range table {
+		qb := &qrBuilder{size: 21}
+		for y := 0; y < qb.size; y++ {
+			qb.modules = append(qb.modules, make([]bool, qb.size))
+			qb.function = append(qb.function, make([]bool, qb.size))
+		}
+		qb.format(item.Level, item.Mask)
+
+		// The copy beside the bottom left finder is the high bits
+		bits := ""
+		for i := 14; i >= 0; i-- {
+			dark := qb.modules[8][qb.size-1-i]
+			if i >= 8 {
+				dark = qb.modules[qb.size-15+i][8]
+			}
+			if dark {
+				bits += "1"
+			} else {
+				bits += "0"
+			}
+		}
+
+		if bits != item.Bits {
+			t.Errorf("%v mask %v: expected %v, got %v", item.Level, item.Mask, item.Bits, bits)
+		}
+	}
+}
+
+func TestQREncode(t *testing.T) {
+	table := []struct {
+		Length int
+		Level  QRLevel
+		Size   int
+	}{
+		{Length: 17, Level: QRLevelL, Size: 21},
+		{Length: 18, Level: QRLevelL, Size: 25},
+		{Length: 14, Level: QRLevelM, Size: 21},
+		{Length: 97, Level: QRLevelH, Size: 53},
+		{Length: 100, Level: QRLevelH, Size: 57},
+	}
+
+	for _, item := range table {
+		modules, err := QREncode([]byte(strings.Repeat("x", item.Length)), item.Level)
+		if err != nil {
+			t.Fatalf("%v bytes: expected nil, got %v", item.Length, err)
+		}
+
+		if len(modules) != item.Size {
+			t.Errorf("%v bytes: expected %v modules, got %v", item.Length, item.Size, len(modules))
+		}
+
+		// Finder pattern centers are dark, with a light ring
+		for _, pt := range [][2]int{{3, 3}, {item.Size - 4, 3}, {3, item.Size - 4}} {
+			if !modules[pt[1]][pt[0]] || modules[pt[1]][pt[0]+2] || !modules[pt[1]][pt[0]+3] {
+				t.Errorf("%v bytes: expected finder pattern at %v", item.Length, pt)
+			}
+		}
+	}
+
+	_, err := QREncode([]byte(strings.Repeat("x", 300)), QRLevelL)
+	if err == nil {
+		t.Errorf("expected an error for too much data")
+	}
+}
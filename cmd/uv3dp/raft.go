@@ -0,0 +1,143 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type RaftCommand struct {
+	*pflag.FlagSet
+
+	Layers  int
+	Margin  int
+	Chamfer int
+}
+
+func NewRaftCommand() (cmd *RaftCommand) {
+	flagSet := pflag.NewFlagSet("raft", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &RaftCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.IntVarP(&cmd.Layers, "layers", "l", 10, "Number of raft layers")
+	cmd.IntVarP(&cmd.Margin, "margin", "m", 20, "Margin of the raft around the footprint of the first layer, in pixels")
+	cmd.IntVarP(&cmd.Chamfer, "chamfer", "c", 10, "Additional margin of the lowest raft layer, in pixels, tapering to none at the top of the raft")
+
+	return
+}
+
+// raftModifier adds raft layers below the model
+type raftModifier struct {
+	uv3dp.Printable
+
+	footprint *image.Gray // Footprint of the first layer of the model
+	layers    int
+	margin    int
+	chamfer   int
+}
+
+func (rm *raftModifier) Size() (size uv3dp.Size) {
+	size = rm.Printable.Size()
+	size.Layers += rm.layers
+
+	return
+}
+
+// The raft layers are all bottom layers
+func (rm *raftModifier) Bottom() (bottom uv3dp.Bottom) {
+	bottom = rm.Printable.Bottom()
+	bottom.Count += rm.layers
+
+	return
+}
+
+func (rm *raftModifier) LayerZ(index int) float32 {
+	height := rm.Printable.Size().LayerHeight
+	if index < rm.layers {
+		return height * float32(index+1)
+	}
+
+	return rm.Printable.LayerZ(index-rm.layers) + height*float32(rm.layers)
+}
+
+func (rm *raftModifier) LayerExposure(index int) uv3dp.Exposure {
+	// Raft layers have the exposure of the first layer
+	if index < rm.layers {
+		return rm.Printable.LayerExposure(0)
+	}
+
+	return rm.Printable.LayerExposure(index - rm.layers)
+}
+
+func (rm *raftModifier) LayerImage(index int) *image.Gray {
+	if index >= rm.layers {
+		return rm.Printable.LayerImage(index - rm.layers)
+	}
+
+	// Chamfer the edges, from the widest at the build plate
+	radius := rm.margin
+	if rm.layers > 1 {
+		radius += rm.chamfer * (rm.layers - 1 - index) / (rm.layers - 1)
+	}
+
+	if radius == 0 {
+		return rm.footprint
+	}
+
+	return uv3dp.MorphImage(rm.footprint, uv3dp.MorphDilate, radius)
+}
+
+func (cmd *RaftCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Layers < 1 {
+		err = fmt.Errorf("raft: --layers must be at least 1")
+		return
+	}
+
+	if cmd.Margin < 0 || cmd.Chamfer < 0 {
+		err = fmt.Errorf("raft: --margin and --chamfer must not be negative")
+		return
+	}
+
+	if input.Size().Layers == 0 {
+		err = fmt.Errorf("raft: no layers to add a raft to")
+		return
+	}
+
+	// The footprint is fully exposed, regardless of anti-aliasing
+	srcImage := input.LayerImage(0)
+	footprint := image.NewGray(srcImage.Bounds())
+	for n, pix := range srcImage.Pix {
+		if pix != 0 {
+			footprint.Pix[n] = 0xff
+		}
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Adding %v raft layers, with a %v pixel margin and %v pixel chamfer",
+		cmd.Layers, cmd.Margin, cmd.Chamfer)
+
+	output = &raftModifier{
+		Printable: input,
+		footprint: footprint,
+		layers:    cmd.Layers,
+		margin:    cmd.Margin,
+		chamfer:   cmd.Chamfer,
+	}
+
+	// The model's layers are above the raft
+	output = uv3dp.RemapLayers(output, uv3dp.LayerRemap{
+		Printable: input,
+		Remap:     func(index int) (int, bool) { return index + cmd.Layers, true },
+	})
+
+	return
+}
@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestRaft(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:   uv3dp.Size{X: 40, Y: 40, Layers: 2, LayerHeight: 0.05},
+		Bottom: uv3dp.Bottom{Count: 1},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(15, 15, 25, 25),
+	}
+
+	cmd := NewRaftCommand()
+	err := cmd.Parse([]string{"--layers", "3", "--margin", "2", "--chamfer", "4"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if output.Size().Layers != 5 {
+		t.Errorf("expected 5 layers, got %v", output.Size().Layers)
+	}
+
+	if output.Bottom().Count != 4 {
+		t.Errorf("expected 4 bottom layers, got %v", output.Bottom().Count)
+	}
+
+	table := []struct {
+		Layer  int
+		Bounds image.Rectangle
+		Z      float32
+	}{
+		{Layer: 0, Bounds: image.Rect(9, 9, 31, 31), Z: 0.05},
+		{Layer: 1, Bounds: image.Rect(11, 11, 29, 29), Z: 0.1},
+		{Layer: 2, Bounds: image.Rect(13, 13, 27, 27), Z: 0.15},
+		{Layer: 3, Bounds: image.Rect(15, 15, 25, 25), Z: 0.2},
+		{Layer: 4, Bounds: image.Rect(15, 15, 25, 25), Z: 0.25},
+	}
+
+	for _, item := range table {
+		bounds := uv3dp.ImageBounds(output.LayerImage(item.Layer))
+		if bounds != item.Bounds {
+			t.Errorf("layer %v: expected %v, got %v", item.Layer, item.Bounds, bounds)
+		}
+
+		z := output.LayerZ(item.Layer)
+		if z < item.Z-0.001 || z > item.Z+0.001 {
+			t.Errorf("layer %v: expected Z of %v, got %v", item.Layer, item.Z, z)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type RefillCommand struct {
+	*pflag.FlagSet
+
+	Vat  float32
+	Wait float32
+}
+
+func NewRefillCommand() (cmd *RefillCommand) {
+	flagSet := pflag.NewFlagSet("refill", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &RefillCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.Float32VarP(&cmd.Vat, "vat", "V", 0, "Capacity of the resin vat, in milliliters (default is the --machine's)")
+	cmd.Float32VarP(&cmd.Wait, "wait", "w", 300, "Time to wait for a refill, in seconds")
+
+	return
+}
+
+// refillModifier pauses the print before layers, by waiting before
+// their exposures, so that the vat can be refilled.
+type refillModifier struct {
+	uv3dp.Printable
+
+	pauses map[int]bool // Layers to wait before
+	wait   float32
+}
+
+func (rm *refillModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	exposure = rm.Printable.LayerExposure(index)
+
+	if rm.pauses[index] {
+		exposure.LightOffTime += rm.wait
+	}
+
+	return
+}
+
+func (cmd *RefillCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	vat := cmd.Vat
+	if !cmd.Changed("vat") && param.Machine != "" {
+		var machine uv3dp.MachineFormat
+		machine, err = uv3dp.LookupMachine(param.Machine)
+		if err != nil {
+			err = fmt.Errorf("refill: %w", err)
+			return
+		}
+		vat = machine.Vat
+	}
+
+	if vat <= 0 {
+		err = fmt.Errorf("refill: the vat capacity is not known (use --vat)")
+		return
+	}
+
+	if cmd.Wait < 0 {
+		err = fmt.Errorf("refill: --wait must not be negative")
+		return
+	}
+
+	output = input
+
+	volumes := uv3dp.LayerVolumes(input)
+	refills := uv3dp.VatRefills(volumes, vat)
+	if len(refills) == 0 {
+		TraceVerbosef(VerbosityNotice, "  Resin use fits in the %.1f ml vat", vat)
+		return
+	}
+
+	pauses := map[int]bool{}
+	for _, layer := range refills {
+		if volumes[layer] > vat {
+			err = fmt.Errorf("refill: layer %v uses more resin than the %.1f ml vat", layer, vat)
+			return
+		}
+		pauses[layer] = true
+		TraceVerbosef(VerbosityNotice, "  Pausing for a refill before layer %v", layer)
+	}
+
+	output = &refillModifier{
+		Printable: input,
+		pauses:    pauses,
+		wait:      cmd.Wait,
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestRefill(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 40, Y: 40, Layers: 5, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8, LightOffTime: 1},
+	}
+	prop.Size.Millimeter.X = 40
+	prop.Size.Millimeter.Y = 40
+
+	// 400 square millimeters, 0.02 ml for each layer
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	cmd := NewRefillCommand()
+	err := cmd.Parse([]string{"--vat", "0.05", "--wait", "120"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	for n := 0; n < prop.Size.Layers; n++ {
+		expected := float32(1)
+		if n == 2 || n == 4 {
+			expected = 121
+		}
+		if output.LayerExposure(n).LightOffTime != expected {
+			t.Errorf("layer %v: expected %vs light off, got %v", n, expected, output.LayerExposure(n).LightOffTime)
+		}
+	}
+
+	cmd = NewRefillCommand()
+	cmd.Parse([]string{"--vat", "0.01"})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for a layer larger than the vat")
+	}
+
+	cmd = NewRefillCommand()
+	cmd.Parse([]string{})
+	_, err = cmd.Filter(input)
+	if err == nil {
+		t.Errorf("expected an error for an unknown vat capacity")
+	}
+}
@@ -0,0 +1,264 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// ReportMetadata is the metadata key of the pipeline that wrote a file
+const ReportMetadata = "Pipeline"
+
+// ReportStep is a file or command of an evaluated pipeline
+type ReportStep struct {
+	Command  string              `json:",omitempty"` // Command name
+	File     string              `json:",omitempty"` // Input or output file name
+	Type     string              `json:",omitempty"` // File format type
+	Options  map[string][]string `json:",omitempty"` // Options that were given, with their resolved values
+	Defaults map[string][]string `json:",omitempty"` // Options that were not given, with their default values
+	Args     []string            `json:",omitempty"` // Arguments that are not options
+}
+
+// Report is the record of an evaluated pipeline
+type Report struct {
+	Version string
+	Options map[string][]string `json:",omitempty"` // Global options that were changed
+	Steps   []ReportStep
+
+	// CommandFile reproduces the pipeline, with 'uv3dp @FILE'
+	CommandFile string
+}
+
+// reportFlags are the flags of a command or file format
+type reportFlags interface {
+	VisitAll(fn func(*pflag.Flag))
+	Lookup(name string) *pflag.Flag
+	ShorthandLookup(name string) *pflag.Flag
+}
+
+// reportValues returns the value of a flag, with one item per value of a slice
+func reportValues(flag *pflag.Flag) (values []string) {
+	slice, ok := flag.Value.(pflag.SliceValue)
+	if ok {
+		return slice.GetSlice()
+	}
+
+	return []string{flag.Value.String()}
+}
+
+// reportOptions returns the resolved values of the flags that were
+// changed, and the values of the flags that were left at their defaults.
+func reportOptions(flags reportFlags) (options, defaults map[string][]string) {
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			if options == nil {
+				options = map[string][]string{}
+			}
+			options[flag.Name] = reportValues(flag)
+		} else {
+			if defaults == nil {
+				defaults = map[string][]string{}
+			}
+			defaults[flag.Name] = reportValues(flag)
+		}
+	})
+
+	return
+}
+
+// reportArgs returns the arguments that are not options, from the
+// arguments consumed by a command or file format.
+func reportArgs(flags reportFlags, consumed []string) (args []string) {
+	// takesValue returns true if the flag's value is the next argument
+	takesValue := func(flag *pflag.Flag) bool {
+		return flag != nil && flag.NoOptDefVal == ""
+	}
+
+	for n := 0; n < len(consumed); n++ {
+		arg := consumed[n]
+		switch {
+		case arg == "--":
+			args = append(args, consumed[n+1:]...)
+			return
+		case strings.HasPrefix(arg, "--"):
+			if !strings.Contains(arg, "=") && takesValue(flags.Lookup(arg[2:])) {
+				n++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Shorthands can be combined, ie '-fv', and the last may have
+			// its value in the same argument, ie '-l5'
+			for i := 1; i < len(arg); i++ {
+				if takesValue(flags.ShorthandLookup(arg[i : i+1])) {
+					if i == len(arg)-1 {
+						n++
+					}
+					break
+				}
+			}
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	return
+}
+
+// reportQuote quotes an argument for a command file, if needed
+func reportQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\r\n\"'\\") {
+		return arg
+	}
+
+	arg = strings.ReplaceAll(arg, "\\", "\\\\")
+	arg = strings.ReplaceAll(arg, "\"", "\\\"")
+
+	return "\"" + arg + "\""
+}
+
+// reportCommandLine returns the options and arguments as a command file line
+func reportCommandLine(name string, options map[string][]string, args []string) string {
+	words := []string{}
+	if name != "" {
+		words = append(words, reportQuote(name))
+	}
+
+	names := []string{}
+	for option := range options {
+		names = append(names, option)
+	}
+	sort.Strings(names)
+
+	for _, option := range names {
+		for _, value := range options[option] {
+			words = append(words, reportQuote("--"+option+"="+value))
+		}
+	}
+
+	for _, arg := range args {
+		words = append(words, reportQuote(arg))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// NewReport starts the report of a pipeline, with the global options
+func NewReport() (report *Report) {
+	options, _ := reportOptions(pflag.CommandLine)
+	delete(options, "report")
+	if len(options) == 0 {
+		options = nil
+	}
+
+	report = &Report{
+		Version: Version,
+		Options: options,
+	}
+
+	return
+}
+
+// AddFile records an input or output file, and the arguments it consumed
+func (report *Report) AddFile(format *uv3dp.Format, consumed []string) {
+	step := ReportStep{
+		File: format.Filename,
+		Type: strings.TrimPrefix(format.Suffix, "."),
+	}
+
+	flags, ok := format.Formatter.(reportFlags)
+	if ok {
+		step.Options, step.Defaults = reportOptions(flags)
+		step.Args = reportArgs(flags, consumed)
+	}
+
+	report.Steps = append(report.Steps, step)
+}
+
+// AddCommand records a command, and the arguments it consumed
+func (report *Report) AddCommand(name string, cmd Commander, consumed []string) {
+	step := ReportStep{
+		Command: name,
+	}
+
+	flags, ok := cmd.(reportFlags)
+	if ok {
+		step.Options, step.Defaults = reportOptions(flags)
+		step.Args = reportArgs(flags, consumed)
+	}
+
+	report.Steps = append(report.Steps, step)
+}
+
+// Pipeline returns the command file that reproduces the pipeline
+func (report *Report) Pipeline() string {
+	lines := []string{}
+
+	global := reportCommandLine("", report.Options, nil)
+	if global != "" {
+		lines = append(lines, global)
+	}
+
+	for _, step := range report.Steps {
+		if step.Command != "" {
+			lines = append(lines, reportCommandLine(step.Command, step.Options, step.Args))
+			continue
+		}
+
+		// The file format type must be the first option of a file
+		line := reportQuote(step.File) + " " + reportQuote("--format="+step.Type)
+		more := reportCommandLine("", step.Options, step.Args)
+		if more != "" {
+			line += " " + more
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Write writes the report as JSON
+func (report *Report) Write(writer io.Writer) (err error) {
+	report.CommandFile = report.Pipeline()
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(report)
+
+	return
+}
+
+// reportModifier adds the pipeline to the metadata of a printable
+type reportModifier struct {
+	uv3dp.Printable
+
+	pipeline string
+}
+
+func (rm *reportModifier) MetadataKeys() (keys []string) {
+	for _, key := range rm.Printable.MetadataKeys() {
+		if key != ReportMetadata {
+			keys = append(keys, key)
+		}
+	}
+
+	keys = append(keys, ReportMetadata)
+
+	return
+}
+
+func (rm *reportModifier) Metadata(key string) (data interface{}, ok bool) {
+	if key != ReportMetadata {
+		return rm.Printable.Metadata(key)
+	}
+
+	return rm.pipeline, true
+}
@@ -0,0 +1,85 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReportArgs(t *testing.T) {
+	table := []struct {
+		Consumed []string
+		Args     []string
+	}{
+		{Consumed: []string{"-o", "6", "export", "m.csv"}, Args: []string{"export", "m.csv"}},
+		{Consumed: []string{"--light-on", "6", "import", "m.csv"}, Args: []string{"import", "m.csv"}},
+		{Consumed: []string{"--light-on=6", "-p255", "export", "m.csv"}, Args: []string{"export", "m.csv"}},
+		{Consumed: []string{"-f", "1"}},
+	}
+
+	for n, item := range table {
+		cmd := NewExposureCommand()
+		args := reportArgs(cmd, item.Consumed)
+		if !cmp.Equal(item.Args, args) {
+			t.Errorf("%v: expected %v, got %v", n, item.Args, args)
+		}
+	}
+}
+
+func TestReportPipeline(t *testing.T) {
+	report := &Report{}
+
+	cmd := NewExposureCommand()
+	consumed := []string{"-o", "6", "export", "my matrix.csv"}
+	err := cmd.Parse(append(consumed, "out.uvj"))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	report.AddCommand("exposure", cmd, consumed)
+
+	step := report.Steps[0]
+	if !cmp.Equal(map[string][]string{"light-on": {"6"}}, step.Options) {
+		t.Errorf("expected light-on of 6, got %v", step.Options)
+	}
+
+	if !cmp.Equal([]string{"0"}, step.Defaults["light-off"]) {
+		t.Errorf("expected light-off default of 0, got %v", step.Defaults)
+	}
+
+	// The command file is read back as the same arguments
+	args, err := CommandExpand(strings.NewReader(report.Pipeline()))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := []string{"exposure", "--light-on=6", "export", "my matrix.csv"}
+	if !cmp.Equal(expected, args) {
+		t.Errorf("expected %q, got %q", expected, args)
+	}
+}
+
+func TestReportQuote(t *testing.T) {
+	words := []string{"plain", "", "with space", `back\slash`, `"quoted"`, "it's"}
+
+	line := []string{}
+	for _, word := range words {
+		line = append(line, reportQuote(word))
+	}
+
+	args, err := CommandExpand(strings.NewReader(strings.Join(line, " ")))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	// Empty arguments are not kept by command files
+	expected := []string{"plain", "with space", `back\slash`, `"quoted"`, "it's"}
+	if !cmp.Equal(expected, args) {
+		t.Errorf("expected %q, got %q", expected, args)
+	}
+}
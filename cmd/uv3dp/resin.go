@@ -6,6 +6,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/pflag"
 
@@ -16,6 +17,10 @@ type ResinCommand struct {
 	*pflag.FlagSet
 
 	ResinName string
+	List      bool
+	Save      string
+	Import    string
+	File      string
 }
 
 func NewResinCommand() (cmd *ResinCommand) {
@@ -24,6 +29,10 @@ func NewResinCommand() (cmd *ResinCommand) {
 	}
 
 	cmd.StringVarP(&cmd.ResinName, "type", "t", "", "Resin type [see 'Known resins' in help]")
+	cmd.BoolVarP(&cmd.List, "list", "l", false, "List the known resins")
+	cmd.StringVarP(&cmd.Save, "save", "s", "", "Save the exposure settings of the printable as a user resin with this name")
+	cmd.StringVarP(&cmd.Import, "import", "i", "", "Import the resins of a JSON or TOML resins file as user resins")
+	cmd.StringVarP(&cmd.File, "file", "f", ResinUserPath, "User resins file")
 
 	cmd.SetInterspersed(false)
 
@@ -61,7 +70,79 @@ func (mod *resinModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
 	return
 }
 
+// resinSave adds resin entries to the user resins file, and to the resin map
+func (cmd *ResinCommand) resinSave(entries map[string]ResinEntry) (err error) {
+	saved, err := ResinLoad(cmd.File)
+	if err != nil {
+		return
+	}
+
+	for name, entry := range entries {
+		saved[name] = entry
+	}
+
+	err = ResinRegister(entries, cmd.File)
+	if err != nil {
+		return
+	}
+
+	err = ResinSave(cmd.File, saved)
+
+	return
+}
+
+// resinImport imports the resins of a resins file as user resins
+func (cmd *ResinCommand) resinImport() (err error) {
+	entries, err := ResinLoad(cmd.Import)
+	if err == nil && len(entries) == 0 {
+		err = fmt.Errorf("%v: no resins found", cmd.Import)
+	}
+	if err != nil {
+		err = fmt.Errorf("resin: %w", err)
+		return
+	}
+
+	err = cmd.resinSave(entries)
+	if err != nil {
+		err = fmt.Errorf("resin: %w", err)
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Imported %v resins from %v", len(entries), cmd.Import)
+
+	return
+}
+
+// Run executes the 'resin' command without a printable, to list or
+// import resins
+func (cmd *ResinCommand) Run() (err error) {
+	if cmd.Changed("type") || cmd.Changed("save") {
+		err = fmt.Errorf("resin: --type and --save need an input file")
+		return
+	}
+
+	if cmd.Changed("import") {
+		err = cmd.resinImport()
+		if err != nil {
+			return
+		}
+	}
+
+	if cmd.List {
+		ResinList(os.Stdout)
+	}
+
+	return
+}
+
 func (cmd *ResinCommand) Filter(input uv3dp.Printable) (mod uv3dp.Printable, err error) {
+	if cmd.Changed("import") {
+		err = cmd.resinImport()
+		if err != nil {
+			return
+		}
+	}
+
 	// Clone the resin defaults from the source printable
 	resin := &Resin{
 		Exposure: input.Exposure(),
@@ -83,5 +164,30 @@ func (cmd *ResinCommand) Filter(input uv3dp.Printable) (mod uv3dp.Printable, err
 		Resin:     *resin,
 	}
 
+	if cmd.Changed("save") {
+		entry := ResinEntry{
+			Exposure: mod.Exposure(),
+			Bottom:   mod.Bottom(),
+		}
+
+		// Keep the density and price of a resin that is being updated
+		known, ok := ResinMap[cmd.Save]
+		if ok {
+			entry.Density = known.Density
+			entry.Price = known.Price
+		}
+
+		err = cmd.resinSave(map[string]ResinEntry{cmd.Save: entry})
+		if err != nil {
+			err = fmt.Errorf("resin: %w", err)
+			return
+		}
+		TraceVerbosef(VerbosityNotice, "  Saved resin %v to %v", cmd.Save, cmd.File)
+	}
+
+	if cmd.List {
+		ResinList(os.Stdout)
+	}
+
 	return
 }
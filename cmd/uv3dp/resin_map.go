@@ -7,6 +7,7 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"sort"
@@ -21,8 +22,14 @@ type Resin struct {
 	Name string
 	uv3dp.Exposure
 	uv3dp.Bottom
+	Density float32 // Density in g/ml, or 0 if not known
+	Price   float32 // Price per liter, or 0 if not known
+	Origin  string  // Where the resin was defined
 }
 
+// Density of resins with no known density, in g/ml
+const defaultResinDensity = 1.1
+
 var ResinMap = map[string](*Resin){}
 
 var ResinConfigPath string
@@ -57,10 +64,40 @@ func setExposureFromDefault(exp *uv3dp.Exposure, def uv3dp.Exposure) {
 	}
 }
 
-// init initializes the resin map from the ChiTuBox config
-func init() {
-	ResinConfigPath = chituboxPath("machine/0.cfg")
+// chituboxResinSet sets a resin attribute of a ChiTuBox config
+func chituboxResinSet(resin *Resin, attr string, val string) {
+	iVal64, _ := strconv.ParseInt(val, 10, 32)
+	fVal64, _ := strconv.ParseFloat(val, 32)
+
+	iVal := int(iVal64)
+	fVal := float32(fVal64)
+
+	switch attr {
+	case "bottomLayCount":
+		resin.Bottom.Count = iVal
+	case "bottomLayerCount":
+		resin.Bottom.Count = iVal
+	case "bottomLayerLiftSpeed":
+		resin.Bottom.Exposure.LiftSpeed = fVal
+	case "bottomLayExposureTime":
+		resin.Bottom.Exposure.LightOnTime = fVal
+	case "bottomLightOffTime":
+		resin.Bottom.Exposure.LightOffTime = fVal
+	case "normalExposureTime":
+		resin.Exposure.LightOnTime = fVal
+	case "normalLayerLiftSpeed":
+		resin.Exposure.LiftSpeed = fVal
+	case "resinDensity":
+		resin.Density = fVal
+	case "resinCost":
+		resin.Price = fVal
+	default:
+		// Ignored
+	}
+}
 
+// resinChituboxLoad adds the resins of the ChiTuBox config to the resin map
+func resinChituboxLoad() {
 	reader, err := os.Open(ResinConfigPath)
 	if err != nil {
 		// This is fine.
@@ -111,33 +148,11 @@ func init() {
 					Name:     name,
 					Exposure: defExposure,
 					Bottom:   uv3dp.Bottom{Count: -1, Exposure: defExposure},
+					Origin:   ResinConfigPath,
 				}
 			}
 
-			iVal64, _ := strconv.ParseInt(val, 10, 32)
-			fVal64, _ := strconv.ParseFloat(val, 32)
-
-			iVal := int(iVal64)
-			fVal := float32(fVal64)
-
-			switch attr {
-			case "bottomLayCount":
-				resin.Bottom.Count = iVal
-			case "bottomLayerCount":
-				resin.Bottom.Count = iVal
-			case "bottomLayerLiftSpeed":
-				resin.Bottom.Exposure.LiftSpeed = fVal
-			case "bottomLayExposureTime":
-				resin.Bottom.Exposure.LightOnTime = fVal
-			case "bottomLightOffTime":
-				resin.Bottom.Exposure.LightOffTime = fVal
-			case "normalExposureTime":
-				resin.Exposure.LightOnTime = fVal
-			case "normalLayerLiftSpeed":
-				resin.Exposure.LiftSpeed = fVal
-			default:
-				// Ignored
-			}
+			chituboxResinSet(resin, attr, val)
 
 			ResinMap[name] = resin
 		}
@@ -157,11 +172,26 @@ func init() {
 	}
 }
 
-func PrintResins() {
-	fmt.Fprintln(os.Stderr)
-	fmt.Fprintf(os.Stderr, "Known resins: (from %v)\n", ResinConfigPath)
-	fmt.Fprintln(os.Stderr)
+// init initializes the resin map from the ChiTuBox config, and then
+// from the user resins files
+func init() {
+	ResinConfigPath = chituboxPath("machine/0.cfg")
+
+	resinChituboxLoad()
 
+	for _, filename := range []string{ResinUserPath, ResinUserTOMLPath} {
+		entries, err := ResinLoad(filename)
+		if err == nil {
+			err = ResinRegister(entries, filename)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
+}
+
+// ResinList shows the known resins
+func ResinList(writer io.Writer) {
 	keys := []string{}
 	for key := range ResinMap {
 		keys = append(keys, key)
@@ -171,9 +201,18 @@ func PrintResins() {
 
 	for _, key := range keys {
 		item := ResinMap[key]
-		fmt.Fprintf(os.Stderr, "    %-40s bottom %v layers, %v; nominal %v\n", key,
+		fmt.Fprintf(writer, "    %-40s bottom %v layers, %v; nominal %v\t(%v)\n", key,
 			item.Bottom.Count,
 			item.Bottom.Exposure.LightOnTime,
-			item.Exposure.LightOnTime)
+			item.Exposure.LightOnTime,
+			item.Origin)
 	}
 }
+
+func PrintResins() {
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintf(os.Stderr, "Known resins: (from %v, and ~/.config/uv3dp/resins.json or resins.toml)\n", ResinConfigPath)
+	fmt.Fprintln(os.Stderr)
+
+	ResinList(os.Stderr)
+}
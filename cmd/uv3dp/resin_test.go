@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestResinSaveImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "resins.json")
+
+	exposure := uv3dp.Exposure{LightOnTime: 2.5, LightPWM: 255, LiftHeight: 5, LiftSpeed: 60}
+	bottom := uv3dp.Bottom{Count: 4, Exposure: uv3dp.Exposure{LightOnTime: 30, LightPWM: 255}}
+	input := uv3dp.NewEmptyPrintable(uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 10, Layers: 8, LayerHeight: 0.05},
+		Exposure: exposure,
+		Bottom:   bottom,
+	})
+
+	cmd := NewResinCommand()
+	err = cmd.Parse([]string{"--file", filename, "--save", "test-saved-resin"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	_, err = cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	entries, err := ResinLoad(filename)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	saved, ok := entries["test-saved-resin"]
+	if !ok || saved.Exposure != exposure || saved.Bottom != bottom {
+		t.Errorf("expected the exposure of the input to be saved, got %+v", entries)
+	}
+
+	resin, ok := ResinMap["test-saved-resin"]
+	if !ok || resin.Origin != filename {
+		t.Errorf("expected the saved resin from %v, got %+v", filename, resin)
+	}
+
+	tomlname := filepath.Join(dir, "import.toml")
+	err = ioutil.WriteFile(tomlname, []byte(`
+["test-imported-resin"]
+Density = 1.2
+
+["test-imported-resin".Exposure]
+LightOnTime = 3
+
+["test-imported-resin".Bottom]
+Count = 2
+LightOnTime = 25
+`), 0644)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	cmd = NewResinCommand()
+	err = cmd.Parse([]string{"--file", filename, "--import", tomlname})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	err = cmd.Run()
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	entries, err = ResinLoad(filename)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if len(entries) != 2 || entries["test-imported-resin"].Density != 1.2 {
+		t.Errorf("expected the imported resin to be added, got %+v", entries)
+	}
+
+	resin, ok = ResinMap["test-imported-resin"]
+	if !ok || resin.Exposure.LightOnTime != 3 || resin.Bottom.Count != 2 || resin.Exposure.LightPWM != 255 {
+		t.Errorf("expected the imported resin, with full PWM, got %+v", resin)
+	}
+
+	cmd = NewResinCommand()
+	cmd.Parse([]string{"--file", filename, "--type", "test-imported-resin"})
+	err = cmd.Run()
+	if err == nil {
+		t.Errorf("expected an error for --type without an input")
+	}
+
+	badname := filepath.Join(dir, "bad.json")
+	ioutil.WriteFile(badname, []byte(`{"bad": {"Exposure": {"LightOnTime": 0}}}`), 0644)
+
+	cmd = NewResinCommand()
+	cmd.Parse([]string{"--file", filename, "--import", badname})
+	err = cmd.Run()
+	if err == nil {
+		t.Errorf("expected an error for a resin with no exposure")
+	}
+}
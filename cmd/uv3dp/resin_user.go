@@ -0,0 +1,155 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nicarran/uv3dp"
+)
+
+var (
+	// ResinUserPath is the file of the user's resins, which saved and
+	// imported resins are added to
+	ResinUserPath = uv3dpPath("resins.json")
+
+	// ResinUserTOMLPath is the file of the user's hand written resins
+	ResinUserTOMLPath = uv3dpPath("resins.toml")
+)
+
+// ResinEntry is a resin in a resins file
+type ResinEntry struct {
+	Exposure uv3dp.Exposure
+	Bottom   uv3dp.Bottom
+	Density  float32 `json:",omitempty"` // Density in g/ml, or 0 if not known
+	Price    float32 `json:",omitempty"` // Price per liter, or 0 if not known
+}
+
+// ResinParse reads resin entries by name, from JSON, or from TOML if
+// the filename has a '.toml' extension
+func ResinParse(filename string, data []byte) (entries map[string]ResinEntry, err error) {
+	entries = map[string]ResinEntry{}
+
+	if strings.ToLower(filepath.Ext(filename)) == ".toml" {
+		var table map[string]interface{}
+		table, err = TOMLParse(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+
+		// TOML tables have the same structure as JSON objects
+		data, err = json.Marshal(table)
+		if err != nil {
+			return
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&entries)
+
+	return
+}
+
+// ResinLoad reads a resins file. A missing file has no resins.
+func ResinLoad(filename string) (entries map[string]ResinEntry, err error) {
+	entries = map[string]ResinEntry{}
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	entries, err = ResinParse(filename, data)
+	if err != nil {
+		err = fmt.Errorf("%v: %w", filename, err)
+	}
+
+	return
+}
+
+// ResinSave writes a resins file, as JSON
+func ResinSave(filename string, entries map[string]ResinEntry) (err error) {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return
+	}
+
+	// Replace the file in one step, so that an interrupted save
+	// does not lose the resins.
+	tmpname := filename + ".tmp"
+	err = ioutil.WriteFile(tmpname, append(data, '\n'), 0644)
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(tmpname, filename)
+
+	return
+}
+
+// ResinRegister adds resin entries to the resin map, replacing any
+// resins with the same names
+func ResinRegister(entries map[string]ResinEntry, origin string) (err error) {
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entry := entries[name]
+		if name == "" {
+			err = fmt.Errorf("%v: resin names must not be empty", origin)
+			return
+		}
+
+		if entry.Exposure.LightOnTime <= 0 {
+			err = fmt.Errorf("%v: resin '%v': Exposure.LightOnTime must be greater than 0", origin, name)
+			return
+		}
+
+		if entry.Bottom.Count < 0 || (entry.Bottom.Count > 0 && entry.Bottom.LightOnTime <= 0) {
+			err = fmt.Errorf("%v: resin '%v': Bottom must have a Count of 0, or a LightOnTime greater than 0", origin, name)
+			return
+		}
+
+		// Files rarely set the PWM, which is full unless it is set
+		if entry.Exposure.LightPWM == 0 {
+			entry.Exposure.LightPWM = 255
+		}
+		if entry.Bottom.LightPWM == 0 {
+			entry.Bottom.LightPWM = 255
+		}
+
+		ResinMap[name] = &Resin{
+			Name:     name,
+			Exposure: entry.Exposure,
+			Bottom:   entry.Bottom,
+			Density:  entry.Density,
+			Price:    entry.Price,
+			Origin:   origin,
+		}
+	}
+
+	return
+}
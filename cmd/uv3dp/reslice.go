@@ -0,0 +1,243 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type ResliceCommand struct {
+	*pflag.FlagSet
+
+	LayerHeight float32
+	Mode        string
+}
+
+func NewResliceCommand() (cmd *ResliceCommand) {
+	cmd = &ResliceCommand{
+		FlagSet: pflag.NewFlagSet("reslice", pflag.ContinueOnError),
+	}
+
+	cmd.Float32VarP(&cmd.LayerHeight, "layer-height", "l", 0.0, "New layer height, in millimeters")
+	cmd.StringVarP(&cmd.Mode, "mode", "m", "blend", "Resampling mode: 'blend' (grayscale blend of the overlapping layers) or 'nearest' (the layer at the middle of each new layer)")
+
+	cmd.SetInterspersed(false)
+
+	return
+}
+
+// Heights within a micrometer are the same, to ignore rounding of the layer Z
+const resliceEpsilon = 0.001
+
+// resliceSource is a layer of the input that overlaps a new layer
+type resliceSource struct {
+	index  int
+	weight float32 // Fraction of the new layer that the input layer covers
+}
+
+// resliceModifier resamples the layers of a printable to a new layer height
+type resliceModifier struct {
+	uv3dp.Printable
+
+	layerHeight float32
+	bottom      uv3dp.Bottom
+	sources     [][]resliceSource
+	nearest     []int
+	blend       bool
+}
+
+// resliceLayers maps the layers of a printable to new layers of a layer
+// height, returning the input layers that overlap each new layer, and
+// the input layer at the middle of each new layer.
+func resliceLayers(input uv3dp.Printable, layerHeight float32) (sources [][]resliceSource, nearest []int) {
+	layers := input.Size().Layers
+	if layers == 0 {
+		return
+	}
+
+	height := float64(input.LayerZ(layers - 1))
+	count := int(math.Round(height / float64(layerHeight)))
+	if count < 1 {
+		count = 1
+	}
+
+	sources = make([][]resliceSource, count)
+	nearest = make([]int, count)
+
+	index := 0
+	for n := 0; n < count; n++ {
+		low := float64(layerHeight) * float64(n)
+		high := low + float64(layerHeight)
+		middle := (low + high) / 2
+
+		// Skip the input layers entirely below the new layer
+		for index < layers-1 && float64(input.LayerZ(index)) <= low+resliceEpsilon {
+			index++
+		}
+
+		for at := index; at < layers; at++ {
+			bottom := 0.0
+			if at > 0 {
+				bottom = float64(input.LayerZ(at - 1))
+			}
+			top := float64(input.LayerZ(at))
+
+			if bottom >= high-resliceEpsilon {
+				break
+			}
+
+			if middle > bottom && middle <= top {
+				nearest[n] = at
+			}
+
+			overlap := math.Min(top, high) - math.Max(bottom, low)
+			if overlap > resliceEpsilon {
+				sources[n] = append(sources[n], resliceSource{index: at, weight: float32(overlap / float64(layerHeight))})
+			}
+		}
+
+		if middle > float64(input.LayerZ(layers-1)) {
+			nearest[n] = layers - 1
+		}
+	}
+
+	return
+}
+
+func (mod *resliceModifier) Size() (size uv3dp.Size) {
+	size = mod.Printable.Size()
+	size.Layers = len(mod.sources)
+	size.LayerHeight = mod.layerHeight
+
+	return
+}
+
+func (mod *resliceModifier) Bottom() (bottom uv3dp.Bottom) {
+	bottom = mod.bottom
+
+	return
+}
+
+func (mod *resliceModifier) LayerZ(index int) (z float32) {
+	z = float32(math.Round(float64(mod.layerHeight)*float64(index+1)*1000) / 1000)
+
+	return
+}
+
+func (mod *resliceModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	exposure = mod.Printable.LayerExposure(mod.nearest[index])
+
+	return
+}
+
+func (mod *resliceModifier) LayerImage(index int) (grayImage *image.Gray) {
+	sources := mod.sources[index]
+
+	if !mod.blend || len(sources) < 2 {
+		grayImage = mod.Printable.LayerImage(mod.nearest[index])
+		return
+	}
+
+	// The weights are normalized, so that a last layer that extends
+	// above the top of the input is not dimmed.
+	var sum []float32
+	total := float32(0)
+	for _, source := range sources {
+		srcImage := mod.Printable.LayerImage(source.index)
+		if sum == nil {
+			grayImage = image.NewGray(srcImage.Bounds())
+			sum = make([]float32, len(srcImage.Pix))
+		}
+
+		for n, pix := range srcImage.Pix {
+			sum[n] += float32(pix) * source.weight
+		}
+		total += source.weight
+	}
+
+	for n, value := range sum {
+		value /= total
+		if value > 255 {
+			value = 255
+		}
+		grayImage.Pix[n] = uint8(value + 0.5)
+	}
+
+	return
+}
+
+// resliceCount returns the number of layers of a new layer height that
+// cover the same height as a number of input layers
+func resliceCount(input uv3dp.Printable, layers int, layerHeight float32) int {
+	if layers <= 0 {
+		return 0
+	}
+
+	if layers > input.Size().Layers {
+		layers = input.Size().Layers
+	}
+
+	count := int(math.Round(float64(input.LayerZ(layers-1)) / float64(layerHeight)))
+	if count < 1 {
+		count = 1
+	}
+
+	return count
+}
+
+func (cmd *ResliceCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.LayerHeight <= 0 {
+		err = fmt.Errorf("reslice: --layer-height must be greater than 0 mm")
+		return
+	}
+
+	if cmd.Mode != "blend" && cmd.Mode != "nearest" {
+		err = fmt.Errorf("reslice: unknown --mode '%v'", cmd.Mode)
+		return
+	}
+
+	sources, nearest := resliceLayers(input, cmd.LayerHeight)
+
+	bottom := input.Bottom()
+	count := bottom.Count
+	bottom.Count = resliceCount(input, count, cmd.LayerHeight)
+	bottom.Transition = resliceCount(input, count+bottom.Transition, cmd.LayerHeight) - bottom.Count
+
+	TraceVerbosef(VerbosityNotice, "  Reslicing %v layers of %v mm to %v layers of %v mm",
+		input.Size().Layers, input.Size().LayerHeight, len(sources), cmd.LayerHeight)
+
+	mod := &resliceModifier{
+		Printable:   input,
+		layerHeight: cmd.LayerHeight,
+		bottom:      bottom,
+		sources:     sources,
+		nearest:     nearest,
+		blend:       cmd.Mode == "blend",
+	}
+
+	// Each input layer is moved to the new layer that its top is in
+	output = uv3dp.RemapLayers(mod, uv3dp.LayerRemap{
+		Printable: input,
+		Remap: func(index int) (int, bool) {
+			top := float64(input.LayerZ(index)) / float64(cmd.LayerHeight)
+			layer := int(math.Ceil(top-resliceEpsilon)) - 1
+			if layer < 0 {
+				layer = 0
+			}
+			if layer >= len(sources) {
+				layer = len(sources) - 1
+			}
+			return layer, layer >= 0
+		},
+	})
+
+	return
+}
@@ -0,0 +1,119 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// reslicePrint has every pixel of each layer at a level
+type reslicePrint struct {
+	uv3dp.Print
+	levels []uint8
+}
+
+func (rp *reslicePrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(rp.Properties.Bounds())
+	for n := range grayImage.Pix {
+		grayImage.Pix[n] = rp.levels[index]
+	}
+	return
+}
+
+func TestReslice(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 2, Y: 2, Layers: 4, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+		Bottom:   uv3dp.Bottom{Count: 2, Exposure: uv3dp.Exposure{LightOnTime: 60}},
+	}
+
+	input := &reslicePrint{Print: uv3dp.Print{Properties: prop}, levels: []uint8{255, 255, 0, 200}}
+
+	table := map[string]struct {
+		Args     []string
+		Z        []float32
+		Levels   []uint8
+		Exposure []float32
+		Bottom   int
+	}{
+		"thinner": {
+			Args:     []string{"-l", "0.025"},
+			Z:        []float32{0.025, 0.05, 0.075, 0.1, 0.125, 0.15, 0.175, 0.2},
+			Levels:   []uint8{255, 255, 255, 255, 0, 0, 200, 200},
+			Exposure: []float32{60, 60, 60, 60, 8, 8, 8, 8},
+			Bottom:   4,
+		},
+		"thicker": {
+			Args:     []string{"-l", "0.1"},
+			Z:        []float32{0.1, 0.2},
+			Levels:   []uint8{255, 100},
+			Exposure: []float32{60, 8},
+			Bottom:   1,
+		},
+		"straddle": {
+			Args:     []string{"-l", "0.075"},
+			Z:        []float32{0.075, 0.15, 0.225},
+			Levels:   []uint8{255, 85, 200},
+			Exposure: []float32{60, 8, 8},
+			Bottom:   1,
+		},
+		"nearest": {
+			Args:     []string{"-l", "0.1", "-m", "nearest"},
+			Z:        []float32{0.1, 0.2},
+			Levels:   []uint8{255, 0},
+			Exposure: []float32{60, 8},
+			Bottom:   1,
+		},
+	}
+
+	for name, item := range table {
+		cmd := NewResliceCommand()
+		err := cmd.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+
+		if output.Size().Layers != len(item.Z) {
+			t.Errorf("%v: expected %v layers, got %v", name, len(item.Z), output.Size().Layers)
+			continue
+		}
+
+		if output.Bottom().Count != item.Bottom {
+			t.Errorf("%v: expected %v bottom layers, got %v", name, item.Bottom, output.Bottom().Count)
+		}
+
+		for n, z := range item.Z {
+			if !convertEqual(output.LayerZ(n), z) {
+				t.Errorf("%v: layer %v: expected Z %v, got %v", name, n, z, output.LayerZ(n))
+			}
+			if level := output.LayerImage(n).Pix[0]; level != item.Levels[n] {
+				t.Errorf("%v: layer %v: expected level %v, got %v", name, n, item.Levels[n], level)
+			}
+			if exposure := output.LayerExposure(n).LightOnTime; exposure != item.Exposure[n] {
+				t.Errorf("%v: layer %v: expected exposure %v, got %v", name, n, item.Exposure[n], exposure)
+			}
+		}
+	}
+
+	for _, args := range [][]string{{}, {"-l", "0.05", "-m", "cubic"}} {
+		cmd := NewResliceCommand()
+		err := cmd.Parse(args)
+		if err == nil {
+			_, err = cmd.Filter(input)
+		}
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type ResumeCommand struct {
+	*pflag.FlagSet
+
+	Z      float32
+	Layer  int
+	Bottom int
+}
+
+func NewResumeCommand() (cmd *ResumeCommand) {
+	cmd = &ResumeCommand{
+		FlagSet: pflag.NewFlagSet("resume", pflag.ContinueOnError),
+	}
+
+	cmd.Float32VarP(&cmd.Z, "z", "z", 0.0, "Resume with the first layer above this Z height, in millimeters")
+	cmd.IntVarP(&cmd.Layer, "layer", "l", 0, "Resume from this layer index")
+	cmd.IntVarP(&cmd.Bottom, "bottom", "b", 0, "Number of resumed layers to expose as bottom layers")
+
+	cmd.SetInterspersed(false)
+
+	return
+}
+
+// resumeModifier is the layers of a printable from a layer upwards, at
+// their original Z heights, with new bottom layers
+type resumeModifier struct {
+	SelectPrintable
+
+	bottom      uv3dp.Bottom
+	inputBottom int // Bottom and transition layers of the input
+}
+
+func (mod *resumeModifier) Bottom() (bottom uv3dp.Bottom) {
+	bottom = mod.bottom
+
+	return
+}
+
+func (mod *resumeModifier) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	switch {
+	case index < mod.bottom.Count:
+		exposure = mod.bottom.Exposure
+	case index+mod.first < mod.inputBottom:
+		// The bottom settings of the input no longer apply
+		exposure = mod.Printable.Exposure()
+	default:
+		exposure = mod.SelectPrintable.LayerExposure(index)
+	}
+
+	return
+}
+
+// ResumeLayer returns the first layer of a printable above a Z height
+func ResumeLayer(input uv3dp.Printable, z float32) (layer int, err error) {
+	layers := input.Size().Layers
+	for layer = 0; layer < layers; layer++ {
+		if input.LayerZ(layer) > z+convertTolerance {
+			return
+		}
+	}
+
+	err = fmt.Errorf("no layers above Z %v mm", z)
+
+	return
+}
+
+func (cmd *ResumeCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Changed("z") == cmd.Changed("layer") {
+		err = fmt.Errorf("resume: exactly one of --z or --layer must be specified")
+		return
+	}
+
+	layers := input.Size().Layers
+
+	first := cmd.Layer
+	if cmd.Changed("z") {
+		first, err = ResumeLayer(input, cmd.Z)
+		if err != nil {
+			err = fmt.Errorf("resume: %w", err)
+			return
+		}
+	}
+
+	if first < 0 || first >= layers {
+		err = fmt.Errorf("resume: layer %v is not in the range 0..%v", first, layers-1)
+		return
+	}
+
+	if cmd.Bottom < 0 {
+		err = fmt.Errorf("resume: --bottom must not be negative")
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Resuming from layer %v, at Z %v mm", first, input.LayerZ(first))
+
+	bottom := input.Bottom()
+	inputBottom := bottom.Count + bottom.Transition
+	bottom.Count = cmd.Bottom
+	bottom.Transition = 0
+	if bottom.Count > layers-first {
+		bottom.Count = layers - first
+	}
+
+	mod := &resumeModifier{
+		SelectPrintable: SelectPrintable{
+			Printable: input,
+			first:     first,
+			count:     layers - first,
+		},
+		bottom:      bottom,
+		inputBottom: inputBottom,
+	}
+
+	output = uv3dp.RemapLayers(mod, uv3dp.LayerRemap{Printable: input, Remap: mod.remap})
+
+	return
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestResume(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 10, Layers: 10, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+		Bottom:   uv3dp.Bottom{Count: 3, Exposure: uv3dp.Exposure{LightOnTime: 60}},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	table := map[string]struct {
+		Args     []string
+		Layers   int
+		Z        []float32
+		Exposure []float32
+	}{
+		"z":      {Args: []string{"-z", "0.35"}, Layers: 3, Z: []float32{0.4, 0.45, 0.5}, Exposure: []float32{8, 8, 8}},
+		"layer":  {Args: []string{"-l", "1"}, Layers: 9, Z: []float32{0.1, 0.15, 0.2}, Exposure: []float32{8, 8, 8}},
+		"bottom": {Args: []string{"-l", "1", "-b", "1"}, Layers: 9, Z: []float32{0.1, 0.15, 0.2}, Exposure: []float32{60, 8, 8}},
+	}
+
+	for name, item := range table {
+		cmd := NewResumeCommand()
+		err := cmd.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+
+		if output.Size().Layers != item.Layers {
+			t.Errorf("%v: expected %v layers, got %v", name, item.Layers, output.Size().Layers)
+		}
+
+		for n, z := range item.Z {
+			if !convertEqual(output.LayerZ(n), z) {
+				t.Errorf("%v: layer %v: expected Z %v, got %v", name, n, z, output.LayerZ(n))
+			}
+			if exposure := output.LayerExposure(n).LightOnTime; exposure != item.Exposure[n] {
+				t.Errorf("%v: layer %v: expected exposure %v, got %v", name, n, item.Exposure[n], exposure)
+			}
+		}
+	}
+
+	for _, args := range [][]string{{}, {"-z", "0.5"}, {"-l", "10"}, {"-z", "0.1", "-l", "2"}} {
+		cmd := NewResumeCommand()
+		err := cmd.Parse(args)
+		if err == nil {
+			_, err = cmd.Filter(input)
+		}
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -15,6 +15,9 @@ type RetractCommand struct {
 
 	RetractHeight float32
 	RetractSpeed  float32
+
+	RetractHeight2 float32
+	RetractSpeed2  float32
 }
 
 func NewRetractCommand() (cmd *RetractCommand) {
@@ -24,6 +27,8 @@ func NewRetractCommand() (cmd *RetractCommand) {
 
 	cmd.Float32VarP(&cmd.RetractHeight, "height", "h", 0.0, "Retract height in mm")
 	cmd.Float32VarP(&cmd.RetractSpeed, "speed", "s", 0.0, "Retract speed in mm/min")
+	cmd.Float32VarP(&cmd.RetractHeight2, "height2", "H", 0.0, "Last stage (TSMC) retract height in mm")
+	cmd.Float32VarP(&cmd.RetractSpeed2, "speed2", "S", 0.0, "Last stage (TSMC) retract speed in mm/min")
 
 	cmd.SetInterspersed(false)
 
@@ -68,6 +73,16 @@ func (cmd *RetractCommand) Filter(input uv3dp.Printable) (mod uv3dp.Printable, e
 		exp.RetractSpeed = cmd.RetractSpeed
 	}
 
+	if cmd.Changed("height2") {
+		TraceVerbosef(VerbosityNotice, "  Setting default last stage retract height to %v mm", cmd.RetractHeight2)
+		exp.RetractHeight2 = cmd.RetractHeight2
+	}
+
+	if cmd.Changed("speed2") {
+		TraceVerbosef(VerbosityNotice, "  Setting default last stage retract speed to %v mm/min", cmd.RetractSpeed2)
+		exp.RetractSpeed2 = cmd.RetractSpeed2
+	}
+
 	mod = &retractModifier{
 		Printable: input,
 		exposure:  exp,
@@ -0,0 +1,167 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/image/draw"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Lanczos resampling, with three lobes
+var lanczos3 = &draw.Kernel{Support: 3, At: func(t float64) float64 {
+	if t == 0 {
+		return 1
+	}
+	if t < 0 {
+		t = -t
+	}
+	if t >= 3 {
+		return 0
+	}
+	pt := math.Pi * t
+	return 3 * math.Sin(pt) * math.Sin(pt/3) / (pt * pt)
+}}
+
+var scaleFilters = map[string]draw.Interpolator{
+	"nearest":  draw.NearestNeighbor,
+	"bilinear": draw.BiLinear,
+	"bicubic":  draw.CatmullRom,
+	"lanczos":  lanczos3,
+}
+
+func scaleFilterNames() string {
+	names := []string{}
+	for name := range scaleFilters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
+
+type ScaleCommand struct {
+	*pflag.FlagSet
+
+	Percent     []float32
+	Millimeters []float32
+	Resample    string
+}
+
+func NewScaleCommand() (cmd *ScaleCommand) {
+	flagSet := pflag.NewFlagSet("scale", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &ScaleCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.Float32SliceVarP(&cmd.Percent, "percent", "p", []float32{100, 100}, "Scale of the model in X and Y, in percent")
+	cmd.Float32SliceVarP(&cmd.Millimeters, "millimeters", "m", []float32{}, "Target size of the model in X and Y, in millimeters")
+	cmd.StringVarP(&cmd.Resample, "filter", "f", "bilinear", "Resampling filter ("+scaleFilterNames()+")")
+
+	return
+}
+
+// scaleModifier scales the model of all layers around the model's center
+type scaleModifier struct {
+	uv3dp.Printable
+
+	filter  draw.Interpolator
+	srcRect image.Rectangle
+	dstRect image.Rectangle
+}
+
+func (sm *scaleModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := sm.Printable.LayerImage(index)
+
+	grayImage = image.NewGray(srcImage.Bounds())
+	sm.filter.Scale(grayImage, sm.dstRect, srcImage, sm.srcRect, draw.Src, nil)
+
+	return
+}
+
+func (cmd *ScaleCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	filter, ok := scaleFilters[cmd.Resample]
+	if !ok {
+		err = fmt.Errorf("scale: filter '%v' is not one of: %v", cmd.Resample, scaleFilterNames())
+		return
+	}
+
+	if len(cmd.Percent) == 1 {
+		cmd.Percent = append(cmd.Percent, cmd.Percent[0])
+	}
+
+	if len(cmd.Percent) != 2 {
+		err = fmt.Errorf("scale: --percent expects one or two values")
+		return
+	}
+
+	if cmd.Changed("millimeters") && len(cmd.Millimeters) != 2 {
+		err = fmt.Errorf("scale: --millimeters expects two values")
+		return
+	}
+
+	output = input
+
+	bounds := uv3dp.ModelBounds(input)
+	if bounds.Empty() {
+		TraceVerbosef(VerbosityWarning, "scale: no model found to scale")
+		return
+	}
+
+	size := input.Size()
+	mmPerPixelX := float64(size.Millimeter.X) / float64(size.X)
+	mmPerPixelY := float64(size.Millimeter.Y) / float64(size.Y)
+
+	scaleX := float64(cmd.Percent[0]) / 100.0
+	scaleY := float64(cmd.Percent[1]) / 100.0
+
+	if cmd.Changed("millimeters") {
+		scaleX = float64(cmd.Millimeters[0]) / (float64(bounds.Dx()) * mmPerPixelX)
+		scaleY = float64(cmd.Millimeters[1]) / (float64(bounds.Dy()) * mmPerPixelY)
+	}
+
+	if scaleX <= 0 || scaleY <= 0 {
+		err = fmt.Errorf("scale: scale must be greater than zero")
+		return
+	}
+
+	// Scale around the center of the model
+	centerX := float64(bounds.Min.X+bounds.Max.X) / 2
+	centerY := float64(bounds.Min.Y+bounds.Max.Y) / 2
+	dx := float64(bounds.Dx()) * scaleX
+	dy := float64(bounds.Dy()) * scaleY
+
+	dstRect := image.Rect(
+		int(math.Round(centerX-dx/2)), int(math.Round(centerY-dy/2)),
+		int(math.Round(centerX+dx/2)), int(math.Round(centerY+dy/2)))
+
+	TraceVerbosef(VerbosityNotice, "  Scaling model by %.4g%% x %.4g%% (%.4g x %.4g mm => %.4g x %.4g mm)",
+		scaleX*100, scaleY*100,
+		float64(bounds.Dx())*mmPerPixelX, float64(bounds.Dy())*mmPerPixelY,
+		float64(dstRect.Dx())*mmPerPixelX, float64(dstRect.Dy())*mmPerPixelY)
+
+	if !dstRect.In(image.Rect(0, 0, size.X, size.Y)) {
+		err = fmt.Errorf("scale: scaled model %v does not fit on the %dx%d bed", dstRect, size.X, size.Y)
+		return
+	}
+
+	output = &scaleModifier{
+		Printable: input,
+		filter:    filter,
+		srcRect:   bounds,
+		dstRect:   dstRect,
+	}
+
+	return
+}
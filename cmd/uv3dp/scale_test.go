@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type scalePrint struct {
+	uv3dp.Print
+	model image.Rectangle
+}
+
+func (sp *scalePrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(sp.Properties.Bounds())
+	for y := sp.model.Min.Y; y < sp.model.Max.Y; y++ {
+		for x := sp.model.Min.X; x < sp.model.Max.X; x++ {
+			grayImage.Pix[y*grayImage.Stride+x] = 0xff
+		}
+	}
+	return
+}
+
+// boundsTest is a filter test of a command, with its expected model bounds
+type boundsTest struct {
+	Args   []string
+	Size   uv3dp.SizeMillimeter // Expected bed size, if not zero
+	Bounds image.Rectangle
+	Valid  bool
+}
+
+// testBounds checks the bounds of the model in the output of each test's
+// command, on a 50x50mm bed of 100x100 pixels
+func testBounds(t *testing.T, newCommand func() Commander, model image.Rectangle, table map[string]boundsTest) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 100, Y: 100, Layers: 2, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 50, Y: 50},
+		},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: model,
+	}
+
+	for name, item := range table {
+		cmd := newCommand()
+		err := cmd.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if (err == nil) != item.Valid {
+			t.Errorf("%v: expected valid %v, got %v", name, item.Valid, err)
+			continue
+		}
+
+		if !item.Valid {
+			continue
+		}
+
+		if item.Size != (uv3dp.SizeMillimeter{}) && output.Size().Millimeter != item.Size {
+			t.Errorf("%v: expected %+v, got %+v", name, item.Size, output.Size().Millimeter)
+		}
+
+		bounds := uv3dp.ImageBounds(output.LayerImage(1))
+		if bounds != item.Bounds {
+			t.Errorf("%v: expected %v, got %v", name, item.Bounds, bounds)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	testBounds(t, func() Commander { return NewScaleCommand() }, image.Rect(40, 30, 60, 50), map[string]boundsTest{
+		"percent":     {Args: []string{"-p", "50", "-f", "nearest"}, Bounds: image.Rect(45, 35, 55, 45), Valid: true},
+		"percent-xy":  {Args: []string{"-p", "200,100", "-f", "nearest"}, Bounds: image.Rect(30, 30, 70, 50), Valid: true},
+		"millimeters": {Args: []string{"-m", "20,5", "-f", "nearest"}, Bounds: image.Rect(30, 35, 70, 45), Valid: true},
+		"lanczos":     {Args: []string{"-p", "50", "-f", "lanczos"}, Bounds: image.Rect(45, 35, 55, 45), Valid: true},
+		"too-big":     {Args: []string{"-p", "600"}},
+		"bad-filter":  {Args: []string{"-f", "unknown"}},
+	})
+}
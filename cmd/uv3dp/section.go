@@ -0,0 +1,200 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type SectionCommand struct {
+	*pflag.FlagSet
+
+	Z float32
+	X float32
+	Y float32
+
+	Filename string
+	args     []string
+}
+
+func NewSectionCommand() (cmd *SectionCommand) {
+	flagSet := pflag.NewFlagSet("section", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &SectionCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.Float32VarP(&cmd.Z, "z", "z", 0.0, "Saves the layer nearest to this Z height, in millimeters")
+	cmd.Float32VarP(&cmd.X, "x", "x", 0.0, "Saves a vertical (YZ) section at this X, in millimeters")
+	cmd.Float32VarP(&cmd.Y, "y", "y", 0.0, "Saves a vertical (XZ) section at this Y, in millimeters")
+
+	return
+}
+
+// Parse parses the flags, and the PNG file to save the section to
+func (cmd *SectionCommand) Parse(args []string) (err error) {
+	err = cmd.FlagSet.Parse(args)
+	if err != nil {
+		return
+	}
+
+	cmd.args = cmd.FlagSet.Args()
+	if len(cmd.args) < 1 {
+		err = fmt.Errorf("section: expected a PNG file to save the section to")
+		return
+	}
+
+	cmd.Filename = cmd.args[0]
+	cmd.args = cmd.args[1:]
+
+	return
+}
+
+// Args returns the arguments after the PNG file
+func (cmd *SectionCommand) Args() []string {
+	return cmd.args
+}
+
+func (cmd *SectionCommand) NArg() int {
+	return len(cmd.args)
+}
+
+// SectionLayer returns the layer with a Z height nearest to a Z
+func SectionLayer(input uv3dp.Printable, z float32) (layer int) {
+	nearest := math.Inf(1)
+	for n := 0; n < input.Size().Layers; n++ {
+		distance := math.Abs(float64(input.LayerZ(n) - z))
+		if distance < nearest {
+			nearest = distance
+			layer = n
+		}
+	}
+
+	return
+}
+
+// SectionVertical returns a vertical section through the layers, at a
+// column (if vertical is true) or a row of the layer images. The top
+// of the section is the top of the print, and its rows are as high as
+// the pixels of the layers are wide, so that it is to scale.
+func SectionVertical(input uv3dp.Printable, at int, vertical bool) (section *image.Gray) {
+	size := input.Size()
+
+	width, pixel := size.X, size.Millimeter.Y/float32(size.Y)
+	if vertical {
+		width, pixel = size.Y, size.Millimeter.X/float32(size.X)
+	}
+
+	// The line of each layer
+	lines := make([][]uint8, size.Layers)
+	uv3dp.WithAllLayers(input, func(p uv3dp.Printable, n int) {
+		layer := p.LayerImage(n)
+		line := make([]uint8, width)
+		for x := range line {
+			if vertical {
+				line[x] = layer.Pix[x*layer.Stride+at]
+			} else {
+				line[x] = layer.Pix[at*layer.Stride+x]
+			}
+		}
+		lines[n] = line
+	})
+
+	height := 0
+	if size.Layers > 0 {
+		height = int(math.Round(float64(input.LayerZ(size.Layers-1) / pixel)))
+	}
+
+	section = image.NewGray(image.Rect(0, 0, width, height))
+
+	layer := 0
+	for row := height - 1; row >= 0; row-- {
+		z := (float32(height-row) - 0.5) * pixel
+		for layer < size.Layers-1 && input.LayerZ(layer) < z {
+			layer++
+		}
+		copy(section.Pix[row*section.Stride:], lines[layer])
+	}
+
+	return
+}
+
+func (cmd *SectionCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	output = input
+
+	planes := 0
+	for _, name := range []string{"z", "x", "y"} {
+		if cmd.Changed(name) {
+			planes++
+		}
+	}
+
+	if planes != 1 {
+		err = fmt.Errorf("section: exactly one of --z, --x, or --y must be specified")
+		return
+	}
+
+	size := input.Size()
+	if size.Layers == 0 {
+		err = fmt.Errorf("section: no layers")
+		return
+	}
+
+	var section *image.Gray
+	switch {
+	case cmd.Changed("z"):
+		layer := SectionLayer(input, cmd.Z)
+		TraceVerbosef(VerbosityNotice, "  Saving layer %v, at Z %v mm, to %v", layer, input.LayerZ(layer), cmd.Filename)
+		section = input.LayerImage(layer)
+	case cmd.Changed("x"):
+		column := int(cmd.X * float32(size.X) / size.Millimeter.X)
+		if column < 0 || column >= size.X {
+			err = fmt.Errorf("section: --x %v is not on the bed", cmd.X)
+			return
+		}
+		TraceVerbosef(VerbosityNotice, "  Saving the section at column %v to %v", column, cmd.Filename)
+		section = SectionVertical(input, column, true)
+	case cmd.Changed("y"):
+		row := int(cmd.Y * float32(size.Y) / size.Millimeter.Y)
+		if row < 0 || row >= size.Y {
+			err = fmt.Errorf("section: --y %v is not on the bed", cmd.Y)
+			return
+		}
+		TraceVerbosef(VerbosityNotice, "  Saving the section at row %v to %v", row, cmd.Filename)
+		section = SectionVertical(input, row, false)
+	}
+
+	writer := os.Stdout
+	if cmd.Filename != uv3dp.FilenameStdio {
+		writer, err = os.Create(cmd.Filename)
+		if err != nil {
+			err = fmt.Errorf("section: %w", err)
+			return
+		}
+	}
+
+	err = png.Encode(writer, section)
+	if writer != os.Stdout {
+		closeErr := writer.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("section: %v: %w", cmd.Filename, err)
+	}
+
+	return
+}
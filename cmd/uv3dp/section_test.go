@@ -0,0 +1,172 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// sectionPrint has a staircase, with each layer two pixels wider than
+// the layer below it
+type sectionPrint struct {
+	uv3dp.Print
+}
+
+func (sp *sectionPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(sp.Properties.Bounds())
+	for y := 0; y < sp.Properties.Size.Y; y++ {
+		for x := 0; x < 2*(index+1); x++ {
+			grayImage.Pix[y*grayImage.Stride+x] = 0xff
+		}
+	}
+
+	return
+}
+
+func newSectionPrint(layerHeight float32) *sectionPrint {
+	return &sectionPrint{uv3dp.Print{Properties: uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 10, Y: 6, Layers: 4, LayerHeight: layerHeight,
+			Millimeter: uv3dp.SizeMillimeter{X: 1, Y: 0.6},
+		},
+	}}}
+}
+
+func TestSectionLayer(t *testing.T) {
+	input := newSectionPrint(0.05)
+
+	table := map[float32]int{
+		0.0:  0,
+		0.07: 0,
+		0.08: 1,
+		0.16: 2,
+		1.0:  3,
+	}
+
+	for z, expected := range table {
+		got := SectionLayer(input, z)
+		if got != expected {
+			t.Errorf("Z %v: expected layer %v, got %v", z, expected, got)
+		}
+	}
+}
+
+func TestSectionVertical(t *testing.T) {
+	table := map[string]struct {
+		layerHeight float32
+		vertical    bool
+		at          int
+		width       int
+		lit         []int // Lit pixels of each row, from the top
+	}{
+		"row": {
+			layerHeight: 0.1,
+			at:          3,
+			width:       10,
+			lit:         []int{8, 6, 4, 2},
+		},
+		"column": {
+			layerHeight: 0.1,
+			vertical:    true,
+			at:          5,
+			width:       6,
+			lit:         []int{6, 6, 0, 0},
+		},
+		"scaled": {
+			layerHeight: 0.04,
+			at:          0,
+			width:       10,
+			lit:         []int{8, 4},
+		},
+	}
+
+	for name, item := range table {
+		section := SectionVertical(newSectionPrint(item.layerHeight), item.at, item.vertical)
+
+		size := image.Pt(item.width, len(item.lit))
+		if section.Bounds().Size() != size {
+			t.Errorf("%v: expected size %v, got %v", name, size, section.Bounds().Size())
+			continue
+		}
+
+		for row, expected := range item.lit {
+			line := &image.Gray{Pix: section.Pix[row*section.Stride : row*section.Stride+size.X]}
+			got := uv3dp.LitPixels(line)
+			if got != expected {
+				t.Errorf("%v: row %v: expected %v lit pixels, got %v", name, row, expected, got)
+			}
+		}
+	}
+}
+
+func TestSection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := newSectionPrint(0.05)
+	filename := filepath.Join(dir, "section.png")
+
+	cmd := NewSectionCommand()
+	err = cmd.Parse([]string{"--z", "0.1", filename, "next"})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if cmd.NArg() != 1 || cmd.Args()[0] != "next" {
+		t.Errorf("expected the arguments after the file, got %v", cmd.Args())
+	}
+
+	output, err := cmd.Filter(input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if output != input {
+		t.Errorf("expected the input to be unchanged")
+	}
+
+	reader, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer reader.Close()
+
+	pic, err := png.Decode(reader)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if pic.Bounds() != image.Rect(0, 0, 10, 6) {
+		t.Errorf("expected a layer image, got bounds %v", pic.Bounds())
+	}
+
+	for _, args := range [][]string{
+		{filename},
+		{"--z", "0.1", "--x", "0.5", filename},
+		{"--x", "1.5", filename},
+	} {
+		cmd = NewSectionCommand()
+		cmd.Parse(args)
+		_, err = cmd.Filter(input)
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+
+	err = NewSectionCommand().Parse([]string{"--z", "0.1"})
+	if err == nil {
+		t.Errorf("expected an error without a file")
+	}
+}
@@ -61,6 +61,13 @@ func (sp *SelectPrintable) Size() (size uv3dp.Size) {
 	return
 }
 
+// remap returns the selected layer of an input layer
+func (sp *SelectPrintable) remap(index int) (int, bool) {
+	index -= sp.first
+
+	return index, index >= 0 && index < sp.count
+}
+
 func (cmd *SelectCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
 	layers := input.Size().Layers
 
@@ -90,7 +97,7 @@ func (cmd *SelectCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable,
 		count:     count,
 	}
 
-	output = sp
+	output = uv3dp.RemapLayers(sp, uv3dp.LayerRemap{Printable: input, Remap: sp.remap})
 
 	return
 }
@@ -0,0 +1,441 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type SplitCommand struct {
+	*pflag.FlagSet
+
+	Layers       int
+	Z            []float32
+	Height       float32
+	Output       string
+	Keys         int
+	KeyDiameter  float32
+	KeyDepth     float32
+	KeyClearance float32
+}
+
+func NewSplitCommand() (cmd *SplitCommand) {
+	flagSet := pflag.NewFlagSet("split", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &SplitCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.IntVarP(&cmd.Layers, "layers", "l", 0, "Split every N layers")
+	cmd.Float32SliceVarP(&cmd.Z, "z", "z", []float32{}, "Split at these Z heights, in millimeters")
+	cmd.Float32VarP(&cmd.Height, "height", "H", 0, "Split into the fewest parts of at most this height (including keys), in millimeters, at the joints with the smallest areas")
+	cmd.IntVarP(&cmd.Keys, "keys", "k", 0, "Number of registration keys at each joint")
+	cmd.Float32VarP(&cmd.KeyDiameter, "key-diameter", "", 3, "Diameter of the registration keys, in millimeters")
+	cmd.Float32VarP(&cmd.KeyDepth, "key-depth", "", 2, "Height of the registration keys, in millimeters")
+	cmd.Float32VarP(&cmd.KeyClearance, "key-clearance", "", 0.1, "Clearance around the registration keys in their sockets, in millimeters")
+	cmd.StringVarP(&cmd.Output, "output", "o", "", "Output filename template, with a printf-style part number (ie 'part-%02d.ctb')")
+
+	return
+}
+
+// splitModifier is a range of layers of a printable, printed as its own job
+type splitModifier struct {
+	uv3dp.Printable
+
+	first int
+	count int
+
+	// Registration keys on top of the part, and sockets for
+	// the keys of the part below in its first layers
+	keys         []image.Point
+	keyRadius    int
+	keyLayers    int
+	sockets      []image.Point
+	socketRadius int
+	socketLayers int
+}
+
+func (sm *splitModifier) Size() (size uv3dp.Size) {
+	size = sm.Printable.Size()
+	size.Layers = sm.count + sm.keyLayers
+
+	return
+}
+
+func (sm *splitModifier) LayerZ(index int) float32 {
+	var base float32
+	if sm.first > 0 {
+		base = sm.Printable.LayerZ(sm.first - 1)
+	}
+
+	if index >= sm.count {
+		// Keys are above the last layer
+		height := sm.Printable.Size().LayerHeight
+		return sm.Printable.LayerZ(sm.first+sm.count-1) - base + float32(index-sm.count+1)*height
+	}
+
+	return sm.Printable.LayerZ(index+sm.first) - base
+}
+
+func (sm *splitModifier) LayerExposure(index int) uv3dp.Exposure {
+	// Each part is printed on the build plate, so it
+	// has the same bottom layers as the original print.
+	bottom := sm.Printable.Bottom()
+	if index < bottom.Count+bottom.Transition {
+		return sm.Printable.LayerExposure(index)
+	}
+
+	if index >= sm.count {
+		index = sm.count - 1
+	}
+
+	return sm.Printable.LayerExposure(index + sm.first)
+}
+
+func (sm *splitModifier) LayerImage(index int) (grayImage *image.Gray) {
+	if index >= sm.count {
+		size := sm.Printable.Size()
+		grayImage = image.NewGray(image.Rect(0, 0, size.X, size.Y))
+		for _, key := range sm.keys {
+			splitDisk(grayImage, key, sm.keyRadius, 0xff)
+		}
+		return
+	}
+
+	grayImage = sm.Printable.LayerImage(index + sm.first)
+
+	if index < sm.socketLayers && len(sm.sockets) > 0 {
+		srcImage := grayImage
+		grayImage = image.NewGray(srcImage.Bounds())
+		copy(grayImage.Pix, srcImage.Pix)
+		for _, socket := range sm.sockets {
+			splitDisk(grayImage, socket, sm.socketRadius, 0)
+		}
+	}
+
+	return
+}
+
+// splitDisk sets the pixels of a disk of an image
+func splitDisk(grayImage *image.Gray, center image.Point, radius int, value uint8) {
+	rect := image.Rect(center.X-radius, center.Y-radius, center.X+radius+1, center.Y+radius+1).Intersect(grayImage.Rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			dx, dy := x-center.X, y-center.Y
+			if dx*dx+dy*dy <= radius*radius {
+				grayImage.Pix[grayImage.PixOffset(x, y)] = value
+			}
+		}
+	}
+}
+
+// SplitLayers returns the first layer of each part, when splitting
+// every 'count' layers, or above each of the Z heights.
+func SplitLayers(input uv3dp.Printable, count int, heights []float32) (firsts []int) {
+	layers := input.Size().Layers
+
+	firsts = []int{0}
+
+	if count > 0 {
+		for n := count; n < layers; n += count {
+			firsts = append(firsts, n)
+		}
+		return
+	}
+
+	heights = append([]float32{}, heights...)
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+	n := 0
+	for _, z := range heights {
+		for ; n < layers && input.LayerZ(n) <= z; n++ {
+		}
+		if n > firsts[len(firsts)-1] && n < layers {
+			firsts = append(firsts, n)
+		}
+	}
+
+	return
+}
+
+// SplitHeight returns the first layer of each part, when splitting into
+// the fewest parts that are at most a height (in millimeters). Of the
+// joints that give the fewest parts, those with the smallest areas are
+// chosen, so that the seams are as small as possible.
+func SplitHeight(input uv3dp.Printable, height float32) (firsts []int, err error) {
+	layers := input.Size().Layers
+
+	// Z height of the bottom of a layer
+	base := func(n int) float32 {
+		if n == 0 {
+			return 0
+		}
+		return input.LayerZ(n - 1)
+	}
+
+	for n := 0; n < layers; n++ {
+		if input.LayerZ(n)-base(n) > height {
+			err = fmt.Errorf("layer %v is taller than %.2f mm", n, height)
+			return
+		}
+	}
+
+	// The lowest joints that leave the fewest parts above them,
+	// found by making the parts as tall as possible from the top.
+	lowest := []int{}
+	for last := layers - 1; last >= 0; {
+		first := last
+		for first > 0 && input.LayerZ(last)-base(first-1) <= height {
+			first--
+		}
+		if first > 0 {
+			lowest = append([]int{first}, lowest...)
+		}
+		last = first - 1
+	}
+
+	areas := uv3dp.LayerVolumes(input)
+
+	firsts = []int{0}
+	for _, low := range lowest {
+		// The highest joint for a part from the last joint
+		first := firsts[len(firsts)-1]
+		high := first
+		for high < layers && input.LayerZ(high)-base(first) <= height {
+			high++
+		}
+
+		joint := high
+		for n := high; n >= low; n-- {
+			if areas[n] < areas[joint] {
+				joint = n
+			}
+		}
+		firsts = append(firsts, joint)
+	}
+
+	return
+}
+
+// SplitKeys returns the centers of up to 'count' registration keys of a
+// radius (in pixels) that fit inside of the lit area of a joint, spread out
+// as far from each other as possible.
+func SplitKeys(joint *image.Gray, count int, radius int) (keys []image.Point) {
+	// Leave a wall of at least the key's radius around the socket
+	area := uv3dp.MorphImage(joint, uv3dp.MorphErode, radius*2)
+
+	rect := area.Bounds()
+	points := []image.Point{}
+	center := image.Point{}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			if area.Pix[area.PixOffset(x, y)] != 0 {
+				points = append(points, image.Point{X: x, Y: y})
+				center = center.Add(image.Point{X: x, Y: y})
+			}
+		}
+	}
+
+	if len(points) == 0 || count <= 0 {
+		return
+	}
+
+	center = center.Div(len(points))
+
+	distance := func(a, b image.Point) int {
+		d := a.Sub(b)
+		return d.X*d.X + d.Y*d.Y
+	}
+
+	// The first key is the nearest to the center, and each
+	// other key is the farthest from the keys before it.
+	nearest := make([]int, len(points))
+	next := 0
+	for n, pt := range points {
+		if distance(pt, center) < distance(points[next], center) {
+			next = n
+		}
+	}
+
+	for len(keys) < count {
+		key := points[next]
+		keys = append(keys, key)
+
+		next = -1
+		for n, pt := range points {
+			d := distance(pt, key)
+			if len(keys) == 1 || d < nearest[n] {
+				nearest[n] = d
+			}
+			if next < 0 || nearest[n] > nearest[next] {
+				next = n
+			}
+		}
+
+		// Keys must not overlap
+		if nearest[next] < 4*radius*radius {
+			break
+		}
+	}
+
+	return
+}
+
+func (cmd *SplitCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	output = input
+
+	if cmd.Output == "" {
+		err = fmt.Errorf("split: --output must be specified")
+		return
+	}
+
+	if fmt.Sprintf(cmd.Output, 1) == fmt.Sprintf(cmd.Output, 2) {
+		err = fmt.Errorf("split: --output '%v' has no part number (ie '%%d')", cmd.Output)
+		return
+	}
+
+	modes := 0
+	for _, name := range []string{"layers", "z", "height"} {
+		if cmd.Changed(name) {
+			modes++
+		}
+	}
+
+	if modes != 1 {
+		err = fmt.Errorf("split: exactly one of --layers, --z, or --height must be specified")
+		return
+	}
+
+	if cmd.Changed("layers") && cmd.Layers <= 0 {
+		err = fmt.Errorf("split: --layers must be greater than zero")
+		return
+	}
+
+	if cmd.Keys < 0 || cmd.KeyClearance < 0 {
+		err = fmt.Errorf("split: --keys and --key-clearance must not be negative")
+		return
+	}
+
+	if cmd.KeyDiameter <= 0 || cmd.KeyDepth <= 0 {
+		err = fmt.Errorf("split: --key-diameter and --key-depth must be greater than zero")
+		return
+	}
+
+	size := input.Size()
+	layers := size.Layers
+
+	// Keys, and their sockets, in pixels and layers
+	keyRadius := int(cmd.KeyDiameter/2/size.Millimeter.X*float32(size.X) + 0.5)
+	keyLayers := int(cmd.KeyDepth/size.LayerHeight + 0.5)
+	socketRadius := int((cmd.KeyDiameter/2+cmd.KeyClearance)/size.Millimeter.X*float32(size.X) + 0.5)
+	socketLayers := int((cmd.KeyDepth+cmd.KeyClearance)/size.LayerHeight + 0.999)
+	if cmd.Keys == 0 {
+		keyLayers = 0
+	}
+
+	var firsts []int
+	if cmd.Changed("height") {
+		height := cmd.Height - float32(keyLayers)*size.LayerHeight
+		if height <= 0 {
+			err = fmt.Errorf("split: --height must be greater than the --key-depth")
+			return
+		}
+		firsts, err = SplitHeight(input, height)
+		if err != nil {
+			err = fmt.Errorf("split: %w", err)
+			return
+		}
+	} else {
+		firsts = SplitLayers(input, cmd.Layers, cmd.Z)
+	}
+
+	// Keys of each joint, on top of the part below it
+	keys := make([][]image.Point, len(firsts))
+	for n := 1; n < len(firsts) && cmd.Keys > 0; n++ {
+		first := firsts[n]
+
+		// The keys must be inside of the top of the part below,
+		// and the socket layers of the part above.
+		joint := input.LayerImage(first - 1)
+		area := image.NewGray(joint.Bounds())
+		copy(area.Pix, joint.Pix)
+		for m := first; m < first+socketLayers && m < layers; m++ {
+			above := input.LayerImage(m)
+			for i, pix := range above.Pix {
+				if pix < area.Pix[i] {
+					area.Pix[i] = pix
+				}
+			}
+		}
+
+		keys[n-1] = SplitKeys(area, cmd.Keys, socketRadius)
+		if len(keys[n-1]) < cmd.Keys {
+			TraceVerbosef(VerbosityWarning, "  Only %v of %v keys fit in the joint at layer %v", len(keys[n-1]), cmd.Keys, first)
+		}
+	}
+
+	for n, first := range firsts {
+		last := layers
+		if n+1 < len(firsts) {
+			last = firsts[n+1]
+		}
+
+		filename := fmt.Sprintf(cmd.Output, n+1)
+
+		TraceVerbosef(VerbosityNotice, "  Layers %v..%v to %v", first, last-1, filename)
+
+		var format *uv3dp.Format
+		format, err = uv3dp.NewFormat(filename, []string{})
+		if err != nil {
+			return
+		}
+
+		var part uv3dp.Printable
+		mod := &splitModifier{
+			Printable: input,
+			first:     first,
+			count:     last - first,
+		}
+
+		if len(keys[n]) > 0 {
+			mod.keys = keys[n]
+			mod.keyRadius = keyRadius
+			mod.keyLayers = keyLayers
+		}
+
+		if n > 0 && len(keys[n-1]) > 0 {
+			mod.sockets = keys[n-1]
+			mod.socketRadius = socketRadius
+			mod.socketLayers = socketLayers
+		}
+
+		part = uv3dp.RemapLayers(mod, uv3dp.LayerRemap{
+			Printable: input,
+			Remap: func(index int) (int, bool) {
+				index -= mod.first
+				return index, index >= 0 && index < mod.count
+			},
+		})
+
+		part, err = CheckFilter(part)
+		if err != nil {
+			return
+		}
+
+		err = format.SetPrintable(part)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,168 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestSplit(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 10, Layers: 10, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+		Bottom:   uv3dp.Bottom{Count: 2, Exposure: uv3dp.Exposure{LightOnTime: 60}},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	table := map[string]struct {
+		Count   int
+		Heights []float32
+		Firsts  []int
+	}{
+		"layers":    {Count: 4, Firsts: []int{0, 4, 8}},
+		"z":         {Heights: []float32{0.3, 0.1}, Firsts: []int{0, 2, 6}},
+		"z-outside": {Heights: []float32{0, 1.0}, Firsts: []int{0}},
+	}
+
+	for name, item := range table {
+		firsts := SplitLayers(input, item.Count, item.Heights)
+		if !cmp.Equal(item.Firsts, firsts) {
+			t.Errorf("%v: expected %v, got %v", name, item.Firsts, firsts)
+		}
+	}
+
+	part := &splitModifier{Printable: input, first: 4, count: 3}
+	if part.Size().Layers != 3 {
+		t.Errorf("expected 3 layers, got %v", part.Size().Layers)
+	}
+
+	for n, expected := range []float32{60, 60, 8} {
+		if !convertEqual(part.LayerZ(n), float32(n+1)*0.05) {
+			t.Errorf("layer %v: expected Z %v, got %v", n, float32(n+1)*0.05, part.LayerZ(n))
+		}
+		if part.LayerExposure(n).LightOnTime != expected {
+			t.Errorf("layer %v: expected exposure %v, got %v", n, expected, part.LayerExposure(n).LightOnTime)
+		}
+	}
+}
+
+// splitPrint has a square of a width (in pixels) on each layer
+type splitPrint struct {
+	uv3dp.Print
+	widths []int
+}
+
+func (sp *splitPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(sp.Properties.Bounds())
+	width := sp.widths[index]
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			grayImage.Pix[y*grayImage.Stride+x] = 0xff
+		}
+	}
+	return
+}
+
+func TestSplitHeight(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 10, Y: 10, Layers: 10, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 5, Y: 5},
+		},
+	}
+
+	table := map[string]struct {
+		Height float32
+		Widths []int
+		Firsts []int
+		Err    bool
+	}{
+		"same":     {Height: 0.2, Widths: []int{5, 5, 5, 5, 5, 5, 5, 5, 5, 5}, Firsts: []int{0, 4, 8}},
+		"narrow":   {Height: 0.2, Widths: []int{5, 5, 5, 2, 5, 5, 5, 5, 5, 5}, Firsts: []int{0, 3, 7}},
+		"too-wide": {Height: 0.2, Widths: []int{5, 2, 5, 5, 5, 5, 5, 5, 5, 5}, Firsts: []int{0, 4, 8}},
+		"one":      {Height: 0.5, Widths: []int{5, 5, 5, 2, 5, 5, 5, 5, 5, 5}, Firsts: []int{0}},
+		"too-thin": {Height: 0.01, Widths: []int{5, 5, 5, 5, 5, 5, 5, 5, 5, 5}, Err: true},
+	}
+
+	for name, item := range table {
+		input := &splitPrint{Print: uv3dp.Print{Properties: prop}, widths: item.Widths}
+		firsts, err := SplitHeight(input, item.Height)
+		if item.Err {
+			if err == nil {
+				t.Errorf("%v: expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if !cmp.Equal(item.Firsts, firsts) {
+			t.Errorf("%v: expected %v, got %v", name, item.Firsts, firsts)
+		}
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	joint := image.NewGray(image.Rect(0, 0, 40, 40))
+	for y := 10; y < 30; y++ {
+		for x := 10; x < 30; x++ {
+			joint.Pix[joint.PixOffset(x, y)] = 0xff
+		}
+	}
+
+	table := map[string]struct {
+		Count  int
+		Radius int
+		Keys   []image.Point
+	}{
+		"one":      {Count: 1, Radius: 2, Keys: []image.Point{{19, 19}}},
+		"two":      {Count: 2, Radius: 2, Keys: []image.Point{{19, 19}, {25, 25}}},
+		"three":    {Count: 3, Radius: 2, Keys: []image.Point{{19, 19}, {25, 25}, {25, 14}}},
+		"crowded":  {Count: 3, Radius: 4, Keys: []image.Point{{19, 19}}},
+		"too-wide": {Count: 1, Radius: 6},
+	}
+
+	for name, item := range table {
+		keys := SplitKeys(joint, item.Count, item.Radius)
+		if !cmp.Equal(item.Keys, keys) {
+			t.Errorf("%v: expected %v, got %v", name, item.Keys, keys)
+		}
+	}
+
+	input := &splitPrint{
+		Print: uv3dp.Print{Properties: uv3dp.Properties{
+			Size: uv3dp.Size{X: 10, Y: 10, Layers: 4, LayerHeight: 0.05},
+		}},
+		widths: []int{10, 10, 10, 10},
+	}
+
+	lower := &splitModifier{Printable: input, first: 0, count: 2,
+		keys: []image.Point{{5, 5}}, keyRadius: 1, keyLayers: 2}
+	if lower.Size().Layers != 4 {
+		t.Errorf("expected 4 layers, got %v", lower.Size().Layers)
+	}
+	if !convertEqual(lower.LayerZ(3), 0.2) {
+		t.Errorf("expected key Z 0.2, got %v", lower.LayerZ(3))
+	}
+	if key := lower.LayerImage(2); uv3dp.LitPixels(key) != 5 || key.GrayAt(5, 5).Y == 0 {
+		t.Errorf("expected a key of 5 pixels at 5,5, got %v pixels", uv3dp.LitPixels(key))
+	}
+
+	upper := &splitModifier{Printable: input, first: 2, count: 2,
+		sockets: []image.Point{{5, 5}}, socketRadius: 1, socketLayers: 1}
+	if socket := upper.LayerImage(0); uv3dp.LitPixels(socket) != 95 || socket.GrayAt(5, 5).Y != 0 {
+		t.Errorf("expected a socket of 5 pixels at 5,5, got %v lit pixels", uv3dp.LitPixels(socket))
+	}
+	if uv3dp.LitPixels(upper.LayerImage(1)) != 100 {
+		t.Errorf("expected no socket above the socket layers")
+	}
+}
@@ -0,0 +1,149 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type StackCommand struct {
+	*pflag.FlagSet
+
+	Input     string
+	Interface int
+}
+
+func NewStackCommand() (cmd *StackCommand) {
+	flagSet := pflag.NewFlagSet("stack", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &StackCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Input, "input", "i", "", "Printable to append on top of the input")
+	cmd.IntVarP(&cmd.Interface, "interface", "n", 0, "Count of appended layers that keep their bottom layer exposure")
+
+	return
+}
+
+// stackModifier appends the layers of a second printable above the input's
+type stackModifier struct {
+	uv3dp.Printable
+
+	other      uv3dp.Printable
+	base       int // Count of layers in the input
+	interfaces int
+}
+
+func (sm *stackModifier) Size() (size uv3dp.Size) {
+	size = sm.Printable.Size()
+	size.Layers = sm.base + sm.other.Size().Layers
+
+	return
+}
+
+func (sm *stackModifier) LayerZ(index int) float32 {
+	if index < sm.base {
+		return sm.Printable.LayerZ(index)
+	}
+
+	var top float32
+	if sm.base > 0 {
+		top = sm.Printable.LayerZ(sm.base - 1)
+	}
+
+	return top + sm.other.LayerZ(index-sm.base)
+}
+
+func (sm *stackModifier) LayerExposure(index int) uv3dp.Exposure {
+	if index < sm.base {
+		return sm.Printable.LayerExposure(index)
+	}
+
+	index -= sm.base
+
+	// The appended layers are cured onto the model, not the build plate,
+	// so only the interface layers keep the bottom layer exposure.
+	bottom := sm.other.Bottom()
+	if index >= sm.interfaces && index < bottom.Count+bottom.Transition {
+		return sm.other.Exposure()
+	}
+
+	return sm.other.LayerExposure(index)
+}
+
+func (sm *stackModifier) LayerImage(index int) *image.Gray {
+	if index < sm.base {
+		return sm.Printable.LayerImage(index)
+	}
+
+	return sm.other.LayerImage(index - sm.base)
+}
+
+func (cmd *StackCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Input == "" {
+		err = fmt.Errorf("stack: --input must be specified")
+		return
+	}
+
+	if cmd.Interface < 0 {
+		err = fmt.Errorf("stack: --interface must not be negative")
+		return
+	}
+
+	format, err := uv3dp.NewFormat(cmd.Input, nil)
+	if err != nil {
+		return
+	}
+
+	other, err := format.Printable()
+	if err != nil {
+		return
+	}
+
+	size := input.Size()
+	otherSize := other.Size()
+
+	if size.X != otherSize.X || size.Y != otherSize.Y {
+		err = fmt.Errorf("stack: %v: resolution %vx%v does not match %vx%v",
+			cmd.Input, otherSize.X, otherSize.Y, size.X, size.Y)
+		return
+	}
+
+	if math.Abs(float64(size.LayerHeight-otherSize.LayerHeight)) > 0.0001 {
+		err = fmt.Errorf("stack: %v: layer height %v mm does not match %v mm",
+			cmd.Input, otherSize.LayerHeight, size.LayerHeight)
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Appending %v layers of %v above layer %v", otherSize.Layers, cmd.Input, size.Layers)
+
+	output = &stackModifier{
+		Printable:  input,
+		other:      other,
+		base:       size.Layers,
+		interfaces: cmd.Interface,
+	}
+
+	output = uv3dp.RemapLayers(output,
+		uv3dp.LayerRemap{
+			Printable: input,
+			Remap:     func(index int) (int, bool) { return index, true },
+		},
+		uv3dp.LayerRemap{
+			Printable: other,
+			Remap:     func(index int) (int, bool) { return index + size.Layers, true },
+		},
+	)
+
+	return
+}
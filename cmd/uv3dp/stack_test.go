@@ -0,0 +1,56 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestStack(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 10, Y: 10, Layers: 2, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+		Bottom:   uv3dp.Bottom{Count: 2, Exposure: uv3dp.Exposure{LightOnTime: 60}},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	prop.Size.Layers = 4
+	other := &uv3dp.Print{Properties: prop}
+
+	output := &stackModifier{
+		Printable:  input,
+		other:      other,
+		base:       2,
+		interfaces: 1,
+	}
+
+	if output.Size().Layers != 6 {
+		t.Errorf("expected 6 layers, got %v", output.Size().Layers)
+	}
+
+	table := []struct {
+		Z           float32
+		LightOnTime float32
+	}{
+		{Z: 0.05, LightOnTime: 60},
+		{Z: 0.10, LightOnTime: 60},
+		{Z: 0.15, LightOnTime: 60},
+		{Z: 0.20, LightOnTime: 8},
+		{Z: 0.25, LightOnTime: 8},
+		{Z: 0.30, LightOnTime: 8},
+	}
+
+	for n, item := range table {
+		if !convertEqual(output.LayerZ(n), item.Z) {
+			t.Errorf("layer %v: expected Z %v, got %v", n, item.Z, output.LayerZ(n))
+		}
+		if output.LayerExposure(n).LightOnTime != item.LightOnTime {
+			t.Errorf("layer %v: expected exposure %v, got %v", n, item.LightOnTime, output.LayerExposure(n).LightOnTime)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// StatsRecord is a single conversion, as saved in the local stats file
+type StatsRecord struct {
+	Time     time.Time
+	Input    string  // Input format suffix
+	Output   string  // Output format suffix
+	Layers   int     // Number of layers written
+	Duration float64 // Seconds from start of decode (or the previous output) to end of encode
+}
+
+// StatsAppend appends a record to the stats file, creating it if needed
+func StatsAppend(filename string, record StatsRecord) (err error) {
+	writer, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer func() { writer.Close() }()
+
+	data, err := json.Marshal(&record)
+	if err != nil {
+		return
+	}
+
+	_, err = writer.Write(append(data, '\n'))
+
+	return
+}
+
+// StatsLoad reads all of the records from a stats file
+func StatsLoad(reader io.Reader) (records []StatsRecord, err error) {
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record StatsRecord
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+
+		records = append(records, record)
+	}
+
+	err = scanner.Err()
+
+	return
+}
+
+type StatsCommand struct {
+	*pflag.FlagSet
+
+	Filename string
+}
+
+func NewStatsCommand() (cmd *StatsCommand) {
+	flagSet := pflag.NewFlagSet("stats", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &StatsCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Filename, "file", "f", "", "Stats file to report on (default is the --stats file)")
+
+	return
+}
+
+// Run executes the stats sub-command ('report')
+func (cmd *StatsCommand) Run() (err error) {
+	filename := param.Stats
+	if cmd.Changed("file") {
+		filename = cmd.Filename
+	}
+
+	if filename == "" {
+		err = fmt.Errorf("stats: no stats file selected (use --stats or --file)")
+		return
+	}
+
+	if cmd.NArg() != 1 || cmd.Arg(0) != "report" {
+		err = fmt.Errorf("stats: expected 'report' sub-command, got %v", cmd.Args())
+		return
+	}
+
+	reader, err := os.Open(filename)
+	if err != nil {
+		return
+	}
+	defer func() { reader.Close() }()
+
+	records, err := StatsLoad(reader)
+	if err != nil {
+		err = fmt.Errorf("%v: %w", filename, err)
+		return
+	}
+
+	StatsReport(os.Stdout, records)
+
+	return
+}
+
+// StatsReport summarizes the conversion records
+func StatsReport(writer io.Writer, records []StatsRecord) {
+	type summary struct {
+		Count    int
+		Layers   int
+		Duration float64
+	}
+
+	var total summary
+	byPath := map[string]*summary{}
+
+	for _, record := range records {
+		path := fmt.Sprintf("%v -> %v", record.Input, record.Output)
+		item, ok := byPath[path]
+		if !ok {
+			item = &summary{}
+			byPath[path] = item
+		}
+
+		for _, sum := range []*summary{item, &total} {
+			sum.Count++
+			sum.Layers += record.Layers
+			sum.Duration += record.Duration
+		}
+	}
+
+	seconds := func(sec float64) time.Duration {
+		return time.Duration(sec * float64(time.Second)).Round(time.Millisecond)
+	}
+
+	fmt.Fprintf(writer, "Conversions: %d\n", total.Count)
+	fmt.Fprintf(writer, "Layers: %d\n", total.Layers)
+	fmt.Fprintf(writer, "Duration: %v\n", seconds(total.Duration))
+	if len(records) > 0 {
+		fmt.Fprintf(writer, "Period: %v - %v\n",
+			records[0].Time.Format(time.RFC3339),
+			records[len(records)-1].Time.Format(time.RFC3339))
+	}
+
+	paths := []string{}
+	for path := range byPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if len(paths) > 0 {
+		fmt.Fprintln(writer)
+	}
+
+	for _, path := range paths {
+		item := byPath[path]
+		fmt.Fprintf(writer, "  %-20s %6d conversions, %8d layers, %v average\n",
+			path, item.Count, item.Layers, seconds(item.Duration/float64(item.Count)))
+	}
+}
@@ -0,0 +1,104 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var statsRecords = []StatsRecord{
+	{Time: time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC), Input: ".ctb", Output: ".sl1", Layers: 100, Duration: 2.0},
+	{Time: time.Date(2020, 5, 2, 10, 0, 0, 0, time.UTC), Input: ".ctb", Output: ".sl1", Layers: 200, Duration: 4.0},
+	{Time: time.Date(2020, 5, 3, 10, 0, 0, 0, time.UTC), Input: ".sl1", Output: ".cbddlp", Layers: 50, Duration: 0.5},
+}
+
+func TestStatsAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The file is created by the first record
+	filename := filepath.Join(dir, "stats.json")
+	for _, record := range statsRecords {
+		err = StatsAppend(filename, record)
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+	}
+
+	reader, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer reader.Close()
+
+	records, err := StatsLoad(reader)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if !cmp.Equal(statsRecords, records) {
+		t.Errorf("%v", cmp.Diff(statsRecords, records))
+	}
+}
+
+func TestStatsLoad(t *testing.T) {
+	good := `{"Time":"2020-05-01T10:00:00Z","Input":".ctb","Output":".sl1","Layers":100,"Duration":2}
+
+{"Time":"2020-05-02T10:00:00Z","Input":".ctb","Output":".sl1","Layers":200,"Duration":4}
+`
+
+	// Blank lines are skipped
+	records, err := StatsLoad(strings.NewReader(good))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if !cmp.Equal(statsRecords[:2], records) {
+		t.Errorf("%v", cmp.Diff(statsRecords[:2], records))
+	}
+
+	// Errors report the line
+	_, err = StatsLoad(strings.NewReader(good + "{\"Layers\": \"many\"}\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 4:") {
+		t.Errorf("expected a line 4 error, got %v", err)
+	}
+}
+
+func TestStatsReport(t *testing.T) {
+	expected := `Conversions: 3
+Layers: 350
+Duration: 6.5s
+Period: 2020-05-01T10:00:00Z - 2020-05-03T10:00:00Z
+
+  .ctb -> .sl1              2 conversions,      300 layers, 3s average
+  .sl1 -> .cbddlp           1 conversions,       50 layers, 500ms average
+`
+
+	buffer := &bytes.Buffer{}
+	StatsReport(buffer, statsRecords)
+
+	if buffer.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buffer.String())
+	}
+
+	// No records
+	buffer = &bytes.Buffer{}
+	StatsReport(buffer, nil)
+
+	if buffer.String() != "Conversions: 0\nLayers: 0\nDuration: 0s\n" {
+		t.Errorf("expected an empty summary, got:\n%v", buffer.String())
+	}
+}
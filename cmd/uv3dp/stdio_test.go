@@ -0,0 +1,118 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// pipeOut saves a printable to stdout ('-'), and returns what was written
+func pipeOut(formatType string, printable uv3dp.Printable) (data []byte, err error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan error)
+	go func() {
+		format, err := uv3dp.NewFormatType(uv3dp.FilenameStdio, formatType, nil)
+		if err == nil {
+			err = format.SetPrintable(printable)
+		}
+		writer.Close()
+		done <- err
+	}()
+
+	data, err = ioutil.ReadAll(reader)
+	if err != nil {
+		return
+	}
+
+	err = <-done
+
+	return
+}
+
+// pipeIn loads a printable from stdin ('-')
+func pipeIn(formatType string, data []byte) (printable uv3dp.Printable, err error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+
+	go func() {
+		writer.Write(data)
+		writer.Close()
+	}()
+
+	format, err := uv3dp.NewFormatType(uv3dp.FilenameStdio, formatType, nil)
+	if err != nil {
+		return
+	}
+
+	printable, err = format.Printable()
+
+	return
+}
+
+func TestStdioRoundTrip(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 40, Y: 40, Layers: 2, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 20, Y: 20},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: 8.0, LightPWM: 255},
+		Bottom:   uv3dp.Bottom{Count: 1, Exposure: uv3dp.Exposure{LightOnTime: 30.0, LightPWM: 255}},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(10, 10, 30, 30),
+	}
+
+	// An archive (zip) format, and a binary format
+	for _, formatType := range []string{"uvj", "ctb"} {
+		data, err := pipeOut(formatType, input)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", formatType, err)
+		}
+
+		output, err := pipeIn(formatType, data)
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", formatType, err)
+		}
+
+		size := output.Size()
+		if size.X != prop.Size.X || size.Y != prop.Size.Y || size.Layers != prop.Size.Layers {
+			t.Errorf("%v: expected %+v, got %+v", formatType, prop.Size, size)
+		}
+
+		if output.LayerExposure(1).LightOnTime != 8.0 {
+			t.Errorf("%v: expected 8 s, got %v s", formatType, output.LayerExposure(1).LightOnTime)
+		}
+
+		for n := 0; n < size.Layers; n++ {
+			if !bytes.Equal(output.LayerImage(n).Pix, input.LayerImage(n).Pix) {
+				t.Errorf("%v: layer %v: image does not match", formatType, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type StreamCommand struct {
+	*pflag.FlagSet
+
+	Listen     string
+	Once       bool
+	Levels     int
+	Precompute bool
+}
+
+// Default number of reduced levels of the layer image pyramids
+const streamPyramidLevels = 4
+
+func NewStreamCommand() (cmd *StreamCommand) {
+	flagSet := pflag.NewFlagSet("stream", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &StreamCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Listen, "listen", "l", ":8080", "Address to serve layers on")
+	cmd.BoolVarP(&cmd.Once, "once", "1", false, "Stop serving once the last layer image has been sent")
+	cmd.IntVarP(&cmd.Levels, "levels", "L", streamPyramidLevels, "Number of reduced image levels, each half the size of the previous")
+	cmd.BoolVarP(&cmd.Precompute, "precompute", "P", false, "Compute the reduced images of all layers before serving")
+
+	return
+}
+
+// StreamJob describes the whole job, as served at '/job'
+type StreamJob struct {
+	Size     uv3dp.Size
+	Exposure uv3dp.Exposure
+	Bottom   uv3dp.Bottom
+}
+
+// StreamLayer describes a single layer, as served at '/layers/N'
+type StreamLayer struct {
+	Index    int
+	Z        float32
+	Exposure uv3dp.Exposure
+}
+
+// StreamHandler serves a printable's layers and exposure timing:
+//
+//	GET /job            Job description (JSON)
+//	GET /layers/N       Layer N's Z and exposure (JSON)
+//	GET /layers/N.png   Layer N's image (PNG)
+//	GET /layers/N.png?level=K
+//	                    Layer N's image, reduced by 2^K (PNG)
+//
+// The done function, if not nil, is called after the last layer's full image is sent.
+func StreamHandler(printable uv3dp.Printable, done func()) http.Handler {
+	mux := http.NewServeMux()
+
+	pyramid, ok := printable.(*uv3dp.PyramidPrintable)
+	if !ok {
+		pyramid = uv3dp.NewPyramidPrintable(printable, streamPyramidLevels)
+	}
+
+	writeJSON := func(w http.ResponseWriter, data interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+	}
+
+	mux.HandleFunc("/job", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &StreamJob{
+			Size:     printable.Size(),
+			Exposure: printable.Exposure(),
+			Bottom:   printable.Bottom(),
+		})
+	})
+
+	mux.HandleFunc("/layers/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/layers/")
+		isImage := strings.HasSuffix(name, ".png")
+		name = strings.TrimSuffix(name, ".png")
+
+		layers := printable.Size().Layers
+		index, err := strconv.Atoi(name)
+		if err != nil || index < 0 || index >= layers {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !isImage {
+			writeJSON(w, &StreamLayer{
+				Index:    index,
+				Z:        printable.LayerZ(index),
+				Exposure: printable.LayerExposure(index),
+			})
+			return
+		}
+
+		level := 0
+		if query := r.URL.Query().Get("level"); query != "" {
+			level, err = strconv.Atoi(query)
+			if err != nil || level < 0 {
+				http.Error(w, "invalid level", http.StatusBadRequest)
+				return
+			}
+		}
+
+		TraceVerbosef(VerbosityNotice, "  Layer %v/%v (level %v) to %v", index+1, layers, level, r.RemoteAddr)
+
+		w.Header().Set("Content-Type", "image/png")
+		err = png.Encode(w, pyramid.LayerPyramid(index, level))
+		if err != nil {
+			TraceVerbosef(VerbosityWarning, "layer %v: %v", index, err)
+			return
+		}
+
+		if index == layers-1 && level == 0 && done != nil {
+			done()
+		}
+	})
+
+	return mux
+}
+
+func (cmd *StreamCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	server := &http.Server{Addr: cmd.Listen}
+
+	var done func()
+	if cmd.Once {
+		done = func() {
+			go server.Shutdown(context.Background())
+		}
+	}
+
+	pyramid := uv3dp.NewPyramidPrintable(input, cmd.Levels)
+	if cmd.Precompute {
+		TraceVerbosef(VerbosityNotice, "  Computing %v reduced levels of %v layers", cmd.Levels, input.Size().Layers)
+		pyramid.Precompute()
+	}
+
+	server.Handler = StreamHandler(pyramid, done)
+
+	fmt.Printf("Streaming %v layers on %v\n", input.Size().Layers, cmd.Listen)
+
+	err = server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		err = nil
+	}
+
+	output = input
+
+	return
+}
@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestStreamHandler(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size:     uv3dp.Size{X: 4, Y: 2, Layers: 2, LayerHeight: 0.05},
+		Exposure: uv3dp.Exposure{LightOnTime: 8, LightPWM: 255},
+		Bottom:   uv3dp.Bottom{Count: 1, Exposure: uv3dp.Exposure{LightOnTime: 30, LightPWM: 255}},
+	}
+
+	done := false
+	server := httptest.NewServer(StreamHandler(uv3dp.NewEmptyPrintable(prop), func() { done = true }))
+	defer server.Close()
+
+	var job StreamJob
+	resp, err := http.Get(server.URL + "/job")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	json.NewDecoder(resp.Body).Decode(&job)
+	resp.Body.Close()
+
+	if job.Size != prop.Size {
+		t.Errorf("expected %+v, got %+v", prop.Size, job.Size)
+	}
+
+	var layer StreamLayer
+	resp, _ = http.Get(server.URL + "/layers/0")
+	json.NewDecoder(resp.Body).Decode(&layer)
+	resp.Body.Close()
+
+	if layer.Index != 0 || layer.Z != 0.05 || layer.Exposure.LightOnTime != 30 {
+		t.Errorf("unexpected layer %+v", layer)
+	}
+
+	resp, _ = http.Get(server.URL + "/layers/1.png")
+	img, err := png.Decode(resp.Body)
+	resp.Body.Close()
+	if err != nil || img.Bounds() != prop.Bounds() {
+		t.Errorf("expected %v image, got %v (%v)", prop.Bounds(), img, err)
+	}
+
+	if !done {
+		t.Errorf("expected done after the last layer")
+	}
+
+	resp, _ = http.Get(server.URL + "/layers/0.png?level=1")
+	img, err = png.Decode(resp.Body)
+	resp.Body.Close()
+	if err != nil || img.Bounds() != image.Rect(0, 0, 2, 1) {
+		t.Errorf("expected %v image, got %v (%v)", image.Rect(0, 0, 2, 1), img, err)
+	}
+
+	resp, _ = http.Get(server.URL + "/layers/0.png?level=-1")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected %v, got %v", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	resp, _ = http.Get(server.URL + "/layers/2.png")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected %v, got %v", http.StatusNotFound, resp.StatusCode)
+	}
+}
@@ -0,0 +1,178 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Subpixel order of each panel type, as the index of the red, green, and
+// blue weight of each subpixel, and whether the subpixels are stacked
+// vertically
+var subpixelPanels = map[string]struct {
+	Order    [3]int
+	Vertical bool
+}{
+	"rgb":  {Order: [3]int{0, 1, 2}},
+	"bgr":  {Order: [3]int{2, 1, 0}},
+	"vrgb": {Order: [3]int{0, 1, 2}, Vertical: true},
+	"vbgr": {Order: [3]int{2, 1, 0}, Vertical: true},
+}
+
+// Subpixel filters, which spread the light of a subpixel over its
+// neighbours to even out the exposure of each pixel
+var subpixelFilters = map[string][5]float32{
+	"none":    {0, 0, 1, 0, 0},
+	"light":   {0, 1.0 / 3, 1.0 / 3, 1.0 / 3, 0},
+	"default": {1.0 / 9, 2.0 / 9, 3.0 / 9, 2.0 / 9, 1.0 / 9},
+}
+
+type SubpixelCommand struct {
+	*pflag.FlagSet
+
+	Panel   string
+	Kernel  string
+	Weights []float32
+}
+
+func NewSubpixelCommand() (cmd *SubpixelCommand) {
+	flagSet := pflag.NewFlagSet("subpixel", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &SubpixelCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Panel, "panel", "p", "rgb", "Subpixel order of the panel: 'rgb', 'bgr', or 'vrgb' and 'vbgr' for vertically stacked subpixels")
+	cmd.StringVarP(&cmd.Kernel, "filter", "f", "default", "Subpixel filter: 'none', 'light', or 'default'")
+	cmd.Float32SliceVarP(&cmd.Weights, "weights", "w", []float32{1, 1, 1}, "Relative UV light of the red, green, and blue subpixels")
+
+	return
+}
+
+// subpixelModifier renders layers sliced at subpixel resolution for an
+// RGB panel. Each gray pixel of the result is a subpixel, so a row of
+// the result is a row of the RGB image that the panel shows.
+type subpixelModifier struct {
+	uv3dp.Printable
+
+	filter   [5]float32
+	scale    [3]float32 // Scale of each subpixel, in panel order
+	vertical bool
+}
+
+// SubpixelRender filters a line of subpixels, and scales each subpixel
+// by the scale of its color.
+func SubpixelRender(line []uint8, filter [5]float32, scale [3]float32) (out []uint8) {
+	out = make([]uint8, len(line))
+
+	for n := range line {
+		sum := float32(0)
+		for k, weight := range filter {
+			at := n + k - len(filter)/2
+			if weight == 0 || at < 0 || at >= len(line) {
+				continue
+			}
+			sum += weight * float32(line[at])
+		}
+
+		value := math.Round(float64(sum * scale[n%3]))
+		out[n] = uint8(math.Min(value, 255))
+	}
+
+	return
+}
+
+func (mod *subpixelModifier) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := mod.Printable.LayerImage(index)
+	bounds := srcImage.Bounds()
+	dx, dy := bounds.Dx(), bounds.Dy()
+
+	grayImage = image.NewGray(bounds)
+
+	if !mod.vertical {
+		for y := 0; y < dy; y++ {
+			line := srcImage.Pix[y*srcImage.Stride : y*srcImage.Stride+dx]
+			copy(grayImage.Pix[y*grayImage.Stride:], SubpixelRender(line, mod.filter, mod.scale))
+		}
+		return
+	}
+
+	line := make([]uint8, dy)
+	for x := 0; x < dx; x++ {
+		for y := 0; y < dy; y++ {
+			line[y] = srcImage.Pix[y*srcImage.Stride+x]
+		}
+		for y, pix := range SubpixelRender(line, mod.filter, mod.scale) {
+			grayImage.Pix[y*grayImage.Stride+x] = pix
+		}
+	}
+
+	return
+}
+
+func (cmd *SubpixelCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	panel, ok := subpixelPanels[cmd.Panel]
+	if !ok {
+		err = fmt.Errorf("subpixel: unknown --panel '%v'", cmd.Panel)
+		return
+	}
+
+	filter, ok := subpixelFilters[cmd.Kernel]
+	if !ok {
+		err = fmt.Errorf("subpixel: unknown --filter '%v'", cmd.Kernel)
+		return
+	}
+
+	if len(cmd.Weights) != 3 {
+		err = fmt.Errorf("subpixel: expected 3 --weights, got %v", len(cmd.Weights))
+		return
+	}
+
+	strongest := float32(0)
+	for _, weight := range cmd.Weights {
+		if weight <= 0 {
+			err = fmt.Errorf("subpixel: --weights must be greater than 0")
+			return
+		}
+		if weight > strongest {
+			strongest = weight
+		}
+	}
+
+	size := input.Size()
+	subpixels := size.X
+	if panel.Vertical {
+		subpixels = size.Y
+	}
+
+	if subpixels%3 != 0 {
+		err = fmt.Errorf("subpixel: %v subpixels are not a multiple of 3; slice the layers at three times the panel resolution", subpixels)
+		return
+	}
+
+	// Dimmer subpixels are scaled up to the light of the strongest
+	var scale [3]float32
+	for n, color := range panel.Order {
+		scale[n] = strongest / cmd.Weights[color]
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Rendering %v subpixels for a '%v' panel, with the '%v' filter", subpixels, cmd.Panel, cmd.Kernel)
+
+	output = &subpixelModifier{
+		Printable: input,
+		filter:    filter,
+		scale:     scale,
+		vertical:  panel.Vertical,
+	}
+
+	return
+}
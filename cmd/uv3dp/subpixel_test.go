@@ -0,0 +1,139 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestSubpixelRender(t *testing.T) {
+	unity := [3]float32{1, 1, 1}
+
+	table := map[string]struct {
+		line   []uint8
+		filter string
+		scale  [3]float32
+		expect []uint8
+	}{
+		"none": {
+			line:   []uint8{0, 0, 90, 0, 0, 0},
+			filter: "none",
+			scale:  unity,
+			expect: []uint8{0, 0, 90, 0, 0, 0},
+		},
+		"light": {
+			line:   []uint8{0, 0, 90, 0, 0, 0},
+			filter: "light",
+			scale:  unity,
+			expect: []uint8{0, 30, 30, 30, 0, 0},
+		},
+		"default": {
+			line:   []uint8{0, 0, 90, 0, 0, 0},
+			filter: "default",
+			scale:  unity,
+			expect: []uint8{10, 20, 30, 20, 10, 0},
+		},
+		"scale": {
+			line:   []uint8{100, 100, 100, 200, 200, 200},
+			filter: "none",
+			scale:  [3]float32{2, 1, 1.5},
+			expect: []uint8{200, 100, 150, 255, 200, 255},
+		},
+	}
+
+	for name, item := range table {
+		got := SubpixelRender(item.line, subpixelFilters[item.filter], item.scale)
+		if !cmp.Equal(item.expect, got) {
+			t.Errorf("%v: expected %v, got %v", name, item.expect, got)
+		}
+	}
+}
+
+func TestSubpixel(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 12, Y: 6, Layers: 1, LayerHeight: 0.05},
+	}
+
+	input := &scalePrint{
+		Print: uv3dp.Print{Properties: prop},
+		model: image.Rect(3, 3, 6, 6),
+	}
+
+	table := map[string]struct {
+		args   []string
+		x, y   int
+		expect uint8
+	}{
+		"rgb": {
+			args:   []string{"--panel", "rgb", "--weights", "0.5,1,1"},
+			x:      2,
+			y:      4,
+			expect: 0x55, // Blue subpixel, spread from the right
+		},
+		"bgr": {
+			args:   []string{"--panel", "bgr", "--weights", "0.5,1,1"},
+			x:      2,
+			y:      4,
+			expect: 0xaa, // Red subpixel, spread from the right and brightened
+		},
+		"vrgb": {
+			args:   []string{"--panel", "vrgb", "--filter", "light"},
+			x:      4,
+			y:      2,
+			expect: 0x55, // Spread from the subpixel above
+		},
+		"outside": {
+			args:   []string{"--filter", "default"},
+			x:      1,
+			y:      4,
+			expect: 0x1c, // Spread from two subpixels to the right
+		},
+	}
+
+	for name, item := range table {
+		cmd := NewSubpixelCommand()
+		err := cmd.Parse(item.args)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		got := output.LayerImage(0).GrayAt(item.x, item.y).Y
+		if got != item.expect {
+			t.Errorf("%v: (%v,%v): expected %#x, got %#x", name, item.x, item.y, item.expect, got)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--panel", "rgbw"},
+		{"--filter", "heavy"},
+		{"--weights", "1,1"},
+		{"--weights", "1,0,1"},
+	} {
+		cmd := NewSubpixelCommand()
+		cmd.Parse(args)
+		_, err := cmd.Filter(input)
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+
+	prop.Size.X = 10
+	cmd := NewSubpixelCommand()
+	cmd.Parse([]string{})
+	_, err := cmd.Filter(&scalePrint{Print: uv3dp.Print{Properties: prop}})
+	if err == nil {
+		t.Errorf("expected an error for a width that is not a multiple of 3")
+	}
+}
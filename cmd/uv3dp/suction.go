@@ -0,0 +1,260 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type SuctionCommand struct {
+	*pflag.FlagSet
+
+	MinVolume float32
+	Peak      float32
+	Fail      bool
+}
+
+func NewSuctionCommand() (cmd *SuctionCommand) {
+	flagSet := pflag.NewFlagSet("suction", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &SuctionCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.Float32VarP(&cmd.MinVolume, "min-volume", "m", 1, "Ignore suction cups smaller than this volume, in cubic millimeters")
+	cmd.Float32VarP(&cmd.Peak, "peak", "P", 90, "Layers with at least this percentage of the largest area of a cup are its peak")
+	cmd.BoolVarP(&cmd.Fail, "fail", "f", false, "Fail if any suction cups are found")
+
+	return
+}
+
+// SuctionCup is a cavity that is closed towards the build plate and open
+// towards the vat, so that it is pressed against the FEP as it is printed.
+type SuctionCup struct {
+	First, Last int             // Layers of the cavity
+	Bounds      image.Rectangle // Bounds of the cavity, in pixels
+	Areas       map[int]int     // Area of the cavity of each layer, in pixels
+	Volume      float32         // Volume of the cavity, in cubic millimeters
+}
+
+// Peak returns the layer with the largest area, and the range of layers
+// with at least a percentage of that area.
+func (cup *SuctionCup) Peak(percent float32) (peak, first, last int) {
+	peak = cup.First
+	for n := cup.First; n <= cup.Last; n++ {
+		if cup.Areas[n] > cup.Areas[peak] {
+			peak = n
+		}
+	}
+
+	limit := float32(cup.Areas[peak]) * percent / 100
+	for first = peak; first > cup.First && float32(cup.Areas[first-1]) >= limit; first-- {
+	}
+	for last = peak; last < cup.Last && float32(cup.Areas[last+1]) >= limit; last++ {
+	}
+
+	return
+}
+
+// Labels of the pixels of a layer that are not in a cavity
+const (
+	suctionLit    = -1 // Lit pixel
+	suctionVented = -2 // Unlit pixel, connected to the edge of the layer
+)
+
+// suctionHoles returns the unlit areas of a layer that are not connected
+// to the edges of the layer, and a label of each pixel: the index of its
+// hole, suctionLit, or suctionVented.
+func suctionHoles(layer *image.Gray) (holes []uv3dp.Island, labels []int) {
+	rect := layer.Bounds()
+
+	inverse := image.NewGray(rect)
+	labels = make([]int, len(layer.Pix))
+	for n, pix := range layer.Pix {
+		if pix == 0 {
+			inverse.Pix[n] = 0xff
+			labels[n] = suctionVented
+		} else {
+			labels[n] = suctionLit
+		}
+	}
+
+	// With nothing below it, every unlit area is an island
+	for _, area := range uv3dp.LayerIslands(image.NewGray(rect), inverse) {
+		if area.Bounds.Min.X == rect.Min.X || area.Bounds.Min.Y == rect.Min.Y ||
+			area.Bounds.Max.X == rect.Max.X || area.Bounds.Max.Y == rect.Max.Y {
+			continue
+		}
+
+		for _, pt := range area.Pixels {
+			labels[inverse.PixOffset(pt.X, pt.Y)] = len(holes)
+		}
+		holes = append(holes, area)
+	}
+
+	return
+}
+
+// SuctionFind finds the suction cups of a printable. The build plate
+// closes the cavities of the first layer.
+func SuctionFind(input uv3dp.Printable) (cups []*SuctionCup) {
+	size := input.Size()
+	voxel := size.Millimeter.X / float32(size.X) * size.Millimeter.Y / float32(size.Y) * size.LayerHeight
+
+	// Cups that are found to be the same cavity are merged into the first
+	merged := []int{}
+	find := func(id int) int {
+		for merged[id] != id {
+			id = merged[id]
+		}
+		return id
+	}
+
+	var belowLabels []int // Labels of the layer below
+	var belowCups []int   // Cup of each hole of the layer below, or -1
+
+	for n := 0; n < size.Layers; n++ {
+		holes, labels := suctionHoles(input.LayerImage(n))
+		holeCups := make([]int, len(holes))
+
+		for h, hole := range holes {
+			vented := false
+			cup := -1
+
+			for _, pt := range hole.Pixels {
+				if belowLabels == nil {
+					// The build plate is below the first layer
+					break
+				}
+
+				below := belowLabels[pt.Y*size.X+pt.X]
+				switch {
+				case below == suctionVented || (below >= 0 && belowCups[below] < 0):
+					vented = true
+				case below >= 0:
+					other := find(belowCups[below])
+					if cup < 0 || other < cup {
+						if cup >= 0 {
+							merged[cup] = other
+						}
+						cup = other
+					} else if other != cup {
+						merged[other] = cup
+					}
+				}
+			}
+
+			if vented {
+				holeCups[h] = -1
+				continue
+			}
+
+			if cup < 0 {
+				cup = len(cups)
+				cups = append(cups, &SuctionCup{First: n, Areas: map[int]int{}})
+				merged = append(merged, cup)
+			}
+
+			item := cups[cup]
+			item.Last = n
+			item.Areas[n] += hole.Area
+			item.Volume += float32(hole.Area) * voxel
+			item.Bounds = item.Bounds.Union(hole.Bounds)
+
+			holeCups[h] = cup
+		}
+
+		belowLabels = labels
+		belowCups = holeCups
+	}
+
+	// Combine the merged cups
+	for id := len(cups) - 1; id >= 0; id-- {
+		target := find(id)
+		if target == id {
+			continue
+		}
+
+		into, from := cups[target], cups[id]
+		if from.First < into.First {
+			into.First = from.First
+		}
+		if from.Last > into.Last {
+			into.Last = from.Last
+		}
+		for layer, area := range from.Areas {
+			into.Areas[layer] += area
+		}
+		into.Volume += from.Volume
+		into.Bounds = into.Bounds.Union(from.Bounds)
+		cups[id] = nil
+	}
+
+	found := []*SuctionCup{}
+	for _, cup := range cups {
+		if cup != nil {
+			found = append(found, cup)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Volume > found[j].Volume })
+
+	return found
+}
+
+// SuctionReport shows the suction cups, with their locations, volumes,
+// and the layers where their suction is the strongest
+func SuctionReport(writer io.Writer, size uv3dp.Size, cups []*SuctionCup, percent float32) {
+	pixelX := size.Millimeter.X / float32(size.X)
+	pixelY := size.Millimeter.Y / float32(size.Y)
+
+	for n, cup := range cups {
+		center := cup.Bounds.Min.Add(cup.Bounds.Max).Div(2)
+		peak, first, last := cup.Peak(percent)
+
+		fmt.Fprintf(writer, "Cup %v: layers %v to %v, %.2f mm^3 at %.2f,%.2f mm, %v\n",
+			n+1, cup.First, cup.Last, cup.Volume, float32(center.X)*pixelX, float32(center.Y)*pixelY, cup.Bounds)
+		fmt.Fprintf(writer, "  Peak: %.2f mm^2 at layer %v (layers %v to %v)\n",
+			float32(cup.Areas[peak])*pixelX*pixelY, peak, first, last)
+	}
+
+	fmt.Fprintf(writer, "Suction cups: %v\n", len(cups))
+}
+
+func (cmd *SuctionCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Peak < 0 || cmd.Peak > 100 {
+		err = fmt.Errorf("suction: --peak must be in the range 0..100")
+		return
+	}
+
+	cups := []*SuctionCup{}
+	for _, cup := range SuctionFind(input) {
+		if cup.Volume >= cmd.MinVolume {
+			cups = append(cups, cup)
+		}
+	}
+
+	SuctionReport(os.Stdout, input.Size(), cups, cmd.Peak)
+
+	TraceVerbosef(VerbosityNotice, "  %v suction cups found", len(cups))
+
+	if cmd.Fail && len(cups) > 0 {
+		err = fmt.Errorf("suction: %v suction cups found", len(cups))
+		return
+	}
+
+	output = input
+
+	return
+}
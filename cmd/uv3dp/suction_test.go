@@ -0,0 +1,148 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// suctionPrint is a 20x20 pixel box, with a solid floor on layers 0
+// and 1, and walls on layers 2 to 5. On layer 6, the cavity is vented
+// by a slot in the wall. A second cavity, closed by the build plate,
+// is on layers 0 and 1.
+type suctionPrint struct {
+	uv3dp.Print
+}
+
+func (sp *suctionPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(sp.Properties.Bounds())
+
+	set := func(rect image.Rectangle, value uint8) {
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				grayImage.Pix[grayImage.PixOffset(x, y)] = value
+			}
+		}
+	}
+
+	set(image.Rect(10, 10, 30, 30), 0xff)
+	if index >= 2 {
+		set(image.Rect(12, 12, 28, 28), 0)
+	}
+	if index >= 6 {
+		set(image.Rect(10, 18, 12, 22), 0)
+	}
+
+	if index < 2 {
+		set(image.Rect(40, 10, 50, 20), 0xff)
+		set(image.Rect(42, 12, 48, 18), 0)
+	}
+
+	return
+}
+
+func TestSuctionFind(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 60, Y: 40, Layers: 8, LayerHeight: 0.05},
+	}
+	prop.Size.Millimeter.X = 30
+	prop.Size.Millimeter.Y = 20
+
+	input := &suctionPrint{Print: uv3dp.Print{Properties: prop}}
+
+	cups := SuctionFind(input)
+	if len(cups) != 2 {
+		t.Fatalf("expected 2 cups, got %+v", cups)
+	}
+
+	cup := cups[0]
+	if cup.First != 2 || cup.Last != 5 || cup.Bounds != image.Rect(12, 12, 28, 28) {
+		t.Errorf("expected cup on layers 2 to 5, got %+v", cup)
+	}
+
+	// 16x16 pixels of 0.5x0.5 mm, on 4 layers of 0.05 mm
+	if cup.Volume < 12.79 || cup.Volume > 12.81 {
+		t.Errorf("expected 12.8 mm^3, got %v", cup.Volume)
+	}
+
+	cup = cups[1]
+	if cup.First != 0 || cup.Last != 1 || cup.Bounds != image.Rect(42, 12, 48, 18) {
+		t.Errorf("expected cup on layers 0 to 1, got %+v", cup)
+	}
+
+	buff := &bytes.Buffer{}
+	SuctionReport(buff, input.Size(), cups[:1], 90)
+	if !strings.Contains(buff.String(), "Peak: 64.00 mm^2 at layer 2 (layers 2 to 5)") {
+		t.Errorf("unexpected report %q", buff.String())
+	}
+}
+
+func TestSuctionPeak(t *testing.T) {
+	cup := &SuctionCup{First: 3, Last: 8, Areas: map[int]int{3: 10, 4: 50, 5: 95, 6: 100, 7: 92, 8: 20}}
+
+	peak, first, last := cup.Peak(90)
+	if peak != 6 || first != 5 || last != 7 {
+		t.Errorf("expected peak 6 on layers 5 to 7, got %v on %v to %v", peak, first, last)
+	}
+}
+
+func TestSuctionMerge(t *testing.T) {
+	// Two cavities, closed on layer 0, that join on layer 1
+	left := image.Rect(2, 2, 4, 6)
+	right := image.Rect(6, 2, 8, 6)
+	join := image.Rect(2, 2, 8, 6)
+
+	layers := [][]image.Rectangle{{left, right}, {join}}
+
+	cups := suctionFindRects(image.Rect(0, 0, 10, 8), layers)
+	if len(cups) != 1 {
+		t.Fatalf("expected 1 cup, got %+v", cups)
+	}
+
+	if cups[0].Areas[0] != 16 || cups[0].Areas[1] != 24 || cups[0].Bounds != join {
+		t.Errorf("expected merged cup, got %+v", cups[0])
+	}
+}
+
+// suctionRectsPrint is a solid block on every layer, with cavities
+type suctionRectsPrint struct {
+	uv3dp.Print
+	layers [][]image.Rectangle
+}
+
+func (sp *suctionRectsPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(sp.Properties.Bounds())
+	rect := grayImage.Bounds().Inset(1)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			grayImage.Pix[grayImage.PixOffset(x, y)] = 0xff
+		}
+	}
+
+	for _, hole := range sp.layers[index] {
+		for y := hole.Min.Y; y < hole.Max.Y; y++ {
+			for x := hole.Min.X; x < hole.Max.X; x++ {
+				grayImage.Pix[grayImage.PixOffset(x, y)] = 0
+			}
+		}
+	}
+
+	return
+}
+
+func suctionFindRects(rect image.Rectangle, layers [][]image.Rectangle) []*SuctionCup {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: rect.Dx(), Y: rect.Dy(), Layers: len(layers), LayerHeight: 0.05},
+	}
+	prop.Size.Millimeter.X = float32(rect.Dx())
+	prop.Size.Millimeter.Y = float32(rect.Dy())
+
+	return SuctionFind(&suctionRectsPrint{Print: uv3dp.Print{Properties: prop}, layers: layers})
+}
@@ -0,0 +1,256 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Space between the plates and pegs of a tolerance test, in millimeters
+const toleranceGap = 2.0
+
+type ToleranceFormatter struct {
+	*pflag.FlagSet
+
+	Machine        string
+	Clearances     []float32
+	Diameter       float32
+	Wall           float32
+	Thickness      float32
+	Height         float32
+	LayerHeight    float32
+	Exposure       float32
+	BottomExposure float32
+	BottomLayers   int
+}
+
+func NewToleranceFormatter() (tf *ToleranceFormatter) {
+	tf = &ToleranceFormatter{
+		FlagSet: pflag.NewFlagSet("tolerance", pflag.ContinueOnError),
+	}
+
+	tf.StringVarP(&tf.Machine, "machine", "M", "photon", "Machine to generate the test for, or machine@variant")
+	tf.Float32SliceVarP(&tf.Clearances, "clearances", "c", []float32{0.05, 0.1, 0.15, 0.2, 0.25, 0.3}, "Clearances between the pegs and their holes, in millimeters")
+	tf.Float32VarP(&tf.Diameter, "diameter", "d", 5.0, "Diameter of the pegs, in millimeters")
+	tf.Float32VarP(&tf.Wall, "wall", "w", 3.0, "Width of the plate around each hole, in millimeters")
+	tf.Float32VarP(&tf.Thickness, "thickness", "t", 3.0, "Thickness of the plates, in millimeters")
+	tf.Float32VarP(&tf.Height, "height", "H", 8.0, "Height of the pegs, in millimeters")
+	tf.Float32VarP(&tf.LayerHeight, "layer-height", "l", 0.05, "Layer height, in millimeters")
+	tf.Float32VarP(&tf.Exposure, "exposure", "e", 8.0, "Exposure time of the normal layers, in seconds")
+	tf.Float32VarP(&tf.BottomExposure, "bottom-exposure", "b", 40.0, "Exposure time of the bottom layers, in seconds")
+	tf.IntVarP(&tf.BottomLayers, "bottom-layers", "B", 3, "Number of bottom layers")
+	tf.SetInterspersed(false)
+
+	return
+}
+
+// toleranceCanvas draws shapes on an image, in millimeters
+type toleranceCanvas struct {
+	img      *image.Gray
+	mmPerPix [2]float64
+}
+
+// fill sets the pixels whose centers are inside of a shape
+func (tc *toleranceCanvas) fill(min, max [2]float64, inside func(x, y float64) bool, value uint8) {
+	rect := tc.img.Rect
+	x0 := int(math.Floor(min[0] / tc.mmPerPix[0]))
+	y0 := int(math.Floor(min[1] / tc.mmPerPix[1]))
+	x1 := int(math.Ceil(max[0] / tc.mmPerPix[0]))
+	y1 := int(math.Ceil(max[1] / tc.mmPerPix[1]))
+
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			if !(image.Point{x, y}).In(rect) {
+				continue
+			}
+			if inside((float64(x)+0.5)*tc.mmPerPix[0], (float64(y)+0.5)*tc.mmPerPix[1]) {
+				tc.img.Pix[y*tc.img.Stride+x] = value
+			}
+		}
+	}
+}
+
+// square draws a square, from its center and size
+func (tc *toleranceCanvas) square(cx, cy, size float64, value uint8) {
+	half := size / 2
+	tc.fill([2]float64{cx - half, cy - half}, [2]float64{cx + half, cy + half},
+		func(x, y float64) bool { return true }, value)
+}
+
+// disk draws a disk, from its center and diameter
+func (tc *toleranceCanvas) disk(cx, cy, diameter float64, value uint8) {
+	radius := diameter / 2
+	tc.fill([2]float64{cx - radius, cy - radius}, [2]float64{cx + radius, cy + radius},
+		func(x, y float64) bool { return (x-cx)*(x-cx)+(y-cy)*(y-cy) <= radius*radius }, value)
+}
+
+// Images draws the layer images of a tolerance test: a plate with a hole
+// for each clearance, next to a peg for that hole. The tests are laid out
+// along the long side of the bed, in order of their clearances. The
+// 'plates' image is used for the layers of the plates, and the 'pegs'
+// image for the layers above them.
+func (tf *ToleranceFormatter) Images(size uv3dp.Size) (plates *image.Gray, pegs *image.Gray, err error) {
+	if len(tf.Clearances) == 0 {
+		err = fmt.Errorf("tolerance: at least one clearance is required")
+		return
+	}
+
+	maxClearance := float32(0)
+	for _, clearance := range tf.Clearances {
+		if clearance < 0 {
+			err = fmt.Errorf("tolerance: clearances must not be negative")
+			return
+		}
+		if clearance > maxClearance {
+			maxClearance = clearance
+		}
+	}
+
+	if tf.Diameter <= 0 || tf.Wall <= 0 {
+		err = fmt.Errorf("tolerance: peg diameter and plate wall must be greater than 0 mm")
+		return
+	}
+
+	plate := float64(tf.Diameter + 2*maxClearance + 2*tf.Wall)
+	pitch := plate + toleranceGap
+	across := plate + toleranceGap + float64(tf.Diameter)
+	along := pitch*float64(len(tf.Clearances)) - toleranceGap
+
+	bedX, bedY := float64(size.Millimeter.X), float64(size.Millimeter.Y)
+	long, short := bedY, bedX
+	if bedX > bedY {
+		long, short = bedX, bedY
+	}
+
+	if along > long || across > short {
+		err = fmt.Errorf("tolerance: %v tests need %.1f x %.1f mm, larger than the %.1f x %.1f mm bed",
+			len(tf.Clearances), across, along, short, long)
+		return
+	}
+
+	rect := image.Rect(0, 0, size.X, size.Y)
+	plates = image.NewGray(rect)
+	pegs = image.NewGray(rect)
+
+	mmPerPix := [2]float64{bedX / float64(size.X), bedY / float64(size.Y)}
+	platesCanvas := &toleranceCanvas{img: plates, mmPerPix: mmPerPix}
+	pegsCanvas := &toleranceCanvas{img: pegs, mmPerPix: mmPerPix}
+
+	// Centers of the plates and pegs, with all of the tests centered on the bed
+	first := (long - along + plate) / 2
+	plateAcross := (short - across + plate) / 2
+	pegAcross := plateAcross + plate/2 + toleranceGap + float64(tf.Diameter)/2
+
+	for n, clearance := range tf.Clearances {
+		at := first + pitch*float64(n)
+
+		plateX, plateY := plateAcross, at
+		pegX, pegY := pegAcross, at
+		if bedX > bedY {
+			plateX, plateY = at, plateAcross
+			pegX, pegY = at, pegAcross
+		}
+
+		platesCanvas.square(plateX, plateY, plate, 255)
+		platesCanvas.disk(plateX, plateY, float64(tf.Diameter+2*clearance), 0)
+		platesCanvas.disk(pegX, pegY, float64(tf.Diameter), 255)
+		pegsCanvas.disk(pegX, pegY, float64(tf.Diameter), 255)
+	}
+
+	return
+}
+
+// TolerancePrint is a printable of a tolerance test
+type TolerancePrint struct {
+	uv3dp.Print
+
+	PlateLayers int
+	Plates      *image.Gray
+	Pegs        *image.Gray
+}
+
+func (tp *TolerancePrint) LayerImage(index int) (ig *image.Gray) {
+	if index < tp.PlateLayers {
+		return tp.Plates
+	}
+
+	return tp.Pegs
+}
+
+func (tf *ToleranceFormatter) Decode(file uv3dp.Reader, filesize int64) (printable uv3dp.Printable, err error) {
+	var prop uv3dp.Properties
+
+	size := &prop.Size
+
+	machine, err := uv3dp.LookupMachine(tf.Machine)
+	if err != nil {
+		return
+	}
+
+	if tf.LayerHeight <= 0 || tf.Thickness < tf.LayerHeight || tf.Height < tf.Thickness {
+		err = fmt.Errorf("tolerance: the pegs must be at least as high as the plates, and the plates at least one layer thick")
+		return
+	}
+
+	if tf.Exposure <= 0 || tf.BottomExposure <= 0 {
+		err = fmt.Errorf("tolerance: exposures must be greater than 0 seconds")
+		return
+	}
+
+	if tf.BottomLayers < 0 {
+		err = fmt.Errorf("tolerance: bottom layers must not be negative")
+		return
+	}
+
+	msize := &machine.Machine.Size
+	size.X = msize.X
+	size.Y = msize.Y
+	size.Millimeter.X = msize.Xmm
+	size.Millimeter.Y = msize.Ymm
+	size.LayerHeight = tf.LayerHeight
+	size.Layers = int(math.Round(float64(tf.Height / tf.LayerHeight)))
+
+	prop.Exposure = uv3dp.Exposure{
+		LightOnTime:   tf.Exposure,
+		LightPWM:      255,
+		LiftHeight:    4,
+		LiftSpeed:     60,
+		RetractHeight: 4,
+		RetractSpeed:  60,
+	}
+
+	prop.Bottom.Count = tf.BottomLayers
+	prop.Bottom.Exposure = prop.Exposure
+	prop.Bottom.Exposure.LightOnTime = tf.BottomExposure
+
+	plates, pegs, err := tf.Images(*size)
+	if err != nil {
+		return
+	}
+
+	printable = &TolerancePrint{
+		Print:       uv3dp.Print{Properties: prop},
+		PlateLayers: int(math.Round(float64(tf.Thickness / tf.LayerHeight))),
+		Plates:      plates,
+		Pegs:        pegs,
+	}
+
+	return
+}
+
+// GeneratesPrintable is true, as tolerance tests are not read from a file
+func (tf *ToleranceFormatter) GeneratesPrintable() bool {
+	return true
+}
+
+func (tf *ToleranceFormatter) Encode(writer uv3dp.Writer, p uv3dp.Printable) (err error) {
+	return
+}
@@ -0,0 +1,61 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestToleranceImages(t *testing.T) {
+	size := uv3dp.Size{X: 40, Y: 40, Millimeter: uv3dp.SizeMillimeter{X: 20, Y: 20}}
+
+	tf := NewToleranceFormatter()
+	err := tf.Parse([]string{"--clearances", "0.5,1.0", "--diameter", "4", "--wall", "1"})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	plates, pegs, err := tf.Images(size)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	// Pixels of each image, in millimeters
+	table := map[string]struct {
+		X, Y   float32
+		Plates uint8
+		Pegs   uint8
+	}{
+		"first hole":   {X: 7, Y: 5},
+		"first plate":  {X: 4.25, Y: 5, Plates: 255},
+		"second hole":  {X: 4.25, Y: 15},
+		"second plate": {X: 3.25, Y: 15, Plates: 255},
+		"first peg":    {X: 15, Y: 5, Plates: 255, Pegs: 255},
+		"second peg":   {X: 15, Y: 15, Plates: 255, Pegs: 255},
+		"between":      {X: 15, Y: 10},
+	}
+
+	for name, item := range table {
+		x, y := int(item.X*2), int(item.Y*2)
+		if plates.GrayAt(x, y).Y != item.Plates {
+			t.Errorf("%v: plates: expected %v, got %v", name, item.Plates, plates.GrayAt(x, y).Y)
+		}
+		if pegs.GrayAt(x, y).Y != item.Pegs {
+			t.Errorf("%v: pegs: expected %v, got %v", name, item.Pegs, pegs.GrayAt(x, y).Y)
+		}
+	}
+
+	err = tf.Parse([]string{"--clearances", "0.1,0.2,0.3"})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	_, _, err = tf.Images(size)
+	if err == nil {
+		t.Errorf("expected an error for tests larger than the bed")
+	}
+}
@@ -0,0 +1,203 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// tomlUncomment removes a comment from a line, outside of quotes
+func tomlUncomment(line string) string {
+	var quote rune
+	escaped := false
+	for n, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:n]
+		}
+	}
+
+	return line
+}
+
+// tomlKey parses a dotted key (ie 'name.Bottom' or '"My Resin".Bottom')
+func tomlKey(text string) (key []string, err error) {
+	text = strings.TrimSpace(text)
+	for len(text) > 0 {
+		var part string
+		switch text[0] {
+		case '"', '\'':
+			end := strings.IndexByte(text[1:], text[0])
+			if end < 0 {
+				err = fmt.Errorf("unterminated key '%v'", text)
+				return
+			}
+			part = text[1 : end+1]
+			text = strings.TrimSpace(text[end+2:])
+		default:
+			end := strings.IndexByte(text, '.')
+			if end < 0 {
+				end = len(text)
+			}
+			part = strings.TrimSpace(text[:end])
+			text = text[end:]
+			if part == "" || strings.ContainsAny(part, " \t\"'") {
+				err = fmt.Errorf("invalid key '%v'", part)
+				return
+			}
+		}
+
+		key = append(key, part)
+
+		if len(text) > 0 {
+			if text[0] != '.' {
+				err = fmt.Errorf("invalid key '%v'", text)
+				return
+			}
+			text = strings.TrimSpace(text[1:])
+			if len(text) == 0 {
+				err = fmt.Errorf("key ends with '.'")
+				return
+			}
+		}
+	}
+
+	if len(key) == 0 {
+		err = fmt.Errorf("empty key")
+	}
+
+	return
+}
+
+// tomlValue parses a string, number or boolean value
+func tomlValue(text string) (value interface{}, err error) {
+	text = strings.TrimSpace(text)
+
+	switch {
+	case text == "true":
+		value = true
+	case text == "false":
+		value = false
+	case strings.HasPrefix(text, "\""):
+		value, err = strconv.Unquote(text)
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") || strings.Contains(text[1:len(text)-1], "'") {
+			err = fmt.Errorf("invalid string %v", text)
+			break
+		}
+		value = text[1 : len(text)-1]
+	default:
+		value, err = strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("invalid value '%v'", text)
+	}
+
+	return
+}
+
+// tomlTable returns the table at a key, creating it if needed
+func tomlTable(root map[string]interface{}, key []string) (table map[string]interface{}, err error) {
+	table = root
+	for _, part := range key {
+		item, ok := table[part]
+		if !ok {
+			item = map[string]interface{}{}
+			table[part] = item
+		}
+
+		table, ok = item.(map[string]interface{})
+		if !ok {
+			err = fmt.Errorf("'%v' is not a table", strings.Join(key, "."))
+			return
+		}
+	}
+
+	return
+}
+
+// TOMLParse parses the subset of TOML used by profile files: tables
+// (ie '[name.Bottom]'), and keys with string, number, or boolean values.
+// Numbers are always float64, as they would be from JSON.
+func TOMLParse(reader io.Reader) (root map[string]interface{}, err error) {
+	root = map[string]interface{}{}
+	table := root
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(tomlUncomment(scanner.Text()))
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") || strings.HasPrefix(text, "[[") {
+				err = fmt.Errorf("line %v: invalid table '%v'", line, text)
+				return
+			}
+
+			var key []string
+			key, err = tomlKey(text[1 : len(text)-1])
+			if err == nil {
+				table, err = tomlTable(root, key)
+			}
+			if err != nil {
+				err = fmt.Errorf("line %v: %w", line, err)
+				return
+			}
+			continue
+		}
+
+		kv := strings.SplitN(text, "=", 2)
+		if len(kv) != 2 {
+			err = fmt.Errorf("line %v: expected 'key = value', got '%v'", line, text)
+			return
+		}
+
+		var key []string
+		key, err = tomlKey(kv[0])
+		if err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+
+		var parent map[string]interface{}
+		parent, err = tomlTable(table, key[:len(key)-1])
+		if err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+
+		name := key[len(key)-1]
+		_, exists := parent[name]
+		if exists {
+			err = fmt.Errorf("line %v: '%v' is already defined", line, strings.Join(key, "."))
+			return
+		}
+
+		parent[name], err = tomlValue(kv[1])
+		if err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+	}
+
+	err = scanner.Err()
+
+	return
+}
@@ -0,0 +1,65 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTOMLParse(t *testing.T) {
+	text := `# Resins
+["My # Resin"]
+Density = 1_100.5 # g/l
+Name = 'literal'
+
+["My # Resin".Exposure]
+LightOnTime = 2.5
+Quoted = "a \"b\" # c"
+Bottom.Count = 6
+Enabled = true
+`
+
+	expected := map[string]interface{}{
+		"My # Resin": map[string]interface{}{
+			"Density": 1100.5,
+			"Name":    "literal",
+			"Exposure": map[string]interface{}{
+				"LightOnTime": 2.5,
+				"Quoted":      `a "b" # c`,
+				"Bottom":      map[string]interface{}{"Count": 6.0},
+				"Enabled":     true,
+			},
+		},
+	}
+
+	table, err := TOMLParse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if !cmp.Equal(expected, table) {
+		t.Errorf("expected %v, got %v", expected, table)
+	}
+
+	for _, bad := range []string{
+		"[table",
+		"[[array]]",
+		"key",
+		"key = ",
+		"key = 'unterminated",
+		"a = 1\na = 2",
+		"a = 1\n[a]",
+		"a. = 1",
+		"bad key = 1",
+	} {
+		_, err = TOMLParse(strings.NewReader(bad))
+		if err == nil {
+			t.Errorf("%#v: expected an error", bad)
+		}
+	}
+}
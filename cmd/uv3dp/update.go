@@ -0,0 +1,440 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	// UpdateRepository is the GitHub repository of this module's releases
+	UpdateRepository = "nicarran/uv3dp"
+
+	// UpdateChecksums is the release asset with the SHA-256 of each binary.
+	// It is published with the binaries, so it detects a corrupted
+	// download, but not a tampered release.
+	UpdateChecksums = "SHA256SUMS"
+
+	// updateInterval is the time between passive update checks
+	updateInterval = 24 * time.Hour
+
+	// updateNoticeTimeout limits how long a passive update check may take
+	updateNoticeTimeout = 2 * time.Second
+)
+
+var (
+	// UpdateURL is the GitHub API location of the latest release
+	UpdateURL = "https://api.github.com/repos/" + UpdateRepository + "/releases/latest"
+
+	// UpdatePath is the file with the passive update notice settings
+	UpdatePath string
+)
+
+// UpdateRelease is a release, as described by the GitHub releases API
+type UpdateRelease struct {
+	Version string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// Asset returns the download location of a release asset
+func (release *UpdateRelease) Asset(name string) (location string, ok bool) {
+	for _, asset := range release.Assets {
+		if asset.Name == name {
+			return asset.URL, true
+		}
+	}
+
+	return
+}
+
+// UpdateSettings are the passive update notice settings
+type UpdateSettings struct {
+	Notify  bool      // Show a notice when a new version is available
+	Checked time.Time // Time of the last check
+	Latest  string    `json:",omitempty"` // Latest version found by the last check
+}
+
+// UpdateAssetName is the name of the release asset for this platform, as
+// published by .github/workflows/release.yml
+func UpdateAssetName() (name string) {
+	name = fmt.Sprintf("uv3dp-%v-%v", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	return
+}
+
+// updateVersionParts splits a version (ie 'v1.2.3') into its numbers
+func updateVersionParts(version string) (parts []int, ok bool) {
+	version = strings.TrimPrefix(version, "v")
+	if version == "" {
+		return
+	}
+
+	for _, field := range strings.Split(version, ".") {
+		part, err := strconv.Atoi(field)
+		if err != nil {
+			return
+		}
+		parts = append(parts, part)
+	}
+
+	ok = true
+
+	return
+}
+
+// UpdateNewer returns true if the latest version is newer than the current
+// version. Versions that can not be compared (ie 'unreleased') are never newer.
+func UpdateNewer(current, latest string) bool {
+	currentParts, ok := updateVersionParts(current)
+	if !ok {
+		return false
+	}
+
+	latestParts, ok := updateVersionParts(latest)
+	if !ok {
+		return false
+	}
+
+	for n := 0; n < len(currentParts) || n < len(latestParts); n++ {
+		var c, l int
+		if n < len(currentParts) {
+			c = currentParts[n]
+		}
+		if n < len(latestParts) {
+			l = latestParts[n]
+		}
+		if c != l {
+			return l > c
+		}
+	}
+
+	return false
+}
+
+// updateGet downloads the contents of a location
+func updateGet(client *http.Client, location string) (data []byte, err error) {
+	resp, err := client.Get(location)
+	if err != nil {
+		return
+	}
+	defer func() { resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s: %s", location, resp.Status)
+		return
+	}
+
+	data, err = ioutil.ReadAll(resp.Body)
+
+	return
+}
+
+// UpdateLatest describes the latest release
+func UpdateLatest(client *http.Client) (release *UpdateRelease, err error) {
+	data, err := updateGet(client, UpdateURL)
+	if err != nil {
+		return
+	}
+
+	release = &UpdateRelease{}
+	err = json.Unmarshal(data, release)
+	if err != nil {
+		err = fmt.Errorf("%v: %w", UpdateURL, err)
+		return
+	}
+
+	return
+}
+
+// UpdateChecksum finds the checksum of the named file, in the
+// 'sha256sum' formatted contents of a checksums file.
+func UpdateChecksum(reader io.Reader, name string) (sum []byte, err error) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+
+		sum, err = hex.DecodeString(fields[0])
+		if err != nil || len(sum) != sha256.Size {
+			err = fmt.Errorf("%v: invalid checksum '%v'", name, fields[0])
+		}
+		return
+	}
+
+	err = scanner.Err()
+	if err == nil {
+		err = fmt.Errorf("%v: no checksum found", name)
+	}
+
+	return
+}
+
+// UpdateDownload downloads the binary for this platform from a release,
+// and verifies it against the release checksums.
+func UpdateDownload(client *http.Client, release *UpdateRelease) (data []byte, err error) {
+	name := UpdateAssetName()
+
+	location, ok := release.Asset(name)
+	if !ok {
+		err = fmt.Errorf("self-update: release %v has no binary for %v/%v", release.Version, runtime.GOOS, runtime.GOARCH)
+		return
+	}
+
+	sumsLocation, ok := release.Asset(UpdateChecksums)
+	if !ok {
+		err = fmt.Errorf("self-update: release %v has no %v, refusing to update", release.Version, UpdateChecksums)
+		return
+	}
+
+	sums, err := updateGet(client, sumsLocation)
+	if err != nil {
+		return
+	}
+
+	expected, err := UpdateChecksum(bytes.NewReader(sums), name)
+	if err != nil {
+		return
+	}
+
+	data, err = updateGet(client, location)
+	if err != nil {
+		return
+	}
+
+	got := sha256.Sum256(data)
+	if !bytes.Equal(got[:], expected) {
+		err = fmt.Errorf("self-update: %v: checksum %x does not match %x", name, got, expected)
+		data = nil
+		return
+	}
+
+	return
+}
+
+// UpdateReplace replaces an executable with new contents. The previous
+// executable is kept with an '.old' suffix, as a running executable can
+// not be overwritten on all platforms.
+func UpdateReplace(executable string, data []byte) (err error) {
+	info, err := os.Stat(executable)
+	if err != nil {
+		return
+	}
+
+	newName := executable + ".new"
+	oldName := executable + ".old"
+
+	err = ioutil.WriteFile(newName, data, info.Mode().Perm())
+	if err != nil {
+		return
+	}
+
+	os.Remove(oldName)
+
+	err = os.Rename(executable, oldName)
+	if err != nil {
+		os.Remove(newName)
+		return
+	}
+
+	err = os.Rename(newName, executable)
+	if err != nil {
+		// Put the previous executable back
+		os.Rename(oldName, executable)
+		return
+	}
+
+	return
+}
+
+// UpdateLoadSettings reads the passive update notice settings.
+// A missing file has the default settings.
+func UpdateLoadSettings(filename string) (settings UpdateSettings, err error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, &settings)
+	if err != nil {
+		err = fmt.Errorf("%v: %w", filename, err)
+	}
+
+	return
+}
+
+// UpdateSaveSettings writes the passive update notice settings
+func UpdateSaveSettings(filename string, settings UpdateSettings) (err error) {
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return
+	}
+
+	data, err := json.MarshalIndent(&settings, "", "  ")
+	if err != nil {
+		return
+	}
+
+	err = ioutil.WriteFile(filename, append(data, '\n'), 0644)
+
+	return
+}
+
+// UpdateNotice shows a notice when a newer version is available, if the
+// notice was enabled with 'self-update --notify'. The latest release is
+// checked at most once a day, and any errors are silently ignored.
+func UpdateNotice(writer io.Writer, filename string, now time.Time) {
+	settings, err := UpdateLoadSettings(filename)
+	if err != nil || !settings.Notify {
+		return
+	}
+
+	if now.Sub(settings.Checked) >= updateInterval {
+		client := &http.Client{Timeout: updateNoticeTimeout}
+		release, err := UpdateLatest(client)
+		if err != nil {
+			return
+		}
+
+		settings.Checked = now
+		settings.Latest = release.Version
+		UpdateSaveSettings(filename, settings)
+	}
+
+	if UpdateNewer(Version, settings.Latest) {
+		fmt.Fprintf(writer, "uv3dp: version %v is available (this is %v), run 'uv3dp self-update' to install it\n",
+			settings.Latest, Version)
+	}
+}
+
+type UpdateCommand struct {
+	*pflag.FlagSet
+
+	Check  bool
+	Force  bool
+	Notify bool
+}
+
+func NewUpdateCommand() (cmd *UpdateCommand) {
+	flagSet := pflag.NewFlagSet("self-update", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &UpdateCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.BoolVarP(&cmd.Check, "check", "c", false, "Only check for a new version")
+	cmd.BoolVarP(&cmd.Force, "force", "f", false, "Install the latest release, even if it is not newer (only checked against the release's own checksums, which cannot detect a tampered release)")
+	cmd.BoolVarP(&cmd.Notify, "notify", "n", false, "Enable (or with --notify=false, disable) a notice when a new version is available")
+
+	return
+}
+
+// Run executes the 'self-update' sub-command
+func (cmd *UpdateCommand) Run() (err error) {
+	if cmd.NArg() != 0 {
+		err = fmt.Errorf("self-update: unexpected arguments %v", cmd.Args())
+		return
+	}
+
+	if cmd.Changed("notify") {
+		var settings UpdateSettings
+		settings, err = UpdateLoadSettings(UpdatePath)
+		if err != nil {
+			return
+		}
+
+		settings.Notify = cmd.Notify
+		err = UpdateSaveSettings(UpdatePath, settings)
+		if err != nil {
+			return
+		}
+
+		TraceVerbosef(VerbosityNotice, "self-update: notices %v in %v",
+			map[bool]string{true: "enabled", false: "disabled"}[cmd.Notify], UpdatePath)
+		return
+	}
+
+	client := &http.Client{}
+
+	release, err := UpdateLatest(client)
+	if err != nil {
+		return
+	}
+
+	newer := UpdateNewer(Version, release.Version)
+
+	if cmd.Check {
+		if newer {
+			fmt.Printf("Version %v is available (this is %v)\n", release.Version, Version)
+		} else {
+			fmt.Printf("Version %v is the latest release (this is %v)\n", release.Version, Version)
+		}
+		return
+	}
+
+	if !newer && !cmd.Force {
+		fmt.Printf("Version %v is the latest release (this is %v), use --force to install it anyway\n", release.Version, Version)
+		return
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return
+	}
+
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return
+	}
+
+	TraceVerbosef(VerbosityNotice, "  Downloading %v of version %v", UpdateAssetName(), release.Version)
+
+	data, err := UpdateDownload(client, release)
+	if err != nil {
+		return
+	}
+
+	err = UpdateReplace(executable, data)
+	if err != nil {
+		err = fmt.Errorf("self-update: %v: %w", executable, err)
+		return
+	}
+
+	fmt.Printf("%v: updated to version %v\n", executable, release.Version)
+
+	return
+}
+
+func init() {
+	UpdatePath = uv3dpPath("update.json")
+}
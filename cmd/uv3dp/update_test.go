@@ -0,0 +1,202 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateNewer(t *testing.T) {
+	table := []struct {
+		Current string
+		Latest  string
+		Newer   bool
+	}{
+		{Current: "v0.1.0", Latest: "v0.2.0", Newer: true},
+		{Current: "v0.2.0", Latest: "v0.2.0", Newer: false},
+		{Current: "v0.10.0", Latest: "v0.9.0", Newer: false},
+		{Current: "v1.2", Latest: "v1.2.1", Newer: true},
+		{Current: "1.2.1", Latest: "v1.2", Newer: false},
+		{Current: "unreleased", Latest: "v9.0.0", Newer: false},
+		{Current: "v1.0.0", Latest: "nightly", Newer: false},
+	}
+
+	for n, item := range table {
+		newer := UpdateNewer(item.Current, item.Latest)
+		if newer != item.Newer {
+			t.Errorf("%v: %v -> %v: expected %v, got %v", n, item.Current, item.Latest, item.Newer, newer)
+		}
+	}
+}
+
+// updateServer serves a release with a binary for this platform
+func updateServer(binary []byte, sums string) (server *httptest.Server) {
+	mux := http.NewServeMux()
+	server = httptest.NewServer(mux)
+
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"tag_name":"v9.0.0","assets":[`+
+			`{"name":%q,"browser_download_url":%q},`+
+			`{"name":%q,"browser_download_url":%q}]}`,
+			UpdateAssetName(), server.URL+"/binary",
+			UpdateChecksums, server.URL+"/sums")
+	})
+	mux.HandleFunc("/binary", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	})
+	mux.HandleFunc("/sums", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sums))
+	})
+
+	return
+}
+
+func TestUpdateDownload(t *testing.T) {
+	defer func(location string) { UpdateURL = location }(UpdateURL)
+
+	binary := []byte("new binary")
+	good := fmt.Sprintf("%x  other\n%x  %v\n", sha256.Sum256([]byte("other")), sha256.Sum256(binary), UpdateAssetName())
+	bad := fmt.Sprintf("%x *%v\n", sha256.Sum256([]byte("tampered")), UpdateAssetName())
+
+	table := []struct {
+		Sums  string
+		Valid bool
+	}{
+		{Sums: good, Valid: true},
+		{Sums: bad, Valid: false},
+		{Sums: "", Valid: false},
+	}
+
+	for n, item := range table {
+		server := updateServer(binary, item.Sums)
+		UpdateURL = server.URL + "/latest"
+
+		release, err := UpdateLatest(server.Client())
+		if err != nil {
+			t.Fatalf("%v: expected nil, got %v", n, err)
+		}
+
+		if release.Version != "v9.0.0" {
+			t.Errorf("%v: expected %v, got %v", n, "v9.0.0", release.Version)
+		}
+
+		data, err := UpdateDownload(server.Client(), release)
+		if (err == nil) != item.Valid {
+			t.Errorf("%v: expected valid %v, got %v", n, item.Valid, err)
+		}
+
+		if item.Valid && !bytes.Equal(data, binary) {
+			t.Errorf("%v: expected %q, got %q", n, binary, data)
+		}
+
+		server.Close()
+	}
+}
+
+func TestUpdateRelease(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "go.mod"))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	module := "module github.com/" + UpdateRepository + "\n"
+	if !strings.HasPrefix(string(data), module) {
+		t.Errorf("expected go.mod to start with %q", module)
+	}
+
+	data, err = ioutil.ReadFile(filepath.Join("..", "..", ".github", "workflows", "release.yml"))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	for _, asset := range []string{UpdateChecksums, "uv3dp-linux-amd64", "uv3dp-darwin-amd64", "uv3dp-windows-amd64.exe"} {
+		if !strings.Contains(string(data), "asset_name: "+asset+"\n") {
+			t.Errorf("expected release.yml to publish %q", asset)
+		}
+	}
+}
+
+func TestUpdateReplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp-update")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	executable := filepath.Join(dir, "uv3dp")
+	ioutil.WriteFile(executable, []byte("old"), 0755)
+
+	err = UpdateReplace(executable, []byte("new"))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	for name, expected := range map[string]string{executable: "new", executable + ".old": "old"} {
+		data, _ := ioutil.ReadFile(name)
+		if string(data) != expected {
+			t.Errorf("%v: expected %q, got %q", name, expected, data)
+		}
+	}
+
+	info, _ := os.Stat(executable)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected %v, got %v", os.FileMode(0755), info.Mode().Perm())
+	}
+}
+
+func TestUpdateNotice(t *testing.T) {
+	defer func(location, version string) { UpdateURL, Version = location, version }(UpdateURL, Version)
+
+	dir, err := ioutil.TempDir("", "uv3dp-update")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := updateServer(nil, "")
+	defer server.Close()
+	UpdateURL = server.URL + "/latest"
+	Version = "v1.0.0"
+
+	filename := filepath.Join(dir, "update.json")
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// Notices are opt-in
+	buff := &bytes.Buffer{}
+	UpdateNotice(buff, filename, now)
+	if buff.Len() != 0 {
+		t.Errorf("expected no notice, got %q", buff.String())
+	}
+
+	UpdateSaveSettings(filename, UpdateSettings{Notify: true})
+
+	UpdateNotice(buff, filename, now)
+	if buff.Len() == 0 {
+		t.Errorf("expected a notice, got none")
+	}
+
+	settings, _ := UpdateLoadSettings(filename)
+	if settings.Latest != "v9.0.0" || !settings.Checked.Equal(now) {
+		t.Errorf("expected check at %v, got %+v", now, settings)
+	}
+
+	// Checks within a day use the saved result
+	server.Close()
+	buff.Reset()
+	UpdateNotice(buff, filename, now.Add(time.Hour))
+	if buff.Len() == 0 {
+		t.Errorf("expected a notice, got none")
+	}
+}
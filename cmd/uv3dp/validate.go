@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type ValidateCommand struct {
+	*pflag.FlagSet
+
+	Machine  string
+	Firmware string
+	Strict   bool
+	Islands  bool
+}
+
+func NewValidateCommand() (cmd *ValidateCommand) {
+	flagSet := pflag.NewFlagSet("validate", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &ValidateCommand{
+		FlagSet: flagSet,
+	}
+
+	cmd.StringVarP(&cmd.Machine, "machine", "M", "", "Machine to check against (default is the --machine)")
+	cmd.StringVarP(&cmd.Firmware, "firmware", "f", "", "Firmware version of the machine (default is the --firmware)")
+	cmd.BoolVarP(&cmd.Strict, "strict", "s", false, "Fail on warnings, as well as on errors")
+	cmd.BoolVarP(&cmd.Islands, "islands", "i", false, "Check for islands (reads all layers)")
+
+	return
+}
+
+// ValidateMachineReport checks a printable against a machine, and the
+// quirks of a version of its firmware (if not empty), as well as the
+// checks made of all output files.
+func ValidateMachineReport(input uv3dp.Printable, machineName string, firmware string, islands bool) (report *uv3dp.ValidationReport, err error) {
+	machine, err := uv3dp.LookupMachine(machineName)
+	if err != nil {
+		return
+	}
+
+	var quirks uv3dp.FirmwareQuirks
+	if firmware != "" {
+		var ok bool
+		quirks, ok = machine.LookupFirmware(firmware)
+		if !ok {
+			TraceVerbosef(VerbosityNotice, "  %v firmware %v: no known quirks", machineName, firmware)
+		}
+	}
+
+	validators := []uv3dp.Validator{uv3dp.ValidateResolution, uv3dp.ValidateExposure, uv3dp.ValidateMachine(machine.Machine, quirks)}
+	if machine.Vat > 0 {
+		validators = append(validators, uv3dp.ValidateVat(machine.Vat))
+	}
+	if islands {
+		validators = append(validators, uv3dp.ValidateIslands)
+	}
+
+	report = uv3dp.Validate(input, validators...)
+
+	return
+}
+
+// ValidateReportWrite shows all of the issues of a report, and a summary
+func ValidateReportWrite(writer io.Writer, report *uv3dp.ValidationReport) {
+	for _, issue := range report.Issues {
+		fmt.Fprintf(writer, "%v\n", issue)
+	}
+
+	fmt.Fprintf(writer, "Validation: %v errors, %v warnings\n",
+		report.Count(uv3dp.ValidationError), report.Count(uv3dp.ValidationWarning))
+}
+
+func (cmd *ValidateCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	machine := cmd.Machine
+	if machine == "" {
+		machine = param.Machine
+	}
+
+	firmware := cmd.Firmware
+	if firmware == "" {
+		firmware = param.Firmware
+	}
+
+	if machine == "" {
+		err = fmt.Errorf("validate: a machine must be selected with --machine")
+		return
+	}
+
+	report, err := ValidateMachineReport(input, machine, firmware, cmd.Islands)
+	if err != nil {
+		err = fmt.Errorf("validate: %w", err)
+		return
+	}
+
+	ValidateReportWrite(os.Stdout, report)
+
+	errors := report.Count(uv3dp.ValidationError)
+	warnings := report.Count(uv3dp.ValidationWarning)
+	if errors > 0 || (cmd.Strict && warnings > 0) {
+		err = fmt.Errorf("validate: %v: %v errors, %v warnings", machine, errors, warnings)
+		return
+	}
+
+	output = input
+
+	return
+}
@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	machine := uv3dp.Machine{
+		Vendor: "Test",
+		Model:  "Validate",
+		Size:   uv3dp.MachineSize{X: 10, Y: 10, Xmm: 5, Ymm: 5},
+		Height: 0.1,
+	}
+	uv3dp.RegisterMachine("test-validate", machine, ".uvj")
+	uv3dp.RegisterMachineFirmware("test-validate", "1.0", uv3dp.FirmwareQuirks{MaxLayers: 2})
+}
+
+func TestValidateMachineReport(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 10, Y: 10, Layers: 3, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 5, Y: 5},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: 8, LightPWM: 255},
+		Bottom:   uv3dp.Bottom{Count: 1, Exposure: uv3dp.Exposure{LightOnTime: 60, LightPWM: 255}},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	table := map[string]struct {
+		Firmware string
+		Errors   int
+	}{
+		"machine":  {Errors: 1},
+		"firmware": {Firmware: "1.0", Errors: 2},
+		"unknown":  {Firmware: "2.0", Errors: 1},
+	}
+
+	for name, item := range table {
+		report, err := ValidateMachineReport(input, "test-validate", item.Firmware, false)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		if report.Count(uv3dp.ValidationError) != item.Errors {
+			t.Errorf("%v: expected %v errors, got %v", name, item.Errors, report.Error())
+		}
+	}
+
+	_, err := ValidateMachineReport(input, "test-missing", "", false)
+	if err == nil {
+		t.Errorf("expected an error for an unknown machine")
+	}
+
+	report, _ := ValidateMachineReport(input, "test-validate", "", false)
+	buff := &bytes.Buffer{}
+	ValidateReportWrite(buff, report)
+	expected := "error: layer 2: height of 0.15 mm is more than the machine's build height of 0.10 mm\nValidation: 1 errors, 0 warnings\n"
+	if buff.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buff.String())
+	}
+}
@@ -0,0 +1,92 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type ZOffsetCommand struct {
+	*pflag.FlagSet
+
+	Offset float32
+	Squish float32
+	Layers int
+}
+
+func NewZOffsetCommand() (cmd *ZOffsetCommand) {
+	cmd = &ZOffsetCommand{
+		FlagSet: pflag.NewFlagSet("zoffset", pflag.ContinueOnError),
+	}
+
+	cmd.Float32VarP(&cmd.Offset, "offset", "o", 0.0, "Z offset of all layers, in millimeters (positive raises the build plate)")
+	cmd.Float32VarP(&cmd.Squish, "squish", "s", 0.0, "Lowers the first layer by this much, in millimeters, pressing it harder against the build plate")
+	cmd.IntVarP(&cmd.Layers, "layers", "l", 1, "Number of layers to spread the squish over")
+
+	cmd.SetInterspersed(false)
+
+	return
+}
+
+// ZOffsetHeights returns the Z height of every layer of a printable,
+// moved by an offset. The first layers are also lowered by a squish,
+// by all of it on the first layer, and less on each layer above it.
+func ZOffsetHeights(input uv3dp.Printable, offset float32, squish float32, layers int) (heights []float32, err error) {
+	heights = make([]float32, input.Size().Layers)
+
+	below := float32(0)
+	for n := range heights {
+		z := input.LayerZ(n) + offset
+		if n < layers {
+			z -= squish * float32(layers-n) / float32(layers)
+		}
+
+		// Round to a tenth of a micrometer, to drop float32 noise
+		z = float32(math.Round(float64(z)*10000) / 10000)
+
+		if z <= below {
+			err = fmt.Errorf("layer %v: Z %v is not above the layer below it (%v)", n, z, below)
+			return
+		}
+
+		heights[n] = z
+		below = z
+	}
+
+	return
+}
+
+func (cmd *ZOffsetCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	if cmd.Layers < 1 {
+		err = fmt.Errorf("zoffset: --layers must be at least 1")
+		return
+	}
+
+	if cmd.Offset != 0 {
+		TraceVerbosef(VerbosityNotice, "  Offsetting all layers by %v mm", cmd.Offset)
+	}
+
+	if cmd.Squish != 0 {
+		TraceVerbosef(VerbosityNotice, "  Squishing the first layer by %v mm, over %v layers", cmd.Squish, cmd.Layers)
+	}
+
+	heights, err := ZOffsetHeights(input, cmd.Offset, cmd.Squish, cmd.Layers)
+	if err != nil {
+		err = fmt.Errorf("zoffset: %w", err)
+		return
+	}
+
+	output = &zTableModifier{
+		Printable: input,
+		heights:   heights,
+	}
+
+	return
+}
@@ -0,0 +1,61 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestZOffset(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 4, LayerHeight: 0.05},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	table := map[string]struct {
+		Args []string
+		Z    []float32
+	}{
+		"offset":  {Args: []string{"-o", "0.1"}, Z: []float32{0.15, 0.2, 0.25, 0.3}},
+		"squish":  {Args: []string{"-s", "0.02"}, Z: []float32{0.03, 0.1, 0.15, 0.2}},
+		"layers":  {Args: []string{"-s", "0.04", "-l", "2"}, Z: []float32{0.01, 0.08, 0.15, 0.2}},
+		"both":    {Args: []string{"-o", "-0.01", "-s", "0.02"}, Z: []float32{0.02, 0.09, 0.14, 0.19}},
+		"nothing": {Args: []string{}, Z: []float32{0.05, 0.1, 0.15, 0.2}},
+	}
+
+	for name, item := range table {
+		cmd := NewZOffsetCommand()
+		err := cmd.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		output, err := cmd.Filter(input)
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+
+		for n, z := range item.Z {
+			if !convertEqual(output.LayerZ(n), z) {
+				t.Errorf("%v: layer %v: expected Z %v, got %v", name, n, z, output.LayerZ(n))
+			}
+		}
+	}
+
+	for _, args := range [][]string{{"-o", "-0.05"}, {"-s", "0.05"}, {"-s", "0.2", "-l", "3"}, {"-l", "0"}} {
+		cmd := NewZOffsetCommand()
+		err := cmd.Parse(args)
+		if err == nil {
+			_, err = cmd.Filter(input)
+		}
+		if err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Columns of a Z table
+var zTableHeader = []string{"Layer", "Z"}
+
+type ZTableCommand struct {
+	*pflag.FlagSet
+
+	Action   string // 'export' or 'import'
+	Filename string
+	args     []string
+}
+
+func NewZTableCommand() (cmd *ZTableCommand) {
+	flagSet := pflag.NewFlagSet("ztable", pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	cmd = &ZTableCommand{
+		FlagSet: flagSet,
+	}
+
+	return
+}
+
+// Parse parses the flags, and the 'export FILE' or 'import FILE' action
+func (cmd *ZTableCommand) Parse(args []string) (err error) {
+	err = cmd.FlagSet.Parse(args)
+	if err != nil {
+		return
+	}
+
+	cmd.args = cmd.FlagSet.Args()
+	if len(cmd.args) < 2 || (cmd.args[0] != "export" && cmd.args[0] != "import") {
+		err = fmt.Errorf("ztable: expected 'export FILE' or 'import FILE'")
+		return
+	}
+
+	cmd.Action = cmd.args[0]
+	cmd.Filename = cmd.args[1]
+	cmd.args = cmd.args[2:]
+
+	return
+}
+
+// Args returns the arguments after the action
+func (cmd *ZTableCommand) Args() []string {
+	return cmd.args
+}
+
+func (cmd *ZTableCommand) NArg() int {
+	return len(cmd.args)
+}
+
+// ZTableExport writes the Z height of every layer as CSV
+func ZTableExport(writer io.Writer, printable uv3dp.Printable) (err error) {
+	csvWriter := csv.NewWriter(writer)
+
+	err = csvWriter.Write(zTableHeader)
+	if err != nil {
+		return
+	}
+
+	for n := 0; n < printable.Size().Layers; n++ {
+		err = csvWriter.Write([]string{strconv.Itoa(n), formatMatrixFloat(printable.LayerZ(n))})
+		if err != nil {
+			return
+		}
+	}
+
+	csvWriter.Flush()
+	err = csvWriter.Error()
+
+	return
+}
+
+// ZTableImport reads a Z table written by ZTableExport, returning the Z
+// height of every layer. Layers missing from the table keep the Z height
+// of the printable, and the heights must increase from layer to layer.
+func ZTableImport(reader io.Reader, printable uv3dp.Printable) (heights []float32, err error) {
+	layers := printable.Size().Layers
+
+	heights = make([]float32, layers)
+	for n := range heights {
+		heights[n] = printable.LayerZ(n)
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = len(zTableHeader)
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return
+	}
+
+	for n, name := range zTableHeader {
+		if header[n] != name {
+			err = fmt.Errorf("column %v: expected '%v', got '%v'", n+1, name, header[n])
+			return
+		}
+	}
+
+	for line := 2; ; line++ {
+		var record []string
+		record, err = csvReader.Read()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+
+		var index int
+		index, err = strconv.Atoi(record[0])
+		if err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+
+		if index < 0 || index >= layers {
+			err = fmt.Errorf("line %v: layer %v is not in the range 0..%v", line, index, layers-1)
+			return
+		}
+
+		var z float64
+		z, err = strconv.ParseFloat(record[1], 32)
+		if err != nil {
+			err = fmt.Errorf("line %v: Z: %w", line, err)
+			return
+		}
+
+		heights[index] = float32(z)
+	}
+
+	below := float32(0)
+	for n, z := range heights {
+		if z <= below {
+			err = fmt.Errorf("layer %v: Z %v is not above the layer below it (%v)", n, z, below)
+			return
+		}
+		below = z
+	}
+
+	return
+}
+
+// zTableModifier sets the Z height of every layer
+type zTableModifier struct {
+	uv3dp.Printable
+
+	heights []float32
+}
+
+func (mod *zTableModifier) LayerZ(index int) (z float32) {
+	z = mod.heights[index]
+
+	return
+}
+
+func (cmd *ZTableCommand) Filter(input uv3dp.Printable) (output uv3dp.Printable, err error) {
+	output = input
+
+	switch cmd.Action {
+	case "export":
+		TraceVerbosef(VerbosityNotice, "  Exporting Z table to %v", cmd.Filename)
+		writer := os.Stdout
+		if cmd.Filename != uv3dp.FilenameStdio {
+			writer, err = os.Create(cmd.Filename)
+			if err != nil {
+				break
+			}
+		}
+		err = ZTableExport(writer, input)
+		if writer != os.Stdout {
+			closeErr := writer.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}
+	case "import":
+		TraceVerbosef(VerbosityNotice, "  Importing Z table from %v", cmd.Filename)
+		reader := os.Stdin
+		if cmd.Filename != uv3dp.FilenameStdio {
+			reader, err = os.Open(cmd.Filename)
+			if err != nil {
+				break
+			}
+			defer reader.Close()
+		}
+		var heights []float32
+		heights, err = ZTableImport(reader, input)
+		if err != nil {
+			break
+		}
+		output = &zTableModifier{
+			Printable: input,
+			heights:   heights,
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("ztable: %v: %w", cmd.Filename, err)
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestZTable(t *testing.T) {
+	prop := uv3dp.Properties{
+		Size: uv3dp.Size{X: 10, Y: 10, Layers: 4, LayerHeight: 0.05},
+	}
+
+	input := &uv3dp.Print{Properties: prop}
+
+	buffer := &bytes.Buffer{}
+	err := ZTableExport(buffer, input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := "Layer,Z\n0,0.05\n1,0.1\n2,0.15\n3,0.2\n"
+	if buffer.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buffer.String())
+	}
+
+	heights, err := ZTableImport(strings.NewReader("Layer,Z\n2,0.125\n3,0.15\n"), input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if !cmp.Equal(heights, []float32{0.05, 0.1, 0.125, 0.15}) {
+		t.Errorf("expected [0.05 0.1 0.125 0.15], got %v", heights)
+	}
+
+	mod := &zTableModifier{Printable: input, heights: heights}
+	if mod.LayerZ(2) != 0.125 {
+		t.Errorf("expected Z 0.125, got %v", mod.LayerZ(2))
+	}
+
+	invalid := map[string]string{
+		"header":     "Layer,Height\n",
+		"layer":      "Layer,Z\n4,0.25\n",
+		"value":      "Layer,Z\n1,high\n",
+		"decreasing": "Layer,Z\n2,0.08\n",
+	}
+
+	for name, data := range invalid {
+		_, err = ZTableImport(strings.NewReader(data), input)
+		if err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
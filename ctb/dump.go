@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package ctb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/go-restruct/restruct"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// DumpRegions describes the header regions of a CTB file
+func (cf *Formatter) DumpRegions(file uv3dp.Reader, filesize int64) (regions []uv3dp.DumpRegion, err error) {
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return
+	}
+
+	header := ctbHeader{}
+	err = restruct.Unpack(data, binary.LittleEndian, &header)
+	if err != nil {
+		return
+	}
+
+	if header.Magic != defaultHeaderMagic {
+		err = fmt.Errorf("Unknown header magic: 0x%08x", header.Magic)
+		return
+	}
+
+	regions = append(regions, uv3dp.DumpStruct("header", 0, &header))
+
+	// unpack adds the region of a structure, if it is within the file
+	unpack := func(name string, offset uint32, value interface{}) bool {
+		if offset == 0 || int(offset) >= len(data) {
+			return false
+		}
+
+		if restruct.Unpack(data[offset:], binary.LittleEndian, value) != nil {
+			return false
+		}
+
+		regions = append(regions, uv3dp.DumpStruct(name, int64(offset), value))
+		return true
+	}
+
+	slicer := ctbSlicer{}
+	if unpack("slicer", header.SlicerOffset, &slicer) {
+		start, end := int64(slicer.MachineOffset), int64(slicer.MachineOffset+slicer.MachineSize)
+		if slicer.MachineSize > 0 && end <= int64(len(data)) {
+			regions = append(regions, uv3dp.DumpRegion{
+				Name:   "machine name",
+				Offset: start,
+				Size:   end - start,
+				Fields: []uv3dp.DumpField{{Name: "Name", Offset: start, Size: end - start, Value: fmt.Sprintf("%q", data[start:end])}},
+			})
+		}
+	}
+
+	unpack("param", header.ParamOffset, &ctbParam{})
+	unpack("preview (huge)", header.PreviewHigh, &ctbPreview{})
+	unpack("preview (tiny)", header.PreviewLow, &ctbPreview{})
+
+	layerDef := ctbLayerDef{}
+	if header.LayerCount > 0 && unpack("layer 0 definition", header.LayerDefs, &layerDef) {
+		if header.LayerCount > 1 {
+			regions = append(regions, layerDefsRegion(regions[len(regions)-1], header.LayerCount))
+		}
+
+		infoSize := layerDef.InfoSize
+		if header.Version >= 3 && infoSize > 0 && layerDef.ImageOffset >= infoSize {
+			unpack("layer 0 image info", layerDef.ImageOffset-infoSize, &ctbImageInfo{})
+		}
+	}
+
+	return
+}
+
+// layerDefsRegion is the region of the layer definitions after the first
+func layerDefsRegion(first uv3dp.DumpRegion, count uint32) uv3dp.DumpRegion {
+	return uv3dp.DumpRegion{
+		Name:   fmt.Sprintf("layer 1..%v definitions", count-1),
+		Offset: first.Offset + first.Size,
+		Size:   first.Size * int64(count-1),
+	}
+}
@@ -0,0 +1,47 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package ctb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDumpRegions(t *testing.T) {
+	formatter := NewFormatter(".ctb")
+	formatter.Parse([]string{})
+
+	buffWriter := &bytes.Buffer{}
+	err := formatter.Encode(buffWriter, emptyPrintable)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	buffReader := bytes.NewReader(buffWriter.Bytes())
+	regions, err := formatter.DumpRegions(buffReader, buffReader.Size())
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	names := map[string]bool{}
+	for _, region := range regions {
+		names[region.Name] = true
+	}
+
+	for _, name := range []string{"header", "param", "slicer", "machine name", "layer 0 definition", "layer 1..3 definitions", "layer 0 image info"} {
+		if !names[name] {
+			t.Errorf("expected a '%v' region, got %+v", name, names)
+		}
+	}
+
+	if regions[0].Size != 0x70 {
+		t.Errorf("expected header size 0x70, got %#x", regions[0].Size)
+	}
+
+	_, err = formatter.DumpRegions(bytes.NewReader(make([]byte, 0x70)), 0x70)
+	if err == nil {
+		t.Errorf("expected an error for a bad magic")
+	}
+}
@@ -33,11 +33,14 @@ const (
 	defaultLightOff         = 1.0
 
 	forceBedSizeMM_3 = 140.0
+
+	versionMin = 1 // Oldest CTB version, no encryption or per-layer info
+	versionMax = 4
 )
 
 type ctbHeader struct {
 	Magic          uint32     // 00:
-	Version        uint32     // 04: Always '3'
+	Version        uint32     // 04: 1 through 4
 	BedSizeMM      [3]float32 // 08:
 	_              [2]uint32  // 14:
 	HeightMM       float32    // 1c:
@@ -124,23 +127,24 @@ type ctbLayerDef struct {
 }
 
 type ctbImageInfo struct {
-	LayerDef     ctbLayerDef // 00:  Repeat of the LayerDef information
-	TotalSize    uint32      // 24:  Total size of ctbImageInfo and Image data
-	LiftHeight   float32     // 28:
-	LiftSpeed    float32     // 2c:
-	Unknown30    uint32      // 30: Zero
-	Unknown34    uint32      // 34: Zero
-	RetractSpeed float32     // 38:
-	Unknown3c    uint32      // 3c: Zero
-	Unknown40    uint32      // 40: Zero
-	Unknown44    uint32      // 44: Zero
-	Unknown48    uint32      // 48: Zero
-	Unknown4c    uint32      // 4c: ??
-	LightPWM     float32     // 50:
+	LayerDef             ctbLayerDef // 00:  Repeat of the LayerDef information
+	TotalSize            uint32      // 24:  Total size of ctbImageInfo and Image data
+	LiftHeight           float32     // 28:
+	LiftSpeed            float32     // 2c:
+	LiftHeight2          float32     // 30: Version 4 only
+	LiftSpeed2           float32     // 34: Version 4 only
+	RetractSpeed         float32     // 38:
+	RetractHeight2       float32     // 3c: Version 4 only
+	RetractSpeed2        float32     // 40: Version 4 only
+	RestTimeBeforeLift   float32     // 44: Version 4 only
+	RestTimeAfterLift    float32     // 48: Version 4 only
+	RestTimeAfterRetract float32     // 4c: Version 4 only
+	LightPWM             float32     // 50:
 }
 
 type Print struct {
 	uv3dp.Print
+	version   uint32
 	layerDef  []ctbLayerDef
 	imageInfo [](*ctbImageInfo)
 
@@ -152,6 +156,7 @@ type Formatter struct {
 
 	EncryptionSeed uint32
 	Version        int
+	Dedup          bool
 }
 
 func NewFormatter(suffix string) (cf *Formatter) {
@@ -163,15 +168,77 @@ func NewFormatter(suffix string) (cf *Formatter) {
 	}
 
 	cf.Uint32VarP(&cf.EncryptionSeed, "encryption-seed", "e", 0, "Specify a specific encryption seed")
-	cf.IntVarP(&cf.Version, "version", "v", 3, "Specify the CTB version (2 or 3)")
+	cf.IntVarP(&cf.Version, "version", "v", 3, "Specify the CTB version (1 through 4)")
+	cf.BoolVarP(&cf.Dedup, "dedup", "d", true, "Share the image data of identical layers (unencrypted version 1 files only)")
+
+	return
+}
+
+// validate checks that the printable can be represented by the selected CTB version
+func (cf *Formatter) validate(printable uv3dp.Printable) (err error) {
+	report := &uv3dp.ValidationReport{}
+
+	if cf.Version < versionMin || cf.Version > versionMax {
+		report.Add(uv3dp.ValidationError, -1, "unsupported version %v", cf.Version)
+	}
+
+	if cf.Version < 2 {
+		if cf.EncryptionSeed != 0 {
+			report.Add(uv3dp.ValidationError, -1, "version %v does not support encryption", cf.Version)
+		}
+
+		for _, pwm := range []uint8{printable.Exposure().LightPWM, printable.Bottom().Exposure.LightPWM} {
+			if pwm != 0 && pwm != 255 {
+				report.Add(uv3dp.ValidationError, -1, "version %v does not support light PWM (%v)", cf.Version, pwm)
+				break
+			}
+		}
+	}
+
+	if cf.Version < 3 {
+		// Only the per-layer exposure and off times can be saved
+		exp := printable.Exposure()
+		bot := printable.Bottom()
+		for n := 0; n < printable.Size().Layers; n++ {
+			want := exp
+			if n < bot.Count {
+				want = bot.Exposure
+			}
+
+			got := printable.LayerExposure(n)
+			if got.LiftHeight != want.LiftHeight ||
+				got.LiftSpeed != want.LiftSpeed ||
+				got.RetractSpeed != want.RetractSpeed ||
+				got.LightPWM != want.LightPWM {
+				report.Add(uv3dp.ValidationError, n, "version %v does not support per-layer lift, retract, or PWM settings", cf.Version)
+			}
+		}
+	}
+
+	if cf.Version < 4 {
+		for n := 0; n < printable.Size().Layers; n++ {
+			got := printable.LayerExposure(n)
+			if got.WaitBeforeCure != 0 || got.WaitAfterCure != 0 || got.WaitAfterLift != 0 {
+				report.Add(uv3dp.ValidationError, n, "version %v does not support wait times (use --version 4)", cf.Version)
+			}
+			if got.LiftHeight2 != 0 || got.RetractHeight2 != 0 {
+				report.Add(uv3dp.ValidationError, n, "version %v does not support two-stage lift or retract (use --version 4)", cf.Version)
+			}
+		}
+	}
+
+	err = report.Err()
 
 	return
 }
 
 // Save a uv3dp.Printable in CTB format
 func (cf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err error) {
-	if cf.Version < 2 || cf.Version > 3 {
-		err = fmt.Errorf("unsupported version %v", cf.Version)
+	// The printer shows the previews, so render any that are missing
+	printable = uv3dp.WithPreviews(printable)
+
+	err = cf.validate(printable)
+	if err != nil {
 		return
 	}
 
@@ -192,9 +259,10 @@ func (cf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err
 	rleHash := map[uint64]rleInfo{}
 
 	// Select an encryption seed
-	// A zero encryption seed is rejected by the printer, so check for that
+	// A zero encryption seed is rejected by the printer, so check for that,
+	// unless this is a version 1 file, which is never encrypted.
 	seed := cf.EncryptionSeed
-	for seed == 0 {
+	for seed == 0 && cf.Version > 1 {
 		seed = rand.Uint32()
 	}
 
@@ -290,8 +358,9 @@ func (cf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err
 		doneMap[n] = make(chan layerInfo, 1)
 	}
 
-	uv3dp.WithAllLayers(printable, func(p uv3dp.Printable, n int) {
-		rle, hash, bitsOn := rleEncodeGraymap(p.LayerImage(n))
+	// The LCD_X_MIRROR projector has its images stored mirrored
+	uv3dp.WithAllLayers(uv3dp.MirrorNormalize(printable, true), func(p uv3dp.Printable, n int) {
+		rle, hash, bitsOn := rleEncodeLayer(p.LayerImage(n))
 		doneMap[n] <- layerInfo{
 			Z:        p.LayerZ(n),
 			Exposure: p.LayerExposure(n),
@@ -310,8 +379,12 @@ func (cf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err
 
 	for n := 0; n < size.Layers; n++ {
 		info := <-doneMap[n]
-		if header.EncryptionSeed != 0 {
+		// Encrypted layers are ciphered by their index, so only
+		// unencrypted identical layers can share their image data.
+		if !cf.Dedup || header.EncryptionSeed != 0 {
 			info.Hash = uint64(n)
+		}
+		if header.EncryptionSeed != 0 {
 			info.Rle = cipher(header.EncryptionSeed, uint32(n), info.Rle)
 		}
 		_, ok := rleHash[info.Hash]
@@ -339,6 +412,16 @@ func (cf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err
 				RetractSpeed: info.Exposure.RetractSpeed,
 				LightPWM:     float32(info.Exposure.LightPWM),
 			}
+
+			if cf.Version >= 4 {
+				imageInfo[n].RestTimeBeforeLift = info.Exposure.WaitAfterCure
+				imageInfo[n].RestTimeAfterLift = info.Exposure.WaitAfterLift
+				imageInfo[n].RestTimeAfterRetract = info.Exposure.WaitBeforeCure
+				imageInfo[n].LiftHeight2 = info.Exposure.LiftHeight2
+				imageInfo[n].LiftSpeed2 = info.Exposure.LiftSpeed2
+				imageInfo[n].RetractHeight2 = info.Exposure.RetractHeight2
+				imageInfo[n].RetractSpeed2 = info.Exposure.RetractSpeed2
+			}
 		}
 
 		totalOn += uint64(info.BitsOn)
@@ -348,7 +431,10 @@ func (cf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err
 	header.BedSizeMM[0] = size.Millimeter.X
 	header.BedSizeMM[1] = size.Millimeter.Y
 	header.BedSizeMM[2] = forceBedSizeMM_3
-	header.HeightMM = size.LayerHeight * float32(size.Layers)
+	header.HeightMM = 0
+	if size.Layers > 0 {
+		header.HeightMM = printable.LayerZ(size.Layers - 1)
+	}
 	header.LayerHeight = size.LayerHeight
 	header.LayerExposure = exp.LightOnTime
 	header.BottomExposure = bot.Exposure.LightOnTime
@@ -531,6 +617,13 @@ func (cf *Formatter) Decode(file uv3dp.Reader, filesize int64) (printable uv3dp.
 		return
 	}
 
+	if header.Version > versionMax {
+		err = uv3dp.DecodeIssue(uv3dp.DecodeIssueVersion, fmt.Errorf("unsupported version %v (maximum %v)", header.Version, versionMax))
+		if err != nil {
+			return
+		}
+	}
+
 	// ctbSlicer info
 	slicer := ctbSlicer{}
 	if header.SlicerOffset > 0 {
@@ -606,8 +699,9 @@ func (cf *Formatter) Decode(file uv3dp.Reader, filesize int64) (printable uv3dp.
 			info := &ctbImageInfo{}
 			err = restruct.Unpack(data[addr-infoSize:addr], binary.LittleEndian, info)
 			if err != nil {
-				imageInfo[n] = info
+				return
 			}
+			imageInfo[n] = info
 		}
 	}
 
@@ -666,14 +760,38 @@ func (cf *Formatter) Decode(file uv3dp.Reader, filesize int64) (printable uv3dp.
 		exp.RetractHeight = defaultRetractHeight
 	}
 
+	// Version 4 only keeps the wait times and the two-stage lift and
+	// retract in the per-layer information, so use the first bottom and
+	// normal layers for the defaults.
+	if header.Version >= 4 {
+		for _, item := range []struct {
+			exposure *uv3dp.Exposure
+			index    int
+			ok       bool
+		}{{&bot.Exposure, 0, bot.Count > 0}, {exp, bot.Count, true}} {
+			if item.ok && item.index < len(imageInfo) && imageInfo[item.index] != nil {
+				info := imageInfo[item.index]
+				item.exposure.WaitBeforeCure = info.RestTimeAfterRetract
+				item.exposure.WaitAfterCure = info.RestTimeBeforeLift
+				item.exposure.WaitAfterLift = info.RestTimeAfterLift
+				item.exposure.LiftHeight2 = info.LiftHeight2
+				item.exposure.LiftSpeed2 = info.LiftSpeed2
+				item.exposure.RetractHeight2 = info.RetractHeight2
+				item.exposure.RetractSpeed2 = info.RetractSpeed2
+			}
+		}
+	}
+
 	ctb := &Print{
 		Print:     uv3dp.Print{Properties: prop},
+		version:   header.Version,
 		layerDef:  layerDef,
 		imageInfo: imageInfo,
 		rleMap:    rleMap,
 	}
 
-	printable = ctb
+	// The LCD_X_MIRROR projector has its images stored mirrored
+	printable = uv3dp.MirrorNormalize(ctb, header.Projector == 1)
 
 	return
 }
@@ -716,6 +834,16 @@ func (ctb *Print) LayerExposure(index int) (exposure uv3dp.Exposure) {
 		exposure.LiftHeight = info.LiftHeight
 		exposure.LiftSpeed = info.LiftSpeed
 		exposure.RetractSpeed = info.RetractSpeed
+
+		if ctb.version >= 4 {
+			exposure.WaitBeforeCure = info.RestTimeAfterRetract
+			exposure.WaitAfterCure = info.RestTimeBeforeLift
+			exposure.WaitAfterLift = info.RestTimeAfterLift
+			exposure.LiftHeight2 = info.LiftHeight2
+			exposure.LiftSpeed2 = info.LiftSpeed2
+			exposure.RetractHeight2 = info.RetractHeight2
+			exposure.RetractSpeed2 = info.RetractSpeed2
+		}
 	}
 
 	return
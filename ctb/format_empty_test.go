@@ -56,7 +56,7 @@ var (
 		},
 	}}
 
-	emptyRaw = []byte{0x86, 0x0, 0xfd, 0x12, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0xa0, 0x41, 0x0, 0x0, 0x20, 0x42, 0x0, 0x0, 0x1b, 0x43, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xcd, 0xcc, 0x4c, 0x3e, 0xcd, 0xcc, 0x4c, 0x3d, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0x2, 0x0, 0x0, 0x0, 0xa, 0x0, 0x0, 0x0, 0x14, 0x0, 0x0, 0x0, 0x70, 0x0, 0x0, 0x0, 0x47, 0x1, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x94, 0x0, 0x0, 0x0, 0x6a, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0xb8, 0x0, 0x0, 0x0, 0x3c, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0xff, 0x0, 0xff, 0x0, 0x42, 0x4, 0xcb, 0x9a, 0xf4, 0x0, 0x0, 0x0, 0x4c, 0x0, 0x0, 0x0, 0x14, 0x0, 0x0, 0x0, 0xc, 0x0, 0x0, 0x0, 0x90, 0x0, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xff, 0xff, 0xef, 0x30, 0xa, 0x0, 0x0, 0x0, 0xa, 0x0, 0x0, 0x0, 0xb4, 0x0, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x20, 0x0, 0x63, 0x30, 0x0, 0x0, 0xb0, 0x40, 0x0, 0x0, 0xf0, 0x42, 0x0, 0x0, 0xb0, 0x40, 0x0, 0x0, 0xf0, 0x42, 0x0, 0x0, 0x48, 0x43, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x10, 0x40, 0x0, 0x0, 0x10, 0x40, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x00, 0x00, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x40, 0x1, 0x0, 0x0, 0x7, 0x0, 0x0, 0x0, 0x7, 0x0, 0x0, 0x0, 0x78, 0x56, 0x34, 0x12, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x7, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0xcd, 0xcc, 0x4c, 0x3d, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0xd7, 0x1, 0x0, 0x0, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xcd, 0xcc, 0xcc, 0x3d, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0xda, 0x1, 0x0, 0x0, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x9a, 0x99, 0x19, 0x3e, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0xdd, 0x1, 0x0, 0x0, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xcd, 0xcc, 0x4c, 0x3e, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0xe0, 0x1, 0x0, 0x0, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xff, 0x61, 0x23, 0x7e, 0x35, 0x46, 0xfd, 0xa, 0xf9, 0x7c, 0xde, 0x1c}
+	emptyRaw = []byte{0x86, 0x0, 0xfd, 0x12, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0xa0, 0x41, 0x0, 0x0, 0x20, 0x42, 0x0, 0x0, 0xc, 0x43, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xcd, 0xcc, 0x4c, 0x3e, 0xcd, 0xcc, 0x4c, 0x3d, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0x2, 0x0, 0x0, 0x0, 0xa, 0x0, 0x0, 0x0, 0x14, 0x0, 0x0, 0x0, 0x70, 0x0, 0x0, 0x0, 0x47, 0x1, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x94, 0x0, 0x0, 0x0, 0x6a, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0xb8, 0x0, 0x0, 0x0, 0x3c, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0xff, 0x0, 0xff, 0x0, 0x42, 0x4, 0xcb, 0x9a, 0xf4, 0x0, 0x0, 0x0, 0x4c, 0x0, 0x0, 0x0, 0x14, 0x0, 0x0, 0x0, 0xc, 0x0, 0x0, 0x0, 0x90, 0x0, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xff, 0xff, 0xef, 0x30, 0xa, 0x0, 0x0, 0x0, 0xa, 0x0, 0x0, 0x0, 0xb4, 0x0, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x20, 0x0, 0x63, 0x30, 0x0, 0x0, 0xb0, 0x40, 0x0, 0x0, 0xf0, 0x42, 0x0, 0x0, 0xb0, 0x40, 0x0, 0x0, 0xf0, 0x42, 0x0, 0x0, 0x48, 0x43, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x10, 0x40, 0x0, 0x0, 0x10, 0x40, 0x2, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x00, 0x00, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x40, 0x1, 0x0, 0x0, 0x7, 0x0, 0x0, 0x0, 0x7, 0x0, 0x0, 0x0, 0x78, 0x56, 0x34, 0x12, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x7, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0xcd, 0xcc, 0x4c, 0x3d, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0xd7, 0x1, 0x0, 0x0, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xcd, 0xcc, 0xcc, 0x3d, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0xda, 0x1, 0x0, 0x0, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x9a, 0x99, 0x19, 0x3e, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0xdd, 0x1, 0x0, 0x0, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xcd, 0xcc, 0x4c, 0x3e, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0xe0, 0x1, 0x0, 0x0, 0x3, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xff, 0x61, 0x23, 0x7e, 0x35, 0x46, 0xfd, 0xa, 0xf9, 0x7c, 0xde, 0x1c}
 )
 
 type bufferMap struct {
@@ -93,6 +93,7 @@ func TestEmptyToRaw(t *testing.T) {
 	for _, item := range table {
 		formatter := NewFormatter(item.Format)
 		formatter.Version = 2
+		formatter.EncryptionSeed = 0x9acb0442
 
 		buffWriter := &bytes.Buffer{}
 		formatter.Encode(buffWriter, emptyPrintable)
@@ -116,6 +117,34 @@ func TestEmptyToRaw(t *testing.T) {
 	}
 }
 
+func TestVersionValidate(t *testing.T) {
+	pwmPrint := &uv3dp.Print{Properties: emptyPrintable.Properties}
+	pwmPrint.Properties.Exposure.LightPWM = 128
+
+	table := []struct {
+		Version   int
+		Printable uv3dp.Printable
+		Valid     bool
+	}{
+		{Version: 0, Printable: emptyPrintable, Valid: false},
+		{Version: 1, Printable: emptyPrintable, Valid: true},
+		{Version: 1, Printable: pwmPrint, Valid: false},
+		{Version: 2, Printable: pwmPrint, Valid: true},
+		{Version: 4, Printable: emptyPrintable, Valid: true},
+		{Version: 5, Printable: emptyPrintable, Valid: false},
+	}
+
+	for _, item := range table {
+		formatter := NewFormatter(".ctb")
+		formatter.Version = item.Version
+
+		err := formatter.Encode(&bytes.Buffer{}, item.Printable)
+		if (err == nil) != item.Valid {
+			t.Errorf("version %v: expected valid %v, got %v", item.Version, item.Valid, err)
+		}
+	}
+}
+
 func fixup(prop *uv3dp.Properties) {
 	// Fill in all the properties that the V2 (.ctb) format can't save
 	prop.Exposure.RetractHeight = defaultRetractHeight
@@ -211,3 +240,108 @@ func TestRawToEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestVersion4Exposure(t *testing.T) {
+	waitPrint := &uv3dp.Print{Properties: emptyPrintable.Properties}
+	waitPrint.Properties.Exposure.WaitBeforeCure = 1.5
+	waitPrint.Properties.Exposure.WaitAfterCure = 2.0
+	waitPrint.Properties.Exposure.WaitAfterLift = 0.5
+	waitPrint.Properties.Exposure.LiftHeight2 = 4.0
+	waitPrint.Properties.Exposure.LiftSpeed2 = 300.0
+	waitPrint.Properties.Exposure.RetractHeight2 = 2.0
+	waitPrint.Properties.Exposure.RetractSpeed2 = 60.0
+
+	formatter := NewFormatter(".ctb")
+	formatter.Version = 3
+
+	err := formatter.Encode(&bytes.Buffer{}, waitPrint)
+	if err == nil {
+		t.Errorf("version 3: expected an error")
+	}
+
+	formatter.Version = 4
+
+	buffer := &bytes.Buffer{}
+	err = formatter.Encode(buffer, waitPrint)
+	if err != nil {
+		t.Fatalf("version 4: expected nil, got %v", err)
+	}
+
+	result, err := formatter.Decode(&bufferMap{Buffer: buffer.Bytes()}, int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	exposure := result.Exposure()
+	if exposure.WaitBeforeCure != 1.5 || exposure.WaitAfterCure != 2.0 || exposure.WaitAfterLift != 0.5 {
+		t.Errorf("expected the default wait times, got %+v", exposure)
+	}
+
+	if exposure.LiftHeight2 != 4.0 || exposure.RetractHeight2 != 2.0 {
+		t.Errorf("expected the default two-stage lift and retract, got %+v", exposure)
+	}
+
+	for n := 0; n < waitPrint.Size().Layers; n++ {
+		expected := waitPrint.LayerExposure(n)
+		got := result.LayerExposure(n)
+		// The retract height cannot be saved by the CTB format
+		got.RetractHeight = expected.RetractHeight
+		if !cmp.Equal(expected, got) {
+			t.Errorf("layer %v: expected %+v, got %+v", n, expected, got)
+		}
+	}
+}
+
+func TestDedup(t *testing.T) {
+	table := []struct {
+		Version int
+		Args    []string
+		Shared  bool
+	}{
+		{Version: 1, Shared: true},
+		{Version: 1, Args: []string{"--dedup=false"}},
+		{Version: 2},
+		{Version: 3},
+	}
+
+	for _, item := range table {
+		formatter := NewFormatter(".ctb")
+		formatter.Version = item.Version
+		err := formatter.Parse(item.Args)
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+
+		buffer := &bytes.Buffer{}
+		err = formatter.Encode(buffer, emptyPrintable)
+		if err != nil {
+			t.Fatalf("version %v %v: expected nil, got %v", item.Version, item.Args, err)
+		}
+
+		result, err := formatter.Decode(&bufferMap{Buffer: buffer.Bytes()}, int64(buffer.Len()))
+		if err != nil {
+			t.Fatalf("version %v %v: expected nil, got %v", item.Version, item.Args, err)
+		}
+
+		// All the layers of the empty printable are identical
+		offsets := map[uint32]bool{}
+		for _, layer := range result.(*Print).layerDef {
+			offsets[layer.ImageOffset] = true
+		}
+
+		expected := emptyPrintable.Size().Layers
+		if item.Shared {
+			expected = 1
+		}
+		if len(offsets) != expected {
+			t.Errorf("version %v %v: expected %v layer images, got %v", item.Version, item.Args, expected, len(offsets))
+		}
+
+		for n := 0; n < result.Size().Layers; n++ {
+			got := result.LayerImage(n)
+			if !bytes.Equal(emptyPrintable.LayerImage(n).Pix, got.Pix) {
+				t.Errorf("version %v %v: layer %v: image does not match", item.Version, item.Args, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package ctb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type mirrorPrint struct {
+	*uv3dp.Print
+}
+
+// LayerImage has one pixel set, on the left of the first row
+func (mp *mirrorPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = mp.Print.LayerImage(index)
+	grayImage.Pix[0] = 0xff
+	return
+}
+
+func TestDecodeProjector(t *testing.T) {
+	defer uv3dp.SetMirrorNormalize(false)
+
+	formatter := NewFormatter(".ctb")
+	formatter.Parse([]string{})
+
+	buffWriter := &bytes.Buffer{}
+	err := formatter.Encode(buffWriter, emptyPrintable)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	for _, normalize := range []bool{false, true} {
+		uv3dp.SetMirrorNormalize(normalize)
+
+		for _, projector := range []uint32{0, 1} {
+			data := append([]byte{}, buffWriter.Bytes()...)
+			binary.LittleEndian.PutUint32(data[0x50:], projector)
+
+			buffReader := bytes.NewReader(data)
+			printable, err := formatter.Decode(buffReader, buffReader.Size())
+			if err != nil {
+				t.Fatalf("%v: expected nil, got %v", projector, err)
+			}
+
+			expected := normalize && projector == 1
+			_, mirrored := printable.(*uv3dp.MirroredPrintable)
+			if mirrored != expected {
+				t.Errorf("normalize %v, projector %v: expected mirrored %v, got %v", normalize, projector, expected, mirrored)
+			}
+		}
+	}
+}
+
+func TestEncodeProjector(t *testing.T) {
+	defer uv3dp.SetMirrorNormalize(false)
+
+	input := &mirrorPrint{Print: emptyPrintable}
+
+	table := []struct {
+		Normalize bool
+		Pixel     int // Offset of the set pixel in the stored image
+	}{
+		{Normalize: false, Pixel: 0},
+		{Normalize: true, Pixel: input.Size().X - 1},
+	}
+
+	for _, item := range table {
+		uv3dp.SetMirrorNormalize(item.Normalize)
+
+		formatter := NewFormatter(".ctb")
+		formatter.Parse([]string{})
+
+		buffWriter := &bytes.Buffer{}
+		err := formatter.Encode(buffWriter, input)
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+
+		buffReader := bytes.NewReader(buffWriter.Bytes())
+		printable, err := formatter.Decode(buffReader, buffReader.Size())
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+
+		// Compare against the stored image
+		stored := printable
+		mp, ok := printable.(*uv3dp.MirroredPrintable)
+		if ok {
+			stored = mp.Printable
+		}
+
+		pix := stored.LayerImage(0).Pix
+		if pix[item.Pixel] == 0 {
+			t.Errorf("normalize %v: expected pixel %v to be set in %v", item.Normalize, item.Pixel, pix[:input.Size().X])
+		}
+
+		// Reading the file back restores the input's orientation
+		pix = printable.LayerImage(0).Pix
+		if pix[0] == 0 {
+			t.Errorf("normalize %v: expected pixel 0 to be set in %v", item.Normalize, pix[:input.Size().X])
+		}
+	}
+}
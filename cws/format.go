@@ -125,7 +125,7 @@ func (cc *cwsConfig) Save(gcode io.Writer) (err error) {
 		case "int":
 			repr = fmt.Sprintf("%v", value.Int())
 		case "float32":
-			repr = fmt.Sprintf("%1.3f", value.Float())
+			repr = uv3dp.FormatFloat(float32(value.Float()), 3)
 		case "string":
 			repr = value.String()
 		case "bool":
@@ -148,15 +148,15 @@ func (cc *cwsConfig) Save(gcode io.Writer) (err error) {
 	if err != nil {
 		return
 	}
-	_, err = fmt.Fprintf(gcode, ";(%-24v= %1.2fmm )\n", "Platform X Size", float32(cc.XResolution)/cc.Xppm)
+	_, err = fmt.Fprintf(gcode, ";(%-24v= %vmm )\n", "Platform X Size", uv3dp.FormatFloat(float32(cc.XResolution)/cc.Xppm, 2))
 	if err != nil {
 		return
 	}
-	_, err = fmt.Fprintf(gcode, ";(%-24v= %1.2fmm )\n", "Platform Y Size", float32(cc.YResolution)/cc.Yppm)
+	_, err = fmt.Fprintf(gcode, ";(%-24v= %vmm )\n", "Platform Y Size", uv3dp.FormatFloat(float32(cc.YResolution)/cc.Yppm, 2))
 	if err != nil {
 		return
 	}
-	_, err = fmt.Fprintf(gcode, ";(%-24v= %1.2fmm )\n", "Platform Z Size", float32(cc.Layers)*cc.LayerThickness)
+	_, err = fmt.Fprintf(gcode, ";(%-24v= %vmm )\n", "Platform Z Size", uv3dp.FormatFloat(float32(cc.Layers)*cc.LayerThickness, 2))
 	if err != nil {
 		return
 	}
@@ -363,7 +363,7 @@ M106 S0
 		layerExposure := printable.LayerExposure(n)
 		if n > 0 {
 			thickness := layerZ - priorZ
-			fmt.Fprintf(gcode, "G1 Z%1.3f F%v\n", -(layerExposure.LiftHeight - thickness), int(layerExposure.LiftSpeed))
+			fmt.Fprintf(gcode, "G1 Z%v F%v\n", uv3dp.FormatFloat(-(layerExposure.LiftHeight-thickness), 3), int(layerExposure.LiftSpeed))
 			// This is just a guess here
 			fmt.Fprintf(gcode, ";<Delay> %v\n", 720000/int(layerExposure.LiftSpeed))
 		}
@@ -372,7 +372,7 @@ M106 S0
 		fmt.Fprintf(gcode, "\n;<Slice> %v\n", n)
 		fmt.Fprintf(gcode, "M106 S%v\n;<Delay> %v\n", layerExposure.LightPWM, int(layerExposure.LightOnTime*1000.0))
 		fmt.Fprintf(gcode, "M106 S0\n;<Slice> Blank\n")
-		fmt.Fprintf(gcode, "G1 Z%1.3f F%v\n", layerExposure.LiftHeight, int(layerExposure.LiftSpeed))
+		fmt.Fprintf(gcode, "G1 Z%v F%v\n", uv3dp.FormatFloat(layerExposure.LiftHeight, 3), int(layerExposure.LiftSpeed))
 		priorZ = layerZ
 	}
 
@@ -437,9 +437,8 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 		if err != nil {
 			return
 		}
-		defer reader.Close()
-
 		layerPng[n], err = ioutil.ReadAll(reader)
+		reader.Close()
 		if err != nil {
 			return
 		}
@@ -466,7 +465,7 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 		defer func() { reader.Close() }()
 
 		var thumb image.Image
-		thumb, err = png.Decode(reader)
+		thumb, err = uv3dp.DecodePreview(reader)
 		if err != nil {
 			return
 		}
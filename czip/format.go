@@ -98,7 +98,7 @@ func (cfg *czipConfig) Unmarshal(in string) (ok bool) {
 		if line[i] == ':' {
 			attr = line[:i]
 			line = line[i+1:]
-			i = 0
+			i = -1 // Restart the scan at the start of the value
 		} else if line[i] == ' ' || line[i] == '\t' || line[i] == '\r' || line[i] == '\n' || line[i] == '#' {
 			break
 		}
@@ -106,10 +106,15 @@ func (cfg *czipConfig) Unmarshal(in string) (ok bool) {
 	val := line[:i]
 
 	fmt.Printf(": '%s' => '%s' '%s'\n", in, attr, val)
-	if attr == "" || val == "" {
+	if attr == "" {
 		return false
 	}
 
+	if val == "" {
+		// Empty attributes (ie ';fileName:') are left as-is
+		return true
+	}
+
 	name := strings.ToUpper(attr[:1]) + attr[1:]
 
 	t := reflect.TypeOf(cfg).Elem()
@@ -158,6 +163,8 @@ type Print struct {
 
 type Format struct {
 	*pflag.FlagSet
+
+	Password string
 }
 
 func NewFormatter(suffix string) (sf *Format) {
@@ -167,13 +174,55 @@ func NewFormatter(suffix string) (sf *Format) {
 		FlagSet: flagSet,
 	}
 
+	sf.StringVarP(&sf.Password, "password", "P", "", "Password for encrypted archive entries")
 	sf.SetInterspersed(false)
 
 	return
 }
 
+// archiveWriter creates archive entries, encrypting them if a password is set
+type archiveWriter struct {
+	*zip.Writer
+
+	encrypted    bool
+	modifiedDate uint16
+	modifiedTime uint16
+}
+
+func (sf *Format) newArchiveWriter(writer io.Writer) (aw *archiveWriter) {
+	now := time_Now().UTC()
+
+	aw = &archiveWriter{
+		Writer:       zip.NewWriter(writer),
+		encrypted:    sf.Password != "",
+		modifiedDate: uint16(((now.Year() - 1980) << 9) | (int(now.Month()) << 5) | now.Day()),
+		modifiedTime: uint16((now.Hour() << 11) | (now.Minute() << 5) | (now.Second() >> 1)),
+	}
+
+	if aw.encrypted {
+		zipEncryptor(aw.Writer, sf.Password, aw.modifiedTime)
+	}
+
+	return
+}
+
+func (aw *archiveWriter) Create(name string) (writer io.Writer, err error) {
+	header := &zip.FileHeader{
+		Name:         name,
+		Method:       zip.Deflate,
+		ModifiedDate: aw.modifiedDate,
+		ModifiedTime: aw.modifiedTime,
+	}
+
+	if aw.encrypted {
+		header.Flags |= zipCryptoFlag
+	}
+
+	return aw.CreateHeader(header)
+}
+
 func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err error) {
-	archive := zip.NewWriter(writer)
+	archive := sf.newArchiveWriter(writer)
 	defer archive.Close()
 
 	size := printable.Size()
@@ -252,17 +301,19 @@ G28 Z0;
 
 		layer_code := fmt.Sprintf(`
 ;LAYER_START:%d
-;currPos:%.2f
+;currPos:%v
 M6054 "%s";show Image
-G0 Z%.2f F%d;
-G0 Z%.2f F%d;
+G0 Z%v F%d;
+G0 Z%v F%d;
 G4 P%d;
 M106 S%d;light on
 G4 P%d;
 M106 S0; light off
 
 ;LAYER_END
-`, n, z, filename, z+exp.LiftHeight, int(exp.LiftSpeed), z, int(exp.RetractSpeed),
+`, n, uv3dp.FormatFloat(z, 2), filename,
+			uv3dp.FormatFloat(z+exp.LiftHeight, 2), int(exp.LiftSpeed),
+			uv3dp.FormatFloat(z, 2), int(exp.RetractSpeed),
 			int(exp.LightOnTime*1000),
 			exp.LightPWM,
 			int(exp.LightOffTime*1000))
@@ -273,11 +324,11 @@ M106 S0; light off
 	gcode += fmt.Sprintf(`
 ;END_GCODE_BEGIN
 M106 S0;
-G1 Z%.2f F25
+G1 Z%v F25
 M18;
 
 ;END_GCODE_END
-`, cfg.MachineZ+cfg.NormalLayerLiftHeight)
+`, uv3dp.FormatFloat(cfg.MachineZ+cfg.NormalLayerLiftHeight, 2))
 
 	// Create the gcode file
 	fileConfig, err := archive.Create("run.gcode")
@@ -325,8 +376,20 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 
 	fileMap := make(map[string](*zip.File))
 
+	encrypted := false
 	for _, file := range archive.File {
 		fileMap[file.Name] = file
+		if (file.Flags & zipCryptoFlag) != 0 {
+			encrypted = true
+		}
+	}
+
+	if encrypted {
+		if sf.Password == "" {
+			err = errors.New("archive is password protected, and no --password was given")
+			return
+		}
+		zipDecryptor(archive, sf.Password)
 	}
 
 	run, found := fileMap["run.gcode"]
@@ -341,9 +404,19 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 	}
 	defer func() { run_reader.Close() }()
 
+	// Read all of the gcode file first, so that an incorrect
+	// password is reported before any layers are decoded.
+	run_data, err := ioutil.ReadAll(run_reader)
+	if err != nil {
+		if encrypted {
+			err = fmt.Errorf("%s: %w (incorrect --password?)", run.Name, err)
+		}
+		return
+	}
+
 	// Load the gcode file
 	header := czipConfig{}
-	scanner := bufio.NewScanner(run_reader)
+	scanner := bufio.NewScanner(bytes.NewReader(run_data))
 	for scanner.Scan() {
 		line := scanner.Text()
 		ok := header.Unmarshal(line)
@@ -366,9 +439,8 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 		if err != nil {
 			return
 		}
-		defer reader.Close()
-
 		layerPng[n], err = ioutil.ReadAll(reader)
+		reader.Close()
 		if err != nil {
 			return
 		}
@@ -395,7 +467,7 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 		defer func() { reader.Close() }()
 
 		var thumb image.Image
-		thumb, err = png.Decode(reader)
+		thumb, err = uv3dp.DecodePreview(reader)
 		if err != nil {
 			return
 		}
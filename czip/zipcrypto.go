@@ -0,0 +1,150 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package czip
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math/rand"
+)
+
+// Traditional PKWARE zip encryption ('ZipCrypto')
+const (
+	zipCryptoFlag       = 0x1
+	zipCryptoHeaderSize = 12
+)
+
+type zipCrypto struct {
+	keys [3]uint32
+}
+
+func newZipCrypto(password string) (zc *zipCrypto) {
+	zc = &zipCrypto{keys: [3]uint32{0x12345678, 0x23456789, 0x34567890}}
+
+	for _, c := range []byte(password) {
+		zc.update(c)
+	}
+
+	return
+}
+
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ (crc >> 8)
+}
+
+func (zc *zipCrypto) update(b byte) {
+	zc.keys[0] = crc32Update(zc.keys[0], b)
+	zc.keys[1] += zc.keys[0] & 0xff
+	zc.keys[1] = zc.keys[1]*134775813 + 1
+	zc.keys[2] = crc32Update(zc.keys[2], byte(zc.keys[1]>>24))
+}
+
+func (zc *zipCrypto) stream() byte {
+	temp := uint16(zc.keys[2]) | 2
+	return byte((uint32(temp) * uint32(temp^1)) >> 8)
+}
+
+func (zc *zipCrypto) decrypt(buff []byte) {
+	for n, c := range buff {
+		c ^= zc.stream()
+		zc.update(c)
+		buff[n] = c
+	}
+}
+
+func (zc *zipCrypto) encrypt(buff []byte) {
+	for n, c := range buff {
+		buff[n] = c ^ zc.stream()
+		zc.update(c)
+	}
+}
+
+type zipDecryptReader struct {
+	zc     *zipCrypto
+	reader io.Reader
+	header bool
+}
+
+func (dr *zipDecryptReader) Read(buff []byte) (n int, err error) {
+	if !dr.header {
+		// The encryption header can only be verified against the
+		// file's CRC, which the decompressor does not have. A
+		// wrong password is caught by the checksum when the
+		// file is fully read.
+		header := make([]byte, zipCryptoHeaderSize)
+		_, err = io.ReadFull(dr.reader, header)
+		if err != nil {
+			return
+		}
+		dr.zc.decrypt(header)
+		dr.header = true
+	}
+
+	n, err = dr.reader.Read(buff)
+	dr.zc.decrypt(buff[:n])
+
+	return
+}
+
+type zipEncryptWriter struct {
+	zc     *zipCrypto
+	writer io.Writer
+	check  byte
+	header bool
+}
+
+func (ew *zipEncryptWriter) Write(buff []byte) (n int, err error) {
+	if !ew.header {
+		// The compressor is created before the entry's local
+		// file header is written, so delay writing the encryption
+		// header until the first compressed data arrives.
+		header := make([]byte, zipCryptoHeaderSize)
+		rand.Read(header[:zipCryptoHeaderSize-1])
+		header[zipCryptoHeaderSize-1] = ew.check
+		ew.zc.encrypt(header)
+
+		_, err = ew.writer.Write(header)
+		if err != nil {
+			return
+		}
+		ew.header = true
+	}
+
+	data := make([]byte, len(buff))
+	copy(data, buff)
+	ew.zc.encrypt(data)
+
+	return ew.writer.Write(data)
+}
+
+// zipDecryptor registers password decryption for all entries of an archive
+func zipDecryptor(archive *zip.Reader, password string) {
+	archive.RegisterDecompressor(zip.Store, func(r io.Reader) io.ReadCloser {
+		return ioutil.NopCloser(&zipDecryptReader{zc: newZipCrypto(password), reader: r})
+	})
+
+	archive.RegisterDecompressor(zip.Deflate, func(r io.Reader) io.ReadCloser {
+		return flate.NewReader(&zipDecryptReader{zc: newZipCrypto(password), reader: r})
+	})
+}
+
+// zipEncryptor registers password encryption for all deflated entries of
+// an archive. As entries are written with a data descriptor, the header
+// check byte is the high byte of the entries' MS-DOS modification time.
+func zipEncryptor(archive *zip.Writer, password string, modifiedTime uint16) {
+	archive.RegisterCompressor(zip.Deflate, func(w io.Writer) (wc io.WriteCloser, err error) {
+		ew := &zipEncryptWriter{
+			zc:     newZipCrypto(password),
+			writer: w,
+			check:  byte(modifiedTime >> 8),
+		}
+
+		wc, err = flate.NewWriter(ew, flate.DefaultCompression)
+		return
+	})
+}
@@ -0,0 +1,68 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package czip
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestZipCrypto(t *testing.T) {
+	content := []byte(";fileName:\n;machineType:default\n")
+
+	buffWriter := &bytes.Buffer{}
+	archive := zip.NewWriter(buffWriter)
+	zipEncryptor(archive, "secret", 0x1234)
+
+	writer, _ := archive.CreateHeader(&zip.FileHeader{
+		Name:         "run.gcode",
+		Method:       zip.Deflate,
+		Flags:        zipCryptoFlag,
+		ModifiedTime: 0x1234,
+	})
+	writer.Write(content)
+	archive.Close()
+
+	table := []struct {
+		Password string
+		Valid    bool
+	}{
+		{Password: "secret", Valid: true},
+		{Password: "wrong", Valid: false},
+	}
+
+	for _, item := range table {
+		buffReader := bytes.NewReader(buffWriter.Bytes())
+		reader, err := zip.NewReader(buffReader, buffReader.Size())
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+
+		if (reader.File[0].Flags & zipCryptoFlag) == 0 {
+			t.Errorf("expected encrypted entry")
+		}
+
+		zipDecryptor(reader, item.Password)
+
+		var got []byte
+		rc, err := reader.File[0].Open()
+		if err == nil {
+			got, err = ioutil.ReadAll(rc)
+			rc.Close()
+		}
+
+		if item.Valid {
+			if err != nil {
+				t.Errorf("%v: expected nil, got %v", item.Password, err)
+			} else if !bytes.Equal(got, content) {
+				t.Errorf("%v: expected %q, got %q", item.Password, content, got)
+			}
+		} else if err == nil {
+			t.Errorf("%v: expected error, got nil", item.Password)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"fmt"
+)
+
+// DecodeStrictness selects how decoders treat problems with a file
+type DecodeStrictness int
+
+const (
+	DecodeNormal  = DecodeStrictness(iota) // Bad checksums are errors, other issues are warnings
+	DecodeStrict                           // All issues are errors
+	DecodeLenient                          // All issues are warnings
+)
+
+// DecodeIssueKind is a kind of problem found by a decoder
+type DecodeIssueKind int
+
+const (
+	DecodeIssueUnknownField = DecodeIssueKind(iota) // Unknown header field or value type
+	DecodeIssueChecksum                             // Checksum does not match the data
+	DecodeIssueVersion                              // File version is newer than supported
+)
+
+var (
+	decodeStrictness = DecodeNormal
+	decodeWarning    = func(err error) {}
+)
+
+// SetDecodeStrictness selects how all decoders treat problems with a file
+func SetDecodeStrictness(strictness DecodeStrictness) {
+	decodeStrictness = strictness
+}
+
+// SetDecodeWarning sets the function called with the issues that are not errors
+func SetDecodeWarning(warning func(err error)) {
+	if warning == nil {
+		warning = func(err error) {}
+	}
+	decodeWarning = warning
+}
+
+// DecodeIssue is called by decoders when they find a problem with a file.
+// It returns the issue as an error if the decoder must stop, or nil
+// (after reporting the issue as a warning) if the decoder should continue.
+func DecodeIssue(kind DecodeIssueKind, issue error) (err error) {
+	fatal := false
+
+	switch decodeStrictness {
+	case DecodeStrict:
+		fatal = true
+	case DecodeNormal:
+		fatal = (kind == DecodeIssueChecksum)
+	case DecodeLenient:
+		fatal = false
+	}
+
+	if fatal {
+		err = issue
+		return
+	}
+
+	decodeWarning(fmt.Errorf("%w (ignored)", issue))
+
+	return
+}
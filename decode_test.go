@@ -0,0 +1,50 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeIssue(t *testing.T) {
+	defer SetDecodeStrictness(DecodeNormal)
+	defer SetDecodeWarning(nil)
+
+	warnings := 0
+	SetDecodeWarning(func(err error) { warnings++ })
+
+	issue := errors.New("issue")
+
+	table := []struct {
+		Strictness DecodeStrictness
+		Kind       DecodeIssueKind
+		Fatal      bool
+	}{
+		{Strictness: DecodeNormal, Kind: DecodeIssueUnknownField},
+		{Strictness: DecodeNormal, Kind: DecodeIssueChecksum, Fatal: true},
+		{Strictness: DecodeNormal, Kind: DecodeIssueVersion},
+		{Strictness: DecodeStrict, Kind: DecodeIssueUnknownField, Fatal: true},
+		{Strictness: DecodeStrict, Kind: DecodeIssueChecksum, Fatal: true},
+		{Strictness: DecodeStrict, Kind: DecodeIssueVersion, Fatal: true},
+		{Strictness: DecodeLenient, Kind: DecodeIssueUnknownField},
+		{Strictness: DecodeLenient, Kind: DecodeIssueChecksum},
+		{Strictness: DecodeLenient, Kind: DecodeIssueVersion},
+	}
+
+	for n, item := range table {
+		SetDecodeStrictness(item.Strictness)
+
+		warnings = 0
+		err := DecodeIssue(item.Kind, issue)
+		if (err != nil) != item.Fatal {
+			t.Errorf("%v: expected fatal %v, got %v", n, item.Fatal, err)
+		}
+
+		if (warnings == 1) == item.Fatal {
+			t.Errorf("%v: expected fatal %v, got %v warnings", n, item.Fatal, warnings)
+		}
+	}
+}
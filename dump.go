@@ -0,0 +1,200 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Largest unknown region shown in full by DumpWrite
+const dumpUnknownLimit = 256
+
+// DumpField is a field of a file's structure
+type DumpField struct {
+	Name    string
+	Offset  int64
+	Size    int64
+	Value   string // Decoded value, if any
+	Unknown bool   // Field's purpose is not known
+}
+
+// DumpRegion is a region of a file, described by its fields
+type DumpRegion struct {
+	Name   string
+	Offset int64
+	Size   int64
+	Fields []DumpField
+}
+
+// DumpingFormatter is a Formatter that can describe the regions of its files
+type DumpingFormatter interface {
+	DumpRegions(reader Reader, filesize int64) (regions []DumpRegion, err error)
+}
+
+// dumpValue formats a field's value
+func dumpValue(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d (0x%x)", value.Uint(), value.Uint())
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", value.Int())
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'g', -1, value.Type().Bits())
+	default:
+		return fmt.Sprintf("%v", value.Interface())
+	}
+}
+
+// dumpFields describes the fields of a structure at an offset, with
+// the fields of nested structures named by their path (ie 'Def.Size')
+func dumpFields(prefix string, offset int64, value reflect.Value) (fields []DumpField, size int64) {
+	vtype := value.Type()
+
+	for n := 0; n < vtype.NumField(); n++ {
+		structField := vtype.Field(n)
+		fieldValue := value.Field(n)
+		name := prefix + structField.Name
+
+		var fieldSize int64
+		if structField.Type.Kind() == reflect.Struct {
+			var nested []DumpField
+			nested, fieldSize = dumpFields(name+".", offset+size, fieldValue)
+			fields = append(fields, nested...)
+		} else {
+			fieldSize = int64(binary.Size(reflect.Zero(structField.Type).Interface()))
+			field := DumpField{
+				Name:    name,
+				Offset:  offset + size,
+				Size:    fieldSize,
+				Unknown: structField.Name == "_" || strings.HasPrefix(structField.Name, "Unknown"),
+			}
+			if structField.Name != "_" && fieldValue.CanInterface() {
+				field.Value = dumpValue(fieldValue)
+			}
+			fields = append(fields, field)
+		}
+
+		size += fieldSize
+	}
+
+	return
+}
+
+// DumpStruct describes a decoded fixed size structure at an offset of a file.
+// Fields named '_', or starting with 'Unknown', are marked as unknown.
+func DumpStruct(name string, offset int64, value interface{}) (region DumpRegion) {
+	region.Name = name
+	region.Offset = offset
+	region.Fields, region.Size = dumpFields("", offset, reflect.Indirect(reflect.ValueOf(value)))
+
+	return
+}
+
+// dumpHex writes the bytes of a range as lines of up to 16 bytes,
+// with the annotation after the first line.
+func dumpHex(writer io.Writer, data []byte, offset int64, annotation string) {
+	for len(data) > 0 || annotation != "" {
+		line := data
+		if len(line) > 16 {
+			line = line[:16]
+		}
+
+		hex := make([]string, len(line))
+		for n, b := range line {
+			hex[n] = fmt.Sprintf("%02x", b)
+		}
+
+		fmt.Fprintf(writer, "%08x  %-47s  %s\n", offset, strings.Join(hex, " "), annotation)
+
+		data = data[len(line):]
+		offset += int64(len(line))
+		annotation = ""
+	}
+}
+
+// dumpUnknown writes a region that is not described by the file format
+func dumpUnknown(writer io.Writer, reader io.ReaderAt, offset, size int64) (err error) {
+	fmt.Fprintf(writer, "%08x: ** unknown region (%v bytes) **\n", offset, size)
+
+	shown := size
+	if shown > dumpUnknownLimit {
+		shown = dumpUnknownLimit
+	}
+
+	data := make([]byte, shown)
+	_, err = reader.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return
+	}
+	err = nil
+
+	dumpHex(writer, data, offset, "")
+	if shown < size {
+		fmt.Fprintf(writer, "%8s  ... %v more bytes\n", "", size-shown)
+	}
+
+	return
+}
+
+// DumpWrite writes an annotated hexdump of the regions of a file, and
+// of the regions between them that the file format does not describe.
+// Unknown fields are marked with a '?'.
+func DumpWrite(writer io.Writer, reader io.ReaderAt, filesize int64, regions []DumpRegion) (err error) {
+	regions = append([]DumpRegion{}, regions...)
+	sort.SliceStable(regions, func(i, j int) bool { return regions[i].Offset < regions[j].Offset })
+
+	pos := int64(0)
+	for _, region := range regions {
+		if region.Offset > pos {
+			err = dumpUnknown(writer, reader, pos, region.Offset-pos)
+			if err != nil {
+				return
+			}
+		}
+
+		fmt.Fprintf(writer, "%08x: %v (%v bytes)\n", region.Offset, region.Name, region.Size)
+		if len(region.Fields) == 0 {
+			fmt.Fprintf(writer, "%8s  ... not shown\n", "")
+		}
+
+		for _, field := range region.Fields {
+			data := make([]byte, field.Size)
+			_, err = reader.ReadAt(data, field.Offset)
+			if err != nil && err != io.EOF {
+				return
+			}
+			err = nil
+
+			mark := " "
+			if field.Unknown {
+				mark = "?"
+			}
+
+			annotation := fmt.Sprintf("%v %v", mark, field.Name)
+			if field.Value != "" {
+				annotation += " = " + field.Value
+			}
+
+			dumpHex(writer, data, field.Offset, annotation)
+		}
+
+		end := region.Offset + region.Size
+		if end > pos {
+			pos = end
+		}
+	}
+
+	if pos < filesize {
+		err = dumpUnknown(writer, reader, pos, filesize-pos)
+	}
+
+	return
+}
@@ -0,0 +1,76 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type dumpTestHeader struct {
+	Magic  uint32
+	_      [2]uint16
+	Nested struct {
+		Scale float32
+		Flags [2]uint8
+	}
+	Unknown0E uint16
+}
+
+func TestDumpStruct(t *testing.T) {
+	header := dumpTestHeader{Magic: 0x1234, Unknown0E: 7}
+	header.Nested.Scale = 0.25
+
+	region := DumpStruct("header", 0x10, &header)
+
+	if region.Size != 16 {
+		t.Errorf("expected size 16, got %v", region.Size)
+	}
+
+	expected := []DumpField{
+		{Name: "Magic", Offset: 0x10, Size: 4, Value: "4660 (0x1234)"},
+		{Name: "_", Offset: 0x14, Size: 4, Unknown: true},
+		{Name: "Nested.Scale", Offset: 0x18, Size: 4, Value: "0.25"},
+		{Name: "Nested.Flags", Offset: 0x1c, Size: 2, Value: "[0 0]"},
+		{Name: "Unknown0E", Offset: 0x1e, Size: 2, Value: "7 (0x7)", Unknown: true},
+	}
+
+	if len(region.Fields) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, region.Fields)
+	}
+
+	for n, field := range region.Fields {
+		if field != expected[n] {
+			t.Errorf("%v: expected %+v, got %+v", n, expected[n], field)
+		}
+	}
+}
+
+func TestDumpWrite(t *testing.T) {
+	data := make([]byte, 0x40)
+	copy(data[0x10:], []byte{0x34, 0x12, 0, 0})
+
+	region := DumpStruct("header", 0x10, &dumpTestHeader{Magic: 0x1234})
+
+	buff := &bytes.Buffer{}
+	err := DumpWrite(buff, bytes.NewReader(data), int64(len(data)), []DumpRegion{region})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	text := buff.String()
+	for _, expected := range []string{
+		"00000000: ** unknown region (16 bytes) **",
+		"00000010: header (16 bytes)",
+		"00000010  34 12 00 00",
+		"? Unknown0E",
+		"00000020: ** unknown region (32 bytes) **",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected %q in:\n%v", expected, text)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"time"
+)
+
+// PhaseTime is the time spent in each phase of printing layers
+type PhaseTime struct {
+	LightOn  time.Duration // Exposure
+	LightOff time.Duration // Delay before the exposure
+	Lift     time.Duration // Lifting away from the vat
+	Retract  time.Duration // Moving back down to the next layer
+	Wait     time.Duration // Resting before and after the exposure, and after the lift
+}
+
+// Total returns the time of all of the phases
+func (pt *PhaseTime) Total() time.Duration {
+	return pt.LightOn + pt.LightOff + pt.Lift + pt.Retract + pt.Wait
+}
+
+// Add adds the time of each phase of another PhaseTime
+func (pt *PhaseTime) Add(other PhaseTime) {
+	pt.LightOn += other.LightOn
+	pt.LightOff += other.LightOff
+	pt.Lift += other.Lift
+	pt.Retract += other.Retract
+	pt.Wait += other.Wait
+}
+
+// seconds converts seconds to a duration
+func seconds(sec float32) time.Duration {
+	return time.Duration(float64(sec) * float64(time.Second))
+}
+
+// phaseSeconds returns the seconds of each phase of an exposure
+func (exp *Exposure) phaseSeconds() (lightOn, lightOff, lift, retract, wait float32) {
+	lightOn = exp.LightOnTime
+	lightOff = exp.LightOffTime
+	wait = exp.WaitBeforeCure + exp.WaitAfterCure + exp.WaitAfterLift
+
+	// Motion is lift; then retract -> move back to start at retract speed
+	if exp.LiftSpeed > 0 {
+		lift = exp.LiftHeight / exp.LiftSpeed * 60
+	}
+
+	if exp.LiftSpeed2 > 0 && exp.LiftHeight2 > 0 {
+		lift += exp.LiftHeight2 / exp.LiftSpeed2 * 60
+	}
+
+	speed := exp.RetractSpeed
+	distance := exp.LiftHeight + exp.LiftHeight2 + exp.RetractHeight*2
+	if speed <= 0 {
+		speed = exp.LiftSpeed
+		distance = exp.LiftHeight + exp.LiftHeight2
+	}
+
+	if exp.RetractSpeed2 > 0 && exp.RetractHeight2 > 0 && distance > 0 {
+		last := exp.RetractHeight2
+		if last > distance {
+			last = distance
+		}
+		retract = last / exp.RetractSpeed2 * 60
+		distance -= last
+	}
+
+	if speed > 0 && distance > 0 {
+		retract += distance / speed * 60
+	}
+
+	return
+}
+
+// PhaseTime returns the time of each phase of an exposure. The platform
+// lifts by the lift height at the lift speed, and moves back down by the
+// lift height and twice the retract height at the retract speed (or by
+// the lift height at the lift speed, if it has no retract speed), resting
+// for the wait times between the moves.
+//
+// With a two-stage (TSMC) lift, the platform then lifts by the second
+// lift height at the second lift speed, and moves the last part of the
+// retract, the second retract height, at the second retract speed.
+func (exp *Exposure) PhaseTime() (pt PhaseTime) {
+	lightOn, lightOff, lift, retract, wait := exp.phaseSeconds()
+
+	pt.LightOn = seconds(lightOn)
+	pt.LightOff = seconds(lightOff)
+	pt.Lift = seconds(lift)
+	pt.Retract = seconds(retract)
+	pt.Wait = seconds(wait)
+
+	return
+}
+
+// EstimatePrintTime returns the time of each phase of printing all of the
+// layers of a printable, using the exposure of each layer, and the time of
+// the bottom layers alone.
+func EstimatePrintTime(p Printable) (all PhaseTime, bottom PhaseTime) {
+	layers := p.Size().Layers
+	bottomCount := p.Bottom().Count
+
+	for n := 0; n < layers; n++ {
+		exposure := p.LayerExposure(n)
+
+		pt := exposure.PhaseTime()
+		all.Add(pt)
+		if n < bottomCount {
+			bottom.Add(pt)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimatePrintTime(t *testing.T) {
+	prop := Properties{
+		Size: Size{X: 10, Y: 10, Layers: 3, LayerHeight: 0.05},
+		Exposure: Exposure{
+			LightOnTime:   10,
+			LightOffTime:  1,
+			LiftHeight:    6,
+			LiftSpeed:     60,
+			RetractSpeed:  300,
+			WaitAfterLift: 2,
+		},
+		Bottom: Bottom{
+			Count: 1,
+			Exposure: Exposure{
+				LightOnTime:  30,
+				LightOffTime: 1,
+				LiftHeight:   6,
+				LiftSpeed:    60,
+			},
+		},
+	}
+
+	all, bottom := EstimatePrintTime(&Print{Properties: prop})
+
+	ms := func(pt PhaseTime) PhaseTime {
+		return PhaseTime{
+			LightOn:  pt.LightOn.Round(time.Millisecond),
+			LightOff: pt.LightOff.Round(time.Millisecond),
+			Lift:     pt.Lift.Round(time.Millisecond),
+			Retract:  pt.Retract.Round(time.Millisecond),
+			Wait:     pt.Wait.Round(time.Millisecond),
+		}
+	}
+
+	table := map[string]struct {
+		Expected PhaseTime
+		Got      PhaseTime
+	}{
+		// Retracts are 6 mm, at the lift speed for the bottom layer
+		"all": {
+			Expected: PhaseTime{LightOn: 50 * time.Second, LightOff: 3 * time.Second, Lift: 18 * time.Second, Retract: 8400 * time.Millisecond, Wait: 4 * time.Second},
+			Got:      ms(all),
+		},
+		"bottom": {
+			Expected: PhaseTime{LightOn: 30 * time.Second, LightOff: 1 * time.Second, Lift: 6 * time.Second, Retract: 6 * time.Second},
+			Got:      ms(bottom),
+		},
+	}
+
+	for name, item := range table {
+		if item.Expected != item.Got {
+			t.Errorf("%v: expected %+v, got %+v", name, item.Expected, item.Got)
+		}
+	}
+
+	total := all.Total().Round(time.Millisecond)
+	duration := PrintDuration(&Print{Properties: prop}).Round(time.Millisecond)
+	if total != duration {
+		t.Errorf("expected a duration of %v, got %v", total, duration)
+	}
+}
+
+func TestPhaseTimeTSMC(t *testing.T) {
+	exp := Exposure{
+		LiftHeight:     2,
+		LiftSpeed:      60,
+		LiftHeight2:    4,
+		LiftSpeed2:     240,
+		RetractSpeed:   240,
+		RetractHeight2: 2,
+		RetractSpeed2:  60,
+	}
+
+	// Lifts 2 mm in 2s, then 4 mm in 1s. Retracts 4 mm in 1s, then 2 mm in 2s.
+	pt := exp.PhaseTime()
+	if pt.Lift != 3*time.Second || pt.Retract != 3*time.Second {
+		t.Errorf("expected 3s lift and 3s retract, got %v lift and %v retract", pt.Lift, pt.Retract)
+	}
+}
@@ -144,6 +144,9 @@ func NewFormatter(suffix string) (cf *Formatter) {
 
 // Save a uv3dp.Printable in CTB format
 func (cf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err error) {
+	// The printer shows the previews, so render any that are missing
+	printable = uv3dp.WithPreviews(printable)
+
 	if cf.Version < 2 || cf.Version > 3 {
 		err = fmt.Errorf("unsupported version %v", cf.Version)
 		return
@@ -245,8 +248,9 @@ func (cf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err
 		doneMap[n] = make(chan layerInfo, 1)
 	}
 
-	uv3dp.WithAllLayers(printable, func(p uv3dp.Printable, n int) {
-		rle, hash, bitsOn := rleEncodeGraymap(p.LayerImage(n))
+	// The LCD_X_MIRROR projector has its images stored mirrored
+	uv3dp.WithAllLayers(uv3dp.MirrorNormalize(printable, true), func(p uv3dp.Printable, n int) {
+		rle, hash, bitsOn := rleEncodeLayer(p.LayerImage(n))
 		doneMap[n] <- layerInfo{
 			Z:        p.LayerZ(n),
 			Exposure: p.LayerExposure(n),
@@ -570,7 +574,8 @@ func (cf *Formatter) Decode(file uv3dp.Reader, filesize int64) (printable uv3dp.
 		rleMap:    rleMap,
 	}
 
-	printable = fdg
+	// The LCD_X_MIRROR projector has its images stored mirrored
+	printable = uv3dp.MirrorNormalize(fdg, header.Projector == 1)
 
 	return
 }
@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatFloat formats a value with a fixed number of decimal places,
+// for use by the text (ini, gcode) encoders.
+//
+// The value is rounded (half away from zero) from its shortest decimal
+// representation, and not from its binary one, so that a layer height of
+// 0.0125 is always '0.013' on every platform.
+func FormatFloat(value float32, decimals int) (repr string) {
+	if decimals < 0 {
+		decimals = 0
+	}
+
+	shortest := strconv.FormatFloat(float64(value), 'f', -1, 32)
+
+	negative := strings.HasPrefix(shortest, "-")
+	shortest = strings.TrimPrefix(shortest, "-")
+
+	whole := shortest
+	fraction := ""
+	if dot := strings.IndexByte(shortest, '.'); dot >= 0 {
+		whole = shortest[:dot]
+		fraction = shortest[dot+1:]
+	}
+
+	roundUp := false
+	if len(fraction) > decimals {
+		roundUp = fraction[decimals] >= '5'
+		fraction = fraction[:decimals]
+	}
+	fraction += strings.Repeat("0", decimals-len(fraction))
+
+	digits := []byte(whole + fraction)
+	if roundUp {
+		n := len(digits) - 1
+		for ; n >= 0 && digits[n] == '9'; n-- {
+			digits[n] = '0'
+		}
+		if n < 0 {
+			digits = append([]byte{'1'}, digits...)
+		} else {
+			digits[n]++
+		}
+	}
+
+	whole = string(digits[:len(digits)-decimals])
+	fraction = string(digits[len(digits)-decimals:])
+
+	repr = whole
+	if decimals > 0 {
+		repr += "." + fraction
+	}
+
+	// Never emit a negative zero
+	if negative && strings.Trim(repr, "0.") != "" {
+		repr = "-" + repr
+	}
+
+	return
+}
+
+// FormatFloatShort is as FormatFloat, without trailing zeros
+func FormatFloatShort(value float32, decimals int) (repr string) {
+	repr = FormatFloat(value, decimals)
+	if strings.Contains(repr, ".") {
+		repr = strings.TrimRight(strings.TrimRight(repr, "0"), ".")
+	}
+
+	return
+}
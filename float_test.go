@@ -0,0 +1,42 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"testing"
+)
+
+func TestFormatFloat(t *testing.T) {
+	table := []struct {
+		Value    float32
+		Decimals int
+		Repr     string
+		Short    string
+	}{
+		{Value: 0, Decimals: 3, Repr: "0.000", Short: "0"},
+		{Value: 0.05, Decimals: 3, Repr: "0.050", Short: "0.05"},
+		{Value: 0.0125, Decimals: 3, Repr: "0.013", Short: "0.013"},
+		{Value: 1.005, Decimals: 2, Repr: "1.01", Short: "1.01"},
+		{Value: 9.9996, Decimals: 3, Repr: "10.000", Short: "10"},
+		{Value: 16.5, Decimals: 0, Repr: "17", Short: "17"},
+		{Value: 100.52, Decimals: 3, Repr: "100.520", Short: "100.52"},
+		{Value: -2.25, Decimals: 1, Repr: "-2.3", Short: "-2.3"},
+		{Value: -0.0001, Decimals: 2, Repr: "0.00", Short: "0"},
+		{Value: 1e-7, Decimals: 3, Repr: "0.000", Short: "0"},
+		{Value: 123456, Decimals: 1, Repr: "123456.0", Short: "123456"},
+	}
+
+	for _, item := range table {
+		repr := FormatFloat(item.Value, item.Decimals)
+		if repr != item.Repr {
+			t.Errorf("%v (%v): expected '%v', got '%v'", item.Value, item.Decimals, item.Repr, repr)
+		}
+
+		short := FormatFloatShort(item.Value, item.Decimals)
+		if short != item.Short {
+			t.Errorf("%v (%v): expected short '%v', got '%v'", item.Value, item.Decimals, item.Short, short)
+		}
+	}
+}
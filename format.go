@@ -5,8 +5,10 @@
 package uv3dp
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"sort"
 	"strings"
@@ -40,6 +42,9 @@ type NewFormatter func(suffix string) (formatter Formatter)
 
 var formatterMap map[string]NewFormatter
 
+// Formatters excluded from this build, and why
+var formatterMissing map[string]string
+
 func RegisterFormatter(suffix string, newFormatter NewFormatter) {
 	if formatterMap == nil {
 		formatterMap = make(map[string]NewFormatter)
@@ -48,15 +53,51 @@ func RegisterFormatter(suffix string, newFormatter NewFormatter) {
 	formatterMap[suffix] = newFormatter
 }
 
+// RegisterFormatterMissing records a suffix whose formatter was excluded
+// from this build, so that attempts to use it can be clearly reported.
+func RegisterFormatterMissing(suffix string, reason string) {
+	if formatterMissing == nil {
+		formatterMissing = make(map[string]string)
+	}
+
+	formatterMissing[suffix] = reason
+}
+
+// FormattersMissing returns the sorted suffixes of all formatters
+// excluded from this build.
+func FormattersMissing() (list []string) {
+	for suffix := range formatterMissing {
+		list = append(list, suffix)
+	}
+	sort.Strings(list)
+
+	return
+}
+
+// formatMissingError reports a formatter excluded from this build
+func formatMissingError(filename string, suffix string) (err error) {
+	reason, found := formatterMissing[suffix]
+	if found {
+		err = fmt.Errorf("%s: File format '%s' is not included in this build (%s)", filename, suffix, reason)
+	}
+
+	return
+}
+
+// Formatters returns the sorted suffixes of all registered formatters.
+// Only the formats whose packages have been imported are registered.
+func Formatters() (list []string) {
+	for suffix := range formatterMap {
+		list = append(list, suffix)
+	}
+	sort.Strings(list)
+
+	return
+}
+
 func FormatterUsage() {
 	if formatterMap != nil {
-		list := []string{}
-		for suffix := range formatterMap {
-			list = append(list, suffix)
-		}
-		sort.Strings(list)
-
-		for _, suffix := range list {
+		for _, suffix := range Formatters() {
 			newFormatter := formatterMap[suffix]
 			fmt.Fprintln(os.Stderr)
 			fmt.Fprintf(os.Stderr, "Options for '%s':\n", suffix)
@@ -66,6 +107,12 @@ func FormatterUsage() {
 	}
 }
 
+// GeneratingFormatter is implemented by formatters that create their
+// printables from their options, instead of decoding a file.
+type GeneratingFormatter interface {
+	GeneratesPrintable() bool
+}
+
 type Format struct {
 	Formatter
 	Suffix   string
@@ -73,20 +120,68 @@ type Format struct {
 }
 
 func NewFormat(filename string, args []string) (format *Format, err error) {
+	return NewFormatType(filename, "", args)
+}
+
+// FormatterSuffix finds the registered suffix for a file format type,
+// which may be given with or without the leading '.'
+func FormatterSuffix(formatType string) (suffix string, ok bool) {
+	for _, suffix = range []string{formatType, "." + formatType} {
+		_, ok = formatterMap[suffix]
+		if ok {
+			return
+		}
+	}
+
+	suffix = ""
+	return
+}
+
+// NewFormatType creates a format for a file, using the formatter for the
+// file format type (ie 'ctb', or '.ctb') if not empty, otherwise the
+// formatter selected by the filename's extension.
+func NewFormatType(filename string, formatType string, args []string) (format *Format, err error) {
 	var formatter Formatter
 	var suffix string
 	var newFormatter NewFormatter
 
-	for suffix, newFormatter = range formatterMap {
-		if strings.HasSuffix(filename, suffix) {
+	if formatType != "" {
+		var ok bool
+		suffix, ok = FormatterSuffix(formatType)
+		if !ok {
+			for _, missing := range []string{formatType, "." + formatType} {
+				err = formatMissingError(filename, missing)
+				if err != nil {
+					return
+				}
+			}
+			err = fmt.Errorf("%s: File format type '%s' unknown", filename, formatType)
+			return
+		}
+		formatter = formatterMap[suffix](suffix)
+	} else {
+		for suffix, newFormatter = range formatterMap {
+			if strings.HasSuffix(urlPath(filename), suffix) {
 
-			// Get formatter, and parse arguments
-			formatter = newFormatter(suffix)
-			break
+				// Get formatter, and parse arguments
+				formatter = newFormatter(suffix)
+				break
+			}
 		}
 	}
 
+	if formatter == nil && filename == FilenameStdio {
+		err = fmt.Errorf("%s: Standard input and output require a file format type", filename)
+		return
+	}
+
 	if formatter == nil {
+		for missing := range formatterMissing {
+			if strings.HasSuffix(urlPath(filename), missing) {
+				err = formatMissingError(filename, missing)
+				return
+			}
+		}
 		err = fmt.Errorf("%s: File extension unknown", filename)
 		return
 	}
@@ -104,23 +199,47 @@ func NewFormat(filename string, args []string) (format *Format, err error) {
 	return
 }
 
+// Filename used for standard input and output
+const FilenameStdio = "-"
+
+// generates returns true if the format's printable is not read from a file
+func (format *Format) generates() bool {
+	generating, ok := format.Formatter.(GeneratingFormatter)
+	return ok && generating.GeneratesPrintable()
+}
+
 func (format *Format) Printable() (printable Printable, err error) {
-	var reader *os.File
+	var reader Reader
 	var filesize int64
 
-	if format.Suffix != "empty" {
-		reader, err = os.Open(format.Filename)
+	if format.Filename == FilenameStdio || IsURL(format.Filename) {
+		// Decoders need random access, so collect all of the input
+		var data []byte
+		if IsURL(format.Filename) {
+			data, err = readURL(format.Filename)
+		} else {
+			data, err = ioutil.ReadAll(os.Stdin)
+		}
 		if err != nil {
 			return
 		}
-		defer func() { reader.Close() }()
+		reader = bytes.NewReader(data)
+		filesize = int64(len(data))
+	} else if !format.generates() {
+		var file *os.File
+		file, err = os.Open(format.Filename)
+		if err != nil {
+			return
+		}
+		defer func() { file.Close() }()
+		reader = file
 
-		filesize, err = reader.Seek(0, io.SeekEnd)
+		filesize, err = file.Seek(0, io.SeekEnd)
 		if err != nil {
 			return
 		}
 
-		_, err = reader.Seek(0, io.SeekStart)
+		_, err = file.Seek(0, io.SeekStart)
 		if err != nil {
 			return
 		}
@@ -132,11 +251,37 @@ func (format *Format) Printable() (printable Printable, err error) {
 	}
 
 	printable = decoded
+
+	// Annotations from the sidecar file, if the file has none
+	if format.Filename != FilenameStdio && !IsURL(format.Filename) && !format.generates() {
+		_, ok := printable.Metadata(AnnotationsMetadata)
+		if !ok {
+			var annotations Annotations
+			annotations, err = annotationsLoad(format.Filename)
+			if err != nil {
+				return
+			}
+			if len(annotations) > 0 {
+				printable = &AnnotatedPrintable{Printable: printable, Annotations: annotations}
+			}
+		}
+	}
+
 	return
 }
 
 // Write writes a printable to the file format
 func (format *Format) SetPrintable(printable Printable) (err error) {
+	if IsURL(format.Filename) {
+		err = fmt.Errorf("%s: URLs can only be used for input", format.Filename)
+		return
+	}
+
+	if format.Filename == FilenameStdio {
+		err = format.Encode(os.Stdout, printable)
+		return
+	}
+
 	writer, err := os.Create(format.Filename)
 	if err != nil {
 		return
@@ -148,5 +293,11 @@ func (format *Format) SetPrintable(printable Printable) (err error) {
 		return
 	}
 
+	// Annotations go to a sidecar file, if the format can't store them
+	annotating, ok := format.Formatter.(AnnotatingFormatter)
+	if !ok || !annotating.StoresAnnotations() {
+		err = annotationsSave(format.Filename, LayerAnnotations(printable))
+	}
+
 	return
 }
@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+type testFormatter struct {
+	*pflag.FlagSet
+}
+
+func (tf *testFormatter) Decode(reader Reader, size int64) (printable Printable, err error) {
+	return
+}
+
+func (tf *testFormatter) Encode(writer Writer, printable Printable) (err error) {
+	return
+}
+
+func TestNewFormatType(t *testing.T) {
+	for _, suffix := range []string{".test-a", ".test-b"} {
+		RegisterFormatter(suffix, func(suffix string) Formatter {
+			return &testFormatter{FlagSet: pflag.NewFlagSet(suffix, pflag.ContinueOnError)}
+		})
+	}
+
+	table := []struct {
+		Filename   string
+		FormatType string
+		Suffix     string
+		Valid      bool
+	}{
+		{Filename: "foo.test-a", Suffix: ".test-a", Valid: true},
+		{Filename: "foo.test-a", FormatType: "test-b", Suffix: ".test-b", Valid: true},
+		{Filename: "foo.test-a", FormatType: ".test-b", Suffix: ".test-b", Valid: true},
+		{Filename: "foo.unknown", FormatType: "test-a", Suffix: ".test-a", Valid: true},
+		{Filename: "foo.unknown"},
+		{Filename: "foo.test-a", FormatType: "unknown"},
+	}
+
+	for n, item := range table {
+		format, err := NewFormatType(item.Filename, item.FormatType, []string{})
+		if (err == nil) != item.Valid {
+			t.Errorf("%v: expected valid %v, got %v", n, item.Valid, err)
+			continue
+		}
+
+		if item.Valid && format.Suffix != item.Suffix {
+			t.Errorf("%v: expected suffix %v, got %v", n, item.Suffix, format.Suffix)
+		}
+	}
+}
+
+func TestNewFormatMissing(t *testing.T) {
+	RegisterFormatterMissing(".test-missing", "test build tag")
+
+	for _, formatType := range []string{"", "test-missing"} {
+		_, err := NewFormatType("foo.test-missing", formatType, []string{})
+		if err == nil || !strings.Contains(err.Error(), "test build tag") {
+			t.Errorf("%q: expected missing format error, got %v", formatType, err)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+//go:build minimal || uv3dp_no_cbddlp
+// +build minimal uv3dp_no_cbddlp
+
+package formats
+
+import (
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	uv3dp.RegisterFormatterMissing(".cbddlp", "minimal or uv3dp_no_cbddlp build tag")
+	uv3dp.RegisterFormatterMissing(".photon", "minimal or uv3dp_no_cbddlp build tag")
+}
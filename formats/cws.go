@@ -0,0 +1,12 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+//go:build !minimal && !uv3dp_no_cws
+// +build !minimal,!uv3dp_no_cws
+
+package formats
+
+import (
+	_ "github.com/nicarran/uv3dp/cws"
+)
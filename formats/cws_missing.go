@@ -0,0 +1,16 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+//go:build minimal || uv3dp_no_cws
+// +build minimal uv3dp_no_cws
+
+package formats
+
+import (
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	uv3dp.RegisterFormatterMissing(".cws", "minimal or uv3dp_no_cws build tag")
+}
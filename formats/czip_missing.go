@@ -0,0 +1,16 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+//go:build minimal || uv3dp_no_czip
+// +build minimal uv3dp_no_czip
+
+package formats
+
+import (
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	uv3dp.RegisterFormatterMissing(".zip", "minimal or uv3dp_no_czip build tag")
+}
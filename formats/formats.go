@@ -0,0 +1,11 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+// Package formats registers all of the file formats supported by uv3dp.
+//
+// Programs that only need some of the formats should import those
+// format packages directly instead, ie:
+//
+//	import _ "github.com/nicarran/uv3dp/sl1"
+package formats
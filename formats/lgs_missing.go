@@ -0,0 +1,17 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+//go:build minimal || uv3dp_no_lgs
+// +build minimal uv3dp_no_lgs
+
+package formats
+
+import (
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	uv3dp.RegisterFormatterMissing(".lgs", "minimal or uv3dp_no_lgs build tag")
+	uv3dp.RegisterFormatterMissing(".lgs30", "minimal or uv3dp_no_lgs build tag")
+}
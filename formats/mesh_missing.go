@@ -0,0 +1,17 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+//go:build minimal || uv3dp_no_mesh
+// +build minimal uv3dp_no_mesh
+
+package formats
+
+import (
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	uv3dp.RegisterFormatterMissing(".stl", "minimal or uv3dp_no_mesh build tag")
+	uv3dp.RegisterFormatterMissing(".obj", "minimal or uv3dp_no_mesh build tag")
+}
@@ -0,0 +1,12 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+//go:build !minimal && !uv3dp_no_pws
+// +build !minimal,!uv3dp_no_pws
+
+package formats
+
+import (
+	_ "github.com/nicarran/uv3dp/pws"
+)
@@ -0,0 +1,17 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+//go:build minimal || uv3dp_no_pws
+// +build minimal uv3dp_no_pws
+
+package formats
+
+import (
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	uv3dp.RegisterFormatterMissing(".pws", "minimal or uv3dp_no_pws build tag")
+	uv3dp.RegisterFormatterMissing(".pw0", "minimal or uv3dp_no_pws build tag")
+}
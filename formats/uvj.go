@@ -0,0 +1,12 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+//go:build !uv3dp_no_uvj
+// +build !uv3dp_no_uvj
+
+package formats
+
+import (
+	_ "github.com/nicarran/uv3dp/uvj"
+)
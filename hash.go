@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc64"
+)
+
+// HashAlgorithm selects the hash of layers and files
+type HashAlgorithm int
+
+const (
+	HashDefault = HashAlgorithm(iota) // CRC-64 for layers, SHA-256 for files
+	HashCRC64                         // CRC-64 (ECMA)
+	HashXXH64                         // XXH64
+	HashSHA256                        // SHA-256
+)
+
+var hashAlgorithmNames = map[HashAlgorithm]string{
+	HashDefault: "default",
+	HashCRC64:   "crc64",
+	HashXXH64:   "xxhash",
+	HashSHA256:  "sha256",
+}
+
+func (algorithm HashAlgorithm) String() string {
+	name, ok := hashAlgorithmNames[algorithm]
+	if !ok {
+		return fmt.Sprintf("HashAlgorithm(%d)", int(algorithm))
+	}
+
+	return name
+}
+
+// ParseHashAlgorithm returns the algorithm for a name, ie 'xxhash'
+func ParseHashAlgorithm(name string) (algorithm HashAlgorithm, err error) {
+	for algorithm, algorithmName := range hashAlgorithmNames {
+		if algorithmName == name {
+			return algorithm, nil
+		}
+	}
+
+	err = fmt.Errorf("unknown hash algorithm '%v'", name)
+	return
+}
+
+var (
+	hashAlgorithm = HashDefault
+	hashCRC64     = crc64.MakeTable(crc64.ECMA)
+)
+
+// SetHashAlgorithm selects the hash of all layers and files
+func SetHashAlgorithm(algorithm HashAlgorithm) {
+	hashAlgorithm = algorithm
+}
+
+// newHash returns a new hash, using the algorithm if it is not the default
+func newHash(algorithm HashAlgorithm, fallback HashAlgorithm) hash.Hash {
+	if algorithm == HashDefault {
+		algorithm = fallback
+	}
+
+	switch algorithm {
+	case HashXXH64:
+		return newXXHash64()
+	case HashSHA256:
+		return sha256.New()
+	default:
+		return crc64.New(hashCRC64)
+	}
+}
+
+// LayerHash returns the hash of the encoded data of a layer, used by
+// encoders to find identical layers.
+func LayerHash(data []byte) (sum uint64) {
+	if hashAlgorithm == HashDefault || hashAlgorithm == HashCRC64 {
+		return crc64.Checksum(data, hashCRC64)
+	}
+
+	hash := newHash(hashAlgorithm, HashCRC64)
+	hash.Write(data)
+
+	return binary.BigEndian.Uint64(hash.Sum(nil))
+}
+
+// NewFileHash returns a new hash for the contents of files
+func NewFileHash() hash.Hash {
+	return newHash(hashAlgorithm, HashSHA256)
+}
+
+// FileHashString returns the text form of a file hash sum. Sums that are
+// not SHA-256 are prefixed by the name of their algorithm, ie 'xxhash:'.
+func FileHashString(sum []byte) string {
+	text := hex.EncodeToString(sum)
+	if hashAlgorithm != HashDefault && hashAlgorithm != HashSHA256 {
+		text = hashAlgorithm.String() + ":" + text
+	}
+
+	return text
+}
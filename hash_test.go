@@ -0,0 +1,80 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"hash/crc64"
+	"testing"
+)
+
+func TestXXHash64(t *testing.T) {
+	table := []struct {
+		Data string
+		Sum  uint64
+	}{
+		{Data: "", Sum: 0xef46db3751d8e999},
+		{Data: "a", Sum: 0xd24ec4f1a98c6e5b},
+		{Data: "abc", Sum: 0x44bc2cf5ad770999},
+		{Data: "Nobody inspects the spammish repetition", Sum: 0xfbcea83c8a378bf1},
+	}
+
+	for _, item := range table {
+		hash := newXXHash64()
+		hash.Write([]byte(item.Data))
+		if hash.Sum64() != item.Sum {
+			t.Errorf("%q: expected %#x, got %#x", item.Data, item.Sum, hash.Sum64())
+		}
+
+		// Written in pieces
+		hash.Reset()
+		for n := 0; n < len(item.Data); n += 5 {
+			end := n + 5
+			if end > len(item.Data) {
+				end = len(item.Data)
+			}
+			hash.Write([]byte(item.Data[n:end]))
+		}
+		if hash.Sum64() != item.Sum {
+			t.Errorf("%q: expected %#x in pieces, got %#x", item.Data, item.Sum, hash.Sum64())
+		}
+	}
+}
+
+func TestHashAlgorithm(t *testing.T) {
+	defer SetHashAlgorithm(HashDefault)
+
+	data := []byte("layer data")
+
+	if LayerHash(data) != crc64.Checksum(data, crc64.MakeTable(crc64.ECMA)) {
+		t.Errorf("expected CRC-64 layer hashes by default")
+	}
+
+	if NewFileHash().Size() != 32 {
+		t.Errorf("expected SHA-256 file hashes by default")
+	}
+
+	algorithm, err := ParseHashAlgorithm("xxhash")
+	if err != nil || algorithm != HashXXH64 {
+		t.Fatalf("expected xxhash, got %v (%v)", algorithm, err)
+	}
+
+	SetHashAlgorithm(algorithm)
+
+	hash := newXXHash64()
+	hash.Write(data)
+	if LayerHash(data) != hash.Sum64() {
+		t.Errorf("expected XXH64 layer hash of %#x, got %#x", hash.Sum64(), LayerHash(data))
+	}
+
+	text := FileHashString(hash.Sum(nil))
+	if text[:7] != "xxhash:" {
+		t.Errorf("expected 'xxhash:' prefix, got %v", text)
+	}
+
+	_, err = ParseHashAlgorithm("md5")
+	if err == nil {
+		t.Errorf("expected an error for an unknown algorithm")
+	}
+}
@@ -3,4 +3,37 @@
 //
 
 // Package uv3dp is a set of tools for data exchange between UV Resin based 3D printers
+//
+// # API stability
+//
+// The v1 API of this package is stable: it will only change in ways that
+// are compatible with existing programs until a v2 module is released.
+// The v1 API is:
+//
+// Printables: the Printable interface, the Print printable, and its
+// Properties, Size, Exposure, Bottom, and PreviewType types.
+//
+// Format registry: RegisterFormatter, Formatters, FormatterSuffix,
+// NewFormat, NewFormatType, and the Format, Formatter, NewFormatter,
+// Reader, and Writer types. File formats are registered by importing
+// their packages, or the 'formats' package for all of them.
+//
+// Machine registry: RegisterMachine, RegisterMachines, Machines,
+// LookupMachine, and the Machine, MachineFormat, and MachineSize types.
+//
+// Filters: printables that modify another printable, from
+// NewDecimatedPrintable, NewMorphedPrintable, NewPyramidPrintable,
+// and MirrorNormalize. New filters embed a Printable, and override its
+// methods.
+//
+// Layer helpers: WithAllLayers, WithEachLayer, Validate and its
+// validators, and the volume and print time estimates.
+//
+// Process-wide settings are not part of the v1 API, and may change between
+// releases: SetDecodeStrictness, SetDecodeWarning, SetHashAlgorithm,
+// SetLayerStore, and SetMirrorNormalize.
+//
+// Other exports support the file format packages and the uv3dp command,
+// and may change between releases. Exports that are replaced are marked
+// as deprecated, and are kept until v2.
 package uv3dp
@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+)
+
+// Island is a connected area of a layer with no support from the layer below
+type Island struct {
+	Bounds image.Rectangle
+	Area   int           // Area, in pixels
+	Pixels []image.Point // Pixels of the island
+}
+
+// LayerIslands returns the connected areas of a layer (including
+// diagonally connected pixels) that have no lit pixels in the layer below.
+func LayerIslands(below, layer *image.Gray) (islands []Island) {
+	rect := layer.Bounds()
+	dx, dy := rect.Dx(), rect.Dy()
+
+	supported := func(x, y int) bool {
+		if below == nil {
+			return true
+		}
+		return below.Pix[y*below.Stride+x] != 0
+	}
+
+	seen := make([]bool, dx*dy)
+	stack := []image.Point{}
+
+	for y := 0; y < dy; y++ {
+		for x := 0; x < dx; x++ {
+			if seen[y*dx+x] || layer.Pix[y*layer.Stride+x] == 0 {
+				continue
+			}
+
+			// Flood fill the connected area
+			island := Island{}
+			isSupported := false
+
+			seen[y*dx+x] = true
+			stack = append(stack[:0], image.Point{X: x, Y: y})
+			for len(stack) > 0 {
+				pt := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+
+				if supported(pt.X, pt.Y) {
+					isSupported = true
+				}
+
+				// Supported areas are only flood filled to mark them as seen
+				if !isSupported {
+					island.Pixels = append(island.Pixels, pt)
+					island.Bounds = island.Bounds.Union(image.Rect(pt.X, pt.Y, pt.X+1, pt.Y+1))
+				}
+
+				for ny := pt.Y - 1; ny <= pt.Y+1; ny++ {
+					for nx := pt.X - 1; nx <= pt.X+1; nx++ {
+						if nx < 0 || ny < 0 || nx >= dx || ny >= dy {
+							continue
+						}
+						if seen[ny*dx+nx] || layer.Pix[ny*layer.Stride+nx] == 0 {
+							continue
+						}
+						seen[ny*dx+nx] = true
+						stack = append(stack, image.Point{X: nx, Y: ny})
+					}
+				}
+			}
+
+			if !isSupported {
+				island.Area = len(island.Pixels)
+				islands = append(islands, island)
+			}
+		}
+	}
+
+	return
+}
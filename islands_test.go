@@ -0,0 +1,51 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"testing"
+)
+
+var (
+	gm_islands_below = `Below
+XX     
+XX     
+       
+       
+`
+
+	gm_islands_layer = `Layer
+ XX  X 
+  X  X 
+      X
+  X    
+`
+)
+
+func TestLayerIslands(t *testing.T) {
+	below := grayFrom(gm_islands_below)
+	layer := grayFrom(gm_islands_layer)
+
+	islands := LayerIslands(below, layer)
+	if len(islands) != 2 {
+		t.Fatalf("expected 2 islands, got %+v", islands)
+	}
+
+	// Diagonally connected pixels are part of the same island
+	if islands[0].Area != 3 || islands[0].Bounds != image.Rect(5, 0, 7, 3) {
+		t.Errorf("expected 3 pixels at (5,0)-(7,3), got %v at %v", islands[0].Area, islands[0].Bounds)
+	}
+
+	if islands[1].Area != 1 || islands[1].Bounds != image.Rect(2, 3, 3, 4) {
+		t.Errorf("expected 1 pixel at (2,3)-(3,4), got %v at %v", islands[1].Area, islands[1].Bounds)
+	}
+
+	// The first layer is supported by the build plate
+	islands = LayerIslands(nil, layer)
+	if len(islands) != 0 {
+		t.Errorf("expected no islands, got %+v", islands)
+	}
+}
@@ -0,0 +1,124 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// LayerStore is an on-disk store of encoded layers, addressed by the SHA-256
+// of their layer images. It can be shared by many jobs, so that identical
+// layers of similar files are only encoded and stored once.
+type LayerStore struct {
+	Dir string
+}
+
+var layerStore *LayerStore
+
+// SetLayerStore selects the layer store used by encoders, or none if nil
+func SetLayerStore(store *LayerStore) {
+	layerStore = store
+}
+
+// path returns the file of an encoded layer
+func (store *LayerStore) path(key string) string {
+	return filepath.Join(store.Dir, key[:2], key[2:])
+}
+
+// Load returns an encoded layer, if it is in the store
+func (store *LayerStore) Load(key string) (data []byte, ok bool) {
+	data, err := ioutil.ReadFile(store.path(key))
+	if err != nil {
+		return
+	}
+
+	ok = true
+
+	return
+}
+
+// Save adds an encoded layer to the store. The layer is written to a
+// temporary file first, so that other jobs never see a partial layer.
+func (store *LayerStore) Save(key string, data []byte) (err error) {
+	path := store.path(key)
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Close()
+	} else {
+		file.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+	}
+
+	return
+}
+
+// LayerStoreKey returns the key of a layer image, for an encoding kind
+// (ie 'ctb-graymap') that includes all of the options of the encoding.
+// Keys are always SHA-256, whatever the hash algorithm selected by
+// SetHashAlgorithm, so that jobs sharing a store agree on them, and
+// so that different layers never share a key.
+func LayerStoreKey(kind string, img *image.Gray) string {
+	hash := sha256.New()
+	hash.Write([]byte(kind))
+
+	rect := img.Bounds()
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint32(size[0:], uint32(rect.Dx()))
+	binary.LittleEndian.PutUint32(size[4:], uint32(rect.Dy()))
+	hash.Write(size)
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		hash.Write(img.Pix[img.PixOffset(rect.Min.X, y):img.PixOffset(rect.Max.X, y)])
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// LayerStoreEncode returns the encoding of a layer image from the layer
+// store, or encodes it (and adds it to the store). The store is only a
+// cache, so failures to add to it are ignored.
+func LayerStoreEncode(kind string, img *image.Gray, encode func(img *image.Gray) []byte) (data []byte) {
+	store := layerStore
+	if store == nil {
+		data = encode(img)
+		return
+	}
+
+	key := LayerStoreKey(kind, img)
+
+	data, ok := store.Load(key)
+	if ok {
+		return
+	}
+
+	data = encode(img)
+	_ = store.Save(key, data)
+
+	return
+}
@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLayerStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uv3dp")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetLayerStore(&LayerStore{Dir: dir})
+	defer SetLayerStore(nil)
+
+	encodes := 0
+	encode := func(img *image.Gray) []byte {
+		encodes++
+		return append([]byte{}, img.Pix...)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	img.Pix[5] = 0xff
+
+	// Identical layers are only encoded once, even by other jobs
+	for n := 0; n < 3; n++ {
+		data := LayerStoreEncode("test", img, encode)
+		if !bytes.Equal(img.Pix, data) {
+			t.Errorf("%v: expected %v, got %v", n, img.Pix, data)
+		}
+	}
+
+	if encodes != 1 {
+		t.Errorf("expected 1 encoding, got %v", encodes)
+	}
+
+	// Other kinds of encodings, and other images, are not shared
+	LayerStoreEncode("other", img, encode)
+	LayerStoreEncode("test", image.NewGray(image.Rect(0, 0, 4, 4)), encode)
+	if encodes != 3 {
+		t.Errorf("expected 3 encodings, got %v", encodes)
+	}
+
+	// Sub-images have the key of their pixels
+	big := image.NewGray(image.Rect(0, 0, 8, 8))
+	big.Pix[big.PixOffset(2, 2)] = 0xff
+	sub := big.SubImage(image.Rect(1, 1, 5, 5)).(*image.Gray)
+	if LayerStoreKey("test", sub) != LayerStoreKey("test", img) {
+		t.Errorf("expected the sub-image to have the same key")
+	}
+
+	// Keys are SHA-256, whatever the selected hash algorithm
+	key := LayerStoreKey("test", img)
+	for _, algorithm := range []HashAlgorithm{HashCRC64, HashXXH64, HashSHA256} {
+		SetHashAlgorithm(algorithm)
+		got := LayerStoreKey("test", img)
+		if len(got) != 64 || got != key {
+			t.Errorf("%v: expected %v, got %v", algorithm, key, got)
+		}
+	}
+	SetHashAlgorithm(HashDefault)
+}
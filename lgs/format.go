@@ -255,9 +255,17 @@ func (cf *Formatter) Decode(file uv3dp.Reader, filesize int64) (printable uv3dp.
 	sizeX := int(header.PreviewSizeX)
 	sizeY := int(header.PreviewSizeY)
 	previewSize := sizeX * sizeY * 2
+	if offset+previewSize > len(data) {
+		err = fmt.Errorf("preview: %vx%v image is truncated", sizeX, sizeY)
+		return
+	}
 	previewRaw := data[offset : offset+previewSize]
 
-	preview := RGB15Decode(image.Rect(0, 0, sizeX, sizeY), previewRaw)
+	preview, err := RGB15Decode(image.Rect(0, 0, sizeX, sizeY), previewRaw)
+	if err != nil {
+		err = fmt.Errorf("preview: %v", err)
+		return
+	}
 	offset += previewSize
 	previewMap := map[uv3dp.PreviewType]image.Image{
 		uv3dp.PreviewTypeTiny: preview,
@@ -0,0 +1,51 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package lgs
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+func TestDecodePreview(t *testing.T) {
+	printable := uv3dp.NewEmptyPrintable(uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 48, Y: 85, Layers: 2, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 5.544, Y: 9.864},
+		},
+		Preview: map[uv3dp.PreviewType]image.Image{
+			uv3dp.PreviewTypeTiny: image.NewRGBA(image.Rect(0, 0, 16, 8)),
+		},
+	})
+
+	formatter := NewFormatter(".lgs", 10)
+
+	buffer := &bytes.Buffer{}
+	err := formatter.Encode(buffer, printable)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	data := buffer.Bytes()
+	result, err := formatter.Decode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	preview, ok := result.Preview(uv3dp.PreviewTypeTiny)
+	if !ok || preview.Bounds() != image.Rect(0, 0, 16, 8) {
+		t.Errorf("expected a 16x8 preview, got %v", preview)
+	}
+
+	// A file truncated in the preview is an error, not a panic
+	short := data[:0xb4+16]
+	_, err = formatter.Decode(bytes.NewReader(short), int64(len(short)))
+	if err == nil {
+		t.Errorf("expected an error for a truncated preview")
+	}
+}
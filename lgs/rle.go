@@ -8,7 +8,8 @@ package lgs
 import (
 	"fmt"
 	"image"
-	"image/color"
+
+	"github.com/nicarran/uv3dp/preview"
 )
 
 func Rle4Encode(pic *image.Gray) (data []byte, err error) {
@@ -82,38 +83,9 @@ func Rle4Decode(data []byte, bounds image.Rectangle) (gi *image.Gray) {
 }
 
 func RGB15Encode(pic image.Image) (data []byte) {
-	bounds := pic.Bounds()
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			c := color.NRGBAModel.Convert(pic.At(x, y)).(color.NRGBA)
-			rgb15 := (uint16(c.R>>3) << 11) |
-				(uint16(c.G>>3) << 6) |
-				(uint16(c.B>>3) << 0)
-			data = append(data, uint8(rgb15>>8), uint8(rgb15&0xff))
-		}
-	}
-
-	return
+	return preview.EncodeRGB15BE(pic)
 }
 
-func RGB15Decode(bounds image.Rectangle, data []byte) (pic image.Image) {
-	size := bounds.Size()
-
-	preview := image.NewNRGBA(bounds)
-
-	for y := 0; y < size.Y; y++ {
-		for x := 0; x < size.X; x++ {
-			n := (y*size.X + x) * 2
-			rgb15 := (uint16(data[n+0]) << 8) | uint16(data[n+1])
-			r := ((uint8(rgb15>>11) & 0x1f) << 3) | 0x7
-			g := ((uint8(rgb15>>6) & 0x1f) << 3) | 0x7
-			b := ((uint8(rgb15>>0) & 0x1f) << 3) | 0x7
-			preview.Set(x, y, color.NRGBA{r, g, b, 0xff})
-		}
-	}
-
-	pic = preview
-
-	return
+func RGB15Decode(bounds image.Rectangle, data []byte) (pic image.Image, err error) {
+	return preview.DecodeRGB15BE(bounds, data)
 }
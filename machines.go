@@ -5,7 +5,11 @@
 package uv3dp
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 )
 
 type MachineSize struct {
@@ -13,22 +17,108 @@ type MachineSize struct {
 	Xmm, Ymm float32
 }
 
+// FirmwareQuirks are the known limitations of a firmware version
+type FirmwareQuirks struct {
+	BottomPWMFull bool // Bottom layers must use a light PWM of 255
+	NormalPWMFull bool // Normal layers must use a light PWM of 255
+	MaxLayers     int  // Largest number of layers, or 0 for no limit
+	Notes         string
+}
+
+// AgingPoint is the exposure scale needed after some hours of use
+type AgingPoint struct {
+	Hours float32
+	Scale float32
+}
+
+// DefaultAgingCurve is a rough model of the decline of the UV output
+// of an LED array through an LCD mask, for machines with no curve of their own
+var DefaultAgingCurve = []AgingPoint{
+	{Hours: 0, Scale: 1.0},
+	{Hours: 500, Scale: 1.03},
+	{Hours: 1000, Scale: 1.07},
+	{Hours: 2000, Scale: 1.15},
+	{Hours: 4000, Scale: 1.3},
+}
+
+// MachineAging is the use of a machine's light source, and the exposure
+// compensation that it needs as it ages
+type MachineAging struct {
+	Hours float32      // Cumulative light source hours
+	Curve []AgingPoint `json:",omitempty"` // Exposure scale by hours, in increasing order of hours
+}
+
+// Scale returns the exposure scale for the aging's hours, interpolated
+// from its curve (or the DefaultAgingCurve, if it has none). Hours beyond
+// the ends of the curve use the scale at that end.
+func (aging *MachineAging) Scale() float32 {
+	curve := aging.Curve
+	if len(curve) == 0 {
+		curve = DefaultAgingCurve
+	}
+
+	if aging.Hours <= curve[0].Hours {
+		return curve[0].Scale
+	}
+
+	for n := 1; n < len(curve); n++ {
+		lo, hi := curve[n-1], curve[n]
+		if aging.Hours < hi.Hours {
+			return lo.Scale + (hi.Scale-lo.Scale)*(aging.Hours-lo.Hours)/(hi.Hours-lo.Hours)
+		}
+	}
+
+	return curve[len(curve)-1].Scale
+}
+
 type Machine struct {
-	Vendor string
-	Model  string
-	Size   MachineSize
+	Vendor   string
+	Model    string
+	Size     MachineSize
+	Variants map[string]MachineSize    // Alternate firmware resolutions, by name
+	Firmware map[string]FirmwareQuirks // Firmware versions with known quirks
+	Aging    MachineAging              // Light source aging, with the hours of a new machine
+	Vat      float32                   // Capacity of the resin vat in milliliters, or 0 if not known
+	Height   float32                   // Build height in millimeters, or 0 if not known
+	MaxSpeed float32                   // Fastest lift and retract speed in mm/min, or 0 if not known
+	Power    float32                   // Light power at the screen in mW/cm², or 0 if not known
 }
 
 type MachineFormat struct {
 	Machine
 	Extension string
 	Args      []string
+	Origin    string // Where the machine was defined
+}
+
+// Origin of the machines registered by the file formats
+const MachineOriginBuiltIn = "built-in"
+
+// MachineEntry is a machine in a machines JSON file
+type MachineEntry struct {
+	Machine
+	Format string   // File format type, ie 'ctb'
+	Args   []string `json:",omitempty"` // File format arguments
 }
 
 var (
+	// MachineFormats is the registry of machines, by name.
+	//
+	// Deprecated: Use Machines and LookupMachine, which keep the registry
+	// from being modified outside of RegisterMachine.
 	MachineFormats = map[string](*MachineFormat){}
 )
 
+// Machines returns the sorted names of all registered machines
+func Machines() (names []string) {
+	for name := range MachineFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return
+}
+
 func RegisterMachine(name string, machine Machine, extension string, args ...string) (err error) {
 	_, ok := MachineFormats[name]
 	if ok {
@@ -40,6 +130,7 @@ func RegisterMachine(name string, machine Machine, extension string, args ...str
 		Machine:   machine,
 		Extension: extension,
 		Args:      args,
+		Origin:    MachineOriginBuiltIn,
 	}
 
 	MachineFormats[name] = machineFormat
@@ -47,6 +138,60 @@ func RegisterMachine(name string, machine Machine, extension string, args ...str
 	return
 }
 
+// LoadMachines reads a JSON object of machine entries by name, and
+// registers them with an origin. Machines that are already registered
+// are replaced, so that users can correct the built-in machines.
+func LoadMachines(reader io.Reader, origin string) (err error) {
+	entries := map[string]MachineEntry{}
+
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&entries)
+	if err != nil {
+		return
+	}
+
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entry := entries[name]
+		if name == "" || strings.Contains(name, "@") {
+			err = fmt.Errorf("machine '%s': names must not be empty, or contain '@'", name)
+			return
+		}
+
+		size := entry.Size
+		if size.X <= 0 || size.Y <= 0 || size.Xmm <= 0 || size.Ymm <= 0 {
+			err = fmt.Errorf("machine '%s': Size must be greater than 0", name)
+			return
+		}
+
+		if entry.Format == "" {
+			err = fmt.Errorf("machine '%s': Format is missing", name)
+			return
+		}
+
+		extension := entry.Format
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+
+		MachineFormats[name] = &MachineFormat{
+			Machine:   entry.Machine,
+			Extension: extension,
+			Args:      entry.Args,
+			Origin:    origin,
+		}
+	}
+
+	return
+}
+
 func RegisterMachines(machineMap map[string]Machine, extension string, args ...string) (err error) {
 	for name, machine := range machineMap {
 		err = RegisterMachine(name, machine, extension, args...)
@@ -57,3 +202,86 @@ func RegisterMachines(machineMap map[string]Machine, extension string, args ...s
 
 	return
 }
+
+// RegisterMachineVariant adds an alternate resolution to a known machine
+func RegisterMachineVariant(name string, variant string, size MachineSize) (err error) {
+	machineFormat, ok := MachineFormats[name]
+	if !ok {
+		err = fmt.Errorf("machine '%s' is not a known machine type", name)
+		return
+	}
+
+	if machineFormat.Variants == nil {
+		machineFormat.Variants = map[string]MachineSize{}
+	}
+
+	machineFormat.Variants[variant] = size
+
+	return
+}
+
+// RegisterMachineFirmware records the quirks of a firmware version of a machine
+func RegisterMachineFirmware(name string, version string, quirks FirmwareQuirks) (err error) {
+	machineFormat, ok := MachineFormats[name]
+	if !ok {
+		err = fmt.Errorf("machine '%s' is not a known machine type", name)
+		return
+	}
+
+	if machineFormat.Firmware == nil {
+		machineFormat.Firmware = map[string]FirmwareQuirks{}
+	}
+
+	machineFormat.Firmware[version] = quirks
+
+	return
+}
+
+// LookupFirmware returns the quirks of a firmware version of a machine,
+// and false if the version has no known quirks.
+func (machine *Machine) LookupFirmware(version string) (quirks FirmwareQuirks, ok bool) {
+	quirks, ok = machine.Firmware[version]
+	return
+}
+
+// LookupMachine finds a machine by name. A variant of the machine can be
+// selected as 'name@variant', in which case the returned machine's size
+// is that of the variant.
+func LookupMachine(name string) (machineFormat MachineFormat, err error) {
+	parts := strings.SplitN(name, "@", 2)
+
+	found, ok := MachineFormats[parts[0]]
+	if !ok {
+		err = fmt.Errorf("machine '%s' is not a known machine type", parts[0])
+		return
+	}
+
+	machineFormat = *found
+
+	// Copy the maps, so that changes to the returned machine
+	// don't change the registered one
+	if found.Variants != nil {
+		machineFormat.Variants = map[string]MachineSize{}
+		for variant, size := range found.Variants {
+			machineFormat.Variants[variant] = size
+		}
+	}
+
+	if found.Firmware != nil {
+		machineFormat.Firmware = map[string]FirmwareQuirks{}
+		for version, quirks := range found.Firmware {
+			machineFormat.Firmware[version] = quirks
+		}
+	}
+
+	if len(parts) > 1 {
+		size, ok := found.Variants[parts[1]]
+		if !ok {
+			err = fmt.Errorf("machine '%s' has no variant '%s'", parts[0], parts[1])
+			return
+		}
+		machineFormat.Size = size
+	}
+
+	return
+}
@@ -0,0 +1,185 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupMachine(t *testing.T) {
+	native := MachineSize{X: 3840, Y: 2400, Xmm: 192, Ymm: 120}
+	binned := MachineSize{X: 1920, Y: 1200, Xmm: 192, Ymm: 120}
+
+	RegisterMachine("test-variant", Machine{Vendor: "Test", Model: "Variant", Size: native}, ".test")
+	err := RegisterMachineVariant("test-variant", "binned", binned)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	err = RegisterMachineFirmware("test-variant", "2.0", FirmwareQuirks{NormalPWMFull: true})
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	err = RegisterMachineVariant("test-unknown", "binned", binned)
+	if err == nil {
+		t.Errorf("expected error registering a variant of an unknown machine")
+	}
+
+	table := []struct {
+		Name  string
+		Size  MachineSize
+		Valid bool
+	}{
+		{Name: "test-variant", Size: native, Valid: true},
+		{Name: "test-variant@binned", Size: binned, Valid: true},
+		{Name: "test-variant@unknown"},
+		{Name: "test-unknown@binned"},
+	}
+
+	for _, item := range table {
+		machine, err := LookupMachine(item.Name)
+		if (err == nil) != item.Valid {
+			t.Errorf("%v: expected valid %v, got %v", item.Name, item.Valid, err)
+			continue
+		}
+
+		if item.Valid && machine.Size != item.Size {
+			t.Errorf("%v: expected %+v, got %+v", item.Name, item.Size, machine.Size)
+		}
+	}
+
+	// The registered machine is not changed by a variant lookup
+	machine, _ := LookupMachine("test-variant")
+	if machine.Size != native {
+		t.Errorf("expected %+v, got %+v", native, machine.Size)
+	}
+
+	// The registered machine is not changed through the returned maps
+	machine.Variants["binned"] = native
+	machine.Variants["other"] = native
+	machine.Firmware["1.0"] = FirmwareQuirks{MaxLayers: 10}
+	machine.Firmware["2.0"] = FirmwareQuirks{}
+
+	machine, _ = LookupMachine("test-variant@binned")
+	if machine.Size != binned {
+		t.Errorf("expected %+v, got %+v", binned, machine.Size)
+	}
+	if len(machine.Variants) != 1 {
+		t.Errorf("expected 1 variant, got %v", machine.Variants)
+	}
+	if _, ok := machine.LookupFirmware("1.0"); ok {
+		t.Errorf("expected no firmware quirks, got %v", machine.Firmware)
+	}
+	if quirks, _ := machine.LookupFirmware("2.0"); !quirks.NormalPWMFull {
+		t.Errorf("expected the registered firmware quirks, got %+v", quirks)
+	}
+}
+
+func TestLookupFirmware(t *testing.T) {
+	RegisterMachine("test-firmware", Machine{Vendor: "Test", Model: "Firmware"}, ".test")
+
+	quirks := FirmwareQuirks{BottomPWMFull: true}
+	err := RegisterMachineFirmware("test-firmware", "4.4.3", quirks)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	err = RegisterMachineFirmware("test-unknown", "4.4.3", quirks)
+	if err == nil {
+		t.Errorf("expected error registering firmware of an unknown machine")
+	}
+
+	machine, err := LookupMachine("test-firmware")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	got, ok := machine.LookupFirmware("4.4.3")
+	if !ok || got != quirks {
+		t.Errorf("expected %+v, got %+v (%v)", quirks, got, ok)
+	}
+
+	_, ok = machine.LookupFirmware("4.4.4")
+	if ok {
+		t.Errorf("expected no quirks for an unknown version")
+	}
+}
+
+func TestMachineAgingScale(t *testing.T) {
+	curve := []AgingPoint{{Hours: 100, Scale: 1.0}, {Hours: 200, Scale: 1.2}, {Hours: 400, Scale: 1.4}}
+
+	table := []struct {
+		Hours float32
+		Scale float32
+	}{
+		{Hours: 0, Scale: 1.0},
+		{Hours: 100, Scale: 1.0},
+		{Hours: 150, Scale: 1.1},
+		{Hours: 300, Scale: 1.3},
+		{Hours: 1000, Scale: 1.4},
+	}
+
+	for _, item := range table {
+		aging := MachineAging{Hours: item.Hours, Curve: curve}
+		scale := aging.Scale()
+		if scale < item.Scale-0.0001 || scale > item.Scale+0.0001 {
+			t.Errorf("%v hours: expected %v, got %v", item.Hours, item.Scale, scale)
+		}
+	}
+
+	// Machines with no curve use the default curve
+	aging := MachineAging{Hours: 1000}
+	if aging.Scale() != 1.07 {
+		t.Errorf("expected default curve scale 1.07, got %v", aging.Scale())
+	}
+}
+
+func TestLoadMachines(t *testing.T) {
+	data := `{
+  "test-loaded": {
+    "Vendor": "Test", "Model": "Loaded",
+    "Size": {"X": 1920, "Y": 1080, "Xmm": 120, "Ymm": 68},
+    "Format": "ctb", "Args": ["--version", "4"],
+    "MaxSpeed": 300
+  }
+}`
+
+	err := LoadMachines(strings.NewReader(data), "test.json")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	machine, err := LookupMachine("test-loaded")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if machine.Extension != ".ctb" || machine.Origin != "test.json" || machine.MaxSpeed != 300 || len(machine.Args) != 2 {
+		t.Errorf("unexpected machine %+v", machine)
+	}
+
+	RegisterMachine("test-builtin", Machine{Size: MachineSize{X: 1, Y: 1, Xmm: 1, Ymm: 1}}, ".sl1")
+	builtin, _ := LookupMachine("test-builtin")
+	if builtin.Origin != MachineOriginBuiltIn {
+		t.Errorf("expected origin %v, got %v", MachineOriginBuiltIn, builtin.Origin)
+	}
+
+	invalid := map[string]string{
+		"json":    `{"test-bad": `,
+		"field":   `{"test-bad": {"Size": {"X": 1, "Y": 1, "Xmm": 1, "Ymm": 1}, "Format": "ctb", "Colour": 1}}`,
+		"size":    `{"test-bad": {"Size": {"X": 1, "Y": 0, "Xmm": 1, "Ymm": 1}, "Format": "ctb"}}`,
+		"format":  `{"test-bad": {"Size": {"X": 1, "Y": 1, "Xmm": 1, "Ymm": 1}}}`,
+		"variant": `{"test@bad": {"Size": {"X": 1, "Y": 1, "Xmm": 1, "Ymm": 1}, "Format": "ctb"}}`,
+	}
+
+	for name, data := range invalid {
+		err = LoadMachines(strings.NewReader(data), "test.json")
+		if err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
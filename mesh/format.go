@@ -0,0 +1,164 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package mesh
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type Formatter struct {
+	*pflag.FlagSet
+
+	Suffix string
+	Step   int
+}
+
+func NewFormatter(suffix string) (f *Formatter) {
+	flagSet := pflag.NewFlagSet(suffix, pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	f = &Formatter{
+		FlagSet: flagSet,
+		Suffix:  suffix,
+	}
+
+	f.IntVarP(&f.Step, "step", "s", 1, "Pixels between the points of the mesh, in X and Y")
+
+	return
+}
+
+// Normal returns the unit normal of a triangle
+func (tri *Triangle) Normal() (normal Vertex) {
+	var u, v [3]float64
+	for axis := range u {
+		u[axis] = float64(tri[1][axis] - tri[0][axis])
+		v[axis] = float64(tri[2][axis] - tri[0][axis])
+	}
+
+	cross := [3]float64{
+		u[1]*v[2] - u[2]*v[1],
+		u[2]*v[0] - u[0]*v[2],
+		u[0]*v[1] - u[1]*v[0],
+	}
+
+	length := math.Sqrt(cross[0]*cross[0] + cross[1]*cross[1] + cross[2]*cross[2])
+	if length == 0 {
+		return
+	}
+
+	for axis := range normal {
+		normal[axis] = float32(cross[axis] / length)
+	}
+
+	return
+}
+
+// EncodeSTL writes triangles as a binary STL file
+func EncodeSTL(writer uv3dp.Writer, triangles []Triangle) (err error) {
+	buffer := bufio.NewWriter(writer)
+
+	header := make([]byte, 80)
+	copy(header, "uv3dp mesh")
+
+	_, err = buffer.Write(header)
+	if err != nil {
+		return
+	}
+
+	err = binary.Write(buffer, binary.LittleEndian, uint32(len(triangles)))
+	if err != nil {
+		return
+	}
+
+	for _, tri := range triangles {
+		facet := struct {
+			Normal   Vertex
+			Triangle Triangle
+			Attr     uint16
+		}{
+			Normal:   tri.Normal(),
+			Triangle: tri,
+		}
+
+		err = binary.Write(buffer, binary.LittleEndian, &facet)
+		if err != nil {
+			return
+		}
+	}
+
+	err = buffer.Flush()
+
+	return
+}
+
+// EncodeOBJ writes triangles as a Wavefront OBJ file, sharing the
+// vertices of adjacent triangles
+func EncodeOBJ(writer uv3dp.Writer, triangles []Triangle) (err error) {
+	buffer := bufio.NewWriter(writer)
+
+	_, err = fmt.Fprintln(buffer, "# uv3dp mesh")
+	if err != nil {
+		return
+	}
+
+	vertexIndex := map[Vertex]int{}
+	faces := make([][3]int, len(triangles))
+	for n, tri := range triangles {
+		for corner, vertex := range tri {
+			index, ok := vertexIndex[vertex]
+			if !ok {
+				index = len(vertexIndex) + 1
+				vertexIndex[vertex] = index
+				_, err = fmt.Fprintf(buffer, "v %v %v %v\n", vertex[0], vertex[1], vertex[2])
+				if err != nil {
+					return
+				}
+			}
+			faces[n][corner] = index
+		}
+	}
+
+	for _, face := range faces {
+		_, err = fmt.Fprintf(buffer, "f %v %v %v\n", face[0], face[1], face[2])
+		if err != nil {
+			return
+		}
+	}
+
+	err = buffer.Flush()
+
+	return
+}
+
+func (f *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err error) {
+	if f.Step < 1 {
+		err = fmt.Errorf("%v: --step must be at least 1", f.Suffix)
+		return
+	}
+
+	triangles := March(printable, f.Step)
+
+	switch f.Suffix {
+	case ".obj":
+		err = EncodeOBJ(writer, triangles)
+	default:
+		err = EncodeSTL(writer, triangles)
+	}
+
+	return
+}
+
+func (f *Formatter) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.Printable, err error) {
+	err = fmt.Errorf("%v: mesh files can not be sliced into layers", f.Suffix)
+
+	return
+}
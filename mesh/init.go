@@ -0,0 +1,17 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+// Package mesh handles output of triangle meshes (STL and OBJ files) reconstructed from the layers
+package mesh
+
+import (
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	newFormatter := func(suffix string) uv3dp.Formatter { return NewFormatter(suffix) }
+
+	uv3dp.RegisterFormatter(".stl", newFormatter)
+	uv3dp.RegisterFormatter(".obj", newFormatter)
+}
@@ -0,0 +1,278 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package mesh
+
+import (
+	"image"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Vertex is a point of a mesh, in millimeters
+type Vertex [3]float32
+
+// Triangle is a face of a mesh, with its vertices counter-clockwise when
+// seen from outside of the mesh
+type Triangle [3]Vertex
+
+// Gray level of the surface of the model
+const marchIsoLevel = 127.5
+
+// Cube corners, edges, and faces. Corner n is at (n&1, (n>>1)&1, (n>>2)&1).
+var (
+	marchEdges [12][2]int // Corners of each edge
+	marchFaces [6][4]int  // Corners of each face, counter-clockwise from outside
+	marchTable [256][][3]int
+)
+
+func init() {
+	edgeIndex := map[[2]int]int{}
+	edges := 0
+	for p := 0; p < 8; p++ {
+		for _, bit := range []int{1, 2, 4} {
+			if p&bit != 0 {
+				continue
+			}
+			q := p | bit
+			marchEdges[edges] = [2]int{p, q}
+			edgeIndex[[2]int{p, q}] = edges
+			edgeIndex[[2]int{q, p}] = edges
+			edges++
+		}
+	}
+
+	for axis := 0; axis < 3; axis++ {
+		b, c := 1<<uint((axis+1)%3), 1<<uint((axis+2)%3)
+		for side := 0; side < 2; side++ {
+			base := side << uint(axis)
+			face := [4]int{base, base | b, base | b | c, base | c}
+			// (b, c) is counter-clockwise around +axis; reverse the
+			// order for the face on the low side of the axis
+			if side == 0 {
+				face[1], face[3] = face[3], face[1]
+			}
+			marchFaces[axis*2+side] = face
+		}
+	}
+
+	for config := range marchTable {
+		marchTable[config] = marchTriangles(config, edgeIndex)
+	}
+}
+
+// marchTriangles returns the triangles, as cube edges, of the surface
+// through a cube with the inside corners set in config
+func marchTriangles(config int, edgeIndex map[[2]int]int) (triangles [][3]int) {
+	inside := func(corner int) bool { return config&(1<<uint(corner)) != 0 }
+
+	// On each face, the surface enters the inside corners and exits them,
+	// going counter-clockwise. Link the exit of each run of inside corners
+	// back to its entry, which separates the inside corners of ambiguous
+	// faces. Each cube edge is exited on one face, and entered on the
+	// other, so the links form loops around the cube.
+	next := map[int]int{}
+	for _, face := range marchFaces {
+		for k := 0; k < 4; k++ {
+			p, q := face[k], face[(k+1)%4]
+			if !inside(p) || inside(q) {
+				continue
+			}
+
+			for j := 1; j < 4; j++ {
+				r, s := face[(k+4-j)%4], face[(k+5-j)%4]
+				if !inside(r) && inside(s) {
+					next[edgeIndex[[2]int{p, q}]] = edgeIndex[[2]int{r, s}]
+					break
+				}
+			}
+		}
+	}
+
+	for start := 0; start < 12; start++ {
+		_, ok := next[start]
+		if !ok {
+			continue
+		}
+
+		loop := []int{}
+		for edge := start; ; {
+			loop = append(loop, edge)
+			following := next[edge]
+			delete(next, edge)
+			edge = following
+			if edge == start {
+				break
+			}
+		}
+
+		// The loops go clockwise when seen from outside of the surface
+		for n := 1; n+1 < len(loop); n++ {
+			triangles = append(triangles, [3]int{loop[0], loop[n+1], loop[n]})
+		}
+	}
+
+	return
+}
+
+// marchLayer is a layer sampled at the mesh resolution
+type marchLayer struct {
+	pix    []uint8
+	bounds image.Rectangle // Bounds of the lit samples
+}
+
+// March reconstructs the surface of the layers of a printable, sampling
+// a square of step by step pixels for each point of the mesh.
+func March(printable uv3dp.Printable, step int) (triangles []Triangle) {
+	if step < 1 {
+		step = 1
+	}
+
+	size := printable.Size()
+	dx := (size.X + step - 1) / step
+	dy := (size.Y + step - 1) / step
+
+	sampleX := float32(step) * size.Millimeter.X / float32(size.X)
+	sampleY := float32(step) * size.Millimeter.Y / float32(size.Y)
+
+	// Z of the middle of each layer, with a layer above and below the
+	// print mirrored about its top and bottom
+	layerZ := func(index int) float32 {
+		switch {
+		case index < 0:
+			return -layerZMiddle(printable, 0)
+		case index >= size.Layers:
+			return 2*printable.LayerZ(size.Layers-1) - layerZMiddle(printable, size.Layers-1)
+		default:
+			return layerZMiddle(printable, index)
+		}
+	}
+
+	empty := &marchLayer{}
+	lower := empty
+	for index := 0; index <= size.Layers; index++ {
+		upper := empty
+		if index < size.Layers {
+			upper = marchSample(printable.LayerImage(index), step, dx, dy)
+		}
+
+		bounds := lower.bounds.Union(upper.bounds)
+		if !bounds.Empty() {
+			z := [2]float32{layerZ(index - 1), layerZ(index)}
+			layers := [2]*marchLayer{lower, upper}
+
+			sample := func(corner int, x, y int) float32 {
+				layer := layers[corner>>2]
+				x += corner & 1
+				y += (corner >> 1) & 1
+				if layer.pix == nil || x < 0 || y < 0 || x >= dx || y >= dy {
+					return 0
+				}
+				return float32(layer.pix[y*dx+x])
+			}
+
+			// Image rows go down, and the Y of the model goes up
+			corner := func(corner int, x, y int) Vertex {
+				x += corner & 1
+				y += (corner >> 1) & 1
+				return Vertex{
+					(float32(x) + 0.5) * sampleX,
+					size.Millimeter.Y - (float32(y)+0.5)*sampleY,
+					z[corner>>2],
+				}
+			}
+
+			for y := bounds.Min.Y - 1; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X - 1; x < bounds.Max.X; x++ {
+					var value [8]float32
+					config := 0
+					for n := range value {
+						value[n] = sample(n, x, y)
+						if value[n] > marchIsoLevel {
+							config |= 1 << uint(n)
+						}
+					}
+
+					for _, edges := range marchTable[config] {
+						var triangle Triangle
+						for n, edge := range edges {
+							p, q := marchEdges[edge][0], marchEdges[edge][1]
+							t := (marchIsoLevel - value[p]) / (value[q] - value[p])
+							vp, vq := corner(p, x, y), corner(q, x, y)
+							for axis := range triangle[n] {
+								triangle[n][axis] = vp[axis] + t*(vq[axis]-vp[axis])
+							}
+						}
+
+						// Flipping the Y axis reverses the winding
+						triangle[1], triangle[2] = triangle[2], triangle[1]
+						triangles = append(triangles, triangle)
+					}
+				}
+			}
+		}
+
+		lower = upper
+	}
+
+	return
+}
+
+// layerZMiddle returns the Z of the middle of a layer
+func layerZMiddle(printable uv3dp.Printable, index int) float32 {
+	bottom := float32(0)
+	if index > 0 {
+		bottom = printable.LayerZ(index - 1)
+	}
+
+	return (bottom + printable.LayerZ(index)) / 2
+}
+
+// marchSample averages each square of step by step pixels of an image
+func marchSample(img *image.Gray, step int, dx int, dy int) (layer *marchLayer) {
+	bounds := img.Bounds()
+	sum := make([]int, dx*dy)
+	count := make([]int, dx*dy)
+
+	for y := 0; y < bounds.Dy(); y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+bounds.Dx()]
+		for x, pix := range row {
+			n := (y/step)*dx + x/step
+			sum[n] += int(pix)
+			count[n]++
+		}
+	}
+
+	layer = &marchLayer{pix: make([]uint8, dx*dy)}
+
+	minX, minY, maxX, maxY := dx, dy, -1, -1
+	for n := range sum {
+		if count[n] == 0 {
+			continue
+		}
+		value := uint8((sum[n] + count[n]/2) / count[n])
+		layer.pix[n] = value
+		if float32(value) > marchIsoLevel {
+			x, y := n%dx, n/dx
+			if x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+			if y < minY {
+				minY = y
+			}
+			if y > maxY {
+				maxY = y
+			}
+		}
+	}
+
+	if maxX >= 0 {
+		layer.bounds = image.Rect(minX, minY, maxX+1, maxY+1)
+	}
+
+	return
+}
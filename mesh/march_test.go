@@ -0,0 +1,181 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package mesh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// voxelPrint has layers with lit pixels from a function
+type voxelPrint struct {
+	uv3dp.Print
+	lit func(x, y, n int) bool
+}
+
+func (vp *voxelPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(vp.Properties.Bounds())
+	for y := 0; y < vp.Properties.Size.Y; y++ {
+		for x := 0; x < vp.Properties.Size.X; x++ {
+			if vp.lit(x, y, index) {
+				grayImage.Pix[y*grayImage.Stride+x] = 0xff
+			}
+		}
+	}
+
+	return
+}
+
+func newVoxelPrint(lit func(x, y, n int) bool) *voxelPrint {
+	return &voxelPrint{
+		Print: uv3dp.Print{Properties: uv3dp.Properties{
+			Size: uv3dp.Size{
+				X: 20, Y: 20, Layers: 10, LayerHeight: 0.1,
+				Millimeter: uv3dp.SizeMillimeter{X: 2, Y: 2},
+			},
+		}},
+		lit: lit,
+	}
+}
+
+// meshVolume returns the signed volume of a mesh, which is positive if
+// its triangles face outwards
+func meshVolume(triangles []Triangle) (volume float64) {
+	for _, tri := range triangles {
+		a, b, c := tri[0], tri[1], tri[2]
+		volume += float64(a[0])*(float64(b[1])*float64(c[2])-float64(b[2])*float64(c[1])) -
+			float64(a[1])*(float64(b[0])*float64(c[2])-float64(b[2])*float64(c[0])) +
+			float64(a[2])*(float64(b[0])*float64(c[1])-float64(b[1])*float64(c[0]))
+	}
+
+	return volume / 6
+}
+
+// meshOpenEdges returns the number of triangle edges without an opposite
+// edge on another triangle
+func meshOpenEdges(triangles []Triangle) (open int) {
+	edges := map[[2]Vertex]int{}
+	for _, tri := range triangles {
+		for n := range tri {
+			edges[[2]Vertex{tri[n], tri[(n+1)%3]}]++
+		}
+	}
+
+	for edge, count := range edges {
+		if edges[[2]Vertex{edge[1], edge[0]}] != count {
+			open++
+		}
+	}
+
+	return
+}
+
+func TestMarchBox(t *testing.T) {
+	input := newVoxelPrint(func(x, y, n int) bool {
+		return x >= 5 && x < 15 && y >= 5 && y < 10 && n >= 2
+	})
+
+	triangles := March(input, 1)
+	if len(triangles) == 0 {
+		t.Fatalf("expected triangles")
+	}
+
+	if open := meshOpenEdges(triangles); open != 0 {
+		t.Errorf("expected a closed mesh, got %v open edges", open)
+	}
+
+	// 1 x 0.5 x 0.8 mm box, with its edges rounded by the sampling
+	volume := meshVolume(triangles)
+	if volume < 0.37 || volume > 0.4 {
+		t.Errorf("expected a volume of about 0.4 mm^3, got %v", volume)
+	}
+
+	for _, tri := range triangles {
+		for _, vertex := range tri {
+			if vertex[2] < 0.2-0.001 || vertex[2] > 1.0+0.001 {
+				t.Fatalf("expected Z in 0.2..1.0, got %v", vertex[2])
+			}
+			// Image rows go down, and the Y of the model goes up
+			if vertex[1] < 1.0-0.001 || vertex[1] > 1.5+0.001 {
+				t.Fatalf("expected Y in 1.0..1.5, got %v", vertex[1])
+			}
+		}
+	}
+}
+
+func TestMarchClosed(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	voxels := map[[3]int]bool{}
+
+	input := newVoxelPrint(func(x, y, n int) bool {
+		return voxels[[3]int{x, y, n}]
+	})
+
+	for n := 0; n < 2000; n++ {
+		voxels[[3]int{random.Intn(20), random.Intn(20), random.Intn(10)}] = true
+	}
+
+	for _, step := range []int{1, 3} {
+		triangles := March(input, step)
+
+		if open := meshOpenEdges(triangles); open != 0 {
+			t.Errorf("step %v: expected a closed mesh, got %v open edges", step, open)
+		}
+
+		if volume := meshVolume(triangles); volume <= 0 {
+			t.Errorf("step %v: expected a positive volume, got %v", step, volume)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	triangles := []Triangle{
+		{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		{{1, 0, 0}, {1, 1, 0}, {0, 1, 0}},
+	}
+
+	buffer := &bytes.Buffer{}
+	err := EncodeSTL(buffer, triangles)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if buffer.Len() != 84+50*len(triangles) {
+		t.Errorf("expected %v bytes, got %v", 84+50*len(triangles), buffer.Len())
+	}
+
+	count := binary.LittleEndian.Uint32(buffer.Bytes()[80:])
+	if count != 2 {
+		t.Errorf("expected 2 triangles, got %v", count)
+	}
+
+	normal := math.Float32frombits(binary.LittleEndian.Uint32(buffer.Bytes()[92:]))
+	if normal != 1 {
+		t.Errorf("expected a normal Z of 1, got %v", normal)
+	}
+
+	buffer.Reset()
+	err = EncodeOBJ(buffer, triangles)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	expected := "# uv3dp mesh\nv 0 0 0\nv 1 0 0\nv 0 1 0\nv 1 1 0\nf 1 2 3\nf 2 4 3\n"
+	if buffer.String() != expected {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, buffer.String())
+	}
+
+	_, err = NewFormatter(".stl").Decode(nil, 0)
+	if err == nil || !strings.Contains(err.Error(), "can not") {
+		t.Errorf("expected an error decoding a mesh, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+)
+
+// Normalize the orientation of layer images stored mirrored
+var mirrorNormalize = false
+
+// SetMirrorNormalize selects if decoders and encoders normalize the
+// orientation of files that store their layer images mirrored, or keep
+// the images as they are stored (the default).
+func SetMirrorNormalize(normalize bool) {
+	mirrorNormalize = normalize
+}
+
+// MirroredPrintable is a printable with its layer images mirrored
+type MirroredPrintable struct {
+	Printable
+	Horizontal bool // Mirror along the X axis
+	Vertical   bool // Mirror along the Y axis
+}
+
+func (mp *MirroredPrintable) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := mp.Printable.LayerImage(index)
+	bounds := srcImage.Bounds()
+
+	grayImage = image.NewGray(bounds)
+
+	dx := bounds.Dx()
+	dy := bounds.Dy()
+	for y := 0; y < dy; y++ {
+		srcY := y
+		if mp.Vertical {
+			srcY = dy - 1 - y
+		}
+
+		src := srcImage.Pix[srcY*srcImage.Stride : srcY*srcImage.Stride+dx]
+		dst := grayImage.Pix[y*grayImage.Stride : y*grayImage.Stride+dx]
+
+		if mp.Horizontal {
+			for x := 0; x < dx; x++ {
+				dst[x] = src[dx-1-x]
+			}
+		} else {
+			copy(dst, src)
+		}
+	}
+
+	return
+}
+
+// MirrorNormalize is used by decoders and encoders of files whose layer
+// images are stored mirrored along the X axis, as indicated by the file's
+// projector type. When enabled by SetMirrorNormalize, the printable is
+// mirrored, so that all printables have the same orientation.
+func MirrorNormalize(printable Printable, mirrored bool) Printable {
+	if !mirrored || !mirrorNormalize {
+		return printable
+	}
+
+	return &MirroredPrintable{Printable: printable, Horizontal: true}
+}
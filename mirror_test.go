@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type mirrorTestPrint struct {
+	Print
+	img *image.Gray
+}
+
+func (mtp *mirrorTestPrint) LayerImage(index int) *image.Gray {
+	return mtp.img
+}
+
+func TestMirroredPrintable(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	img.Pix = []uint8{
+		1, 2, 3,
+		4, 5, 6,
+	}
+
+	input := &mirrorTestPrint{img: img}
+
+	table := []struct {
+		Horizontal, Vertical bool
+		Pix                  []uint8
+	}{
+		{Pix: []uint8{1, 2, 3, 4, 5, 6}},
+		{Horizontal: true, Pix: []uint8{3, 2, 1, 6, 5, 4}},
+		{Vertical: true, Pix: []uint8{4, 5, 6, 1, 2, 3}},
+		{Horizontal: true, Vertical: true, Pix: []uint8{6, 5, 4, 3, 2, 1}},
+	}
+
+	for n, item := range table {
+		mp := &MirroredPrintable{Printable: input, Horizontal: item.Horizontal, Vertical: item.Vertical}
+		pix := mp.LayerImage(0).Pix
+		if !cmp.Equal(item.Pix, pix) {
+			t.Errorf("%v: expected %v, got %v", n, item.Pix, pix)
+		}
+	}
+}
+
+func TestMirrorNormalize(t *testing.T) {
+	defer SetMirrorNormalize(false)
+
+	input := &mirrorTestPrint{}
+
+	if MirrorNormalize(input, true) != Printable(input) {
+		t.Errorf("expected input to be unchanged when normalization is disabled")
+	}
+
+	SetMirrorNormalize(true)
+	if MirrorNormalize(input, false) != Printable(input) {
+		t.Errorf("expected unmirrored input to be unchanged")
+	}
+
+	mp, ok := MirrorNormalize(input, true).(*MirroredPrintable)
+	if !ok || !mp.Horizontal || mp.Vertical {
+		t.Errorf("expected a horizontally mirrored printable, got %+v", mp)
+	}
+}
@@ -0,0 +1,244 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"fmt"
+	"image"
+)
+
+type MorphOperation int
+
+const (
+	MorphErode  = MorphOperation(iota) // Remove pixels from the edges of islands
+	MorphDilate                        // Add pixels to the edges of islands
+	MorphOpen                          // Erode, then dilate (removes small features)
+	MorphClose                         // Dilate, then erode (fills small holes)
+)
+
+var morphOperationNames = map[MorphOperation]string{
+	MorphErode:  "erode",
+	MorphDilate: "dilate",
+	MorphOpen:   "open",
+	MorphClose:  "close",
+}
+
+func (op MorphOperation) String() string {
+	name, ok := morphOperationNames[op]
+	if !ok {
+		return fmt.Sprintf("MorphOperation(%d)", int(op))
+	}
+
+	return name
+}
+
+// ParseMorphOperation returns the operation for a name, ie 'dilate'
+func ParseMorphOperation(name string) (op MorphOperation, err error) {
+	for op, opName := range morphOperationNames {
+		if opName == name {
+			return op, nil
+		}
+	}
+
+	err = fmt.Errorf("unknown morphology operation '%v'", name)
+	return
+}
+
+// MorphedPrintable applies a morphological operation to a range of layers
+type MorphedPrintable struct {
+	Printable
+	Operation  MorphOperation
+	Radius     int // Radius, in pixels, of the square structuring element
+	Iterations int // Number of times to apply the operation
+	FirstLayer int // First layer to apply the operation to
+	Layers     int // Count of layers to apply the operation to
+}
+
+func NewMorphedPrintable(printable Printable, op MorphOperation) (mp *MorphedPrintable) {
+	mp = &MorphedPrintable{
+		Printable:  printable,
+		Operation:  op,
+		Radius:     1,
+		Iterations: 1,
+		FirstLayer: 0,
+		Layers:     printable.Size().Layers,
+	}
+
+	return
+}
+
+func (mp *MorphedPrintable) LayerImage(index int) (ig *image.Gray) {
+	ig = mp.Printable.LayerImage(index)
+
+	if index < mp.FirstLayer || (index-mp.FirstLayer) >= mp.Layers {
+		return
+	}
+
+	for n := 0; n < mp.Iterations; n++ {
+		ig = MorphImage(ig, mp.Operation, mp.Radius)
+	}
+
+	return
+}
+
+// MorphImage applies a morphological operation to an image
+func MorphImage(ig *image.Gray, op MorphOperation, radius int) *image.Gray {
+	switch op {
+	case MorphErode:
+		ig = morphGray(ig, radius, false)
+	case MorphDilate:
+		ig = morphGray(ig, radius, true)
+	case MorphOpen:
+		ig = morphGray(morphGray(ig, radius, false), radius, true)
+	case MorphClose:
+		ig = morphGray(morphGray(ig, radius, true), radius, false)
+	}
+
+	return ig
+}
+
+// morphRow sets each pixel of out to the min (or max) of the pixels
+// of in that are within the radius. Pixels outside of the row are ignored,
+// so that the edges of the bed are not eroded.
+//
+// The window min (or max) is found from running values within blocks of
+// the window's width (van Herk/Gil-Werman), so the cost does not depend
+// on the radius.
+func morphRow(out []uint8, in []uint8, stride int, count int, radius int, dilate bool) {
+	width := 2*radius + 1
+
+	// A dilation is an erosion of the inverted pixels
+	flip := uint8(0)
+	if dilate {
+		flip = 0xff
+	}
+
+	// Pad the row, so that the ignored pixels never win
+	padded := make([]uint8, count+2*radius)
+	for n := range padded {
+		padded[n] = 0xff
+	}
+	for n := 0; n < count; n++ {
+		padded[radius+n] = in[n*stride] ^ flip
+	}
+
+	// Running minimums from the start, and to the end, of each block
+	head := make([]uint8, len(padded))
+	tail := make([]uint8, len(padded))
+	for start := 0; start < len(padded); start += width {
+		end := start + width
+		if end > len(padded) {
+			end = len(padded)
+		}
+
+		head[start] = padded[start]
+		for n := start + 1; n < end; n++ {
+			pix := padded[n]
+			if head[n-1] < pix {
+				pix = head[n-1]
+			}
+			head[n] = pix
+		}
+
+		tail[end-1] = padded[end-1]
+		for n := end - 2; n >= start; n-- {
+			pix := padded[n]
+			if tail[n+1] < pix {
+				pix = tail[n+1]
+			}
+			tail[n] = pix
+		}
+	}
+
+	// Each window spans the end of one block, and the start of the next
+	for n := 0; n < count; n++ {
+		pix := tail[n]
+		if head[n+width-1] < pix {
+			pix = head[n+width-1]
+		}
+		out[n*stride] = pix ^ flip
+	}
+}
+
+// morphColumns sets each pixel of out to the min (or max) of the pixels
+// of in that are within the radius in its column. The columns are handled
+// together, a row at a time, in the same way as morphRow.
+func morphColumns(out *image.Gray, in *image.Gray, radius int, dilate bool) {
+	rect := in.Bounds()
+	dx, dy := rect.Dx(), rect.Dy()
+	width := 2*radius + 1
+	length := dy + 2*radius
+
+	pad := make([]uint8, dx)
+	if !dilate {
+		for n := range pad {
+			pad[n] = 0xff
+		}
+	}
+
+	padded := func(n int) []uint8 {
+		if n < radius || n >= dy+radius {
+			return pad
+		}
+		return in.Pix[(n-radius)*in.Stride:][:dx]
+	}
+
+	// Running rows from the start, and to the end, of each block
+	head := make([]uint8, length*dx)
+	tail := make([]uint8, length*dx)
+	for n := 0; n < length; n++ {
+		if n%width == 0 {
+			copy(head[n*dx:], padded(n))
+		} else {
+			morphPick(head[n*dx:][:dx], head[(n-1)*dx:][:dx], padded(n), dilate)
+		}
+	}
+	for n := length - 1; n >= 0; n-- {
+		if n%width == width-1 || n == length-1 {
+			copy(tail[n*dx:], padded(n))
+		} else {
+			morphPick(tail[n*dx:][:dx], tail[(n+1)*dx:][:dx], padded(n), dilate)
+		}
+	}
+
+	for n := 0; n < dy; n++ {
+		morphPick(out.Pix[n*out.Stride:][:dx], tail[n*dx:][:dx], head[(n+width-1)*dx:][:dx], dilate)
+	}
+}
+
+// morphPick sets out to the min (or max) of a and b
+func morphPick(out []uint8, a []uint8, b []uint8, dilate bool) {
+	if dilate {
+		for n, pix := range a {
+			if b[n] > pix {
+				pix = b[n]
+			}
+			out[n] = pix
+		}
+	} else {
+		for n, pix := range a {
+			if b[n] < pix {
+				pix = b[n]
+			}
+			out[n] = pix
+		}
+	}
+}
+
+// morphGray erodes (or dilates) an image with a square structuring element
+func morphGray(in *image.Gray, radius int, dilate bool) (out *image.Gray) {
+	rect := in.Bounds()
+	dx, dy := rect.Dx(), rect.Dy()
+
+	rows := image.NewGray(rect)
+	for y := 0; y < dy; y++ {
+		morphRow(rows.Pix[y*rows.Stride:], in.Pix[y*in.Stride:], 1, dx, radius, dilate)
+	}
+
+	out = image.NewGray(rect)
+	morphColumns(out, rows, radius, dilate)
+
+	return
+}
@@ -0,0 +1,156 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"testing"
+)
+
+var (
+	gm_dot = `Dot
+     
+     
+  X  
+     
+     
+`
+
+	gm_dot_dilate = `Dot Dilated
+     
+ XXX 
+ XXX 
+ XXX 
+     
+`
+
+	gm_hole = `Hole
+XXXXX
+XXXXX
+XX XX
+XXXXX
+XXXXX
+`
+
+	gm_empty = `Empty
+     
+     
+     
+     
+     
+`
+
+	gm_full = `Full
+XXXXX
+XXXXX
+XXXXX
+XXXXX
+XXXXX
+`
+)
+
+func TestMorph(t *testing.T) {
+	table := []struct {
+		name string
+		in   string
+		out  string
+		op   MorphOperation
+	}{
+		{name: "erode", in: gm_eye, out: gm_eye_dec, op: MorphErode},
+		{name: "erode-bottom", in: gm_bottom, out: gm_bottom_dec, op: MorphErode},
+		{name: "dilate", in: gm_dot, out: gm_dot_dilate, op: MorphDilate},
+		{name: "open", in: gm_dot, out: gm_empty, op: MorphOpen},
+		{name: "close", in: gm_hole, out: gm_full, op: MorphClose},
+	}
+
+	for _, item := range table {
+		gm_in := grayFrom(item.in)
+		gm_out := grayFrom(item.out)
+
+		val := gm_in
+		switch item.op {
+		case MorphErode:
+			val = morphGray(gm_in, 1, false)
+		case MorphDilate:
+			val = morphGray(gm_in, 1, true)
+		case MorphOpen:
+			val = morphGray(morphGray(gm_in, 1, false), 1, true)
+		case MorphClose:
+			val = morphGray(morphGray(gm_in, 1, true), 1, false)
+		}
+
+		for n := 0; n < len(val.Pix); n++ {
+			if val.Pix[n] != gm_out.Pix[n] {
+				t.Fatalf("%s: %d expected %#v, got %#v", item.name, n, gm_out.Pix[n], val.Pix[n])
+			}
+		}
+	}
+}
+
+func TestParseMorphOperation(t *testing.T) {
+	for _, op := range []MorphOperation{MorphErode, MorphDilate, MorphOpen, MorphClose} {
+		parsed, err := ParseMorphOperation(op.String())
+		if err != nil || parsed != op {
+			t.Errorf("%v: expected %v, got %v (%v)", op, op, parsed, err)
+		}
+	}
+
+	_, err := ParseMorphOperation("melt")
+	if err == nil {
+		t.Errorf("expected an error for an unknown operation")
+	}
+}
+
+func TestMorphRow(t *testing.T) {
+	in := []uint8{0x10, 0xff, 0x30, 0x00, 0x80, 0xff, 0xff, 0x40, 0x20, 0x90, 0x00}
+
+	for radius := 0; radius < len(in)+2; radius++ {
+		for _, dilate := range []bool{false, true} {
+			out := make([]uint8, len(in))
+			morphRow(out, in, 1, len(in), radius, dilate)
+
+			for n := range in {
+				// The min (or max) of the pixels within the radius
+				want := in[n]
+				for m := n - radius; m <= n+radius; m++ {
+					if m < 0 || m >= len(in) {
+						continue
+					}
+					if (dilate && in[m] > want) || (!dilate && in[m] < want) {
+						want = in[m]
+					}
+				}
+
+				if out[n] != want {
+					t.Errorf("radius %v, dilate %v: %v: expected %#x, got %#x", radius, dilate, n, want, out[n])
+				}
+			}
+		}
+	}
+}
+
+func TestMorphColumns(t *testing.T) {
+	in := grayFrom(gm_eye)
+	rect := in.Bounds()
+
+	for radius := 0; radius < rect.Dy()+2; radius++ {
+		for _, dilate := range []bool{false, true} {
+			out := image.NewGray(rect)
+			morphColumns(out, in, radius, dilate)
+
+			// Each column is the same as a row with a stride
+			want := image.NewGray(rect)
+			for x := 0; x < rect.Dx(); x++ {
+				morphRow(want.Pix[x:], in.Pix[x:], in.Stride, rect.Dy(), radius, dilate)
+			}
+
+			for n := range want.Pix {
+				if out.Pix[n] != want.Pix[n] {
+					t.Fatalf("radius %v, dilate %v: %v: expected %#x, got %#x", radius, dilate, n, want.Pix[n], out.Pix[n])
+				}
+			}
+		}
+	}
+}
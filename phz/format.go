@@ -127,6 +127,9 @@ func NewFormatter(suffix string) (pf *Formatter) {
 
 // Save a uv3dp.Printable in CBD DLP format
 func (pf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err error) {
+	// The printer shows the previews, so render any that are missing
+	printable = uv3dp.WithPreviews(printable)
+
 	size := printable.Size()
 	exp := printable.Exposure()
 	bot := printable.Bottom()
@@ -222,8 +225,9 @@ func (pf *Formatter) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err
 		doneMap[n] = make(chan layerInfo, 1)
 	}
 
-	uv3dp.WithAllLayers(printable, func(p uv3dp.Printable, n int) {
-		rle, hash, bitsOn := rleEncodeGraymap(p.LayerImage(n))
+	// The LCD_X_MIRROR projector has its images stored mirrored
+	uv3dp.WithAllLayers(uv3dp.MirrorNormalize(printable, true), func(p uv3dp.Printable, n int) {
+		rle, hash, bitsOn := rleEncodeLayer(p.LayerImage(n))
 		doneMap[n] <- layerInfo{
 			Z:        p.LayerZ(n),
 			Exposure: p.LayerExposure(n),
@@ -513,7 +517,8 @@ func (pf *Formatter) Decode(file uv3dp.Reader, filesize int64) (printable uv3dp.
 		rleMap:   rleMap,
 	}
 
-	printable = phz
+	// The LCD_X_MIRROR projector has its images stored mirrored
+	printable = uv3dp.MirrorNormalize(phz, header.Projector == 1)
 
 	return
 }
@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+
+	// Supported preview input formats
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// DecodePreview decodes a PNG, JPEG, GIF, or BMP preview image
+//
+// The result is always an *image.RGBA, so that each format's preview
+// encoder (RGB565, RLE'd RGB15, PNG, etc) sees the same pixel layout
+// regardless of the source image's color model.
+func DecodePreview(reader io.Reader) (pic image.Image, err error) {
+	src, _, err := image.Decode(reader)
+	if err != nil {
+		return
+	}
+
+	rgba, ok := src.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(src.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
+	}
+
+	pic = rgba
+
+	return
+}
+
+// FitPreview scales a preview image to fit a size, keeping its aspect
+// ratio, and centers it on a black background of that size.
+func FitPreview(src image.Image, size image.Point) (pic *image.RGBA) {
+	pic = image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(pic, pic.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	srcSize := src.Bounds().Size()
+	if srcSize.X <= 0 || srcSize.Y <= 0 {
+		return
+	}
+
+	fit := size
+	if srcSize.X*size.Y > size.X*srcSize.Y {
+		fit.Y = srcSize.Y * size.X / srcSize.X
+	} else {
+		fit.X = srcSize.X * size.Y / srcSize.Y
+	}
+
+	origin := size.Sub(fit).Div(2)
+	rect := image.Rectangle{Min: origin, Max: origin.Add(fit)}
+
+	xdraw.CatmullRom.Scale(pic, rect, src, src.Bounds(), draw.Over, nil)
+
+	return
+}
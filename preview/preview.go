@@ -0,0 +1,271 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+// Package preview handles the pixel encodings used by printable preview images
+package preview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"encoding/binary"
+)
+
+// Encoding is a preview image pixel encoding
+type Encoding int
+
+const (
+	EncodingRGB565  = Encoding(iota) // Little-endian 16-bit RGB, 5:6:5 bits
+	EncodingRGB15BE                  // Big-endian 16-bit RGB, 5:5:5 bits (bit 5 unused)
+	EncodingRLE15                    // Run-length encoded little-endian RGB, 5:5:5 bits (bit 5 is the repeat flag)
+)
+
+const (
+	rle15EncodingLimit = 0xfff
+	repeatRLE15Mask    = uint16(1 << 5)
+)
+
+func (enc Encoding) String() string {
+	switch enc {
+	case EncodingRGB565:
+		return "RGB565"
+	case EncodingRGB15BE:
+		return "RGB15BE"
+	case EncodingRLE15:
+		return "RLE15"
+	default:
+		return fmt.Sprintf("Encoding(%d)", int(enc))
+	}
+}
+
+// Encode a preview image using the selected encoding
+func Encode(enc Encoding, pic image.Image) (data []byte, err error) {
+	switch enc {
+	case EncodingRGB565:
+		data = EncodeRGB565(pic)
+	case EncodingRGB15BE:
+		data = EncodeRGB15BE(pic)
+	case EncodingRLE15:
+		data = EncodeRLE15(pic)
+	default:
+		err = fmt.Errorf("preview: unknown encoding %v", enc)
+	}
+
+	return
+}
+
+// Decode a preview image using the selected encoding
+func Decode(enc Encoding, bounds image.Rectangle, data []byte) (pic *image.RGBA, err error) {
+	switch enc {
+	case EncodingRGB565:
+		pic, err = DecodeRGB565(bounds, data)
+	case EncodingRGB15BE:
+		pic, err = DecodeRGB15BE(bounds, data)
+	case EncodingRLE15:
+		pic, err = DecodeRLE15(bounds, data)
+	default:
+		err = fmt.Errorf("preview: unknown encoding %v", enc)
+	}
+
+	return
+}
+
+func color5to8(c5 uint16) (c8 uint8) {
+	c5 &= 0x1f
+	return uint8((c5 << 3) | (c5 >> 2))
+}
+
+func color6to8(c6 uint16) (c8 uint8) {
+	c6 &= 0x3f
+	return uint8((c6 << 2) | (c6 >> 4))
+}
+
+func color16to5(c16 uint32) (c5 uint16) {
+	return uint16((c16 >> (16 - 5)) & 0x1f)
+}
+
+func color16to6(c16 uint32) (c6 uint16) {
+	return uint16((c16 >> (16 - 6)) & 0x3f)
+}
+
+// rgb15 packs a color into 5:5:5 bits, at bits 11, 6, and 0
+func rgb15(c color.Color) (c15 uint16) {
+	r, g, b, _ := c.RGBA()
+	c15 = color16to5(r)<<11 | color16to5(g)<<6 | color16to5(b)
+	return
+}
+
+func rgb15Color(c15 uint16) color.RGBA {
+	return color.RGBA{
+		R: color5to8(c15 >> 11),
+		G: color5to8(c15 >> 6),
+		B: color5to8(c15 >> 0),
+		A: 0xff,
+	}
+}
+
+func checkSize(bounds image.Rectangle, data []byte) (err error) {
+	size := bounds.Size()
+	if len(data) != size.X*size.Y*2 {
+		err = fmt.Errorf("preview: %vx%v image: expected %v bytes, got %v", size.X, size.Y, size.X*size.Y*2, len(data))
+	}
+
+	return
+}
+
+// EncodeRGB565 encodes an image as little-endian 5:6:5 RGB pixels
+func EncodeRGB565(pic image.Image) (data []byte) {
+	bounds := pic.Bounds()
+	size := bounds.Size()
+
+	data = make([]byte, 0, size.X*size.Y*2)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := pic.At(x, y).RGBA()
+			c16 := color16to5(r)<<11 | color16to6(g)<<5 | color16to5(b)
+			data = append(data, uint8(c16), uint8(c16>>8))
+		}
+	}
+
+	return
+}
+
+// DecodeRGB565 decodes little-endian 5:6:5 RGB pixels
+func DecodeRGB565(bounds image.Rectangle, data []byte) (pic *image.RGBA, err error) {
+	err = checkSize(bounds, data)
+	if err != nil {
+		return
+	}
+
+	pic = image.NewRGBA(bounds)
+	pix := pic.Pix
+	for n := 0; n < len(data); n += 2 {
+		c16 := binary.LittleEndian.Uint16(data[n : n+2])
+		pix[n*2+0] = color5to8(c16 >> 11)
+		pix[n*2+1] = color6to8(c16 >> 5)
+		pix[n*2+2] = color5to8(c16 >> 0)
+		pix[n*2+3] = 0xff
+	}
+
+	return
+}
+
+// EncodeRGB15BE encodes an image as big-endian 5:5:5 RGB pixels
+func EncodeRGB15BE(pic image.Image) (data []byte) {
+	bounds := pic.Bounds()
+	size := bounds.Size()
+
+	data = make([]byte, 0, size.X*size.Y*2)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c15 := rgb15(pic.At(x, y))
+			data = append(data, uint8(c15>>8), uint8(c15))
+		}
+	}
+
+	return
+}
+
+// DecodeRGB15BE decodes big-endian 5:5:5 RGB pixels
+func DecodeRGB15BE(bounds image.Rectangle, data []byte) (pic *image.RGBA, err error) {
+	err = checkSize(bounds, data)
+	if err != nil {
+		return
+	}
+
+	pic = image.NewRGBA(bounds)
+	for n := 0; n < len(data); n += 2 {
+		c := rgb15Color(binary.BigEndian.Uint16(data[n : n+2]))
+		pic.Pix[n*2+0] = c.R
+		pic.Pix[n*2+1] = c.G
+		pic.Pix[n*2+2] = c.B
+		pic.Pix[n*2+3] = c.A
+	}
+
+	return
+}
+
+func rle15(c15 uint16, rep int) (rle []byte) {
+	switch rep {
+	case 0:
+		// pass...
+	case 1:
+		data := [2]byte{}
+		binary.LittleEndian.PutUint16(data[0:2], c15)
+		rle = data[:]
+	case 2:
+		data := [4]byte{}
+		binary.LittleEndian.PutUint16(data[0:2], c15)
+		binary.LittleEndian.PutUint16(data[2:4], c15)
+		rle = data[:]
+	default:
+		data := [4]byte{}
+		binary.LittleEndian.PutUint16(data[0:2], c15|repeatRLE15Mask)
+		binary.LittleEndian.PutUint16(data[2:4], uint16(rep-1)|(0x3000))
+		rle = data[:]
+	}
+
+	return
+}
+
+// EncodeRLE15 run-length encodes an image as ChiTuBox style 5:5:5 RGB pixels
+func EncodeRLE15(pic image.Image) (rle []byte) {
+	bounds := pic.Bounds()
+
+	c15 := uint16(0)
+	rep := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			nc15 := rgb15(pic.At(x, y))
+			if nc15 == c15 {
+				rep++
+				if rep == rle15EncodingLimit {
+					rle = append(rle, rle15(c15, rep)...)
+					rep = 0
+				}
+			} else {
+				rle = append(rle, rle15(c15, rep)...)
+				c15 = nc15
+				rep = 1
+			}
+		}
+	}
+
+	rle = append(rle, rle15(c15, rep)...)
+
+	return
+}
+
+// DecodeRLE15 decodes ChiTuBox style run-length encoded 5:5:5 RGB pixels
+func DecodeRLE15(bounds image.Rectangle, rle []byte) (pic *image.RGBA, err error) {
+	pic = image.NewRGBA(bounds)
+
+	y := bounds.Min.Y
+	x := bounds.Min.X
+	for n := 0; n+2 <= len(rle); n += 2 {
+		c16 := binary.LittleEndian.Uint16(rle[n : n+2])
+		repeat := int(1)
+		if (c16 & repeatRLE15Mask) != 0 {
+			n += 2
+			if n+2 > len(rle) {
+				err = fmt.Errorf("preview: RLE15 data truncated at offset %v", n)
+				return
+			}
+			repeat += int(binary.LittleEndian.Uint16(rle[n:n+2]) & 0xfff)
+		}
+
+		c := rgb15Color(c16)
+		for r := 0; r < repeat; r++ {
+			pic.SetRGBA(x, y, c)
+			x++
+			if x == bounds.Max.X {
+				x = bounds.Min.X
+				y++
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,79 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package preview
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func testImage() (pic *image.RGBA) {
+	pic = image.NewRGBA(image.Rect(0, 0, 4, 3))
+
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			pic.SetRGBA(x, y, color.RGBA{R: 0xff, A: 0xff})
+		}
+	}
+
+	pic.SetRGBA(1, 1, color.RGBA{G: 0xff, A: 0xff})
+	pic.SetRGBA(2, 1, color.RGBA{B: 0xff, A: 0xff})
+	pic.SetRGBA(3, 2, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+
+	return
+}
+
+func TestRoundTrip(t *testing.T) {
+	pic := testImage()
+
+	for _, enc := range []Encoding{EncodingRGB565, EncodingRGB15BE, EncodingRLE15} {
+		data, err := Encode(enc, pic)
+		if err != nil {
+			t.Fatalf("%v: %v", enc, err)
+		}
+
+		out, err := Decode(enc, pic.Bounds(), data)
+		if err != nil {
+			t.Fatalf("%v: %v", enc, err)
+		}
+
+		if !bytes.Equal(pic.Pix, out.Pix) {
+			t.Errorf("%v: expected %#v, got %#v", enc, pic.Pix, out.Pix)
+		}
+	}
+}
+
+func TestEncodeRLE15(t *testing.T) {
+	pic := testImage()
+
+	out_rle := []byte{
+		0x20, 0xf8, 0x04, 0x30, // 5 x red
+		0xc0, 0x07, // 1 x green
+		0x1f, 0x00, // 1 x blue
+		0x20, 0xf8, 0x03, 0x30, // 4 x red
+		0xdf, 0xff, // 1 x white
+	}
+
+	rle := EncodeRLE15(pic)
+	if !bytes.Equal(rle, out_rle) {
+		t.Errorf("expected %#v, got %#v", out_rle, rle)
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	bounds := image.Rect(0, 0, 4, 3)
+
+	_, err := DecodeRLE15(bounds, []byte{0x20, 0xf8})
+	if err == nil {
+		t.Errorf("RLE15: expected error, got nil")
+	}
+
+	_, err = DecodeRGB565(bounds, make([]byte, 4))
+	if err == nil {
+		t.Errorf("RGB565: expected error, got nil")
+	}
+}
@@ -0,0 +1,91 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+
+	"golang.org/x/image/bmp"
+)
+
+func TestDecodePreview(t *testing.T) {
+	bounds := image.Rect(0, 0, 12, 8)
+
+	table := []struct {
+		Name   string
+		Encode func(w io.Writer, m image.Image) error
+	}{
+		{"png", png.Encode},
+		{"jpeg", func(w io.Writer, m image.Image) error { return jpeg.Encode(w, m, nil) }},
+		{"gif", func(w io.Writer, m image.Image) error { return gif.Encode(w, m, nil) }},
+		{"bmp", bmp.Encode},
+	}
+
+	for _, item := range table {
+		buff := &bytes.Buffer{}
+		err := item.Encode(buff, image.NewRGBA(bounds))
+		if err != nil {
+			t.Fatalf("%v: %v", item.Name, err)
+		}
+
+		pic, err := DecodePreview(buff)
+		if err != nil {
+			t.Errorf("%v: expected nil, got %v", item.Name, err)
+			continue
+		}
+
+		_, ok := pic.(*image.RGBA)
+		if !ok {
+			t.Errorf("%v: expected *image.RGBA, got %T", item.Name, pic)
+		}
+
+		if pic.Bounds() != bounds {
+			t.Errorf("%v: expected bounds %v, got %v", item.Name, bounds, pic.Bounds())
+		}
+	}
+}
+
+func TestFitPreview(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 40, 10))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	table := map[string]struct {
+		Size    image.Point
+		Inside  image.Point
+		Outside image.Point
+	}{
+		"letterbox": {Size: image.Pt(20, 20), Inside: image.Pt(10, 10), Outside: image.Pt(10, 2)},
+		"pillarbox": {Size: image.Pt(80, 10), Inside: image.Pt(40, 5), Outside: image.Pt(10, 5)},
+		"exact":     {Size: image.Pt(20, 5), Inside: image.Pt(0, 0), Outside: image.Pt(-1, -1)},
+	}
+
+	for name, item := range table {
+		pic := FitPreview(src, item.Size)
+
+		if pic.Bounds().Size() != item.Size {
+			t.Errorf("%v: expected size %v, got %v", name, item.Size, pic.Bounds().Size())
+		}
+
+		if got := pic.RGBAAt(item.Inside.X, item.Inside.Y); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+			t.Errorf("%v: %v: expected white, got %v", name, item.Inside, got)
+		}
+
+		if item.Outside.X < 0 {
+			continue
+		}
+
+		if got := pic.RGBAAt(item.Outside.X, item.Outside.Y); got != (color.RGBA{0, 0, 0, 0xff}) {
+			t.Errorf("%v: %v: expected black, got %v", name, item.Outside, got)
+		}
+	}
+}
@@ -40,6 +40,11 @@ func WithAllLayers(p Printable, do func(p Printable, n int)) {
 			<-guard
 		}(p, do, n)
 	}
+
+	// Wait for the last layers to finish
+	for n := 0; n < cap(guard); n++ {
+		guard <- struct{}{}
+	}
 }
 
 // WithEachLayer executes a function in over all of the layers, serially (but possibly out of order)
@@ -64,3 +69,53 @@ func PrintDuration(p Printable) (duration time.Duration) {
 
 	return
 }
+
+// ImageBounds returns the smallest rectangle containing all
+// of the non-zero pixels of an image
+func ImageBounds(img *image.Gray) (bounds image.Rectangle) {
+	rect := img.Bounds()
+
+	minX, minY := rect.Dx(), rect.Dy()
+	maxX, maxY := -1, -1
+
+	for y := 0; y < rect.Dy(); y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+rect.Dx()]
+		for x, pix := range row {
+			if pix == 0 {
+				continue
+			}
+			if x < minX {
+				minX = x
+			}
+			if x > maxX {
+				maxX = x
+			}
+			if y < minY {
+				minY = y
+			}
+			maxY = y
+		}
+	}
+
+	if maxX >= 0 {
+		bounds = image.Rect(minX, minY, maxX+1, maxY+1).Add(rect.Min)
+	}
+
+	return
+}
+
+// ModelBounds returns the smallest rectangle containing all
+// of the non-zero pixels of all of the layers
+func ModelBounds(p Printable) (bounds image.Rectangle) {
+	var mutex sync.Mutex
+
+	WithAllLayers(p, func(p Printable, n int) {
+		layerBounds := ImageBounds(p.LayerImage(n))
+
+		mutex.Lock()
+		bounds = bounds.Union(layerBounds)
+		mutex.Unlock()
+	})
+
+	return
+}
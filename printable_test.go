@@ -0,0 +1,26 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithAllLayers(t *testing.T) {
+	empty := NewEmptyPrintable(Properties{Size: Size{X: 10, Y: 10, Layers: 20}})
+
+	var done int32
+	WithAllLayers(empty, func(p Printable, n int) {
+		// Make the last layers finish well after they are started
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		atomic.AddInt32(&done, 1)
+	})
+
+	if atomic.LoadInt32(&done) != 20 {
+		t.Errorf("expected all 20 layers to be done, got %v", done)
+	}
+}
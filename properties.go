@@ -23,32 +23,27 @@ type Size struct {
 
 // Per-layer exposure
 type Exposure struct {
-	LightOnTime   float32 // Exposure time
-	LightOffTime  float32 // Cool down time
-	LightPWM      uint8   `json:",omitempty"` // PWM from 1..255
-	LiftHeight    float32 // mm
-	LiftSpeed     float32 // mm/min
-	RetractHeight float32 `json:",omitempty"` // mm
-	RetractSpeed  float32 `json:",omitempty"` // mm/min
+	LightOnTime    float32 // Exposure time
+	LightOffTime   float32 // Cool down time
+	LightPWM       uint8   `json:",omitempty"` // PWM from 1..255
+	LiftHeight     float32 // mm
+	LiftSpeed      float32 // mm/min
+	RetractHeight  float32 `json:",omitempty"` // mm
+	RetractSpeed   float32 `json:",omitempty"` // mm/min
+	WaitBeforeCure float32 `json:",omitempty"` // Rest before the light is on, in seconds
+	WaitAfterCure  float32 `json:",omitempty"` // Rest before the lift, in seconds
+	WaitAfterLift  float32 `json:",omitempty"` // Rest before the retract, in seconds
+	LiftHeight2    float32 `json:",omitempty"` // Second stage of the lift, in mm
+	LiftSpeed2     float32 `json:",omitempty"` // Second stage of the lift, in mm/min
+	RetractHeight2 float32 `json:",omitempty"` // Last stage of the retract, in mm
+	RetractSpeed2  float32 `json:",omitempty"` // Last stage of the retract, in mm/min
 }
 
 // Total duration of an exposure
 func (exp *Exposure) Duration() (total time.Duration) {
-	totalSec := exp.LightOnTime + exp.LightOffTime
-
-	// Motion is lift; then retract -> move back to start at retract speed
-	if exp.LiftSpeed > 0 {
-		totalSec += exp.LiftHeight / exp.LiftSpeed * 60
-	}
-
-	if exp.RetractSpeed > 0 {
-		totalSec += (exp.LiftHeight + exp.RetractHeight*2) / exp.RetractSpeed * 60
-	} else {
-		if exp.LiftSpeed > 0 {
-			totalSec += exp.LiftHeight / exp.LiftSpeed * 60
-		}
-	}
+	lightOn, lightOff, lift, retract, wait := exp.phaseSeconds()
 
+	totalSec := lightOn + lightOff + lift + retract + wait
 	total = time.Duration(totalSec * float32(time.Second))
 
 	return
@@ -63,6 +58,13 @@ func (exp *Exposure) Interpolate(target Exposure, scale float32) (result Exposur
 	result.LiftSpeed = exp.LiftSpeed + (target.LiftSpeed-exp.LiftSpeed)*scale
 	result.RetractHeight = exp.RetractHeight + (target.RetractHeight-exp.RetractHeight)*scale
 	result.RetractSpeed = exp.RetractSpeed + (target.RetractSpeed-exp.RetractSpeed)*scale
+	result.WaitBeforeCure = exp.WaitBeforeCure + (target.WaitBeforeCure-exp.WaitBeforeCure)*scale
+	result.WaitAfterCure = exp.WaitAfterCure + (target.WaitAfterCure-exp.WaitAfterCure)*scale
+	result.WaitAfterLift = exp.WaitAfterLift + (target.WaitAfterLift-exp.WaitAfterLift)*scale
+	result.LiftHeight2 = exp.LiftHeight2 + (target.LiftHeight2-exp.LiftHeight2)*scale
+	result.LiftSpeed2 = exp.LiftSpeed2 + (target.LiftSpeed2-exp.LiftSpeed2)*scale
+	result.RetractHeight2 = exp.RetractHeight2 + (target.RetractHeight2-exp.RetractHeight2)*scale
+	result.RetractSpeed2 = exp.RetractSpeed2 + (target.RetractSpeed2-exp.RetractSpeed2)*scale
 
 	return
 }
@@ -11,8 +11,11 @@ import (
 	"github.com/go-restruct/restruct"
 	"image"
 	"io/ioutil"
+	"sync/atomic"
+	"time"
 
 	"github.com/nicarran/uv3dp"
+	"github.com/nicarran/uv3dp/preview"
 	"github.com/spf13/pflag"
 	"golang.org/x/image/draw"
 )
@@ -110,7 +113,8 @@ type Header struct {
 	Price             float32
 	ResinType         uint32 // 0x24 ?
 	PerLayerOverride  uint32 // bool
-	_                 [3]uint32
+	PrintTime         uint32 // In seconds
+	_                 [2]uint32
 }
 
 func (header *Header) Marshal(offset uint32) (data []byte, err error) {
@@ -140,72 +144,44 @@ type Preview struct {
 	imageData []byte
 }
 
-func (preview *Preview) Marshal(offset uint32) (data []byte, err error) {
-	data, err = (&Section{Mark: sectionMarkPreview}).Marshal(preview, preview.imageData)
+func (pv *Preview) Marshal(offset uint32) (data []byte, err error) {
+	data, err = (&Section{Mark: sectionMarkPreview}).Marshal(pv, pv.imageData)
 	return
 }
 
-func (preview *Preview) Unmarshal(raw []byte) (err error) {
-	data, err := (&Section{Mark: sectionMarkPreview}).Unmarshal(raw, preview)
+func (pv *Preview) Unmarshal(raw []byte) (err error) {
+	data, err := (&Section{Mark: sectionMarkPreview}).Unmarshal(raw, pv)
 
-	if len(data) != int(2*(preview.Width*preview.Height)) {
+	if len(data) != int(2*(pv.Width*pv.Height)) {
 		err = fmt.Errorf("preview image %vx%v: Expected %d bytes of image, got %v",
-			preview.Width, preview.Height,
-			2*(preview.Width*preview.Height),
+			pv.Width, pv.Height,
+			2*(pv.Width*pv.Height),
 			len(data))
 		return
 	}
 
-	preview.imageData = data
+	pv.imageData = data
 
 	return
 }
 
-func (preview *Preview) GetImage() (preview_image *image.RGBA, err error) {
-	bounds := image.Rect(0, 0, int(preview.Width), int(preview.Height))
-	preview_image = image.NewRGBA(bounds)
-	pix := preview_image.Pix
-	data := preview.imageData
-
-	if len(pix)/4 != len(data)/2 {
-		panic(fmt.Sprintf("expected %v pixels, got %v", len(pix)/4, len(data)/2))
-	}
-
-	for n := 0; n < len(data); n += 2 {
-		color16 := binary.LittleEndian.Uint16(data[n : n+2])
-		r5 := uint8((color16 >> 11) & 0x1f)
-		g5 := uint8((color16 >> 5) & 0x3f)
-		b5 := uint8((color16 >> 0) & 0x1f)
-		pix[n*2+0] = (r5 << 3) | (r5 & 0x7)
-		pix[n*2+1] = (g5 << 2) | (g5 & 0x3)
-		pix[n*2+2] = (b5 << 3) | (b5 & 0x7)
-		pix[n*2+3] = 0xff
-	}
-
+func (pv *Preview) GetImage() (preview_image *image.RGBA, err error) {
+	bounds := image.Rect(0, 0, int(pv.Width), int(pv.Height))
+	preview_image, err = preview.DecodeRGB565(bounds, pv.imageData)
 	return
 }
 
-func (preview *Preview) SetImage(preview_image image.Image) {
-	preview.Width = defaultPreviewWidth
-	preview.Height = defaultPreviewHeight
-	preview.Resolution = 42 // dpi?
+func (pv *Preview) SetImage(preview_image image.Image) {
+	pv.Width = defaultPreviewWidth
+	pv.Height = defaultPreviewHeight
+	pv.Resolution = 42 // dpi?
 
 	// Rescale to the expected preview size
 	newRect := image.Rect(0, 0, defaultPreviewWidth, defaultPreviewHeight)
 	newImage := image.NewRGBA(newRect)
 	draw.NearestNeighbor.Scale(newImage, newRect, preview_image, preview_image.Bounds(), draw.Src, nil)
 
-	pix := newImage.Pix
-	data := make([]byte, newRect.Size().X*newRect.Size().Y*2)
-	for n := 0; n < len(pix); n += 4 {
-		r := pix[n+0] >> 3
-		g := pix[n+1] >> 2
-		b := pix[n+2] >> 3
-		color := (uint16(r) << 11) | (uint16(g) << 5) | (uint16(b) << 0)
-		binary.LittleEndian.PutUint16(data[n/2:], color)
-	}
-
-	preview.imageData = data
+	pv.imageData = preview.EncodeRGB565(newImage)
 }
 
 type SliceFormat int
@@ -237,12 +213,20 @@ func (slice *Slice) SetImage(gray *image.Gray) (err error) {
 	var data []byte
 	switch slice.Format {
 	case SliceFormatPWS:
-		for level := 0; level < slice.AntiAlias; level++ {
-			rle, _, _ := rle1EncodeBitmap(gray, level, slice.AntiAlias)
-			data = append(data, rle...)
-		}
+		kind := fmt.Sprintf("pws-bitmap-%v", slice.AntiAlias)
+		data = uv3dp.LayerStoreEncode(kind, gray, func(gray *image.Gray) (data []byte) {
+			for level := 0; level < slice.AntiAlias; level++ {
+				rle, _, _ := rle1EncodeBitmap(gray, level, slice.AntiAlias)
+				data = append(data, rle...)
+			}
+			return
+		})
 	case SliceFormatPW0:
-		data, err = rle4EncodeBitmaps(gray, slice.AntiAlias)
+		kind := fmt.Sprintf("pw0-graymap-%v", slice.AntiAlias)
+		data = uv3dp.LayerStoreEncode(kind, gray, func(gray *image.Gray) (data []byte) {
+			data, err = rle4EncodeBitmaps(gray, slice.AntiAlias)
+			return
+		})
 		if err != nil {
 			return
 		}
@@ -292,7 +276,10 @@ type Print struct {
 type Format struct {
 	*pflag.FlagSet
 
-	AntiAlias   int // AntiAlias level, one of [1,2,4,8]
+	AntiAlias   int       // AntiAlias level, one of [1,2,4,8]
+	RERF        bool      // Encode as an R_E_R_F exposure test
+	RERFScale   []float32 // Exposure scale of each R_E_R_F region
+	Dedup       bool      // Share the image data of identical layers
 	sliceFormat SliceFormat
 }
 
@@ -311,6 +298,9 @@ func NewFormatter(suffix string) (sf *Format) {
 	}
 
 	sf.IntVarP(&sf.AntiAlias, "anti-alias", "a", 1, "Override antialias level (1,2,4,8)")
+	sf.BoolVarP(&sf.RERF, "rerf", "", false, "Encode an "+RERFName+" exposure test, with a copy of the model in each region of the screen")
+	sf.Float32SliceVarP(&sf.RERFScale, "rerf-scale", "", RERFScale, "Exposure scale of each "+RERFName+" region, as applied by the firmware")
+	sf.BoolVarP(&sf.Dedup, "dedup", "d", true, "Share the image data of identical layers")
 
 	sf.SetInterspersed(false)
 
@@ -318,6 +308,16 @@ func NewFormatter(suffix string) (sf *Format) {
 }
 
 func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err error) {
+	// The printer shows the previews, so render any that are missing
+	printable = uv3dp.WithPreviews(printable)
+
+	if sf.RERF {
+		printable, err = newRERFPrintable(printable, sf.RERFScale)
+		if err != nil {
+			return
+		}
+	}
+
 	size := printable.Size()
 	exposure := printable.Exposure()
 	bottom := printable.Bottom()
@@ -353,6 +353,7 @@ func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err er
 	}
 
 	layers := make([]Layer, size.Layers)
+	lit := int64(0)
 
 	uv3dp.WithAllLayers(printable, func(p uv3dp.Printable, n int) {
 		exposure := p.LayerExposure(n)
@@ -365,11 +366,16 @@ func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err er
 
 		l.slice.AntiAlias = sf.AntiAlias
 		l.slice.Format = sf.sliceFormat
-		l.slice.SetImage(p.LayerImage(n))
+		layerImage := p.LayerImage(n)
+		l.slice.SetImage(layerImage)
+		atomic.AddInt64(&lit, int64(uv3dp.LitPixels(layerImage)))
 
 		layers[n] = l
 	})
 
+	header.Volume = float32(lit) * size.PixelVolume()
+	header.PrintTime = uint32(uv3dp.PrintDuration(printable) / time.Second)
+
 	layerdef := LayerDef{
 		Layers: uint32(len(layers)),
 		Layer:  layers,
@@ -403,13 +409,28 @@ func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err er
 
 	filemark.LayerImageAddr = filemark.LayerDefAddr + uint32(len(layerdefData))
 
-	// Compute the layer offset
+	// Compute the layer offset, sharing the images of identical layers
 	offset := filemark.LayerImageAddr
+	imageHash := map[uint64]int{}
+	imageList := []int{}
 	for n := 0; n < len(layers); n++ {
-		size := uint32(len(layers[n].slice.Data))
+		data := layers[n].slice.Data
+		if sf.Dedup {
+			hash := uv3dp.LayerHash(data)
+			prior, ok := imageHash[hash]
+			if ok && bytes.Equal(layers[prior].slice.Data, data) {
+				layers[n].ImageAddr = layers[prior].ImageAddr
+				layers[n].ImageLength = layers[prior].ImageLength
+				continue
+			}
+			imageHash[hash] = n
+		}
+
+		size := uint32(len(data))
 		layers[n].ImageAddr = offset
 		layers[n].ImageLength = size
 		offset += size
+		imageList = append(imageList, n)
 	}
 	layerdef.Layer = layers
 
@@ -449,8 +470,11 @@ func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err er
 	}
 
 	// Write out layer images
-	for _, layer := range layerdef.Layer {
-		_, err = writer.Write(layer.slice.Data)
+	for _, n := range imageList {
+		_, err = writer.Write(layers[n].slice.Data)
+		if err != nil {
+			return
+		}
 	}
 
 	return
@@ -476,8 +500,10 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 	}
 
 	if filemark.Version != 1 {
-		err = fmt.Errorf("invalid Version %v, exepcted %v", filemark.Version, 1)
-		return
+		err = uv3dp.DecodeIssue(uv3dp.DecodeIssueVersion, fmt.Errorf("invalid Version %v, expected %v", filemark.Version, 1))
+		if err != nil {
+			return
+		}
 	}
 
 	// Extract header
@@ -585,7 +611,7 @@ func (pws *Print) LayerExposure(index int) (exposure uv3dp.Exposure) {
 	return
 }
 
-func (pws *Print) Image(index int) (slice *image.Gray) {
+func (pws *Print) LayerImage(index int) (slice *image.Gray) {
 	slice, err := pws.layers[index].slice.GetImage()
 	if err != nil {
 		panic(fmt.Sprintf("pws: layer %v: %s", index+1, err))
@@ -53,7 +53,7 @@ var (
 		},
 	})
 
-	emptyRawHeader = []byte{0x41, 0x4e, 0x59, 0x43, 0x55, 0x42, 0x49, 0x43, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x30, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x90, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xbc, 0x26, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x50, 0x27, 0x1, 0x0, 0x48, 0x45, 0x41, 0x44, 0x45, 0x52, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x50, 0x0, 0x0, 0x0, 0x0, 0x0, 0xfa, 0x44, 0xcd, 0xcc, 0x4c, 0x3d, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x0, 0x40, 0x0, 0x0, 0xb0, 0x40, 0x0, 0x0, 0x0, 0x40, 0x55, 0x55, 0x55, 0x40, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0xa, 0x0, 0x0, 0x0, 0x14, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x50, 0x52, 0x45, 0x56, 0x49, 0x45, 0x57, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1c, 0x26, 0x1, 0x0, 0xe0, 0x0, 0x0, 0x0, 0x2a, 0x0, 0x0, 0x0, 0xa8, 0x0, 0x0, 0x0,
+	emptyRawHeader = []byte{0x41, 0x4e, 0x59, 0x43, 0x55, 0x42, 0x49, 0x43, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x4, 0x0, 0x0, 0x0, 0x30, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x90, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0xbc, 0x26, 0x1, 0x0, 0x0, 0x0, 0x0, 0x0, 0x50, 0x27, 0x1, 0x0, 0x48, 0x45, 0x41, 0x44, 0x45, 0x52, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x50, 0x0, 0x0, 0x0, 0x0, 0x0, 0xfa, 0x44, 0xcd, 0xcc, 0x4c, 0x3d, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x10, 0x40, 0x0, 0x0, 0x84, 0x41, 0x0, 0x0, 0x0, 0x40, 0x0, 0x0, 0xb0, 0x40, 0x0, 0x0, 0x0, 0x40, 0x55, 0x55, 0x55, 0x40, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0xa, 0x0, 0x0, 0x0, 0x14, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x0, 0x0, 0x0, 0x5c, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x50, 0x52, 0x45, 0x56, 0x49, 0x45, 0x57, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1c, 0x26, 0x1, 0x0, 0xe0, 0x0, 0x0, 0x0, 0x2a, 0x0, 0x0, 0x0, 0xa8, 0x0, 0x0, 0x0,
 		0, 0, 0, 0,
 		0, 0, 0, 0,
 		0, 0, 0, 0,
@@ -98,6 +98,7 @@ func TestEmptyToRaw(t *testing.T) {
 	for _, item := range table {
 		formatter := NewFormatter(item.Format)
 		formatter.AntiAlias = 1
+		formatter.Dedup = false
 
 		buffWriter := &bytes.Buffer{}
 		formatter.Encode(buffWriter, emptyPrintable)
@@ -160,3 +161,38 @@ func TestRawToEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestDedup(t *testing.T) {
+	encoded := map[bool][]byte{}
+	for _, dedup := range []bool{false, true} {
+		formatter := NewFormatter(".pws")
+		formatter.Dedup = dedup
+
+		buffer := &bytes.Buffer{}
+		err := formatter.Encode(buffer, emptyPrintable)
+		if err != nil {
+			t.Fatalf("dedup %v: expected nil, got %v", dedup, err)
+		}
+		encoded[dedup] = buffer.Bytes()
+
+		result, err := formatter.Decode(&bufferMap{Buffer: buffer.Bytes()}, int64(buffer.Len()))
+		if err != nil {
+			t.Fatalf("dedup %v: expected nil, got %v", dedup, err)
+		}
+
+		for n := 0; n < emptyPrintable.Size().Layers; n++ {
+			expected := emptyPrintable.LayerImage(n)
+			got := result.LayerImage(n)
+			if !bytes.Equal(expected.Pix, got.Pix) {
+				t.Errorf("dedup %v: layer %v: image does not match", dedup, n)
+			}
+		}
+	}
+
+	// The three repeated layers have no image data of their own
+	imageSize := 2
+	if len(encoded[false])-len(encoded[true]) != 3*imageSize {
+		t.Errorf("expected %v bytes less with dedup, got %v vs %v bytes",
+			3*imageSize, len(encoded[true]), len(encoded[false]))
+	}
+}
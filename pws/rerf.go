@@ -0,0 +1,141 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package pws
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// RERFName is the filename (without the extension) that the Anycubic
+// firmware prints as an R_E_R_F exposure test.
+const RERFName = "R_E_R_F"
+
+// RERFRegionCount is the number of regions of an R_E_R_F exposure test
+const RERFRegionCount = 8
+
+// RERFScale is the default exposure scale of each R_E_R_F region, which
+// the firmware applies to the normal exposure time of the file.
+var RERFScale = []float32{1.00, 1.25, 1.50, 1.75, 2.00, 2.25, 2.50, 2.75}
+
+// RERFRegions divides a screen into the R_E_R_F regions, as two columns
+// along the long side of the screen, numbered left to right, top to bottom.
+func RERFRegions(size uv3dp.Size) (regions []image.Rectangle) {
+	columns, rows := 2, RERFRegionCount/2
+	if size.X > size.Y {
+		columns, rows = rows, columns
+	}
+
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			regions = append(regions, image.Rect(
+				size.X*column/columns, size.Y*row/rows,
+				size.X*(column+1)/columns, size.Y*(row+1)/rows,
+			))
+		}
+	}
+
+	return
+}
+
+// RERFExposure returns the normal exposure time of the file that brackets
+// an exposure time with the regions: the mean of the region exposures
+// is the exposure time.
+func RERFExposure(lightOnTime float32, scale []float32) (base float32) {
+	mean := float32(0)
+	for _, value := range scale {
+		mean += value
+	}
+	mean /= float32(len(scale))
+
+	base = lightOnTime / mean
+
+	return
+}
+
+// rerfPrintable places a copy of the model in each R_E_R_F region
+type rerfPrintable struct {
+	uv3dp.Printable
+
+	bounds  image.Rectangle
+	offsets []image.Point
+	normal  uv3dp.Exposure
+}
+
+func newRERFPrintable(printable uv3dp.Printable, scale []float32) (rp *rerfPrintable, err error) {
+	if len(scale) != RERFRegionCount {
+		err = fmt.Errorf("%v: expected %v region scales, got %v", RERFName, RERFRegionCount, len(scale))
+		return
+	}
+
+	for _, value := range scale {
+		if value <= 0 {
+			err = fmt.Errorf("%v: region scales must be greater than 0", RERFName)
+			return
+		}
+	}
+
+	size := printable.Size()
+	bounds := uv3dp.ModelBounds(printable)
+	if bounds.Empty() {
+		err = fmt.Errorf("%v: no model to copy to the regions", RERFName)
+		return
+	}
+
+	rp = &rerfPrintable{
+		Printable: printable,
+		bounds:    bounds,
+		normal:    printable.Exposure(),
+	}
+
+	for _, region := range RERFRegions(size) {
+		if bounds.Dx() > region.Dx() || bounds.Dy() > region.Dy() {
+			err = fmt.Errorf("%v: model of %vx%v pixels does not fit in the regions of %vx%v pixels",
+				RERFName, bounds.Dx(), bounds.Dy(), region.Dx(), region.Dy())
+			return
+		}
+
+		offset := image.Point{
+			X: region.Min.X + (region.Dx()-bounds.Dx())/2 - bounds.Min.X,
+			Y: region.Min.Y + (region.Dy()-bounds.Dy())/2 - bounds.Min.Y,
+		}
+		rp.offsets = append(rp.offsets, offset)
+	}
+
+	rp.normal.LightOnTime = RERFExposure(rp.normal.LightOnTime, scale)
+
+	return
+}
+
+func (rp *rerfPrintable) Exposure() (exposure uv3dp.Exposure) {
+	return rp.normal
+}
+
+// LayerExposure uses the same exposure for all normal layers, as the
+// firmware scales the normal exposure time of the file for each region.
+func (rp *rerfPrintable) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	if index < rp.Bottom().Count {
+		exposure = rp.Printable.LayerExposure(index)
+		return
+	}
+
+	exposure = rp.normal
+
+	return
+}
+
+func (rp *rerfPrintable) LayerImage(index int) (grayImage *image.Gray) {
+	srcImage := rp.Printable.LayerImage(index)
+
+	grayImage = image.NewGray(srcImage.Bounds())
+	for _, offset := range rp.offsets {
+		draw.Draw(grayImage, rp.bounds.Add(offset), srcImage, rp.bounds.Min, draw.Src)
+	}
+
+	return
+}
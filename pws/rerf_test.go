@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package pws
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// modelPrint has a square model on all layers
+type modelPrint struct {
+	uv3dp.Print
+	model image.Rectangle
+}
+
+func (mp *modelPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(mp.Bounds())
+	for y := mp.model.Min.Y; y < mp.model.Max.Y; y++ {
+		for x := mp.model.Min.X; x < mp.model.Max.X; x++ {
+			grayImage.Pix[y*grayImage.Stride+x] = 255
+		}
+	}
+
+	return
+}
+
+func TestRERFRegions(t *testing.T) {
+	regions := RERFRegions(uv3dp.Size{X: 10, Y: 20})
+	if len(regions) != RERFRegionCount {
+		t.Fatalf("expected %v regions, got %v", RERFRegionCount, len(regions))
+	}
+
+	expected := []image.Rectangle{image.Rect(0, 0, 5, 5), image.Rect(5, 0, 10, 5), image.Rect(5, 15, 10, 20)}
+	for n, region := range []image.Rectangle{regions[0], regions[1], regions[7]} {
+		if region != expected[n] {
+			t.Errorf("expected %v, got %v", expected[n], region)
+		}
+	}
+
+	regions = RERFRegions(uv3dp.Size{X: 20, Y: 10})
+	if regions[3] != image.Rect(15, 0, 20, 5) {
+		t.Errorf("landscape: expected %v, got %v", image.Rect(15, 0, 20, 5), regions[3])
+	}
+}
+
+func TestRERFEncode(t *testing.T) {
+	prop := emptyPrintable.Properties
+	input := &modelPrint{Print: uv3dp.Print{Properties: prop}, model: image.Rect(0, 0, 3, 2)}
+
+	formatter := NewFormatter(".pws")
+	err := formatter.Parse([]string{"--rerf"})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	buffWriter := &bytes.Buffer{}
+	err = formatter.Encode(buffWriter, input)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	raw := buffWriter.Bytes()
+	result, err := formatter.Decode(&bufferMap{Buffer: raw}, int64(len(raw)))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	base := float32(16.5 / 1.875)
+	if result.LayerExposure(3).LightOnTime != base {
+		t.Errorf("expected normal exposure of %v, got %v", base, result.LayerExposure(3).LightOnTime)
+	}
+	if result.LayerExposure(0).LightOnTime != 16.5 {
+		t.Errorf("expected bottom exposure of %v, got %v", 16.5, result.LayerExposure(0).LightOnTime)
+	}
+
+	layerImage := result.LayerImage(2)
+	if uv3dp.LitPixels(layerImage) != RERFRegionCount*6 {
+		t.Errorf("expected %v lit pixels, got %v", RERFRegionCount*6, uv3dp.LitPixels(layerImage))
+	}
+	if uv3dp.ImageBounds(layerImage) != image.Rect(1, 1, 9, 18) {
+		t.Errorf("expected copies within %v, got %v", image.Rect(1, 1, 9, 18), uv3dp.ImageBounds(layerImage))
+	}
+
+	input.model = image.Rect(0, 0, 6, 2)
+	err = formatter.Encode(&bytes.Buffer{}, input)
+	if err == nil {
+		t.Errorf("expected an error for a model larger than the regions")
+	}
+}
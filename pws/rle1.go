@@ -9,21 +9,15 @@ import (
 	"image"
 	"image/color"
 
-	"hash/crc64"
+	"github.com/nicarran/uv3dp"
 )
 
 const (
 	rle1EncodingLimit = 0x7d // Yah, I know. Feels weird. But required.
 )
 
-var tab64 *crc64.Table
-
 func hash64(data []byte) (hash uint64) {
-	if tab64 == nil {
-		tab64 = crc64.MakeTable(crc64.ECMA)
-	}
-
-	hash = crc64.Checksum(data, tab64)
+	hash = uv3dp.LayerHash(data)
 	return
 }
 
@@ -9,6 +9,8 @@ import (
 	"image"
 
 	"encoding/binary"
+
+	"github.com/nicarran/uv3dp"
 )
 
 const (
@@ -132,8 +134,10 @@ func rle4DecodeInto(pix []uint8, rle []byte, mask uint8) (data []byte, err error
 	check := binary.BigEndian.Uint16(data)
 
 	if check != expect {
-		err = fmt.Errorf("checksum expected %04x, got %04x", expect, check)
-		return
+		err = uv3dp.DecodeIssue(uv3dp.DecodeIssueChecksum, fmt.Errorf("checksum expected %04x, got %04x", expect, check))
+		if err != nil {
+			return
+		}
 	}
 
 	data = data[2:]
@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"sync"
+)
+
+// PyramidPrintable keeps mip-mapped copies of the layer images, so that
+// viewers can zoom out of large layers without decoding the full image.
+type PyramidPrintable struct {
+	Printable
+	Levels int // Number of reduced levels, each half the size of the previous
+
+	mutex    sync.Mutex
+	pyramids map[int][]*image.Gray
+}
+
+func NewPyramidPrintable(printable Printable, levels int) (pp *PyramidPrintable) {
+	pp = &PyramidPrintable{
+		Printable: printable,
+		Levels:    levels,
+		pyramids:  map[int][]*image.Gray{},
+	}
+
+	return
+}
+
+// halveGray reduces an image to half of its size, averaging each 2x2 block of pixels
+func halveGray(in *image.Gray) (out *image.Gray) {
+	bounds := in.Bounds()
+	out = image.NewGray(image.Rect(0, 0, (bounds.Dx()+1)/2, (bounds.Dy()+1)/2))
+
+	for y := 0; y < out.Rect.Max.Y; y++ {
+		for x := 0; x < out.Rect.Max.X; x++ {
+			sum := 0
+			count := 0
+			for dy := 0; dy < 2; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					inX := bounds.Min.X + x*2 + dx
+					inY := bounds.Min.Y + y*2 + dy
+					if inX < bounds.Max.X && inY < bounds.Max.Y {
+						sum += int(in.Pix[in.PixOffset(inX, inY)])
+						count++
+					}
+				}
+			}
+			out.Pix[y*out.Stride+x] = uint8((sum + count/2) / count)
+		}
+	}
+
+	return
+}
+
+// pyramid returns the reduced levels of a layer, computing them if needed
+func (pp *PyramidPrintable) pyramid(index int) (levels []*image.Gray) {
+	pp.mutex.Lock()
+	levels, ok := pp.pyramids[index]
+	pp.mutex.Unlock()
+
+	if ok {
+		return
+	}
+
+	img := pp.Printable.LayerImage(index)
+	for n := 0; n < pp.Levels && (img.Rect.Dx() > 1 || img.Rect.Dy() > 1); n++ {
+		img = halveGray(img)
+		levels = append(levels, img)
+	}
+
+	pp.mutex.Lock()
+	pp.pyramids[index] = levels
+	pp.mutex.Unlock()
+
+	return
+}
+
+// LayerPyramid returns the layer image at a level of the pyramid. Level 0
+// is the full layer image, and levels past the smallest return the smallest.
+func (pp *PyramidPrintable) LayerPyramid(index int, level int) (img *image.Gray) {
+	if level <= 0 {
+		return pp.Printable.LayerImage(index)
+	}
+
+	levels := pp.pyramid(index)
+	if len(levels) == 0 {
+		return pp.Printable.LayerImage(index)
+	}
+
+	if level > len(levels) {
+		level = len(levels)
+	}
+
+	img = levels[level-1]
+
+	return
+}
+
+// Precompute computes the pyramids of all of the layers
+func (pp *PyramidPrintable) Precompute() {
+	WithAllLayers(pp, func(p Printable, n int) {
+		pp.pyramid(n)
+	})
+}
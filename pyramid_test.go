@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"testing"
+)
+
+type pyramidPrint struct {
+	Print
+}
+
+func (pp *pyramidPrint) LayerImage(index int) (img *image.Gray) {
+	img = image.NewGray(pp.Properties.Bounds())
+	// Left half on, right half off
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx()/2; x++ {
+			img.Pix[y*img.Stride+x] = 0xff
+		}
+	}
+
+	return
+}
+
+func TestPyramidPrintable(t *testing.T) {
+	prop := Properties{Size: Size{X: 8, Y: 5, Layers: 2}}
+
+	pp := NewPyramidPrintable(&pyramidPrint{Print: Print{Properties: prop}}, 8)
+	pp.Precompute()
+
+	table := []struct {
+		Level  int
+		Bounds image.Rectangle
+		Pix    []uint8
+	}{
+		{Level: 1, Bounds: image.Rect(0, 0, 4, 3), Pix: []uint8{0xff, 0xff, 0, 0}},
+		{Level: 2, Bounds: image.Rect(0, 0, 2, 2), Pix: []uint8{0xff, 0}},
+		{Level: 3, Bounds: image.Rect(0, 0, 1, 1), Pix: []uint8{0x80}},
+		{Level: 9, Bounds: image.Rect(0, 0, 1, 1), Pix: []uint8{0x80}},
+	}
+
+	for _, item := range table {
+		img := pp.LayerPyramid(1, item.Level)
+		if img.Bounds() != item.Bounds {
+			t.Errorf("level %v: expected %v, got %v", item.Level, item.Bounds, img.Bounds())
+			continue
+		}
+
+		for x, pix := range item.Pix {
+			if img.Pix[x] != pix {
+				t.Errorf("level %v: pixel %v: expected %#x, got %#x", item.Level, x, pix, img.Pix[x])
+			}
+		}
+	}
+
+	if pp.LayerPyramid(0, 0).Bounds() != prop.Bounds() {
+		t.Errorf("level 0: expected %v, got %v", prop.Bounds(), pp.LayerPyramid(0, 0).Bounds())
+	}
+}
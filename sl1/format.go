@@ -8,6 +8,7 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"image"
@@ -50,6 +51,29 @@ var (
 	}
 )
 
+// Metadata keys for the config.ini entries that have no Properties equivalent
+var configMetadata = map[string]string{
+	"materialName":   "MaterialName",
+	"printProfile":   "PrintProfile",
+	"printerModel":   "PrinterModel",
+	"printerProfile": "PrinterProfile",
+}
+
+const usedMaterialMetadata = "UsedMaterial" // float32, in milliliters
+
+// Metadata key of the layer height of a decoded SL1 file. The 'materialName'
+// and 'printProfile' entries name the layer height, so they are only carried
+// over while it is unchanged.
+const profileLayerHeightMetadata = "ProfileLayerHeight" // float32, in millimeters
+
+// Archive entry of the per-layer exposures. The printer only uses the
+// config.ini exposures, but they are kept for conversions.
+const layerExposureFile = "exposure.json"
+
+// Archive entry of the per-layer Z heights, for variable layer heights.
+// The printer only uses the config.ini layer height.
+const layerZFile = "z.json"
+
 type sl1Config struct {
 	jobDir       string
 	expTime      float32
@@ -139,8 +163,10 @@ type ReadSeekCloser interface {
 
 type Print struct {
 	uv3dp.Print
-	config   sl1Config
-	layerPng []([]byte)
+	config        sl1Config
+	layerPng      []([]byte)
+	layerExposure []uv3dp.Exposure
+	layerZ        []float32
 }
 
 type Format struct {
@@ -179,17 +205,23 @@ func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err er
 	bot_slow := printable.Bottom().Count
 	bot_fade := printable.Bottom().Transition
 
-	layerHeight := fmt.Sprintf("%.3g", size.LayerHeight)
+	layerHeight := uv3dp.FormatFloatShort(size.LayerHeight, 3)
 	materialName := sf.MaterialName
 	if strings.HasSuffix(materialName, " @") {
 		materialName += layerHeight
 	}
 
+	usedMaterial := "0.0"
+	used := uv3dp.PrintVolume(printable)
+	if used > 0 {
+		usedMaterial = uv3dp.FormatFloat(used, 3)
+	}
+
 	config_ini := map[string]string{
 		"action":                "print",
 		"jobDir":                "uv3dp",
-		"expTime":               fmt.Sprintf("%.3g", exp.LightOnTime),
-		"expTimeFirst":          fmt.Sprintf("%.3g", bot.LightOnTime),
+		"expTime":               uv3dp.FormatFloatShort(exp.LightOnTime, 3),
+		"expTimeFirst":          uv3dp.FormatFloatShort(bot.LightOnTime, 3),
 		"fileCreationTimestamp": sl1Timestamp(),
 		"layerHeight":           layerHeight,
 		"materialName":          materialName,
@@ -197,11 +229,33 @@ func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err er
 		"numFast":               fmt.Sprintf("%v", size.Layers),
 		"numSlow":               fmt.Sprintf("%v", bot_slow),
 		"printProfile":          layerHeight + " Normal",
-		"printTime":             fmt.Sprintf("%.3f", float32(uv3dp.PrintDuration(printable))/float32(time.Second)),
+		"printTime":             uv3dp.FormatFloat(float32(uv3dp.PrintDuration(printable))/float32(time.Second), 3),
 		"printerModel":          "SL1",
 		"printerProfile":        "Original Prusa SL1",
 		"prusaSlicerVersion":    "uv3dp",
-		"usedMaterial":          "0.0", // TODO: Calculate this properly!
+		"usedMaterial":          usedMaterial,
+	}
+
+	// Carry over the entries from a decoded SL1 file
+	profileHeight, _ := printable.Metadata(profileLayerHeightMetadata)
+	for attr, key := range configMetadata {
+		if attr == "materialName" && sf.Changed("material-name") {
+			continue
+		}
+
+		if (attr == "materialName" || attr == "printProfile") && profileHeight != size.LayerHeight {
+			continue
+		}
+
+		data, ok := printable.Metadata(key)
+		if !ok {
+			continue
+		}
+
+		value, ok := data.(string)
+		if ok && value != "" {
+			config_ini[attr] = value
+		}
 	}
 
 	// Create the config file
@@ -235,6 +289,56 @@ func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err er
 			return
 		}
 	})
+	if err != nil {
+		return
+	}
+
+	// Save the per-layer exposures, if any differ from the defaults
+	defaults := uv3dp.Properties{Size: size, Exposure: exp, Bottom: printable.Bottom()}
+	layerExposure := make([]uv3dp.Exposure, size.Layers)
+	perLayer := false
+	for n := range layerExposure {
+		layerExposure[n] = printable.LayerExposure(n)
+		if layerExposure[n] != defaults.LayerExposure(n) {
+			perLayer = true
+		}
+	}
+
+	if perLayer {
+		var writer io.Writer
+		writer, err = archive.Create(layerExposureFile)
+		if err != nil {
+			return
+		}
+
+		err = json.NewEncoder(writer).Encode(layerExposure)
+		if err != nil {
+			return
+		}
+	}
+
+	// Save the per-layer Z heights, if any differ from the defaults
+	layerZ := make([]float32, size.Layers)
+	variable := false
+	for n := range layerZ {
+		layerZ[n] = printable.LayerZ(n)
+		if layerZ[n] != defaults.LayerZ(n) {
+			variable = true
+		}
+	}
+
+	if variable {
+		var writer io.Writer
+		writer, err = archive.Create(layerZFile)
+		if err != nil {
+			return
+		}
+
+		err = json.NewEncoder(writer).Encode(layerZ)
+		if err != nil {
+			return
+		}
+	}
 
 	// Save the thumbnails
 	previews := []uv3dp.PreviewType{
@@ -347,10 +451,51 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 		if err != nil {
 			return
 		}
-		defer reader.Close()
-
 		layerPng[n], err = ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	// Collect the per-layer exposures
+	var layerExposure []uv3dp.Exposure
+	file, ok := fileMap[layerExposureFile]
+	if ok {
+		var reader io.ReadCloser
+		reader, err = file.Open()
+		if err != nil {
+			return
+		}
+		err = json.NewDecoder(reader).Decode(&layerExposure)
+		reader.Close()
 		if err != nil {
+			err = fmt.Errorf("%s: %w", layerExposureFile, err)
+			return
+		}
+		if len(layerExposure) != len(layerPng) {
+			err = fmt.Errorf("%s: expected %v layers, got %v", layerExposureFile, len(layerPng), len(layerExposure))
+			return
+		}
+	}
+
+	// Collect the per-layer Z heights
+	var layerZ []float32
+	file, ok = fileMap[layerZFile]
+	if ok {
+		var reader io.ReadCloser
+		reader, err = file.Open()
+		if err != nil {
+			return
+		}
+		err = json.NewDecoder(reader).Decode(&layerZ)
+		reader.Close()
+		if err != nil {
+			err = fmt.Errorf("%s: %w", layerZFile, err)
+			return
+		}
+		if len(layerZ) != len(layerPng) {
+			err = fmt.Errorf("%s: expected %v layers, got %v", layerZFile, len(layerPng), len(layerZ))
 			return
 		}
 	}
@@ -376,14 +521,26 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 		defer func() { reader.Close() }()
 
 		var thumb image.Image
-		thumb, err = png.Decode(reader)
+		thumb, err = uv3dp.DecodePreview(reader)
 		if err != nil {
 			return
 		}
 		thumbImage[pt] = thumb
 	}
 
-	prop := uv3dp.Properties{}
+	prop := uv3dp.Properties{
+		Metadata: make(map[string]interface{}),
+	}
+
+	for attr, key := range configMetadata {
+		value, ok := config_map[attr]
+		if ok {
+			prop.Metadata[key] = value
+		}
+	}
+
+	prop.Metadata[usedMaterialMetadata] = config.usedMaterial
+	prop.Metadata[profileLayerHeightMetadata] = config.layerHeight
 
 	size := &prop.Size
 	size.X = int(config.pixelsX)
@@ -420,8 +577,10 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 	prop.Preview = thumbImage
 
 	sl1 := &Print{
-		Print:    uv3dp.Print{Properties: prop},
-		layerPng: layerPng,
+		Print:         uv3dp.Print{Properties: prop},
+		layerPng:      layerPng,
+		layerExposure: layerExposure,
+		layerZ:        layerZ,
 	}
 
 	printable = sl1
@@ -432,6 +591,28 @@ func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.P
 func (sl1 *Print) Close() {
 }
 
+func (sl1 *Print) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	if sl1.layerExposure != nil {
+		exposure = sl1.layerExposure[index]
+		return
+	}
+
+	exposure = sl1.Print.LayerExposure(index)
+
+	return
+}
+
+func (sl1 *Print) LayerZ(index int) (z float32) {
+	if sl1.layerZ != nil {
+		z = sl1.layerZ[index]
+		return
+	}
+
+	z = sl1.Print.LayerZ(index)
+
+	return
+}
+
 func (sl1 *Print) LayerImage(index int) (imageGray *image.Gray) {
 	pngImage, err := png.Decode(bytes.NewReader(sl1.layerPng[index]))
 	if err != nil {
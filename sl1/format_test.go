@@ -145,3 +145,178 @@ func TestEncodeEmptySl1(t *testing.T) {
 		}
 	}
 }
+
+func TestEncodeMetadataSl1(t *testing.T) {
+	time_Now = func() (now time.Time) { return }
+
+	prop := testProperties
+	prop.Metadata = map[string]interface{}{
+		"MaterialName":   "Prusa Orange Tough @0.05",
+		"PrintProfile":   "0.05 Fast",
+		"PrinterModel":   "SL1S",
+		"PrinterProfile": "Original Prusa SL1S SPEED",
+		"UsedMaterial":   float32(12.5),
+
+		"ProfileLayerHeight": float32(0.05),
+	}
+
+	// The used material is computed from the (empty) layers
+
+	expected := strings.NewReplacer(
+		"materialName = 3DM-ABS @0.05", "materialName = Prusa Orange Tough @0.05",
+		"printProfile = 0.05 Normal", "printProfile = 0.05 Fast",
+		"printerModel = SL1", "printerModel = SL1S",
+		"printerProfile = Original Prusa SL1", "printerProfile = Original Prusa SL1S SPEED",
+	).Replace(testConfigIni)
+
+	formatter := NewFormatter(".sl1")
+
+	buffWriter := &bytes.Buffer{}
+	formatter.Encode(buffWriter, uv3dp.NewEmptyPrintable(prop))
+
+	buffReader := &bufferReader{buffWriter.Bytes()}
+
+	archive, _ := zip.NewReader(buffReader, buffReader.Len())
+	for _, file := range archive.File {
+		if file.Name != "config.ini" {
+			continue
+		}
+
+		rc, _ := file.Open()
+		defer rc.Close()
+		got, _ := ioutil.ReadAll(rc)
+
+		if expected != string(got) {
+			t.Errorf("%s: expected:\n%v\n  got:\n%v", file.Name, expected, string(got))
+		}
+	}
+}
+
+func TestEncodeMetadataLayerHeightSl1(t *testing.T) {
+	time_Now = func() (now time.Time) { return }
+
+	// Resliced from 0.1mm to 0.05mm layers
+	prop := testProperties
+	prop.Metadata = map[string]interface{}{
+		"MaterialName":   "Prusa Orange Tough @0.1",
+		"PrintProfile":   "0.1 Fast",
+		"PrinterModel":   "SL1S",
+		"PrinterProfile": "Original Prusa SL1S SPEED",
+
+		"ProfileLayerHeight": float32(0.1),
+	}
+
+	// Only the entries that do not name the layer height are kept
+	expected := strings.NewReplacer(
+		"printerModel = SL1", "printerModel = SL1S",
+		"printerProfile = Original Prusa SL1", "printerProfile = Original Prusa SL1S SPEED",
+	).Replace(testConfigIni)
+
+	formatter := NewFormatter(".sl1")
+
+	buffWriter := &bytes.Buffer{}
+	formatter.Encode(buffWriter, uv3dp.NewEmptyPrintable(prop))
+
+	buffReader := &bufferReader{buffWriter.Bytes()}
+
+	archive, _ := zip.NewReader(buffReader, buffReader.Len())
+	for _, file := range archive.File {
+		if file.Name != "config.ini" {
+			continue
+		}
+
+		rc, _ := file.Open()
+		defer rc.Close()
+		got, _ := ioutil.ReadAll(rc)
+
+		if expected != string(got) {
+			t.Errorf("%s: expected:\n%v\n  got:\n%v", file.Name, expected, string(got))
+		}
+	}
+}
+
+// layerExposurePrint has a longer exposure, and a thinner layer, on its last layer
+type layerExposurePrint struct {
+	uv3dp.Print
+}
+
+func (lep *layerExposurePrint) LayerZ(index int) (z float32) {
+	z = lep.Print.LayerZ(index)
+	if index == lep.Size().Layers-1 {
+		z -= lep.Size().LayerHeight / 2
+	}
+
+	return
+}
+
+func (lep *layerExposurePrint) LayerExposure(index int) (exposure uv3dp.Exposure) {
+	exposure = lep.Print.LayerExposure(index)
+	if index == lep.Size().Layers-1 {
+		exposure.LightOnTime = 30
+	}
+
+	return
+}
+
+func TestLayerExposureSl1(t *testing.T) {
+	time_Now = func() (now time.Time) { return }
+
+	formatter := NewFormatter(".sl1")
+
+	for _, perLayer := range []bool{false, true} {
+		var input uv3dp.Printable = uv3dp.NewEmptyPrintable(testProperties)
+		if perLayer {
+			input = &layerExposurePrint{Print: uv3dp.Print{Properties: testProperties}}
+		}
+
+		buffWriter := &bytes.Buffer{}
+		err := formatter.Encode(buffWriter, input)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		// Add the PrusaSlicer settings the decoder needs
+		encoded := &bufferReader{buffWriter.Bytes()}
+		archive, _ := zip.NewReader(encoded, encoded.Len())
+
+		buffZip := &bytes.Buffer{}
+		rezip := zip.NewWriter(buffZip)
+		found, foundZ := false, false
+		for _, file := range archive.File {
+			found = found || file.Name == layerExposureFile
+			foundZ = foundZ || file.Name == layerZFile
+			rc, _ := file.Open()
+			data, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			writer, _ := rezip.Create(file.Name)
+			writer.Write(data)
+		}
+		writer, _ := rezip.Create("prusaslicer.ini")
+		writer.Write([]byte("display_height = 40\ndisplay_width = 20\ndisplay_pixels_x = 20\ndisplay_pixels_y = 10\n"))
+		rezip.Close()
+
+		if found != perLayer {
+			t.Errorf("per-layer %v: expected %v in archive to be %v", perLayer, layerExposureFile, perLayer)
+		}
+
+		if foundZ != perLayer {
+			t.Errorf("per-layer %v: expected %v in archive to be %v", perLayer, layerZFile, perLayer)
+		}
+
+		result, err := formatter.Decode(bytes.NewReader(buffZip.Bytes()), int64(buffZip.Len()))
+		if err != nil {
+			t.Fatalf("per-layer %v: %v", perLayer, err)
+		}
+
+		for n := 0; n < testProperties.Size.Layers; n++ {
+			got := result.LayerExposure(n)
+			expected := input.LayerExposure(n)
+			if perLayer && got != expected {
+				t.Errorf("layer %v: expected %+v, got %+v", n, expected, got)
+			}
+			if result.LayerZ(n) != input.LayerZ(n) {
+				t.Errorf("layer %v: expected Z %v, got %v", n, input.LayerZ(n), result.LayerZ(n))
+			}
+		}
+	}
+}
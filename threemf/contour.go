@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package threemf
+
+import (
+	"image"
+)
+
+// Gray level of the pixels inside of the model
+const contourThreshold = 0x80
+
+// Contours traces the outlines of the lit pixels of an image, along the
+// pixel edges. The outlines go clockwise around the lit pixels, and
+// counter-clockwise around holes, as seen in the image (with its rows
+// going down), and only have a vertex where their direction changes.
+func Contours(img *image.Gray) (contours [][]image.Point) {
+	bounds := img.Bounds()
+
+	lit := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= bounds.Dx() || y >= bounds.Dy() {
+			return false
+		}
+		return img.Pix[y*img.Stride+x] >= contourThreshold
+	}
+
+	// Pixel edges between lit and unlit pixels, with the lit pixel on
+	// their right, by their starting corner
+	edges := map[image.Point][]image.Point{}
+	var starts []image.Point
+	addEdge := func(from, to image.Point) {
+		if len(edges[from]) == 0 {
+			starts = append(starts, from)
+		}
+		edges[from] = append(edges[from], to)
+	}
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			if !lit(x, y) {
+				continue
+			}
+			if !lit(x, y-1) {
+				addEdge(image.Pt(x, y), image.Pt(x+1, y))
+			}
+			if !lit(x+1, y) {
+				addEdge(image.Pt(x+1, y), image.Pt(x+1, y+1))
+			}
+			if !lit(x, y+1) {
+				addEdge(image.Pt(x+1, y+1), image.Pt(x, y+1))
+			}
+			if !lit(x-1, y) {
+				addEdge(image.Pt(x, y+1), image.Pt(x, y))
+			}
+		}
+	}
+
+	for _, start := range starts {
+		for len(edges[start]) > 0 {
+			contours = append(contours, contourTrace(edges, start))
+		}
+	}
+
+	return
+}
+
+// contourTrace follows pixel edges from a corner until it returns to it,
+// removing the edges it follows
+func contourTrace(edges map[image.Point][]image.Point, start image.Point) (contour []image.Point) {
+	at := start
+	var first, direction image.Point
+
+	for {
+		out := edges[at]
+
+		// Where two lit pixels only touch at a corner, turn right, so
+		// that the outline stays on the same pixel
+		choice := 0
+		if len(out) > 1 {
+			right := image.Pt(-direction.Y, direction.X)
+			for n, to := range out {
+				if to.Sub(at) == right {
+					choice = n
+				}
+			}
+		}
+
+		to := out[choice]
+		edges[at] = append(out[:choice], out[choice+1:]...)
+		if len(edges[at]) == 0 {
+			delete(edges, at)
+		}
+
+		turn := to.Sub(at)
+		if first == (image.Point{}) {
+			first = turn
+		}
+		if turn != direction {
+			contour = append(contour, at)
+			direction = turn
+		}
+
+		at = to
+		if at == start {
+			break
+		}
+	}
+
+	// The start corner is not a vertex if the outline is straight there
+	if len(contour) > 1 && first == direction {
+		contour = contour[1:]
+	}
+
+	return
+}
@@ -0,0 +1,306 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package threemf
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nicarran/uv3dp"
+)
+
+const (
+	contentTypesXML = `<?xml version="1.0" encoding="UTF-8"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="model" ContentType="application/vnd.ms-package.3dmanufacturing-3dmodel+xml"/>
+</Types>
+`
+	relationshipsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Target="/3D/3dmodel.model" Id="rel0" Type="http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"/>
+</Relationships>
+`
+	modelHeaderXML = `<?xml version="1.0" encoding="UTF-8"?>
+<model unit="millimeter" xml:lang="en-US" requiredextensions="s"
+  xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02"
+  xmlns:s="http://schemas.microsoft.com/3dmanufacturing/slice/2015/07"
+  xmlns:uv3dp="https://github.com/nicarran/uv3dp">
+`
+)
+
+// Format is the 3MF file format, with the layers in its slice extension
+type Format struct {
+	*pflag.FlagSet
+}
+
+func NewFormatter(suffix string) (sf *Format) {
+	flagSet := pflag.NewFlagSet(suffix, pflag.ContinueOnError)
+	flagSet.SetInterspersed(false)
+
+	sf = &Format{
+		FlagSet: flagSet,
+	}
+
+	return
+}
+
+// writeMetadata writes a 3MF metadata element
+func writeMetadata(writer io.Writer, name string, value string) (err error) {
+	var escaped strings.Builder
+	err = xml.EscapeText(&escaped, []byte(value))
+	if err != nil {
+		return
+	}
+
+	_, err = fmt.Fprintf(writer, "  <metadata name=\"%s\" preserve=\"1\">%s</metadata>\n", name, escaped.String())
+
+	return
+}
+
+// writeMetadataJSON writes a 3MF metadata element of a value as JSON
+func writeMetadataJSON(writer io.Writer, name string, value interface{}) (err error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	err = writeMetadata(writer, name, string(data))
+
+	return
+}
+
+// writeSlice writes a layer's outlines as a slice of the slice stack.
+// Image rows go down, and the Y of the model goes up, so the outlines
+// are counter-clockwise around the model, as seen from above.
+func writeSlice(writer io.Writer, size uv3dp.Size, z float32, contours [][]image.Point) (err error) {
+	if len(contours) == 0 {
+		_, err = fmt.Fprintf(writer, "      <s:slice ztop=\"%v\"/>\n", z)
+		return
+	}
+
+	pixelX := size.Millimeter.X / float32(size.X)
+	pixelY := size.Millimeter.Y / float32(size.Y)
+
+	_, err = fmt.Fprintf(writer, "      <s:slice ztop=\"%v\">\n        <s:vertices>\n", z)
+	if err != nil {
+		return
+	}
+
+	for _, contour := range contours {
+		for _, pt := range contour {
+			_, err = fmt.Fprintf(writer, "          <s:vertex x=\"%v\" y=\"%v\"/>\n",
+				float32(pt.X)*pixelX, size.Millimeter.Y-float32(pt.Y)*pixelY)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	_, err = fmt.Fprintf(writer, "        </s:vertices>\n")
+	if err != nil {
+		return
+	}
+
+	start := 0
+	for _, contour := range contours {
+		_, err = fmt.Fprintf(writer, "        <s:polygon startv=\"%v\">\n", start)
+		if err != nil {
+			return
+		}
+
+		for n := 1; n <= len(contour); n++ {
+			_, err = fmt.Fprintf(writer, "          <s:segment v2=\"%v\"/>\n", start+n%len(contour))
+			if err != nil {
+				return
+			}
+		}
+
+		_, err = fmt.Fprintf(writer, "        </s:polygon>\n")
+		if err != nil {
+			return
+		}
+
+		start += len(contour)
+	}
+
+	_, err = fmt.Fprintf(writer, "      </s:slice>\n")
+
+	return
+}
+
+// writeBox writes a box as the low resolution mesh of the model
+func writeBox(writer io.Writer, min [3]float32, max [3]float32) (err error) {
+	_, err = fmt.Fprintf(writer, "      <mesh>\n        <vertices>\n")
+	if err != nil {
+		return
+	}
+
+	// Vertex n is at the min or max of each axis, by bits 0, 1, and 2
+	for n := 0; n < 8; n++ {
+		var pt [3]float32
+		for axis := range pt {
+			pt[axis] = min[axis]
+			if n&(1<<uint(axis)) != 0 {
+				pt[axis] = max[axis]
+			}
+		}
+		_, err = fmt.Fprintf(writer, "          <vertex x=\"%v\" y=\"%v\" z=\"%v\"/>\n", pt[0], pt[1], pt[2])
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = fmt.Fprintf(writer, "        </vertices>\n        <triangles>\n")
+	if err != nil {
+		return
+	}
+
+	// Counter-clockwise from outside of the box
+	triangles := [][3]int{
+		{0, 2, 1}, {1, 2, 3}, // Bottom
+		{4, 5, 6}, {5, 7, 6}, // Top
+		{0, 1, 4}, {1, 5, 4}, // Front
+		{2, 6, 3}, {3, 6, 7}, // Back
+		{0, 4, 2}, {2, 4, 6}, // Left
+		{1, 3, 5}, {3, 7, 5}, // Right
+	}
+
+	for _, tri := range triangles {
+		_, err = fmt.Fprintf(writer, "          <triangle v1=\"%v\" v2=\"%v\" v3=\"%v\"/>\n", tri[0], tri[1], tri[2])
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = fmt.Fprintf(writer, "        </triangles>\n      </mesh>\n")
+
+	return
+}
+
+func (sf *Format) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err error) {
+	size := printable.Size()
+
+	// Trace the outlines of all the layers
+	contours := make([][][]image.Point, size.Layers)
+	uv3dp.WithAllLayers(printable, func(p uv3dp.Printable, n int) {
+		contours[n] = Contours(p.LayerImage(n))
+	})
+
+	var bounds image.Rectangle
+	for _, layer := range contours {
+		for _, contour := range layer {
+			for _, pt := range contour {
+				bounds = bounds.Union(image.Rectangle{Min: pt, Max: pt.Add(image.Pt(1, 1))})
+			}
+		}
+	}
+
+	if bounds.Empty() {
+		err = fmt.Errorf("no model in the layers")
+		return
+	}
+
+	archive := zip.NewWriter(writer)
+	defer archive.Close()
+
+	for _, file := range []struct {
+		Name    string
+		Content string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", relationshipsXML},
+	} {
+		var fileWriter io.Writer
+		fileWriter, err = archive.Create(file.Name)
+		if err != nil {
+			return
+		}
+		_, err = io.WriteString(fileWriter, file.Content)
+		if err != nil {
+			return
+		}
+	}
+
+	fileWriter, err := archive.Create("3D/3dmodel.model")
+	if err != nil {
+		return
+	}
+
+	model := bufio.NewWriter(fileWriter)
+
+	_, err = io.WriteString(model, modelHeaderXML)
+	if err != nil {
+		return
+	}
+
+	err = writeMetadata(model, "Application", "uv3dp")
+	if err != nil {
+		return
+	}
+
+	for _, item := range []struct {
+		Name  string
+		Value interface{}
+	}{
+		{"uv3dp:Size", size},
+		{"uv3dp:Exposure", printable.Exposure()},
+		{"uv3dp:Bottom", printable.Bottom()},
+	} {
+		err = writeMetadataJSON(model, item.Name, item.Value)
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = fmt.Fprintf(model, "  <resources>\n    <s:slicestack id=\"1\" zbottom=\"0\">\n")
+	if err != nil {
+		return
+	}
+
+	for n, layer := range contours {
+		err = writeSlice(model, size, printable.LayerZ(n), layer)
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = fmt.Fprintf(model, "    </s:slicestack>\n    <object id=\"2\" type=\"model\" s:slicestackid=\"1\" s:meshresolution=\"lowres\">\n")
+	if err != nil {
+		return
+	}
+
+	pixelX := size.Millimeter.X / float32(size.X)
+	pixelY := size.Millimeter.Y / float32(size.Y)
+	err = writeBox(model,
+		[3]float32{float32(bounds.Min.X) * pixelX, size.Millimeter.Y - float32(bounds.Max.Y-1)*pixelY, 0},
+		[3]float32{float32(bounds.Max.X-1) * pixelX, size.Millimeter.Y - float32(bounds.Min.Y)*pixelY, printable.LayerZ(size.Layers - 1)})
+	if err != nil {
+		return
+	}
+
+	_, err = fmt.Fprintf(model, "    </object>\n  </resources>\n  <build>\n    <item objectid=\"2\"/>\n  </build>\n</model>\n")
+	if err != nil {
+		return
+	}
+
+	err = model.Flush()
+
+	return
+}
+
+func (sf *Format) Decode(reader uv3dp.Reader, filesize int64) (printable uv3dp.Printable, err error) {
+	err = fmt.Errorf("3MF files can not be read")
+
+	return
+}
@@ -0,0 +1,180 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package threemf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"image"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// ringPrint has a square ring on every layer, and a pixel that only
+// touches the ring at a corner
+type ringPrint struct {
+	uv3dp.Print
+}
+
+func ringImage(bounds image.Rectangle) (grayImage *image.Gray) {
+	grayImage = image.NewGray(bounds)
+	for y := 2; y < 8; y++ {
+		for x := 2; x < 8; x++ {
+			if x < 4 || x >= 6 || y < 4 || y >= 6 {
+				grayImage.Pix[y*grayImage.Stride+x] = 0xff
+			}
+		}
+	}
+	grayImage.Pix[8*grayImage.Stride+8] = 0xff
+
+	return
+}
+
+func (rp *ringPrint) LayerImage(index int) *image.Gray {
+	return ringImage(rp.Properties.Bounds())
+}
+
+// contourArea returns the signed area of a contour, as seen in the image
+func contourArea(contour []image.Point) (area int) {
+	for n, pt := range contour {
+		next := contour[(n+1)%len(contour)]
+		area += pt.X*next.Y - next.X*pt.Y
+	}
+
+	return area / 2
+}
+
+func TestContours(t *testing.T) {
+	contours := Contours(ringImage(image.Rect(0, 0, 10, 10)))
+
+	expected := [][]image.Point{
+		{{2, 2}, {8, 2}, {8, 8}, {2, 8}},
+		{{4, 4}, {4, 6}, {6, 6}, {6, 4}},
+		{{8, 8}, {9, 8}, {9, 9}, {8, 9}},
+	}
+
+	if !cmp.Equal(expected, contours) {
+		t.Fatalf("expected %v, got %v", expected, contours)
+	}
+
+	// Clockwise around the model, counter-clockwise around holes
+	for n, area := range []int{36, -4, 1} {
+		if contourArea(contours[n]) != area {
+			t.Errorf("contour %v: expected area %v, got %v", n, area, contourArea(contours[n]))
+		}
+	}
+}
+
+type modelXML struct {
+	Metadata []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"metadata"`
+	Slices []struct {
+		ZTop     float32 `xml:"ztop,attr"`
+		Vertices []struct {
+			X float32 `xml:"x,attr"`
+			Y float32 `xml:"y,attr"`
+		} `xml:"vertices>vertex"`
+		Polygons []struct {
+			StartV   int `xml:"startv,attr"`
+			Segments []struct {
+				V2 int `xml:"v2,attr"`
+			} `xml:"segment"`
+		} `xml:"polygon"`
+	} `xml:"resources>slicestack>slice"`
+	Triangles []struct{} `xml:"resources>object>mesh>triangles>triangle"`
+}
+
+func TestEncode(t *testing.T) {
+	input := &ringPrint{uv3dp.Print{Properties: uv3dp.Properties{
+		Size: uv3dp.Size{
+			X: 10, Y: 10, Layers: 2, LayerHeight: 0.05,
+			Millimeter: uv3dp.SizeMillimeter{X: 5, Y: 5},
+		},
+		Exposure: uv3dp.Exposure{LightOnTime: 8},
+	}}}
+
+	buffer := &bytes.Buffer{}
+	err := NewFormatter(".3mf").Encode(buffer, input)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "3D/3dmodel.model"} {
+		if files[name] == nil {
+			t.Fatalf("expected %v in the archive", name)
+		}
+	}
+
+	reader, err := files["3D/3dmodel.model"].Open()
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	data, _ := ioutil.ReadAll(reader)
+	reader.Close()
+
+	var model modelXML
+	err = xml.Unmarshal(data, &model)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	metadata := map[string]string{}
+	for _, item := range model.Metadata {
+		metadata[item.Name] = item.Value
+	}
+
+	if metadata["uv3dp:Exposure"] == "" || metadata["uv3dp:Bottom"] == "" {
+		t.Errorf("expected exposure metadata, got %v", metadata)
+	}
+
+	if len(model.Slices) != 2 || model.Slices[1].ZTop != 0.1 {
+		t.Fatalf("expected 2 slices, up to Z 0.1, got %+v", model.Slices)
+	}
+
+	slice := model.Slices[0]
+	if len(slice.Vertices) != 12 || len(slice.Polygons) != 3 {
+		t.Fatalf("expected 12 vertices in 3 polygons, got %+v", slice)
+	}
+
+	// Image rows go down, and the Y of the model goes up
+	if slice.Vertices[0].X != 1 || slice.Vertices[0].Y != 4 {
+		t.Errorf("expected the first vertex at (1, 4), got %+v", slice.Vertices[0])
+	}
+
+	// Polygons are closed
+	for _, polygon := range slice.Polygons {
+		last := polygon.Segments[len(polygon.Segments)-1].V2
+		if last != polygon.StartV {
+			t.Errorf("expected polygon %v to be closed, ending at %v", polygon.StartV, last)
+		}
+	}
+
+	if len(model.Triangles) != 12 {
+		t.Errorf("expected a box of 12 triangles, got %v", len(model.Triangles))
+	}
+
+	empty := uv3dp.NewEmptyPrintable(input.Properties)
+	err = NewFormatter(".3mf").Encode(&bytes.Buffer{}, empty)
+	if err == nil {
+		t.Errorf("expected an error for a print without a model")
+	}
+}
@@ -0,0 +1,16 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+// Package threemf handles output of 3MF files, with the layers as outlines in the 3MF slice extension
+package threemf
+
+import (
+	"github.com/nicarran/uv3dp"
+)
+
+func init() {
+	newFormatter := func(suffix string) uv3dp.Formatter { return NewFormatter(suffix) }
+
+	uv3dp.RegisterFormatter(".3mf", newFormatter)
+}
@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+// DefaultPreviewSize returns the size of a preview type, for formats that
+// have no size of their own
+func DefaultPreviewSize(previewType PreviewType) (size image.Point) {
+	switch previewType {
+	case PreviewTypeTiny:
+		size = image.Pt(200, 125)
+	default:
+		size = image.Pt(400, 300)
+	}
+
+	return
+}
+
+// Color of the rendered model, before shading
+var thumbnailColor = color.RGBA{R: 0x40, G: 0xa0, B: 0xff, A: 0xff}
+
+// RenderPreview renders a top-down view of the layers of a printable,
+// shaded by the height of the model and lit from the upper left, scaled
+// to fit a size.
+func RenderPreview(printable Printable, size image.Point) (pic *image.RGBA) {
+	prop := printable.Size()
+	if prop.X <= 0 || prop.Y <= 0 || size.X <= 0 || size.Y <= 0 {
+		pic = FitPreview(image.NewRGBA(image.Rectangle{}), size)
+		return
+	}
+
+	// Each cell of the height map is the highest layer over a square of
+	// pixels, so that the map is about the size of the preview.
+	cell := (prop.X + size.X - 1) / size.X
+	if step := (prop.Y + size.Y - 1) / size.Y; step > cell {
+		cell = step
+	}
+	dx := (prop.X + cell - 1) / cell
+	dy := (prop.Y + cell - 1) / cell
+
+	heights := make([]int, dx*dy)
+	var mutex sync.Mutex
+
+	WithAllLayers(printable, func(p Printable, n int) {
+		layer := p.LayerImage(n)
+		bounds := layer.Bounds()
+
+		lit := make([]bool, dx*dy)
+		for y := 0; y < bounds.Dy(); y++ {
+			row := layer.Pix[y*layer.Stride : y*layer.Stride+bounds.Dx()]
+			for x, pix := range row {
+				if pix >= 0x80 {
+					lit[(y/cell)*dx+x/cell] = true
+				}
+			}
+		}
+
+		mutex.Lock()
+		for c, ok := range lit {
+			if ok && heights[c] < n+1 {
+				heights[c] = n + 1
+			}
+		}
+		mutex.Unlock()
+	})
+
+	top := 1
+	for _, height := range heights {
+		if height > top {
+			top = height
+		}
+	}
+
+	// Scale from layers per cell of the height map to the slope of the model
+	slope := float64(prop.LayerHeight) * float64(prop.X) / (float64(prop.Millimeter.X) * float64(cell))
+	if prop.Millimeter.X <= 0 || prop.LayerHeight <= 0 {
+		slope = 1
+	}
+
+	heightAt := func(x, y int) float64 {
+		if x < 0 || y < 0 || x >= dx || y >= dy {
+			return 0
+		}
+		return float64(heights[y*dx+x])
+	}
+
+	light := [3]float64{-1, -1, 1}
+	length := math.Sqrt(3)
+
+	shaded := image.NewRGBA(image.Rect(0, 0, dx, dy))
+	for y := 0; y < dy; y++ {
+		for x := 0; x < dx; x++ {
+			height := heightAt(x, y)
+			if height == 0 {
+				shaded.SetRGBA(x, y, color.RGBA{A: 0xff})
+				continue
+			}
+
+			nx := -(heightAt(x+1, y) - heightAt(x-1, y)) / 2 * slope
+			ny := -(heightAt(x, y+1) - heightAt(x, y-1)) / 2 * slope
+			lambert := (nx*light[0] + ny*light[1] + light[2]) / (length * math.Sqrt(nx*nx+ny*ny+1))
+			if lambert < 0 {
+				lambert = 0
+			}
+
+			shade := (0.3 + 0.7*lambert) * (0.5 + 0.5*height/float64(top))
+			shaded.SetRGBA(x, y, color.RGBA{
+				R: uint8(float64(thumbnailColor.R) * shade),
+				G: uint8(float64(thumbnailColor.G) * shade),
+				B: uint8(float64(thumbnailColor.B) * shade),
+				A: 0xff,
+			})
+		}
+	}
+
+	pic = FitPreview(shaded, size)
+
+	return
+}
+
+// previewPrintable renders the previews that a printable is missing
+type previewPrintable struct {
+	Printable
+
+	once sync.Once
+	huge *image.RGBA
+}
+
+// WithPreviews returns a printable that has all of the preview types,
+// rendering those missing from the printable from its layers. The layers
+// are only rendered once, and only if a missing preview is used.
+func WithPreviews(printable Printable) Printable {
+	return &previewPrintable{Printable: printable}
+}
+
+func (pp *previewPrintable) Preview(index PreviewType) (pic image.Image, ok bool) {
+	pic, ok = pp.Printable.Preview(index)
+	if ok {
+		return
+	}
+
+	pp.once.Do(func() {
+		pp.huge = RenderPreview(pp.Printable, DefaultPreviewSize(PreviewTypeHuge))
+	})
+
+	pic = pp.huge
+	if index != PreviewTypeHuge {
+		pic = FitPreview(pp.huge, DefaultPreviewSize(index))
+	}
+	ok = true
+
+	return
+}
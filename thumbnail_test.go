@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// stepPrint is a two step pyramid, with a wide lower step and a narrow
+// upper step
+type stepPrint struct {
+	Print
+}
+
+func (sp *stepPrint) LayerImage(index int) (grayImage *image.Gray) {
+	grayImage = image.NewGray(sp.Properties.Bounds())
+
+	rect := image.Rect(20, 20, 60, 60)
+	if index >= 5 {
+		rect = image.Rect(30, 30, 50, 50)
+	}
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			grayImage.Pix[y*grayImage.Stride+x] = 0xff
+		}
+	}
+
+	return
+}
+
+func TestRenderPreview(t *testing.T) {
+	input := &stepPrint{Print{Properties: Properties{
+		Size: Size{
+			X: 80, Y: 80, Layers: 10, LayerHeight: 0.05,
+			Millimeter: SizeMillimeter{X: 40, Y: 40},
+		},
+	}}}
+
+	pic := RenderPreview(input, image.Pt(80, 80))
+	if pic.Bounds().Size() != image.Pt(80, 80) {
+		t.Fatalf("expected size 80x80, got %v", pic.Bounds().Size())
+	}
+
+	outside := pic.RGBAAt(5, 5)
+	if outside != (color.RGBA{A: 0xff}) {
+		t.Errorf("expected black outside the model, got %v", outside)
+	}
+
+	lower := pic.RGBAAt(25, 25)
+	upper := pic.RGBAAt(40, 40)
+	if lower.B == 0 || upper.B <= lower.B {
+		t.Errorf("expected the upper step (%v) to be brighter than the lower step (%v)", upper, lower)
+	}
+}
+
+func TestWithPreviews(t *testing.T) {
+	huge := image.NewRGBA(image.Rect(0, 0, 20, 10))
+
+	input := &stepPrint{Print{Properties: Properties{
+		Size: Size{
+			X: 80, Y: 80, Layers: 10, LayerHeight: 0.05,
+			Millimeter: SizeMillimeter{X: 40, Y: 40},
+		},
+		Preview: map[PreviewType]image.Image{PreviewTypeHuge: huge},
+	}}}
+
+	output := WithPreviews(input)
+
+	pic, ok := output.Preview(PreviewTypeHuge)
+	if !ok || pic != huge {
+		t.Errorf("expected the huge preview of the input")
+	}
+
+	pic, ok = output.Preview(PreviewTypeTiny)
+	if !ok {
+		t.Fatalf("expected a rendered tiny preview")
+	}
+
+	size := DefaultPreviewSize(PreviewTypeTiny)
+	if pic.Bounds().Size() != size {
+		t.Errorf("expected size %v, got %v", size, pic.Bounds().Size())
+	}
+}
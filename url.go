@@ -0,0 +1,83 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// IsURL returns true if the filename is an HTTP or HTTPS URL
+func IsURL(filename string) bool {
+	return strings.HasPrefix(filename, "http://") || strings.HasPrefix(filename, "https://")
+}
+
+// urlPath returns the path of a URL (without any query), for selecting
+// a formatter by its extension. Other filenames are returned unchanged.
+func urlPath(filename string) (path string) {
+	path = filename
+
+	if IsURL(filename) {
+		parsed, err := url.Parse(filename)
+		if err == nil {
+			path = parsed.Path
+		}
+	}
+
+	return
+}
+
+// progressReader shows the progress of a read of known size
+type progressReader struct {
+	reader io.Reader
+	total  int64
+	count  int64
+}
+
+func (pr *progressReader) Read(buff []byte) (n int, err error) {
+	n, err = pr.reader.Read(buff)
+	pr.count += int64(n)
+	defaultProgress.Show(float32(pr.count) * 100.0 / float32(pr.total))
+
+	return
+}
+
+// readURL downloads the contents of an HTTP or HTTPS URL
+func readURL(location string) (data []byte, err error) {
+	resp, err := http.Get(location)
+	if err != nil {
+		return
+	}
+	defer func() { resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("%s: %s", location, resp.Status)
+		return
+	}
+
+	var reader io.Reader = resp.Body
+	if resp.ContentLength > 0 {
+		reader = &progressReader{reader: resp.Body, total: resp.ContentLength}
+		defer defaultProgress.Stop()
+	}
+
+	buff := &bytes.Buffer{}
+	if resp.ContentLength > 0 {
+		buff.Grow(int(resp.ContentLength))
+	}
+
+	_, err = io.Copy(buff, reader)
+	if err != nil {
+		return
+	}
+
+	data = buff.Bytes()
+
+	return
+}
@@ -0,0 +1,67 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type testProgress struct {
+	percent float32
+	stopped bool
+}
+
+func (tp *testProgress) Show(percent float32) {
+	tp.percent = percent
+}
+
+func (tp *testProgress) Stop() {
+	tp.stopped = true
+}
+
+func TestReadURL(t *testing.T) {
+	content := bytes.Repeat([]byte("uv3dp"), 10000)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job.test" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	progress := &testProgress{}
+	SetProgress(progress)
+	defer SetProgress(nil)
+
+	data, err := readURL(server.URL + "/job.test")
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	if !bytes.Equal(data, content) {
+		t.Errorf("expected %v bytes, got %v bytes", len(content), len(data))
+	}
+
+	if progress.percent != 100.0 || !progress.stopped {
+		t.Errorf("expected completed progress, got %+v", progress)
+	}
+
+	_, err = readURL(server.URL + "/missing.test")
+	if err == nil {
+		t.Errorf("expected error for missing URL")
+	}
+
+	path := urlPath(server.URL + "/job.test?token=abc")
+	if path != "/job.test" {
+		t.Errorf("expected /job.test, got %v", path)
+	}
+}
@@ -29,6 +29,10 @@ const (
 	defaultPixelsX   = 1440
 	defaultPixelsY   = 2560
 	defaultCacheSize = 16
+
+	// Version 1: Properties and per-layer Z and exposure
+	// Version 2: Adds typed job and per-layer metadata, and notes
+	uvjVersion = 2
 )
 
 type ErrConfigMissing string
@@ -46,10 +50,14 @@ func (e ErrConfigInvalid) Error() string {
 type UVJLayer struct {
 	Z        float32
 	Exposure uv3dp.Exposure
+	Metadata map[string]UVJValue `json:",omitempty"`
 }
 
 type UVJConfig struct {
+	Version    int    `json:",omitempty"`
+	Notes      string `json:",omitempty"`
 	Properties uv3dp.Properties
+	Metadata   map[string]UVJValue `json:",omitempty"`
 	Layers     []UVJLayer
 }
 
@@ -75,6 +83,11 @@ func NewUVJFormatter(suffix string) (sf *UVJFormat) {
 	return
 }
 
+// StoresAnnotations is true, as the layer annotations are saved as metadata
+func (sf *UVJFormat) StoresAnnotations() bool {
+	return true
+}
+
 func (sf *UVJFormat) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err error) {
 	archive := zip.NewWriter(writer)
 	defer archive.Close()
@@ -94,10 +107,31 @@ func (sf *UVJFormat) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err
 	}
 
 	config := UVJConfig{
+		Version:    uvjVersion,
 		Properties: prop,
 		Layers:     make([]UVJLayer, prop.Size.Layers),
 	}
 
+	notes, ok := printable.Metadata(NotesMetadata)
+	if ok {
+		config.Notes, _ = notes.(string)
+	}
+
+	var keys []string
+	for _, key := range printable.MetadataKeys() {
+		if key != NotesMetadata && key != LayerMetadataKey {
+			keys = append(keys, key)
+		}
+	}
+
+	config.Metadata, err = metadataEncode(keys, printable.Metadata)
+	if err != nil {
+		return
+	}
+
+	layerData, _ := printable.Metadata(LayerMetadataKey)
+	layerMetadata, _ := layerData.(LayerMetadata)
+
 	// Create all the layers
 	uv3dp.WithEachLayer(printable, func(p uv3dp.Printable, n int) {
 		filename := fmt.Sprintf("slice/%08d.png", n)
@@ -124,8 +158,25 @@ func (sf *UVJFormat) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (err
 			Z:        p.LayerZ(n),
 			Exposure: exposure,
 		}
+
+		metadata, ok := layerMetadata[n]
+		if ok {
+			lookup := func(key string) (data interface{}, ok bool) {
+				data, ok = metadata[key]
+				return
+			}
+			config.Layers[n].Metadata, err = metadataEncode(metadataKeys(metadata), lookup)
+			if err != nil {
+				err = fmt.Errorf("layer %v: %w", n, err)
+				return
+			}
+		}
 	})
 
+	if err != nil {
+		return
+	}
+
 	// Create the config file
 	fileConfig, err := archive.Create("config.json")
 	if err != nil {
@@ -218,13 +269,48 @@ func (sf *UVJFormat) Decode(reader uv3dp.Reader, filesize int64) (printable uv3d
 		return
 	}
 
+	if config.Version > uvjVersion {
+		err = uv3dp.DecodeIssue(uv3dp.DecodeIssueVersion, fmt.Errorf("config.json: version %v is not supported (maximum %v)", config.Version, uvjVersion))
+		if err != nil {
+			return
+		}
+	}
+
+	config.Properties.Metadata, err = metadataDecode(config.Metadata)
+	if err != nil {
+		err = fmt.Errorf("config.json: %w", err)
+		return
+	}
+
+	if len(config.Notes) > 0 {
+		config.Properties.Metadata[NotesMetadata] = config.Notes
+	}
+
+	layerMetadata := LayerMetadata{}
+	for n, layer := range config.Layers {
+		if len(layer.Metadata) == 0 {
+			continue
+		}
+
+		layerMetadata[n], err = metadataDecode(layer.Metadata)
+		if err != nil {
+			err = fmt.Errorf("config.json: layer %v: %w", n, err)
+			return
+		}
+	}
+
+	if len(layerMetadata) > 0 {
+		config.Properties.Metadata[LayerMetadataKey] = layerMetadata
+	}
+
 	// Check layers
 	if len(config.Layers) > 0 && len(config.Layers) != config.Properties.Size.Layers {
-		err = fmt.Errorf("config.json: expected %v layers, found %v layers", config.Properties.Size.Layers, config.Layers)
+		err = fmt.Errorf("config.json: expected %v layers, found %v layers", config.Properties.Size.Layers, len(config.Layers))
 		return
 	}
 
-	// Collect the layer files
+	// Collect the layer files, closing each as we go so that
+	// large (zip64) archives don't hold every decompressor open.
 	layerPng := make([]([]byte), config.Properties.Size.Layers)
 	for n := 0; n < cap(layerPng); n++ {
 		name := fmt.Sprintf("slice/%08d.png", n)
@@ -238,9 +324,8 @@ func (sf *UVJFormat) Decode(reader uv3dp.Reader, filesize int64) (printable uv3d
 		if err != nil {
 			return
 		}
-		defer reader.Close()
-
 		layerPng[n], err = ioutil.ReadAll(reader)
+		reader.Close()
 		if err != nil {
 			return
 		}
@@ -267,7 +352,7 @@ func (sf *UVJFormat) Decode(reader uv3dp.Reader, filesize int64) (printable uv3d
 		defer func() { reader.Close() }()
 
 		var thumb image.Image
-		thumb, err = png.Decode(reader)
+		thumb, err = uv3dp.DecodePreview(reader)
 		if err != nil {
 			err = fmt.Errorf("%s: %w", file.Name, err)
 			return
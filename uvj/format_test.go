@@ -8,6 +8,7 @@ package uvj
 import (
 	"archive/zip"
 	"bytes"
+	"fmt"
 	"image"
 	"image/color"
 	"image/png"
@@ -134,6 +135,7 @@ var (
 
 const (
 	testConfigJson = `{
+  "Version": 2,
   "Properties": {
     "Size": {
       "X": 10,
@@ -273,3 +275,165 @@ func TestEncodeEmptyUVJ(t *testing.T) {
 		}
 	}
 }
+
+func TestZip64UVJ(t *testing.T) {
+	// More than 65535 archive members forces the zip64 end-of-directory records
+	layers := 0x10000
+
+	buffPng := &bytes.Buffer{}
+	png.Encode(buffPng, image.NewGray(image.Rect(0, 0, 1, 1)))
+
+	buffWriter := &bytes.Buffer{}
+	archive := zip.NewWriter(buffWriter)
+
+	config, _ := archive.Create("config.json")
+	fmt.Fprintf(config, `{"Properties":{"Size":{"X":1,"Y":1,"Layers":%d,"LayerHeight":0.05}}}`, layers)
+
+	for n := 0; n < layers; n++ {
+		slice, _ := archive.Create(fmt.Sprintf("slice/%08d.png", n))
+		slice.Write(buffPng.Bytes())
+	}
+	archive.Close()
+
+	buffReader := bytes.NewReader(buffWriter.Bytes())
+
+	formatter := NewUVJFormatter(".uvj")
+	printable, err := formatter.Decode(buffReader, buffReader.Size())
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if printable.Size().Layers != layers {
+		t.Errorf("expected %v layers, got %v", layers, printable.Size().Layers)
+	}
+
+	last := printable.LayerImage(layers - 1)
+	if last.Bounds() != image.Rect(0, 0, 1, 1) {
+		t.Errorf("expected last layer bounds %v, got %v", image.Rect(0, 0, 1, 1), last.Bounds())
+	}
+}
+
+func TestMetadataUVJ(t *testing.T) {
+	prop := testProperties
+	prop.Metadata = map[string]interface{}{
+		NotesMetadata:  "Calibration run\nSecond line",
+		"Machine":      "EPAX E6 Mono",
+		"UsedMaterial": float32(12.5),
+		"Private":      &struct{ A int }{A: 1},
+
+		uv3dp.AnnotationsMetadata: uv3dp.Annotations{2: "Support failure seen here"},
+	}
+
+	layerMetadata := LayerMetadata{
+		0: {"Support": true},
+		2: {"Z-Hop": float32(0.25), "Tags": []string{"a", "b"}},
+	}
+	prop.Metadata[LayerMetadataKey] = layerMetadata
+
+	empty := NewPatternPrint(prop, []uint8{0, 0xff})
+
+	formatter := NewUVJFormatter(".uvj")
+
+	buffWriter := &bytes.Buffer{}
+	err := formatter.Encode(buffWriter, empty)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	buffReader := bytes.NewReader(buffWriter.Bytes())
+	printable, err := formatter.Decode(buffReader, buffReader.Size())
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	for _, key := range []string{NotesMetadata, "Machine", "UsedMaterial"} {
+		data, ok := printable.Metadata(key)
+		if !ok || data != prop.Metadata[key] {
+			t.Errorf("%v: expected %#v, got %#v", key, prop.Metadata[key], data)
+		}
+	}
+
+	annotations := uv3dp.LayerAnnotations(printable)
+	if len(annotations) != 1 || annotations[2] != "Support failure seen here" {
+		t.Errorf("Annotations: expected %#v, got %#v", prop.Metadata[uv3dp.AnnotationsMetadata], annotations)
+	}
+
+	_, ok := printable.Metadata("Private")
+	if ok {
+		t.Errorf("Private: expected to be dropped")
+	}
+
+	data, _ := printable.Metadata(LayerMetadataKey)
+	got, _ := data.(LayerMetadata)
+	if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", layerMetadata) {
+		t.Errorf("%v: expected %#v, got %#v", LayerMetadataKey, layerMetadata, got)
+	}
+}
+
+func TestVersionUVJ(t *testing.T) {
+	defer uv3dp.SetDecodeStrictness(uv3dp.DecodeNormal)
+
+	table := []struct {
+		Config  string
+		Valid   bool
+		Lenient bool // Valid when not decoding strictly
+	}{
+		{Config: `{"Properties":{"Size":{"X":1,"Y":1,"Layers":1}}}`, Valid: true},
+		{Config: `{"Version":1,"Properties":{"Size":{"X":1,"Y":1,"Layers":1}}}`, Valid: true},
+		{Config: `{"Version":3,"Properties":{"Size":{"X":1,"Y":1,"Layers":1}}}`, Lenient: true},
+		{Config: `{"Version":2,"Metadata":{"A":{"Type":"chan int","Value":0}},"Properties":{"Size":{"X":1,"Y":1,"Layers":1}}}`, Lenient: true},
+		{Config: `{"Version":2,"Properties":{"Size":{"X":1,"Y":1,"Layers":1}`, Valid: false},
+	}
+
+	buffPng := &bytes.Buffer{}
+	png.Encode(buffPng, image.NewGray(image.Rect(0, 0, 1, 1)))
+
+	for n, item := range table {
+		buffWriter := &bytes.Buffer{}
+		archive := zip.NewWriter(buffWriter)
+		config, _ := archive.Create("config.json")
+		config.Write([]byte(item.Config))
+		slice, _ := archive.Create("slice/00000000.png")
+		slice.Write(buffPng.Bytes())
+		archive.Close()
+
+		buffReader := bytes.NewReader(buffWriter.Bytes())
+
+		uv3dp.SetDecodeStrictness(uv3dp.DecodeStrict)
+		_, err := NewUVJFormatter(".uvj").Decode(buffReader, buffReader.Size())
+		if (err == nil) != item.Valid {
+			t.Errorf("%v: strict: expected valid %v, got %v", n, item.Valid, err)
+		}
+
+		uv3dp.SetDecodeStrictness(uv3dp.DecodeNormal)
+		_, err = NewUVJFormatter(".uvj").Decode(buffReader, buffReader.Size())
+		if (err == nil) != (item.Valid || item.Lenient) {
+			t.Errorf("%v: normal: expected valid %v, got %v", n, item.Valid || item.Lenient, err)
+		}
+	}
+}
+
+func TestLayerMetadataRemap(t *testing.T) {
+	layerMetadata := LayerMetadata{
+		0: {"Support": true},
+		1: {"Support": false, "Z-Hop": float32(0.25)},
+		3: {"Tags": []string{"a"}},
+	}
+
+	// Merge the first two layers, and drop the last one
+	remapped := layerMetadata.RemapLayers(func(index int) (int, bool) { return index / 2, index < 3 })
+	merged := remapped.MergeLayers(LayerMetadata{1: {"Tags": []string{"b"}}})
+
+	expected := LayerMetadata{
+		0: {"Support": true, "Z-Hop": float32(0.25)},
+		1: {"Tags": []string{"b"}},
+	}
+	if fmt.Sprintf("%v", merged) != fmt.Sprintf("%v", expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+
+	// The original is unchanged
+	if len(layerMetadata[0]) != 1 {
+		t.Errorf("expected the original to be unchanged, got %v", layerMetadata)
+	}
+}
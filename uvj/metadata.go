@@ -0,0 +1,169 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uvj
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/nicarran/uv3dp"
+)
+
+// Metadata key used for the job-level notes
+const NotesMetadata = "Notes"
+
+// Metadata key of the per-layer metadata. It is kept with the rest of
+// the printable's metadata, so that every filter passes it through.
+const LayerMetadataKey = "LayerMetadata"
+
+// LayerMetadata is the metadata of each layer, by layer index
+type LayerMetadata map[int]map[string]interface{}
+
+// RemapLayers returns a copy of the layer metadata, with the metadata
+// of each layer moved to the index from remap. When layers are moved to
+// the same index, the metadata of the lowest layer is kept for each key.
+func (lm LayerMetadata) RemapLayers(remap func(index int) (int, bool)) uv3dp.LayerIndexed {
+	indexes := []int{}
+	for index := range lm {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	remapped := LayerMetadata{}
+	for _, index := range indexes {
+		to, ok := remap(index)
+		if ok {
+			remapped.add(to, lm[index])
+		}
+	}
+
+	return remapped
+}
+
+// MergeLayers returns a copy of the layer metadata, with the metadata
+// of another set of layer metadata added
+func (lm LayerMetadata) MergeLayers(other uv3dp.LayerIndexed) uv3dp.LayerIndexed {
+	same := func(index int) (int, bool) { return index, true }
+
+	merged, _ := lm.RemapLayers(same).(LayerMetadata)
+
+	more, _ := other.(LayerMetadata)
+	for index, metadata := range more {
+		merged.add(index, metadata)
+	}
+
+	return merged
+}
+
+// add adds the keys of metadata that a layer does not have yet
+func (lm LayerMetadata) add(index int, metadata map[string]interface{}) {
+	layer, ok := lm[index]
+	if !ok {
+		layer = map[string]interface{}{}
+		lm[index] = layer
+	}
+
+	for key, data := range metadata {
+		_, ok = layer[key]
+		if !ok {
+			layer[key] = data
+		}
+	}
+}
+
+// UVJValue is a metadata value, tagged with its Go type so that
+// it decodes to the same type it was encoded from.
+type UVJValue struct {
+	Type  string
+	Value json.RawMessage
+}
+
+// Metadata types that survive a round trip through a UVJ file
+var uvjValueTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, value := range []interface{}{
+		"", false,
+		int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0),
+		[]string{}, []float32{}, []float64{}, []int{},
+		uv3dp.Annotations{},
+	} {
+		uvjValueTypes[fmt.Sprintf("%T", value)] = reflect.TypeOf(value)
+	}
+}
+
+// metadataEncode converts a set of metadata to its tagged form. Values
+// of types that can't be represented (ie format-private structures)
+// are not encoded.
+func metadataEncode(keys []string, lookup func(key string) (interface{}, bool)) (values map[string]UVJValue, err error) {
+	for _, key := range keys {
+		data, ok := lookup(key)
+		if !ok {
+			continue
+		}
+
+		kind := fmt.Sprintf("%T", data)
+		_, ok = uvjValueTypes[kind]
+		if !ok {
+			continue
+		}
+
+		var raw []byte
+		raw, err = json.Marshal(data)
+		if err != nil {
+			err = fmt.Errorf("metadata '%v': %w", key, err)
+			return
+		}
+
+		if values == nil {
+			values = map[string]UVJValue{}
+		}
+		values[key] = UVJValue{Type: kind, Value: raw}
+	}
+
+	return
+}
+
+// metadataDecode converts tagged metadata back to its original types
+func metadataDecode(values map[string]UVJValue) (metadata map[string]interface{}, err error) {
+	metadata = map[string]interface{}{}
+
+	for key, value := range values {
+		kind, ok := uvjValueTypes[value.Type]
+		if !ok {
+			err = uv3dp.DecodeIssue(uv3dp.DecodeIssueUnknownField, fmt.Errorf("metadata '%v': unsupported type '%v'", key, value.Type))
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		data := reflect.New(kind)
+		err = json.Unmarshal(value.Value, data.Interface())
+		if err != nil {
+			err = fmt.Errorf("metadata '%v': %w", key, err)
+			return
+		}
+
+		metadata[key] = data.Elem().Interface()
+	}
+
+	return
+}
+
+// metadataKeys returns the sorted keys of a metadata map
+func metadataKeys(metadata map[string]interface{}) (keys []string) {
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return
+}
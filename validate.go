@@ -0,0 +1,308 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ValidationSeverity is the severity of a validation issue
+type ValidationSeverity int
+
+const (
+	ValidationInfo    = ValidationSeverity(iota) // Informational only
+	ValidationWarning                            // May cause a poor print
+	ValidationError                              // Will cause a failed print, or can't be printed
+)
+
+var validationSeverityNames = map[ValidationSeverity]string{
+	ValidationInfo:    "info",
+	ValidationWarning: "warning",
+	ValidationError:   "error",
+}
+
+func (severity ValidationSeverity) String() string {
+	name, ok := validationSeverityNames[severity]
+	if !ok {
+		return fmt.Sprintf("ValidationSeverity(%d)", int(severity))
+	}
+
+	return name
+}
+
+// ValidationIssue is a problem found by a validator
+type ValidationIssue struct {
+	Severity ValidationSeverity
+	Layer    int // Layer of the issue, or -1 for the whole printable
+	Message  string
+}
+
+func (issue ValidationIssue) String() string {
+	if issue.Layer < 0 {
+		return fmt.Sprintf("%v: %v", issue.Severity, issue.Message)
+	}
+
+	return fmt.Sprintf("%v: layer %v: %v", issue.Severity, issue.Layer, issue.Message)
+}
+
+// ValidationReport collects all of the issues found by validators
+type ValidationReport struct {
+	Issues []ValidationIssue
+
+	mutex sync.Mutex
+}
+
+// Add adds an issue to the report
+func (report *ValidationReport) Add(severity ValidationSeverity, layer int, format string, args ...interface{}) {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	report.Issues = append(report.Issues, ValidationIssue{
+		Severity: severity,
+		Layer:    layer,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Count returns the number of issues of a severity
+func (report *ValidationReport) Count(severity ValidationSeverity) (count int) {
+	for _, issue := range report.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+
+	return
+}
+
+// Sort orders the issues by layer, with the whole printable issues first
+func (report *ValidationReport) Sort() {
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Layer < report.Issues[j].Layer
+	})
+}
+
+// Error describes all of the issues of the report
+func (report *ValidationReport) Error() string {
+	lines := []string{
+		fmt.Sprintf("%v errors, %v warnings", report.Count(ValidationError), report.Count(ValidationWarning)),
+	}
+
+	for _, issue := range report.Issues {
+		lines = append(lines, "  "+issue.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Err returns the report as an error if it has any errors, or nil
+func (report *ValidationReport) Err() error {
+	if report.Count(ValidationError) == 0 {
+		return nil
+	}
+
+	return report
+}
+
+// Validator checks a printable, adding the issues it finds to a report
+type Validator func(printable Printable, report *ValidationReport)
+
+// Validate runs all of the validators, and reports all of their issues
+func Validate(printable Printable, validators ...Validator) (report *ValidationReport) {
+	report = &ValidationReport{}
+
+	for _, validator := range validators {
+		validator(printable, report)
+	}
+
+	report.Sort()
+
+	return
+}
+
+// ValidateResolution checks the size of a printable
+func ValidateResolution(printable Printable, report *ValidationReport) {
+	size := printable.Size()
+
+	if size.X <= 0 || size.Y <= 0 {
+		report.Add(ValidationError, -1, "resolution of %vx%v pixels is empty", size.X, size.Y)
+	}
+
+	if size.Millimeter.X <= 0 || size.Millimeter.Y <= 0 {
+		report.Add(ValidationError, -1, "bed size of %vx%v mm is empty", size.Millimeter.X, size.Millimeter.Y)
+	} else if size.X > 0 && size.Y > 0 {
+		pixelX := size.Millimeter.X / float32(size.X)
+		pixelY := size.Millimeter.Y / float32(size.Y)
+		ratio := pixelX / pixelY
+		if ratio < 0.99 || ratio > 1.01 {
+			report.Add(ValidationWarning, -1, "pixels are not square (%.4g x %.4g mm)", pixelX, pixelY)
+		}
+	}
+
+	if size.LayerHeight <= 0 {
+		report.Add(ValidationError, -1, "layer height of %v mm is not positive", size.LayerHeight)
+	}
+
+	if size.Layers <= 0 {
+		report.Add(ValidationError, -1, "no layers")
+	}
+}
+
+// validationRepeats collects issues that repeat over many layers,
+// so that they can be reported once, at their first layer.
+type validationRepeats struct {
+	order  []ValidationIssue
+	counts map[ValidationIssue]int
+}
+
+func (repeats *validationRepeats) Add(severity ValidationSeverity, layer int, format string, args ...interface{}) {
+	if repeats.counts == nil {
+		repeats.counts = map[ValidationIssue]int{}
+	}
+
+	key := ValidationIssue{Severity: severity, Message: fmt.Sprintf(format, args...)}
+	count, found := repeats.counts[key]
+	if !found {
+		repeats.order = append(repeats.order, ValidationIssue{Severity: severity, Layer: layer, Message: key.Message})
+	}
+	repeats.counts[key] = count + 1
+}
+
+func (repeats *validationRepeats) Report(report *ValidationReport) {
+	for _, issue := range repeats.order {
+		more := repeats.counts[ValidationIssue{Severity: issue.Severity, Message: issue.Message}] - 1
+		if more > 0 {
+			report.Add(issue.Severity, issue.Layer, "%v (and %v more layers)", issue.Message, more)
+		} else {
+			report.Add(issue.Severity, issue.Layer, "%v", issue.Message)
+		}
+	}
+}
+
+// ValidateExposure checks the exposure of all of the layers
+func ValidateExposure(printable Printable, report *ValidationReport) {
+	bottom := printable.Bottom()
+
+	if bottom.Count > printable.Size().Layers {
+		report.Add(ValidationWarning, -1, "bottom layer count of %v is more than the %v layers", bottom.Count, printable.Size().Layers)
+	}
+
+	repeats := &validationRepeats{}
+
+	for n := 0; n < printable.Size().Layers; n++ {
+		exposure := printable.LayerExposure(n)
+
+		if exposure.LightOnTime < 0 {
+			repeats.Add(ValidationError, n, "light on time of %v seconds is negative", exposure.LightOnTime)
+		} else if exposure.LightOnTime == 0 {
+			repeats.Add(ValidationWarning, n, "light on time is zero")
+		}
+
+		if exposure.LightOffTime < 0 {
+			repeats.Add(ValidationError, n, "light off time of %v seconds is negative", exposure.LightOffTime)
+		}
+
+		if exposure.LiftHeight < 0 {
+			repeats.Add(ValidationError, n, "lift height of %v mm is negative", exposure.LiftHeight)
+		}
+
+		if exposure.LiftHeight > 0 && exposure.LiftSpeed <= 0 {
+			repeats.Add(ValidationWarning, n, "lift speed of %v mm/min is not positive", exposure.LiftSpeed)
+		}
+	}
+
+	repeats.Report(report)
+}
+
+// ValidateVat returns a validator that checks that the resin used by the
+// layers fits in a vat of a capacity, in milliliters.
+func ValidateVat(capacity float32) Validator {
+	return func(printable Printable, report *ValidationReport) {
+		volumes := LayerVolumes(printable)
+
+		total := float32(0)
+		for _, volume := range volumes {
+			total += volume
+		}
+
+		refills := VatRefills(volumes, capacity)
+		if len(refills) > 0 {
+			report.Add(ValidationWarning, refills[0], "resin use of %.1f ml is more than the %.1f ml vat, which must be refilled %v times",
+				total, capacity, len(refills))
+		}
+	}
+}
+
+// ValidateMachine returns a validator that checks that a printable fits
+// the limits of a machine, and the quirks of its firmware.
+func ValidateMachine(machine Machine, quirks FirmwareQuirks) Validator {
+	return func(printable Printable, report *ValidationReport) {
+		size := printable.Size()
+
+		if size.X != machine.Size.X || size.Y != machine.Size.Y {
+			report.Add(ValidationError, -1, "resolution of %vx%v pixels is not the machine's %vx%v pixels",
+				size.X, size.Y, machine.Size.X, machine.Size.Y)
+		} else if math.Abs(float64(size.Millimeter.X-machine.Size.Xmm)) > 0.01 ||
+			math.Abs(float64(size.Millimeter.Y-machine.Size.Ymm)) > 0.01 {
+			report.Add(ValidationWarning, -1, "bed size of %.4gx%.4g mm is not the machine's %.4gx%.4g mm",
+				size.Millimeter.X, size.Millimeter.Y, machine.Size.Xmm, machine.Size.Ymm)
+		}
+
+		if quirks.MaxLayers > 0 && size.Layers > quirks.MaxLayers {
+			report.Add(ValidationError, -1, "%v layers is more than the firmware's limit of %v layers", size.Layers, quirks.MaxLayers)
+		}
+
+		if machine.Height > 0 && size.Layers > 0 {
+			last := size.Layers - 1
+			if z := printable.LayerZ(last); z > machine.Height {
+				report.Add(ValidationError, last, "height of %.2f mm is more than the machine's build height of %.2f mm", z, machine.Height)
+			}
+		}
+
+		bottom := printable.Bottom()
+		repeats := &validationRepeats{}
+
+		for n := 0; n < size.Layers; n++ {
+			exposure := printable.LayerExposure(n)
+
+			if machine.MaxSpeed > 0 && exposure.LiftSpeed > machine.MaxSpeed {
+				repeats.Add(ValidationError, n, "lift speed of %v mm/min is more than the machine's %v mm/min", exposure.LiftSpeed, machine.MaxSpeed)
+			}
+
+			if machine.MaxSpeed > 0 && exposure.RetractSpeed > machine.MaxSpeed {
+				repeats.Add(ValidationError, n, "retract speed of %v mm/min is more than the machine's %v mm/min", exposure.RetractSpeed, machine.MaxSpeed)
+			}
+
+			fullPWM := quirks.NormalPWMFull
+			if n < bottom.Count {
+				fullPWM = quirks.BottomPWMFull
+			}
+
+			if fullPWM && exposure.LightPWM != 255 {
+				repeats.Add(ValidationError, n, "light PWM of %v is not supported by the firmware", exposure.LightPWM)
+			}
+		}
+
+		repeats.Report(report)
+	}
+}
+
+// ValidateIslands checks all of the layers for areas with no support
+// from the layer below.
+func ValidateIslands(printable Printable, report *ValidationReport) {
+	WithAllLayers(printable, func(p Printable, n int) {
+		if n == 0 {
+			return
+		}
+
+		for _, island := range LayerIslands(p.LayerImage(n-1), p.LayerImage(n)) {
+			report.Add(ValidationError, n, "island of %v pixels at %v", island.Area, island.Bounds)
+		}
+	})
+}
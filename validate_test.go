@@ -0,0 +1,148 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"errors"
+	"image"
+	"testing"
+)
+
+type validateTestPrint struct {
+	Print
+	layers []*image.Gray
+}
+
+func (vtp *validateTestPrint) LayerImage(index int) *image.Gray {
+	return vtp.layers[index]
+}
+
+func TestValidate(t *testing.T) {
+	input := &validateTestPrint{
+		Print: Print{
+			Properties: Properties{
+				Size: Size{X: 7, Y: 4, Layers: 2, LayerHeight: 0.05},
+				Exposure: Exposure{
+					LightOnTime: -1,
+					LiftHeight:  5,
+					LiftSpeed:   60,
+				},
+			},
+		},
+		layers: []*image.Gray{grayFrom(gm_islands_below), grayFrom(gm_islands_layer)},
+	}
+
+	report := Validate(input, ValidateResolution, ValidateExposure, ValidateIslands)
+
+	// All of the problems are reported, not just the first
+	expected := []ValidationIssue{
+		{Severity: ValidationError, Layer: -1, Message: "bed size of 0x0 mm is empty"},
+		{Severity: ValidationError, Layer: 0, Message: "light on time of -1 seconds is negative (and 1 more layers)"},
+		{Severity: ValidationError, Layer: 1, Message: "island of 3 pixels at (5,0)-(7,3)"},
+		{Severity: ValidationError, Layer: 1, Message: "island of 1 pixels at (2,3)-(3,4)"},
+	}
+
+	if len(report.Issues) != len(expected) {
+		t.Fatalf("expected %v issues, got %v", len(expected), report.Error())
+	}
+
+	for n, issue := range report.Issues {
+		if issue != expected[n] {
+			t.Errorf("%v: expected %v, got %v", n, expected[n], issue)
+		}
+	}
+
+	var validationReport *ValidationReport
+	if !errors.As(report.Err(), &validationReport) {
+		t.Errorf("expected a *ValidationReport error, got %v", report.Err())
+	}
+
+	// Warnings are not errors
+	report = &ValidationReport{}
+	report.Add(ValidationWarning, 3, "lift speed of %v mm/min is not positive", 0)
+	if report.Err() != nil {
+		t.Errorf("expected nil, got %v", report.Err())
+	}
+}
+
+func TestValidateVat(t *testing.T) {
+	size := Size{X: 100, Y: 50, Layers: 4, LayerHeight: 0.05}
+	size.Millimeter.X = 50
+	size.Millimeter.Y = 25
+
+	// 0.000625 ml for each layer
+	vp := &volumePrint{Print{Properties: Properties{Size: size}}}
+
+	report := Validate(vp, ValidateVat(0.002))
+	if len(report.Issues) != 1 || report.Issues[0].Layer != 3 || report.Issues[0].Severity != ValidationWarning {
+		t.Errorf("expected a warning at layer 3, got %v", report.Issues)
+	}
+
+	report = Validate(vp, ValidateVat(0.003))
+	if len(report.Issues) != 0 {
+		t.Errorf("expected no issues, got %v", report.Issues)
+	}
+}
+
+func TestValidateMachine(t *testing.T) {
+	prop := Properties{
+		Size: Size{X: 10, Y: 20, Layers: 4, LayerHeight: 0.05, Millimeter: SizeMillimeter{X: 5, Y: 10}},
+		Exposure: Exposure{
+			LightOnTime:  8,
+			LightPWM:     128,
+			LiftHeight:   5,
+			LiftSpeed:    300,
+			RetractSpeed: 100,
+		},
+		Bottom: Bottom{Count: 1, Exposure: Exposure{LightOnTime: 60, LightPWM: 255, LiftSpeed: 60}},
+	}
+
+	machine := Machine{Size: MachineSize{10, 20, 5, 10}}
+
+	table := map[string]struct {
+		Machine Machine
+		Quirks  FirmwareQuirks
+		Issues  []ValidationIssue
+	}{
+		"fits": {Machine: machine},
+		"resolution": {
+			Machine: Machine{Size: MachineSize{20, 20, 5, 10}},
+			Issues:  []ValidationIssue{{Severity: ValidationError, Layer: -1, Message: "resolution of 10x20 pixels is not the machine's 20x20 pixels"}},
+		},
+		"bed": {
+			Machine: Machine{Size: MachineSize{10, 20, 5, 12}},
+			Issues:  []ValidationIssue{{Severity: ValidationWarning, Layer: -1, Message: "bed size of 5x10 mm is not the machine's 5x12 mm"}},
+		},
+		"height": {
+			Machine: Machine{Size: machine.Size, Height: 0.15},
+			Issues:  []ValidationIssue{{Severity: ValidationError, Layer: 3, Message: "height of 0.20 mm is more than the machine's build height of 0.15 mm"}},
+		},
+		"speed": {
+			Machine: Machine{Size: machine.Size, MaxSpeed: 200},
+			Issues:  []ValidationIssue{{Severity: ValidationError, Layer: 1, Message: "lift speed of 300 mm/min is more than the machine's 200 mm/min (and 2 more layers)"}},
+		},
+		"firmware": {
+			Machine: machine,
+			Quirks:  FirmwareQuirks{NormalPWMFull: true, MaxLayers: 3},
+			Issues: []ValidationIssue{
+				{Severity: ValidationError, Layer: -1, Message: "4 layers is more than the firmware's limit of 3 layers"},
+				{Severity: ValidationError, Layer: 1, Message: "light PWM of 128 is not supported by the firmware (and 2 more layers)"},
+			},
+		},
+	}
+
+	for name, item := range table {
+		report := Validate(&Print{Properties: prop}, ValidateMachine(item.Machine, item.Quirks))
+		if len(report.Issues) != len(item.Issues) {
+			t.Errorf("%v: expected %v issues, got %v", name, len(item.Issues), report.Error())
+			continue
+		}
+		for n, issue := range report.Issues {
+			if issue != item.Issues[n] {
+				t.Errorf("%v: expected %v, got %v", name, item.Issues[n], issue)
+			}
+		}
+	}
+}
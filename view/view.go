@@ -0,0 +1,236 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+// Package view adapts a printable for GUI toolkits (ie fyne or gio),
+// exposing its layers as cached image.Image values, prefetched in the
+// background, with notifications when layers become available or the
+// printable is replaced.
+package view
+
+import (
+	"container/list"
+	"image"
+	"sync"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type EventKind int
+
+const (
+	EventLayerReady = EventKind(iota) // A layer image was added to the cache
+	EventPrintable                    // The printable was replaced
+)
+
+// Event is a change notification. Layer is only valid for EventLayerReady.
+type Event struct {
+	Kind  EventKind
+	Layer int
+}
+
+// Default number of layer images kept in the cache
+const DefaultCacheSize = 16
+
+type cacheEntry struct {
+	layer int
+	image *image.Gray
+}
+
+// Viewer is a layer image cache for a printable, safe for concurrent use
+type Viewer struct {
+	mutex     sync.Mutex
+	printable uv3dp.Printable
+	cacheSize int
+	cache     map[int]*list.Element
+	lru       *list.List        // Most recently used first
+	pending   map[int]chan bool // Layers being decoded
+	listeners map[int]func(Event)
+	nextID    int
+	epoch     int // Incremented each time the printable is replaced
+}
+
+// NewViewer returns a viewer for a printable, caching up to
+// cacheSize layer images (DefaultCacheSize if less than 1).
+func NewViewer(printable uv3dp.Printable, cacheSize int) (viewer *Viewer) {
+	if cacheSize < 1 {
+		cacheSize = DefaultCacheSize
+	}
+
+	viewer = &Viewer{
+		printable: printable,
+		cacheSize: cacheSize,
+		cache:     map[int]*list.Element{},
+		lru:       list.New(),
+		pending:   map[int]chan bool{},
+		listeners: map[int]func(Event){},
+	}
+
+	return
+}
+
+// Printable returns the printable being viewed
+func (viewer *Viewer) Printable() uv3dp.Printable {
+	viewer.mutex.Lock()
+	defer viewer.mutex.Unlock()
+
+	return viewer.printable
+}
+
+// SetPrintable replaces the printable being viewed, and empties the cache
+func (viewer *Viewer) SetPrintable(printable uv3dp.Printable) {
+	viewer.mutex.Lock()
+	viewer.printable = printable
+	viewer.cache = map[int]*list.Element{}
+	viewer.lru.Init()
+	viewer.pending = map[int]chan bool{}
+	viewer.epoch++
+	viewer.mutex.Unlock()
+
+	viewer.notify(Event{Kind: EventPrintable})
+}
+
+// Subscribe calls the function on every change, until the returned
+// function is called. The function may be called from any goroutine.
+func (viewer *Viewer) Subscribe(listener func(Event)) (unsubscribe func()) {
+	viewer.mutex.Lock()
+	id := viewer.nextID
+	viewer.nextID++
+	viewer.listeners[id] = listener
+	viewer.mutex.Unlock()
+
+	unsubscribe = func() {
+		viewer.mutex.Lock()
+		delete(viewer.listeners, id)
+		viewer.mutex.Unlock()
+	}
+
+	return
+}
+
+func (viewer *Viewer) notify(event Event) {
+	viewer.mutex.Lock()
+	listeners := make([]func(Event), 0, len(viewer.listeners))
+	for _, listener := range viewer.listeners {
+		listeners = append(listeners, listener)
+	}
+	viewer.mutex.Unlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// cached returns a cached layer image, marking it as recently used.
+// The mutex must be held.
+func (viewer *Viewer) cached(index int) (img *image.Gray, ok bool) {
+	elem, ok := viewer.cache[index]
+	if ok {
+		viewer.lru.MoveToFront(elem)
+		img = elem.Value.(*cacheEntry).image
+	}
+
+	return
+}
+
+// Cached returns a layer image only if it is in the cache
+func (viewer *Viewer) Cached(index int) (img image.Image, ok bool) {
+	viewer.mutex.Lock()
+	defer viewer.mutex.Unlock()
+
+	gray, ok := viewer.cached(index)
+	if ok {
+		img = gray
+	}
+
+	return
+}
+
+// Layer returns a layer image, decoding it if it is not in the cache
+func (viewer *Viewer) Layer(index int) image.Image {
+	return viewer.layer(index)
+}
+
+func (viewer *Viewer) layer(index int) *image.Gray {
+	for {
+		viewer.mutex.Lock()
+		img, ok := viewer.cached(index)
+		if ok {
+			viewer.mutex.Unlock()
+			return img
+		}
+
+		// Wait for another goroutine that is decoding this layer
+		done, busy := viewer.pending[index]
+		if busy {
+			viewer.mutex.Unlock()
+			<-done
+			continue
+		}
+
+		done = make(chan bool)
+		viewer.pending[index] = done
+		printable := viewer.printable
+		epoch := viewer.epoch
+		viewer.mutex.Unlock()
+
+		img = printable.LayerImage(index)
+
+		viewer.mutex.Lock()
+		current := epoch == viewer.epoch
+		if current {
+			delete(viewer.pending, index)
+			viewer.insert(index, img)
+		}
+		viewer.mutex.Unlock()
+		close(done)
+
+		if current {
+			viewer.notify(Event{Kind: EventLayerReady, Layer: index})
+		}
+
+		return img
+	}
+}
+
+// insert adds a layer image to the cache, evicting the least recently
+// used images. The mutex must be held.
+func (viewer *Viewer) insert(index int, img *image.Gray) {
+	viewer.cache[index] = viewer.lru.PushFront(&cacheEntry{layer: index, image: img})
+
+	for viewer.lru.Len() > viewer.cacheSize {
+		oldest := viewer.lru.Back()
+		viewer.lru.Remove(oldest)
+		delete(viewer.cache, oldest.Value.(*cacheEntry).layer)
+	}
+}
+
+// Prefetch decodes the layers within radius of a layer in the background,
+// nearest first, so that stepping through the layers does not wait on
+// the decoder. EventLayerReady is sent as each layer is cached.
+func (viewer *Viewer) Prefetch(index int, radius int) {
+	layers := viewer.Printable().Size().Layers
+
+	order := []int{}
+	if index >= 0 && index < layers {
+		order = append(order, index)
+	}
+	for distance := 1; distance <= radius; distance++ {
+		for _, n := range []int{index + distance, index - distance} {
+			if n >= 0 && n < layers {
+				order = append(order, n)
+			}
+		}
+	}
+
+	// Never prefetch more than the cache holds
+	if len(order) > viewer.cacheSize {
+		order = order[:viewer.cacheSize]
+	}
+
+	go func() {
+		for _, n := range order {
+			viewer.layer(n)
+		}
+	}()
+}
@@ -0,0 +1,118 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package view
+
+import (
+	"image"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nicarran/uv3dp"
+)
+
+type countPrint struct {
+	uv3dp.Print
+	decodes int32
+}
+
+func (cp *countPrint) LayerImage(index int) *image.Gray {
+	atomic.AddInt32(&cp.decodes, 1)
+	img := image.NewGray(cp.Properties.Bounds())
+	img.Pix[0] = uint8(index)
+	return img
+}
+
+func newCountPrint() *countPrint {
+	return &countPrint{
+		Print: uv3dp.Print{Properties: uv3dp.Properties{Size: uv3dp.Size{X: 2, Y: 2, Layers: 10}}},
+	}
+}
+
+func TestViewerCache(t *testing.T) {
+	printable := newCountPrint()
+	viewer := NewViewer(printable, 2)
+
+	events := []Event{}
+	unsubscribe := viewer.Subscribe(func(event Event) { events = append(events, event) })
+
+	for _, n := range []int{1, 1, 2, 1, 3} {
+		img := viewer.Layer(n).(*image.Gray)
+		if img.Pix[0] != uint8(n) {
+			t.Errorf("layer %v: got image of layer %v", n, img.Pix[0])
+		}
+	}
+
+	// Layer 2 was the least recently used when layer 3 was added
+	if printable.decodes != 3 {
+		t.Errorf("expected 3 decodes, got %v", printable.decodes)
+	}
+
+	_, ok := viewer.Cached(2)
+	if ok {
+		t.Errorf("expected layer 2 to be evicted")
+	}
+
+	_, ok = viewer.Cached(1)
+	if !ok {
+		t.Errorf("expected layer 1 to be cached")
+	}
+
+	expected := []Event{{EventLayerReady, 1}, {EventLayerReady, 2}, {EventLayerReady, 3}}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, events)
+	}
+	for n := range expected {
+		if events[n] != expected[n] {
+			t.Errorf("event %v: expected %v, got %v", n, expected[n], events[n])
+		}
+	}
+
+	unsubscribe()
+
+	viewer.SetPrintable(newCountPrint())
+	_, ok = viewer.Cached(1)
+	if ok {
+		t.Errorf("expected an empty cache after SetPrintable")
+	}
+
+	if len(events) != len(expected) {
+		t.Errorf("expected no events after unsubscribe, got %v", events[len(expected):])
+	}
+}
+
+func TestViewerPrefetch(t *testing.T) {
+	printable := newCountPrint()
+	viewer := NewViewer(printable, 8)
+
+	ready := make(chan int, 10)
+	viewer.Subscribe(func(event Event) {
+		if event.Kind == EventLayerReady {
+			ready <- event.Layer
+		}
+	})
+
+	viewer.Prefetch(0, 2)
+
+	for n := 0; n < 3; n++ {
+		select {
+		case <-ready:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for prefetch")
+		}
+	}
+
+	for _, n := range []int{0, 1, 2} {
+		_, ok := viewer.Cached(n)
+		if !ok {
+			t.Errorf("expected layer %v to be prefetched", n)
+		}
+	}
+
+	viewer.Layer(1)
+	if atomic.LoadInt32(&printable.decodes) != 3 {
+		t.Errorf("expected 3 decodes, got %v", printable.decodes)
+	}
+}
@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"sync/atomic"
+)
+
+// PixelVolume returns the volume of resin cured by a pixel, in milliliters
+func (size *Size) PixelVolume() float32 {
+	if size.X == 0 || size.Y == 0 {
+		return 0
+	}
+
+	pixelArea := float64(size.Millimeter.X) * float64(size.Millimeter.Y) / (float64(size.X) * float64(size.Y))
+
+	return float32(pixelArea * float64(size.LayerHeight) / 1000.0)
+}
+
+// LitPixels returns the number of pixels of an image that are not off
+func LitPixels(img *image.Gray) (count int) {
+	for _, pix := range img.Pix {
+		if pix != 0 {
+			count++
+		}
+	}
+
+	return
+}
+
+// PrintVolume returns the volume of resin cured by all of the layers,
+// in milliliters
+func PrintVolume(printable Printable) float32 {
+	size := printable.Size()
+
+	lit := int64(0)
+	WithAllLayers(printable, func(p Printable, n int) {
+		atomic.AddInt64(&lit, int64(LitPixels(p.LayerImage(n))))
+	})
+
+	return float32(lit) * size.PixelVolume()
+}
+
+// LayerVolumes returns the volume of resin cured by each layer, in milliliters
+func LayerVolumes(printable Printable) (volumes []float32) {
+	size := printable.Size()
+	pixelVolume := size.PixelVolume()
+
+	volumes = make([]float32, size.Layers)
+	WithAllLayers(printable, func(p Printable, n int) {
+		volumes[n] = float32(LitPixels(p.LayerImage(n))) * pixelVolume
+	})
+
+	return
+}
+
+// VatRefills returns the layers that would use more resin than is left
+// in a vat of a capacity (in milliliters), if it is filled at the start
+// of the print, and before each of those layers.
+func VatRefills(volumes []float32, capacity float32) (layers []int) {
+	if capacity <= 0 {
+		return
+	}
+
+	left := capacity
+	for n, volume := range volumes {
+		if volume > left {
+			layers = append(layers, n)
+			left = capacity
+		}
+		left -= volume
+	}
+
+	return
+}
@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"image"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type volumePrint struct {
+	Print
+}
+
+func (vp *volumePrint) LayerImage(index int) (ig *image.Gray) {
+	ig = image.NewGray(vp.Bounds())
+	for n := 0; n < 100; n++ {
+		ig.Pix[n] = uint8(n%2) * 0xff
+	}
+
+	return
+}
+
+func TestPrintVolume(t *testing.T) {
+	size := Size{X: 100, Y: 50, Layers: 4, LayerHeight: 0.05}
+	size.Millimeter.X = 50
+	size.Millimeter.Y = 25
+
+	// 0.5 x 0.5 x 0.05 mm
+	pixelVolume := size.PixelVolume()
+	if pixelVolume < 0.0000124 || pixelVolume > 0.0000126 {
+		t.Errorf("expected 0.0000125 ml, got %v", pixelVolume)
+	}
+
+	vp := &volumePrint{Print{Properties: Properties{Size: size}}}
+
+	// 50 pixels on each of 4 layers
+	volume := PrintVolume(vp)
+	if volume < 0.00249 || volume > 0.00251 {
+		t.Errorf("expected 0.0025 ml, got %v", volume)
+	}
+
+	if (&Size{}).PixelVolume() != 0 {
+		t.Errorf("expected no volume for an empty size")
+	}
+}
+
+func TestVatRefills(t *testing.T) {
+	volumes := []float32{4, 3, 2, 5, 1, 6, 2}
+
+	table := []struct {
+		Capacity float32
+		Layers   []int
+	}{
+		{Capacity: 0},
+		{Capacity: 23},
+		{Capacity: 10, Layers: []int{3, 5}},
+		{Capacity: 9, Layers: []int{3, 5}},
+		{Capacity: 7, Layers: []int{2, 4, 6}},
+	}
+
+	for _, item := range table {
+		layers := VatRefills(volumes, item.Capacity)
+		if !cmp.Equal(item.Layers, layers) {
+			t.Errorf("%v ml: expected %v, got %v", item.Capacity, item.Layers, layers)
+		}
+	}
+}
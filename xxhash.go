@@ -0,0 +1,149 @@
+//
+// Copyright (c) 2020 Jason S. McMullan <jason.mcmullan@gmail.com>
+//
+
+package uv3dp
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// XXH64 primes (variables, so that their sums may overflow)
+var (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// xxHash64 is the XXH64 hash, with a seed of zero
+type xxHash64 struct {
+	v1, v2, v3, v4 uint64
+	total          uint64
+	mem            [32]byte
+	memSize        int
+}
+
+// newXXHash64 returns a new XXH64 hash
+func newXXHash64() hash.Hash64 {
+	xx := &xxHash64{}
+	xx.Reset()
+
+	return xx
+}
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	acc *= xxPrime1
+
+	return acc
+}
+
+func xxMergeRound(acc, val uint64) uint64 {
+	val = xxRound(0, val)
+	acc ^= val
+	acc = acc*xxPrime1 + xxPrime4
+
+	return acc
+}
+
+func (xx *xxHash64) Reset() {
+	xx.v1 = xxPrime1 + xxPrime2
+	xx.v2 = xxPrime2
+	xx.v3 = 0
+	xx.v4 = -xxPrime1
+	xx.total = 0
+	xx.memSize = 0
+}
+
+func (xx *xxHash64) Size() int {
+	return 8
+}
+
+func (xx *xxHash64) BlockSize() int {
+	return 32
+}
+
+// stripe mixes a 32 byte stripe into the accumulators
+func (xx *xxHash64) stripe(b []byte) {
+	xx.v1 = xxRound(xx.v1, binary.LittleEndian.Uint64(b[0:]))
+	xx.v2 = xxRound(xx.v2, binary.LittleEndian.Uint64(b[8:]))
+	xx.v3 = xxRound(xx.v3, binary.LittleEndian.Uint64(b[16:]))
+	xx.v4 = xxRound(xx.v4, binary.LittleEndian.Uint64(b[24:]))
+}
+
+func (xx *xxHash64) Write(b []byte) (n int, err error) {
+	n = len(b)
+	xx.total += uint64(n)
+
+	if xx.memSize+len(b) < 32 {
+		xx.memSize += copy(xx.mem[xx.memSize:], b)
+		return
+	}
+
+	if xx.memSize > 0 {
+		used := copy(xx.mem[xx.memSize:], b)
+		xx.stripe(xx.mem[:])
+		b = b[used:]
+		xx.memSize = 0
+	}
+
+	for ; len(b) >= 32; b = b[32:] {
+		xx.stripe(b)
+	}
+
+	xx.memSize = copy(xx.mem[:], b)
+
+	return
+}
+
+func (xx *xxHash64) Sum64() (h uint64) {
+	if xx.total >= 32 {
+		h = bits.RotateLeft64(xx.v1, 1) + bits.RotateLeft64(xx.v2, 7) +
+			bits.RotateLeft64(xx.v3, 12) + bits.RotateLeft64(xx.v4, 18)
+		h = xxMergeRound(h, xx.v1)
+		h = xxMergeRound(h, xx.v2)
+		h = xxMergeRound(h, xx.v3)
+		h = xxMergeRound(h, xx.v4)
+	} else {
+		h = xx.v3 + xxPrime5
+	}
+
+	h += xx.total
+
+	b := xx.mem[:xx.memSize]
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		b = b[4:]
+	}
+
+	for _, c := range b {
+		h ^= uint64(c) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+
+	return
+}
+
+func (xx *xxHash64) Sum(b []byte) []byte {
+	sum := make([]byte, 8)
+	binary.BigEndian.PutUint64(sum, xx.Sum64())
+
+	return append(b, sum...)
+}
@@ -146,7 +146,7 @@ func (sf *ZcodexFormat) Encode(writer uv3dp.Writer, printable uv3dp.Printable) (
 	}
 
 	us.MaxLayer = size.Layers
-	us.LayerThickness = fmt.Sprintf("%.2g mm", size.LayerHeight)
+	us.LayerThickness = uv3dp.FormatFloatShort(size.LayerHeight, 3) + " mm"
 	us.LayerExposureTime = int(exposure.LightOnTime * 1000.0)
 	us.ExposureOffTime = int(exposure.LightOffTime * 1000.0)
 	us.BottomLayerExposureTime = int(bottom.Exposure.LightOnTime * 1000.0)
@@ -304,9 +304,8 @@ func (sf *ZcodexFormat) Decode(reader uv3dp.Reader, filesize int64) (printable u
 		if err != nil {
 			return
 		}
-		defer reader.Close()
-
 		layerPng[n], err = ioutil.ReadAll(reader)
+		reader.Close()
 		if err != nil {
 			return
 		}
@@ -333,7 +332,7 @@ func (sf *ZcodexFormat) Decode(reader uv3dp.Reader, filesize int64) (printable u
 		defer func() { reader.Close() }()
 
 		var thumb image.Image
-		thumb, err = png.Decode(reader)
+		thumb, err = uv3dp.DecodePreview(reader)
 		if err != nil {
 			return
 		}